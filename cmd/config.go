@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/yourorg/go-api-template/config"
+	core_config "github.com/yourorg/go-api-template/core/config"
+	"github.com/yourorg/go-api-template/core/secrets"
+	"github.com/yourorg/go-api-template/utils/runtime"
+	"gopkg.in/yaml.v3"
+)
+
+var configCmd = &cobra.Command{
+	Use:     "config",
+	GroupID: groupAdmin,
+	Short:   "Inspect the configuration",
+}
+
+var configPrintFormat string
+
+var configPrintCmd = &cobra.Command{
+	Use:   "print",
+	Short: "Print the merged configuration with secrets masked",
+	Long: `Print the configuration as the server would see it: the config files of --profile merged,
+APP_ environment overrides, ${VAR} placeholders and secret store references applied.
+Fields tagged secret:"true" are masked. Validation problems are reported on stderr.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profile, err := cmd.Flags().GetString("profile")
+		if err != nil {
+			return err
+		}
+		if err := resolveConfig(runtime.ValidateProfile(profile)); err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		cfg := config.GetConfig()
+		if err := printConfig(cmd.OutOrStdout(), cfg.Redacted(), configPrintFormat); err != nil {
+			return err
+		}
+		if err := cfg.Validate(); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+		}
+		return nil
+	},
+}
+
+var configSchemaOutput string
+
+var configSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Generate the JSON Schema of the config files",
+	Long: `Generate the JSON Schema of the config files from the Config struct tree, for editor
+autocomplete and CI validation. Reference it from a config file with
+  # yaml-language-server: $schema=./config.schema.json`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		if configSchemaOutput != "" {
+			file, err := os.Create(configSchemaOutput)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			out = file
+		}
+
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		return enc.Encode(core_config.Schema())
+	},
+}
+
+var configEncryptRecipients []string
+
+var configEncryptCmd = &cobra.Command{
+	Use:   "encrypt [value]",
+	Short: "Encrypt a config value to age recipients",
+	Long: `Encrypt a value, read from stdin when not given, to the age recipients and print it as an
+enc: value to paste in a config file. The server decrypts it at load time with the identities of
+SOPS_AGE_KEY or SOPS_AGE_KEY_FILE; age-keygen creates a key pair.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var value []byte
+		if len(args) == 1 {
+			value = []byte(args[0])
+		} else {
+			var err error
+			if value, err = io.ReadAll(cmd.InOrStdin()); err != nil {
+				return err
+			}
+			value = bytes.TrimSuffix(value, []byte("\n"))
+		}
+
+		ciphertext, err := secrets.EncryptAge(value, configEncryptRecipients...)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), secrets.SchemeEncrypted+":"+base64.StdEncoding.EncodeToString(ciphertext))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configPrintCmd)
+	configCmd.AddCommand(configSchemaCmd)
+	configCmd.AddCommand(configEncryptCmd)
+
+	configPrintCmd.Flags().StringVarP(&configPrintFormat, "output", "o", "yaml", "Output format: yaml or json")
+	_ = configPrintCmd.RegisterFlagCompletionFunc("output", completeFormats("yaml", "json"))
+	configSchemaCmd.Flags().StringVarP(&configSchemaOutput, "output", "o", "", "File to write, stdout when empty")
+	configEncryptCmd.Flags().StringArrayVarP(&configEncryptRecipients, "recipient", "r", nil, "age1... public key, repeatable")
+	_ = configEncryptCmd.MarkFlagRequired("recipient")
+}
+
+func printConfig(w io.Writer, cfg map[string]any, format string) error {
+	switch format {
+	case "yaml":
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		defer enc.Close()
+		return enc.Encode(cfg)
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(cfg)
+	default:
+		return fmt.Errorf("unknown output format %q, use yaml or json", format)
+	}
+}
@@ -0,0 +1,259 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/jackc/pgx/v5"
+	"github.com/spf13/cobra"
+	"github.com/yourorg/go-api-template/config"
+	"github.com/yourorg/go-api-template/core/fixtures"
+	"github.com/yourorg/go-api-template/core/pgdb"
+	"github.com/yourorg/go-api-template/utils/runtime"
+)
+
+var (
+	dbMigrate bool
+	dbSeed    string
+	dbYes     bool
+)
+
+var dbCmd = &cobra.Command{
+	Use:     "db",
+	GroupID: groupDB,
+	Short:   "Database management commands",
+	Long: `Create, drop and reset the database of postgres.write of --profile, connected to the
+maintenance database with the postgres.admin credentials, or those of postgres.write when
+unset. Dropping and seeding are refused on production profiles.`,
+}
+
+var dbCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create the database and its schema",
+	Long:  "Create the database and schema of postgres.write when they do not exist, then optionally run the migrations and load a fixture set",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		env, err := profileFlag(cmd)
+		if err != nil {
+			return err
+		}
+		if dbSeed != "" && env.IsProduction() {
+			return fmt.Errorf("refusing to load fixtures into the production profile %s", env)
+		}
+		cfg, err := dbConfig(env)
+		if err != nil {
+			return err
+		}
+		return createDatabase(cmd, cfg, dbMigrate, dbSeed)
+	},
+}
+
+var dbDropCmd = &cobra.Command{
+	Use:   "drop",
+	Short: "Drop the database",
+	Long:  "Drop the database of postgres.write, disconnecting its sessions. Requires --yes",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		env, err := profileFlag(cmd)
+		if err != nil {
+			return err
+		}
+		if err := confirmDrop(cmd, env); err != nil {
+			return err
+		}
+		cfg, err := dbConfig(env)
+		if err != nil {
+			return err
+		}
+		return dropDatabase(cmd, cfg)
+	},
+}
+
+var dbResetCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "Drop, create and migrate the database",
+	Long:  "Drop and create the database of postgres.write, run the migrations and optionally load a fixture set. Requires --yes",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		env, err := profileFlag(cmd)
+		if err != nil {
+			return err
+		}
+		if err := confirmDrop(cmd, env); err != nil {
+			return err
+		}
+		cfg, err := dbConfig(env)
+		if err != nil {
+			return err
+		}
+		if err := dropDatabase(cmd, cfg); err != nil {
+			return err
+		}
+		return createDatabase(cmd, cfg, true, dbSeed)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(dbCmd)
+	dbCmd.AddCommand(dbCreateCmd)
+	dbCmd.AddCommand(dbDropCmd)
+	dbCmd.AddCommand(dbResetCmd)
+
+	dbCreateCmd.Flags().BoolVar(&dbMigrate, "migrate", false, "Run the pending migrations after creating")
+	for _, c := range []*cobra.Command{dbCreateCmd, dbResetCmd} {
+		c.Flags().StringVar(&dbSeed, "seed", "", "Fixture set of "+fixturesDir+" to load after migrating")
+		_ = c.RegisterFlagCompletionFunc("seed", completeFixtureSets)
+	}
+	for _, c := range []*cobra.Command{dbDropCmd, dbResetCmd} {
+		c.Flags().BoolVar(&dbYes, "yes", false, "Confirm dropping the database and its data")
+	}
+}
+
+// dbConfig loads the config of profile, which must have a write database
+func dbConfig(profile runtime.Environment) (*config.Config, error) {
+	cfg, err := loadValidConfig(profile)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Postgres.Write.Host == "" || cfg.Postgres.Write.Database == "" {
+		return nil, errors.New("postgres.write.host and postgres.write.database are required")
+	}
+	return cfg, nil
+}
+
+// confirmDrop refuses dropping the database of production profiles, and without --yes
+func confirmDrop(cmd *cobra.Command, env runtime.Environment) error {
+	if env.IsProduction() {
+		return fmt.Errorf("refusing to %s the database of the production profile %s", cmd.Name(), env)
+	}
+	if !dbYes {
+		return fmt.Errorf("db %s deletes the database and its data, pass --yes to confirm", cmd.Name())
+	}
+	return nil
+}
+
+// adminConnect connects to the maintenance database with the admin credentials
+func adminConnect(ctx context.Context, postgres pgdb.Postgres) (*pgx.Conn, error) {
+	conn, err := pgdb.Connect(ctx, postgres.AdminConfig())
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to postgres as admin: %w", err)
+	}
+	return conn, nil
+}
+
+func dropDatabase(cmd *cobra.Command, cfg *config.Config) error {
+	ctx := cmd.Context()
+	conn, err := adminConnect(ctx, cfg.Postgres)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.WithoutCancel(ctx))
+
+	name := cfg.Postgres.Write.Database
+	dropped, err := pgdb.DropDatabase(ctx, conn, name)
+	if err != nil {
+		return err
+	}
+	if dropped {
+		fmt.Fprintf(cmd.OutOrStdout(), "Dropped database %s\n", name)
+	} else {
+		fmt.Fprintf(cmd.OutOrStdout(), "Database %s does not exist\n", name)
+	}
+	return nil
+}
+
+func createDatabase(cmd *cobra.Command, cfg *config.Config, migrateUp bool, seed string) error {
+	// the set is read before anything is created, a typo then changes nothing
+	var set *fixtures.Set
+	if seed != "" {
+		var err error
+		if set, err = fixtures.Load(fixturesRoot, seed); err != nil {
+			return err
+		}
+	}
+
+	ctx := cmd.Context()
+	write := cfg.Postgres.Write
+	admin := cfg.Postgres.AdminConfig()
+	conn, err := adminConnect(ctx, cfg.Postgres)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.WithoutCancel(ctx))
+
+	// the application user owns what the admin creates for it
+	owner := ""
+	if write.Username != admin.Username {
+		owner = write.Username
+	}
+	created, err := pgdb.CreateDatabase(ctx, conn, write.Database, owner)
+	if err != nil {
+		return err
+	}
+	if created {
+		fmt.Fprintf(cmd.OutOrStdout(), "Created database %s\n", write.Database)
+	} else {
+		fmt.Fprintf(cmd.OutOrStdout(), "Database %s already exists\n", write.Database)
+	}
+
+	if write.Schema != "" && write.Schema != "public" {
+		admin.Database = write.Database
+		if err := createSchema(ctx, admin, write.Schema, owner); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Schema %s is ready\n", write.Schema)
+	}
+
+	if migrateUp {
+		if err := migrateDatabase(cmd, write); err != nil {
+			return err
+		}
+	}
+	if set != nil {
+		return applyFixtureSet(cmd, write, set, false)
+	}
+	return nil
+}
+
+// createSchema creates schema in the database of cfg when it does not exist
+func createSchema(ctx context.Context, cfg pgdb.PostgresConfig, schema string, owner string) error {
+	conn, err := pgdb.Connect(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database %s: %w", cfg.Database, err)
+	}
+	defer conn.Close(context.WithoutCancel(ctx))
+
+	sql := "CREATE SCHEMA IF NOT EXISTS " + pgx.Identifier{schema}.Sanitize()
+	if owner != "" {
+		sql += " AUTHORIZATION " + pgx.Identifier{owner}.Sanitize()
+	}
+	if _, err := conn.Exec(ctx, sql); err != nil {
+		return fmt.Errorf("failed to create schema %s: %w", schema, err)
+	}
+	return nil
+}
+
+// migrateDatabase runs the pending migrations of migrationsDir with the write credentials
+func migrateDatabase(cmd *cobra.Command, write pgdb.PostgresConfig) error {
+	m, err := newMigrationInstance(buildDatabaseURL(write))
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	err = m.Up()
+	switch {
+	case errors.Is(err, migrate.ErrNoChange):
+		fmt.Fprintln(cmd.OutOrStdout(), "No migrations to run")
+	case err != nil:
+		return fmt.Errorf("failed to run migrations: %w", err)
+	default:
+		fmt.Fprintln(cmd.OutOrStdout(), "Migrations completed successfully")
+	}
+	return nil
+}
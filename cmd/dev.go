@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yourorg/go-api-template/config"
+	"github.com/yourorg/go-api-template/core/devserver"
+	"github.com/yourorg/go-api-template/utils/runtime"
+)
+
+var (
+	devPort        string
+	devServe       string
+	devWait        time.Duration
+	devStopTimeout time.Duration
+)
+
+var devCmd = &cobra.Command{
+	Use:     "dev",
+	Short:   "Run the server with rebuilds on change",
+	GroupID: groupServe,
+	Long: `Build and run --serve (serve:all-api by default) with --profile, and rebuild and restart it
+when Go files, config, templates or migrations change. Requests go through a proxy on --port,
+restServer.port by default, which holds them while the server restarts; after a failed build
+they get the compiler output and the previous server keeps running when there was one.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := cmd.Context()
+		cmd.SilenceUsage = true
+		profile, err := cmd.Flags().GetString("profile")
+		if err != nil {
+			return err
+		}
+		port := devPort
+		if port == "" {
+			port = devDefaultPort(runtime.ValidateProfile(profile))
+		}
+
+		dir, err := os.MkdirTemp("", "dev-server-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+		binary := filepath.Join(dir, RootCmdName)
+
+		changes, err := devserver.Watch(ctx, devserver.DefaultWatchOptions(), slog.Default())
+		if err != nil {
+			return fmt.Errorf("failed to watch files: %w", err)
+		}
+
+		listener, err := net.Listen("tcp", ":"+port)
+		if err != nil {
+			return fmt.Errorf("failed to listen on port %s: %w", port, err)
+		}
+		proxy := devserver.NewProxy(devWait)
+		proxyServer := &http.Server{Handler: proxy, ReadHeaderTimeout: 10 * time.Second}
+		go func() {
+			if err := proxyServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				slog.ErrorContext(ctx, "[DEV] proxy stopped", "error", err)
+			}
+		}()
+		slog.InfoContext(ctx, fmt.Sprintf("[DEV] Serving on http://localhost:%s, restarting %s on changes", port, devServe))
+
+		serveArgs := []string{devServe}
+		if profile != "" {
+			serveArgs = append(serveArgs, "--profile", profile)
+		}
+		var server *devserver.Process
+		restart := func() {
+			start := time.Now()
+			if err := devserver.Build(ctx, ".", binary); err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				slog.ErrorContext(ctx, "[DEV] "+err.Error())
+				if server == nil {
+					proxy.Failed(err.Error())
+				} else {
+					slog.WarnContext(ctx, "[DEV] the previous server keeps running")
+				}
+				return
+			}
+
+			proxy.Restarting()
+			if server != nil {
+				if err := server.Stop(devStopTimeout); err != nil {
+					slog.WarnContext(ctx, "[DEV] server stop", "error", err)
+				}
+				server = nil
+			}
+
+			upstreamPort, err := devserver.FreePort()
+			if err != nil {
+				proxy.Failed(err.Error())
+				return
+			}
+			next, err := devserver.Start(binary, serveArgs, []string{"APP_RESTSERVER_PORT=" + strconv.Itoa(upstreamPort)})
+			if err != nil {
+				proxy.Failed(fmt.Sprintf("failed to start the server: %s", err))
+				return
+			}
+			server = next
+
+			addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(upstreamPort))
+			if err := server.WaitReady(ctx, addr, devWait); err != nil {
+				slog.ErrorContext(ctx, "[DEV] "+err.Error())
+				proxy.Failed(err.Error())
+				return
+			}
+			proxy.Ready(&url.URL{Scheme: "http", Host: addr})
+			slog.InfoContext(ctx, "[DEV] server ready", "duration", time.Since(start).Round(time.Millisecond))
+		}
+
+		restart()
+		for {
+			var exited <-chan struct{}
+			if server != nil {
+				exited = server.Exited()
+			}
+
+			select {
+			case <-ctx.Done():
+				if server != nil {
+					_ = server.Stop(devStopTimeout)
+				}
+				shutdownCtx, cancel := context.WithTimeout(context.Background(), devStopTimeout)
+				defer cancel()
+				return proxyServer.Shutdown(shutdownCtx)
+			case files, ok := <-changes:
+				if !ok {
+					return errors.New("file watcher stopped")
+				}
+				slog.InfoContext(ctx, "[DEV] rebuilding", "changed", devChangedFiles(files))
+				restart()
+			case <-exited:
+				slog.ErrorContext(ctx, "[DEV] server exited, waiting for changes", "error", server.Err())
+				proxy.Failed(fmt.Sprintf("the server exited (%v), fix it and save to restart", server.Err()))
+				server = nil
+			}
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(devCmd)
+
+	devCmd.Flags().StringVar(&devPort, "port", "", "Port of the proxy, restServer.port of the profile by default")
+	devCmd.Flags().StringVar(&devServe, "serve", "serve:all-api", "Serve command to run")
+	devCmd.Flags().DurationVar(&devWait, "wait", 30*time.Second, "How long requests and startup wait for the server")
+	devCmd.Flags().DurationVar(&devStopTimeout, "stop-timeout", 10*time.Second, "How long a server has to shut down before it is killed")
+	_ = devCmd.RegisterFlagCompletionFunc("serve", completeFormats("serve:all-api", "serve:all"))
+}
+
+// devDefaultPort is restServer.port of profile, 8080 when the config does not load
+func devDefaultPort(profile runtime.Environment) string {
+	if err := resolveConfig(profile); err == nil {
+		if cfg := config.GetConfig(); cfg != nil && cfg.RestServer.Port != "" {
+			return cfg.RestServer.Port
+		}
+	}
+	return "8080"
+}
+
+// devChangedFiles shortens the changed files of a rebuild for the log
+func devChangedFiles(files []string) string {
+	const shown = 3
+	if len(files) <= shown {
+		return strings.Join(files, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(files[:shown], ", "), len(files)-shown)
+}
@@ -0,0 +1,233 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/spf13/cobra"
+	"github.com/yourorg/go-api-template/config"
+	"github.com/yourorg/go-api-template/core/cache"
+	core_config "github.com/yourorg/go-api-template/core/config"
+	"github.com/yourorg/go-api-template/core/doctor"
+	"github.com/yourorg/go-api-template/core/health"
+	"github.com/yourorg/go-api-template/core/httpclient"
+	"github.com/yourorg/go-api-template/core/logger"
+	"github.com/yourorg/go-api-template/core/pgdb"
+	"github.com/yourorg/go-api-template/core/pgdb/migration"
+	"github.com/yourorg/go-api-template/utils/runtime"
+)
+
+var (
+	doctorFormat  string
+	doctorTimeout time.Duration
+	doctorVerbose bool
+)
+
+var doctorCmd = &cobra.Command{
+	Use:     "doctor",
+	GroupID: groupAdmin,
+	Short:   "Diagnose the environment of a profile",
+	Long: `Load and validate the config of --profile, then check that Postgres, Redis and LM Studio
+are reachable and that the database migrations are up to date, and print a readiness report.
+Dependencies left out of the config are skipped. The command fails when a check fails.`,
+	RunE: runDoctor,
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+	doctorCmd.Flags().StringVarP(&doctorFormat, "output", "o", "text", "Output format: text or json")
+	_ = doctorCmd.RegisterFlagCompletionFunc("output", completeFormats("text", "json"))
+	doctorCmd.Flags().DurationVar(&doctorTimeout, "timeout", 5*time.Second, "Timeout of each check")
+	doctorCmd.Flags().BoolVarP(&doctorVerbose, "verbose", "v", false, "Print the logs of the checks")
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	if doctorFormat != "text" && doctorFormat != "json" {
+		return fmt.Errorf("unknown output format %q, use text or json", doctorFormat)
+	}
+	profileFlag, err := cmd.Flags().GetString("profile")
+	if err != nil {
+		return err
+	}
+	profile := runtime.ValidateProfile(profileFlag)
+
+	// the report is the output, logs of the checks only with --verbose
+	if doctorVerbose {
+		setUpLogger(profile)
+	} else {
+		logger.Slog = slog.New(slog.NewTextHandler(io.Discard, nil))
+		slog.SetDefault(logger.Slog)
+	}
+
+	ctx := cmd.Context()
+	report := doctor.Run(ctx, doctorTimeout, doctor.Check{Name: "config", Run: checkConfig(profile)})
+	if report.Healthy() {
+		cfg := config.GetConfig().Config
+		dependencies := doctor.Run(ctx, doctorTimeout,
+			doctor.Check{Name: "postgres", Run: checkPostgres(cfg.Postgres)},
+			doctor.Check{Name: "migrations", Run: checkMigrations(cfg.Postgres.Write)},
+			doctor.Check{Name: "redis", Run: checkRedis(cfg.Redis)},
+			doctor.Check{Name: "lm studio", Run: checkLMStudio(cfg)},
+		)
+		report.Results = append(report.Results, dependencies.Results...)
+	}
+
+	out := cmd.OutOrStdout()
+	if doctorFormat == "json" {
+		err = report.WriteJSON(out)
+	} else {
+		fmt.Fprintf(out, "Doctor report for profile %s\n\n", profile)
+		err = report.WriteText(out)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !report.Healthy() {
+		cmd.SilenceUsage, cmd.SilenceErrors = true, true
+		return fmt.Errorf("doctor found %d failed check(s)", report.Count(doctor.StatusFail))
+	}
+	return nil
+}
+
+// checkConfig loads the config files of profile and validates them
+func checkConfig(profile runtime.Environment) doctor.CheckFunc {
+	return func(ctx context.Context) (doctor.Status, string) {
+		paths, err := core_config.GetConfigFilePaths(runtime.RuntimeCfg{Env: profile})
+		if err != nil {
+			return doctor.StatusFail, err.Error()
+		}
+		if err := config.ResolveConfigFromFiles(ctx, paths...); err != nil {
+			return doctor.StatusFail, err.Error()
+		}
+		if err := config.GetConfig().Validate(); err != nil {
+			return doctor.StatusFail, err.Error()
+		}
+		var found []string
+		for _, path := range paths {
+			if _, err := os.Stat(path); err == nil {
+				found = append(found, path)
+			}
+		}
+		return doctor.StatusOK, "valid: " + strings.Join(found, ", ")
+	}
+}
+
+// checkPostgres connects to the write and, when distinct, the read database
+func checkPostgres(cfg pgdb.Postgres) doctor.CheckFunc {
+	return func(ctx context.Context) (doctor.Status, string) {
+		if cfg.Write.Host == "" && cfg.Read.Host == "" {
+			return doctor.StatusSkip, "postgres is not configured"
+		}
+		if err := pgdb.InitPgConnectionPool(ctx, cfg); err != nil {
+			return doctor.StatusFail, fmt.Sprintf("connecting to %s: %s", postgresAddr(cfg.Write), err)
+		}
+		defer pgdb.ClosePgPool()
+
+		writePool, _ := pgdb.GetWritePgPool()
+		status, message := doctor.FromHealth(health.NewPgxDatabaseChecker(writePool))(ctx)
+		message = postgresAddr(cfg.Write) + ": " + message
+		if readPool, _ := pgdb.GetReadPgPool(); readPool != writePool {
+			readStatus, readMessage := doctor.FromHealth(health.NewPgxDatabaseChecker(readPool))(ctx)
+			message += "\nread " + postgresAddr(cfg.Read) + ": " + readMessage
+			if readStatus != doctor.StatusOK {
+				status = readStatus
+			}
+		}
+		return status, message
+	}
+}
+
+func postgresAddr(cfg pgdb.PostgresConfig) string {
+	return fmt.Sprintf("%s:%d/%s", cfg.Host, cfg.Port, cfg.Database)
+}
+
+// checkMigrations compares the version of the database with the migrations directory
+func checkMigrations(cfg pgdb.PostgresConfig) doctor.CheckFunc {
+	return func(ctx context.Context) (doctor.Status, string) {
+		if cfg.Host == "" {
+			return doctor.StatusSkip, "postgres is not configured"
+		}
+		migrations, err := migration.Load(migrationsDir)
+		if err != nil {
+			return doctor.StatusFail, err.Error()
+		}
+		versions := migration.Versions(migrations)
+
+		timeout := max(int(doctorTimeout.Seconds()), 1)
+		m, err := newMigrationInstance(buildDatabaseURL(cfg) + "&connect_timeout=" + strconv.Itoa(timeout))
+		if err != nil {
+			return doctor.StatusFail, err.Error()
+		}
+		defer m.Close()
+
+		version, dirty, err := m.Version()
+		if errors.Is(err, migrate.ErrNilVersion) {
+			return doctor.StatusFail, fmt.Sprintf("database is not migrated, %d migration(s) pending: run make migrate", len(versions))
+		}
+		if err != nil {
+			return doctor.StatusFail, err.Error()
+		}
+		if dirty {
+			return doctor.StatusFail, fmt.Sprintf("version %d is dirty, fix the database then run migrate force %d", version, version)
+		}
+
+		pending := 0
+		for _, v := range versions {
+			if v > version {
+				pending++
+			}
+		}
+		switch {
+		case pending > 0:
+			return doctor.StatusFail, fmt.Sprintf("version %d, %d migration(s) pending: run make migrate", version, pending)
+		case len(versions) > 0 && version > versions[len(versions)-1]:
+			return doctor.StatusWarn, fmt.Sprintf("version %d is ahead of the latest migration %d", version, versions[len(versions)-1])
+		default:
+			return doctor.StatusOK, fmt.Sprintf("version %d, up to date", version)
+		}
+	}
+}
+
+// checkRedis pings the configured Redis
+func checkRedis(cfg cache.RedisConfig) doctor.CheckFunc {
+	return func(ctx context.Context) (doctor.Status, string) {
+		if cfg.Host == "" {
+			return doctor.StatusSkip, "redis is not configured"
+		}
+		client := cache.NewRedisService(cfg)
+		defer client.Close()
+
+		addr := fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)
+		if err := client.Ping(ctx); err != nil {
+			return doctor.StatusFail, fmt.Sprintf("%s: %s", addr, err)
+		}
+		return doctor.StatusOK, addr + " is reachable"
+	}
+}
+
+// checkLMStudio lists the models of LM Studio and looks for the configured one
+func checkLMStudio(cfg core_config.Config) doctor.CheckFunc {
+	return func(ctx context.Context) (doctor.Status, string) {
+		switch {
+		case cfg.LMStudio.EnableMock:
+			return doctor.StatusSkip, "lmStudio.enableMock is set"
+		case cfg.LLM.Provider != "" && cfg.LLM.Provider != httpclient.ProviderLMStudio:
+			return doctor.StatusSkip, fmt.Sprintf("llm.provider is %s", cfg.LLM.Provider)
+		case cfg.LMStudio.BaseUrl == "":
+			return doctor.StatusSkip, "lmStudio.baseUrl is not configured"
+		}
+
+		client := httpclient.NewLmStudioHttpClient(&cfg.LMStudio, *logger.Slog)
+		status, message := doctor.FromHealth(health.NewModelChecker(client.Models, cfg.LMStudio.Model))(ctx)
+		return status, cfg.LMStudio.BaseUrl + ": " + message
+	}
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/yourorg/go-api-template/core/exception"
+)
+
+var errorsCmd = &cobra.Command{
+	Use:     "errors",
+	GroupID: groupAdmin,
+	Short:   "Error catalog commands",
+	Long:    "Commands to validate the error catalog and generate typed accessors from it",
+}
+
+var errorsValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validate an error catalog",
+	Long:  "Check that error names and codes are unique and statuses and messages are set",
+	RunE:  runErrorsValidate,
+}
+
+var errorsGenerateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generate typed accessors from an error catalog",
+	Long:  "Generate a Go struct with one *ExceptionError field per catalog entry (used by go generate ./core/exception)",
+	RunE:  runErrorsGenerate,
+}
+
+var (
+	errorsCatalogPath string
+	errorsOutPath     string
+	errorsPackage     string
+	errorsType        string
+)
+
+func init() {
+	rootCmd.AddCommand(errorsCmd)
+	errorsCmd.AddCommand(errorsValidateCmd)
+	errorsCmd.AddCommand(errorsGenerateCmd)
+
+	errorsCmd.PersistentFlags().StringVar(&errorsCatalogPath, "catalog", "core/exception/catalog.yaml", "Path to the error catalog")
+	errorsGenerateCmd.Flags().StringVar(&errorsOutPath, "out", "core/exception/application_errors_gen.go", "Output file")
+	errorsGenerateCmd.Flags().StringVar(&errorsPackage, "package", "exception", "Package name of the generated file")
+	errorsGenerateCmd.Flags().StringVar(&errorsType, "type", "MockDataServiceErrors", "Name of the generated struct")
+}
+
+func runErrorsValidate(cmd *cobra.Command, args []string) error {
+	catalog, err := exception.LoadCatalog(errorsCatalogPath)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Error catalog %s is valid (%d errors)\n", errorsCatalogPath, len(catalog.Errors))
+	return nil
+}
+
+func runErrorsGenerate(cmd *cobra.Command, args []string) error {
+	catalog, err := exception.LoadCatalog(errorsCatalogPath)
+	if err != nil {
+		return err
+	}
+
+	src, err := exception.GenerateAccessors(catalog, errorsPackage, errorsType)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(errorsOutPath, src, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", errorsOutPath, err)
+	}
+
+	fmt.Printf("Generated %s from %s\n", errorsOutPath, errorsCatalogPath)
+	return nil
+}
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/spf13/cobra"
+	"github.com/yourorg/go-api-template/core/fixtures"
+	"github.com/yourorg/go-api-template/core/pgdb"
+)
+
+// fixturesDir holds the fixture sets, relative to the working directory
+const fixturesDir = "fixtures"
+
+var (
+	fixturesReset bool
+	fixturesRoot  string
+)
+
+var fixturesCmd = &cobra.Command{
+	Use:     "fixtures",
+	GroupID: groupDB,
+	Short:   "Seed data commands",
+	Long:    "Load named fixture sets of seed data to reset integration environments and demos",
+}
+
+var fixturesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the fixture sets",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		names, err := fixtures.List(fixturesRoot)
+		if err != nil {
+			return fmt.Errorf("failed to list fixture sets: %w", err)
+		}
+		if len(names) == 0 {
+			fmt.Fprintf(cmd.OutOrStdout(), "No fixture sets in %s\n", fixturesRoot)
+			return nil
+		}
+		for _, name := range names {
+			fmt.Fprintln(cmd.OutOrStdout(), name)
+		}
+		return nil
+	},
+}
+
+var fixturesLoadCmd = &cobra.Command{
+	Use:   "load [set]",
+	Short: "Load a fixture set into the database",
+	Long: `Run the .sql and .yaml files of fixtures/<set> in name order against the database of
+--profile, in a single transaction so a failing file leaves nothing behind. --reset empties
+the tables filled by the YAML files first. Production profiles are refused.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeFixtureSets,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		env, err := profileFlag(cmd)
+		if err != nil {
+			return err
+		}
+		if env.IsProduction() {
+			return fmt.Errorf("refusing to load fixtures into the production profile %s", env)
+		}
+
+		set, err := fixtures.Load(fixturesRoot, args[0])
+		if err != nil {
+			return err
+		}
+
+		cfg, err := loadValidConfig(env)
+		if err != nil {
+			return err
+		}
+		return applyFixtureSet(cmd, cfg.Postgres.Write, set, fixturesReset)
+	},
+}
+
+// completeFixtureSets completes the set argument of fixtures load
+func completeFixtureSets(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	names, _ := fixtures.List(fixturesRoot)
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// applyFixtureSet loads set into the database of postgresConfig in a single transaction
+func applyFixtureSet(cmd *cobra.Command, postgresConfig pgdb.PostgresConfig, set *fixtures.Set, reset bool) error {
+	ctx := cmd.Context()
+	conn, err := pgdb.Connect(ctx, postgresConfig)
+	if err != nil {
+		return fmt.Errorf("failed to connect to postgres: %w", err)
+	}
+	defer conn.Close(context.WithoutCancel(ctx))
+
+	err = pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		return set.Apply(ctx, tx, reset)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to load fixture set %s, nothing was loaded: %w", set.Name, err)
+	}
+
+	fmt.Fprintf(cmd.OutOrStdout(), "Loaded fixture set %s into %s (%d statement(s))\n",
+		set.Name, postgresConfig.Database, len(set.Statements))
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(fixturesCmd)
+	fixturesCmd.AddCommand(fixturesListCmd)
+	fixturesCmd.AddCommand(fixturesLoadCmd)
+
+	fixturesCmd.PersistentFlags().StringVar(&fixturesRoot, "dir", fixturesDir, "Directory of the fixture sets")
+	fixturesLoadCmd.Flags().BoolVar(&fixturesReset, "reset", false, "Empty the tables of the YAML files before loading")
+}
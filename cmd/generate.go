@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/yourorg/go-api-template/core/scaffold"
+)
+
+var generateCmd = &cobra.Command{
+	Use:     "generate",
+	GroupID: groupAdmin,
+	Short:   "Code generation commands",
+	Long:    "Commands to scaffold code following the conventions of the template",
+}
+
+var generateResourceCmd = &cobra.Command{
+	Use:   "resource <Name>",
+	Short: "Scaffold a CRUD resource",
+	Long: `Scaffold a CRUD resource from its name, e.g. OrderItem or order_item: model structs with
+validation tags, a repository interface and its Postgres implementation, a service, a
+migration creating its table, a unit test, and the registration of the repository, the
+service and the /api/v1 routes at the +scaffold markers. Run it from the project root.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runGenerateResource,
+}
+
+var (
+	generateForce         bool
+	generateSkipMigration bool
+)
+
+func init() {
+	rootCmd.AddCommand(generateCmd)
+	generateCmd.AddCommand(generateResourceCmd)
+
+	generateResourceCmd.Flags().BoolVar(&generateForce, "force", false, "Overwrite existing files of the resource")
+	generateResourceCmd.Flags().BoolVar(&generateSkipMigration, "skip-migration", false, "Do not create the migration of the table")
+}
+
+func runGenerateResource(cmd *cobra.Command, args []string) error {
+	module, err := scaffold.ModulePath(".")
+	if err != nil {
+		return fmt.Errorf("run generate from the project root: %w", err)
+	}
+	resource, err := scaffold.NewResource(args[0], module)
+	if err != nil {
+		return err
+	}
+
+	files, err := scaffold.Generate(resource)
+	if err != nil {
+		return err
+	}
+	if !generateForce {
+		for _, file := range files {
+			if _, err := os.Stat(file.Path); err == nil {
+				return fmt.Errorf("%s already exists, use --force to overwrite", file.Path)
+			}
+		}
+	}
+
+	for _, file := range files {
+		if err := os.MkdirAll(filepath.Dir(file.Path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(file.Path, file.Content, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", file.Path, err)
+		}
+		fmt.Printf("Created %s\n", file.Path)
+	}
+
+	if !generateSkipMigration {
+		up, down, err := scaffold.Migration(resource)
+		if err != nil {
+			return err
+		}
+		if err := writeMigrationFiles("create_"+resource.Table+"_table", up, down); err != nil {
+			return err
+		}
+	}
+
+	changed, err := scaffold.Register(".", resource)
+	if err != nil {
+		return fmt.Errorf("failed to register %s: %w", resource.Name, err)
+	}
+	for _, path := range changed {
+		fmt.Printf("Updated %s\n", path)
+	}
+
+	fmt.Printf("Resource %s is served at /api/v1/%s once migrated (make migrate); add its fields to the model, repository and migration\n",
+		resource.Name, resource.Path)
+	return nil
+}
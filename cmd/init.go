@@ -0,0 +1,246 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/yourorg/go-api-template/core/auth"
+	core_config "github.com/yourorg/go-api-template/core/config"
+)
+
+const (
+	// initConfigFile is the developer overlay merged over every profile
+	initConfigFile   = "config/config.local.yaml"
+	initTemplateFile = "config/example.config.yaml"
+)
+
+var (
+	initForce    bool
+	initDefaults bool
+	initMigrate  bool
+)
+
+var initCmd = &cobra.Command{
+	Use:     "init",
+	Short:   "Generate config/config.local.yaml interactively",
+	GroupID: groupAdmin,
+	Long: `Ask for the REST port, Postgres, Redis and LM Studio settings and write them with a generated
+JWT secret to ` + initConfigFile + `, based on ` + initTemplateFile + ` and keeping its comments.
+Then optionally create the database and run the migrations, going from clone to a running
+server with:
+  go run main.go init && go run main.go dev`,
+	Example: `  go run main.go init
+  go run main.go init --defaults --migrate   # docker-compose settings, no questions`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		if _, err := os.Stat(initConfigFile); err == nil && !initForce {
+			return fmt.Errorf("%s already exists, pass --force to overwrite it", initConfigFile)
+		}
+
+		p := &prompter{in: bufio.NewReader(cmd.InOrStdin()), out: cmd.OutOrStdout(), defaults: initDefaults}
+		if !initDefaults {
+			fmt.Fprintf(p.out, "Writing %s, Enter keeps the [default] and - leaves a value empty.\n\n", initConfigFile)
+		}
+		values, err := askConfigValues(p)
+		if err != nil {
+			return err
+		}
+
+		migrateUp := initMigrate
+		if !migrateUp && values["postgres.write.host"] != "" {
+			if migrateUp, err = p.confirm("Create the database and run the migrations now?", false); err != nil {
+				return err
+			}
+		}
+
+		template, err := os.ReadFile(initTemplateFile)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		file, err := core_config.SetValues(template, values)
+		if err != nil {
+			return fmt.Errorf("failed to fill %s: %w", initTemplateFile, err)
+		}
+		// the file holds the JWT secret and database password
+		if err := os.WriteFile(initConfigFile, file, 0600); err != nil {
+			return err
+		}
+		fmt.Fprintf(p.out, "Wrote %s\n", initConfigFile)
+
+		if migrateUp {
+			env, err := profileFlag(cmd)
+			if err != nil {
+				return err
+			}
+			cfg, err := dbConfig(env)
+			if err != nil {
+				return err
+			}
+			if err := createDatabase(cmd, cfg, true, ""); err != nil {
+				return err
+			}
+		}
+
+		fmt.Fprintln(p.out, "\nStart the server with:\n  go run main.go dev")
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(initCmd)
+
+	initCmd.Flags().BoolVar(&initForce, "force", false, "Overwrite an existing "+initConfigFile)
+	initCmd.Flags().BoolVar(&initDefaults, "defaults", false, "Use the defaults, matching docker-compose.yml, without asking")
+	initCmd.Flags().BoolVar(&initMigrate, "migrate", false, "Create the database and run the migrations without asking")
+}
+
+// askConfigValues asks for the settings a local server needs, keyed like the config file
+func askConfigValues(p *prompter) (map[string]any, error) {
+	values := map[string]any{"env": "local"}
+
+	port, err := p.askPort("REST server port", 8080)
+	if err != nil {
+		return nil, err
+	}
+	values["restServer.port"] = strconv.Itoa(port)
+
+	host, err := p.ask("Postgres host (- without a database)", "localhost")
+	if err != nil {
+		return nil, err
+	}
+	values["postgres.read.host"], values["postgres.write.host"] = host, host
+	if host != "" {
+		if port, err = p.askPort("Postgres port", 5432); err != nil {
+			return nil, err
+		}
+		answers := map[string]string{"username": "postgres", "password": "postgres", "database": "go_api_template"}
+		for _, key := range []string{"username", "password", "database"} {
+			if answers[key], err = p.ask("Postgres "+key, answers[key]); err != nil {
+				return nil, err
+			}
+		}
+		for _, pool := range []string{"read", "write"} {
+			values["postgres."+pool+".port"] = port
+			for key, answer := range answers {
+				values["postgres."+pool+"."+key] = answer
+			}
+		}
+	}
+
+	if host, err = p.ask("Redis host (- without Redis)", "localhost"); err != nil {
+		return nil, err
+	}
+	values["redis.host"] = host
+	if host == "" {
+		values["jobs.store"] = "memory"
+	} else if values["redis.port"], err = p.askPort("Redis port", 6379); err != nil {
+		return nil, err
+	}
+
+	mock, err := p.confirm("Mock LM Studio instead of calling a model server?", false)
+	if err != nil {
+		return nil, err
+	}
+	values["lmStudio.enableMock"] = mock
+	if !mock {
+		for {
+			address, err := p.ask("LM Studio URL", "http://localhost:1234")
+			if err != nil {
+				return nil, err
+			}
+			u, err := url.Parse(address)
+			if err == nil && u.Scheme != "" && u.Host != "" {
+				values["lmStudio.protocol"], values["lmStudio.baseUrl"] = u.Scheme, u.Host+strings.TrimSuffix(u.Path, "/")
+				break
+			}
+			if p.defaults {
+				return nil, fmt.Errorf("invalid LM Studio URL %q", address)
+			}
+			fmt.Fprintln(p.out, "  enter a URL such as http://localhost:1234")
+		}
+		if values["lmStudio.model"], err = p.ask("LM Studio model (- for the loaded one)", ""); err != nil {
+			return nil, err
+		}
+	}
+
+	secret, err := auth.GenerateSecretKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate secret: %w", err)
+	}
+	values["auth.jwtSecretKey"] = secret
+	return values, nil
+}
+
+// prompter asks questions on the command input, or answers them with their defaults
+type prompter struct {
+	in       *bufio.Reader
+	out      io.Writer
+	defaults bool
+}
+
+// ask returns the answer to question, def for an empty one and "" for -
+func (p *prompter) ask(question string, def string) (string, error) {
+	if p.defaults {
+		return def, nil
+	}
+	if def != "" {
+		fmt.Fprintf(p.out, "%s [%s]: ", question, def)
+	} else {
+		fmt.Fprintf(p.out, "%s: ", question)
+	}
+
+	line, err := p.in.ReadString('\n')
+	if err != nil && (!errors.Is(err, io.EOF) || line == "") {
+		if errors.Is(err, io.EOF) {
+			return "", errors.New("input ended before every question was answered, use --defaults to skip them")
+		}
+		return "", err
+	}
+	switch answer := strings.TrimSpace(line); answer {
+	case "":
+		return def, nil
+	case "-":
+		return "", nil
+	default:
+		return answer, nil
+	}
+}
+
+func (p *prompter) askPort(question string, def int) (int, error) {
+	for {
+		answer, err := p.ask(question, strconv.Itoa(def))
+		if err != nil {
+			return 0, err
+		}
+		if port, err := strconv.Atoi(answer); err == nil && port > 0 && port <= 65535 {
+			return port, nil
+		}
+		fmt.Fprintln(p.out, "  enter a port number between 1 and 65535")
+	}
+}
+
+func (p *prompter) confirm(question string, def bool) (bool, error) {
+	options := map[bool]string{true: "Y/n", false: "y/N"}[def]
+	for {
+		answer, err := p.ask(question+" ["+options+"]", "")
+		if err != nil || answer == "" {
+			return def, err
+		}
+		switch strings.ToLower(answer) {
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+		fmt.Fprintln(p.out, "  answer y or n")
+	}
+}
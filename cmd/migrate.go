@@ -2,10 +2,12 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/golang-migrate/migrate/v4"
@@ -13,19 +15,24 @@ import (
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/spf13/cobra"
 	"github.com/yourorg/go-api-template/config"
+	core_config "github.com/yourorg/go-api-template/core/config"
 	"github.com/yourorg/go-api-template/core/pgdb"
+	"github.com/yourorg/go-api-template/core/pgdb/migration"
+	sqllib "github.com/yourorg/go-api-template/core/pgdb/sql_lib"
+	"github.com/yourorg/go-api-template/utils/runtime"
 )
 
 var migrateCmd = &cobra.Command{
-	Use:   "migrate",
-	Short: "Database migration commands",
-	Long:  "Database migration commands to manage database schema changes",
+	Use:     "migrate",
+	GroupID: groupDB,
+	Short:   "Database migration commands",
+	Long:    "Database migration commands to manage database schema changes",
 }
 
 var migrateUpCmd = &cobra.Command{
 	Use:   "up",
 	Short: "Run all pending migrations",
-	Long:  "Apply all pending database migrations",
+	Long:  "Apply all pending database migrations. Use --steps flag to apply only the next migrations",
 	RunE:  runMigrateUp,
 }
 
@@ -39,10 +46,29 @@ var migrateDownCmd = &cobra.Command{
 var migrateStatusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show migration status",
-	Long:  "Show current migration status and pending migrations",
+	Long:  "Show current migration status and pending migrations. Exits non-zero when migrations are pending, for gating CI",
 	RunE:  runMigrateStatus,
 }
 
+var migrateGotoCmd = &cobra.Command{
+	Use:   "goto [version]",
+	Short: "Migrate up or down to a specific version",
+	Long:  "Apply or rollback migrations until the database is at the given migration version",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runMigrateGoto,
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) > 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		migrations, _ := migration.Load(migrationsDir)
+		var versions []string
+		for _, m := range migrations {
+			versions = append(versions, fmt.Sprintf("%d\t%s", m.Version, m.Name))
+		}
+		return versions, cobra.ShellCompDirectiveNoFileComp
+	},
+}
+
 var migrateCreateCmd = &cobra.Command{
 	Use:   "create [name]",
 	Short: "Create a new migration",
@@ -59,6 +85,14 @@ var migrateForceCmd = &cobra.Command{
 	RunE:  runMigrateForce,
 }
 
+var migrateSoftDeleteCmd = &cobra.Command{
+	Use:   "soft-delete [table]",
+	Short: "Create a migration adding soft delete to a table",
+	Long:  "Create a migration that adds a deleted_at column and partial index to a table, for use with sqllib.EnableSoftDelete",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runMigrateSoftDelete,
+}
+
 var migrateVersionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Show current migration version",
@@ -67,10 +101,15 @@ var migrateVersionCmd = &cobra.Command{
 }
 
 var (
-	migrateSteps int
-	migrateAll   bool
+	migrateSteps   int
+	migrateUpSteps int
+	migrateAll     bool
+	migrateDryRun  bool
 )
 
+// migrationsDir holds the SQL migrations, relative to the working directory
+const migrationsDir = "migrations"
+
 func init() {
 	// Add migrate command to root
 	rootCmd.AddCommand(migrateCmd)
@@ -82,16 +121,28 @@ func init() {
 	migrateCmd.AddCommand(migrateCreateCmd)
 	migrateCmd.AddCommand(migrateForceCmd)
 	migrateCmd.AddCommand(migrateVersionCmd)
+	migrateCmd.AddCommand(migrateSoftDeleteCmd)
+	migrateCmd.AddCommand(migrateGotoCmd)
 
 	// Add flags
+	migrateUpCmd.Flags().IntVar(&migrateUpSteps, "steps", 0, "Number of migrations to apply (0 applies all pending)")
 	migrateDownCmd.Flags().IntVar(&migrateSteps, "steps", 1, "Number of migrations to rollback")
 	migrateDownCmd.Flags().BoolVar(&migrateAll, "all", false, "Rollback all migrations")
+	for _, c := range []*cobra.Command{migrateUpCmd, migrateDownCmd, migrateGotoCmd} {
+		c.Flags().BoolVar(&migrateDryRun, "dry-run", false, "Print the migration files and SQL that would run without applying them")
+	}
 }
 
 func getMigrationInstance() (*migrate.Migrate, error) {
 	// Load configuration
 	ctx := context.Background()
-	if err := config.ResolveConfigFromFile(ctx, "config/config.local.yaml"); err != nil {
+	// --profile defaults to local, whose overlay used to be the only file read here
+	profile, _ := rootCmd.PersistentFlags().GetString("profile")
+	configPaths, err := core_config.GetConfigFilePaths(runtime.RuntimeCfg{Env: runtime.ValidateProfile(profile)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := config.ResolveConfigFromFiles(ctx, configPaths...); err != nil {
 		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
 	
@@ -100,13 +151,12 @@ func getMigrationInstance() (*migrate.Migrate, error) {
 		return nil, fmt.Errorf("config is nil")
 	}
 
-	// Build database URL
-	dbURL := buildDatabaseURL(cfg.Postgres.Write)
-
-	// Get migrations path
-	migrationsPath := "file://migrations"
+	return newMigrationInstance(buildDatabaseURL(cfg.Postgres.Write))
+}
 
-	m, err := migrate.New(migrationsPath, dbURL)
+// newMigrationInstance opens the migrations directory against the database of dbURL
+func newMigrationInstance(dbURL string) (*migrate.Migrate, error) {
+	m, err := migrate.New("file://"+migrationsDir, dbURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create migrate instance: %w", err)
 	}
@@ -131,9 +181,32 @@ func runMigrateUp(cmd *cobra.Command, args []string) error {
 	}
 	defer m.Close()
 
-	fmt.Println("Running migrations...")
+	if migrateUpSteps < 0 {
+		return fmt.Errorf("--steps must not be negative, use migrate down to rollback")
+	}
+
+	if migrateDryRun {
+		return dryRunMigrations(m, func(migrations []migration.Migration, current uint) ([]migration.Step, error) {
+			if migrateUpSteps > 0 {
+				return migration.Steps(migrations, current, migrateUpSteps), nil
+			}
+			return migration.Pending(migrations, current), nil
+		})
+	}
+
+	if migrateUpSteps > 0 {
+		fmt.Printf("Running %d migration(s)...\n", migrateUpSteps)
+		err = m.Steps(migrateUpSteps)
+	} else {
+		fmt.Println("Running migrations...")
+		err = m.Up()
+	}
 
-	err = m.Up()
+	var short migrate.ErrShortLimit
+	if errors.As(err, &short) {
+		fmt.Printf("Only %d migration(s) were pending\n", uint(migrateUpSteps)-short.Short)
+		err = nil
+	}
 	if err != nil && err != migrate.ErrNoChange {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
@@ -154,6 +227,15 @@ func runMigrateDown(cmd *cobra.Command, args []string) error {
 	}
 	defer m.Close()
 
+	if migrateDryRun {
+		return dryRunMigrations(m, func(migrations []migration.Migration, current uint) ([]migration.Step, error) {
+			if migrateAll {
+				return migration.Steps(migrations, current, -len(migrations)), nil
+			}
+			return migration.Steps(migrations, current, -migrateSteps), nil
+		})
+	}
+
 	if migrateAll {
 		fmt.Println("Rolling back all migrations...")
 		err = m.Down()
@@ -162,6 +244,11 @@ func runMigrateDown(cmd *cobra.Command, args []string) error {
 		err = m.Steps(-migrateSteps)
 	}
 
+	var short migrate.ErrShortLimit
+	if errors.As(err, &short) {
+		fmt.Printf("Only %d migration(s) were applied\n", uint(migrateSteps)-short.Short)
+		err = nil
+	}
 	if err != nil && err != migrate.ErrNoChange {
 		return fmt.Errorf("failed to rollback migrations: %w", err)
 	}
@@ -189,25 +276,70 @@ func runMigrateStatus(cmd *cobra.Command, args []string) error {
 
 	if err == migrate.ErrNilVersion {
 		fmt.Println("Database is not initialized")
+	} else {
+		fmt.Printf("Current migration version: %d\n", version)
+		if dirty {
+			fmt.Println("Database is in dirty state - manual intervention may be required")
+		} else {
+			fmt.Println("Database is in clean state")
+		}
+	}
+
+	migrations, err := migration.Load(migrationsDir)
+	if err != nil {
+		return fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	pending := migration.Pending(migrations, version)
+	if len(pending) == 0 {
+		fmt.Println("No pending migrations")
 		return nil
 	}
 
-	fmt.Printf("Current migration version: %d\n", version)
-	if dirty {
-		fmt.Println("Database is in dirty state - manual intervention may be required")
-	} else {
-		fmt.Println("Database is in clean state")
+	fmt.Printf("Pending migrations:\n")
+	for _, step := range pending {
+		fmt.Printf("  %s\n", step.Path())
 	}
 
-	return nil
+	// Pending migrations are a failure so CI can gate deploys on this command
+	cmd.SilenceUsage = true
+	return fmt.Errorf("%d migration(s) pending", len(pending))
 }
 
 func runMigrateCreate(cmd *cobra.Command, args []string) error {
 	name := args[0]
+
+	upContent := fmt.Sprintf("-- Migration: %s\n-- Created: %s\n-- Description: %s\n\n-- Add your up migration here\n",
+		name, time.Now().Format(time.RFC3339), name)
+
+	downContent := fmt.Sprintf("-- Migration: %s (rollback)\n-- Created: %s\n-- Description: Rollback %s\n\n-- Add your down migration here\n",
+		name, time.Now().Format(time.RFC3339), name)
+
+	return writeMigrationFiles(name, upContent, downContent)
+}
+
+func runMigrateSoftDelete(cmd *cobra.Command, args []string) error {
+	table := args[0]
+
+	up, down, err := sqllib.SoftDeleteMigration(table)
+	if err != nil {
+		return fmt.Errorf("invalid table name: %w", err)
+	}
+
+	name := fmt.Sprintf("add_soft_delete_to_%s", strings.ReplaceAll(table, ".", "_"))
+	if err := writeMigrationFiles(name, up+"\n", down+"\n"); err != nil {
+		return err
+	}
+
+	fmt.Printf("Remember to register the table with sqllib.EnableSoftDelete(%q)\n", table)
+	return nil
+}
+
+// writeMigrationFiles writes timestamped up/down migration files into the migrations directory
+func writeMigrationFiles(name string, upContent string, downContent string) error {
 	timestamp := time.Now().Format("20060102150405")
 
 	// Create migrations directory if it doesn't exist
-	migrationsDir := "migrations"
 	if _, err := os.Stat(migrationsDir); os.IsNotExist(err) {
 		if err := os.MkdirAll(migrationsDir, 0755); err != nil {
 			return fmt.Errorf("failed to create migrations directory: %w", err)
@@ -218,9 +350,6 @@ func runMigrateCreate(cmd *cobra.Command, args []string) error {
 	upFilename := fmt.Sprintf("%s_%s.up.sql", timestamp, name)
 	upPath := filepath.Join(migrationsDir, upFilename)
 
-	upContent := fmt.Sprintf("-- Migration: %s\n-- Created: %s\n-- Description: %s\n\n-- Add your up migration here\n",
-		name, time.Now().Format(time.RFC3339), name)
-
 	if err := os.WriteFile(upPath, []byte(upContent), 0644); err != nil {
 		return fmt.Errorf("failed to create up migration file: %w", err)
 	}
@@ -229,9 +358,6 @@ func runMigrateCreate(cmd *cobra.Command, args []string) error {
 	downFilename := fmt.Sprintf("%s_%s.down.sql", timestamp, name)
 	downPath := filepath.Join(migrationsDir, downFilename)
 
-	downContent := fmt.Sprintf("-- Migration: %s (rollback)\n-- Created: %s\n-- Description: Rollback %s\n\n-- Add your down migration here\n",
-		name, time.Now().Format(time.RFC3339), name)
-
 	if err := os.WriteFile(downPath, []byte(downContent), 0644); err != nil {
 		return fmt.Errorf("failed to create down migration file: %w", err)
 	}
@@ -267,6 +393,82 @@ func runMigrateForce(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func runMigrateGoto(cmd *cobra.Command, args []string) error {
+	versionStr := args[0]
+	version, err := strconv.ParseUint(versionStr, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid version number: %s", versionStr)
+	}
+
+	m, err := getMigrationInstance()
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if migrateDryRun {
+		return dryRunMigrations(m, func(migrations []migration.Migration, current uint) ([]migration.Step, error) {
+			return migration.Goto(migrations, current, uint(version))
+		})
+	}
+
+	fmt.Printf("Migrating database to version %d...\n", version)
+
+	err = m.Migrate(uint(version))
+	if err != nil && err != migrate.ErrNoChange {
+		return fmt.Errorf("failed to migrate to version %d: %w", version, err)
+	}
+
+	if err == migrate.ErrNoChange {
+		fmt.Printf("Database is already at version %d\n", version)
+	} else {
+		fmt.Printf("Database migrated to version %d\n", version)
+	}
+
+	return nil
+}
+
+// dryRunMigrations prints the files, and their SQL, that plan picks from the
+// migrations directory for the current database version, without applying them
+func dryRunMigrations(m *migrate.Migrate, plan func(migrations []migration.Migration, current uint) ([]migration.Step, error)) error {
+	version, dirty, err := m.Version()
+	if err != nil && err != migrate.ErrNilVersion {
+		return fmt.Errorf("failed to get version: %w", err)
+	}
+	if dirty {
+		return fmt.Errorf("database is in dirty state at version %d, fix it then run migrate force %d", version, version)
+	}
+
+	migrations, err := migration.Load(migrationsDir)
+	if err != nil {
+		return fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	steps, err := plan(migrations, version)
+	if err != nil {
+		return err
+	}
+
+	if len(steps) == 0 {
+		fmt.Println("Dry run: no migrations to run")
+		return nil
+	}
+
+	fmt.Printf("Dry run: %d migration(s) would run, nothing was applied\n", len(steps))
+	for _, step := range steps {
+		sql, err := migration.ReadSQL(step)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("\n-- %s (%s)\n%s", step.Path(), step.Direction, sql)
+		if !strings.HasSuffix(sql, "\n") {
+			fmt.Println()
+		}
+	}
+
+	return nil
+}
+
 func runMigrateVersion(cmd *cobra.Command, args []string) error {
 	m, err := getMigrationInstance()
 	if err != nil {
@@ -1,6 +1,3 @@
-/*
-Copyright © 2025 NAME HERE <EMAIL ADDRESS>
-*/
 package cmd
 
 import (
@@ -8,37 +5,55 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/yourorg/go-api-template/config"
 	core_config "github.com/yourorg/go-api-template/core/config"
+	"github.com/yourorg/go-api-template/core/lifecycle"
 	"github.com/yourorg/go-api-template/core/logger"
 	"github.com/yourorg/go-api-template/core/pgdb"
+	"github.com/yourorg/go-api-template/core/telemetry"
+	middleware "github.com/yourorg/go-api-template/core/transport/httpserver/middlewares"
 	"github.com/yourorg/go-api-template/internal/build"
 	"github.com/yourorg/go-api-template/utils/runtime"
 	"github.com/spf13/cobra"
 )
 
-var RootCmdName = "main"
+// RootCmdName is the name of the binary in help and completion scripts, which must match
+// the command the shell completes: main for go run main.go and the Docker image
+var RootCmdName = filepath.Base(os.Args[0])
+
+// Command groups of the root help
+const (
+	groupServe = "serve"
+	groupDB    = "db"
+	groupAuth  = "auth"
+	groupAdmin = "admin"
+)
 
 var rootCmd = &cobra.Command{
-	Use:   "help",
-	Short: "A brief description of your application",
-	Long: `A longer description that spans multiple lines and likely contains
-examples and usage of using your application. For example:
-
-Cobra is a CLI library for Go that empowers applications.
-This application is a tool to generate the needed files
-to quickly create a Cobra application.`,
-	// Uncomment the following line if your bare application
-	// has an action associated with it:
-	// Run: func(cmd *cobra.Command, args []string) { },
+	Use:   RootCmdName,
+	Short: "REST API service with its database, auth and admin tooling",
+	Long: `Runs the REST API and its background job worker, and manages what they depend on:
+database migrations and fixtures, JWT secrets and tokens, config, error catalog and code
+generation. Commands read config/config.yaml with the overlays of --profile.`,
+	Example: strings.ReplaceAll(`  {name} serve:all-api --profile local
+  {name} migrate up --dry-run
+  {name} doctor --profile dev
+  source <({name} completion bash)`, "{name}", RootCmdName),
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
+// SIGINT and SIGTERM cancel the command context, which starts the graceful shutdown of serve commands;
+// a second signal kills the process right away.
 func Execute() {
-	err := rootCmd.Execute()
+	ctx, stop := lifecycle.SignalContext(context.Background(), os.Exit, os.Interrupt, syscall.SIGTERM)
+	err := rootCmd.ExecuteContext(ctx)
+	stop()
 	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
@@ -46,16 +61,45 @@ func Execute() {
 }
 
 func init() {
-	// Here you will define your flags and configuration settings.
-	// Cobra supports persistent flags, which, if defined here,
-	// will be global for your application.
+	rootCmd.AddGroup(
+		&cobra.Group{ID: groupServe, Title: "Serve:"},
+		&cobra.Group{ID: groupDB, Title: "Database:"},
+		&cobra.Group{ID: groupAuth, Title: "Auth:"},
+		&cobra.Group{ID: groupAdmin, Title: "Admin:"},
+	)
+	rootCmd.SetHelpCommandGroupID(groupAdmin)
+	rootCmd.SetCompletionCommandGroupID(groupAdmin)
+
+	rootCmd.PersistentFlags().String("profile", "", "Profile for the service to run: local, dev, sit, stg, prd or one of "+runtime.ProfilesFile)
+	_ = rootCmd.RegisterFlagCompletionFunc("profile", completeProfiles)
+	cobra.OnInitialize(loadProfiles)
+}
 
-	// rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.go.yaml)")
+// completeProfiles completes --profile with the registered environments
+func completeProfiles(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	// OnInitialize does not run for completions
+	_ = runtime.LoadProfiles(runtime.ProfilesFile)
+
+	var profiles []string
+	for _, env := range runtime.Environments() {
+		profiles = append(profiles, string(env))
+	}
+	return profiles, cobra.ShellCompDirectiveNoFileComp
+}
 
-	// Cobra also supports local flags, which will only run
-	// when this action is called directly.
-	rootCmd.PersistentFlags().String("profile", "", "Profile for the service to run")
-	rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
+// completeFormats completes an --output flag with formats
+func completeFormats(formats ...string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return formats, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// loadProfiles registers the custom environments of runtime.ProfilesFile before --profile is read
+func loadProfiles() {
+	if err := runtime.LoadProfiles(runtime.ProfilesFile); err != nil {
+		fmt.Println("Error reading profiles file", err.Error())
+		os.Exit(1)
+	}
 }
 
 // To get config at runtime
@@ -64,12 +108,26 @@ func getConfigFunc() core_config.Config {
 }
 
 func setUpLogger(validateProfile runtime.Environment) {
+	logger.DefaultVersion = build.Version
 	logger.InitLogger(validateProfile)
 }
 
 func setUpConfig(profile runtime.Environment) {
 	// Read config from config file
+	if err := resolveConfig(profile); err != nil {
+		fmt.Println("Error reading global config file", err.Error())
+	}
 
+	// fail fast on a broken config instead of misbehaving at runtime
+	if err := config.GetConfig().Validate(); err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+}
+
+// resolveConfig loads the config files of profile: config.yaml, then the overlay of the
+// profile, then config.local.yaml
+func resolveConfig(profile runtime.Environment) error {
 	runtimeCfg := runtime.RuntimeCfg{
 		Microservice: build.ServiceName,
 		Env:          profile,
@@ -77,16 +135,61 @@ func setUpConfig(profile runtime.Environment) {
 
 	ctx := context.Background()
 
-	globalConfigPath, err := core_config.GetGlobalConfigFilePath(runtimeCfg)
+	configPaths, err := core_config.GetConfigFilePaths(runtimeCfg)
 	if err != nil {
-		fmt.Println("Error getting global config file path", err.Error())
-		return
+		return fmt.Errorf("getting config file paths: %w", err)
 	}
 
-	slog.InfoContext(ctx, "Getting config from file", "file", globalConfigPath)
-	err = config.ResolveConfigFromFile(ctx, globalConfigPath)
+	slog.InfoContext(ctx, "Getting config from files", "files", configPaths)
+	return config.ResolveConfigFromFiles(ctx, configPaths...)
+}
+
+// profileFlag returns the environment of --profile
+func profileFlag(cmd *cobra.Command) (runtime.Environment, error) {
+	profile, err := cmd.Flags().GetString("profile")
 	if err != nil {
-		fmt.Println("Error reading global config file", err.Error())
+		return "", err
+	}
+	return runtime.ValidateProfile(profile), nil
+}
+
+// loadValidConfig loads the config of profile for commands that report errors instead of exiting
+func loadValidConfig(profile runtime.Environment) (*config.Config, error) {
+	if err := resolveConfig(profile); err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	cfg := config.GetConfig()
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// setUpTelemetry installs the OTLP meter provider and the tracer provider enabled in
+// telemetry. It is registered first so it closes last, flushing what the other resources
+// recorded.
+func setUpTelemetry() {
+	ctx := context.Background()
+	cfg := config.GetConfig()
+	telemetryConfig := cfg.Telemetry
+	// the service name and version of the build, and the environment unless configured
+	base, err := telemetry.Resource(build.Resource(), map[string]string{"deployment.environment.name": cfg.Env})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to set up telemetry, metrics and traces are not exported", "error", err)
+		return
+	}
+	shutdown, err := telemetry.Setup(ctx, telemetryConfig, base, middleware.BaggageSpanProcessor{})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to set up telemetry, metrics and traces are not exported", "error", err)
+		return
+	}
+	lifecycle.Register("telemetry", shutdown)
+
+	if telemetryConfig.Traces.Enabled {
+		slog.InfoContext(ctx, "Exporting traces", "exporter", telemetryConfig.Traces.Exporter, "endpoint", telemetryConfig.Traces.Endpoint, "sampleRatio", telemetryConfig.Traces.SampleRatio)
+	}
+	if telemetryConfig.Metrics.Enabled {
+		slog.InfoContext(ctx, "Exporting metrics over OTLP", "endpoint", telemetryConfig.Metrics.Endpoint, "interval", telemetryConfig.Metrics.Interval)
 	}
 }
 
@@ -111,6 +214,10 @@ func setUpPostgres() {
 		if err != nil {
 			slog.ErrorContext(ctx, "Failed in pgx.InitPgConnectionPool()", "error", err)
 		}
+		lifecycle.Register("postgres", func(ctx context.Context) error {
+			pgdb.ClosePgPool()
+			return nil
+		})
 		slog.InfoContext(ctx, "pgxPool initialized")
 	}
 }
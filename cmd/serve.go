@@ -6,17 +6,20 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"sync"
 	"time"
 
+	"github.com/yourorg/go-api-template/config"
 	core_config "github.com/yourorg/go-api-template/core/config"
+	"github.com/yourorg/go-api-template/core/jobs"
+	"github.com/yourorg/go-api-template/core/lifecycle"
+	"github.com/yourorg/go-api-template/core/outbox"
 	"github.com/yourorg/go-api-template/internal/server"
 	"github.com/yourorg/go-api-template/utils/runtime"
 	"github.com/spf13/cobra"
 )
 
 func init() {
-	InitServeCommandGroup(rootCmd)
-
 	servePreRunFunc := func(cmd *cobra.Command, _ []string) {
 		profile, err := cmd.Flags().GetString("profile")
 		if err != nil {
@@ -25,6 +28,7 @@ func init() {
 		validatedProfile := runtime.ValidateProfile(profile)
 		setUpLogger(validatedProfile)
 		setUpConfig(validatedProfile)
+		setUpTelemetry()
 		setUpPostgres()
 	}
 
@@ -33,11 +37,42 @@ func init() {
 		servePreRunFunc,
 		getConfigFunc,
 		WithHTTPServer(server.NewHttpServer),
+		WithOutboxPoller(server.NewOutboxPoller),
+		WithJobWorker(server.NewInProcessJobWorker),
+	)
+
+	NewServe(
+		rootCmd,
+		servePreRunFunc,
+		getConfigFunc,
+		WithCommand("serve:all", "Start REST API server, job worker and scheduler",
+			`Run the REST API server, and in the same process the job worker and scheduler selected
+by serveAll, sharing the Postgres and Redis pools and the graceful shutdown. For small
+deployments that do not run the worker command separately.`),
+		WithHTTPServer(server.NewHttpServer),
+		WithOutboxPoller(server.NewOutboxPoller),
+		WithJobWorker(server.NewServeAllJobWorker),
+		WithScheduler(server.NewJobScheduler),
 	)
 }
 
 type ServeOpts struct {
-	initHTTPServer func() (*http.Server, error)
+	use              string
+	short            string
+	long             string
+	initHTTPServer   func() (*http.Server, error)
+	initOutboxPoller func() (*outbox.Poller, error)
+	initJobWorker    func() (*jobs.Worker, error)
+	initScheduler    func() (*jobs.Scheduler, error)
+}
+
+// WithCommand names the serve command, serve:all-api by default
+func WithCommand(use string, short string, long string) ServeOptsFunc {
+	return func(o *ServeOpts) {
+		o.use = use
+		o.short = short
+		o.long = long
+	}
 }
 
 func WithHTTPServer(fn func() (*http.Server, error)) ServeOptsFunc {
@@ -46,58 +81,224 @@ func WithHTTPServer(fn func() (*http.Server, error)) ServeOptsFunc {
 	}
 }
 
+// WithOutboxPoller runs the outbox poller alongside the server.
+// fn may return a nil poller when the outbox is disabled.
+func WithOutboxPoller(fn func() (*outbox.Poller, error)) ServeOptsFunc {
+	return func(o *ServeOpts) {
+		o.initOutboxPoller = fn
+	}
+}
+
+// WithJobWorker runs a job worker alongside the server, for jobs no separate worker process sees.
+// fn may return a nil worker, the worker command then runs the jobs.
+func WithJobWorker(fn func() (*jobs.Worker, error)) ServeOptsFunc {
+	return func(o *ServeOpts) {
+		o.initJobWorker = fn
+	}
+}
+
+// WithScheduler runs a job scheduler alongside the server.
+// fn may return a nil scheduler when nothing is scheduled.
+func WithScheduler(fn func() (*jobs.Scheduler, error)) ServeOptsFunc {
+	return func(o *ServeOpts) {
+		o.initScheduler = fn
+	}
+}
+
 func defaultServeOpts() ServeOpts {
-	return ServeOpts{}
+	return ServeOpts{
+		use:   "serve:all-api",
+		short: "Start REST API server",
+	}
 }
 
 type ServeOptsFunc func(*ServeOpts)
 
 func NewServe(rootCmd *cobra.Command, preRunFunc func(cmd *cobra.Command, _ []string), getConfig func() core_config.Config, serveOpts ...ServeOptsFunc) *cobra.Command {
+	o := defaultServeOpts()
+	for _, f := range serveOpts {
+		f(&o)
+	}
+
 	command := cobra.Command{
-		Use:     "serve:all-api",
-		Short:   "Start REST API server",
-		GroupID: "serve",
+		Use:     o.use,
+		Short:   o.short,
+		Long:    o.long,
+		GroupID: groupServe,
 		PreRun:  preRunFunc,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			o := defaultServeOpts()
-			for _, f := range serveOpts {
-				f(&o)
-			}
 			ctx := cmd.Context()
 			cfg := getConfig()
 			restPort := cfg.RestServer.Port
 			localIP, _ := getLocalIP()
 
+			// sections with config.OnChange subscribers apply without a restart
+			if cfg.Reload.WatchFile || cfg.Reload.SIGHUP {
+				config.Watch(ctx, cfg.Reload)
+			}
+			// rotated secrets referenced by the config are picked up like a reload
+			config.RenewSecrets(ctx, cfg.Secrets.RenewInterval)
+			serveDiagnostics(ctx)
+
+			var restServer *http.Server
 			if o.initHTTPServer != nil {
-				restServer, err := o.initHTTPServer()
+				var err error
+				restServer, err = o.initHTTPServer()
 				if err != nil {
 					return fmt.Errorf("failed to create REST server: %w", err)
 				}
 				go func() {
+					scheme := "http"
+					if restServer.TLSConfig != nil {
+						scheme = "https"
+					}
 					slog.InfoContext(ctx, fmt.Sprintf("[REST] Starting server on port %s", restPort))
-					slog.InfoContext(ctx, fmt.Sprintf("[REST] Local: http://localhost:%s", restPort))
-					slog.InfoContext(ctx, fmt.Sprintf("[REST] Network: http://%s:%s", localIP, restPort))
+					slog.InfoContext(ctx, fmt.Sprintf("[REST] Local: %s://localhost:%s", scheme, restPort))
+					slog.InfoContext(ctx, fmt.Sprintf("[REST] Network: %s://%s:%s", scheme, localIP, restPort))
 					slog.InfoContext(ctx, "[REST] waiting for requests...")
-					if err := restServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					if err := listenAndServe(restServer); err != nil && !errors.Is(err, http.ErrServerClosed) {
 						slog.ErrorContext(ctx, fmt.Sprintf("[REST] failed to serve: %s\n", err))
 					}
 
 				}()
+			}
 
-				go func() {
-					<-ctx.Done()
-					gracefulShutdownCtx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
-					defer cancel()
-					restServer.Shutdown(gracefulShutdownCtx)
-				}()
+			// background workers stop with ctx; resources they use are closed after they return
+			var workers sync.WaitGroup
+			if o.initOutboxPoller != nil {
+				poller, err := o.initOutboxPoller()
+				if err != nil {
+					return fmt.Errorf("failed to create outbox poller: %w", err)
+				}
+				if poller != nil {
+					workers.Add(1)
+					go func() {
+						defer workers.Done()
+						if err := poller.Run(ctx); err != nil {
+							slog.ErrorContext(ctx, fmt.Sprintf("[OUTBOX] poller stopped: %s", err))
+						}
+					}()
+				}
+			}
 
+			if o.initJobWorker != nil {
+				worker, err := o.initJobWorker()
+				if err != nil {
+					return fmt.Errorf("failed to create job worker: %w", err)
+				}
+				if worker != nil {
+					workers.Add(1)
+					go func() {
+						defer workers.Done()
+						if err := worker.Run(ctx); err != nil {
+							slog.ErrorContext(ctx, fmt.Sprintf("[JOBS] worker stopped: %s", err))
+						}
+					}()
+				}
+			}
+
+			if o.initScheduler != nil {
+				scheduler, err := o.initScheduler()
+				if err != nil {
+					return fmt.Errorf("failed to create job scheduler: %w", err)
+				}
+				if scheduler != nil {
+					workers.Add(1)
+					go func() {
+						defer workers.Done()
+						if err := scheduler.Run(ctx); err != nil {
+							slog.ErrorContext(ctx, fmt.Sprintf("[SCHEDULER] scheduler stopped: %s", err))
+						}
+					}()
+				}
 			}
 
 			<-ctx.Done()
-			return nil
+			return gracefulShutdown(restServer, cfg.RestServer.Shutdown, &workers)
 		},
 	}
 
 	rootCmd.AddCommand(&command)
 	return &command
 }
+
+// listenAndServe serves HTTPS when the server has a TLS config, its certificates come from there
+func listenAndServe(server *http.Server) error {
+	if server.TLSConfig != nil {
+		return server.ListenAndServeTLS("", "")
+	}
+	return server.ListenAndServe()
+}
+
+// serveDiagnostics starts the separate pprof and expvar listener when configured. It is closed
+// with the lifecycle resources, so profiles can still be captured while the server drains.
+func serveDiagnostics(ctx context.Context) {
+	diagServer := server.NewDiagnosticsServer()
+	if diagServer == nil {
+		return
+	}
+	lifecycle.Register("diagnostics", diagServer.Shutdown)
+	go func() {
+		slog.InfoContext(ctx, fmt.Sprintf("[DIAGNOSTICS] Serving pprof and expvar on %s", diagServer.Addr))
+		if err := diagServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.ErrorContext(ctx, fmt.Sprintf("[DIAGNOSTICS] failed to serve: %s", err))
+		}
+	}()
+}
+
+// resourceCloseTimeout bounds closing the lifecycle resources after the server stopped
+const resourceCloseTimeout = 10 * time.Second
+
+// gracefulShutdown fails readiness and keeps serving for the drain delay, so load balancers
+// stop routing here, then stops accepting and waits for in-flight requests and background
+// workers before closing the resources registered with the lifecycle registry
+func gracefulShutdown(restServer *http.Server, cfg core_config.ShutdownConfig, workers *sync.WaitGroup) error {
+	ctx := context.Background()
+	registry := lifecycle.Default()
+	registry.BeginShutdown()
+
+	var errs []error
+	if restServer != nil {
+		// clients reconnect after their current request, reaching another instance
+		restServer.SetKeepAlivesEnabled(false)
+		if cfg.DrainDelay > 0 {
+			slog.InfoContext(ctx, "[REST] draining before shutdown", "delay", cfg.DrainDelay)
+			time.Sleep(cfg.DrainDelay)
+		}
+	}
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = time.Minute
+	}
+	shutdownCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if restServer != nil {
+		slog.InfoContext(ctx, "[REST] shutting down, waiting for in-flight requests", "timeout", timeout)
+		if err := restServer.Shutdown(shutdownCtx); err != nil {
+			slog.ErrorContext(ctx, "[REST] requests still in flight at shutdown timeout", "error", err.Error())
+			errs = append(errs, fmt.Errorf("REST server shutdown: %w", err))
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-shutdownCtx.Done():
+		slog.WarnContext(ctx, "Background workers did not stop before the shutdown timeout")
+	}
+
+	closeCtx, cancelClose := context.WithTimeout(ctx, resourceCloseTimeout)
+	defer cancelClose()
+	if err := registry.Close(closeCtx); err != nil {
+		errs = append(errs, err)
+	}
+
+	slog.InfoContext(ctx, "Shutdown complete")
+	return errors.Join(errs...)
+}
@@ -3,8 +3,6 @@ package cmd
 import (
 	"fmt"
 	"net"
-
-	"github.com/spf13/cobra"
 )
 
 type BuildInfo struct {
@@ -13,10 +11,6 @@ type BuildInfo struct {
 	Version     string
 }
 
-func InitServeCommandGroup(rootCmd *cobra.Command) {
-	rootCmd.AddGroup(&cobra.Group{ID: "serve", Title: "Serve:"})
-}
-
 func getLocalIP() (string, error) {
 	addrs, err := net.InterfaceAddrs()
 	if err != nil {
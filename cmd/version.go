@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/yourorg/go-api-template/internal/build"
+)
+
+var versionFormat string
+
+var versionCmd = &cobra.Command{
+	Use:     "version",
+	GroupID: groupAdmin,
+	Short:   "Print the version and build metadata",
+	Long: `Print the version, git commit, build date and Go version of the binary, injected with
+ldflags by make build and the Dockerfile. The same values are reported by the health
+endpoints, as dd.version in logs and in the OpenTelemetry resource (build.Resource).`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		info := build.Get()
+		out := cmd.OutOrStdout()
+		switch versionFormat {
+		case "text":
+			fmt.Fprintf(out, "%s %s\n", info.Service, info.Version)
+			fmt.Fprintf(out, "  commit:     %s\n", info.Commit)
+			fmt.Fprintf(out, "  built:      %s\n", info.Time)
+			fmt.Fprintf(out, "  go version: %s\n", info.GoVersion)
+			fmt.Fprintf(out, "  os/arch:    %s\n", info.OSArch)
+			return nil
+		case "json":
+			enc := json.NewEncoder(out)
+			enc.SetIndent("", "  ")
+			return enc.Encode(info)
+		default:
+			return fmt.Errorf("unknown output format %q, use text or json", versionFormat)
+		}
+	},
+}
+
+func init() {
+	versionCmd.Flags().StringVarP(&versionFormat, "output", "o", "text", "Output format: text or json")
+	_ = versionCmd.RegisterFlagCompletionFunc("output", completeFormats("text", "json"))
+	rootCmd.AddCommand(versionCmd)
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/spf13/cobra"
+	"github.com/yourorg/go-api-template/core/cache"
+	"github.com/yourorg/go-api-template/core/jobs"
+	"github.com/yourorg/go-api-template/core/lifecycle"
+	"github.com/yourorg/go-api-template/internal/server"
+	"github.com/yourorg/go-api-template/utils/runtime"
+)
+
+var workerCmd = &cobra.Command{
+	Use:     "worker",
+	Short:   "Run background jobs",
+	GroupID: groupServe,
+	Long: `Run the handlers of the jobs enqueued by the API, each queue of jobs.queues with its
+concurrency, until SIGINT or SIGTERM; jobs in progress then finish within
+restServer.shutdown.timeout. Needs jobs.enabled and the redis store, memory store jobs run
+inside serve:all-api. serve:all runs the worker in the API process instead.`,
+	PreRun: func(cmd *cobra.Command, _ []string) {
+		profile, err := cmd.Flags().GetString("profile")
+		if err != nil {
+			slog.Error("Error getting profile flag", slog.Any("Error", err))
+		}
+		validatedProfile := runtime.ValidateProfile(profile)
+		setUpLogger(validatedProfile)
+		setUpConfig(validatedProfile)
+		setUpTelemetry()
+		setUpPostgres()
+	},
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		ctx := cmd.Context()
+		cfg := getConfigFunc()
+		switch {
+		case !cfg.Jobs.Enabled:
+			return errors.New("jobs are disabled, set jobs.enabled")
+		case cfg.Jobs.Store == jobs.StoreMemory:
+			return errors.New("memory store jobs run inside serve:all-api, set jobs.store to redis for a worker process")
+		}
+
+		// the worker has no REST port, only a separate diagnostics.address serves the profiles
+		serveDiagnostics(ctx)
+
+		worker, err := server.NewJobWorker()
+		if err != nil {
+			return fmt.Errorf("failed to create job worker: %w", err)
+		}
+		if cacheService := cache.GetRedisService(); cacheService != nil {
+			lifecycle.Register("redis", func(ctx context.Context) error {
+				return cacheService.Close()
+			})
+		}
+
+		var workers sync.WaitGroup
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			if err := worker.Run(ctx); err != nil {
+				slog.ErrorContext(ctx, fmt.Sprintf("[JOBS] worker stopped: %s", err))
+			}
+		}()
+
+		<-ctx.Done()
+		return gracefulShutdown(nil, cfg.RestServer.Shutdown, &workers)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(workerCmd)
+}
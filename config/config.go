@@ -1,17 +1,35 @@
 package config
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"io/fs"
 	"log/slog"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 
 	"dario.cat/mergo"
 	core_config "github.com/yourorg/go-api-template/core/config"
+	"github.com/yourorg/go-api-template/core/secrets"
 	"github.com/spf13/viper"
 )
 
-var finalConfig *Config
-var cfgFromFile *Config
+// EnvPrefix prefixes the environment variables overriding config keys: the key path in upper case
+// joined by underscores, e.g. APP_AUTH_JWTSECRETKEY for auth.jwtSecretKey or
+// APP_POSTGRES_WRITE_PASSWORD for postgres.write.password. Lists are comma separated.
+const EnvPrefix = "APP"
+
+var finalConfig atomic.Pointer[Config]
+
+// resolvedPaths are the files read by ResolveConfigFromFiles, read again by Reload
+var resolvedPaths []string
 
 var m sync.Mutex
 
@@ -19,44 +37,231 @@ type Config struct {
 	core_config.Config `mapstructure:",squash"`
 }
 
+// NewConfig sets the config, the keys it leaves zero taking their value from core_config.Defaults
 func NewConfig(cfg Config) {
-	finalConfig = &cfg
+	defaults := Config{Config: core_config.Defaults()}
+	_ = mergo.Merge(&cfg, defaults)
+	finalConfig.Store(&cfg)
 }
 
 func ResolveConfigFromFile(ctx context.Context, configPath string) error {
+	return ResolveConfigFromFiles(ctx, configPath)
+}
+
+// ResolveConfigFromFiles merges the files in order, a later file overriding the keys it sets
+// and keeping the others, so overlays only hold what differs. Missing files are skipped but
+// at least one must exist.
+func ResolveConfigFromFiles(ctx context.Context, configPaths ...string) error {
 	m.Lock()
 	defer m.Unlock()
 
-	if finalConfig == nil {
-		finalConfig = &Config{}
+	finalConfig.CompareAndSwap(nil, &Config{})
+	cfgFromFile, err := loadConfigFiles(ctx, configPaths)
+	if err != nil {
+		return err
+	}
+
+	next := &Config{}
+	if current := finalConfig.Load(); current != nil {
+		*next = *current
+	}
+	err = mergo.Merge(next, cfgFromFile, mergo.WithOverride)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error merging config from secrets", "error", err)
+		return err
 	}
 
-	viper.SetConfigName(configPath)
-	viper.SetConfigFile(configPath)
-	viper.SetConfigType("yaml")
+	finalConfig.Store(next)
+	resolvedPaths = configPaths
+	return nil
+}
+
+// loadConfigFiles merges configPaths with their ${ENV_VAR} placeholders expanded, then applies
+// the EnvPrefix overrides
+func loadConfigFiles(ctx context.Context, configPaths []string) (*Config, error) {
+	v := viper.New()
+	v.SetEnvPrefix(EnvPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+	// AutomaticEnv only covers keys viper knows of, bind the keys missing from the file too
+	bindEnvKeys(v, reflect.TypeOf(Config{}), "")
+	// keys set in no file and no variable take their value from core_config.Defaults
+	setDefaults(v, reflect.ValueOf(core_config.Defaults()), "")
+
+	merged := map[string]interface{}{}
+	var loaded []string
+	for _, path := range configPaths {
+		if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) && len(configPaths) > 1 {
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err == nil && secrets.IsSOPS(data) {
+			// sops encrypted files are decrypted in memory only
+			data, err = secrets.DecryptSOPS(ctx, data, nil)
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "Error getting config file", "file", path, "error", err)
+			return nil, err
+		}
 
-	err := viper.ReadInConfig()
+		file := viper.New()
+		file.SetConfigType("yaml")
+		err = file.ReadConfig(bytes.NewReader(data))
+		if err != nil {
+			slog.ErrorContext(ctx, "Error getting config file", "file", path, "error", err)
+			return nil, err
+		}
+
+		// ${ENV_VAR} placeholders keep secrets out of the file
+		settings, err := expandEnv(file.AllSettings())
+		if err != nil {
+			slog.ErrorContext(ctx, "Error expanding config file", "file", path, "error", err)
+			return nil, err
+		}
+		// maps are merged key by key, lists and scalars replaced, false and "" included
+		err = mergo.Merge(&merged, settings.(map[string]interface{}), mergo.WithOverride)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error merging config file", "file", path, "error", err)
+			return nil, err
+		}
+		loaded = append(loaded, path)
+	}
+	if len(loaded) == 0 {
+		return nil, fmt.Errorf("none of the config files exist: %s", strings.Join(configPaths, ", "))
+	}
+
+	err := v.MergeConfigMap(merged)
 	if err != nil {
 		slog.ErrorContext(ctx, "Error getting config file", "error", err)
-		return err
+		return nil, err
 	}
 
-	err = viper.Unmarshal(&cfgFromFile)
+	cfgFromFile := &Config{}
+	err = v.Unmarshal(cfgFromFile)
 	if err != nil {
 		slog.ErrorContext(ctx, "Error unmarshalling config file", "error", err)
-		return err
+		return nil, err
 	}
 
-	err = mergo.Merge(finalConfig, cfgFromFile, mergo.WithOverride)
+	// vault:, awssm:, gcpsm: and enc: references keep secrets out of the file and the environment
+	err = secretResolver(cfgFromFile.Secrets).ResolveStruct(ctx, cfgFromFile)
 	if err != nil {
-		slog.ErrorContext(ctx, "Error merging config from secrets", "error", err)
-		return err
+		slog.ErrorContext(ctx, "Error resolving config secrets", "error", err)
+		return nil, err
 	}
 
-	// ...
-	return nil
+	return cfgFromFile, nil
+}
+
+// envPlaceholder matches ${NAME} and ${NAME:-default}
+var envPlaceholder = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// expandEnv replaces the ${NAME} placeholders of the string values in settings with the
+// environment variable NAME, or the default of ${NAME:-default} when it is unset or empty.
+// An unset variable without a default is an error rather than an empty secret.
+func expandEnv(settings interface{}) (interface{}, error) {
+	var missing []string
+	var expand func(value interface{}) interface{}
+	expand = func(value interface{}) interface{} {
+		switch val := value.(type) {
+		case map[string]interface{}:
+			for k, item := range val {
+				val[k] = expand(item)
+			}
+		case []interface{}:
+			for i, item := range val {
+				val[i] = expand(item)
+			}
+		case string:
+			return envPlaceholder.ReplaceAllStringFunc(val, func(placeholder string) string {
+				match := envPlaceholder.FindStringSubmatch(placeholder)
+				if env := os.Getenv(match[1]); env != "" {
+					return env
+				}
+				if match[2] != "" {
+					return match[3]
+				}
+				missing = append(missing, match[1])
+				return ""
+			})
+		}
+		return value
+	}
+
+	expanded := expand(settings)
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("environment variables referenced by the config are not set: %s", strings.Join(missing, ", "))
+	}
+	return expanded, nil
+}
+
+// bindEnvKeys binds every mapstructure key of t, so EnvPrefix variables apply to keys absent from the file
+func bindEnvKeys(v *viper.Viper, t reflect.Type, prefix string) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if opts == "squash" || (name == "" && field.Anonymous) {
+			bindEnvKeys(v, fieldType, prefix)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		key := prefix + name
+		if fieldType.Kind() == reflect.Struct && fieldType.PkgPath() != "time" {
+			bindEnvKeys(v, fieldType, key+".")
+			continue
+		}
+		_ = v.BindEnv(key)
+	}
+}
+
+// setDefaults registers the non-zero values of val as viper defaults under their mapstructure keys
+func setDefaults(v *viper.Viper, val reflect.Value, prefix string) {
+	t := val.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+
+		fieldVal := val.Field(i)
+		if opts == "squash" || (name == "" && field.Anonymous) {
+			if fieldVal.Kind() == reflect.Struct {
+				setDefaults(v, fieldVal, prefix)
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		key := prefix + name
+		switch {
+		case fieldVal.IsZero():
+		case fieldVal.Kind() == reflect.Struct && fieldVal.Type().PkgPath() != "time":
+			setDefaults(v, fieldVal, key+".")
+		default:
+			v.SetDefault(key, fieldVal.Interface())
+		}
+	}
 }
 
 func GetConfig() *Config {
-	return finalConfig
+	return finalConfig.Load()
 }
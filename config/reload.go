@@ -0,0 +1,160 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+	core_config "github.com/yourorg/go-api-template/core/config"
+)
+
+// ChangeFunc is called with the previous and the reloaded config
+type ChangeFunc func(old, new *Config)
+
+type subscription struct {
+	section string
+	fn      ChangeFunc
+}
+
+var (
+	subscriptionsMu sync.Mutex
+	subscriptions   = map[*subscription]struct{}{}
+)
+
+// OnChange calls fn after a reload changed section, a top-level key such as "rateLimit" or
+// "logging"; an empty section subscribes to every change. Only subscribed settings take effect
+// without a restart, the others are picked up by GetConfig but not by components built at startup.
+// The returned func removes the subscription.
+func OnChange(section string, fn ChangeFunc) func() {
+	sub := &subscription{section: section, fn: fn}
+	subscriptionsMu.Lock()
+	subscriptions[sub] = struct{}{}
+	subscriptionsMu.Unlock()
+
+	return func() {
+		subscriptionsMu.Lock()
+		delete(subscriptions, sub)
+		subscriptionsMu.Unlock()
+	}
+}
+
+// Reload reads the files given to ResolveConfigFromFiles again. A config failing Validate is
+// rejected and the current one kept. Subscribers of the changed sections are called in turn,
+// they must not call Reload.
+func Reload(ctx context.Context) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if len(resolvedPaths) == 0 {
+		return errors.New("config: Reload called before ResolveConfigFromFiles")
+	}
+	next, err := loadConfigFiles(ctx, resolvedPaths)
+	if err != nil {
+		return err
+	}
+	if err := next.Validate(); err != nil {
+		return err
+	}
+
+	old := finalConfig.Swap(next)
+	changed := changedSections(old, next)
+	if len(changed) == 0 {
+		return nil
+	}
+	slog.InfoContext(ctx, "Config reloaded", "sections", changed)
+
+	subscriptionsMu.Lock()
+	var notify []ChangeFunc
+	for sub := range subscriptions {
+		if sub.section == "" || contains(changed, sub.section) {
+			notify = append(notify, sub.fn)
+		}
+	}
+	subscriptionsMu.Unlock()
+
+	for _, fn := range notify {
+		fn(old, next)
+	}
+	return nil
+}
+
+// changedSections returns the top-level keys whose values differ between old and new
+func changedSections(old, new *Config) []string {
+	if old == nil {
+		old = &Config{}
+	}
+	oldVal := reflect.ValueOf(old.Config)
+	newVal := reflect.ValueOf(new.Config)
+
+	var changed []string
+	for i := 0; i < oldVal.NumField(); i++ {
+		name, _, _ := strings.Cut(oldVal.Type().Field(i).Tag.Get("mapstructure"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		if !reflect.DeepEqual(oldVal.Field(i).Interface(), newVal.Field(i).Interface()) {
+			changed = append(changed, name)
+		}
+	}
+	return changed
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Watch reloads the config when one of its files is written or created (WatchFile) or the process receives
+// SIGHUP (SIGHUP), until ctx is done. Failed reloads are logged and keep the current config.
+func Watch(ctx context.Context, opts core_config.ReloadConfig) {
+	reload := func(trigger string) {
+		if err := Reload(ctx); err != nil {
+			slog.ErrorContext(ctx, "Config reload failed, keeping the current config", "trigger", trigger, "error", err.Error())
+		}
+	}
+
+	if opts.WatchFile {
+		m.Lock()
+		paths := resolvedPaths
+		m.Unlock()
+
+		for _, path := range paths {
+			watcher := viper.New()
+			watcher.SetConfigFile(path)
+			watcher.OnConfigChange(func(e fsnotify.Event) {
+				if ctx.Err() == nil {
+					reload("file")
+				}
+			})
+			watcher.WatchConfig()
+		}
+	}
+
+	if opts.SIGHUP {
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		go func() {
+			defer signal.Stop(hup)
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-hup:
+					reload("SIGHUP")
+				}
+			}
+		}()
+	}
+}
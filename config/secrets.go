@@ -0,0 +1,68 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/yourorg/go-api-template/core/secrets"
+)
+
+var (
+	secretsMu      sync.Mutex
+	secretsConfig  secrets.Config
+	secretsCurrent *secrets.Resolver
+)
+
+// secretResolver returns the resolver for cfg, kept across reloads so its cache is reused
+// until the secrets section itself changes
+func secretResolver(cfg secrets.Config) *secrets.Resolver {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+
+	if secretsCurrent == nil || !reflect.DeepEqual(cfg, secretsConfig) {
+		secretsCurrent = secrets.NewResolver(cfg, nil)
+		secretsConfig = cfg
+	}
+	return secretsCurrent
+}
+
+// RenewSecrets fetches the referenced secrets every interval until ctx is done, reloading the
+// config when one of them was rotated. Failed fetches are logged and keep the cached values.
+func RenewSecrets(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			secretsMu.Lock()
+			resolver := secretsCurrent
+			secretsMu.Unlock()
+			if resolver == nil {
+				continue
+			}
+
+			changed, err := resolver.Renew(ctx)
+			if err != nil {
+				slog.ErrorContext(ctx, "Secret renewal failed, keeping the cached values", "error", err.Error())
+			}
+			if !changed {
+				continue
+			}
+			if err := Reload(ctx); err != nil {
+				slog.ErrorContext(ctx, "Config reload after secret rotation failed, keeping the current config", "error", err.Error())
+			}
+		}
+	}()
+}
@@ -0,0 +1,104 @@
+// Package audit records who changed which entity through the API: the middleware of a route
+// stores the actor, route, entity, hashes of the value before and after and the outcome of
+// every POST, PUT, PATCH and DELETE, and the store answers the trail of an entity.
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+const (
+	StoreMemory   = "memory"
+	StorePostgres = "postgres"
+
+	// OutcomeSuccess is the outcome of changes answered below 400
+	OutcomeSuccess = "success"
+	// OutcomeFailure is the outcome of changes answered with an error
+	OutcomeFailure = "failure"
+
+	// AnonymousActor made the changes of requests without an authenticated user or client
+	AnonymousActor = "anonymous"
+)
+
+// Config selects the audit store and who may read the trails
+type Config struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Store is memory, lost on restart, or postgres, the audit_records table
+	Store string `mapstructure:"store" enum:"memory postgres"`
+	// Role of the JWTs allowed to query trails
+	Role string `mapstructure:"role"`
+}
+
+// DefaultConfig returns default audit configuration, disabled
+func DefaultConfig() Config {
+	return Config{
+		Store: StoreMemory,
+		Role:  "admin",
+	}
+}
+
+// Record is a data-changing request
+type Record struct {
+	ID        int64     `json:"id"`
+	Time      time.Time `json:"time"`
+	Actor     string    `json:"actor"`
+	RequestID string    `json:"request_id,omitempty"`
+	Method    string    `json:"method"`
+	// Route is the pattern that matched, e.g. /api/v1/examples/{id}
+	Route    string `json:"route"`
+	Entity   string `json:"entity"`
+	EntityID string `json:"entity_id,omitempty"`
+	// OldHash and NewHash are the SHA-256 of the value before and after the change, empty
+	// when unknown, so a trail shows changes without keeping the values
+	OldHash string `json:"old_hash,omitempty"`
+	NewHash string `json:"new_hash,omitempty"`
+	Status  int    `json:"status"`
+	Outcome string `json:"outcome"`
+}
+
+// Store keeps the records
+type Store interface {
+	// Append stores record, setting its ID
+	Append(ctx context.Context, record *Record) error
+	// List returns the last limit records of the entity, newest first
+	List(ctx context.Context, entity string, entityID string, limit int) ([]Record, error)
+}
+
+// Hint tells the middleware of a route which entity it changes
+type Hint struct {
+	// Entity is the type of the entity, e.g. "example"
+	Entity string
+	// IDParam is the path wildcard holding the entity ID, e.g. "id" of /examples/{id}
+	IDParam string
+	// IDField is the dotted JSON field of the response holding the ID of a created entity,
+	// e.g. "data.id"; IDParam wins when both are set
+	IDField string
+	// Load returns the current value of the entity, hashed before and after the change.
+	// Without it, only the new value is hashed, from the request body.
+	Load func(ctx context.Context, id string) (any, error)
+}
+
+// Hash returns the hex SHA-256 of the JSON encoding of value, empty for nil
+func Hash(value any) string {
+	if value == nil {
+		return ""
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return HashBytes(data)
+}
+
+// HashBytes returns the hex SHA-256 of data, empty for no data
+func HashBytes(data []byte) string {
+	if len(data) == 0 {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
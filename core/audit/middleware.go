@@ -0,0 +1,198 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	middleware "github.com/yourorg/go-api-template/core/transport/httpserver/middlewares"
+)
+
+// maxBodySize bounds the request and response bodies read for hashes and created IDs
+const maxBodySize = 1 << 20
+
+// RecorderOption customizes a recorder
+type RecorderOption func(*Recorder)
+
+// WithClock sets the clock records are timed with
+func WithClock(now func() time.Time) RecorderOption {
+	return func(r *Recorder) {
+		r.now = now
+	}
+}
+
+// Recorder stores the records of the routes wrapped by its middleware
+type Recorder struct {
+	store Store
+	now   func() time.Time
+}
+
+// NewRecorder returns a recorder appending to store
+func NewRecorder(store Store, opts ...RecorderOption) *Recorder {
+	r := &Recorder{store: store, now: time.Now}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Middleware records the POST, PUT, PATCH and DELETE requests of a route as changes of the
+// entity of hint; other methods pass through. Wrap the route after authentication, so the
+// user is known:
+//
+//	v1.Put("/examples/{id}", recorder.Middleware(audit.Hint{Entity: "example", IDParam: "id"})(handler))
+//
+// A record that cannot be stored is logged, the response is not failed.
+func (rec *Recorder) Middleware(hint Hint) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+			default:
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx := r.Context()
+			record := Record{
+				Time:   rec.now(),
+				Actor:  actorFromContext(ctx),
+				Method: r.Method,
+				Route:  r.Pattern,
+				Entity: hint.Entity,
+			}
+			if route, ok := middleware.GetRouteFromContext(ctx); ok {
+				record.Route = route
+			}
+			record.RequestID, _ = middleware.GetRequestIDFromContext(ctx)
+			if hint.IDParam != "" {
+				record.EntityID = r.PathValue(hint.IDParam)
+			}
+
+			if hint.Load != nil && record.EntityID != "" {
+				record.OldHash = rec.load(ctx, hint, record.EntityID)
+			}
+			var body []byte
+			if hint.Load == nil && r.Body != nil && r.Method != http.MethodDelete {
+				// hashed as the new value, then handed on to the handler
+				body, _ = io.ReadAll(io.LimitReader(r.Body, maxBodySize))
+				r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+			}
+
+			rw := &responseWriter{ResponseWriter: w, status: http.StatusOK, capture: record.EntityID == "" && hint.IDField != ""}
+			next.ServeHTTP(rw, r)
+
+			record.Status = rw.status
+			record.Outcome = OutcomeSuccess
+			if rw.status >= http.StatusBadRequest {
+				record.Outcome = OutcomeFailure
+			}
+			if record.EntityID == "" && hint.IDField != "" {
+				record.EntityID = jsonField(rw.body.Bytes(), hint.IDField)
+			}
+			if record.Outcome == OutcomeSuccess {
+				switch {
+				case r.Method == http.MethodDelete:
+				case hint.Load != nil && record.EntityID != "":
+					record.NewHash = rec.load(ctx, hint, record.EntityID)
+				case hint.Load == nil:
+					record.NewHash = HashBytes(body)
+				}
+			}
+
+			// recorded even when the client went away
+			if err := rec.store.Append(context.WithoutCancel(ctx), &record); err != nil {
+				slog.ErrorContext(ctx, "Failed to record audit record", "entity", record.Entity, "entity_id", record.EntityID, "error", err.Error())
+			}
+		})
+	}
+}
+
+// load hashes the current value of the entity, empty when it cannot be loaded
+func (rec *Recorder) load(ctx context.Context, hint Hint, id string) string {
+	value, err := hint.Load(ctx, id)
+	if err != nil {
+		return ""
+	}
+	return Hash(value)
+}
+
+// actorFromContext returns the authenticated user, the mTLS client or AnonymousActor
+func actorFromContext(ctx context.Context) string {
+	if userID, ok := middleware.GetUserIDFromContext(ctx); ok && userID != "" {
+		return userID
+	}
+	if identity, ok := middleware.GetClientIdentityFromContext(ctx); ok && identity.CommonName != "" {
+		return "client:" + identity.CommonName
+	}
+	return AnonymousActor
+}
+
+// jsonField returns the dotted field of a JSON object as a string, e.g. "data.id"
+func jsonField(data []byte, path string) string {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return ""
+	}
+	for _, key := range strings.Split(path, ".") {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return ""
+		}
+		value = object[key]
+	}
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	default:
+		return ""
+	}
+}
+
+// responseWriter keeps the status and, with capture, the body of the response
+type responseWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	capture     bool
+	body        bytes.Buffer
+}
+
+func (rw *responseWriter) WriteHeader(statusCode int) {
+	if !rw.wroteHeader {
+		rw.status, rw.wroteHeader = statusCode, true
+	}
+	rw.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	rw.wroteHeader = true
+	if rw.capture && rw.body.Len() < maxBodySize {
+		rw.body.Write(b[:min(len(b), maxBodySize-rw.body.Len())])
+	}
+	return rw.ResponseWriter.Write(b)
+}
+
+// Hijack passes the connection through, e.g. for WebSocket upgrades
+func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if !rw.wroteHeader {
+		rw.status, rw.wroteHeader = http.StatusSwitchingProtocols, true
+	}
+	return http.NewResponseController(rw.ResponseWriter).Hijack()
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
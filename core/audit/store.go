@@ -0,0 +1,95 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/yourorg/go-api-template/core/pgdb"
+)
+
+// MemoryStore keeps records in process; they are lost on restart and not shared between instances
+type MemoryStore struct {
+	mu      sync.Mutex
+	records []Record
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+func (s *MemoryStore) Append(ctx context.Context, record *Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record.ID = int64(len(s.records)) + 1
+	s.records = append(s.records, *record)
+	return nil
+}
+
+func (s *MemoryStore) List(ctx context.Context, entity string, entityID string, limit int) ([]Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var records []Record
+	for i := len(s.records) - 1; i >= 0 && (limit <= 0 || len(records) < limit); i-- {
+		if s.records[i].Entity == entity && s.records[i].EntityID == entityID {
+			records = append(records, s.records[i])
+		}
+	}
+	return records, nil
+}
+
+const TableName = "audit_records"
+
+// PostgresStore keeps records in the audit_records table, see migrations
+type PostgresStore struct {
+	db pgdb.DBTX
+}
+
+// NewPostgresStore creates a store on db, usually the write pool
+func NewPostgresStore(db pgdb.DBTX) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+func (s *PostgresStore) Append(ctx context.Context, record *Record) error {
+	err := s.db.QueryRow(ctx, `
+		INSERT INTO `+TableName+` (recorded_at, actor, request_id, method, route, entity, entity_id, old_hash, new_hash, status, outcome)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id`,
+		record.Time, record.Actor, record.RequestID, record.Method, record.Route, record.Entity, record.EntityID,
+		record.OldHash, record.NewHash, record.Status, record.Outcome).Scan(&record.ID)
+	if err != nil {
+		return fmt.Errorf("error recording audit record: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) List(ctx context.Context, entity string, entityID string, limit int) ([]Record, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT id, recorded_at, actor, request_id, method, route, entity, entity_id, old_hash, new_hash, status, outcome
+		FROM `+TableName+`
+		WHERE entity = $1 AND entity_id = $2
+		ORDER BY id DESC
+		LIMIT $3`,
+		entity, entityID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error reading audit records: %w", err)
+	}
+	defer rows.Close()
+
+	var records []Record
+	for rows.Next() {
+		var r Record
+		if err := rows.Scan(&r.ID, &r.Time, &r.Actor, &r.RequestID, &r.Method, &r.Route, &r.Entity, &r.EntityID,
+			&r.OldHash, &r.NewHash, &r.Status, &r.Outcome); err != nil {
+			return nil, fmt.Errorf("error reading audit records: %w", err)
+		}
+		records = append(records, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading audit records: %w", err)
+	}
+	return records, nil
+}
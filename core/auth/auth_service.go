@@ -68,6 +68,11 @@ func (s *AuthService) GenerateTokens(userID, email string, roles []string) (*Tok
 
 // generateAccessToken creates a JWT access token
 func (s *AuthService) generateAccessToken(userID, email string, roles []string) (string, error) {
+	return s.GenerateAccessToken(userID, email, roles, s.tokenExpiration)
+}
+
+// GenerateAccessToken creates a JWT access token valid for ttl, e.g. to call the API in tests
+func (s *AuthService) GenerateAccessToken(userID, email string, roles []string, ttl time.Duration) (string, error) {
 	now := time.Now()
 	claims := &middleware.UserClaims{
 		UserID: userID,
@@ -75,7 +80,7 @@ func (s *AuthService) generateAccessToken(userID, email string, roles []string)
 		Roles:  roles,
 		RegisteredClaims: jwt.RegisteredClaims{
 			IssuedAt:  jwt.NewNumericDate(now),
-			ExpiresAt: jwt.NewNumericDate(now.Add(s.tokenExpiration)),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
 			NotBefore: jwt.NewNumericDate(now),
 			Issuer:    "go-api-template",
 			Subject:   userID,
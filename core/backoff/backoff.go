@@ -0,0 +1,25 @@
+// Package backoff computes the delays between the attempts of retried operations, such as
+// jobs, event deliveries, outbox messages and outbound HTTP requests
+package backoff
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// Exponential returns the delay after the attempt-th attempt, counted from 1: base doubled for
+// each attempt after the first, capped at ceiling, with full jitter, i.e. a random delay up to the
+// capped one so retries of many callers spread out. It is 0 when the capped delay is not positive.
+func Exponential(base, ceiling time.Duration, attempt int) time.Duration {
+	delay := base
+	for i := 1; i < attempt && delay < ceiling; i++ {
+		delay *= 2
+	}
+	if delay > ceiling {
+		delay = ceiling
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int64N(int64(delay)) + 1)
+}
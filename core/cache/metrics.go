@@ -0,0 +1,40 @@
+package cache
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const meterName = "github.com/yourorg/go-api-template/core/cache"
+
+// Results of a cache lookup
+const (
+	lookupHit   = "hit"
+	lookupMiss  = "miss"
+	lookupError = "error"
+)
+
+var (
+	lookupCounter     metric.Int64Counter
+	lookupCounterOnce sync.Once
+)
+
+// recordLookup counts a Get by result, hit, miss or error, for the hit ratio
+func recordLookup(ctx context.Context, result string) {
+	lookupCounterOnce.Do(func() {
+		// instruments from the global meter follow a provider installed later
+		lookupCounter, _ = otel.Meter(meterName).Int64Counter(
+			"cache.lookups",
+			metric.WithDescription("Number of cache reads by result"),
+			metric.WithUnit("{lookup}"),
+		)
+	})
+	if lookupCounter == nil {
+		return
+	}
+	lookupCounter.Add(ctx, 1, metric.WithAttributes(attribute.String("cache.result", result)))
+}
@@ -15,7 +15,7 @@ import (
 type RedisConfig struct {
 	Host         string        `mapstructure:"host"`
 	Port         int           `mapstructure:"port"`
-	Password     string        `mapstructure:"password"`
+	Password     string        `mapstructure:"password" secret:"true"`
 	Database     int           `mapstructure:"database"`
 	MaxRetries   int           `mapstructure:"maxRetries"`
 	PoolSize     int           `mapstructure:"poolSize"`
@@ -103,12 +103,15 @@ func InitRedisService(config RedisConfig) error {
 func (r *redisService) Get(ctx context.Context, key string) (string, error) {
 	result, err := r.client.Get(ctx, key).Result()
 	if err == redis.Nil {
+		recordLookup(ctx, lookupMiss)
 		return "", ErrCacheKeyNotFound
 	}
 	if err != nil {
+		recordLookup(ctx, lookupError)
 		logger.Slog.Error("Redis GET error", "key", key, "error", err.Error())
 		return "", fmt.Errorf("redis get error: %w", err)
 	}
+	recordLookup(ctx, lookupHit)
 	return result, nil
 }
 
@@ -1,22 +1,111 @@
 package core_config
 
 import (
+	"time"
+
+	"github.com/yourorg/go-api-template/core/audit"
 	"github.com/yourorg/go-api-template/core/cache"
+	"github.com/yourorg/go-api-template/core/diagnostics"
+	"github.com/yourorg/go-api-template/core/eventbus"
+	"github.com/yourorg/go-api-template/core/exception"
+	"github.com/yourorg/go-api-template/core/i18n"
+	"github.com/yourorg/go-api-template/core/jobs"
+	"github.com/yourorg/go-api-template/core/notify"
+	"github.com/yourorg/go-api-template/core/outbox"
 	"github.com/yourorg/go-api-template/core/pgdb"
+	"github.com/yourorg/go-api-template/core/secrets"
+	"github.com/yourorg/go-api-template/core/slo"
+	"github.com/yourorg/go-api-template/core/storage"
+	"github.com/yourorg/go-api-template/core/telemetry"
+	middleware "github.com/yourorg/go-api-template/core/transport/httpserver/middlewares"
+	"github.com/yourorg/go-api-template/utils/runtime"
 )
 
 type Config struct {
-	Env        string         `mapstructure:"env"`
-	RestServer RestServer     `mapstructure:"restServer"`
-	CORS       CORS           `mapstructure:"cors"`
-	Postgres   pgdb.Postgres  `mapstructure:"postgres"`
-	LMStudio   LMStudioConfig `mapstructure:"lmStudio"`
-	Auth       AuthConfig     `mapstructure:"auth"`
-	Redis      cache.RedisConfig `mapstructure:"redis"`
-	RateLimit  RateLimitConfig `mapstructure:"rateLimit"`
+	Env        string         `mapstructure:"env" description:"Environment: local, dev, sit, stg or prd, the latter enabling the production safeguards"`
+	// Debug exposes debug_message and stack hints in error responses; ignored when env is prd
+	Debug      bool           `mapstructure:"debug" description:"Expose debug_message and stack hints in error responses, ignored in prd"`
+	RestServer RestServer     `mapstructure:"restServer" description:"HTTP server"`
+	CORS       CORS           `mapstructure:"cors" description:"Cross-origin requests"`
+	// CSRF protects cookie sessions, see middleware.CSRFMiddleware
+	CSRF       middleware.CSRFConfig `mapstructure:"csrf" description:"CSRF protection of cookie sessions"`
+	Postgres   pgdb.Postgres  `mapstructure:"postgres" description:"Read and write PostgreSQL connections, an empty host disables one"`
+	LMStudio   LMStudioConfig `mapstructure:"lmStudio" description:"LM Studio endpoint, used by the lmstudio LLM provider"`
+	LLM        LLMConfig      `mapstructure:"llm" description:"LLM provider, cache, limits and usage accounting"`
+	Auth       AuthConfig     `mapstructure:"auth" description:"JWT authentication"`
+	Redis      cache.RedisConfig `mapstructure:"redis" description:"Redis connection, an empty host disables it"`
+	RateLimit  RateLimitConfig `mapstructure:"rateLimit" description:"Request rate limiting, reloadable"`
+	Outbox     outbox.Config   `mapstructure:"outbox" description:"Transactional outbox poller"`
+	// Jobs is the background job queue, consumed by the worker command
+	Jobs       jobs.Config     `mapstructure:"jobs" description:"Background job queue and worker"`
+	// ServeAll selects what serve:all runs next to the REST server
+	ServeAll ServeAllConfig `mapstructure:"serveAll" description:"Components run by serve:all next to the REST server"`
+	// ErrorCatalog is an optional path to an error catalog overriding the embedded one
+	ErrorCatalog string `mapstructure:"errorCatalog" description:"Path to an error catalog overriding the embedded one"`
+	ErrorStack   exception.StackConfig `mapstructure:"errorStack" description:"Stack frames captured per error severity"`
+	Logging      LoggingConfig         `mapstructure:"logging" description:"Log level and request logs, reloadable"`
+	// Reload re-reads the config file while running, see config.Watch
+	Reload ReloadConfig `mapstructure:"reload" description:"What triggers a config reload"`
+	// Secrets resolves vault:, awssm: and gcpsm: references in the other values
+	Secrets secrets.Config `mapstructure:"secrets" description:"Secret stores resolving vault:, awssm: and gcpsm: references"`
+	// Telemetry exports metrics over OTLP, see telemetry.Setup
+	Telemetry telemetry.Config `mapstructure:"telemetry" description:"OpenTelemetry metrics export over OTLP"`
+	// Diagnostics serves pprof profiles and expvar variables, see diagnostics.Handler
+	Diagnostics diagnostics.Config `mapstructure:"diagnostics" description:"pprof and expvar endpoints for capturing profiles"`
+	// SLO exports the burn rates of route group objectives, see slo.Tracker
+	SLO slo.Config `mapstructure:"slo" description:"Availability and latency objectives of route groups, exported as burn rates"`
+	// Audit records the data-changing requests of the audited routes, see audit.Recorder
+	Audit audit.Config `mapstructure:"audit" description:"Audit trail of data-changing requests per entity"`
+	// EventBus publishes events to the handlers subscribed to their topic, see eventbus.Bus
+	EventBus eventbus.Config `mapstructure:"eventBus" description:"Event bus on memory, NATS or Kafka, with retries and dead-letter topics"`
+	// Storage keeps uploaded files, see storage.Storage
+	Storage storage.Config `mapstructure:"storage" description:"Object storage on local disk, S3 or GCS, and the upload endpoint"`
+	// Notifications delivers the notifications of the users through the job queue, see notify.Notifier
+	Notifications notify.Config `mapstructure:"notifications" description:"User notifications by email, webhook and Slack, per event type and user preferences"`
+	// I18n negotiates the locale of the error and validation messages, see i18n.Middleware
+	I18n i18n.Config `mapstructure:"i18n" description:"Localized API messages negotiated from Accept-Language"`
+	// MockData bounds the mock data generated by the LLM, see mockdata.Generator
+	MockData MockDataConfig `mapstructure:"mockData" description:"Mock data generation: rows and tables per request, model and prompt limits"`
+}
+
+// ReloadConfig selects what triggers a config reload. Sections with subscribers
+// (config.OnChange), such as rateLimit and logging, apply without a restart.
+type ReloadConfig struct {
+	WatchFile bool `mapstructure:"watchFile"` // reload when the file is written
+	SIGHUP    bool `mapstructure:"sighup"`    // reload on kill -HUP
+}
+
+// LoggingConfig controls the canonical request logs
+type LoggingConfig struct {
+	// Level is debug, info, warn or error; empty keeps the level of the profile
+	Level string `mapstructure:"level" enum:"debug info warn error"`
+	// MaxBodyBytes caps logged request and response bodies; 0 uses 4096, negative logs only their size
+	MaxBodyBytes int `mapstructure:"maxBodyBytes"`
+}
+
+// ServeAllConfig selects the components serve:all runs in the REST server process, for small
+// deployments without separate worker processes
+type ServeAllConfig struct {
+	// Worker consumes jobs.queues whatever the jobs store, instead of the worker command
+	Worker bool `mapstructure:"worker"`
+	// Scheduler enqueues jobs.schedules; run it in a single process
+	Scheduler bool `mapstructure:"scheduler"`
 }
 
+// DebugEnabled reports whether debug mode is on, which is never the case in production
+func (c Config) DebugEnabled() bool {
+	return c.Debug && !runtime.Environment(c.Env).IsProduction()
+}
+
+// CORS is the policy of every route, Routes overriding it under path prefixes
 type CORS struct {
+	CORSPolicy `mapstructure:",squash"`
+	// Routes apply another policy under a path prefix, e.g. a public API allowing any origin
+	// and an admin API restricted to internal ones. The longest matching prefix wins.
+	Routes []CORSRoute `mapstructure:"routes"`
+}
+
+type CORSPolicy struct {
 	AllowedMethods []string `mapstructure:"allowedMethods"`
 	AllowedHeaders []string `mapstructure:"allowedHeaders"`
 	AllowedOrigins []string `mapstructure:"allowedOrigins"` // Default: ["*"]
@@ -24,8 +113,90 @@ type CORS struct {
 	MaxAge         int      `mapstructure:"maxAge"` // Default: 7200 (seconds)
 }
 
+// CORSRoute is the policy of the routes under PathPrefix; unset fields keep the global policy
+type CORSRoute struct {
+	PathPrefix string `mapstructure:"pathPrefix"` // e.g. /api/admin, matching /api/admin and /api/admin/...
+	CORSPolicy `mapstructure:",squash"`
+}
+
 type RestServer struct {
 	Port string `mapstructure:"port"`
+	// ErrorFormat is "default" or "problem" (RFC 7807 application/problem+json)
+	ErrorFormat     string `mapstructure:"errorFormat" enum:"default problem"`
+	ProblemTypeBase string `mapstructure:"problemTypeBase"` // e.g. "https://example.com/errors"
+	// ResponseEnvelope wraps successful responses: "none" (default) or "data" for {"status", "data", "request_id"}
+	ResponseEnvelope string `mapstructure:"responseEnvelope" enum:"none data"`
+	Compression     middleware.CompressionConfig `mapstructure:"compression"`
+	// Timeout is the server-side budget per request, also bounding database and LLM calls
+	Timeout middleware.TimeoutConfig `mapstructure:"timeout"`
+	OpenAPI OpenAPIConfig            `mapstructure:"openapi"`
+	// MediaTypes restricts the request/response encodings negotiated by Content-Type and Accept,
+	// the first one being the default; empty allows JSON, XML, msgpack and CSV
+	MediaTypes []string `mapstructure:"mediaTypes"`
+	Decoding   DecodingConfig `mapstructure:"decoding"`
+	// ETag adds validators to GET responses of the versioned API, answering 304 on If-None-Match
+	ETag     middleware.ETagConfig `mapstructure:"etag"`
+	// TraceBaggage tags request spans, outbound baggage and logs with request, user and tenant IDs
+	TraceBaggage middleware.TraceBaggageConfig `mapstructure:"traceBaggage"`
+	// DebugTrace samples and logs at debug level the requests of admins sent with X-Debug-Trace: true
+	DebugTrace middleware.DebugTraceConfig `mapstructure:"debugTrace"`
+	Shutdown   ShutdownConfig `mapstructure:"shutdown"`
+	TLS        TLSConfig      `mapstructure:"tls"`
+	// H2C serves HTTP/2 without TLS, for running behind a proxy that terminates TLS
+	H2C bool `mapstructure:"h2c"`
+}
+
+// DecodingConfig makes request decoding strict instead of ignoring mistakes in payloads
+type DecodingConfig struct {
+	// DisallowUnknownFields answers 422 for JSON and msgpack bodies with fields the request does not have
+	DisallowUnknownFields bool `mapstructure:"disallowUnknownFields"`
+	// RequireContentType answers 415 for bodies without a Content-Type
+	RequireContentType bool `mapstructure:"requireContentType"`
+}
+
+// TLSConfig serves HTTPS from a certificate and key file, or from certificates obtained
+// with ACME (Let's Encrypt). HTTP/2 is negotiated over TLS unless DisableHTTP2 is set.
+type TLSConfig struct {
+	Enabled      bool             `mapstructure:"enabled"`
+	CertFile     string           `mapstructure:"certFile"`
+	KeyFile      string           `mapstructure:"keyFile"`
+	MinVersion   string           `mapstructure:"minVersion" enum:"1.2 1.3"`
+	DisableHTTP2 bool             `mapstructure:"disableHTTP2"`
+	Autocert     AutocertConfig   `mapstructure:"autocert"`
+	ClientAuth   ClientAuthConfig `mapstructure:"clientAuth"`
+}
+
+// AutocertConfig obtains certificates for Domains with the TLS-ALPN-01 challenge,
+// so the server must be reachable on port 443 under those names
+type AutocertConfig struct {
+	Enabled  bool     `mapstructure:"enabled"`
+	Domains  []string `mapstructure:"domains"`
+	CacheDir string   `mapstructure:"cacheDir"`
+	Email    string   `mapstructure:"email"`
+}
+
+// ClientAuthConfig enables mutual TLS
+type ClientAuthConfig struct {
+	// Mode is "none" (default), "request" (ask, do not verify), "verify" (verify when sent)
+	// or "require"; only verified certificates yield a client identity
+	Mode   string `mapstructure:"mode" enum:"none request verify require"`
+	CAFile string `mapstructure:"caFile"` // PEM bundle of the CAs issuing client certificates
+}
+
+// ShutdownConfig controls draining on SIGTERM: readiness fails right away, requests are
+// still served for DrainDelay so load balancers can stop routing here, then the server
+// stops accepting and waits up to Timeout for in-flight requests before resources are closed
+type ShutdownConfig struct {
+	DrainDelay time.Duration `mapstructure:"drainDelay"`
+	Timeout    time.Duration `mapstructure:"timeout"`
+}
+
+// OpenAPIConfig serves the spec generated from the registered routes at /openapi.json
+type OpenAPIConfig struct {
+	Enabled   bool   `mapstructure:"enabled"`
+	Title     string `mapstructure:"title"`
+	Version   string `mapstructure:"version"`
+	SwaggerUI bool   `mapstructure:"swaggerUI"` // also serve Swagger UI at /docs
 }
 
 type LMStudioConfig struct {
@@ -35,10 +206,150 @@ type LMStudioConfig struct {
 	Temperature float64 `mapstructure:"temperature"`
 	MaxTokens   int     `mapstructure:"maxTokens"`
 	EnableMock  bool    `mapstructure:"enableMock"`
+	// RequestTimeout is the default budget per completion request; 0 leaves only the transport timeout
+	RequestTimeout time.Duration `mapstructure:"requestTimeout"`
+	Retry       RetryConfig `mapstructure:"retry"`
+	CircuitBreaker CircuitBreakerConfig `mapstructure:"circuitBreaker"`
+	Transport      HTTPTransportConfig  `mapstructure:"transport"`
+	Auth           OutboundAuthConfig   `mapstructure:"auth"`
+}
+
+// OutboundAuthConfig sets the credentials sent to the endpoint
+type OutboundAuthConfig struct {
+	Type         string   `mapstructure:"type" enum:"none apiKey bearer basic oauth2"`
+	Header       string   `mapstructure:"header"` // apiKey header, default X-API-Key
+	Token        string   `mapstructure:"token" secret:"true"` // apiKey or bearer token
+	Username     string   `mapstructure:"username"`
+	Password     string   `mapstructure:"password" secret:"true"`
+	TokenURL     string   `mapstructure:"tokenUrl"` // oauth2 client credentials
+	ClientID     string   `mapstructure:"clientId"`
+	ClientSecret string   `mapstructure:"clientSecret" secret:"true"`
+	Scopes       []string `mapstructure:"scopes"`
+}
+
+// HTTPTransportConfig tunes outbound connections; zero values keep the Go defaults
+type HTTPTransportConfig struct {
+	Timeout               time.Duration `mapstructure:"timeout"` // whole request, default 10m
+	DialTimeout           time.Duration `mapstructure:"dialTimeout"`
+	KeepAlive             time.Duration `mapstructure:"keepAlive"`
+	TLSHandshakeTimeout   time.Duration `mapstructure:"tlsHandshakeTimeout"`
+	ResponseHeaderTimeout time.Duration `mapstructure:"responseHeaderTimeout"`
+	IdleConnTimeout       time.Duration `mapstructure:"idleConnTimeout"`
+	MaxIdleConns          int           `mapstructure:"maxIdleConns"`
+	MaxIdleConnsPerHost   int           `mapstructure:"maxIdleConnsPerHost"`
+	MaxConnsPerHost       int           `mapstructure:"maxConnsPerHost"`
+	ProxyURL              string        `mapstructure:"proxyUrl"` // e.g. "http://proxy.corp:3128"; empty uses HTTP(S)_PROXY
+	CAFile                string        `mapstructure:"caFile"`   // PEM bundle added to the system roots
+}
+
+// LLMConfig selects the LLM backend. LM Studio uses the lmStudio section,
+// the other providers their own section.
+type LLMConfig struct {
+	Provider string            `mapstructure:"provider" enum:"lmstudio openai azure ollama"`
+	OpenAI   LLMProviderConfig `mapstructure:"openai"`
+	Azure    LLMProviderConfig `mapstructure:"azure"`
+	Ollama   LLMProviderConfig `mapstructure:"ollama"`
+	Cache    LLMCacheConfig    `mapstructure:"cache"`
+	Limits   LLMLimitsConfig   `mapstructure:"limits"`
+	Usage    LLMUsageConfig    `mapstructure:"usage"`
+}
+
+// LLMUsageConfig records token usage per caller and month
+type LLMUsageConfig struct {
+	Enabled            bool   `mapstructure:"enabled"`
+	Store              string `mapstructure:"store" enum:"memory redis postgres"`
+	MonthlyTokenBudget int64  `mapstructure:"monthlyTokenBudget"` // per caller, 0 disables enforcement
+}
+
+// LLMLimitsConfig protects the model server; requests over the limits queue
+// for up to QueueTimeout and then fail with 429. Zero disables a limit.
+type LLMLimitsConfig struct {
+	MaxConcurrent     int           `mapstructure:"maxConcurrent"`
+	RequestsPerMinute int           `mapstructure:"requestsPerMinute"` // per instance
+	QueueTimeout      time.Duration `mapstructure:"queueTimeout"`
+}
+
+// LLMCacheConfig reuses answers to identical prompts; the cache needs Redis
+type LLMCacheConfig struct {
+	Enabled bool          `mapstructure:"enabled"`
+	TTL     time.Duration `mapstructure:"ttl"`
+	Dedup   bool          `mapstructure:"dedup"` // share one upstream call between concurrent identical requests
+}
+
+// MockDataConfig bounds the mock data generated by the LLM, see mockdata.Generator
+type MockDataConfig struct {
+	// DefaultRows generated per table when the request does not say
+	DefaultRows int `mapstructure:"defaultRows"`
+	// MaxRows a request may ask for per table
+	MaxRows int `mapstructure:"maxRows"`
+	// MaxTables of a batch, the parents of the requested tables included
+	MaxTables int `mapstructure:"maxTables"`
+	// Model requested from the LLM provider, empty for its configured model
+	Model       string  `mapstructure:"model"`
+	Temperature float64 `mapstructure:"temperature"`
+	// MaxTokens of the completion of a table, 0 for the default of the model
+	MaxTokens int `mapstructure:"maxTokens"`
+	// MaxParentKeys caps the keys of a parent table listed in the prompt of a child table
+	MaxParentKeys int `mapstructure:"maxParentKeys"`
+	// Sandbox checks the generated statements against the sandbox target: none, explain plans
+	// them, execute runs them in a transaction rolled back
+	Sandbox string `mapstructure:"sandbox" enum:"none explain execute"`
+	// SandboxTarget is the database of the sandbox, the write database when its host is empty
+	SandboxTarget pgdb.PostgresConfig `mapstructure:"sandboxTarget"`
+	// StatementTimeout cancels a statement of the sandbox or of apply running longer, 0 for none
+	StatementTimeout time.Duration `mapstructure:"statementTimeout"`
+	// Role of the JWTs allowed to generate mock data, empty denies every caller
+	Role string `mapstructure:"role"`
+	// Apply executes the generated mock data against a target database, for the requests asking
+	// for it; never on a production profile
+	Apply MockDataApplyConfig `mapstructure:"apply"`
+}
+
+// MockDataApplyConfig is the target database of the generated mock data, see mockdata.Applier
+type MockDataApplyConfig struct {
+	// Enabled lets the requests apply the generated mock data
+	Enabled bool `mapstructure:"enabled"`
+	// Target database, the write database when its host is empty; its schema, when set,
+	// qualifies the tables inserted into
+	Target pgdb.PostgresConfig `mapstructure:"target"`
+	// DatabaseRole the inserts run as, SET LOCAL ROLE, a role of the target user granted INSERT
+	// on the tables only; empty runs them as the target user
+	DatabaseRole string `mapstructure:"databaseRole"`
+	// BatchSize caps the rows of each INSERT executed, 0 executes the inserts as generated
+	BatchSize int `mapstructure:"batchSize"`
+	// Transaction is batch to commit a batch at once, only when every table applied, or table
+	// to commit each table on its own
+	Transaction string `mapstructure:"transaction" enum:"batch table"`
+}
+
+type LLMProviderConfig struct {
+	LMStudioConfig `mapstructure:",squash"` // protocol, baseUrl, model, retry, circuitBreaker
+	APIKey         string                   `mapstructure:"apiKey" secret:"true"`
+	APIVersion     string                   `mapstructure:"apiVersion"` // Azure OpenAI only, e.g. "2024-06-01"
+	// ModelMapping maps model names used by services to provider models (Azure: deployment names)
+	ModelMapping map[string]string `mapstructure:"modelMapping"`
+}
+
+// CircuitBreakerConfig configures the per-host circuit breaker of outbound HTTP calls;
+// zero values use the client defaults
+type CircuitBreakerConfig struct {
+	Enabled          bool          `mapstructure:"enabled"`
+	FailureThreshold int           `mapstructure:"failureThreshold"` // consecutive failures before opening
+	OpenDuration     time.Duration `mapstructure:"openDuration"`
+	HalfOpenProbes   int           `mapstructure:"halfOpenProbes"`
+}
+
+// RetryConfig configures retries of outbound HTTP calls; zero values use the client defaults
+type RetryConfig struct {
+	MaxAttempts          int           `mapstructure:"maxAttempts"` // including the first attempt, 1 disables retries
+	BaseBackoff          time.Duration `mapstructure:"baseBackoff"`
+	MaxBackoff           time.Duration `mapstructure:"maxBackoff"`
+	RetryableStatusCodes []int         `mapstructure:"retryableStatusCodes"`
+	RetryNonIdempotent   bool          `mapstructure:"retryNonIdempotent"`
 }
 
 type AuthConfig struct {
-	JWTSecretKey   string   `mapstructure:"jwtSecretKey"`
+	JWTSecretKey   string   `mapstructure:"jwtSecretKey" secret:"true"`
 	SkipAuthPaths  []string `mapstructure:"skipAuthPaths"`
 	TokenDuration  string   `mapstructure:"tokenDuration"`  // e.g., "24h"
 	RefreshDuration string  `mapstructure:"refreshDuration"` // e.g., "168h" (7 days)
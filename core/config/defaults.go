@@ -0,0 +1,99 @@
+package core_config
+
+import (
+	"time"
+
+	"github.com/yourorg/go-api-template/core/audit"
+	"github.com/yourorg/go-api-template/core/diagnostics"
+	"github.com/yourorg/go-api-template/core/eventbus"
+	"github.com/yourorg/go-api-template/core/exception"
+	"github.com/yourorg/go-api-template/core/i18n"
+	"github.com/yourorg/go-api-template/core/jobs"
+	"github.com/yourorg/go-api-template/core/logger"
+	"github.com/yourorg/go-api-template/core/notify"
+	"github.com/yourorg/go-api-template/core/outbox"
+	"github.com/yourorg/go-api-template/core/ratelimit"
+	"github.com/yourorg/go-api-template/core/secrets"
+	"github.com/yourorg/go-api-template/core/slo"
+	"github.com/yourorg/go-api-template/core/storage"
+	"github.com/yourorg/go-api-template/core/telemetry"
+	middleware "github.com/yourorg/go-api-template/core/transport/httpserver/middlewares"
+)
+
+// Defaults is the single place config defaults are set: the loader applies it under the
+// config files and environment, so a key set nowhere takes its value from here, and the
+// generated schema documents it. Sections owned by a package start from that package's
+// defaults; optional features stay disabled until enabled. The log level is left empty so
+// the logger keeps the level of the profile.
+func Defaults() Config {
+	rateLimit := ratelimit.DefaultConfig()
+
+	csrf := middleware.DefaultCSRFConfig()
+	csrf.Enabled = false
+	compression := middleware.DefaultCompressionConfig()
+	compression.Enabled = false
+	compression.Level = 0
+
+	return Config{
+		RestServer: RestServer{
+			ErrorFormat:      "default",
+			ResponseEnvelope: "none",
+			Compression:      compression,
+			TraceBaggage:     middleware.TraceBaggageConfig{TenantHeader: middleware.TenantIDHeader},
+			DebugTrace:       middleware.DefaultDebugTraceConfig(),
+			Shutdown:         ShutdownConfig{Timeout: time.Minute},
+			// a mock data batch makes a completion per table, each bounded by its own request timeout
+			Timeout: middleware.TimeoutConfig{Routes: map[string]time.Duration{"/api/v1/mock-data": 0}},
+			TLS: TLSConfig{
+				MinVersion: "1.2",
+				Autocert:   AutocertConfig{CacheDir: "certs"},
+				ClientAuth: ClientAuthConfig{Mode: "none"},
+			},
+		},
+		CORS: CORS{CORSPolicy: CORSPolicy{
+			AllowedOrigins: []string{"*"},
+			MaxAge:         7200,
+		}},
+		CSRF: csrf,
+		LLM: LLMConfig{
+			Provider: "lmstudio",
+			Cache:    LLMCacheConfig{TTL: 24 * time.Hour},
+			Usage:    LLMUsageConfig{Store: "memory"},
+		},
+		RateLimit: RateLimitConfig{
+			Requests:       rateLimit.Requests,
+			Window:         rateLimit.Window.String(),
+			SkipPaths:      rateLimit.SkipPaths,
+			IncludeHeaders: rateLimit.IncludeHeaders,
+			Message:        rateLimit.Message,
+			StatusCode:     rateLimit.StatusCode,
+		},
+		Outbox:        outbox.DefaultConfig(),
+		Jobs:          jobs.DefaultConfig(),
+		ErrorStack:    exception.DefaultStackConfig(),
+		Logging:       LoggingConfig{MaxBodyBytes: logger.DefaultMaxBodySize},
+		Secrets:       secrets.Config{CacheTTL: secrets.DefaultCacheTTL},
+		Telemetry:     telemetry.DefaultConfig(),
+		Diagnostics:   diagnostics.DefaultConfig(),
+		SLO:           slo.DefaultConfig(),
+		Audit:         audit.DefaultConfig(),
+		EventBus:      eventbus.DefaultConfig(),
+		Storage:       storage.DefaultConfig(),
+		Notifications: notify.DefaultConfig(),
+		I18n:          i18n.DefaultConfig(),
+		MockData: MockDataConfig{
+			DefaultRows:      10,
+			MaxRows:          200,
+			MaxTables:        30,
+			Temperature:      0.7,
+			MaxParentKeys:    100,
+			Sandbox:          "none",
+			StatementTimeout: 10 * time.Second,
+			Role:             "admin",
+			Apply: MockDataApplyConfig{
+				BatchSize:   100,
+				Transaction: "batch",
+			},
+		},
+	}
+}
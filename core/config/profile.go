@@ -2,6 +2,7 @@ package core_config
 
 import (
 	"html/template"
+	"slices"
 	"strings"
 
 	"github.com/yourorg/go-api-template/utils/runtime"
@@ -13,6 +14,12 @@ type Profile struct {
 
 const configGlobalTemplate = "config/config.{{.Env}}.yaml"
 
+// Config layers surrounding the profile file, see GetConfigFilePaths
+const (
+	configBaseFile  = "config/config.yaml"
+	configLocalFile = "config/config.local.yaml"
+)
+
 func GetGlobalConfigFilePath(cfg runtime.RuntimeCfg) (string, error) {
 	var result strings.Builder
 
@@ -32,3 +39,26 @@ func GetGlobalConfigFilePath(cfg runtime.RuntimeCfg) (string, error) {
 	configName := result.String()
 	return configName, nil
 }
+
+// GetConfigFilePaths returns the config layers of a profile, each overriding the previous:
+// config/config.yaml shared by every environment, config/config.<env>.yaml of each overlay of the
+// runtime profile and the untracked config/config.local.yaml for developer overrides. Missing
+// layers are skipped when loading.
+func GetConfigFilePaths(cfg runtime.RuntimeCfg) ([]string, error) {
+	overlays := []runtime.Environment{cfg.Env}
+	if profile, ok := runtime.LookupProfile(cfg.Env); ok {
+		overlays = profile.Overlays
+	}
+
+	paths := []string{configBaseFile}
+	for _, env := range overlays {
+		overlayPath, err := GetGlobalConfigFilePath(runtime.RuntimeCfg{Microservice: cfg.Microservice, Env: env})
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(paths, overlayPath) && overlayPath != configLocalFile {
+			paths = append(paths, overlayPath)
+		}
+	}
+	return append(paths, configLocalFile), nil
+}
@@ -0,0 +1,95 @@
+package core_config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// RedactedValue replaces the value of a field tagged secret:"true" that is set
+const RedactedValue = "******"
+
+// Redacted returns the config as nested maps keyed like the config file, with the fields
+// tagged secret:"true" masked, ready to be printed as YAML or JSON
+func (c Config) Redacted() map[string]any {
+	out := map[string]any{}
+	redactStruct(reflect.ValueOf(c), out)
+	return out
+}
+
+func redactStruct(val reflect.Value, out map[string]any) {
+	t := val.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+
+		fieldVal := val.Field(i)
+		if opts == "squash" || (name == "" && field.Anonymous) {
+			for fieldVal.Kind() == reflect.Ptr && !fieldVal.IsNil() {
+				fieldVal = fieldVal.Elem()
+			}
+			if fieldVal.Kind() == reflect.Struct {
+				redactStruct(fieldVal, out)
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		if field.Tag.Get("secret") == "true" {
+			if !fieldVal.IsZero() {
+				out[name] = RedactedValue
+			} else {
+				out[name] = redactValue(fieldVal)
+			}
+			continue
+		}
+		out[name] = redactValue(fieldVal)
+	}
+}
+
+func redactValue(val reflect.Value) any {
+	if val.Type() == reflect.TypeOf(time.Duration(0)) {
+		return time.Duration(val.Int()).String()
+	}
+
+	switch val.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if val.IsNil() {
+			return nil
+		}
+		return redactValue(val.Elem())
+	case reflect.Struct:
+		if val.Type().PkgPath() == "time" {
+			return val.Interface()
+		}
+		out := map[string]any{}
+		redactStruct(val, out)
+		return out
+	case reflect.Slice, reflect.Array:
+		if val.Kind() == reflect.Slice && val.IsNil() {
+			return []any{}
+		}
+		items := make([]any, val.Len())
+		for i := range items {
+			items[i] = redactValue(val.Index(i))
+		}
+		return items
+	case reflect.Map:
+		out := make(map[string]any, val.Len())
+		iter := val.MapRange()
+		for iter.Next() {
+			out[fmt.Sprint(iter.Key().Interface())] = redactValue(iter.Value())
+		}
+		return out
+	case reflect.Func, reflect.Chan:
+		return nil
+	default:
+		return val.Interface()
+	}
+}
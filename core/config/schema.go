@@ -0,0 +1,193 @@
+package core_config
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JSONSchemaDraft is the dialect of the schema returned by Schema
+const JSONSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// envPlaceholderPattern matches a value made of a ${VAR} or ${VAR:-default} placeholder,
+// accepted for every non-string key since it is expanded before decoding
+const envPlaceholderPattern = `^\$\{[A-Za-z_][A-Za-z0-9_]*(:-[^}]*)?\}$`
+
+// durationPattern matches the durations accepted by time.ParseDuration, e.g. 30s or 1h30m
+const durationPattern = `^-?([0-9]+(\.[0-9]*)?(ns|us|µs|ms|s|m|h))+$|^0$`
+
+// JSONSchema is the subset of JSON Schema generated for config files
+type JSONSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+	Enum                 []any                  `json:"enum,omitempty"`
+	Default              any                    `json:"default,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	AdditionalProperties any                    `json:"additionalProperties,omitempty"`
+	AnyOf                []*JSONSchema          `json:"anyOf,omitempty"`
+}
+
+// Schema describes the config file from the Config struct tree: keys come from the
+// mapstructure tags, descriptions and allowed values from the description and enum (space
+// separated) tags, defaults from Defaults. Unknown keys are rejected so typos fail validation.
+func Schema() *JSONSchema {
+	schema := structSchema(reflect.TypeFor[Config](), reflect.ValueOf(Defaults()))
+	schema.Schema = JSONSchemaDraft
+	schema.Title = "Service configuration"
+	return schema
+}
+
+// structSchema describes t, defaults being a value of t or invalid when there are none
+func structSchema(t reflect.Type, defaults reflect.Value) *JSONSchema {
+	schema := &JSONSchema{Type: "object", Properties: map[string]*JSONSchema{}, AdditionalProperties: false}
+	addProperties(schema, t, defaults)
+	return schema
+}
+
+func addProperties(schema *JSONSchema, t reflect.Type, defaults reflect.Value) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		var fieldDefault reflect.Value
+		if defaults.IsValid() && field.Type.Kind() != reflect.Ptr {
+			fieldDefault = defaults.Field(i)
+		}
+		if opts == "squash" || (name == "" && field.Anonymous) {
+			if fieldType.Kind() == reflect.Struct {
+				addProperties(schema, fieldType, fieldDefault)
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if fieldType.Kind() == reflect.Func || fieldType.Kind() == reflect.Chan {
+			continue
+		}
+
+		var property *JSONSchema
+		if fieldType.Kind() == reflect.Struct && fieldType != reflect.TypeFor[time.Duration]() {
+			property = structSchema(fieldType, fieldDefault)
+		} else {
+			property = typeSchema(fieldType)
+			if fieldDefault.IsValid() && !fieldDefault.IsZero() {
+				property.Default = defaultValue(fieldDefault)
+			}
+		}
+		property.Description = field.Tag.Get("description")
+		if enum := field.Tag.Get("enum"); enum != "" {
+			for _, value := range strings.Fields(enum) {
+				property.Enum = append(property.Enum, tagValue(fieldType, value))
+			}
+			// an empty value keeps the default of the key
+			if fieldType.Kind() == reflect.String {
+				property.Enum = append(property.Enum, "")
+			}
+		}
+		schema.Properties[name] = withPlaceholder(property)
+	}
+}
+
+func typeSchema(t reflect.Type) *JSONSchema {
+	if t == reflect.TypeFor[time.Duration]() {
+		return &JSONSchema{Type: "string", Pattern: durationPattern}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return typeSchema(t.Elem())
+	case reflect.String:
+		return &JSONSchema{Type: "string"}
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &JSONSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &JSONSchema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &JSONSchema{Type: "array", Items: withPlaceholder(typeSchema(t.Elem()))}
+	case reflect.Map:
+		return &JSONSchema{Type: "object", AdditionalProperties: withPlaceholder(typeSchema(t.Elem()))}
+	case reflect.Struct:
+		return structSchema(t, reflect.Value{})
+	default:
+		return &JSONSchema{}
+	}
+}
+
+// withPlaceholder also accepts a ${VAR} placeholder for the scalars that are not strings
+func withPlaceholder(schema *JSONSchema) *JSONSchema {
+	switch schema.Type {
+	case "boolean", "integer", "number":
+	default:
+		return schema
+	}
+	description, def := schema.Description, schema.Default
+	schema.Description, schema.Default = "", nil
+	return &JSONSchema{
+		Description: description,
+		Default:     def,
+		AnyOf:       []*JSONSchema{schema, {Type: "string", Pattern: envPlaceholderPattern}},
+	}
+}
+
+// defaultValue returns val as written in a config file, durations as strings such as 1m0s
+func defaultValue(val reflect.Value) any {
+	if val.Type() == reflect.TypeFor[time.Duration]() {
+		return time.Duration(val.Int()).String()
+	}
+	if val.Kind() == reflect.Map && val.Type().Elem() == reflect.TypeFor[time.Duration]() {
+		durations := make(map[string]any, val.Len())
+		for entries := val.MapRange(); entries.Next(); {
+			durations[entries.Key().String()] = defaultValue(entries.Value())
+		}
+		return durations
+	}
+	return val.Interface()
+}
+
+// tagValue converts an enum tag to the JSON type of t
+func tagValue(t reflect.Type, value string) any {
+	switch t.Kind() {
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if t == reflect.TypeFor[time.Duration]() {
+			return value
+		}
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return n
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n, err := strconv.ParseUint(value, 10, 64); err == nil {
+			return n
+		}
+	case reflect.Float32, reflect.Float64:
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			return n
+		}
+	case reflect.Slice:
+		var items []any
+		for _, item := range strings.Split(value, ",") {
+			items = append(items, tagValue(t.Elem(), strings.TrimSpace(item)))
+		}
+		return items
+	}
+	return value
+}
@@ -0,0 +1,98 @@
+package core_config
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SetValues returns the YAML config file with the values of dotted keys such as
+// restServer.port replaced, keeping its comments and key order; keys missing from the file
+// are appended to their section
+func SetValues(file []byte, values map[string]any) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(file, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := setValue(doc.Content[0], strings.Split(key, "."), values[key]); err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+	}
+
+	var out bytes.Buffer
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return spaceSections(out.Bytes()), nil
+}
+
+// spaceSections puts back the blank line the encoder drops before each top-level key and
+// its comments
+func spaceSections(file []byte) []byte {
+	lines := strings.SplitAfter(string(file), "\n")
+	var out strings.Builder
+	for i, line := range lines {
+		if i > 0 && line != "" && line[0] != ' ' && line[0] != '-' && line[0] != '\n' {
+			// comments stay attached to the key below them
+			if prev := lines[i-1]; prev != "" && prev[0] != '#' {
+				out.WriteString("\n")
+			}
+		}
+		out.WriteString(line)
+	}
+	return []byte(out.String())
+}
+
+func setValue(node *yaml.Node, path []string, value any) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s is not a section", node.Value)
+	}
+
+	var next *yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == path[0] {
+			next = node.Content[i+1]
+			break
+		}
+	}
+	if next == nil {
+		next = &yaml.Node{Kind: yaml.MappingNode}
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: path[0]}, next)
+	}
+	if len(path) > 1 {
+		return setValue(next, path[1:], value)
+	}
+
+	var encoded yaml.Node
+	if err := encoded.Encode(value); err != nil {
+		return err
+	}
+	// strings keep the quoting of the value they replace, new ones are quoted like the file
+	if encoded.Kind == yaml.ScalarNode && encoded.Tag == "!!str" {
+		encoded.Style = yaml.DoubleQuotedStyle
+		if next.Kind == yaml.ScalarNode {
+			encoded.Style = next.Style
+		}
+	}
+	encoded.HeadComment, encoded.LineComment, encoded.FootComment = next.HeadComment, next.LineComment, next.FootComment
+	*next = encoded
+	return nil
+}
@@ -0,0 +1,498 @@
+package core_config
+
+import (
+	"fmt"
+	"net"
+	"net/mail"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yourorg/go-api-template/core/audit"
+	"github.com/yourorg/go-api-template/core/eventbus"
+	"github.com/yourorg/go-api-template/core/i18n"
+	"github.com/yourorg/go-api-template/core/jobs"
+	"github.com/yourorg/go-api-template/core/notify"
+	"github.com/yourorg/go-api-template/core/outbox"
+	"github.com/yourorg/go-api-template/core/pgdb"
+	"github.com/yourorg/go-api-template/core/storage"
+	"github.com/yourorg/go-api-template/core/telemetry"
+	"github.com/yourorg/go-api-template/core/tracing"
+	middleware "github.com/yourorg/go-api-template/core/transport/httpserver/middlewares"
+	"github.com/yourorg/go-api-template/utils/runtime"
+)
+
+// Violation is one invalid config key
+type Violation struct {
+	Key     string // e.g. restServer.port
+	Message string
+}
+
+// ValidationError lists every violation found by Config.Validate
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "invalid config, %d problem(s):", len(e.Violations))
+	for _, v := range e.Violations {
+		fmt.Fprintf(&b, "\n  - %s: %s", v.Key, v.Message)
+	}
+	return b.String()
+}
+
+// validator collects violations so that all of them are reported at once
+type validator struct {
+	violations []Violation
+}
+
+func (v *validator) add(key string, format string, args ...any) {
+	v.violations = append(v.violations, Violation{Key: key, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) required(key string, value string) {
+	if strings.TrimSpace(value) == "" {
+		v.add(key, "is required")
+	}
+}
+
+func (v *validator) oneOf(key string, value string, allowed ...string) {
+	if !slices.Contains(allowed, value) {
+		v.add(key, "must be one of %s, got %q", strings.Join(slices.DeleteFunc(allowed, func(s string) bool { return s == "" }), ", "), value)
+	}
+}
+
+func (v *validator) duration(key string, value string) {
+	if value == "" {
+		return
+	}
+	if d, err := time.ParseDuration(value); err != nil {
+		v.add(key, "must be a duration such as 30s, 15m or 24h, got %q", value)
+	} else if d <= 0 {
+		v.add(key, "must be positive, got %q", value)
+	}
+}
+
+func (v *validator) nonNegative(key string, value int64) {
+	if value < 0 {
+		v.add(key, "must not be negative, got %d", value)
+	}
+}
+
+// Validate checks the merged config, returning a *ValidationError listing every violation
+// so a broken deployment fails at startup instead of misbehaving on the first request
+func (c Config) Validate() error {
+	v := &validator{}
+
+	v.required("env", c.Env)
+	c.validateRestServer(v)
+	c.validateCORS(v)
+	c.validateAuth(v)
+	c.validateRateLimit(v)
+	c.validateCSRF(v)
+	c.validateLLM(v)
+	c.validateJobs(v)
+	c.validateTelemetry(v)
+	c.validateDiagnostics(v)
+	c.validateSLO(v)
+	c.validateAudit(v)
+	c.validateOutbox(v)
+	c.validateEventBus(v)
+	c.validateStorage(v)
+	c.validateNotifications(v)
+	c.validateI18n(v)
+	c.validateMockData(v)
+	validatePostgres(v, "postgres.write", c.Postgres.Write)
+	validatePostgres(v, "postgres.read", c.Postgres.Read)
+	v.oneOf("logging.level", c.Logging.Level, "", "debug", "info", "warn", "error")
+	if c.Redis.Port < 0 || c.Redis.Port > 65535 {
+		v.add("redis.port", "must be a port number, got %d", c.Redis.Port)
+	}
+
+	if len(v.violations) > 0 {
+		return &ValidationError{Violations: v.violations}
+	}
+	return nil
+}
+
+func (c Config) validateRestServer(v *validator) {
+	s := c.RestServer
+	if port, err := strconv.Atoi(s.Port); s.Port == "" {
+		v.add("restServer.port", "is required")
+	} else if err != nil || port < 1 || port > 65535 {
+		v.add("restServer.port", "must be a port number between 1 and 65535, got %q", s.Port)
+	}
+	v.oneOf("restServer.errorFormat", s.ErrorFormat, "", "default", "problem")
+	v.oneOf("restServer.responseEnvelope", s.ResponseEnvelope, "", "none", "data")
+	v.nonNegative("restServer.timeout.default", int64(s.Timeout.Default))
+	v.nonNegative("restServer.shutdown.drainDelay", int64(s.Shutdown.DrainDelay))
+	v.nonNegative("restServer.shutdown.timeout", int64(s.Shutdown.Timeout))
+
+	if tls := s.TLS; tls.Enabled {
+		if tls.Autocert.Enabled {
+			if len(tls.Autocert.Domains) == 0 {
+				v.add("restServer.tls.autocert.domains", "is required when autocert is enabled")
+			}
+		} else if tls.CertFile == "" || tls.KeyFile == "" {
+			v.add("restServer.tls", "certFile and keyFile are required unless autocert is enabled")
+		}
+		v.oneOf("restServer.tls.minVersion", tls.MinVersion, "", "1.2", "1.3")
+		v.oneOf("restServer.tls.clientAuth.mode", tls.ClientAuth.Mode, "", "none", "request", "verify", "require")
+		if (tls.ClientAuth.Mode == "verify" || tls.ClientAuth.Mode == "require") && tls.ClientAuth.CAFile == "" {
+			v.add("restServer.tls.clientAuth.caFile", "is required to verify client certificates")
+		}
+	}
+	if s.OpenAPI.Enabled {
+		v.required("restServer.openapi.title", s.OpenAPI.Title)
+	}
+}
+
+func (c Config) validateCORS(v *validator) {
+	seen := map[string]bool{}
+	for i, route := range c.CORS.Routes {
+		key := fmt.Sprintf("cors.routes[%d].pathPrefix", i)
+		prefix := strings.TrimSuffix(route.PathPrefix, "/")
+		switch {
+		case !strings.HasPrefix(route.PathPrefix, "/"):
+			v.add(key, "must start with /, got %q", route.PathPrefix)
+		case seen[prefix]:
+			v.add(key, "%q is already configured", route.PathPrefix)
+		}
+		seen[prefix] = true
+	}
+}
+
+func (c Config) validateAuth(v *validator) {
+	v.required("auth.jwtSecretKey", c.Auth.JWTSecretKey)
+	if runtime.Environment(c.Env).IsProduction() && c.Auth.JWTSecretKey != "" && len(c.Auth.JWTSecretKey) < 32 {
+		v.add("auth.jwtSecretKey", "must be at least 32 characters in production")
+	}
+	v.duration("auth.tokenDuration", c.Auth.TokenDuration)
+	v.duration("auth.refreshDuration", c.Auth.RefreshDuration)
+}
+
+// validateRateLimit checks the limiter settings; without Redis the server falls back to a
+// memory limiter per instance, so redis is not required here
+func (c Config) validateRateLimit(v *validator) {
+	if !c.RateLimit.Enabled {
+		return
+	}
+	v.duration("rateLimit.window", c.RateLimit.Window)
+	v.nonNegative("rateLimit.requests", int64(c.RateLimit.Requests))
+	if code := c.RateLimit.StatusCode; code != 0 && (code < 400 || code > 599) {
+		v.add("rateLimit.statusCode", "must be an HTTP error status, got %d", code)
+	}
+}
+
+func (c Config) validateCSRF(v *validator) {
+	if !c.CSRF.Enabled {
+		return
+	}
+	v.oneOf("csrf.mode", c.CSRF.Mode, "", middleware.CSRFDoubleSubmit, middleware.CSRFSynchronizer)
+	if c.CSRF.Mode == middleware.CSRFSynchronizer {
+		v.required("csrf.secret", c.CSRF.Secret)
+		v.required("csrf.sessionCookie", c.CSRF.SessionCookie)
+	}
+}
+
+func (c Config) validateJobs(v *validator) {
+	if !c.Jobs.Enabled {
+		return
+	}
+	v.oneOf("jobs.store", c.Jobs.Store, "", jobs.StoreRedis, jobs.StoreMemory)
+	if c.Jobs.Store != jobs.StoreMemory && c.Redis.Host == "" {
+		v.add("jobs.store", "redis requires redis.host")
+	}
+	v.nonNegative("jobs.timeout", int64(c.Jobs.Timeout))
+	for queue, concurrency := range c.Jobs.Queues {
+		v.nonNegative("jobs.queues."+queue, int64(concurrency))
+	}
+	for i, schedule := range c.Jobs.Schedules {
+		key := fmt.Sprintf("jobs.schedules[%d]", i)
+		v.required(key+".type", schedule.Type)
+		if schedule.Every <= 0 {
+			v.add(key+".every", "must be a positive duration")
+		}
+	}
+}
+
+func (c Config) validateTelemetry(v *validator) {
+	if traces := c.Telemetry.Traces; traces.Enabled {
+		v.oneOf("telemetry.traces.exporter", traces.Exporter, "", tracing.ExporterOTLP, tracing.ExporterStdout)
+		if traces.Exporter != tracing.ExporterStdout {
+			v.required("telemetry.traces.endpoint", traces.Endpoint)
+		}
+		if traces.SampleRatio < 0 || traces.SampleRatio > 1 {
+			v.add("telemetry.traces.sampleRatio", "must be between 0 and 1, got %g", traces.SampleRatio)
+		}
+		v.nonNegative("telemetry.traces.timeout", int64(traces.Timeout))
+	}
+
+	metrics := c.Telemetry.Metrics
+	if !metrics.Enabled {
+		return
+	}
+	v.required("telemetry.metrics.endpoint", metrics.Endpoint)
+	v.oneOf("telemetry.metrics.temporality", metrics.Temporality, "", telemetry.TemporalityCumulative, telemetry.TemporalityDelta)
+	v.nonNegative("telemetry.metrics.interval", int64(metrics.Interval))
+	v.nonNegative("telemetry.metrics.timeout", int64(metrics.Timeout))
+	v.nonNegative("telemetry.runtime.interval", int64(c.Telemetry.Runtime.Interval))
+}
+
+func (c Config) validateDiagnostics(v *validator) {
+	diag := c.Diagnostics
+	if !diag.Enabled {
+		return
+	}
+	if diag.Address == "" {
+		// served on the REST port, where only the JWT keeps them private
+		v.required("diagnostics.role", diag.Role)
+		return
+	}
+	host, port, err := net.SplitHostPort(diag.Address)
+	switch {
+	case err != nil:
+		v.add("diagnostics.address", "must be host:port, got %q", diag.Address)
+	case port == c.RestServer.Port:
+		v.add("diagnostics.address", "must not use the REST port %s, leave it empty to serve there", port)
+	case !diag.AllowRemote && !isLoopback(host):
+		// the separate listener has no authentication
+		v.add("diagnostics.address", "must be a loopback address such as 127.0.0.1:%s, got %q; set diagnostics.allowRemote to listen on the network", port, diag.Address)
+	}
+}
+
+// isLoopback reports whether host only listens on the loopback interface, "" listens on all
+func isLoopback(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func (c Config) validateSLO(v *validator) {
+	if !c.SLO.Enabled {
+		return
+	}
+	for i, window := range c.SLO.Windows {
+		if window <= 0 {
+			v.add(fmt.Sprintf("slo.windows[%d]", i), "must be positive, got %s", window)
+		}
+	}
+	names := map[string]bool{}
+	for i, objective := range c.SLO.Objectives {
+		key := fmt.Sprintf("slo.objectives[%d]", i)
+		v.required(key+".name", objective.Name)
+		if names[objective.Name] {
+			v.add(key+".name", "duplicates objective %q", objective.Name)
+		}
+		names[objective.Name] = true
+		v.required(key+".pathPrefix", objective.PathPrefix)
+		if objective.Availability == 0 && objective.Latency == 0 {
+			v.add(key, "needs an availability or latency target")
+		}
+		if objective.Availability < 0 || objective.Availability >= 1 {
+			v.add(key+".availability", "must be between 0 and 1 (exclusive), got %g", objective.Availability)
+		}
+		if objective.Latency < 0 || objective.Latency >= 1 {
+			v.add(key+".latency", "must be between 0 and 1 (exclusive), got %g", objective.Latency)
+		}
+		if objective.Latency > 0 && objective.LatencyThreshold <= 0 {
+			v.add(key+".latencyThreshold", "is required with a latency target")
+		}
+	}
+}
+
+func (c Config) validateAudit(v *validator) {
+	if !c.Audit.Enabled {
+		return
+	}
+	v.oneOf("audit.store", c.Audit.Store, "", audit.StoreMemory, audit.StorePostgres)
+	if c.Audit.Store == audit.StorePostgres && c.Postgres.Write.Host == "" {
+		v.add("audit.store", "postgres requires postgres.write.host")
+	}
+	v.required("audit.role", c.Audit.Role)
+}
+
+func (c Config) validateOutbox(v *validator) {
+	if !c.Outbox.Enabled {
+		return
+	}
+	sink := c.Outbox.Sink
+	v.oneOf("outbox.sink.type", sink.Type, "", outbox.SinkWebhook, outbox.SinkEventBus)
+	switch sink.Type {
+	case "", outbox.SinkWebhook:
+		v.required("outbox.sink.webhookUrl", sink.WebhookURL)
+	case outbox.SinkEventBus:
+		if !c.EventBus.Enabled {
+			v.add("outbox.sink.type", "eventbus requires eventBus.enabled")
+		}
+	}
+}
+
+func (c Config) validateEventBus(v *validator) {
+	bus := c.EventBus
+	if !bus.Enabled {
+		return
+	}
+	v.oneOf("eventBus.driver", bus.Driver, "", eventbus.DriverMemory, eventbus.DriverNATS, eventbus.DriverKafka)
+	switch bus.Driver {
+	case eventbus.DriverNATS:
+		v.required("eventBus.nats.url", bus.NATS.URL)
+	case eventbus.DriverKafka:
+		v.required("eventBus.kafka.restProxyUrl", bus.Kafka.RestProxyURL)
+		v.nonNegative("eventBus.kafka.pollInterval", int64(bus.Kafka.PollInterval))
+		v.nonNegative("eventBus.kafka.timeout", int64(bus.Kafka.Timeout))
+	}
+	v.nonNegative("eventBus.maxAttempts", int64(bus.MaxAttempts))
+	v.nonNegative("eventBus.baseBackoff", int64(bus.BaseBackoff))
+	v.nonNegative("eventBus.maxBackoff", int64(bus.MaxBackoff))
+}
+
+func (c Config) validateStorage(v *validator) {
+	store := c.Storage
+	if !store.Enabled {
+		return
+	}
+	v.oneOf("storage.driver", store.Driver, "", storage.DriverLocal, storage.DriverS3, storage.DriverGCS)
+	switch store.Driver {
+	case "", storage.DriverLocal:
+		v.required("storage.local.root", store.Local.Root)
+		v.required("storage.local.baseUrl", store.Local.BaseURL)
+		v.required("storage.local.signingKey", store.Local.SigningKey)
+	case storage.DriverS3:
+		v.required("storage.s3.bucket", store.S3.Bucket)
+	case storage.DriverGCS:
+		v.required("storage.gcs.bucket", store.GCS.Bucket)
+	}
+	v.nonNegative("storage.maxUploadSize", store.MaxUploadSize)
+	v.nonNegative("storage.maxFileSize", store.MaxFileSize)
+	for i, allowed := range store.AllowedTypes {
+		if major, minor, ok := strings.Cut(allowed, "/"); !ok || major == "" || minor == "" {
+			v.add(fmt.Sprintf("storage.allowedTypes[%d]", i), "must be a media type such as image/png or image/*, got %q", allowed)
+		}
+	}
+	v.nonNegative("storage.signedUrlExpiry", int64(store.SignedURLExpiry))
+	if store.SignedURLExpiry > 7*24*time.Hour {
+		v.add("storage.signedUrlExpiry", "must be at most 7 days, got %s", store.SignedURLExpiry)
+	}
+}
+
+func (c Config) validateNotifications(v *validator) {
+	n := c.Notifications
+	if !n.Enabled {
+		return
+	}
+	if !c.Jobs.Enabled {
+		v.add("notifications.enabled", "requires jobs.enabled, the notifications are delivered by jobs")
+	} else if _, ok := c.Jobs.Queues[n.Queue]; !ok && n.Queue != "" {
+		v.add("notifications.queue", "must be one of jobs.queues, got %q", n.Queue)
+	}
+	v.oneOf("notifications.store", n.Store, "", notify.StoreMemory, notify.StorePostgres)
+	if n.Store == notify.StorePostgres && c.Postgres.Write.Host == "" {
+		v.add("notifications.store", "postgres requires postgres.write.host")
+	}
+	v.nonNegative("notifications.maxAttempts", int64(n.MaxAttempts))
+	for i, route := range n.Routes {
+		key := fmt.Sprintf("notifications.routes[%d]", i)
+		v.required(key+".event", route.Event)
+		for j, channel := range route.Channels {
+			if !n.Available(channel) {
+				v.add(fmt.Sprintf("%s.channels[%d]", key, j), "must be a configured channel among email, webhook and slack, got %q", channel)
+			}
+		}
+	}
+	if n.Available(notify.ChannelEmail) {
+		if _, err := mail.ParseAddress(n.Email.From); err != nil {
+			v.add("notifications.email.from", "must be an email address, got %q", n.Email.From)
+		}
+		v.oneOf("notifications.email.tls", n.Email.TLS, "", "starttls", "tls", "none")
+	}
+}
+
+func (c Config) validateI18n(v *validator) {
+	if !c.I18n.Enabled {
+		return
+	}
+	v.required("i18n.defaultLocale", c.I18n.DefaultLocale)
+	if c.I18n.DefaultLocale != "" && !i18n.ValidLocale(c.I18n.DefaultLocale) {
+		v.add("i18n.defaultLocale", "must be a language tag such as en or en-GB, got %q", c.I18n.DefaultLocale)
+	}
+}
+
+func (c Config) validateMockData(v *validator) {
+	m := c.MockData
+	v.nonNegative("mockData.defaultRows", int64(m.DefaultRows))
+	v.nonNegative("mockData.maxRows", int64(m.MaxRows))
+	v.nonNegative("mockData.maxTables", int64(m.MaxTables))
+	v.nonNegative("mockData.maxTokens", int64(m.MaxTokens))
+	v.nonNegative("mockData.maxParentKeys", int64(m.MaxParentKeys))
+	if m.MaxRows > 0 && m.DefaultRows > m.MaxRows {
+		v.add("mockData.defaultRows", "must be at most mockData.maxRows (%d), got %d", m.MaxRows, m.DefaultRows)
+	}
+	if m.Temperature < 0 || m.Temperature > 2 {
+		v.add("mockData.temperature", "must be between 0 and 2, got %g", m.Temperature)
+	}
+	v.oneOf("mockData.sandbox", m.Sandbox, "", "none", "explain", "execute")
+	validatePostgres(v, "mockData.sandboxTarget", m.SandboxTarget)
+	v.nonNegative("mockData.statementTimeout", int64(m.StatementTimeout))
+	if m.Apply.Enabled && runtime.Environment(c.Env).IsProduction() {
+		v.add("mockData.apply.enabled", "must be false on the production profile %s", c.Env)
+	}
+	v.nonNegative("mockData.apply.batchSize", int64(m.Apply.BatchSize))
+	v.oneOf("mockData.apply.transaction", m.Apply.Transaction, "", "batch", "table")
+	validatePostgres(v, "mockData.apply.target", m.Apply.Target)
+}
+
+func (c Config) validateLLM(v *validator) {
+	llm := c.LLM
+	provider := strings.ToLower(llm.Provider)
+	v.oneOf("llm.provider", provider, "", "lmstudio", "openai", "azure", "ollama")
+	switch provider {
+	case "openai", "ollama", "azure":
+		p := map[string]LLMProviderConfig{"openai": llm.OpenAI, "ollama": llm.Ollama, "azure": llm.Azure}[provider]
+		v.required("llm."+provider+".baseUrl", p.BaseUrl)
+		if provider != "ollama" && p.APIKey == "" && p.Auth.Type == "" {
+			v.add("llm."+provider+".apiKey", "is required unless auth is configured")
+		}
+		if provider == "azure" {
+			v.required("llm.azure.apiVersion", p.APIVersion)
+		}
+	}
+
+	if llm.Cache.Enabled && c.Redis.Host == "" {
+		v.add("llm.cache.enabled", "requires redis.host")
+	}
+	if llm.Usage.Enabled {
+		v.oneOf("llm.usage.store", llm.Usage.Store, "", "memory", "redis", "postgres")
+		switch llm.Usage.Store {
+		case "redis":
+			if c.Redis.Host == "" {
+				v.add("llm.usage.store", "redis requires redis.host")
+			}
+		case "postgres":
+			if c.Postgres.Write.Host == "" {
+				v.add("llm.usage.store", "postgres requires postgres.write.host")
+			}
+		}
+	}
+	v.nonNegative("llm.limits.maxConcurrent", int64(llm.Limits.MaxConcurrent))
+	v.nonNegative("llm.limits.requestsPerMinute", int64(llm.Limits.RequestsPerMinute))
+}
+
+// validatePostgres checks a connection once its host is set; an empty host disables it
+func validatePostgres(v *validator, key string, p pgdb.PostgresConfig) {
+	if p.Host == "" {
+		return
+	}
+	if p.Port < 1 || p.Port > 65535 {
+		v.add(key+".port", "must be a port number between 1 and 65535, got %d", p.Port)
+	}
+	v.required(key+".database", p.Database)
+	v.required(key+".username", p.Username)
+	if p.MaxConnections < 0 {
+		v.add(key+".maxConnections", "must not be negative, got %d", p.MaxConnections)
+	}
+}
@@ -0,0 +1,108 @@
+package devserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// Build compiles the package pkg into output, returning the compiler output on failure
+func Build(ctx context.Context, pkg string, output string) error {
+	cmd := exec.CommandContext(ctx, "go", "build", "-o", output, pkg)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if len(out) == 0 {
+			return err
+		}
+		return fmt.Errorf("build failed:\n%s", out)
+	}
+	return nil
+}
+
+// Process is a running server started by Start
+type Process struct {
+	cmd    *exec.Cmd
+	exited chan struct{}
+	err    error
+}
+
+// Start runs binary with args, its output going to the dev command output, and env appended
+// to the environment of the dev command
+func Start(binary string, args []string, env []string) (*Process, error) {
+	cmd := exec.Command(binary, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), env...)
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	p := &Process{cmd: cmd, exited: make(chan struct{})}
+	go func() {
+		p.err = cmd.Wait()
+		close(p.exited)
+	}()
+	return p, nil
+}
+
+// Exited is closed when the process exits, Err then returning its exit error
+func (p *Process) Exited() <-chan struct{} {
+	return p.exited
+}
+
+// Err returns the exit error of an exited process
+func (p *Process) Err() error {
+	<-p.exited
+	return p.err
+}
+
+// Stop interrupts the process, letting it shut down gracefully, and kills it after timeout
+func (p *Process) Stop(timeout time.Duration) error {
+	if err := p.cmd.Process.Signal(os.Interrupt); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		// platforms without interrupts get killed right away
+		timeout = 0
+	}
+	select {
+	case <-p.exited:
+		return nil
+	case <-time.After(timeout):
+		if err := p.cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+			return err
+		}
+		<-p.exited
+		return fmt.Errorf("killed after not stopping within %s", timeout)
+	}
+}
+
+// WaitReady waits until addr accepts connections, failing when the process exits first
+func (p *Process) WaitReady(ctx context.Context, addr string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		conn, err := net.DialTimeout("tcp", addr, 100*time.Millisecond)
+		if err == nil {
+			return conn.Close()
+		}
+		select {
+		case <-p.exited:
+			return fmt.Errorf("server exited before listening on %s: %v", addr, p.err)
+		case <-ctx.Done():
+			return fmt.Errorf("server is not listening on %s after %s", addr, timeout)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// FreePort returns a TCP port of localhost no one listens on
+func FreePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
@@ -0,0 +1,89 @@
+package devserver
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Proxy forwards requests to the current server. Requests arriving during a restart wait for
+// the new server, up to the wait of NewProxy; after a failed build they get its output.
+type Proxy struct {
+	wait time.Duration
+
+	mu      sync.Mutex
+	proxy   *httputil.ReverseProxy
+	ready   chan struct{}
+	failure string
+}
+
+// NewProxy returns a proxy holding requests up to wait while no server is ready
+func NewProxy(wait time.Duration) *Proxy {
+	return &Proxy{wait: wait, ready: make(chan struct{})}
+}
+
+// Restarting holds the requests that arrive from now on until Ready or Failed
+func (p *Proxy) Restarting() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	select {
+	case <-p.ready:
+		p.ready = make(chan struct{})
+	default:
+	}
+}
+
+// Ready forwards the held and next requests to target
+func (p *Proxy) Ready(target *url.URL) {
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	// server-sent events and streamed completions are relayed as they are written
+	proxy.FlushInterval = -1
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.proxy, p.failure = proxy, ""
+	p.release()
+}
+
+// Failed answers the held and next requests with message until the next Ready
+func (p *Proxy) Failed(message string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.proxy, p.failure = nil, message
+	p.release()
+}
+
+func (p *Proxy) release() {
+	select {
+	case <-p.ready:
+	default:
+		close(p.ready)
+	}
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	ready := p.ready
+	p.mu.Unlock()
+
+	select {
+	case <-ready:
+	case <-r.Context().Done():
+		return
+	case <-time.After(p.wait):
+		http.Error(w, fmt.Sprintf("dev server: the server did not start within %s, see the dev command output", p.wait), http.StatusServiceUnavailable)
+		return
+	}
+
+	p.mu.Lock()
+	proxy, failure := p.proxy, p.failure
+	p.mu.Unlock()
+	if proxy == nil {
+		http.Error(w, "dev server: "+failure, http.StatusBadGateway)
+		return
+	}
+	proxy.ServeHTTP(w, r)
+}
@@ -0,0 +1,138 @@
+// Package devserver is the local development loop of the dev command: it watches the sources,
+// rebuilds and restarts the server on change, and proxies requests to it so clients keep one
+// port and wait out restarts instead of seeing connection errors.
+package devserver
+
+import (
+	"context"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// WatchOptions selects the files whose changes restart the server
+type WatchOptions struct {
+	// Dirs are watched recursively
+	Dirs []string
+	// Extensions of the files that trigger a rebuild, e.g. .go and .yaml
+	Extensions []string
+	// Ignore are directory names never watched, such as .git or bin
+	Ignore []string
+	// Debounce groups the changes of a burst, like a save-all or a git checkout
+	Debounce time.Duration
+}
+
+// DefaultWatchOptions watches the Go sources, config, templates and migrations of the working directory
+func DefaultWatchOptions() WatchOptions {
+	return WatchOptions{
+		Dirs:       []string{"."},
+		Extensions: []string{".go", ".yaml", ".yml", ".json", ".sql", ".tmpl"},
+		Ignore:     []string{".git", "bin", "tmp", "vendor", "node_modules", "tests", "fixtures"},
+		Debounce:   300 * time.Millisecond,
+	}
+}
+
+// Matches reports whether a change of path, relative to a watched directory, triggers a rebuild
+func (o WatchOptions) Matches(path string) bool {
+	for _, dir := range strings.Split(filepath.ToSlash(filepath.Dir(path)), "/") {
+		if slices.Contains(o.Ignore, dir) {
+			return false
+		}
+	}
+	base := filepath.Base(path)
+	// editors write swap and backup files next to the ones being edited
+	if strings.HasPrefix(base, ".") || strings.HasSuffix(base, "~") {
+		return false
+	}
+	return slices.Contains(o.Extensions, filepath.Ext(base))
+}
+
+// Watch sends the files changed in a burst of changes until ctx is cancelled
+func Watch(ctx context.Context, opts WatchOptions, logger *slog.Logger) (<-chan []string, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	for _, dir := range opts.Dirs {
+		if err := addTree(watcher, dir, opts.Ignore); err != nil {
+			watcher.Close()
+			return nil, err
+		}
+	}
+
+	changes := make(chan []string)
+	go func() {
+		defer watcher.Close()
+		defer close(changes)
+
+		var pending []string
+		var flush <-chan time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				logger.WarnContext(ctx, "File watcher error", "error", err)
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				// directories created later, e.g. a new package, are watched too
+				if event.Has(fsnotify.Create) {
+					if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+						_ = addTree(watcher, event.Name, opts.Ignore)
+						continue
+					}
+				}
+				if event.Has(fsnotify.Chmod) || !opts.Matches(relative(opts.Dirs, event.Name)) {
+					continue
+				}
+				if !slices.Contains(pending, event.Name) {
+					pending = append(pending, event.Name)
+				}
+				flush = time.After(opts.Debounce)
+			case <-flush:
+				select {
+				case changes <- pending:
+				case <-ctx.Done():
+					return
+				}
+				pending, flush = nil, nil
+			}
+		}
+	}()
+	return changes, nil
+}
+
+// relative returns path relative to the watched directory containing it, so directories above
+// it, such as /tmp, are not matched against the ignored names
+func relative(dirs []string, path string) string {
+	for _, dir := range dirs {
+		if rel, err := filepath.Rel(dir, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return rel
+		}
+	}
+	return path
+}
+
+// addTree watches dir and its subdirectories except the ignored ones
+func addTree(watcher *fsnotify.Watcher, dir string, ignore []string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+		if path != dir && (slices.Contains(ignore, d.Name()) || strings.HasPrefix(d.Name(), ".")) {
+			return filepath.SkipDir
+		}
+		return watcher.Add(path)
+	})
+}
@@ -0,0 +1,60 @@
+// Package diagnostics serves the runtime profiles of net/http/pprof under /debug/pprof and
+// the expvar variables, such as memstats, at /debug/vars, so CPU and heap profiles can be
+// captured from a running process during an incident:
+//
+//	go tool pprof http://127.0.0.1:6060/debug/pprof/profile?seconds=30
+//	go tool pprof http://127.0.0.1:6060/debug/pprof/heap
+//
+// The endpoints reveal the command line and memory of the process, they are served on a
+// separate listener bound to the loopback interface or behind authentication, never openly.
+package diagnostics
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"time"
+)
+
+// Config enables the diagnostics endpoints
+type Config struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Address of the separate listener, e.g. 127.0.0.1:6060 to keep it off the network;
+	// empty serves the endpoints on the REST port to callers with a JWT carrying Role
+	Address string `mapstructure:"address"`
+	// Role required on the REST port
+	Role string `mapstructure:"role"`
+	// AllowRemote accepts an Address off the loopback interface, e.g. for a sidecar scraping
+	// profiles; the separate listener has no authentication, firewall it
+	AllowRemote bool `mapstructure:"allowRemote"`
+}
+
+// DefaultConfig returns default diagnostics configuration, disabled
+func DefaultConfig() Config {
+	return Config{
+		Address: "127.0.0.1:6060",
+		Role:    "admin",
+	}
+}
+
+// Handler serves /debug/pprof/ and /debug/vars; other paths are not found
+func Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+	return mux
+}
+
+// NewServer returns the separate listener of cfg. It has no write timeout, CPU profiles and
+// execution traces stream for the seconds asked for.
+func NewServer(cfg Config) *http.Server {
+	return &http.Server{
+		Addr:              cfg.Address,
+		Handler:           Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
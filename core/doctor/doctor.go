@@ -0,0 +1,154 @@
+// Package doctor runs diagnostics of the environment of the service, such as the reachability
+// of its dependencies, and reports them in one readiness report.
+package doctor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourorg/go-api-template/core/health"
+)
+
+// Status is the outcome of a check
+type Status string
+
+const (
+	StatusOK   Status = "ok"
+	StatusWarn Status = "warn"
+	StatusFail Status = "fail"
+	StatusSkip Status = "skip"
+)
+
+// CheckFunc diagnoses one part of the environment, returning its status and a short explanation
+type CheckFunc func(ctx context.Context) (Status, string)
+
+// Check is a named diagnostic
+type Check struct {
+	Name string
+	Run  CheckFunc
+}
+
+// Result is the outcome of a check in a report
+type Result struct {
+	Name     string        `json:"name"`
+	Status   Status        `json:"status"`
+	Message  string        `json:"message"`
+	Duration time.Duration `json:"-"`
+}
+
+// MarshalJSON adds the duration in milliseconds
+func (r Result) MarshalJSON() ([]byte, error) {
+	type result Result
+	return json.Marshal(struct {
+		result
+		DurationMS float64 `json:"duration_ms"`
+	}{result(r), float64(r.Duration.Microseconds()) / 1000})
+}
+
+// Report lists the results of the checks in the order they were given
+type Report struct {
+	Results []Result `json:"results"`
+}
+
+// Run runs the checks concurrently, each bounded by timeout, a panic failing its check
+func Run(ctx context.Context, timeout time.Duration, checks ...Check) Report {
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = run(ctx, timeout, check)
+		}()
+	}
+	wg.Wait()
+	return Report{Results: results}
+}
+
+func run(ctx context.Context, timeout time.Duration, check Check) (result Result) {
+	start := time.Now()
+	result.Name = check.Name
+	defer func() {
+		if r := recover(); r != nil {
+			result.Status, result.Message = StatusFail, fmt.Sprintf("check panicked: %v", r)
+		}
+		result.Duration = time.Since(start)
+	}()
+
+	checkCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	result.Status, result.Message = check.Run(checkCtx)
+	if checkCtx.Err() != nil && result.Status != StatusOK && result.Status != StatusSkip {
+		result.Status, result.Message = StatusFail, fmt.Sprintf("timed out after %s: %s", timeout, result.Message)
+	}
+	return result
+}
+
+// FromHealth runs a health checker, a degraded component being a warning
+func FromHealth(checker health.Checker) CheckFunc {
+	return func(ctx context.Context) (Status, string) {
+		component := checker.Check(ctx)
+		switch component.Status {
+		case health.StatusHealthy:
+			return StatusOK, component.Message
+		case health.StatusDegraded:
+			return StatusWarn, component.Message
+		default:
+			return StatusFail, component.Message
+		}
+	}
+}
+
+// Count returns the number of results with status
+func (r Report) Count(status Status) int {
+	count := 0
+	for _, result := range r.Results {
+		if result.Status == status {
+			count++
+		}
+	}
+	return count
+}
+
+// Healthy reports whether no check failed; warnings and skipped checks do not count
+func (r Report) Healthy() bool {
+	return r.Count(StatusFail) == 0
+}
+
+// WriteText prints one line per check and a summary, continuation lines of a message indented
+func (r Report) WriteText(w io.Writer) error {
+	width := 0
+	for _, result := range r.Results {
+		width = max(width, len(result.Name))
+	}
+
+	var b strings.Builder
+	for _, result := range r.Results {
+		lines := strings.Split(strings.TrimSpace(result.Message), "\n")
+		fmt.Fprintf(&b, "[%-4s] %-*s  %s", result.Status, width, result.Name, lines[0])
+		if result.Status != StatusSkip {
+			fmt.Fprintf(&b, " (%s)", result.Duration.Round(time.Millisecond))
+		}
+		b.WriteString("\n")
+		for _, line := range lines[1:] {
+			fmt.Fprintf(&b, "%*s%s\n", width+9, "", strings.TrimLeft(line, "\t"))
+		}
+	}
+	fmt.Fprintf(&b, "\n%d ok, %d warnings, %d failed, %d skipped\n",
+		r.Count(StatusOK), r.Count(StatusWarn), r.Count(StatusFail), r.Count(StatusSkip))
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON prints the report as indented JSON
+func (r Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
@@ -0,0 +1,234 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yourorg/go-api-template/core/backoff"
+	"github.com/yourorg/go-api-template/core/exception"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// Option customizes a bus
+type Option func(*Bus)
+
+// WithClock sets the clock events are timed with
+func WithClock(now func() time.Time) Option {
+	return func(b *Bus) {
+		b.now = now
+	}
+}
+
+// Bus publishes envelopes through its driver and retries the handlers of its subscriptions
+type Bus struct {
+	config Config
+	driver Driver
+	logger *slog.Logger
+	now    func() time.Time
+}
+
+// New returns a bus on driver, filling the unset retry settings with the defaults
+func New(config Config, driver Driver, logger *slog.Logger, opts ...Option) *Bus {
+	defaults := DefaultConfig()
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaults.MaxAttempts
+	}
+	if config.BaseBackoff <= 0 {
+		config.BaseBackoff = defaults.BaseBackoff
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = defaults.MaxBackoff
+	}
+	if config.DeadLetterSuffix == "" {
+		config.DeadLetterSuffix = defaults.DeadLetterSuffix
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	b := &Bus{
+		config: config,
+		driver: driver,
+		logger: logger.With("component", "eventbus"),
+		now:    time.Now,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// PublishOption customizes a published event
+type PublishOption func(*Envelope)
+
+// WithKey orders the event with the other events of key, on the drivers that partition topics
+func WithKey(key string) PublishOption {
+	return func(e *Envelope) {
+		e.Key = key
+	}
+}
+
+// WithHeaders adds headers to the event
+func WithHeaders(headers map[string]string) PublishOption {
+	return func(e *Envelope) {
+		for k, v := range headers {
+			e.Headers[k] = v
+		}
+	}
+}
+
+// Publish sends an event of type eventType with data marshalled to JSON to topic, returning its ID
+func (b *Bus) Publish(ctx context.Context, topic string, eventType string, data any, opts ...PublishOption) (string, error) {
+	if topic == "" {
+		return "", errors.New("event topic is empty")
+	}
+	if eventType == "" {
+		return "", errors.New("event type is empty")
+	}
+
+	body, err := json.Marshal(data)
+	if err != nil {
+		return "", fmt.Errorf("error marshalling event data: %w", err)
+	}
+	id, err := uuid.NewV7()
+	if err != nil {
+		return "", err
+	}
+
+	event := Envelope{
+		ID:      id.String(),
+		Type:    eventType,
+		Topic:   topic,
+		Time:    b.now().UTC(),
+		Headers: map[string]string{},
+		Data:    body,
+	}
+	for _, opt := range opts {
+		opt(&event)
+	}
+	// the handlers continue the trace of the publisher
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(event.Headers))
+
+	if err := b.publish(ctx, topic, event); err != nil {
+		return "", err
+	}
+	return event.ID, nil
+}
+
+// PublishEnvelope sends an event built by the caller to its topic, keeping its ID, e.g. the ID
+// of the outbox message it comes from; the time defaults to now
+func (b *Bus) PublishEnvelope(ctx context.Context, event Envelope) error {
+	if event.ID == "" || event.Type == "" || event.Topic == "" {
+		return exception.MarkPermanent(errors.New("event ID, type and topic are required"))
+	}
+	if event.Time.IsZero() {
+		event.Time = b.now().UTC()
+	}
+	return b.publish(ctx, event.Topic, event)
+}
+
+func (b *Bus) publish(ctx context.Context, topic string, event Envelope) error {
+	message, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("error marshalling event: %w", err)
+	}
+	if err := b.driver.Publish(ctx, topic, event.Key, message); err != nil {
+		return fmt.Errorf("error publishing event to %s: %w", topic, err)
+	}
+	return nil
+}
+
+// Subscribe runs handler for the events of topic received by group. The subscribers sharing a
+// group share its events, each group receiving every event.
+func (b *Bus) Subscribe(ctx context.Context, topic string, group string, handler Handler) (Subscription, error) {
+	if topic == "" || group == "" {
+		return nil, errors.New("event topic and group are required")
+	}
+	sub, err := b.driver.Subscribe(ctx, topic, group, b.deliver(topic, group, handler))
+	if err != nil {
+		return nil, fmt.Errorf("error subscribing %s to %s: %w", group, topic, err)
+	}
+	b.logger.InfoContext(ctx, "Event subscription started", "topic", topic, "group", group)
+	return sub, nil
+}
+
+// DeadLetterTopic returns the topic the events of topic out of attempts are published to
+func (b *Bus) DeadLetterTopic(topic string) string {
+	return topic + b.config.DeadLetterSuffix
+}
+
+// Close closes the driver; unsubscribe first, so the deliveries in progress finish
+func (b *Bus) Close() error {
+	return b.driver.Close()
+}
+
+// deliver decodes the messages for handler, retrying it with backoff until it succeeds, fails
+// permanently or runs out of attempts, then dead-letters the event
+func (b *Bus) deliver(topic string, group string, handler Handler) func(ctx context.Context, message []byte) error {
+	return func(ctx context.Context, message []byte) error {
+		var event Envelope
+		if err := json.Unmarshal(message, &event); err != nil {
+			b.logger.ErrorContext(ctx, "Event is not an envelope, moved to the dead-letter topic", "topic", topic, "group", group, "error", err)
+			return b.driver.Publish(context.WithoutCancel(ctx), b.DeadLetterTopic(topic), "", message)
+		}
+		ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(event.Headers))
+		ctx = context.WithValue(ctx, groupKey{}, group)
+
+		for attempt := 1; ; attempt++ {
+			start := time.Now()
+			// a handler in progress finishes on unsubscribe, the backoff does not
+			err := b.run(context.WithoutCancel(ctx), handler, event)
+			if err == nil {
+				b.logger.DebugContext(ctx, "Event handled", "id", event.ID, "type", event.Type, "topic", topic, "group", group, "duration", time.Since(start))
+				return nil
+			}
+
+			if exception.IsPermanent(err) || attempt >= b.config.MaxAttempts {
+				b.logger.ErrorContext(ctx, "Event failed, moved to the dead-letter topic",
+					"id", event.ID, "type", event.Type, "topic", topic, "group", group, "attempts", attempt, "error", err)
+				if err := b.deadLetter(context.WithoutCancel(ctx), topic, group, event, attempt, err); err != nil {
+					b.logger.ErrorContext(ctx, "Failed to publish the event to the dead-letter topic", "id", event.ID, "topic", topic, "group", group, "error", err)
+					return err
+				}
+				return nil
+			}
+
+			delay := backoff.Exponential(b.config.BaseBackoff, b.config.MaxBackoff, attempt)
+			b.logger.WarnContext(ctx, "Event failed, retrying",
+				"id", event.ID, "type", event.Type, "topic", topic, "group", group, "attempts", attempt, "retry_in", delay, "error", err)
+			select {
+			case <-ctx.Done():
+				// not acknowledged, the drivers that keep messages deliver it again
+				return ctx.Err()
+			case <-time.After(delay):
+			}
+		}
+	}
+}
+
+// run calls handler, turning a panic into a failed attempt
+func (b *Bus) run(ctx context.Context, handler Handler, event Envelope) (err error) {
+	defer exception.Recover(ctx, &err)
+	return handler(ctx, event)
+}
+
+// deadLetter publishes event to the dead-letter topic of topic with the reason it failed
+func (b *Bus) deadLetter(ctx context.Context, topic string, group string, event Envelope, attempts int, cause error) error {
+	headers := make(map[string]string, len(event.Headers)+4)
+	for k, v := range event.Headers {
+		headers[k] = v
+	}
+	headers[HeaderDeadLetterTopic] = topic
+	headers[HeaderDeadLetterGroup] = group
+	headers[HeaderDeadLetterAttempts] = strconv.Itoa(attempts)
+	headers[HeaderDeadLetterError] = cause.Error()
+	event.Headers = headers
+	return b.publish(ctx, b.DeadLetterTopic(topic), event)
+}
@@ -0,0 +1,147 @@
+// Package eventbus publishes events to topics and runs the handlers subscribed to them, in
+// process or through NATS or Kafka. Events travel as JSON envelopes. Every consumer group of a
+// topic receives each event once, handed to one of the subscribers of the group. A failing
+// handler is retried with backoff, and the events out of attempts are published to the
+// dead-letter topic of their topic.
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/yourorg/go-api-template/core/exception"
+)
+
+// Drivers carrying the events
+const (
+	DriverMemory = "memory"
+	DriverNATS   = "nats"
+	DriverKafka  = "kafka"
+)
+
+// Headers set on the events published to a dead-letter topic
+const (
+	HeaderDeadLetterTopic    = "dead-letter-topic"
+	HeaderDeadLetterGroup    = "dead-letter-group"
+	HeaderDeadLetterAttempts = "dead-letter-attempts"
+	HeaderDeadLetterError    = "dead-letter-error"
+)
+
+// Config selects the driver of the bus and how failed events are retried
+type Config struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Driver is memory, within the process, nats or kafka
+	Driver string `mapstructure:"driver" enum:"memory nats kafka"`
+	// MaxAttempts runs of a handler before its event is dead-lettered
+	MaxAttempts int           `mapstructure:"maxAttempts"`
+	BaseBackoff time.Duration `mapstructure:"baseBackoff"`
+	MaxBackoff  time.Duration `mapstructure:"maxBackoff"`
+	// DeadLetterSuffix is appended to a topic to name its dead-letter topic
+	DeadLetterSuffix string      `mapstructure:"deadLetterSuffix"`
+	NATS             NATSConfig  `mapstructure:"nats"`
+	Kafka            KafkaConfig `mapstructure:"kafka"`
+}
+
+// DefaultConfig returns default event bus configuration, disabled
+func DefaultConfig() Config {
+	return Config{
+		Driver:           DriverMemory,
+		MaxAttempts:      5,
+		BaseBackoff:      500 * time.Millisecond,
+		MaxBackoff:       30 * time.Second,
+		DeadLetterSuffix: ".dlq",
+		NATS: NATSConfig{
+			URL: "nats://localhost:4222",
+		},
+		Kafka: KafkaConfig{
+			RestProxyURL: "http://localhost:8082",
+			PollInterval: time.Second,
+			Timeout:      10 * time.Second,
+		},
+	}
+}
+
+// Envelope is an event as it travels on the bus
+type Envelope struct {
+	ID    string `json:"id"`
+	Type  string `json:"type"`
+	Topic string `json:"topic"`
+	// Key orders the events of a key on the drivers that partition topics
+	Key  string    `json:"key,omitempty"`
+	Time time.Time `json:"time"`
+	// Headers carry the trace context of the publisher and the dead-letter headers
+	Headers map[string]string `json:"headers,omitempty"`
+	Data    json.RawMessage   `json:"data"`
+}
+
+// Decode unmarshals the data of the event into v; data that does not decode is not retried
+func (e Envelope) Decode(v any) error {
+	if err := json.Unmarshal(e.Data, v); err != nil {
+		return exception.MarkPermanent(fmt.Errorf("error decoding %s event data: %w", e.Type, err))
+	}
+	return nil
+}
+
+// Handler handles an event; an error retries it unless exception.IsPermanent, which
+// dead-letters it
+type Handler func(ctx context.Context, event Envelope) error
+
+// Typed returns a handler decoding the data of the events into T before calling fn
+func Typed[T any](fn func(ctx context.Context, event Envelope, data T) error) Handler {
+	return func(ctx context.Context, event Envelope) error {
+		var data T
+		if err := event.Decode(&data); err != nil {
+			return err
+		}
+		return fn(ctx, event, data)
+	}
+}
+
+// Subscription is a handler subscribed to a topic
+type Subscription interface {
+	// Unsubscribe stops the deliveries, waiting for the one in progress
+	Unsubscribe() error
+}
+
+// Driver carries encoded envelopes between the publishers and the subscribers
+type Driver interface {
+	// Publish sends message to topic once the broker accepted it
+	Publish(ctx context.Context, topic string, key string, message []byte) error
+	// Subscribe calls deliver with the messages of topic, each message of the topic going
+	// to one subscriber of group. The context of deliver is cancelled on Unsubscribe; a
+	// message is acknowledged when deliver returns nil, the drivers that keep messages
+	// deliver the others again.
+	Subscribe(ctx context.Context, topic string, group string, deliver func(ctx context.Context, message []byte) error) (Subscription, error)
+	// Close releases the connection of the driver
+	Close() error
+}
+
+// NewDriver returns the driver selected by cfg
+func NewDriver(cfg Config, logger *slog.Logger) (Driver, error) {
+	switch cfg.Driver {
+	case "", DriverMemory:
+		return NewMemoryDriver(), nil
+	case DriverNATS:
+		return NewNATSDriver(cfg.NATS)
+	case DriverKafka:
+		return NewKafkaDriver(cfg.Kafka, nil, logger)
+	default:
+		return nil, fmt.Errorf("unknown event bus driver: %q", cfg.Driver)
+	}
+}
+
+// Open returns a bus on the driver selected by cfg
+func Open(cfg Config, logger *slog.Logger) (*Bus, error) {
+	driver, err := NewDriver(cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+	return New(cfg, driver, logger), nil
+}
+
+// errClosed is returned by the drivers used after Close
+var errClosed = errors.New("event bus is closed")
@@ -0,0 +1,155 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/yourorg/go-api-template/core/pgdb"
+)
+
+type groupKey struct{}
+
+// GroupFromContext returns the consumer group a handler was called for
+func GroupFromContext(ctx context.Context) (string, bool) {
+	group, ok := ctx.Value(groupKey{}).(string)
+	return group, ok
+}
+
+// ProcessedStore remembers the events each consumer group handled. Delivery is at least once:
+// an event comes again when its acknowledgement or outbox update is lost.
+type ProcessedStore interface {
+	// Processed reports whether group handled the event
+	Processed(ctx context.Context, group string, eventID string) (bool, error)
+	// MarkProcessed records that group handled the event
+	MarkProcessed(ctx context.Context, group string, eventID string) error
+}
+
+// Idempotent skips the events the group of the subscription already handled, and records those
+// handler succeeds with. An event delivered twice at the same time may run twice; handlers
+// writing to Postgres get exactly-once effects with IdempotentTx.
+func Idempotent(store ProcessedStore, handler Handler) Handler {
+	return func(ctx context.Context, event Envelope) error {
+		group, _ := GroupFromContext(ctx)
+		processed, err := store.Processed(ctx, group, event.ID)
+		if err != nil {
+			return fmt.Errorf("error checking processed event: %w", err)
+		}
+		if processed {
+			slog.DebugContext(ctx, "Event already processed, skipped", "id", event.ID, "type", event.Type, "group", group)
+			return nil
+		}
+
+		if err := handler(ctx, event); err != nil {
+			return err
+		}
+		// a retry would run the handler again, the event is only handled twice if it comes back
+		if err := store.MarkProcessed(ctx, group, event.ID); err != nil {
+			slog.WarnContext(ctx, "Failed to record processed event", "id", event.ID, "group", group, "error", err)
+		}
+		return nil
+	}
+}
+
+// IdempotentTx runs handler in a transaction of the write pool that also records the event as
+// processed by the group, so its writes are applied once however often the event comes. The
+// events already recorded are skipped; see the processed_events table in migrations.
+func IdempotentTx(handler func(ctx context.Context, tx pgx.Tx, event Envelope) error) Handler {
+	return func(ctx context.Context, event Envelope) error {
+		group, _ := GroupFromContext(ctx)
+		return pgdb.WithinTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+			// waits for a concurrent delivery of the event to commit or roll back
+			claimed, err := claimProcessed(ctx, tx, group, event.ID)
+			if err != nil {
+				return err
+			}
+			if !claimed {
+				slog.DebugContext(ctx, "Event already processed, skipped", "id", event.ID, "type", event.Type, "group", group)
+				return nil
+			}
+			return handler(ctx, tx, event)
+		})
+	}
+}
+
+// MemoryProcessedStore remembers the last events handled in process, forgetting the oldest past
+// its capacity; the events are not shared between instances
+type MemoryProcessedStore struct {
+	mu       sync.Mutex
+	capacity int
+	seen     map[string]struct{}
+	order    []string
+}
+
+// NewMemoryProcessedStore creates a store remembering up to capacity events, 10000 when not positive
+func NewMemoryProcessedStore(capacity int) *MemoryProcessedStore {
+	if capacity <= 0 {
+		capacity = 10000
+	}
+	return &MemoryProcessedStore{capacity: capacity, seen: map[string]struct{}{}}
+}
+
+func (s *MemoryProcessedStore) Processed(ctx context.Context, group string, eventID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.seen[group+"\x00"+eventID]
+	return ok, nil
+}
+
+func (s *MemoryProcessedStore) MarkProcessed(ctx context.Context, group string, eventID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := group + "\x00" + eventID
+	if _, ok := s.seen[key]; ok {
+		return nil
+	}
+	if len(s.order) >= s.capacity {
+		delete(s.seen, s.order[0])
+		s.order = s.order[1:]
+	}
+	s.seen[key] = struct{}{}
+	s.order = append(s.order, key)
+	return nil
+}
+
+const ProcessedTableName = "processed_events"
+
+// PostgresProcessedStore keeps the processed events in the processed_events table
+type PostgresProcessedStore struct {
+	db pgdb.DBTX
+}
+
+// NewPostgresProcessedStore creates a store on db, usually the write pool
+func NewPostgresProcessedStore(db pgdb.DBTX) *PostgresProcessedStore {
+	return &PostgresProcessedStore{db: db}
+}
+
+func (s *PostgresProcessedStore) Processed(ctx context.Context, group string, eventID string) (bool, error) {
+	var processed bool
+	err := s.db.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM `+ProcessedTableName+` WHERE consumer_group = $1 AND event_id = $2)`,
+		group, eventID).Scan(&processed)
+	if err != nil {
+		return false, fmt.Errorf("error reading processed event: %w", err)
+	}
+	return processed, nil
+}
+
+func (s *PostgresProcessedStore) MarkProcessed(ctx context.Context, group string, eventID string) error {
+	_, err := claimProcessed(ctx, s.db, group, eventID)
+	return err
+}
+
+// claimProcessed records the event as processed by group, false when it already was
+func claimProcessed(ctx context.Context, db pgdb.DBTX, group string, eventID string) (bool, error) {
+	tag, err := db.Exec(ctx,
+		`INSERT INTO `+ProcessedTableName+` (consumer_group, event_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+		group, eventID)
+	if err != nil {
+		return false, fmt.Errorf("error recording processed event: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
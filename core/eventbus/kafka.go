@@ -0,0 +1,362 @@
+package eventbus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yourorg/go-api-template/core/exception"
+)
+
+// Content types of the v2 API of the Kafka REST Proxy, messages travel in the binary format
+const (
+	kafkaContentType       = "application/vnd.kafka.v2+json"
+	kafkaBinaryContentType = "application/vnd.kafka.binary.v2+json"
+)
+
+// KafkaConfig configures the kafka driver, which talks to Kafka through a Confluent REST
+// Proxy, so the services need no Kafka client library
+type KafkaConfig struct {
+	// RestProxyURL is the base URL of the REST Proxy, e.g. http://kafka-rest:8082
+	RestProxyURL string `mapstructure:"restProxyUrl"`
+	// PollInterval is how long a fetch waits for records, and the wait after an empty one
+	PollInterval time.Duration `mapstructure:"pollInterval"`
+	// Timeout bounds a request to the proxy
+	Timeout time.Duration `mapstructure:"timeout"`
+	// Headers are added to the requests to the proxy, e.g. Authorization
+	Headers map[string]string `mapstructure:"headers" secret:"true"`
+}
+
+// KafkaDriver carries messages over Kafka topics through the REST Proxy, the groups being
+// consumer groups. Offsets are committed once a message is delivered; a subscription that
+// fails to deliver one seeks back to it, so it is delivered again.
+type KafkaDriver struct {
+	url          string
+	headers      map[string]string
+	pollInterval time.Duration
+	timeout      time.Duration
+	client       *http.Client
+	logger       *slog.Logger
+
+	mu     sync.Mutex
+	subs   map[*kafkaSubscription]struct{}
+	closed bool
+}
+
+// NewKafkaDriver returns a driver on cfg.RestProxyURL; client defaults to one bounded by cfg.Timeout
+func NewKafkaDriver(cfg KafkaConfig, client *http.Client, logger *slog.Logger) (*KafkaDriver, error) {
+	if cfg.RestProxyURL == "" {
+		return nil, errors.New("kafka rest proxy url is empty")
+	}
+	defaults := DefaultConfig().Kafka
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = defaults.PollInterval
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaults.Timeout
+	}
+	if client == nil {
+		// a fetch waits up to the poll interval on top of the request itself
+		client = &http.Client{Timeout: cfg.Timeout + cfg.PollInterval}
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &KafkaDriver{
+		url:          strings.TrimSuffix(cfg.RestProxyURL, "/"),
+		headers:      cfg.Headers,
+		pollInterval: cfg.PollInterval,
+		timeout:      cfg.Timeout,
+		client:       client,
+		logger:       logger.With("component", "eventbus", "driver", DriverKafka),
+		subs:         map[*kafkaSubscription]struct{}{},
+	}, nil
+}
+
+type kafkaRecord struct {
+	Topic     string `json:"topic,omitempty"`
+	Key       []byte `json:"key,omitempty"`
+	Value     []byte `json:"value"`
+	Partition int32  `json:"partition,omitempty"`
+	Offset    int64  `json:"offset,omitempty"`
+}
+
+type kafkaOffset struct {
+	Topic     string `json:"topic"`
+	Partition int32  `json:"partition"`
+	Offset    int64  `json:"offset"`
+}
+
+// kafkaError is an error response of the proxy
+type kafkaError struct {
+	Status  int    `json:"-"`
+	Code    int    `json:"error_code"`
+	Message string `json:"message"`
+}
+
+func (e *kafkaError) Error() string {
+	return fmt.Sprintf("kafka rest proxy returned status %d: %d %s", e.Status, e.Code, e.Message)
+}
+
+func (d *KafkaDriver) Publish(ctx context.Context, topic string, key string, message []byte) error {
+	record := kafkaRecord{Value: message}
+	if key != "" {
+		record.Key = []byte(key)
+	}
+	var resp struct {
+		Offsets []struct {
+			ErrorCode *int    `json:"error_code"`
+			Error     *string `json:"error"`
+		} `json:"offsets"`
+	}
+	err := d.do(ctx, http.MethodPost, "/topics/"+url.PathEscape(topic), kafkaBinaryContentType,
+		map[string]any{"records": []kafkaRecord{record}}, &resp)
+	if err != nil {
+		return err
+	}
+	for _, offset := range resp.Offsets {
+		if offset.Error != nil {
+			return fmt.Errorf("kafka rejected the record: %s", *offset.Error)
+		}
+	}
+	return nil
+}
+
+func (d *KafkaDriver) Subscribe(ctx context.Context, topic string, group string, deliver func(ctx context.Context, message []byte) error) (Subscription, error) {
+	d.mu.Lock()
+	closed := d.closed
+	d.mu.Unlock()
+	if closed {
+		return nil, errClosed
+	}
+
+	subCtx, cancel := context.WithCancel(context.Background())
+	s := &kafkaSubscription{
+		driver:  d,
+		topic:   topic,
+		group:   group,
+		deliver: deliver,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+	if err := s.open(ctx); err != nil {
+		cancel()
+		return nil, err
+	}
+
+	d.mu.Lock()
+	d.subs[s] = struct{}{}
+	d.mu.Unlock()
+
+	go s.run(subCtx)
+	return s, nil
+}
+
+// Close unsubscribes every subscription, deleting their consumer instances
+func (d *KafkaDriver) Close() error {
+	d.mu.Lock()
+	d.closed = true
+	subs := make([]*kafkaSubscription, 0, len(d.subs))
+	for s := range d.subs {
+		subs = append(subs, s)
+	}
+	d.mu.Unlock()
+
+	var errs []error
+	for _, s := range subs {
+		errs = append(errs, s.Unsubscribe())
+	}
+	return errors.Join(errs...)
+}
+
+// do sends in as JSON to path and decodes the response into out, when not nil
+func (d *KafkaDriver) do(ctx context.Context, method string, path string, contentType string, in any, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	r, err := http.NewRequestWithContext(ctx, method, d.url+path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		r.Header.Set("Content-Type", contentType)
+	}
+	r.Header.Set("Accept", contentType)
+	for k, v := range d.headers {
+		r.Header.Set(k, v)
+	}
+
+	resp, err := d.client.Do(r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		kerr := &kafkaError{Status: resp.StatusCode}
+		_ = json.Unmarshal(data, kerr)
+		if exception.RetryClassFromHTTPStatus(resp.StatusCode) == exception.RetryPermanent {
+			return exception.MarkPermanent(kerr)
+		}
+		return kerr
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+// kafkaSubscription fetches the records of a consumer instance of its group
+type kafkaSubscription struct {
+	driver   *KafkaDriver
+	topic    string
+	group    string
+	deliver  func(ctx context.Context, message []byte) error
+	cancel   context.CancelFunc
+	done     chan struct{}
+	once     sync.Once
+	instance string
+}
+
+// open creates the consumer instance and subscribes it to the topic
+func (s *kafkaSubscription) open(ctx context.Context) error {
+	var created struct {
+		InstanceID string `json:"instance_id"`
+	}
+	err := s.driver.do(ctx, http.MethodPost, "/consumers/"+url.PathEscape(s.group), kafkaContentType, map[string]string{
+		"name":               uuid.NewString(),
+		"format":             "binary",
+		"auto.offset.reset":  "earliest",
+		"auto.commit.enable": "false",
+	}, &created)
+	if err != nil {
+		return fmt.Errorf("error creating kafka consumer: %w", err)
+	}
+	s.instance = "/consumers/" + url.PathEscape(s.group) + "/instances/" + url.PathEscape(created.InstanceID)
+
+	err = s.driver.do(ctx, http.MethodPost, s.instance+"/subscription", kafkaContentType,
+		map[string][]string{"topics": {s.topic}}, nil)
+	if err != nil {
+		s.close(ctx)
+		return fmt.Errorf("error subscribing kafka consumer: %w", err)
+	}
+	return nil
+}
+
+// close deletes the consumer instance, its partitions going to the other members of the group
+func (s *kafkaSubscription) close(ctx context.Context) error {
+	return s.driver.do(ctx, http.MethodDelete, s.instance, kafkaContentType, nil, nil)
+}
+
+func (s *kafkaSubscription) run(ctx context.Context) {
+	defer close(s.done)
+	for ctx.Err() == nil {
+		delivered, err := s.poll(ctx)
+		if err != nil && ctx.Err() == nil {
+			var kerr *kafkaError
+			if errors.As(err, &kerr) && kerr.Status == http.StatusNotFound {
+				// the proxy deleted the instance after it was idle too long
+				s.driver.logger.WarnContext(ctx, "Kafka consumer expired, recreating it", "topic", s.topic, "group", s.group)
+				err = s.open(ctx)
+			}
+			if err != nil {
+				s.driver.logger.ErrorContext(ctx, "Error consuming kafka records", "topic", s.topic, "group", s.group, "error", err)
+			}
+		}
+		if delivered && err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(s.driver.pollInterval):
+		}
+	}
+}
+
+// poll fetches records and delivers them in order, reporting whether there were any. After a
+// failed delivery, the partitions seek back to their first record not delivered.
+func (s *kafkaSubscription) poll(ctx context.Context) (bool, error) {
+	var records []kafkaRecord
+	path := s.instance + "/records?timeout=" + strconv.FormatInt(s.driver.pollInterval.Milliseconds(), 10)
+	if err := s.driver.do(ctx, http.MethodGet, path, kafkaBinaryContentType, nil, &records); err != nil {
+		return false, err
+	}
+
+	storeCtx := context.WithoutCancel(ctx)
+	for i, record := range records {
+		if err := s.deliver(ctx, record.Value); err != nil {
+			// not wrapped, a 404 of the dead-letter topic is not the one of the instance
+			return true, errors.Join(fmt.Errorf("error delivering kafka record: %v", err), s.seek(storeCtx, records[i:]))
+		}
+		// the proxy commits the offset following the one given
+		err := s.driver.do(storeCtx, http.MethodPost, s.instance+"/offsets", kafkaContentType, map[string][]kafkaOffset{
+			"offsets": {{Topic: record.Topic, Partition: record.Partition, Offset: record.Offset}},
+		}, nil)
+		if err != nil {
+			return true, fmt.Errorf("error committing kafka offset: %w", err)
+		}
+	}
+	return len(records) > 0, nil
+}
+
+// seek moves the partitions of records back to their first record
+func (s *kafkaSubscription) seek(ctx context.Context, records []kafkaRecord) error {
+	type partition struct {
+		topic string
+		id    int32
+	}
+	seen := map[partition]bool{}
+	var offsets []kafkaOffset
+	for _, record := range records {
+		p := partition{record.Topic, record.Partition}
+		if !seen[p] {
+			seen[p] = true
+			offsets = append(offsets, kafkaOffset{Topic: record.Topic, Partition: record.Partition, Offset: record.Offset})
+		}
+	}
+	err := s.driver.do(ctx, http.MethodPost, s.instance+"/positions", kafkaContentType, map[string][]kafkaOffset{"offsets": offsets}, nil)
+	if err != nil {
+		return fmt.Errorf("error seeking kafka consumer: %w", err)
+	}
+	return nil
+}
+
+func (s *kafkaSubscription) Unsubscribe() error {
+	var err error
+	s.once.Do(func() {
+		s.cancel()
+		<-s.done
+
+		s.driver.mu.Lock()
+		delete(s.driver.subs, s)
+		s.driver.mu.Unlock()
+
+		ctx, cancel := context.WithTimeout(context.Background(), s.driver.timeout)
+		defer cancel()
+		err = s.close(ctx)
+	})
+	return err
+}
@@ -0,0 +1,169 @@
+package eventbus
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryDriver carries messages within the process, for tests and single-instance deployments.
+// Messages are not kept: those published to a topic without subscribers, or queued for a
+// subscriber when it unsubscribes, are dropped.
+type MemoryDriver struct {
+	mu     sync.Mutex
+	topics map[string]map[string]*memoryGroup
+	closed bool
+}
+
+// memoryGroup hands the messages of a topic to its members in turn
+type memoryGroup struct {
+	members []*memorySubscription
+	next    int
+}
+
+// NewMemoryDriver creates a driver without subscriptions
+func NewMemoryDriver() *MemoryDriver {
+	return &MemoryDriver{topics: map[string]map[string]*memoryGroup{}}
+}
+
+func (d *MemoryDriver) Publish(ctx context.Context, topic string, key string, message []byte) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return errClosed
+	}
+
+	for _, group := range d.topics[topic] {
+		member := group.members[group.next%len(group.members)]
+		group.next++
+		member.push(message)
+	}
+	return nil
+}
+
+func (d *MemoryDriver) Subscribe(ctx context.Context, topic string, group string, deliver func(ctx context.Context, message []byte) error) (Subscription, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return nil, errClosed
+	}
+
+	subCtx, cancel := context.WithCancel(context.Background())
+	sub := &memorySubscription{
+		driver:  d,
+		topic:   topic,
+		group:   group,
+		cancel:  cancel,
+		signal:  make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		deliver: deliver,
+	}
+	groups, ok := d.topics[topic]
+	if !ok {
+		groups = map[string]*memoryGroup{}
+		d.topics[topic] = groups
+	}
+	if groups[group] == nil {
+		groups[group] = &memoryGroup{}
+	}
+	groups[group].members = append(groups[group].members, sub)
+
+	go sub.run(subCtx)
+	return sub, nil
+}
+
+// Close unsubscribes every subscription
+func (d *MemoryDriver) Close() error {
+	d.mu.Lock()
+	d.closed = true
+	var subs []*memorySubscription
+	for _, groups := range d.topics {
+		for _, group := range groups {
+			subs = append(subs, group.members...)
+		}
+	}
+	d.mu.Unlock()
+
+	for _, sub := range subs {
+		sub.Unsubscribe()
+	}
+	return nil
+}
+
+func (d *MemoryDriver) remove(sub *memorySubscription) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	group := d.topics[sub.topic][sub.group]
+	if group == nil {
+		return
+	}
+	for i, member := range group.members {
+		if member == sub {
+			group.members = append(group.members[:i], group.members[i+1:]...)
+			break
+		}
+	}
+	if len(group.members) == 0 {
+		delete(d.topics[sub.topic], sub.group)
+	}
+}
+
+// memorySubscription delivers its queue one message at a time
+type memorySubscription struct {
+	driver  *MemoryDriver
+	topic   string
+	group   string
+	deliver func(ctx context.Context, message []byte) error
+	cancel  context.CancelFunc
+	signal  chan struct{}
+	done    chan struct{}
+	once    sync.Once
+
+	mu    sync.Mutex
+	queue [][]byte
+}
+
+func (s *memorySubscription) push(message []byte) {
+	s.mu.Lock()
+	s.queue = append(s.queue, message)
+	s.mu.Unlock()
+
+	select {
+	case s.signal <- struct{}{}:
+	default:
+	}
+}
+
+func (s *memorySubscription) run(ctx context.Context) {
+	defer close(s.done)
+	for {
+		s.mu.Lock()
+		if len(s.queue) == 0 {
+			s.mu.Unlock()
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.signal:
+				continue
+			}
+		}
+		message := s.queue[0]
+		s.queue = s.queue[1:]
+		s.mu.Unlock()
+
+		if ctx.Err() != nil {
+			return
+		}
+		// nothing keeps the message, a failed delivery was already logged by the bus
+		_ = s.deliver(ctx, message)
+	}
+}
+
+func (s *memorySubscription) Unsubscribe() error {
+	s.once.Do(func() {
+		s.driver.remove(s)
+		s.cancel()
+		<-s.done
+	})
+	return nil
+}
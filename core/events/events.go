@@ -0,0 +1,180 @@
+// Package events dispatches domain events to the handlers registered within the process, so
+// services keep side effects such as cache invalidation or notifications out of their business
+// logic. Handlers run synchronously, in the order they were registered, or asynchronously; a
+// failing or panicking handler does not keep the others from running. Events meant for other
+// processes go through the eventbus package instead.
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/yourorg/go-api-template/core/exception"
+	"github.com/yourorg/go-api-template/core/pgdb"
+)
+
+// Event is a fact of the domain, e.g. an order was placed
+type Event interface {
+	// EventName names the kind of event the handlers are registered for, e.g. order.placed
+	EventName() string
+}
+
+// Handler handles an event
+type Handler func(ctx context.Context, event Event) error
+
+type registration struct {
+	name    string
+	handler Handler
+	async   bool
+}
+
+// HandlerOption customizes a registered handler
+type HandlerOption func(*registration)
+
+// Async runs the handler in its own goroutine, Dispatch not waiting for it; its errors are
+// logged. The handler gets the context of Dispatch without its cancellation.
+func Async() HandlerOption {
+	return func(r *registration) {
+		r.async = true
+	}
+}
+
+// Named names the handler in the logs
+func Named(name string) HandlerOption {
+	return func(r *registration) {
+		r.name = name
+	}
+}
+
+// Dispatcher runs the handlers registered for the events dispatched. It is safe for concurrent
+// use; the handlers registered during a Dispatch only see the next events.
+type Dispatcher struct {
+	mu       sync.RWMutex
+	handlers map[string][]registration
+	closed   bool
+	running  sync.WaitGroup
+	logger   *slog.Logger
+}
+
+// NewDispatcher returns a dispatcher without handlers; logger defaults to slog.Default
+func NewDispatcher(logger *slog.Logger) *Dispatcher {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Dispatcher{
+		handlers: map[string][]registration{},
+		logger:   logger.With("component", "events"),
+	}
+}
+
+// Register runs handler for the events of name, after the handlers registered before it. The
+// handler is named after the event and its rank in the logs, unless Named.
+func (d *Dispatcher) Register(name string, handler Handler, opts ...HandlerOption) {
+	reg := registration{handler: handler}
+	for _, opt := range opts {
+		opt(&reg)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if reg.name == "" {
+		reg.name = fmt.Sprintf("%s#%d", name, len(d.handlers[name])+1)
+	}
+	d.handlers[name] = append(d.handlers[name], reg)
+}
+
+// On registers handler for the events of type E, under the name returned by the EventName of
+// the zero E, which must not depend on the fields of the event
+func On[E Event](d *Dispatcher, handler func(ctx context.Context, event E) error, opts ...HandlerOption) {
+	var zero E
+	d.Register(zero.EventName(), func(ctx context.Context, event Event) error {
+		typed, ok := event.(E)
+		if !ok {
+			return fmt.Errorf("event %s is a %T, not a %T", event.EventName(), event, zero)
+		}
+		return handler(ctx, typed)
+	}, opts...)
+}
+
+// Dispatch runs the synchronous handlers of event in order and starts the asynchronous ones.
+// Every synchronous handler runs even when another fails; their errors are logged and joined.
+// Once the dispatcher is closed, the asynchronous handlers are skipped.
+func (d *Dispatcher) Dispatch(ctx context.Context, event Event) error {
+	d.mu.RLock()
+	handlers := d.handlers[event.EventName()]
+	closed := d.closed
+	if !closed {
+		for _, reg := range handlers {
+			if reg.async {
+				d.running.Add(1)
+			}
+		}
+	}
+	d.mu.RUnlock()
+
+	var errs []error
+	for _, reg := range handlers {
+		if !reg.async {
+			if err := d.run(ctx, reg, event); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", reg.name, err))
+			}
+			continue
+		}
+		if closed {
+			d.logger.WarnContext(ctx, "Dispatcher is closed, asynchronous handler skipped", "event", event.EventName(), "handler", reg.name)
+			continue
+		}
+		go func(reg registration) {
+			defer d.running.Done()
+			_ = d.run(context.WithoutCancel(ctx), reg, event)
+		}(reg)
+	}
+	return errors.Join(errs...)
+}
+
+// DispatchAfterCommit dispatches event once the transaction of ctx committed, see
+// pgdb.AfterCommit, or right away outside of a transaction. An event of a transaction rolled
+// back is dropped. The errors of the handlers are only logged, the transaction being over.
+func (d *Dispatcher) DispatchAfterCommit(ctx context.Context, event Event) {
+	pgdb.AfterCommit(ctx, func(ctx context.Context) {
+		_ = d.Dispatch(ctx, event)
+	})
+}
+
+// run calls the handler of reg, turning a panic into an error, and logs its failure
+func (d *Dispatcher) run(ctx context.Context, reg registration, event Event) (err error) {
+	start := time.Now()
+	defer func() {
+		if err != nil {
+			d.logger.ErrorContext(ctx, "Event handler failed", "event", event.EventName(), "handler", reg.name, "async", reg.async, "error", err)
+			return
+		}
+		d.logger.DebugContext(ctx, "Event handled", "event", event.EventName(), "handler", reg.name, "duration", time.Since(start))
+	}()
+	defer exception.Recover(ctx, &err)
+	return reg.handler(ctx, event)
+}
+
+// Close waits for the asynchronous handlers in progress until ctx is done; the events
+// dispatched afterwards only run the synchronous handlers
+func (d *Dispatcher) Close(ctx context.Context) error {
+	d.mu.Lock()
+	d.closed = true
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("asynchronous event handlers did not finish: %w", ctx.Err())
+	}
+}
@@ -1,7 +1,5 @@
 package exception
 
-import "net/http"
-
 type ApplicationErrors struct {
 	Debug        bool
 	MemberErrors *MockDataServiceErrors
@@ -9,7 +7,7 @@ type ApplicationErrors struct {
 
 func NewApplicationErrors() *ApplicationErrors {
 	return &ApplicationErrors{
-		Debug:        false,
+		Debug:        DebugEnabled(),
 		MemberErrors: NewMockDataServiceErrors(),
 	}
 }
@@ -21,21 +19,13 @@ type CommonApplicationErrors interface {
 	ThrowInvalidRequest() *ExceptionError
 }
 
-type MockDataServiceErrors struct {
-	CommonApplicationErrors
-	ErrUnauthorized     *ExceptionError
-	ErrPermissionDenied *ExceptionError
-	ErrNotFound         *ExceptionError
-	ErrUnableToProceed  *ExceptionError
-	ErrInvalidRequest   *ExceptionError
-}
+//go:generate go run ../../main.go errors generate --catalog catalog.yaml --out application_errors_gen.go --package exception --type MockDataServiceErrors
 
+// NewMockDataServiceErrors builds the application errors from the embedded catalog.yaml
 func NewMockDataServiceErrors() *MockDataServiceErrors {
-	return &MockDataServiceErrors{
-		ErrUnauthorized:     NewExceptionError(400, 200000, "Unauthorized", http.StatusUnauthorized),
-		ErrPermissionDenied: NewExceptionError(400, 200001, "Permission Denied (Forbidden error)", http.StatusForbidden),
-		ErrNotFound:         NewExceptionError(400, 200002, "Not found", http.StatusNotFound),
-		ErrUnableToProceed:  NewExceptionError(500, 209999, "Unable to proceed", http.StatusInternalServerError),
-		ErrInvalidRequest:   NewExceptionError(500, 210000, "Invalid Request", http.StatusInternalServerError),
+	errs, err := NewMockDataServiceErrorsFromCatalog(DefaultCatalog())
+	if err != nil {
+		panic(err)
 	}
+	return errs
 }
@@ -0,0 +1,171 @@
+package exception
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed catalog.yaml
+var defaultCatalogYAML []byte
+
+var (
+	defaultCatalog     *Catalog
+	defaultCatalogOnce sync.Once
+)
+
+var catalogNamePattern = regexp.MustCompile(`^[A-Z][A-Za-z0-9]*$`)
+
+// CatalogEntry is a single error definition in the catalog file
+type CatalogEntry struct {
+	Name       string            `yaml:"name"`
+	Code       int32             `yaml:"code"`
+	HTTPStatus int               `yaml:"httpStatus"`
+	APIStatus  int               `yaml:"apiStatus"`
+	Message    string            `yaml:"message"`
+	Messages   map[string]string `yaml:"messages"`
+	Retryable  bool              `yaml:"retryable"`
+	Temporary  bool              `yaml:"temporary"`
+}
+
+// Catalog holds the application errors loaded from a YAML file
+type Catalog struct {
+	Errors []CatalogEntry `yaml:"errors"`
+
+	byName map[string]*ExceptionError
+}
+
+// ParseCatalog parses and validates a catalog
+func ParseCatalog(data []byte) (*Catalog, error) {
+	var c Catalog
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("error parsing error catalog: %w", err)
+	}
+
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	c.byName = make(map[string]*ExceptionError, len(c.Errors))
+	for _, entry := range c.Errors {
+		cErr := NewExceptionError(entry.APIStatus, entry.Code, entry.Message, entry.HTTPStatus)
+		cErr.Messages = entry.Messages
+		cErr.Temporary = entry.Temporary
+		if entry.Retryable || entry.Temporary {
+			cErr.Retry = RetryRetryable
+		}
+		c.byName[entry.Name] = cErr
+	}
+
+	return &c, nil
+}
+
+// LoadCatalog reads a catalog file from disk
+func LoadCatalog(path string) (*Catalog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading error catalog: %w", err)
+	}
+	return ParseCatalog(data)
+}
+
+// DefaultCatalog returns the catalog embedded from catalog.yaml
+func DefaultCatalog() *Catalog {
+	defaultCatalogOnce.Do(func() {
+		c, err := ParseCatalog(defaultCatalogYAML)
+		if err != nil {
+			panic(fmt.Sprintf("invalid embedded error catalog: %v", err))
+		}
+		defaultCatalog = c
+	})
+	return defaultCatalog
+}
+
+// Validate checks names and codes are unique and statuses are valid
+func (c *Catalog) Validate() error {
+	if len(c.Errors) == 0 {
+		return errors.New("error catalog is empty")
+	}
+
+	var errs []error
+	names := make(map[string]bool, len(c.Errors))
+	codes := make(map[int32]string, len(c.Errors))
+
+	for i, entry := range c.Errors {
+		if !catalogNamePattern.MatchString(entry.Name) {
+			errs = append(errs, fmt.Errorf("errors[%d]: name %q must be an exported Go identifier", i, entry.Name))
+		}
+		if names[entry.Name] {
+			errs = append(errs, fmt.Errorf("errors[%d]: duplicate name %q", i, entry.Name))
+		}
+		names[entry.Name] = true
+
+		if other, ok := codes[entry.Code]; ok {
+			errs = append(errs, fmt.Errorf("errors[%d]: code %d of %q is already used by %q", i, entry.Code, entry.Name, other))
+		}
+		codes[entry.Code] = entry.Name
+
+		if http.StatusText(entry.HTTPStatus) == "" {
+			errs = append(errs, fmt.Errorf("errors[%d]: %q has invalid httpStatus %d", i, entry.Name, entry.HTTPStatus))
+		}
+		if strings.TrimSpace(entry.Message) == "" {
+			errs = append(errs, fmt.Errorf("errors[%d]: %q has no message", i, entry.Name))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Get returns a copy of the named error, or nil if the catalog has no such entry
+func (c *Catalog) Get(name string) *ExceptionError {
+	cErr, ok := c.byName[name]
+	if !ok {
+		return nil
+	}
+	return cErr.Copy()
+}
+
+// Translator returns the message of an error code in locale, ok false when it has none
+type Translator func(locale string, code int32) (message string, ok bool)
+
+var translator atomic.Pointer[Translator]
+
+// SetTranslator sets the messages LocalizedMessage falls back on for the locales missing from
+// the catalog messages of an error, e.g. i18n.Bundle.ErrorMessage; nil removes them
+func SetTranslator(t Translator) {
+	if t == nil {
+		translator.Store(nil)
+		return
+	}
+	translator.Store(&t)
+}
+
+// LocalizedMessage returns the message for locale (e.g. "th" or "th-TH"),
+// falling back to the base language, then the translator (see SetTranslator) and then GlobalMessage.
+func (cErr *ExceptionError) LocalizedMessage(locale string) string {
+	if locale == "" {
+		return cErr.GlobalMessage
+	}
+	if msg, ok := cErr.Messages[locale]; ok {
+		return msg
+	}
+	if base, _, found := strings.Cut(locale, "-"); found {
+		if msg, ok := cErr.Messages[base]; ok {
+			return msg
+		}
+	}
+	if t := translator.Load(); t != nil {
+		if msg, ok := (*t)(locale, cErr.Code); ok {
+			return msg
+		}
+	}
+	return cErr.GlobalMessage
+}
@@ -0,0 +1,78 @@
+package exception
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"text/template"
+)
+
+var accessorsTemplate = template.Must(template.New("accessors").Parse(`// Code generated by "main errors generate"; DO NOT EDIT.
+
+package {{ .Package }}
+
+import (
+	"fmt"
+{{- if .Qualifier }}
+
+	"github.com/yourorg/go-api-template/core/exception"
+{{- end }}
+)
+
+type {{ .Type }} struct {
+	{{ .Qualifier }}CommonApplicationErrors
+{{- range .Entries }}
+	Err{{ .Name }} *{{ $.Qualifier }}ExceptionError
+{{- end }}
+}
+
+// New{{ .Type }}FromCatalog builds the typed accessors from a catalog.
+// It fails when the catalog is missing any of the generated entries.
+func New{{ .Type }}FromCatalog(c *{{ .Qualifier }}Catalog) (*{{ .Type }}, error) {
+	var missing []string
+	get := func(name string) *{{ .Qualifier }}ExceptionError {
+		cErr := c.Get(name)
+		if cErr == nil {
+			missing = append(missing, name)
+		}
+		return cErr
+	}
+
+	errs := &{{ .Type }}{
+{{- range .Entries }}
+		Err{{ .Name }}: get("{{ .Name }}"),
+{{- end }}
+	}
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("error catalog is missing %v", missing)
+	}
+	return errs, nil
+}
+`))
+
+// GenerateAccessors renders Go source with a struct exposing one field per catalog entry.
+// typeName is the struct name and pkg the package the file is written to.
+func GenerateAccessors(c *Catalog, pkg string, typeName string) ([]byte, error) {
+	qualifier := ""
+	if pkg != "exception" {
+		qualifier = "exception."
+	}
+
+	var buf bytes.Buffer
+	err := accessorsTemplate.Execute(&buf, map[string]any{
+		"Package":   pkg,
+		"Type":      typeName,
+		"Qualifier": qualifier,
+		"Entries":   c.Errors,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error rendering accessors: %w", err)
+	}
+
+	formatted, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("error formatting accessors: %w", err)
+	}
+	return formatted, nil
+}
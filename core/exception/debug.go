@@ -0,0 +1,16 @@
+package exception
+
+import "sync/atomic"
+
+var debugMode atomic.Bool
+
+// SetDebug enables debug mode, which allows internal details such as panic values
+// in DebugMessage. It should stay off in production.
+func SetDebug(enabled bool) {
+	debugMode.Store(enabled)
+}
+
+// DebugEnabled reports whether debug mode is on
+func DebugEnabled() bool {
+	return debugMode.Load()
+}
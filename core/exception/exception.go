@@ -2,6 +2,7 @@ package exception
 
 import (
 	"encoding/json"
+	stderrors "errors"
 	"fmt"
 	"reflect"
 
@@ -28,8 +29,11 @@ type ExceptionError struct {
 	// ErrItems       []*ErrItem
 	ErrFields        []string
 	ErrWithDatas     map[string]string
+	Messages         map[string]string // per-locale messages from the error catalog, see LocalizedMessage
 	Level            Level
 	OverrideLogLevel bool
+	Retry            RetryClass // see ClassifyRetry
+	Temporary        bool
 
 	// This is used to store the stack trace of the error.
 	// This is not a field that will be marshalled to JSON.
@@ -44,6 +48,7 @@ func (cErr *ExceptionError) Error() string {
 	cpyCErr := cErr.Copy()
 	cpyCErr.StackErrors = nil
 	cpyCErr.DebugMessage = ""
+	cpyCErr.Messages = nil
 	strCpyCErr, err := json.Marshal(cpyCErr)
 	if err != nil {
 		return cErr.GlobalMessage
@@ -116,10 +121,55 @@ func (cErr *ExceptionError) WithMessage(globalMessage string) *ExceptionError {
 func (cErr *ExceptionError) WithDebugMessage(debugMessage string) *ExceptionError {
 	newErr := cErr.Copy()
 	newErr.DebugMessage = debugMessage
-	newErr.StackErrors = errors.New(newErr.GlobalMessage)
+	// keep the original cause if the error was already wrapped
+	if newErr.StackErrors == nil {
+		newErr.StackErrors = captureStack(stderrors.New(newErr.GlobalMessage), newErr.logLevel(), 1)
+	}
+	return newErr
+}
+
+// Wrap returns a copy of the ExceptionError with err as the underlying cause.
+// The stack of err is kept when it has one, otherwise it is captured here
+// according to the StackConfig depth for the error's log level.
+func (cErr *ExceptionError) Wrap(err error) *ExceptionError {
+	newErr := cErr.Copy()
+	if err == nil {
+		return newErr
+	}
+
+	var st stackTracer
+	if stderrors.As(err, &st) {
+		newErr.StackErrors = err
+	} else {
+		newErr.StackErrors = captureStack(err, newErr.logLevel(), 1)
+	}
 	return newErr
 }
 
+// Unwrap returns the underlying cause so errors.Is/As can see through ExceptionError
+func (cErr *ExceptionError) Unwrap() error {
+	return cErr.StackErrors
+}
+
+// Is reports whether target is an ExceptionError with the same Code,
+// so errors.Is(err, appErrors.ErrNotFound) matches copies made by With* and Wrap.
+func (cErr *ExceptionError) Is(target error) bool {
+	t, ok := target.(*ExceptionError)
+	if !ok || t == nil {
+		return false
+	}
+	return cErr.Code == t.Code
+}
+
+// AsExceptionError finds the first ExceptionError in err's chain
+func AsExceptionError(err error) (*ExceptionError, bool) {
+	var cErr *ExceptionError
+	if stderrors.As(err, &cErr) && cErr != nil {
+		return cErr, true
+	}
+	return nil, false
+}
+
 // This method creates a deep copy of the ExceptionError.
 func (cErr *ExceptionError) Copy() *ExceptionError {
 	// Copy primitive fields
@@ -149,7 +199,8 @@ type errorField struct {
 func GetStackField(err error) errorField {
 	var stack string
 
-	if serr, ok := err.(stackTracer); ok {
+	var serr stackTracer
+	if stderrors.As(err, &serr) {
 		st := serr.StackTrace()
 		stack = fmt.Sprintf("%+v", st)
 		if len(stack) > 0 && stack[0] == '\n' {
@@ -0,0 +1,171 @@
+package exception
+
+import (
+	"net/http"
+	"strconv"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCErrorDomain is the ErrorInfo domain attached to statuses built from ExceptionError
+var GRPCErrorDomain = "go-api-template"
+
+const grpcAPIStatusKey = "api_status"
+
+// GRPCCodeFromHTTP maps an HTTP status code to the closest gRPC code
+func GRPCCodeFromHTTP(httpStatusCode int) codes.Code {
+	switch httpStatusCode {
+	case http.StatusOK:
+		return codes.OK
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusPreconditionFailed:
+		return codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case 499: // client closed request
+		return codes.Canceled
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout, http.StatusRequestTimeout:
+		return codes.DeadlineExceeded
+	default:
+		if httpStatusCode >= 500 {
+			return codes.Internal
+		}
+		if httpStatusCode >= 400 {
+			return codes.InvalidArgument
+		}
+		return codes.Unknown
+	}
+}
+
+// HTTPStatusFromGRPC maps a gRPC code to an HTTP status code
+func HTTPStatusFromGRPC(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.FailedPrecondition:
+		return http.StatusPreconditionFailed
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// GRPCStatus converts the error to a gRPC status. The application code, API status
+// and ErrWithDatas are carried in an ErrorInfo detail and ErrFields in a BadRequest detail.
+// status.FromError and status.Code pick this up through wrapping.
+func (cErr *ExceptionError) GRPCStatus() *status.Status {
+	st := status.New(GRPCCodeFromHTTP(cErr.HttpStatusCode), cErr.GlobalMessage)
+
+	metadata := make(map[string]string, len(cErr.ErrWithDatas)+1)
+	for k, v := range cErr.ErrWithDatas {
+		metadata[k] = v
+	}
+	metadata[grpcAPIStatusKey] = strconv.Itoa(cErr.APIStatusCode)
+
+	withDetails, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason:   strconv.FormatInt(int64(cErr.Code), 10),
+		Domain:   GRPCErrorDomain,
+		Metadata: metadata,
+	})
+	if err != nil {
+		return st
+	}
+
+	if len(cErr.ErrFields) > 0 {
+		violations := make([]*errdetails.BadRequest_FieldViolation, 0, len(cErr.ErrFields))
+		for _, field := range cErr.ErrFields {
+			violations = append(violations, &errdetails.BadRequest_FieldViolation{Field: field})
+		}
+		if withFields, err := withDetails.WithDetails(&errdetails.BadRequest{FieldViolations: violations}); err == nil {
+			withDetails = withFields
+		}
+	}
+
+	return withDetails
+}
+
+// FromGRPCStatus rebuilds an ExceptionError from a status produced by GRPCStatus.
+// Statuses from other services are mapped by code with the status message as GlobalMessage.
+func FromGRPCStatus(st *status.Status) *ExceptionError {
+	httpStatusCode := HTTPStatusFromGRPC(st.Code())
+	cErr := NewExceptionError(httpStatusCode, 0, st.Message(), httpStatusCode)
+
+	for _, detail := range st.Details() {
+		switch d := detail.(type) {
+		case *errdetails.ErrorInfo:
+			if d.GetDomain() != GRPCErrorDomain {
+				continue
+			}
+			if code, err := strconv.ParseInt(d.GetReason(), 10, 32); err == nil {
+				cErr.Code = int32(code)
+			}
+			for k, v := range d.GetMetadata() {
+				if k == grpcAPIStatusKey {
+					if apiStatus, err := strconv.Atoi(v); err == nil {
+						cErr.APIStatusCode = apiStatus
+					}
+					continue
+				}
+				if cErr.ErrWithDatas == nil {
+					cErr.ErrWithDatas = make(map[string]string)
+				}
+				cErr.ErrWithDatas[k] = v
+			}
+		case *errdetails.BadRequest:
+			for _, violation := range d.GetFieldViolations() {
+				cErr.ErrFields = append(cErr.ErrFields, violation.GetField())
+			}
+		}
+	}
+
+	return cErr
+}
+
+// FromGRPCError converts an error returned by a gRPC client into an ExceptionError.
+// ExceptionErrors in the chain are returned as is; nil stays nil.
+func FromGRPCError(err error) *ExceptionError {
+	if err == nil {
+		return nil
+	}
+	if cErr, ok := AsExceptionError(err); ok {
+		return cErr
+	}
+
+	// FromError returns an Unknown status for non-gRPC errors
+	st, _ := status.FromError(err)
+	return FromGRPCStatus(st).Wrap(err)
+}
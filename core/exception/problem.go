@@ -0,0 +1,78 @@
+package exception
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ProblemContentType is the media type for RFC 7807 Problem Details
+const ProblemContentType = "application/problem+json"
+
+// ProblemDetails is the RFC 7807 representation of an error.
+// Extensions are rendered as top-level members next to the standard ones.
+type ProblemDetails struct {
+	Type       string
+	Title      string
+	Status     int
+	Detail     string
+	Instance   string
+	Extensions map[string]any
+}
+
+// reserved members that extensions must not overwrite
+var problemMembers = []string{"type", "title", "status", "detail", "instance"}
+
+// MarshalJSON flattens Extensions into the problem object
+func (p ProblemDetails) MarshalJSON() ([]byte, error) {
+	out := make(map[string]any, len(p.Extensions)+len(problemMembers))
+	for k, v := range p.Extensions {
+		out[k] = v
+	}
+
+	out["type"] = p.Type
+	if out["type"] == "" {
+		out["type"] = "about:blank"
+	}
+	out["title"] = p.Title
+	out["status"] = p.Status
+	if p.Detail != "" {
+		out["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		out["instance"] = p.Instance
+	}
+
+	return json.Marshal(out)
+}
+
+// ToProblem converts the ExceptionError into Problem Details.
+// typeBaseURI is joined with the error code to build "type"; when empty "about:blank" is used.
+// ErrWithDatas entries become extension members alongside code, api_status and fields.
+func (cErr *ExceptionError) ToProblem(typeBaseURI string, instance string) ProblemDetails {
+	problem := ProblemDetails{
+		Title:    cErr.GlobalMessage,
+		Status:   cErr.HttpStatusCode,
+		Instance: instance,
+		Extensions: map[string]any{
+			"code":       cErr.Code,
+			"api_status": cErr.APIStatusCode,
+		},
+	}
+
+	if problem.Status == 0 {
+		problem.Status = http.StatusInternalServerError
+	}
+	if typeBaseURI != "" {
+		problem.Type = fmt.Sprintf("%s/%d", strings.TrimSuffix(typeBaseURI, "/"), cErr.Code)
+	}
+	if len(cErr.ErrFields) > 0 {
+		problem.Extensions["fields"] = cErr.ErrFields
+	}
+	for k, v := range cErr.ErrWithDatas {
+		problem.Extensions[k] = v
+	}
+
+	return problem
+}
@@ -0,0 +1,70 @@
+package exception
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/pkg/errors"
+)
+
+// ErrPanic is the cause of errors produced by Recover, so callers can match them with errors.Is
+var ErrPanic = errors.New("panic recovered")
+
+// Recover converts a panic into an ErrUnableToProceed carrying the panic value and stack.
+// It must be deferred directly:
+//
+//	func (w *Worker) run(ctx context.Context) (err error) {
+//		defer exception.Recover(ctx, &err)
+//		...
+//	}
+//
+// When errp is nil the panic is only logged.
+// The panic value is put in DebugMessage only in debug mode (see SetDebug).
+func Recover(ctx context.Context, errp *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	cErr := FromPanic(r)
+	slog.ErrorContext(ctx, "Recovered from panic",
+		"panic", fmt.Sprint(r),
+		"stack", string(cErr.StackCaller),
+	)
+
+	if errp != nil {
+		*errp = cErr
+	}
+}
+
+// FromPanic builds the ExceptionError for a recovered panic value
+func FromPanic(r any) *ExceptionError {
+	cErr := unableToProceed()
+
+	cause, ok := r.(error)
+	if ok {
+		cause = fmt.Errorf("%w: %w", ErrPanic, cause)
+	} else {
+		cause = fmt.Errorf("%w: %v", ErrPanic, r)
+	}
+	// capture the stack here, inside the deferred call, so it includes the panicking frames
+	cErr.StackErrors = errors.WithStack(cause)
+	cErr.StackCaller = debug.Stack()
+
+	if DebugEnabled() {
+		cErr.DebugMessage = fmt.Sprint(r)
+	} else {
+		cErr.DebugMessage = ErrPanic.Error()
+	}
+	return cErr
+}
+
+func unableToProceed() *ExceptionError {
+	if cErr := DefaultCatalog().Get("UnableToProceed"); cErr != nil {
+		return cErr
+	}
+	return NewExceptionError(http.StatusInternalServerError, 209999, "Unable to proceed", http.StatusInternalServerError)
+}
@@ -0,0 +1,235 @@
+package exception
+
+import (
+	"context"
+	stderrors "errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/redis/go-redis/v9"
+)
+
+// RetryClass tells callers whether an operation that failed with an error may be retried
+type RetryClass int
+
+const (
+	// RetryUnknown means the error was not classified; callers apply their own default
+	RetryUnknown RetryClass = iota
+	// RetryRetryable means retrying the same operation may succeed
+	RetryRetryable
+	// RetryPermanent means retrying will fail the same way
+	RetryPermanent
+)
+
+// WithRetryable marks the error as safe to retry
+func (cErr *ExceptionError) WithRetryable() *ExceptionError {
+	newErr := cErr.Copy()
+	newErr.Retry = RetryRetryable
+	return newErr
+}
+
+// WithPermanent marks the error as not worth retrying
+func (cErr *ExceptionError) WithPermanent() *ExceptionError {
+	newErr := cErr.Copy()
+	newErr.Retry = RetryPermanent
+	return newErr
+}
+
+// WithTemporary marks the error as a transient condition, which also makes it retryable
+func (cErr *ExceptionError) WithTemporary() *ExceptionError {
+	newErr := cErr.Copy()
+	newErr.Temporary = true
+	if newErr.Retry == RetryUnknown {
+		newErr.Retry = RetryRetryable
+	}
+	return newErr
+}
+
+type retryMarker struct {
+	err       error
+	class     RetryClass
+	temporary bool
+}
+
+func (m *retryMarker) Error() string { return m.err.Error() }
+func (m *retryMarker) Unwrap() error { return m.err }
+
+// MarkRetryable wraps err so ClassifyRetry reports it as retryable
+func MarkRetryable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &retryMarker{err: err, class: RetryRetryable}
+}
+
+// MarkTemporary wraps err so IsTemporary and IsRetryable report true
+func MarkTemporary(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &retryMarker{err: err, class: RetryRetryable, temporary: true}
+}
+
+// MarkPermanent wraps err so ClassifyRetry reports it as permanent
+func MarkPermanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &retryMarker{err: err, class: RetryPermanent}
+}
+
+// IsRetryable reports whether err was classified as retryable
+func IsRetryable(err error) bool {
+	return ClassifyRetry(err) == RetryRetryable
+}
+
+// IsPermanent reports whether err was classified as not worth retrying
+func IsPermanent(err error) bool {
+	return ClassifyRetry(err) == RetryPermanent
+}
+
+// IsTemporary reports whether err is a transient condition such as a timeout,
+// a dropped connection or a server that is starting up.
+func IsTemporary(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var cErr *ExceptionError
+	if stderrors.As(err, &cErr) && cErr.Temporary {
+		return true
+	}
+	var marker *retryMarker
+	if stderrors.As(err, &marker) && marker.temporary {
+		return true
+	}
+
+	return isTransient(err)
+}
+
+// ClassifyRetry classifies err for retry decisions. Explicit markers (ExceptionError.Retry,
+// MarkRetryable, MarkPermanent) win over the built-in rules for context, net, pgx and redis errors.
+func ClassifyRetry(err error) RetryClass {
+	if err == nil {
+		return RetryUnknown
+	}
+
+	// the outermost explicit marker wins
+	for e := err; e != nil; e = stderrors.Unwrap(e) {
+		switch v := e.(type) {
+		case *ExceptionError:
+			if v.Retry != RetryUnknown {
+				return v.Retry
+			}
+			if v.Temporary {
+				return RetryRetryable
+			}
+		case *retryMarker:
+			return v.class
+		}
+	}
+
+	if stderrors.Is(err, context.Canceled) || stderrors.Is(err, redis.Nil) {
+		return RetryPermanent
+	}
+
+	var pgErr *pgconn.PgError
+	if stderrors.As(err, &pgErr) {
+		return classifyPgCode(pgErr.Code)
+	}
+
+	if isTransient(err) || pgconn.SafeToRetry(err) {
+		return RetryRetryable
+	}
+
+	return RetryUnknown
+}
+
+// RetryClassFromHTTPStatus classifies an upstream HTTP response status
+func RetryClassFromHTTPStatus(statusCode int) RetryClass {
+	switch {
+	case statusCode == http.StatusRequestTimeout,
+		statusCode == http.StatusTooEarly,
+		statusCode == http.StatusTooManyRequests,
+		statusCode == http.StatusBadGateway,
+		statusCode == http.StatusServiceUnavailable,
+		statusCode == http.StatusGatewayTimeout:
+		return RetryRetryable
+	case statusCode >= 400 && statusCode < 500:
+		return RetryPermanent
+	default:
+		return RetryUnknown
+	}
+}
+
+// isTransient matches timeouts and connection level failures
+func isTransient(err error) bool {
+	if stderrors.Is(err, context.DeadlineExceeded) ||
+		stderrors.Is(err, io.ErrUnexpectedEOF) ||
+		stderrors.Is(err, syscall.ECONNREFUSED) ||
+		stderrors.Is(err, syscall.ECONNRESET) ||
+		stderrors.Is(err, syscall.EPIPE) {
+		return true
+	}
+
+	if pgconn.Timeout(err) {
+		return true
+	}
+
+	var netErr net.Error
+	if stderrors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	var pgErr *pgconn.PgError
+	if stderrors.As(err, &pgErr) {
+		return isTransientPgCode(pgErr.Code)
+	}
+
+	var redisErr redis.Error
+	if stderrors.As(err, &redisErr) {
+		return isTransientRedisError(redisErr.Error())
+	}
+
+	return false
+}
+
+// classifyPgCode classifies a Postgres SQLSTATE
+// (https://www.postgresql.org/docs/current/errcodes-appendix.html)
+func classifyPgCode(code string) RetryClass {
+	switch {
+	case code == "40001", // serialization_failure
+		code == "40P01", // deadlock_detected
+		code == "55P03", // lock_not_available
+		isTransientPgCode(code):
+		return RetryRetryable
+	case strings.HasPrefix(code, "22"), // data exception
+		strings.HasPrefix(code, "23"), // integrity constraint violation
+		strings.HasPrefix(code, "42"): // syntax error or access rule violation
+		return RetryPermanent
+	default:
+		return RetryUnknown
+	}
+}
+
+func isTransientPgCode(code string) bool {
+	return strings.HasPrefix(code, "08") || // connection exception
+		strings.HasPrefix(code, "53") || // insufficient resources
+		code == "57P01" || // admin_shutdown
+		code == "57P02" || // crash_shutdown
+		code == "57P03" // cannot_connect_now
+}
+
+// isTransientRedisError matches the error prefixes Redis uses for transient server states
+func isTransientRedisError(msg string) bool {
+	for _, prefix := range []string{"LOADING ", "READONLY ", "CLUSTERDOWN ", "TRYAGAIN ", "MASTERDOWN ", "BUSY "} {
+		if strings.HasPrefix(msg, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+package exception
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+)
+
+// StackConfig sets how many frames are captured for errors logged at each level.
+// 0 disables capture for that level; only the program counters are recorded
+// and frames are resolved when the stack is formatted for logging.
+type StackConfig struct {
+	Error int `mapstructure:"error"`
+	Warn  int `mapstructure:"warn"`
+	Info  int `mapstructure:"info"`
+	Debug int `mapstructure:"debug"`
+}
+
+// DefaultStackConfig captures stacks only for errors logged at error level
+func DefaultStackConfig() StackConfig {
+	return StackConfig{Error: 32}
+}
+
+var stackConfig atomic.Pointer[StackConfig]
+
+// SetStackConfig sets the stack capture depth per level; a zero StackConfig
+// disables capture at every level. The config file gets DefaultStackConfig
+// from the defaults of the errorStack section.
+func SetStackConfig(cfg StackConfig) {
+	stackConfig.Store(&cfg)
+}
+
+func stackDepth(level Level) int {
+	cfg := DefaultStackConfig()
+	if stored := stackConfig.Load(); stored != nil {
+		cfg = *stored
+	}
+
+	switch level {
+	case LevelDebug:
+		return cfg.Debug
+	case LevelInfo:
+		return cfg.Info
+	case LevelWarn:
+		return cfg.Warn
+	default:
+		return cfg.Error
+	}
+}
+
+// logLevel is the level the canonical logger will use for the error
+func (cErr *ExceptionError) logLevel() Level {
+	if cErr.OverrideLogLevel {
+		return cErr.Level
+	}
+	return LevelError
+}
+
+// WithLevel returns a copy logged at level instead of error level.
+// Set it before WithDebugMessage or Wrap so the stack depth for level applies.
+func (cErr *ExceptionError) WithLevel(level Level) *ExceptionError {
+	newErr := cErr.Copy()
+	newErr.Level = level
+	newErr.OverrideLogLevel = true
+	return newErr
+}
+
+// withStack records the callers of err without resolving them
+type withStack struct {
+	err error
+	pcs []uintptr
+}
+
+func (w *withStack) Error() string { return w.err.Error() }
+func (w *withStack) Unwrap() error { return w.err }
+
+// StackTrace implements the pkg/errors stackTracer interface used by GetStackField
+func (w *withStack) StackTrace() errors.StackTrace {
+	frames := make(errors.StackTrace, len(w.pcs))
+	for i, pc := range w.pcs {
+		frames[i] = errors.Frame(pc)
+	}
+	return frames
+}
+
+func (w *withStack) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			fmt.Fprintf(s, "%+v", w.err)
+			w.StackTrace().Format(s, verb)
+			return
+		}
+		fallthrough
+	case 's':
+		io.WriteString(s, w.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", w.Error())
+	}
+}
+
+// captureStack attaches the caller's stack to err using the depth configured for level.
+// skip is the number of frames between the public method and this function.
+func captureStack(err error, level Level, skip int) error {
+	depth := stackDepth(level)
+	if depth <= 0 {
+		return err
+	}
+
+	pcs := make([]uintptr, depth)
+	// skip runtime.Callers, captureStack and the callers inside this package
+	n := runtime.Callers(skip+2, pcs)
+	return &withStack{err: err, pcs: pcs[:n]}
+}
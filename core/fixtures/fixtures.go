@@ -0,0 +1,250 @@
+// Package fixtures loads named sets of seed data into Postgres so integration
+// environments and demos can be reset to a known state.
+//
+// A set is the directory fixtures/<set>, whose files run in name order: .sql
+// files run as they are and .yaml/.yml files map tables to the rows to insert,
+// e.g.
+//
+//	users:
+//	  - email: demo@example.com
+//	    roles: [admin]
+//	products:
+//	  - sku: DEMO-1
+//	    metadata: {color: red}
+//
+// Lists of strings are inserted as arrays, other lists and maps as JSON.
+package fixtures
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrUnknownSet is returned when a set has no directory
+var ErrUnknownSet = errors.New("unknown fixture set")
+
+// setName keeps set names to a single directory of the fixtures root
+var setName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Execer runs statements, satisfied by pgx.Tx, *pgx.Conn and *pgxpool.Pool
+type Execer interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+// Statement is a single statement of a fixture file
+type Statement struct {
+	Source string
+	SQL    string
+	Args   []any
+}
+
+// Set is a loaded fixture set
+type Set struct {
+	Name       string
+	Statements []Statement
+	// Tables are the tables filled by the YAML files of the set, in load order
+	Tables []string
+}
+
+// List returns the names of the sets of root, sorted
+func List(root string) ([]string, error) {
+	entries, err := os.ReadDir(root)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() && setName.MatchString(entry.Name()) {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+// Load reads the set name of root
+func Load(root string, name string) (*Set, error) {
+	if !setName.MatchString(name) {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownSet, name)
+	}
+
+	dir := filepath.Join(root, name)
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s, no directory %s", ErrUnknownSet, name, dir)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	set := &Set{Name: name}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+
+		switch filepath.Ext(entry.Name()) {
+		case ".sql":
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+			set.Statements = append(set.Statements, Statement{Source: path, SQL: string(content)})
+		case ".yaml", ".yml":
+			if err := set.loadYAML(path); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(set.Statements) == 0 && len(set.Tables) == 0 {
+		return nil, fmt.Errorf("fixture set %s has no .sql or .yaml files", name)
+	}
+	return set, nil
+}
+
+// loadYAML appends an insert per row of the tables of path
+func (s *Set) loadYAML(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	// Decode into a node first to keep the tables in file order
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+	tables := doc.Content[0]
+	if tables.Kind != yaml.MappingNode {
+		return fmt.Errorf("%s: expected a mapping of tables to rows", path)
+	}
+
+	for i := 0; i < len(tables.Content); i += 2 {
+		table := tables.Content[i].Value
+		var rows []map[string]any
+		if err := tables.Content[i+1].Decode(&rows); err != nil {
+			return fmt.Errorf("%s: %s: expected a list of rows: %w", path, table, err)
+		}
+
+		if !slices.Contains(s.Tables, table) {
+			s.Tables = append(s.Tables, table)
+		}
+		for n, row := range rows {
+			statement, err := insert(table, row)
+			if err != nil {
+				return fmt.Errorf("%s: %s row %d: %w", path, table, n+1, err)
+			}
+			statement.Source = path
+			s.Statements = append(s.Statements, statement)
+		}
+	}
+	return nil
+}
+
+// insert builds the INSERT of row into table, columns in name order
+func insert(table string, row map[string]any) (Statement, error) {
+	if len(row) == 0 {
+		return Statement{}, errors.New("row has no columns")
+	}
+
+	columns := make([]string, 0, len(row))
+	for column := range row {
+		columns = append(columns, column)
+	}
+	slices.Sort(columns)
+
+	quoted := make([]string, len(columns))
+	placeholders := make([]string, len(columns))
+	args := make([]any, len(columns))
+	for i, column := range columns {
+		quoted[i] = pgx.Identifier{column}.Sanitize()
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+
+		value, err := columnValue(row[column])
+		if err != nil {
+			return Statement{}, fmt.Errorf("%s: %w", column, err)
+		}
+		args[i] = value
+	}
+
+	return Statement{
+		SQL: fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+			identifier(table), strings.Join(quoted, ", "), strings.Join(placeholders, ", ")),
+		Args: args,
+	}, nil
+}
+
+// columnValue converts YAML lists of strings to arrays and other lists and maps to JSON
+func columnValue(value any) (any, error) {
+	switch v := value.(type) {
+	case []any:
+		strs := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return jsonValue(v)
+			}
+			strs = append(strs, s)
+		}
+		return strs, nil
+	case map[string]any:
+		return jsonValue(v)
+	default:
+		return v, nil
+	}
+}
+
+func jsonValue(value any) (string, error) {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// identifier quotes a table name that may be schema qualified
+func identifier(table string) string {
+	return pgx.Identifier(strings.Split(table, ".")).Sanitize()
+}
+
+// Apply runs the statements of the set on db, emptying the tables of its YAML
+// files first when reset is set. Run it in a transaction so a failing set
+// leaves nothing behind.
+func (s *Set) Apply(ctx context.Context, db Execer, reset bool) error {
+	if reset && len(s.Tables) > 0 {
+		tables := make([]string, len(s.Tables))
+		for i, table := range s.Tables {
+			tables[i] = identifier(table)
+		}
+		if _, err := db.Exec(ctx, "TRUNCATE "+strings.Join(tables, ", ")+" RESTART IDENTITY CASCADE"); err != nil {
+			return fmt.Errorf("failed to reset tables: %w", err)
+		}
+	}
+
+	for _, statement := range s.Statements {
+		if _, err := db.Exec(ctx, statement.SQL, statement.Args...); err != nil {
+			return fmt.Errorf("%s: %w", statement.Source, err)
+		}
+	}
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/yourorg/go-api-template/core/lifecycle"
 	"github.com/yourorg/go-api-template/core/logger"
 )
 
@@ -42,6 +43,8 @@ type HealthResponse struct {
 // SystemInfo represents system information
 type SystemInfo struct {
 	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildTime string `json:"build_time,omitempty"`
 	GoVersion string `json:"go_version"`
 	NumCPU    int    `json:"num_cpu"`
 	NumGoroutines int    `json:"num_goroutines"`
@@ -61,17 +64,24 @@ type Checker interface {
 	Check(ctx context.Context) ComponentHealth
 }
 
+// BuildInfo describes the running binary in health responses
+type BuildInfo struct {
+	Version string
+	Commit  string
+	Time    string
+}
+
 // HealthService manages health checks
 type HealthService struct {
 	checkers map[string]Checker
-	version  string
+	build    BuildInfo
 }
 
-// NewHealthService creates a new health service
-func NewHealthService(version string) *HealthService {
+// NewHealthService creates a new health service reporting build
+func NewHealthService(build BuildInfo) *HealthService {
 	return &HealthService{
 		checkers: make(map[string]Checker),
-		version:  version,
+		build:    build,
 	}
 }
 
@@ -102,9 +112,9 @@ func (hs *HealthService) Check(ctx context.Context) HealthResponse {
 	return HealthResponse{
 		Status:     overallStatus,
 		Timestamp:  start,
-		Version:    hs.version,
+		Version:    hs.build.Version,
 		Components: components,
-		System:     getSystemInfo(),
+		System:     hs.getSystemInfo(),
 	}
 }
 
@@ -113,8 +123,8 @@ func (hs *HealthService) Liveness(ctx context.Context) HealthResponse {
 	return HealthResponse{
 		Status:    StatusHealthy,
 		Timestamp: time.Now(),
-		Version:   hs.version,
-		System:    getSystemInfo(),
+		Version:   hs.build.Version,
+		System:    hs.getSystemInfo(),
 	}
 }
 
@@ -124,6 +134,17 @@ func (hs *HealthService) Readiness(ctx context.Context) HealthResponse {
 	criticalComponents := make(map[string]ComponentHealth)
 	overallStatus := StatusHealthy
 
+	// A process draining before shutdown must not receive new traffic
+	if lifecycle.ShuttingDown() {
+		criticalComponents["shutdown"] = ComponentHealth{
+			Name:      "shutdown",
+			Status:    StatusUnhealthy,
+			Message:   "Server is shutting down",
+			Timestamp: time.Now(),
+		}
+		overallStatus = StatusUnhealthy
+	}
+
 	// Check only critical components for readiness
 	for name, checker := range hs.checkers {
 		// Only check database for readiness (Redis is not critical for serving traffic)
@@ -140,19 +161,21 @@ func (hs *HealthService) Readiness(ctx context.Context) HealthResponse {
 	return HealthResponse{
 		Status:     overallStatus,
 		Timestamp:  time.Now(),
-		Version:    hs.version,
+		Version:    hs.build.Version,
 		Components: criticalComponents,
-		System:     getSystemInfo(),
+		System:     hs.getSystemInfo(),
 	}
 }
 
 // getSystemInfo returns system information
-func getSystemInfo() SystemInfo {
+func (hs *HealthService) getSystemInfo() SystemInfo {
 	var m runtime.MemStats
 	runtime.ReadMemStats(&m)
 
 	return SystemInfo{
-		Version:       "1.0.0", // This could be injected during build
+		Version:       hs.build.Version,
+		Commit:        hs.build.Commit,
+		BuildTime:     hs.build.Time,
 		GoVersion:     runtime.Version(),
 		NumCPU:        runtime.NumCPU(),
 		NumGoroutines: runtime.NumGoroutine(),
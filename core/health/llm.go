@@ -0,0 +1,68 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/yourorg/go-api-template/core/httpclient/models"
+)
+
+// ModelChecker checks that the LLM server is reachable and serves the configured model
+type ModelChecker struct {
+	client models.ModelsServiceClient
+	model  string
+}
+
+// NewModelChecker creates a new LLM model checker
+func NewModelChecker(client models.ModelsServiceClient, model string) *ModelChecker {
+	return &ModelChecker{client: client, model: model}
+}
+
+// Check implements the Checker interface for the LLM server
+func (mc *ModelChecker) Check(ctx context.Context) ComponentHealth {
+	start := time.Now()
+
+	listCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	list, err := mc.client.ListModels(listCtx)
+	duration := time.Since(start)
+
+	if err != nil {
+		slog.ErrorContext(ctx, "LLM health check failed", "error", err.Error())
+		return ComponentHealth{
+			Name:      "llm",
+			Status:    StatusUnhealthy,
+			Message:   fmt.Sprintf("Listing models failed: %v", err),
+			Timestamp: start,
+			Duration:  duration,
+		}
+	}
+
+	details := map[string]string{
+		"model":  mc.model,
+		"models": fmt.Sprintf("%d", len(list.Data)),
+	}
+
+	if mc.model != "" && !list.Has(mc.model) {
+		return ComponentHealth{
+			Name:      "llm",
+			Status:    StatusUnhealthy,
+			Message:   fmt.Sprintf("Model %s is not loaded", mc.model),
+			Details:   details,
+			Timestamp: start,
+			Duration:  duration,
+		}
+	}
+
+	return ComponentHealth{
+		Name:      "llm",
+		Status:    StatusHealthy,
+		Message:   "LLM server is healthy",
+		Details:   details,
+		Timestamp: start,
+		Duration:  duration,
+	}
+}
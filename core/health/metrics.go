@@ -0,0 +1,64 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const meterName = "github.com/yourorg/go-api-template/core/health"
+
+var (
+	exported     atomic.Pointer[HealthService]
+	exportedOnce sync.Once
+)
+
+// statusValues of the health.component.status gauge
+var statusValues = map[Status]float64{
+	StatusHealthy:   1,
+	StatusDegraded:  0.5,
+	StatusUnhealthy: 0,
+}
+
+// ExportMetrics runs the checks of hs at each metric collection and reports every component
+// as the health.component.status gauge, 1 healthy, 0.5 degraded and 0 unhealthy, and the
+// duration of its check. The last service exported is reported.
+func (hs *HealthService) ExportMetrics() {
+	exported.Store(hs)
+	exportedOnce.Do(func() {
+		// instruments from the global meter follow a provider installed later
+		meter := otel.Meter(meterName)
+		status, err := meter.Float64ObservableGauge(
+			"health.component.status",
+			metric.WithDescription("Health of a dependency, 1 healthy, 0.5 degraded and 0 unhealthy"),
+			metric.WithUnit("1"),
+		)
+		if err != nil {
+			return
+		}
+		duration, err := meter.Float64ObservableGauge(
+			"health.component.check.duration",
+			metric.WithDescription("Duration of the last health check of a dependency"),
+			metric.WithUnit("s"),
+		)
+		if err != nil {
+			return
+		}
+		meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+			hs := exported.Load()
+			if hs == nil {
+				return nil
+			}
+			for name, component := range hs.Check(ctx).Components {
+				attrs := metric.WithAttributes(attribute.String("health.component", name))
+				o.ObserveFloat64(status, statusValues[component.Status], attrs)
+				o.ObserveFloat64(duration, component.Duration.Seconds(), attrs)
+			}
+			return nil
+		}, status, duration)
+	})
+}
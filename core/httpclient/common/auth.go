@@ -0,0 +1,263 @@
+package common
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	core_config "github.com/yourorg/go-api-template/core/config"
+)
+
+const (
+	AuthTypeNone   = "none"
+	AuthTypeAPIKey = "apiKey"
+	AuthTypeBearer = "bearer"
+	AuthTypeBasic  = "basic"
+	AuthTypeOAuth2 = "oauth2"
+)
+
+// DefaultAPIKeyHeader is used by API key auth when no header is configured
+const DefaultAPIKeyHeader = "X-API-Key"
+
+// tokenExpiryLeeway refreshes OAuth2 tokens a little before they expire
+const tokenExpiryLeeway = 30 * time.Second
+
+// TokenProvider adds credentials to an outbound request. It is called for every
+// attempt, so implementations can refresh expired tokens.
+type TokenProvider interface {
+	Authorize(ctx context.Context, r *http.Request) error
+}
+
+// APIKeyProvider sends a static key in a header, e.g. "api-key" for Azure OpenAI
+type APIKeyProvider struct {
+	Header string
+	Key    string
+}
+
+func NewAPIKeyProvider(header, key string) *APIKeyProvider {
+	if header == "" {
+		header = DefaultAPIKeyHeader
+	}
+	return &APIKeyProvider{Header: header, Key: key}
+}
+
+func (p *APIKeyProvider) Authorize(_ context.Context, r *http.Request) error {
+	r.Header.Set(p.Header, p.Key)
+	return nil
+}
+
+// StaticTokenProvider sends a fixed Authorization header
+type StaticTokenProvider struct {
+	Type  TokenType
+	Token string
+}
+
+func NewBearerTokenProvider(token string) *StaticTokenProvider {
+	return &StaticTokenProvider{Type: Bearer, Token: token}
+}
+
+func NewBasicTokenProvider(username, password string) *StaticTokenProvider {
+	return &StaticTokenProvider{Type: Basic, Token: base64.StdEncoding.EncodeToString([]byte(username + ":" + password))}
+}
+
+func (p *StaticTokenProvider) Authorize(_ context.Context, r *http.Request) error {
+	r.Header.Set("Authorization", p.Type.String()+" "+p.Token)
+	return nil
+}
+
+func (t TokenType) String() string {
+	switch t {
+	case Bearer:
+		return "Bearer"
+	case Basic:
+		return "Basic"
+	default:
+		return fmt.Sprintf("TokenType(%d)", int(t))
+	}
+}
+
+// ClientCredentialsProvider implements the OAuth2 client credentials grant.
+// The token is cached until shortly before it expires, or until the endpoint answers 401.
+type ClientCredentialsProvider struct {
+	tokenURL     string
+	clientID     string
+	clientSecret string
+	scopes       []string
+	httpClient   *http.Client
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+func NewClientCredentialsProvider(tokenURL, clientID, clientSecret string, scopes []string, httpClient *http.Client) *ClientCredentialsProvider {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &ClientCredentialsProvider{
+		tokenURL:     tokenURL,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		scopes:       scopes,
+		httpClient:   httpClient,
+	}
+}
+
+func (p *ClientCredentialsProvider) Authorize(ctx context.Context, r *http.Request) error {
+	token, err := p.Token(ctx)
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// Token returns the cached access token, fetching a new one when needed
+func (p *ClientCredentialsProvider) Token(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.token != "" && time.Now().Before(p.expires) {
+		return p.token, nil
+	}
+
+	token, expiresIn, err := p.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	p.token = token
+	p.expires = time.Now().Add(expiresIn - tokenExpiryLeeway)
+	return p.token, nil
+}
+
+// Invalidate drops the cached token so the next request fetches a new one
+func (p *ClientCredentialsProvider) Invalidate() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.token = ""
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+func (p *ClientCredentialsProvider) fetch(ctx context.Context) (string, time.Duration, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(p.scopes) > 0 {
+		form.Set("scope", strings.Join(p.scopes, " "))
+	}
+
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, p.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, err
+	}
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Set("Accept", "application/json")
+	r.SetBasicAuth(url.QueryEscape(p.clientID), url.QueryEscape(p.clientSecret))
+
+	resp, err := p.httpClient.Do(r)
+	if err != nil {
+		return "", 0, fmt.Errorf("error requesting oauth2 token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", 0, fmt.Errorf("error reading oauth2 token: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, TransportError{
+			Code:        resp.StatusCode,
+			Description: fmt.Sprintf("got %d response from %s is %s", resp.StatusCode, p.tokenURL, body),
+		}
+	}
+
+	var token tokenResponse
+	if err := json.Unmarshal(body, &token); err != nil {
+		return "", 0, fmt.Errorf("error decoding oauth2 token: %w", err)
+	}
+	if token.AccessToken == "" {
+		return "", 0, errors.New("oauth2 token response has no access_token")
+	}
+
+	expiresIn := time.Duration(token.ExpiresIn) * time.Second
+	if expiresIn <= tokenExpiryLeeway {
+		// no or very short expiry: cache briefly rather than not at all
+		expiresIn = 2 * tokenExpiryLeeway
+	}
+	return token.AccessToken, expiresIn, nil
+}
+
+// AuthTransport authorizes every request with Provider before passing it to Base
+type AuthTransport struct {
+	Base     http.RoundTripper
+	Provider TokenProvider
+}
+
+func NewAuthTransport(base http.RoundTripper, provider TokenProvider) *AuthTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &AuthTransport{Base: base, Provider: provider}
+}
+
+func (t *AuthTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	// a RoundTripper must not modify the caller's request
+	req := r.Clone(r.Context())
+	if err := t.Provider.Authorize(req.Context(), req); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, fmt.Errorf("error authorizing request: %w", err)
+	}
+
+	resp, err := t.Base.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized {
+		if invalidator, ok := t.Provider.(interface{ Invalidate() }); ok {
+			invalidator.Invalidate()
+		}
+	}
+	return resp, err
+}
+
+// TokenProviderFromConfig builds the provider for cfg; it returns nil when no auth is configured.
+// OAuth2 token requests go through base, without the circuit breaker or retries.
+func TokenProviderFromConfig(cfg core_config.OutboundAuthConfig, base http.RoundTripper) (TokenProvider, error) {
+	switch cfg.Type {
+	case "", AuthTypeNone:
+		return nil, nil
+	case AuthTypeAPIKey:
+		if cfg.Token == "" {
+			return nil, errors.New("auth apiKey: token is empty")
+		}
+		return NewAPIKeyProvider(cfg.Header, cfg.Token), nil
+	case AuthTypeBearer:
+		if cfg.Token == "" {
+			return nil, errors.New("auth bearer: token is empty")
+		}
+		return NewBearerTokenProvider(cfg.Token), nil
+	case AuthTypeBasic:
+		if cfg.Username == "" {
+			return nil, errors.New("auth basic: username is empty")
+		}
+		return NewBasicTokenProvider(cfg.Username, cfg.Password), nil
+	case AuthTypeOAuth2:
+		if cfg.TokenURL == "" || cfg.ClientID == "" || cfg.ClientSecret == "" {
+			return nil, errors.New("auth oauth2: tokenUrl, clientId and clientSecret are required")
+		}
+		httpClient := &http.Client{Timeout: 30 * time.Second, Transport: base}
+		return NewClientCredentialsProvider(cfg.TokenURL, cfg.ClientID, cfg.ClientSecret, cfg.Scopes, httpClient), nil
+	default:
+		return nil, fmt.Errorf("unknown auth type %q", cfg.Type)
+	}
+}
@@ -0,0 +1,232 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	core_config "github.com/yourorg/go-api-template/core/config"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// ErrCircuitOpen is returned without calling the host while its circuit is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+type CircuitState int
+
+const (
+	CircuitClosed CircuitState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// CircuitBreakerPolicy controls when a host's circuit opens and how it recovers
+type CircuitBreakerPolicy struct {
+	// FailureThreshold consecutive failures open the circuit
+	FailureThreshold int
+	// OpenDuration is how long requests fail fast before probing again
+	OpenDuration time.Duration
+	// HalfOpenProbes successful probes close the circuit; one failure reopens it
+	HalfOpenProbes int
+}
+
+// DefaultCircuitBreakerPolicy opens after 5 consecutive failures for 30 seconds
+func DefaultCircuitBreakerPolicy() CircuitBreakerPolicy {
+	return CircuitBreakerPolicy{
+		FailureThreshold: 5,
+		OpenDuration:     30 * time.Second,
+		HalfOpenProbes:   1,
+	}
+}
+
+// CircuitBreakerPolicyFromConfig fills unset config values from DefaultCircuitBreakerPolicy
+func CircuitBreakerPolicyFromConfig(cfg core_config.CircuitBreakerConfig) CircuitBreakerPolicy {
+	policy := DefaultCircuitBreakerPolicy()
+	if cfg.FailureThreshold > 0 {
+		policy.FailureThreshold = cfg.FailureThreshold
+	}
+	if cfg.OpenDuration > 0 {
+		policy.OpenDuration = cfg.OpenDuration
+	}
+	if cfg.HalfOpenProbes > 0 {
+		policy.HalfOpenProbes = cfg.HalfOpenProbes
+	}
+	return policy
+}
+
+type circuit struct {
+	mu        sync.Mutex
+	state     CircuitState
+	failures  int
+	openedAt  time.Time
+	probes    int // in-flight half-open probes
+	successes int // successful half-open probes
+}
+
+// CircuitBreakerTransport is an http.RoundTripper keeping one circuit per host.
+// Transport errors and 5xx responses count as failures.
+type CircuitBreakerTransport struct {
+	base     http.RoundTripper
+	policy   CircuitBreakerPolicy
+	logger   *slog.Logger
+	circuits sync.Map // host -> *circuit
+	now      func() time.Time
+}
+
+// NewCircuitBreakerTransport wraps base (http.DefaultTransport when nil)
+func NewCircuitBreakerTransport(base http.RoundTripper, policy CircuitBreakerPolicy, logger *slog.Logger) *CircuitBreakerTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &CircuitBreakerTransport{
+		base:   base,
+		policy: policy,
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// State returns the circuit state for host
+func (t *CircuitBreakerTransport) State(host string) CircuitState {
+	c := t.circuit(host)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state
+}
+
+func (t *CircuitBreakerTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	host := r.URL.Host
+	c := t.circuit(host)
+
+	if !t.allow(r.Context(), host, c) {
+		return nil, ErrCircuitOpen
+	}
+
+	resp, err := t.base.RoundTrip(r)
+	// a canceled caller says nothing about the host
+	if err != nil && errors.Is(r.Context().Err(), context.Canceled) {
+		t.release(c)
+		return resp, err
+	}
+	t.record(r.Context(), host, c, err == nil && resp.StatusCode < http.StatusInternalServerError)
+	return resp, err
+}
+
+func (t *CircuitBreakerTransport) circuit(host string) *circuit {
+	c, _ := t.circuits.LoadOrStore(host, &circuit{})
+	return c.(*circuit)
+}
+
+func (t *CircuitBreakerTransport) allow(ctx context.Context, host string, c *circuit) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch c.state {
+	case CircuitOpen:
+		if t.now().Sub(c.openedAt) < t.policy.OpenDuration {
+			return false
+		}
+		t.transition(ctx, host, c, CircuitHalfOpen)
+		fallthrough
+	case CircuitHalfOpen:
+		if c.probes+c.successes >= t.policy.HalfOpenProbes {
+			return false
+		}
+		c.probes++
+	}
+	return true
+}
+
+func (t *CircuitBreakerTransport) release(c *circuit) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.state == CircuitHalfOpen && c.probes > 0 {
+		c.probes--
+	}
+}
+
+func (t *CircuitBreakerTransport) record(ctx context.Context, host string, c *circuit, success bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch c.state {
+	case CircuitHalfOpen:
+		c.probes--
+		if !success {
+			t.transition(ctx, host, c, CircuitOpen)
+			return
+		}
+		c.successes++
+		if c.successes >= t.policy.HalfOpenProbes {
+			t.transition(ctx, host, c, CircuitClosed)
+		}
+	case CircuitClosed:
+		if success {
+			c.failures = 0
+			return
+		}
+		c.failures++
+		if c.failures >= t.policy.FailureThreshold {
+			t.transition(ctx, host, c, CircuitOpen)
+		}
+	}
+}
+
+// transition must be called with c.mu held
+func (t *CircuitBreakerTransport) transition(ctx context.Context, host string, c *circuit, to CircuitState) {
+	from := c.state
+	c.state = to
+	c.failures = 0
+	c.probes = 0
+	c.successes = 0
+	if to == CircuitOpen {
+		c.openedAt = t.now()
+	}
+
+	t.logger.WarnContext(ctx, "Circuit breaker state changed",
+		"host", host,
+		"from", from.String(),
+		"to", to.String(),
+	)
+	recordCircuitTransition(ctx, host, to)
+}
+
+var (
+	circuitCounter     metric.Int64Counter
+	circuitCounterOnce sync.Once
+)
+
+func recordCircuitTransition(ctx context.Context, host string, to CircuitState) {
+	circuitCounterOnce.Do(func() {
+		circuitCounter, _ = meter.Int64Counter(
+			"http.client.circuit_breaker.transitions",
+			metric.WithDescription("Number of circuit breaker state changes per host"),
+			metric.WithUnit("{transition}"),
+		)
+	})
+	if circuitCounter == nil {
+		return
+	}
+	circuitCounter.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("server.address", host),
+		attribute.String("state", to.String()),
+	))
+}
@@ -0,0 +1,139 @@
+package common
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	core_config "github.com/yourorg/go-api-template/core/config"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// DefaultClientTimeout bounds a whole request including reading the body;
+// completions can take minutes on local models
+const DefaultClientTimeout = 10 * time.Minute
+
+// NewHTTPClient builds a client from the endpoint's transport settings and wraps it
+// with tracing, the circuit breaker when enabled and the configured auth. Unset
+// settings keep the http.DefaultTransport values.
+func NewHTTPClient(cfg *core_config.LMStudioConfig, logger *slog.Logger) (*http.Client, error) {
+	if cfg == nil {
+		return nil, errors.New("config is nil")
+	}
+
+	transport, err := NewTransport(cfg.Transport)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := cfg.Transport.Timeout
+	if timeout <= 0 {
+		timeout = DefaultClientTimeout
+	}
+
+	// a client span per attempt, with the trace context injected into the request
+	var roundTripper http.RoundTripper = NewTracingTransport(transport)
+	if cfg.CircuitBreaker.Enabled {
+		// fail fast while the backend is down instead of waiting for the timeout
+		roundTripper = NewCircuitBreakerTransport(roundTripper, CircuitBreakerPolicyFromConfig(cfg.CircuitBreaker), logger)
+	}
+
+	provider, err := TokenProviderFromConfig(cfg.Auth, transport)
+	if err != nil {
+		return nil, err
+	}
+	if provider != nil {
+		roundTripper = NewAuthTransport(roundTripper, provider)
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: roundTripper,
+	}, nil
+}
+
+// NewTracingTransport wraps base with otelhttp, propagating W3C traceparent and baggage headers
+func NewTracingTransport(base http.RoundTripper) http.RoundTripper {
+	return otelhttp.NewTransport(base,
+		otelhttp.WithPropagators(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})),
+	)
+}
+
+// NewTransport clones http.DefaultTransport and applies cfg
+func NewTransport(cfg core_config.HTTPTransportConfig) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	dialTimeout, keepAlive := 30*time.Second, 30*time.Second
+	if cfg.DialTimeout > 0 {
+		dialTimeout = cfg.DialTimeout
+	}
+	if cfg.KeepAlive > 0 {
+		keepAlive = cfg.KeepAlive
+	}
+	transport.DialContext = (&net.Dialer{Timeout: dialTimeout, KeepAlive: keepAlive}).DialContext
+
+	if cfg.MaxIdleConns > 0 {
+		transport.MaxIdleConns = cfg.MaxIdleConns
+	}
+	if cfg.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = cfg.MaxIdleConnsPerHost
+	}
+	if cfg.MaxConnsPerHost > 0 {
+		transport.MaxConnsPerHost = cfg.MaxConnsPerHost
+	}
+	if cfg.IdleConnTimeout > 0 {
+		transport.IdleConnTimeout = cfg.IdleConnTimeout
+	}
+	if cfg.TLSHandshakeTimeout > 0 {
+		transport.TLSHandshakeTimeout = cfg.TLSHandshakeTimeout
+	}
+	if cfg.ResponseHeaderTimeout > 0 {
+		transport.ResponseHeaderTimeout = cfg.ResponseHeaderTimeout
+	}
+
+	if cfg.ProxyURL != "" {
+		proxyURL, err := url.Parse(cfg.ProxyURL)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy url %q", cfg.ProxyURL)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if cfg.CAFile != "" {
+		pool, err := loadCertPool(cfg.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{}
+		}
+		transport.TLSClientConfig.RootCAs = pool
+	}
+
+	return transport, nil
+}
+
+// loadCertPool adds the PEM bundle at path to the system roots
+func loadCertPool(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading CA bundle: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in CA bundle %s", path)
+	}
+	return pool, nil
+}
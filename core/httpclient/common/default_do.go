@@ -9,6 +9,7 @@ import (
 
 	"github.com/google/uuid"
 	core_config "github.com/yourorg/go-api-template/core/config"
+	middleware "github.com/yourorg/go-api-template/core/transport/httpserver/middlewares"
 )
 
 type ContentType int
@@ -47,7 +48,9 @@ func BuildURL(cfg *core_config.LMStudioConfig, path string) (string, error) {
 	return fmt.Sprintf("%s://%s/%s", cfg.Protocol, cfg.BaseUrl, path), nil
 }
 
-func DefaultDo(ctx context.Context, cfg *core_config.LMStudioConfig, r *http.Request, c *http.Client, contentType ContentType, tokenType TokenType, customContentType *string) (*http.Response, error) {
+// DefaultDo sets the common headers and sends r with retries. Credentials are added by
+// the client's TokenProvider (see NewHTTPClient); tokenType is informational only.
+func DefaultDo(ctx context.Context, cfg *core_config.LMStudioConfig, r *http.Request, c Doer, contentType ContentType, tokenType TokenType, customContentType *string) (*http.Response, error) {
 	switch contentType {
 	case ApplicationJson:
 		r.Header.Set("Content-Type", "application/json")
@@ -62,7 +65,17 @@ func DefaultDo(ctx context.Context, cfg *core_config.LMStudioConfig, r *http.Req
 		return nil, err
 	}
 	r.Header.Set("RequestID", reqId.String())
-	resp, err := c.Do(r)
+	// forward the inbound request ID so downstream logs can be correlated with ours
+	if inboundId, ok := middleware.GetRequestIDFromContext(ctx); ok && r.Header.Get(middleware.RequestIDHeader) == "" {
+		r.Header.Set(middleware.RequestIDHeader, inboundId)
+	}
+	// POSTs are retried only with an Idempotency-Key of the caller or a policy opting in (see
+	// canRetry): the LLM backends do not deduplicate on a key, a retried completion runs twice
+	policy := DefaultRetryPolicy()
+	if cfg != nil {
+		policy = RetryPolicyFromConfig(cfg.Retry)
+	}
+	resp, err := DoWithRetry(ctx, policy, r, c)
 	if err != nil {
 		print(err.Error())
 		return nil, err
@@ -9,10 +9,8 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
-	"time"
 
 	core_config "github.com/yourorg/go-api-template/core/config"
-	"github.com/yourorg/go-api-template/core/logger"
 	"go.opentelemetry.io/otel"
 )
 
@@ -20,7 +18,19 @@ const name = "aoa.common"
 
 var tracer = otel.Tracer(name)
 
-func Do[T any, R any, E error](ctx context.Context, cfg *core_config.LMStudioConfig, httpClient *http.Client, path string, req interface{}, slogger *slog.Logger) (R, error) {
+var meter = otel.Meter(name)
+
+// RequestOption modifies an outbound request before it is sent, e.g. to add auth headers
+type RequestOption func(r *http.Request)
+
+// WithHeader sets a header on the outbound request
+func WithHeader(key, value string) RequestOption {
+	return func(r *http.Request) {
+		r.Header.Set(key, value)
+	}
+}
+
+func Do[T any, R any, E error](ctx context.Context, cfg *core_config.LMStudioConfig, httpClient Doer, path string, req interface{}, slogger *slog.Logger, opts ...RequestOption) (R, error) {
 	ctx, span := tracer.Start(ctx, path)
 	defer span.End()
 
@@ -31,7 +41,6 @@ func Do[T any, R any, E error](ctx context.Context, cfg *core_config.LMStudioCon
 		return *typedResp, errors.New("req is not a valid type")
 	}
 
-	starTime := time.Now()
 	if cfg == nil {
 		return *typedResp, errors.New("AOA config is nil")
 	}
@@ -41,18 +50,39 @@ func Do[T any, R any, E error](ctx context.Context, cfg *core_config.LMStudioCon
 		return *typedResp, err
 	}
 
+	return send[R, E](ctx, cfg, httpClient, http.MethodPost, path, bytes.NewReader(payload), ApplicationJson, nil, slogger, opts)
+}
+
+// Get sends a GET request to path and decodes the JSON response into R
+func Get[R any, E error](ctx context.Context, cfg *core_config.LMStudioConfig, httpClient Doer, path string, slogger *slog.Logger, opts ...RequestOption) (R, error) {
+	ctx, span := tracer.Start(ctx, path)
+	defer span.End()
+
+	if cfg == nil {
+		return *new(R), errors.New("AOA config is nil")
+	}
+
+	return send[R, E](ctx, cfg, httpClient, http.MethodGet, path, nil, ApplicationJson, nil, slogger, opts)
+}
+
+func send[R any, E error](ctx context.Context, cfg *core_config.LMStudioConfig, httpClient Doer, method string, path string, body io.Reader, contentType ContentType, customContentType *string, slogger *slog.Logger, opts []RequestOption) (R, error) {
+	typedResp := new(R)
+
 	fullPath, err := BuildURL(cfg, path) // common lms for build full url
 	if err != nil {
 		return *typedResp, err
 	}
 
-	r, err := http.NewRequestWithContext(ctx, http.MethodPost, fullPath, bytes.NewReader(payload))
+	r, err := http.NewRequestWithContext(ctx, method, fullPath, body)
 	if err != nil {
 		return *typedResp, err
 	}
 
-	token := Basic
-	httpResp, err := DefaultDo(ctx, cfg, r, httpClient, ApplicationJson, token, nil)
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	httpResp, err := defaultDoer(ctx, cfg, httpClient, slogger, path, contentType, customContentType).Do(r)
 	if err != nil {
 		return *typedResp, err
 	}
@@ -65,7 +95,6 @@ func Do[T any, R any, E error](ctx context.Context, cfg *core_config.LMStudioCon
 
 	var commonErrorResponse error
 	respErr := new(E)
-	logLevel := logger.Info
 
 	switch httpResp.StatusCode {
 	case http.StatusOK, http.StatusCreated:
@@ -79,33 +108,21 @@ func Do[T any, R any, E error](ctx context.Context, cfg *core_config.LMStudioCon
 		if err != nil {
 			return *typedResp, err
 		}
-		logLevel = logger.Error
 		commonErrorResponse = *respErr
 	default:
 		commonErrorResponse = TransportError{
 			Code:        httpResp.StatusCode,
 			Description: fmt.Sprintf("got %d response from %s is %s", httpResp.StatusCode, fullPath, responseData),
 		}
-		logLevel = logger.Error
 	}
 
-	logger.CanonicalLogger(
-		ctx,
-		*slogger,
-		logLevel,
-		payload,
-		responseData,
-		commonErrorResponse,
-		logger.CanonicalLog{
-			Transport: "http",
-			Traffic:   "external",
-			Method:    http.MethodPost,
-			Status:    httpResp.StatusCode,
-			Path:      path,
-			Duration:  time.Since(starTime),
-		},
-		[]any{},
-	)
-
 	return *typedResp, commonErrorResponse
 }
+
+// defaultDoer sends with DefaultDo (headers and retries) and logs the call
+func defaultDoer(ctx context.Context, cfg *core_config.LMStudioConfig, httpClient Doer, slogger *slog.Logger, path string, contentType ContentType, customContentType *string) Doer {
+	do := DoerFunc(func(r *http.Request) (*http.Response, error) {
+		return DefaultDo(ctx, cfg, r, httpClient, contentType, Basic, customContentType)
+	})
+	return Chain(do, CanonicalLogging(*slogger, path))
+}
@@ -0,0 +1,31 @@
+package common
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// request durations and sizes are recorded by the otelhttp transport of NewTracingTransport
+
+var (
+	retryCounter     metric.Int64Counter
+	retryCounterOnce sync.Once
+)
+
+// recordRetry counts a request sent again by DoWithRetry, by host
+func recordRetry(ctx context.Context, host string) {
+	retryCounterOnce.Do(func() {
+		retryCounter, _ = meter.Int64Counter(
+			"http.client.retries",
+			metric.WithDescription("Number of outbound requests retried after a transient failure"),
+			metric.WithUnit("{retry}"),
+		)
+	})
+	if retryCounter == nil {
+		return
+	}
+	retryCounter.Add(ctx, 1, metric.WithAttributes(attribute.String("server.address", host)))
+}
@@ -0,0 +1,149 @@
+package common
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourorg/go-api-template/core/logger"
+)
+
+// Doer sends an outbound request; *http.Client implements it
+type Doer interface {
+	Do(r *http.Request) (*http.Response, error)
+}
+
+// DoerFunc adapts a function to Doer
+type DoerFunc func(r *http.Request) (*http.Response, error)
+
+func (f DoerFunc) Do(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// Middleware wraps a Doer, e.g. to record metrics, inject headers or log
+type Middleware func(next Doer) Doer
+
+// Chain wraps d with mws; the first middleware is the outermost
+func Chain(d Doer, mws ...Middleware) Doer {
+	for i := len(mws) - 1; i >= 0; i-- {
+		d = mws[i](d)
+	}
+	return d
+}
+
+// maxLoggedBody caps how much of a response body CanonicalLogging keeps
+const maxLoggedBody = 64 << 10
+
+// CanonicalLogging writes one canonical log line per call. The line is written when the
+// response body is closed, so the duration covers reading it; event streams are logged
+// by size only.
+func CanonicalLogging(slogger slog.Logger, path string) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(r *http.Request) (*http.Response, error) {
+			start := time.Now()
+			request := requestBody(r)
+
+			resp, err := next.Do(r)
+			if err != nil {
+				logCall(r, slogger, path, request, nil, 0, err, time.Since(start))
+				return resp, err
+			}
+
+			resp.Body = &loggingBody{
+				ReadCloser: resp.Body,
+				stream:     strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream"),
+				done: func(response []byte, readErr error) {
+					callErr := readErr
+					if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+						callErr = TransportError{
+							Code:        resp.StatusCode,
+							Description: fmt.Sprintf("got %d response from %s", resp.StatusCode, r.URL.Redacted()),
+						}
+					}
+					logCall(r, slogger, path, request, response, resp.StatusCode, callErr, time.Since(start))
+				},
+			}
+			return resp, nil
+		})
+	}
+}
+
+// requestBody returns a copy of the request payload without consuming it
+func requestBody(r *http.Request) []byte {
+	if r.GetBody == nil {
+		return nil
+	}
+	body, err := r.GetBody()
+	if err != nil {
+		return nil
+	}
+	defer body.Close()
+	payload, _ := io.ReadAll(io.LimitReader(body, maxLoggedBody))
+	return payload
+}
+
+func logCall(r *http.Request, slogger slog.Logger, path string, request, response []byte, status int, err error, duration time.Duration) {
+	level := logger.Info
+	if err != nil {
+		level = logger.Error
+	}
+	logger.CanonicalLogger(
+		r.Context(),
+		slogger,
+		level,
+		request,
+		response,
+		err,
+		logger.CanonicalLog{
+			Transport: "http",
+			Traffic:   "external",
+			Method:    r.Method,
+			Status:    status,
+			Path:      path,
+			Duration:  duration,
+		},
+		[]any{},
+	)
+}
+
+// loggingBody keeps the start of the body and reports it once on Close,
+// with the error that ended reading early, if any
+type loggingBody struct {
+	io.ReadCloser
+	stream bool
+	done   func(response []byte, readErr error)
+
+	buf     bytes.Buffer
+	size    int
+	readErr error
+	once    sync.Once
+}
+
+func (b *loggingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.size += n
+	if err != nil && err != io.EOF {
+		b.readErr = err
+	}
+	if !b.stream && b.buf.Len() < maxLoggedBody {
+		b.buf.Write(p[:min(n, maxLoggedBody-b.buf.Len())])
+	}
+	return n, err
+}
+
+func (b *loggingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() {
+		if b.stream {
+			b.done([]byte(fmt.Sprintf(`{"stream":true,"bytes":%d}`, b.size)), b.readErr)
+			return
+		}
+		b.done(b.buf.Bytes(), b.readErr)
+	})
+	return err
+}
@@ -0,0 +1,106 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strings"
+
+	core_config "github.com/yourorg/go-api-template/core/config"
+)
+
+// Part is one part of a multipart/form-data body. Parts without a FileName are plain form fields.
+type Part struct {
+	Name        string
+	FileName    string
+	ContentType string // defaults to application/octet-stream for files
+	Content     io.Reader
+}
+
+// FieldPart is a plain form field
+func FieldPart(name, value string) Part {
+	return Part{Name: name, Content: strings.NewReader(value)}
+}
+
+// FilePart is a file streamed from content; content is closed after it is sent if it is an io.Closer
+func FilePart(name, fileName, contentType string, content io.Reader) Part {
+	return Part{Name: name, FileName: fileName, ContentType: contentType, Content: content}
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// NewMultipartBody streams parts as a multipart/form-data body without buffering them in
+// memory. It returns the body and its Content-Type including the boundary. The body can
+// be read only once, so requests using it are not retried.
+func NewMultipartBody(parts ...Part) (io.ReadCloser, string) {
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+
+	go func() {
+		err := writeParts(writer, parts)
+		if err == nil {
+			err = writer.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+
+	return pr, writer.FormDataContentType()
+}
+
+func writeParts(writer *multipart.Writer, parts []Part) error {
+	defer func() {
+		for _, part := range parts {
+			if closer, ok := part.Content.(io.Closer); ok {
+				closer.Close()
+			}
+		}
+	}()
+
+	for _, part := range parts {
+		header := make(textproto.MIMEHeader)
+		disposition := fmt.Sprintf(`form-data; name="%s"`, quoteEscaper.Replace(part.Name))
+		if part.FileName != "" {
+			disposition += fmt.Sprintf(`; filename="%s"`, quoteEscaper.Replace(part.FileName))
+			contentType := part.ContentType
+			if contentType == "" {
+				contentType = "application/octet-stream"
+			}
+			header.Set("Content-Type", contentType)
+		} else if part.ContentType != "" {
+			header.Set("Content-Type", part.ContentType)
+		}
+		header.Set("Content-Disposition", disposition)
+
+		w, err := writer.CreatePart(header)
+		if err != nil {
+			return err
+		}
+		if part.Content == nil {
+			continue
+		}
+		if _, err := io.Copy(w, part.Content); err != nil {
+			return fmt.Errorf("error writing part %s: %w", part.Name, err)
+		}
+	}
+	return nil
+}
+
+// DoMultipart POSTs parts as multipart/form-data to path and decodes the JSON response into R
+func DoMultipart[R any, E error](ctx context.Context, cfg *core_config.LMStudioConfig, httpClient Doer, path string, parts []Part, slogger *slog.Logger, opts ...RequestOption) (R, error) {
+	ctx, span := tracer.Start(ctx, path)
+	defer span.End()
+
+	if cfg == nil {
+		return *new(R), fmt.Errorf("AOA config is nil")
+	}
+
+	body, contentType := NewMultipartBody(parts...)
+	// closed by the transport once sent; closing again unblocks the writer if sending failed early
+	defer body.Close()
+
+	return send[R, E](ctx, cfg, httpClient, http.MethodPost, path, body, MultiPartFormData, &contentType, slogger, opts)
+}
@@ -0,0 +1,149 @@
+package common
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/yourorg/go-api-template/core/backoff"
+	core_config "github.com/yourorg/go-api-template/core/config"
+	"github.com/yourorg/go-api-template/core/exception"
+)
+
+// RetryPolicy controls how DoWithRetry retries a request
+type RetryPolicy struct {
+	// MaxAttempts includes the first attempt; 1 disables retries
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// RetryableStatusCodes are retried in addition to transport errors
+	// classified as retryable by exception.ClassifyRetry
+	RetryableStatusCodes []int
+	// RetryNonIdempotent allows retrying POST/PATCH requests without an Idempotency-Key header
+	RetryNonIdempotent bool
+}
+
+// DefaultRetryPolicy retries gateway errors and rate limits up to 3 attempts
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:          3,
+		BaseBackoff:          500 * time.Millisecond,
+		MaxBackoff:           10 * time.Second,
+		RetryableStatusCodes: []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+	}
+}
+
+// RetryPolicyFromConfig fills unset config values from DefaultRetryPolicy
+func RetryPolicyFromConfig(cfg core_config.RetryConfig) RetryPolicy {
+	policy := DefaultRetryPolicy()
+	if cfg.MaxAttempts > 0 {
+		policy.MaxAttempts = cfg.MaxAttempts
+	}
+	if cfg.BaseBackoff > 0 {
+		policy.BaseBackoff = cfg.BaseBackoff
+	}
+	if cfg.MaxBackoff > 0 {
+		policy.MaxBackoff = cfg.MaxBackoff
+	}
+	if len(cfg.RetryableStatusCodes) > 0 {
+		policy.RetryableStatusCodes = cfg.RetryableStatusCodes
+	}
+	policy.RetryNonIdempotent = cfg.RetryNonIdempotent
+	return policy
+}
+
+// DoWithRetry sends r with c, retrying transient failures according to policy.
+// Retry-After on 429/503 responses is honored when it does not exceed MaxBackoff.
+// Requests with a body must be created with http.NewRequest* so the body can be replayed.
+func DoWithRetry(ctx context.Context, policy RetryPolicy, r *http.Request, c Doer) (*http.Response, error) {
+	maxAttempts := policy.MaxAttempts
+	if maxAttempts < 1 || !canRetry(policy, r) {
+		maxAttempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		req := r
+		if attempt > 1 {
+			var err error
+			if req, err = rewind(r); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := c.Do(req)
+		if attempt >= maxAttempts || !shouldRetry(policy, resp, err) {
+			return resp, err
+		}
+
+		delay := backoff.Exponential(policy.BaseBackoff, policy.MaxBackoff, attempt)
+		if resp != nil {
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+				if retryAfter > policy.MaxBackoff {
+					// the server asked for a longer pause than we are willing to wait
+					return resp, nil
+				}
+				delay = retryAfter
+			}
+			// drain so the connection can be reused
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		recordRetry(ctx, r.URL.Host)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// canRetry is the idempotency guard: only safe methods, requests with an
+// Idempotency-Key header, or policies that opt in are retried
+func canRetry(policy RetryPolicy, r *http.Request) bool {
+	if r.Body != nil && r.Body != http.NoBody && r.GetBody == nil {
+		return false
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return policy.RetryNonIdempotent || r.Header.Get("Idempotency-Key") != ""
+}
+
+func shouldRetry(policy RetryPolicy, resp *http.Response, err error) bool {
+	if err != nil {
+		return exception.IsRetryable(err)
+	}
+	return slices.Contains(policy.RetryableStatusCodes, resp.StatusCode)
+}
+
+func rewind(r *http.Request) (*http.Request, error) {
+	req := r.Clone(r.Context())
+	if r.GetBody != nil {
+		body, err := r.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = body
+	}
+	return req, nil
+}
+
+// parseRetryAfter accepts delay-seconds or an HTTP date
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0), true
+	}
+	return 0, false
+}
@@ -0,0 +1,145 @@
+package common
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	core_config "github.com/yourorg/go-api-template/core/config"
+)
+
+// Event is a single server-sent event
+type Event struct {
+	ID    string
+	Event string
+	Data  string
+}
+
+// maxEventLineSize bounds a single SSE line; LLM chunks are small but tool calls can be long
+const maxEventLineSize = 1 << 20
+
+// ReadEvents parses a text/event-stream body. Comment lines and retry fields are ignored.
+func ReadEvents(r io.Reader) iter.Seq2[Event, error] {
+	return func(yield func(Event, error) bool) {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxEventLineSize)
+
+		var event Event
+		var data []string
+		for scanner.Scan() {
+			line := scanner.Text()
+			if line == "" {
+				// a blank line dispatches the event
+				if len(data) > 0 {
+					event.Data = strings.Join(data, "\n")
+					if !yield(event, nil) {
+						return
+					}
+				}
+				event, data = Event{}, nil
+				continue
+			}
+			if strings.HasPrefix(line, ":") {
+				continue
+			}
+
+			field, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "data":
+				data = append(data, value)
+			case "event":
+				event.Event = value
+			case "id":
+				event.ID = value
+			}
+		}
+
+		if err := scanner.Err(); err != nil {
+			yield(Event{}, err)
+			return
+		}
+		if len(data) > 0 {
+			event.Data = strings.Join(data, "\n")
+			yield(event, nil)
+		}
+	}
+}
+
+// DoStream POSTs req and returns the server-sent events of the response. Non-2xx
+// responses are decoded into E and yielded as the error. Breaking out of the loop
+// or canceling ctx closes the connection.
+func DoStream[T any, E error](ctx context.Context, cfg *core_config.LMStudioConfig, httpClient Doer, path string, req T, slogger *slog.Logger, opts ...RequestOption) iter.Seq2[Event, error] {
+	return func(yield func(Event, error) bool) {
+		ctx, span := tracer.Start(ctx, path)
+		defer span.End()
+
+		if cfg == nil {
+			yield(Event{}, fmt.Errorf("AOA config is nil"))
+			return
+		}
+
+		payload, err := json.Marshal(req)
+		if err != nil {
+			yield(Event{}, err)
+			return
+		}
+
+		fullPath, err := BuildURL(cfg, path)
+		if err != nil {
+			yield(Event{}, err)
+			return
+		}
+
+		r, err := http.NewRequestWithContext(ctx, http.MethodPost, fullPath, bytes.NewReader(payload))
+		if err != nil {
+			yield(Event{}, err)
+			return
+		}
+		r.Header.Set("Accept", "text/event-stream")
+		for _, opt := range opts {
+			opt(r)
+		}
+
+		httpResp, err := defaultDoer(ctx, cfg, httpClient, slogger, path, ApplicationJson, nil).Do(r)
+		if err != nil {
+			yield(Event{}, err)
+			return
+		}
+		defer httpResp.Body.Close()
+
+		if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+			responseData, _ := io.ReadAll(httpResp.Body)
+			respErr := new(E)
+			if err := json.Unmarshal(responseData, respErr); err == nil && any(*respErr) != nil {
+				yield(Event{}, *respErr)
+				return
+			}
+			yield(Event{}, TransportError{
+				Code:        httpResp.StatusCode,
+				Description: fmt.Sprintf("got %d response from %s is %s", httpResp.StatusCode, fullPath, responseData),
+			})
+			return
+		}
+
+		for event, err := range ReadEvents(httpResp.Body) {
+			if err != nil {
+				if ctx.Err() != nil {
+					err = ctx.Err()
+				}
+				yield(Event{}, err)
+				return
+			}
+			if !yield(event, nil) {
+				return
+			}
+		}
+	}
+}
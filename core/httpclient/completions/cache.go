@@ -0,0 +1,107 @@
+package completions
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/yourorg/go-api-template/core/cache"
+	"golang.org/x/sync/singleflight"
+)
+
+// DefaultCacheTTL is used by WithCache when ttl is not positive
+const DefaultCacheTTL = 24 * time.Hour
+
+// WithCache stores successful completions in cacheService, keyed on a hash of the
+// prompt, so identical requests are answered without calling the model again
+func WithCache(cacheService cache.CacheService, ttl time.Duration) ClientOption {
+	return func(s *completionsServiceClient) {
+		if ttl <= 0 {
+			ttl = DefaultCacheTTL
+		}
+		s.cache = cacheService
+		s.cacheTTL = ttl
+	}
+}
+
+// WithDeduplication makes concurrent identical requests share a single upstream call
+func WithDeduplication() ClientOption {
+	return func(s *completionsServiceClient) {
+		s.inflight = &singleflight.Group{}
+	}
+}
+
+// completionKey identifies a prompt; Timeout and Stream do not change the answer
+type completionKey struct {
+	Path        string           `json:"path"`
+	Model       string           `json:"model"`
+	Messages    []MessageRequest `json:"messages"`
+	Temperature float64          `json:"temperature"`
+	MaxTokens   int              `json:"max_tokens"`
+}
+
+func (s *completionsServiceClient) cacheKey(req CompletionRequest) (string, error) {
+	payload, err := json.Marshal(completionKey{
+		Path:        s.path(req.Model),
+		Model:       req.Model,
+		Messages:    req.Messages,
+		Temperature: req.Temperature,
+		MaxTokens:   req.MaxTokens,
+	})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(payload)
+	return cache.BuildCacheKey("completions", hex.EncodeToString(sum[:])), nil
+}
+
+// cachedCompletion serves req from the cache and/or a shared in-flight call
+func (s *completionsServiceClient) cachedCompletion(ctx context.Context, req CompletionRequest) (CompletionResponse, error) {
+	key, err := s.cacheKey(req)
+	if err != nil {
+		return s.complete(ctx, req)
+	}
+
+	if s.cache != nil {
+		var resp CompletionResponse
+		err := s.cache.GetJSON(ctx, key, &resp)
+		if err == nil {
+			s.logger.DebugContext(ctx, "Completion served from cache", "key", key)
+			return resp, nil
+		}
+		if !errors.Is(err, cache.ErrCacheKeyNotFound) {
+			s.logger.WarnContext(ctx, "Completion cache lookup failed", "key", key, "error", err)
+		}
+	}
+
+	if s.inflight == nil {
+		return s.fetchAndStore(ctx, key, req)
+	}
+
+	// the shared call must not be canceled by whichever caller happened to start it
+	ch := s.inflight.DoChan(key, func() (any, error) {
+		return s.fetchAndStore(context.WithoutCancel(ctx), key, req)
+	})
+	select {
+	case <-ctx.Done():
+		return CompletionResponse{}, s.timeoutError(ctx, req, ctx.Err())
+	case result := <-ch:
+		resp, _ := result.Val.(CompletionResponse)
+		return resp, result.Err
+	}
+}
+
+func (s *completionsServiceClient) fetchAndStore(ctx context.Context, key string, req CompletionRequest) (CompletionResponse, error) {
+	resp, err := s.complete(ctx, req)
+	if err != nil || s.cache == nil {
+		return resp, err
+	}
+
+	if err := s.cache.SetJSON(ctx, key, resp, s.cacheTTL); err != nil {
+		s.logger.WarnContext(ctx, "Failed to cache completion", "key", key, "error", err)
+	}
+	return resp, nil
+}
@@ -0,0 +1,95 @@
+package completions
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/yourorg/go-api-template/core/exception"
+	"github.com/yourorg/go-api-template/core/ratelimit"
+)
+
+// WithConcurrencyLimit caps the number of generations in flight; further requests queue
+func WithConcurrencyLimit(n int) ClientOption {
+	return func(s *completionsServiceClient) {
+		if n > 0 {
+			s.slots = make(chan struct{}, n)
+		}
+	}
+}
+
+// WithRateLimit queues requests until limiter allows them, e.g. a memory limiter
+// of M requests per minute. key identifies the model server in the limiter.
+func WithRateLimit(limiter ratelimit.Limiter, key string) ClientOption {
+	return func(s *completionsServiceClient) {
+		s.limiter = limiter
+		s.limiterKey = key
+	}
+}
+
+// WithQueueTimeout bounds how long a request waits for a slot before failing with
+// ErrTooManyRequests; by default it waits as long as ctx allows
+func WithQueueTimeout(timeout time.Duration) ClientOption {
+	return func(s *completionsServiceClient) {
+		s.queueTimeout = timeout
+	}
+}
+
+// acquire waits for a concurrency slot and the rate limiter. The returned
+// release func must be called once the request, including any stream, is done.
+func (s *completionsServiceClient) acquire(ctx context.Context, req CompletionRequest) (func(), error) {
+	if s.slots == nil && s.limiter == nil {
+		return func() {}, nil
+	}
+
+	start := time.Now()
+	waitCtx := ctx
+	if s.queueTimeout > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, s.queueTimeout)
+		defer cancel()
+	}
+
+	release := func() {}
+	if s.slots != nil {
+		select {
+		case s.slots <- struct{}{}:
+			release = func() { <-s.slots }
+		case <-waitCtx.Done():
+			return nil, s.queueError(ctx, req, waitCtx.Err(), start)
+		}
+	}
+
+	if s.limiter != nil {
+		err := ratelimit.Wait(waitCtx, s.limiter, s.limiterKey)
+		switch {
+		case err == nil:
+		case errors.Is(err, ratelimit.ErrWaitExceedsDeadline), waitCtx.Err() != nil:
+			release()
+			return nil, s.queueError(ctx, req, err, start)
+		default:
+			// a broken limiter backend should not take the model server down with it
+			s.logger.WarnContext(ctx, "Completions rate limiter failed, continuing", "error", err)
+		}
+	}
+
+	return release, nil
+}
+
+// queueError reports a request that gave up waiting: the caller's own cancellation
+// or deadline as is, the queue timeout as ErrTooManyRequests
+func (s *completionsServiceClient) queueError(ctx context.Context, req CompletionRequest, err error, start time.Time) error {
+	if ctx.Err() != nil {
+		return s.timeoutError(ctx, req, ctx.Err())
+	}
+
+	cErr := exception.DefaultCatalog().Get("TooManyRequests")
+	if cErr == nil {
+		cErr = exception.NewExceptionError(http.StatusBadRequest, 200003, "Too many requests, please try again later", http.StatusTooManyRequests).WithTemporary()
+	}
+	return cErr.WithDatas(map[string]string{
+		"queued_ms": strconv.FormatInt(time.Since(start).Milliseconds(), 10),
+	}).Wrap(err)
+}
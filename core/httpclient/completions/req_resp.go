@@ -1,11 +1,16 @@
 package completions
 
+import "time"
+
 type CompletionRequest struct {
 	Model       string           `json:"model"`
 	Messages    []MessageRequest `json:"messages"`
 	Temperature float64          `json:"temperature"`
 	MaxTokens   int              `json:"max_tokens"`
 	Stream      bool             `json:"stream"`
+	// Timeout bounds this request, overriding the configured requestTimeout.
+	// When exceeded the call fails with *TimeoutError. It is not sent upstream.
+	Timeout time.Duration `json:"-"`
 }
 
 type MessageRequest struct {
@@ -63,6 +68,27 @@ type Runtime struct {
 	SupportedFormats []string `json:"supported_formats"`
 }
 
+// CompletionChunk is one server-sent event of a streamed completion
+type CompletionChunk struct {
+	ID      string        `json:"id"`
+	Object  string        `json:"object"`
+	Created int64         `json:"created"`
+	Model   string        `json:"model"`
+	Choices []ChunkChoice `json:"choices"`
+	Usage   *Usage        `json:"usage,omitempty"` // only on the last chunk, when the server reports it
+}
+
+type ChunkChoice struct {
+	Index        int     `json:"index"`
+	Delta        Delta   `json:"delta"`
+	FinishReason *string `json:"finish_reason"` // nullable
+}
+
+type Delta struct {
+	Role    string `json:"role,omitempty"`
+	Content string `json:"content"`
+}
+
 type CompletionError struct {
 	Errors ErrorDetail `json:"error"`
 }
@@ -2,37 +2,164 @@ package completions
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
 	"log/slog"
 	"net/http"
 	"time"
 
+	"github.com/yourorg/go-api-template/core/cache"
 	core_config "github.com/yourorg/go-api-template/core/config"
 	"github.com/yourorg/go-api-template/core/httpclient/common"
+	"github.com/yourorg/go-api-template/core/ratelimit"
+	"golang.org/x/sync/singleflight"
 )
 
 type CompletionsServiceClient interface {
 	GetCompletionsService(ctx context.Context, req CompletionRequest) (CompletionResponse, error)
+	// GetCompletionsStream streams completion deltas as they are generated.
+	// Stop early by breaking out of the loop or canceling ctx.
+	GetCompletionsStream(ctx context.Context, req CompletionRequest) iter.Seq2[CompletionChunk, error]
 }
 
 type completionsServiceClient struct {
-	cfg        *core_config.LMStudioConfig
-	httpClient *http.Client
-	logger     slog.Logger
+	cfg         *core_config.LMStudioConfig
+	httpClient  *http.Client
+	middlewares []common.Middleware
+	doer        common.Doer // httpClient wrapped with middlewares
+	logger      slog.Logger
+	path        func(model string) string
+	requestOpts []common.RequestOption
+	cache       cache.CacheService
+	cacheTTL    time.Duration
+	inflight    *singleflight.Group
+	// outbound limits, see limit.go
+	slots        chan struct{}
+	limiter      ratelimit.Limiter
+	limiterKey   string
+	queueTimeout time.Duration
 }
 
-func NewCompletionsServiceClient(cfg *core_config.LMStudioConfig, logger slog.Logger) CompletionsServiceClient {
-	httpClient := http.Client{
-		Timeout: 10 * time.Minute,
+// ClientOption customizes the completions client for OpenAI compatible backends other than LM Studio
+type ClientOption func(*completionsServiceClient)
+
+// WithPathFunc sets the request path per model, e.g. for Azure OpenAI deployments
+func WithPathFunc(path func(model string) string) ClientOption {
+	return func(s *completionsServiceClient) {
+		s.path = path
+	}
+}
+
+// WithHTTPClient sets the client used for requests, see common.NewHTTPClient
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(s *completionsServiceClient) {
+		s.httpClient = httpClient
 	}
-	return &completionsServiceClient{
-		cfg:        cfg,
-		httpClient: &httpClient,
-		logger:     logger,
+}
+
+// WithMiddleware wraps every outbound call, e.g. for metrics or custom logging;
+// the first middleware is the outermost
+func WithMiddleware(mws ...common.Middleware) ClientOption {
+	return func(s *completionsServiceClient) {
+		s.middlewares = append(s.middlewares, mws...)
+	}
+}
+
+// WithRequestOptions applies opts, such as auth headers, to every request
+func WithRequestOptions(opts ...common.RequestOption) ClientOption {
+	return func(s *completionsServiceClient) {
+		s.requestOpts = append(s.requestOpts, opts...)
+	}
+}
+
+func NewCompletionsServiceClient(cfg *core_config.LMStudioConfig, logger slog.Logger, opts ...ClientOption) CompletionsServiceClient {
+	client := &completionsServiceClient{
+		cfg:    cfg,
+		logger: logger,
+		path: func(string) string {
+			return GET_COMPLETIONS_URL
+		},
+	}
+	for _, opt := range opts {
+		opt(client)
+	}
+
+	if client.httpClient == nil {
+		httpClient, err := common.NewHTTPClient(cfg, &logger)
+		if err != nil {
+			// use WithHTTPClient to surface transport config errors at startup
+			logger.Error("Invalid http client config, using defaults", "error", err)
+			httpClient = &http.Client{Timeout: common.DefaultClientTimeout}
+		}
+		client.httpClient = httpClient
 	}
+	client.doer = common.Chain(client.httpClient, client.middlewares...)
+	return client
 }
 
 func (s *completionsServiceClient) GetCompletionsService(ctx context.Context, req CompletionRequest) (CompletionResponse, error) {
-	path := GET_COMPLETIONS_URL
+	if s.cache != nil || s.inflight != nil {
+		return s.cachedCompletion(ctx, req)
+	}
+	return s.complete(ctx, req)
+}
+
+func (s *completionsServiceClient) complete(ctx context.Context, req CompletionRequest) (CompletionResponse, error) {
+	path := s.path(req.Model)
 	slogger := s.logger.With("method", "GetCompletionsService")
-	return common.Do[CompletionRequest, CompletionResponse, *CompletionError](ctx, s.cfg, s.httpClient, path, req, slogger)
+
+	release, err := s.acquire(ctx, req)
+	if err != nil {
+		return CompletionResponse{}, err
+	}
+	defer release()
+
+	ctx, cancel := s.withBudget(ctx, req)
+	defer cancel()
+
+	resp, err := common.Do[CompletionRequest, CompletionResponse, *CompletionError](ctx, s.cfg, s.doer, path, req, slogger, s.requestOpts...)
+	return resp, s.timeoutError(ctx, req, err)
+}
+
+// streamDone is the data of the final event in an OpenAI compatible stream
+const streamDone = "[DONE]"
+
+func (s *completionsServiceClient) GetCompletionsStream(ctx context.Context, req CompletionRequest) iter.Seq2[CompletionChunk, error] {
+	path := s.path(req.Model)
+	slogger := s.logger.With("method", "GetCompletionsStream")
+	req.Stream = true
+
+	return func(yield func(CompletionChunk, error) bool) {
+		// the slot is held until the stream ends
+		release, err := s.acquire(ctx, req)
+		if err != nil {
+			yield(CompletionChunk{}, err)
+			return
+		}
+		defer release()
+
+		// the budget covers the whole stream, not just the first byte
+		ctx, cancel := s.withBudget(ctx, req)
+		defer cancel()
+
+		for event, err := range common.DoStream[CompletionRequest, *CompletionError](ctx, s.cfg, s.doer, path, req, slogger, s.requestOpts...) {
+			if err != nil {
+				yield(CompletionChunk{}, s.timeoutError(ctx, req, err))
+				return
+			}
+			if event.Data == streamDone {
+				return
+			}
+
+			var chunk CompletionChunk
+			if err := json.Unmarshal([]byte(event.Data), &chunk); err != nil {
+				yield(CompletionChunk{}, fmt.Errorf("error decoding completion chunk: %w", err))
+				return
+			}
+			if !yield(chunk, nil) {
+				return
+			}
+		}
+	}
 }
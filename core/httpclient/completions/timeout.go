@@ -0,0 +1,63 @@
+package completions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// errBudgetExceeded is the context cause set when a request's budget runs out
+var errBudgetExceeded = errors.New("completion budget exceeded")
+
+// TimeoutError is returned when a completion exceeds its deadline,
+// either the request budget or a deadline already set on the caller's context.
+type TimeoutError struct {
+	Budget time.Duration // 0 when the caller's context deadline was hit
+	Err    error
+}
+
+func (e *TimeoutError) Error() string {
+	if e.Budget > 0 {
+		return fmt.Sprintf("completion timed out after %s: %v", e.Budget, e.Err)
+	}
+	return fmt.Sprintf("completion timed out: %v", e.Err)
+}
+
+func (e *TimeoutError) Unwrap() error { return e.Err }
+
+// Timeout implements net.Error style timeout detection
+func (e *TimeoutError) Timeout() bool { return true }
+
+// Is matches context.DeadlineExceeded so generic timeout checks keep working
+func (e *TimeoutError) Is(target error) bool {
+	return target == context.DeadlineExceeded
+}
+
+// budget returns the timeout for req: its own Timeout, else the configured default
+func (s *completionsServiceClient) budget(req CompletionRequest) time.Duration {
+	if req.Timeout > 0 {
+		return req.Timeout
+	}
+	return s.cfg.RequestTimeout
+}
+
+// withBudget derives the request context. The cancel func must be called when the request is done.
+func (s *completionsServiceClient) withBudget(ctx context.Context, req CompletionRequest) (context.Context, context.CancelFunc) {
+	budget := s.budget(req)
+	if budget <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeoutCause(ctx, budget, errBudgetExceeded)
+}
+
+// timeoutError converts err into a TimeoutError when ctx ran out of time
+func (s *completionsServiceClient) timeoutError(ctx context.Context, req CompletionRequest, err error) error {
+	if err == nil || !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	if errors.Is(context.Cause(ctx), errBudgetExceeded) {
+		return &TimeoutError{Budget: s.budget(req), Err: err}
+	}
+	return &TimeoutError{Err: err}
+}
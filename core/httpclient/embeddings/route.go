@@ -0,0 +1,5 @@
+package embeddings
+
+var (
+	POST_EMBEDDINGS_URL = "v1/embeddings"
+)
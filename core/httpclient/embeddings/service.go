@@ -0,0 +1,50 @@
+package embeddings
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"slices"
+
+	core_config "github.com/yourorg/go-api-template/core/config"
+	"github.com/yourorg/go-api-template/core/httpclient/common"
+	"github.com/yourorg/go-api-template/core/httpclient/completions"
+)
+
+type EmbeddingsServiceClient interface {
+	// GetEmbeddings returns one vector per input, in input order
+	GetEmbeddings(ctx context.Context, req EmbeddingRequest) (EmbeddingResponse, error)
+}
+
+type embeddingsServiceClient struct {
+	cfg        *core_config.LMStudioConfig
+	httpClient *http.Client
+	logger     slog.Logger
+}
+
+// NewEmbeddingsServiceClient uses httpClient when given, otherwise one built from cfg
+func NewEmbeddingsServiceClient(cfg *core_config.LMStudioConfig, logger slog.Logger, httpClient *http.Client) EmbeddingsServiceClient {
+	if httpClient == nil {
+		var err error
+		if httpClient, err = common.NewHTTPClient(cfg, &logger); err != nil {
+			logger.Error("Invalid http client config, using defaults", "error", err)
+			httpClient = &http.Client{Timeout: common.DefaultClientTimeout}
+		}
+	}
+	return &embeddingsServiceClient{
+		cfg:        cfg,
+		httpClient: httpClient,
+		logger:     logger,
+	}
+}
+
+func (s *embeddingsServiceClient) GetEmbeddings(ctx context.Context, req EmbeddingRequest) (EmbeddingResponse, error) {
+	slogger := s.logger.With("method", "GetEmbeddings")
+	// errors use the same envelope as chat completions
+	resp, err := common.Do[EmbeddingRequest, EmbeddingResponse, *completions.CompletionError](ctx, s.cfg, s.httpClient, POST_EMBEDDINGS_URL, req, slogger)
+	if err != nil {
+		return resp, err
+	}
+	slices.SortFunc(resp.Data, func(a, b Embedding) int { return a.Index - b.Index })
+	return resp, nil
+}
@@ -0,0 +1,147 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"log/slog"
+	"net/url"
+	"strings"
+
+	core_config "github.com/yourorg/go-api-template/core/config"
+	"github.com/yourorg/go-api-template/core/httpclient/common"
+	"github.com/yourorg/go-api-template/core/httpclient/completions"
+)
+
+const (
+	ProviderLMStudio = "lmstudio"
+	ProviderOpenAI   = "openai"
+	ProviderAzure    = "azure"
+	ProviderOllama   = "ollama"
+)
+
+// LLMProvider is the chat completion backend used by the service layer.
+// All providers speak the OpenAI chat completions format.
+type LLMProvider interface {
+	Name() string
+	Complete(ctx context.Context, req completions.CompletionRequest) (completions.CompletionResponse, error)
+	CompleteStream(ctx context.Context, req completions.CompletionRequest) iter.Seq2[completions.CompletionChunk, error]
+}
+
+// NewLLMProvider builds the provider selected by cfg.Provider; opts apply to its completions client
+func NewLLMProvider(cfg core_config.LLMConfig, lmStudio *core_config.LMStudioConfig, logger slog.Logger, opts ...completions.ClientOption) (LLMProvider, error) {
+	switch strings.ToLower(cfg.Provider) {
+	case "", ProviderLMStudio:
+		httpClient, err := common.NewHTTPClient(lmStudio, &logger)
+		if err != nil {
+			return nil, fmt.Errorf("llm provider %s: %w", ProviderLMStudio, err)
+		}
+		client := completions.NewCompletionsServiceClient(lmStudio, logger, append(opts, completions.WithHTTPClient(httpClient))...)
+		return newChatProvider(ProviderLMStudio, lmStudio, nil, client), nil
+	case ProviderOpenAI:
+		return newOpenAIProvider(ProviderOpenAI, &cfg.OpenAI, logger, opts)
+	case ProviderOllama:
+		return newOpenAIProvider(ProviderOllama, &cfg.Ollama, logger, opts)
+	case ProviderAzure:
+		return newAzureProvider(&cfg.Azure, logger, opts)
+	default:
+		return nil, fmt.Errorf("unknown llm provider: %q", cfg.Provider)
+	}
+}
+
+// newOpenAIProvider covers OpenAI and servers exposing its API such as Ollama (/v1/chat/completions)
+func newOpenAIProvider(name string, cfg *core_config.LLMProviderConfig, logger slog.Logger, opts []completions.ClientOption) (LLMProvider, error) {
+	if cfg.BaseUrl == "" {
+		return nil, fmt.Errorf("llm provider %s: baseUrl is empty", name)
+	}
+	if name == ProviderOpenAI && cfg.APIKey == "" && cfg.Auth.Type == "" {
+		return nil, fmt.Errorf("llm provider %s: apiKey is empty", name)
+	}
+
+	httpClient, err := common.NewHTTPClient(withAPIKeyAuth(cfg, core_config.OutboundAuthConfig{
+		Type:  common.AuthTypeBearer,
+		Token: cfg.APIKey,
+	}), &logger)
+	if err != nil {
+		return nil, fmt.Errorf("llm provider %s: %w", name, err)
+	}
+
+	client := completions.NewCompletionsServiceClient(&cfg.LMStudioConfig, logger, append(opts, completions.WithHTTPClient(httpClient))...)
+	return newChatProvider(name, &cfg.LMStudioConfig, cfg.ModelMapping, client), nil
+}
+
+// newAzureProvider routes each model to its deployment: /openai/deployments/{deployment}/chat/completions
+func newAzureProvider(cfg *core_config.LLMProviderConfig, logger slog.Logger, opts []completions.ClientOption) (LLMProvider, error) {
+	if cfg.BaseUrl == "" || (cfg.APIKey == "" && cfg.Auth.Type == "") || cfg.APIVersion == "" {
+		return nil, fmt.Errorf("llm provider %s: baseUrl, apiKey and apiVersion are required", ProviderAzure)
+	}
+
+	httpClient, err := common.NewHTTPClient(withAPIKeyAuth(cfg, core_config.OutboundAuthConfig{
+		Type:   common.AuthTypeAPIKey,
+		Header: "api-key",
+		Token:  cfg.APIKey,
+	}), &logger)
+	if err != nil {
+		return nil, fmt.Errorf("llm provider %s: %w", ProviderAzure, err)
+	}
+
+	client := completions.NewCompletionsServiceClient(&cfg.LMStudioConfig, logger, append(opts,
+		completions.WithHTTPClient(httpClient),
+		completions.WithPathFunc(func(deployment string) string {
+			return fmt.Sprintf("openai/deployments/%s/chat/completions?api-version=%s",
+				url.PathEscape(deployment), url.QueryEscape(cfg.APIVersion))
+		}),
+	)...)
+	return newChatProvider(ProviderAzure, &cfg.LMStudioConfig, cfg.ModelMapping, client), nil
+}
+
+// withAPIKeyAuth returns the endpoint config authenticating with apiKey,
+// unless an auth section is configured explicitly (e.g. oauth2 for Azure AD)
+func withAPIKeyAuth(cfg *core_config.LLMProviderConfig, apiKeyAuth core_config.OutboundAuthConfig) *core_config.LMStudioConfig {
+	endpoint := cfg.LMStudioConfig
+	if endpoint.Auth.Type == "" && cfg.APIKey != "" {
+		endpoint.Auth = apiKeyAuth
+	}
+	return &endpoint
+}
+
+type chatProvider struct {
+	name         string
+	defaultModel string
+	modelMapping map[string]string
+	client       completions.CompletionsServiceClient
+}
+
+func newChatProvider(name string, cfg *core_config.LMStudioConfig, modelMapping map[string]string, client completions.CompletionsServiceClient) *chatProvider {
+	return &chatProvider{
+		name:         name,
+		defaultModel: cfg.Model,
+		modelMapping: modelMapping,
+		client:       client,
+	}
+}
+
+func (p *chatProvider) Name() string {
+	return p.name
+}
+
+func (p *chatProvider) Complete(ctx context.Context, req completions.CompletionRequest) (completions.CompletionResponse, error) {
+	req.Model = p.model(req.Model)
+	return p.client.GetCompletionsService(ctx, req)
+}
+
+func (p *chatProvider) CompleteStream(ctx context.Context, req completions.CompletionRequest) iter.Seq2[completions.CompletionChunk, error] {
+	req.Model = p.model(req.Model)
+	return p.client.GetCompletionsStream(ctx, req)
+}
+
+// model resolves the requested model through the mapping, defaulting to the configured model
+func (p *chatProvider) model(requested string) string {
+	if requested == "" {
+		requested = p.defaultModel
+	}
+	if mapped, ok := p.modelMapping[requested]; ok {
+		return mapped
+	}
+	return requested
+}
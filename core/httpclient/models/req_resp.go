@@ -0,0 +1,22 @@
+package models
+
+type ModelList struct {
+	Object string  `json:"object"`
+	Data   []Model `json:"data"`
+}
+
+type Model struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	OwnedBy string `json:"owned_by"`
+}
+
+// Has reports whether the model with id is available
+func (l ModelList) Has(id string) bool {
+	for _, model := range l.Data {
+		if model.ID == id {
+			return true
+		}
+	}
+	return false
+}
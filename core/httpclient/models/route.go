@@ -0,0 +1,5 @@
+package models
+
+var (
+	GET_MODELS_URL = "v1/models"
+)
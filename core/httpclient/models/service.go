@@ -0,0 +1,44 @@
+package models
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	core_config "github.com/yourorg/go-api-template/core/config"
+	"github.com/yourorg/go-api-template/core/httpclient/common"
+	"github.com/yourorg/go-api-template/core/httpclient/completions"
+)
+
+type ModelsServiceClient interface {
+	// ListModels returns the models the server can serve
+	ListModels(ctx context.Context) (ModelList, error)
+}
+
+type modelsServiceClient struct {
+	cfg        *core_config.LMStudioConfig
+	httpClient *http.Client
+	logger     slog.Logger
+}
+
+// NewModelsServiceClient uses httpClient when given, otherwise one built from cfg
+func NewModelsServiceClient(cfg *core_config.LMStudioConfig, logger slog.Logger, httpClient *http.Client) ModelsServiceClient {
+	if httpClient == nil {
+		var err error
+		if httpClient, err = common.NewHTTPClient(cfg, &logger); err != nil {
+			logger.Error("Invalid http client config, using defaults", "error", err)
+			httpClient = &http.Client{Timeout: common.DefaultClientTimeout}
+		}
+	}
+	return &modelsServiceClient{
+		cfg:        cfg,
+		httpClient: httpClient,
+		logger:     logger,
+	}
+}
+
+func (s *modelsServiceClient) ListModels(ctx context.Context) (ModelList, error) {
+	slogger := s.logger.With("method", "ListModels")
+	// errors use the same envelope as chat completions
+	return common.Get[ModelList, *completions.CompletionError](ctx, s.cfg, s.httpClient, GET_MODELS_URL, slogger)
+}
@@ -2,18 +2,32 @@ package httpclient
 
 import (
 	"log/slog"
+	"net/http"
 
 	core_config "github.com/yourorg/go-api-template/core/config"
+	"github.com/yourorg/go-api-template/core/httpclient/common"
 	"github.com/yourorg/go-api-template/core/httpclient/completions"
+	"github.com/yourorg/go-api-template/core/httpclient/embeddings"
+	"github.com/yourorg/go-api-template/core/httpclient/models"
 )
 
 type LmStudioServiceClient struct {
 	GetCompletionsService completions.CompletionsServiceClient
+	Models                models.ModelsServiceClient
+	Embeddings            embeddings.EmbeddingsServiceClient
 }
 
 func NewLmStudioHttpClient(cfg *core_config.LMStudioConfig, logger slog.Logger) *LmStudioServiceClient {
+	httpClient, err := common.NewHTTPClient(cfg, &logger)
+	if err != nil {
+		logger.Error("Invalid http client config, using defaults", "error", err)
+		httpClient = &http.Client{Timeout: common.DefaultClientTimeout}
+	}
+
 	return &LmStudioServiceClient{
-		GetCompletionsService: completions.NewCompletionsServiceClient(cfg, logger),
+		GetCompletionsService: completions.NewCompletionsServiceClient(cfg, logger, completions.WithHTTPClient(httpClient)),
+		Models:                models.NewModelsServiceClient(cfg, logger, httpClient),
+		Embeddings:            embeddings.NewEmbeddingsServiceClient(cfg, logger, httpClient),
 	}
 }
 
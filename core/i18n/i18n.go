@@ -0,0 +1,254 @@
+// Package i18n localizes the messages of the API. A Bundle holds a message catalog per locale,
+// embedded from locales/ and extended from a directory of <locale>.yaml files. Middleware
+// negotiates the locale of each request from Accept-Language among the locales of the bundle
+// and Translate returns a message in the locale of a context, falling back to the base language
+// and then the default locale.
+package i18n
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed locales/*.yaml
+var embeddedLocales embed.FS
+
+var localePattern = regexp.MustCompile(`^[A-Za-z]{2,8}(-[A-Za-z0-9]{1,8})*$`)
+
+// Config negotiates the locale of the requests
+type Config struct {
+	Enabled bool `mapstructure:"enabled"`
+	// DefaultLocale answers the requests without an acceptable locale, and is the fallback of
+	// the messages missing from the other locales
+	DefaultLocale string `mapstructure:"defaultLocale"`
+	// Dir holds <locale>.yaml catalogs merged over the embedded ones, e.g. locales/th.yaml
+	Dir string `mapstructure:"dir"`
+}
+
+// DefaultConfig returns default i18n configuration, disabled in English
+func DefaultConfig() Config {
+	return Config{DefaultLocale: "en"}
+}
+
+// ValidLocale reports whether locale is a BCP 47 language tag, e.g. th or en-GB
+func ValidLocale(locale string) bool {
+	return localePattern.MatchString(locale)
+}
+
+// Bundle holds the message catalogs of the locales
+type Bundle struct {
+	mu            sync.RWMutex
+	defaultLocale string
+	catalogs      map[string]map[string]string
+}
+
+// NewBundle returns an empty bundle falling back to defaultLocale
+func NewBundle(defaultLocale string) *Bundle {
+	if defaultLocale == "" {
+		defaultLocale = DefaultConfig().DefaultLocale
+	}
+	return &Bundle{defaultLocale: defaultLocale, catalogs: map[string]map[string]string{}}
+}
+
+// New returns a bundle of the embedded catalogs merged with the ones of cfg.Dir
+func New(cfg Config) (*Bundle, error) {
+	b := NewBundle(cfg.DefaultLocale)
+	if err := b.LoadFS(embeddedLocales, "locales"); err != nil {
+		return nil, err
+	}
+	if cfg.Dir != "" {
+		if err := b.LoadFS(os.DirFS(cfg.Dir), "."); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// DefaultLocale returns the locale the bundle falls back to
+func (b *Bundle) DefaultLocale() string {
+	return b.defaultLocale
+}
+
+// Locales returns the locales with a catalog, sorted
+func (b *Bundle) Locales() []string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	locales := make([]string, 0, len(b.catalogs))
+	for locale := range b.catalogs {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// AddMessages merges messages, by key, into the catalog of locale
+func (b *Bundle) AddMessages(locale string, messages map[string]string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	catalog, ok := b.catalogs[locale]
+	if !ok {
+		catalog = make(map[string]string, len(messages))
+		b.catalogs[locale] = catalog
+	}
+	for key, message := range messages {
+		catalog[key] = message
+	}
+}
+
+// Parse merges the YAML catalog data into the catalog of locale. Nested keys are joined with
+// dots, so validation: {required: ...} is the message validation.required.
+func (b *Bundle) Parse(locale string, data []byte) error {
+	if !ValidLocale(locale) {
+		return fmt.Errorf("invalid locale %q", locale)
+	}
+	var tree map[string]any
+	if err := yaml.Unmarshal(data, &tree); err != nil {
+		return fmt.Errorf("error parsing %s messages: %w", locale, err)
+	}
+	messages := map[string]string{}
+	if err := flatten("", tree, messages); err != nil {
+		return fmt.Errorf("error parsing %s messages: %w", locale, err)
+	}
+	b.AddMessages(locale, messages)
+	return nil
+}
+
+func flatten(prefix string, tree map[string]any, messages map[string]string) error {
+	for key, value := range tree {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		switch value := value.(type) {
+		case string:
+			messages[key] = value
+		case map[string]any:
+			if err := flatten(key, value, messages); err != nil {
+				return err
+			}
+		case map[any]any:
+			// keys of other types than strings, e.g. the codes of errors.<code>
+			tree := make(map[string]any, len(value))
+			for k, v := range value {
+				tree[fmt.Sprint(k)] = v
+			}
+			if err := flatten(key, tree, messages); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%s is not a message", key)
+		}
+	}
+	return nil
+}
+
+// LoadFS parses the <locale>.yaml files of dir in fsys
+func (b *Bundle) LoadFS(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return fmt.Errorf("error reading message catalogs: %w", err)
+	}
+	var errs []error
+	for _, entry := range entries {
+		locale, ok := strings.CutSuffix(entry.Name(), ".yaml")
+		if entry.IsDir() || !ok {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error reading %s: %w", entry.Name(), err))
+			continue
+		}
+		if err := b.Parse(locale, data); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", entry.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Translate returns the message key in locale with the {name} placeholders replaced by params.
+// A message missing from locale is looked up in its base language, e.g. th for th-TH, then in
+// the default locale; ok is false when none has it.
+func (b *Bundle) Translate(locale string, key string, params map[string]string) (message string, ok bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, candidate := range fallbacks(locale, b.defaultLocale) {
+		if message, ok = b.catalogs[candidate][key]; ok {
+			return replaceParams(message, params), true
+		}
+	}
+	return "", false
+}
+
+// ErrorMessage returns the errors.<code> message of locale, see exception.SetTranslator
+func (b *Bundle) ErrorMessage(locale string, code int32) (string, bool) {
+	return b.Translate(locale, "errors."+strconv.Itoa(int(code)), nil)
+}
+
+// fallbacks returns the locales looked up for locale, most specific first
+func fallbacks(locale string, defaultLocale string) []string {
+	var locales []string
+	for tag := locale; tag != ""; {
+		locales = append(locales, tag)
+		i := strings.LastIndexByte(tag, '-')
+		if i < 0 {
+			break
+		}
+		tag = tag[:i]
+	}
+	if !slices.Contains(locales, defaultLocale) {
+		locales = append(locales, defaultLocale)
+	}
+	return locales
+}
+
+func replaceParams(message string, params map[string]string) string {
+	if len(params) == 0 || !strings.Contains(message, "{") {
+		return message
+	}
+	pairs := make([]string, 0, 2*len(params))
+	for name, value := range params {
+		pairs = append(pairs, "{"+name+"}", value)
+	}
+	return strings.NewReplacer(pairs...).Replace(message)
+}
+
+type contextKey struct{}
+
+type localizer struct {
+	bundle *Bundle
+	locale string
+}
+
+// WithLocale returns a context translating into locale with the messages of bundle
+func WithLocale(ctx context.Context, bundle *Bundle, locale string) context.Context {
+	return context.WithValue(ctx, contextKey{}, localizer{bundle: bundle, locale: locale})
+}
+
+// LocaleFromContext returns the locale negotiated for the request of ctx, see Middleware
+func LocaleFromContext(ctx context.Context) (string, bool) {
+	l, ok := ctx.Value(contextKey{}).(localizer)
+	return l.locale, ok
+}
+
+// Translate returns the message key in the locale of ctx, see Bundle.Translate; ok is false
+// outside of a localized request
+func Translate(ctx context.Context, key string, params map[string]string) (string, bool) {
+	l, ok := ctx.Value(contextKey{}).(localizer)
+	if !ok || l.bundle == nil {
+		return "", false
+	}
+	return l.bundle.Translate(l.locale, key, params)
+}
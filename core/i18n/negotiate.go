@@ -0,0 +1,89 @@
+package i18n
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Match returns the locale of the bundle best matching the Accept-Language header value,
+// the default locale when none is acceptable. The language ranges are tried by decreasing
+// quality, each one matching a locale of the same tag, then of its base language, e.g. th for
+// th-TH, then of its language in another region, e.g. en-GB for en-US.
+func (b *Bundle) Match(acceptLanguage string) string {
+	locales := b.Locales()
+	for _, tag := range parseAcceptLanguage(acceptLanguage) {
+		if tag == "*" {
+			return b.defaultLocale
+		}
+		base, _, _ := strings.Cut(tag, "-")
+		var baseMatch, languageMatch string
+		for _, locale := range locales {
+			switch {
+			case strings.EqualFold(locale, tag):
+				return locale
+			case strings.EqualFold(locale, base):
+				baseMatch = locale
+			case languageMatch == "" && strings.EqualFold(strings.SplitN(locale, "-", 2)[0], base):
+				languageMatch = locale
+			}
+		}
+		if baseMatch != "" {
+			return baseMatch
+		}
+		if languageMatch != "" {
+			return languageMatch
+		}
+	}
+	return b.defaultLocale
+}
+
+// parseAcceptLanguage returns the language ranges of header by decreasing quality, dropping
+// the ones of quality 0
+func parseAcceptLanguage(header string) []string {
+	type languageRange struct {
+		tag     string
+		quality float64
+	}
+	var ranges []languageRange
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		quality := 1.0
+		for _, param := range strings.Split(params, ";") {
+			name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if name == "q" {
+				if q, err := strconv.ParseFloat(value, 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		if quality > 0 {
+			ranges = append(ranges, languageRange{tag: tag, quality: quality})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].quality > ranges[j].quality })
+
+	tags := make([]string, len(ranges))
+	for i, r := range ranges {
+		tags[i] = r.tag
+	}
+	return tags
+}
+
+// Middleware negotiates the locale of the requests with bundle.Match, see LocaleFromContext and
+// Translate, and answers it in the Content-Language header
+func Middleware(bundle *Bundle) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			locale := bundle.Match(r.Header.Get("Accept-Language"))
+			w.Header().Set("Content-Language", locale)
+			w.Header().Add("Vary", "Accept-Language")
+			next.ServeHTTP(w, r.WithContext(WithLocale(r.Context(), bundle, locale)))
+		})
+	}
+}
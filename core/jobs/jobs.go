@@ -0,0 +1,161 @@
+// Package jobs runs work outside of HTTP requests: handlers enqueue a job and return, a worker
+// process picks it up. Jobs can be delayed, failed jobs are retried with backoff and the ones
+// out of attempts are moved to a dead-letter list.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yourorg/go-api-template/core/exception"
+)
+
+// Stores backing the queue
+const (
+	StoreRedis  = "redis"
+	StoreMemory = "memory"
+)
+
+// DefaultQueue receives the jobs enqueued without OnQueue
+const DefaultQueue = "default"
+
+// Config holds job queue and worker configuration
+type Config struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Store is redis, shared by the API and the worker command, or memory where jobs run inside serve
+	Store        string        `mapstructure:"store" enum:"redis memory"`
+	PollInterval time.Duration `mapstructure:"pollInterval"`
+	MaxAttempts  int           `mapstructure:"maxAttempts"`
+	BaseBackoff  time.Duration `mapstructure:"baseBackoff"`
+	MaxBackoff   time.Duration `mapstructure:"maxBackoff"`
+	// Timeout bounds a single run of a job
+	Timeout time.Duration `mapstructure:"timeout"`
+	// Queues maps the queues a worker consumes to their concurrency, 0 leaving a queue to other workers
+	Queues map[string]int `mapstructure:"queues"`
+	// Schedules are the recurring jobs enqueued by the scheduler of serve:all
+	Schedules []Schedule `mapstructure:"schedules"`
+}
+
+// DefaultConfig returns default job configuration
+func DefaultConfig() Config {
+	return Config{
+		Store:        StoreRedis,
+		PollInterval: time.Second,
+		MaxAttempts:  5,
+		BaseBackoff:  time.Second,
+		MaxBackoff:   10 * time.Minute,
+		Timeout:      5 * time.Minute,
+		Queues:       map[string]int{DefaultQueue: 4},
+	}
+}
+
+// Job is a unit of work stored in a queue
+type Job struct {
+	ID          string          `json:"id"`
+	Queue       string          `json:"queue"`
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"payload"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts,omitempty"`
+	LastError   string          `json:"last_error,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// Decode unmarshals the payload of the job into v; a payload that does not decode is not retried
+func (j Job) Decode(v any) error {
+	if err := json.Unmarshal(j.Payload, v); err != nil {
+		return exception.MarkPermanent(fmt.Errorf("error decoding %s job payload: %w", j.Type, err))
+	}
+	return nil
+}
+
+// Queue stores jobs between Enqueue and the worker. A reserved job is leased: it goes back to
+// the queue when it is neither completed, retried nor buried before the lease expires.
+type Queue interface {
+	// Enqueue adds a job runnable from runAt, right away when runAt is zero or past
+	Enqueue(ctx context.Context, job Job, runAt time.Time) error
+	// Reserve takes the next runnable job of a queue, false when there is none
+	Reserve(ctx context.Context, queue string, lease time.Duration) (Job, bool, error)
+	// Complete removes a reserved job
+	Complete(ctx context.Context, job Job) error
+	// Retry puts a reserved job back, runnable from runAt
+	Retry(ctx context.Context, job Job, runAt time.Time) error
+	// Bury moves a reserved job to the dead-letter list of its queue
+	Bury(ctx context.Context, job Job) error
+	// Dead returns up to limit jobs of the dead-letter list of a queue, most recent first
+	Dead(ctx context.Context, queue string, limit int) ([]Job, error)
+}
+
+// EnqueueOption customizes an enqueued job
+type EnqueueOption func(*enqueueOptions)
+
+type enqueueOptions struct {
+	queue       string
+	runAt       time.Time
+	maxAttempts int
+}
+
+// OnQueue enqueues the job on queue instead of DefaultQueue
+func OnQueue(queue string) EnqueueOption {
+	return func(o *enqueueOptions) {
+		o.queue = queue
+	}
+}
+
+// WithDelay runs the job no earlier than delay from now
+func WithDelay(delay time.Duration) EnqueueOption {
+	return func(o *enqueueOptions) {
+		o.runAt = time.Now().Add(delay)
+	}
+}
+
+// WithRunAt runs the job no earlier than t
+func WithRunAt(t time.Time) EnqueueOption {
+	return func(o *enqueueOptions) {
+		o.runAt = t
+	}
+}
+
+// WithMaxAttempts overrides the maxAttempts of the worker for this job
+func WithMaxAttempts(attempts int) EnqueueOption {
+	return func(o *enqueueOptions) {
+		o.maxAttempts = attempts
+	}
+}
+
+// Enqueue adds a job of type jobType with payload marshalled to JSON, returning its ID
+func Enqueue(ctx context.Context, q Queue, jobType string, payload any, opts ...EnqueueOption) (string, error) {
+	if jobType == "" {
+		return "", errors.New("job type is empty")
+	}
+	o := enqueueOptions{queue: DefaultQueue}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("error marshalling job payload: %w", err)
+	}
+	id, err := uuid.NewV7()
+	if err != nil {
+		return "", err
+	}
+
+	job := Job{
+		ID:          id.String(),
+		Queue:       o.queue,
+		Type:        jobType,
+		Payload:     body,
+		MaxAttempts: o.maxAttempts,
+		CreatedAt:   time.Now().UTC(),
+	}
+	if err := q.Enqueue(ctx, job, o.runAt); err != nil {
+		return "", fmt.Errorf("error enqueueing job: %w", err)
+	}
+	return job.ID, nil
+}
@@ -0,0 +1,103 @@
+package jobs
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+type scheduledJob struct {
+	job   Job
+	runAt time.Time
+}
+
+type leasedJob struct {
+	job     Job
+	expires time.Time
+}
+
+// MemoryQueue keeps jobs in process; they are lost on restart and only a worker of the same
+// process sees them
+type MemoryQueue struct {
+	mu      sync.Mutex
+	pending map[string][]scheduledJob
+	active  map[string]leasedJob
+	dead    map[string][]Job
+	now     func() time.Time
+}
+
+// NewMemoryQueue creates an empty in-memory queue
+func NewMemoryQueue() *MemoryQueue {
+	return &MemoryQueue{
+		pending: map[string][]scheduledJob{},
+		active:  map[string]leasedJob{},
+		dead:    map[string][]Job{},
+		now:     time.Now,
+	}
+}
+
+func (q *MemoryQueue) Enqueue(ctx context.Context, job Job, runAt time.Time) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pending[job.Queue] = append(q.pending[job.Queue], scheduledJob{job: job, runAt: runAt})
+	return nil
+}
+
+// Reserve returns the runnable job enqueued first, expired leases going back to the queue
+func (q *MemoryQueue) Reserve(ctx context.Context, queue string, lease time.Duration) (Job, bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.now()
+	for id, leased := range q.active {
+		if leased.job.Queue == queue && !now.Before(leased.expires) {
+			delete(q.active, id)
+			q.pending[queue] = append(q.pending[queue], scheduledJob{job: leased.job})
+		}
+	}
+
+	pending := q.pending[queue]
+	i := slices.IndexFunc(pending, func(s scheduledJob) bool { return !now.Before(s.runAt) })
+	if i < 0 {
+		return Job{}, false, nil
+	}
+	job := pending[i].job
+	q.pending[queue] = slices.Delete(pending, i, i+1)
+	q.active[job.ID] = leasedJob{job: job, expires: now.Add(lease)}
+	return job, true, nil
+}
+
+func (q *MemoryQueue) Complete(ctx context.Context, job Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.active, job.ID)
+	return nil
+}
+
+func (q *MemoryQueue) Retry(ctx context.Context, job Job, runAt time.Time) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.active, job.ID)
+	q.pending[job.Queue] = append(q.pending[job.Queue], scheduledJob{job: job, runAt: runAt})
+	return nil
+}
+
+func (q *MemoryQueue) Bury(ctx context.Context, job Job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.active, job.ID)
+	q.dead[job.Queue] = append(q.dead[job.Queue], job)
+	return nil
+}
+
+func (q *MemoryQueue) Dead(ctx context.Context, queue string, limit int) ([]Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	dead := slices.Clone(q.dead[queue])
+	slices.Reverse(dead)
+	if limit > 0 && len(dead) > limit {
+		dead = dead[:limit]
+	}
+	return dead, nil
+}
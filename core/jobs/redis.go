@@ -0,0 +1,175 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/yourorg/go-api-template/core/cache"
+)
+
+// maxDeadJobs caps the dead-letter list of a queue, the oldest jobs being dropped
+const maxDeadJobs = 1000
+
+// reserveScript moves the due delayed jobs and the expired leases to the ready list, then leases
+// its first job. KEYS: ready, delayed, active, data; ARGV: now and lease deadline in milliseconds.
+var reserveScript = redis.NewScript(`
+for _, key in ipairs({KEYS[2], KEYS[3]}) do
+	local due = redis.call('ZRANGEBYSCORE', key, '-inf', ARGV[1], 'LIMIT', 0, 100)
+	for _, id in ipairs(due) do
+		redis.call('ZREM', key, id)
+		redis.call('RPUSH', KEYS[1], id)
+	end
+end
+local id = redis.call('LPOP', KEYS[1])
+if not id then
+	return false
+end
+local data = redis.call('HGET', KEYS[4], id)
+if not data then
+	return false
+end
+redis.call('ZADD', KEYS[3], ARGV[2], id)
+return data
+`)
+
+// buryScript moves a leased job to the dead-letter list, trimming it to its maximum length.
+// KEYS: active, data, dead; ARGV: id, job, maximum length.
+var buryScript = redis.NewScript(`
+redis.call('ZREM', KEYS[1], ARGV[1])
+redis.call('HSET', KEYS[2], ARGV[1], ARGV[2])
+redis.call('LPUSH', KEYS[3], ARGV[1])
+local max = tonumber(ARGV[3])
+for _, id in ipairs(redis.call('LRANGE', KEYS[3], max, -1)) do
+	redis.call('HDEL', KEYS[2], id)
+end
+redis.call('LTRIM', KEYS[3], 0, max - 1)
+return 0
+`)
+
+// RedisQueue keeps each queue in a ready list, a delayed and an active sorted set, a dead-letter
+// list and a hash of the jobs by ID, all under jobs:{queue}: so a cluster keeps them on one slot
+type RedisQueue struct {
+	cacheService cache.CacheService
+	now          func() time.Time
+}
+
+// NewRedisQueue creates a queue on the given Redis service
+func NewRedisQueue(cacheService cache.CacheService) *RedisQueue {
+	return &RedisQueue{cacheService: cacheService, now: time.Now}
+}
+
+func (q *RedisQueue) key(queue string, name string) string {
+	return fmt.Sprintf("jobs:{%s}:%s", queue, name)
+}
+
+func (q *RedisQueue) Enqueue(ctx context.Context, job Job, runAt time.Time) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	pipe := q.cacheService.GetClient().TxPipeline()
+	pipe.HSet(ctx, q.key(job.Queue, "data"), job.ID, data)
+	if runAt.After(q.now()) {
+		pipe.ZAdd(ctx, q.key(job.Queue, "delayed"), redis.Z{Score: float64(runAt.UnixMilli()), Member: job.ID})
+	} else {
+		pipe.RPush(ctx, q.key(job.Queue, "ready"), job.ID)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("redis enqueue error: %w", err)
+	}
+	return nil
+}
+
+func (q *RedisQueue) Reserve(ctx context.Context, queue string, lease time.Duration) (Job, bool, error) {
+	now := q.now()
+	keys := []string{q.key(queue, "ready"), q.key(queue, "delayed"), q.key(queue, "active"), q.key(queue, "data")}
+	data, err := reserveScript.Run(ctx, q.cacheService.GetClient(), keys, now.UnixMilli(), now.Add(lease).UnixMilli()).Text()
+	if errors.Is(err, redis.Nil) {
+		return Job{}, false, nil
+	}
+	if err != nil {
+		return Job{}, false, fmt.Errorf("redis reserve error: %w", err)
+	}
+
+	var job Job
+	if err := json.Unmarshal([]byte(data), &job); err != nil {
+		return Job{}, false, fmt.Errorf("error decoding job: %w", err)
+	}
+	return job, true, nil
+}
+
+func (q *RedisQueue) Complete(ctx context.Context, job Job) error {
+	pipe := q.cacheService.GetClient().TxPipeline()
+	pipe.ZRem(ctx, q.key(job.Queue, "active"), job.ID)
+	pipe.HDel(ctx, q.key(job.Queue, "data"), job.ID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("redis complete error: %w", err)
+	}
+	return nil
+}
+
+func (q *RedisQueue) Retry(ctx context.Context, job Job, runAt time.Time) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	pipe := q.cacheService.GetClient().TxPipeline()
+	pipe.ZRem(ctx, q.key(job.Queue, "active"), job.ID)
+	pipe.HSet(ctx, q.key(job.Queue, "data"), job.ID, data)
+	pipe.ZAdd(ctx, q.key(job.Queue, "delayed"), redis.Z{Score: float64(runAt.UnixMilli()), Member: job.ID})
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("redis retry error: %w", err)
+	}
+	return nil
+}
+
+func (q *RedisQueue) Bury(ctx context.Context, job Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	keys := []string{q.key(job.Queue, "active"), q.key(job.Queue, "data"), q.key(job.Queue, "dead")}
+	if err := buryScript.Run(ctx, q.cacheService.GetClient(), keys, job.ID, data, maxDeadJobs).Err(); err != nil {
+		return fmt.Errorf("redis bury error: %w", err)
+	}
+	return nil
+}
+
+func (q *RedisQueue) Dead(ctx context.Context, queue string, limit int) ([]Job, error) {
+	client := q.cacheService.GetClient()
+	if limit <= 0 {
+		limit = maxDeadJobs
+	}
+	ids, err := client.LRange(ctx, q.key(queue, "dead"), 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("redis dead jobs error: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	values, err := client.HMGet(ctx, q.key(queue, "data"), ids...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("redis dead jobs error: %w", err)
+	}
+	jobs := make([]Job, 0, len(values))
+	for _, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var job Job
+		if err := json.Unmarshal([]byte(data), &job); err != nil {
+			return nil, fmt.Errorf("error decoding job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
@@ -0,0 +1,115 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Schedule enqueues a job of Type every Every, for the worker to run like any other job
+type Schedule struct {
+	Name string `mapstructure:"name"`
+	// Type is the job type, a handler registered on the worker
+	Type string `mapstructure:"type"`
+	// Queue receives the jobs, DefaultQueue when empty
+	Queue   string         `mapstructure:"queue"`
+	Every   time.Duration  `mapstructure:"every"`
+	Payload map[string]any `mapstructure:"payload"`
+}
+
+// Scheduler enqueues the jobs of its schedules. It keeps no state: every process running a
+// scheduler enqueues its own jobs, so run it in one process only.
+type Scheduler struct {
+	queue     Queue
+	schedules []Schedule
+	logger    *slog.Logger
+}
+
+// NewScheduler creates a scheduler enqueueing the jobs of schedules on queue
+func NewScheduler(queue Queue, schedules []Schedule, logger *slog.Logger) (*Scheduler, error) {
+	for i, schedule := range schedules {
+		if schedule.Type == "" {
+			return nil, fmt.Errorf("schedule %d has no job type", i)
+		}
+		if schedule.Every <= 0 {
+			return nil, fmt.Errorf("schedule %s: every must be positive", schedule.name())
+		}
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &Scheduler{
+		queue:     queue,
+		schedules: schedules,
+		logger:    logger.With("component", "scheduler"),
+	}, nil
+}
+
+// name identifies a schedule in logs, its job type when it has no name
+func (s Schedule) name() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	return s.Type
+}
+
+// Run enqueues the job of each schedule every interval, the first one interval after Run is
+// called, until ctx is cancelled
+func (s *Scheduler) Run(ctx context.Context) error {
+	if len(s.schedules) == 0 {
+		return errors.New("no schedules")
+	}
+
+	var wg sync.WaitGroup
+	for _, schedule := range s.schedules {
+		s.logger.InfoContext(ctx, "Schedule started", "schedule", schedule.name(), "type", schedule.Type, "every", schedule.Every)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.run(ctx, schedule)
+		}()
+	}
+
+	wg.Wait()
+	s.logger.InfoContext(ctx, "Scheduler stopped")
+	return nil
+}
+
+func (s *Scheduler) run(ctx context.Context, schedule Schedule) {
+	ticker := time.NewTicker(schedule.Every)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.Enqueue(ctx, schedule); err != nil {
+				s.logger.ErrorContext(ctx, "Error enqueueing scheduled job", "schedule", schedule.name(), "error", err)
+			}
+		}
+	}
+}
+
+// Enqueue adds the job of schedule now, returning its ID
+func (s *Scheduler) Enqueue(ctx context.Context, schedule Schedule) (string, error) {
+	queue := schedule.Queue
+	if queue == "" {
+		queue = DefaultQueue
+	}
+	payload := schedule.Payload
+	if payload == nil {
+		payload = map[string]any{}
+	}
+
+	id, err := Enqueue(ctx, s.queue, schedule.Type, payload, OnQueue(queue))
+	if err != nil {
+		return "", err
+	}
+	s.logger.DebugContext(ctx, "Scheduled job enqueued", "schedule", schedule.name(), "id", id, "queue", queue)
+	return id, nil
+}
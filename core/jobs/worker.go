@@ -0,0 +1,166 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yourorg/go-api-template/core/backoff"
+	"github.com/yourorg/go-api-template/core/exception"
+)
+
+// leaseGrace is added to the job timeout before an unfinished job is handed to another worker
+const leaseGrace = 30 * time.Second
+
+// Handler runs a job; an error retries it unless exception.IsPermanent, which buries it
+type Handler func(ctx context.Context, job Job) error
+
+// Worker runs the handlers of the jobs of its queues, each queue with its own concurrency
+type Worker struct {
+	config   Config
+	queue    Queue
+	handlers map[string]Handler
+	logger   *slog.Logger
+}
+
+// NewWorker creates a worker consuming queue; register handlers with Handle before Run
+func NewWorker(config Config, queue Queue, logger *slog.Logger) *Worker {
+	defaults := DefaultConfig()
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaults.PollInterval
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaults.MaxAttempts
+	}
+	if config.BaseBackoff <= 0 {
+		config.BaseBackoff = defaults.BaseBackoff
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = defaults.MaxBackoff
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaults.Timeout
+	}
+	if len(config.Queues) == 0 {
+		config.Queues = defaults.Queues
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &Worker{
+		config:   config,
+		queue:    queue,
+		handlers: map[string]Handler{},
+		logger:   logger.With("component", "jobs"),
+	}
+}
+
+// Handle registers the handler of a job type
+func (w *Worker) Handle(jobType string, handler Handler) {
+	w.handlers[jobType] = handler
+}
+
+// Run consumes the queues until ctx is cancelled, skipping those with no concurrency, then waits
+// for the jobs in progress. Those keep running after ctx is cancelled, bounded by the job timeout.
+func (w *Worker) Run(ctx context.Context) error {
+	queues := make([]string, 0, len(w.config.Queues))
+	for queue := range w.config.Queues {
+		queues = append(queues, queue)
+	}
+	sort.Strings(queues)
+
+	var wg sync.WaitGroup
+	for _, queue := range queues {
+		concurrency := w.config.Queues[queue]
+		if concurrency <= 0 {
+			continue
+		}
+		w.logger.InfoContext(ctx, "Job worker started", "queue", queue, "concurrency", concurrency)
+		for i := 0; i < concurrency; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				w.consume(ctx, queue)
+			}()
+		}
+	}
+
+	wg.Wait()
+	w.logger.InfoContext(ctx, "Job worker stopped")
+	return nil
+}
+
+// consume runs the jobs of a queue one at a time, polling while it is empty
+func (w *Worker) consume(ctx context.Context, queue string) {
+	for ctx.Err() == nil {
+		processed, err := w.ProcessNext(ctx, queue)
+		if err != nil {
+			w.logger.ErrorContext(ctx, "Error processing job", "queue", queue, "error", err)
+		}
+		if processed && err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(w.config.PollInterval):
+		}
+	}
+}
+
+// ProcessNext runs the next runnable job of queue, reporting whether there was one
+func (w *Worker) ProcessNext(ctx context.Context, queue string) (bool, error) {
+	job, ok, err := w.queue.Reserve(ctx, queue, w.config.Timeout+leaseGrace)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	// a job in progress finishes on shutdown, its lease would hand it to another worker anyway
+	storeCtx := context.WithoutCancel(ctx)
+	jobCtx, cancel := context.WithTimeout(storeCtx, w.config.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	err = w.run(jobCtx, job)
+	job.Attempts++
+	if err == nil {
+		w.logger.InfoContext(ctx, "Job completed", "id", job.ID, "type", job.Type, "queue", job.Queue, "duration", time.Since(start))
+		return true, w.queue.Complete(storeCtx, job)
+	}
+
+	job.LastError = err.Error()
+	maxAttempts := w.config.MaxAttempts
+	if job.MaxAttempts > 0 {
+		maxAttempts = job.MaxAttempts
+	}
+	if exception.IsPermanent(err) || job.Attempts >= maxAttempts {
+		w.logger.ErrorContext(ctx, "Job failed, moved to the dead-letter list",
+			"id", job.ID, "type", job.Type, "queue", job.Queue, "attempts", job.Attempts, "error", err)
+		return true, w.queue.Bury(storeCtx, job)
+	}
+
+	delay := backoff.Exponential(w.config.BaseBackoff, w.config.MaxBackoff, job.Attempts)
+	w.logger.WarnContext(ctx, "Job failed, retrying",
+		"id", job.ID, "type", job.Type, "queue", job.Queue, "attempts", job.Attempts, "retry_in", delay, "error", err)
+	return true, w.queue.Retry(storeCtx, job, time.Now().Add(delay))
+}
+
+// run calls the handler of the job, turning a panic into a failed attempt
+func (w *Worker) run(ctx context.Context, job Job) (err error) {
+	defer exception.Recover(ctx, &err)
+
+	handler, ok := w.handlers[job.Type]
+	if !ok {
+		return exception.MarkPermanent(fmt.Errorf("no handler for job type %q", job.Type))
+	}
+	err = handler(ctx, job)
+	if errors.Is(err, context.DeadlineExceeded) && ctx.Err() != nil {
+		return fmt.Errorf("job timed out after %s: %w", w.config.Timeout, err)
+	}
+	return err
+}
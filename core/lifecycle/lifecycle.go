@@ -0,0 +1,104 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CloseFunc releases a resource; it should give up once ctx is done
+type CloseFunc func(ctx context.Context) error
+
+type resource struct {
+	name  string
+	close CloseFunc
+}
+
+// Registry closes the resources of the process, such as connection pools, on shutdown
+type Registry struct {
+	mu        sync.Mutex
+	resources []resource
+	closed    bool
+
+	shuttingDown atomic.Bool
+}
+
+// NewRegistry returns an empty registry, most code uses the package level functions instead
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+var defaultRegistry = NewRegistry()
+
+// Default returns the registry of the process used by cmd/serve
+func Default() *Registry {
+	return defaultRegistry
+}
+
+// Register adds a resource closed by Close, after the resources registered later.
+// Registering once the registry is closed closes the resource right away.
+func (reg *Registry) Register(name string, closeFunc CloseFunc) {
+	reg.mu.Lock()
+	if !reg.closed {
+		reg.resources = append(reg.resources, resource{name: name, close: closeFunc})
+		reg.mu.Unlock()
+		return
+	}
+	reg.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	closeResource(ctx, resource{name: name, close: closeFunc})
+}
+
+// BeginShutdown marks the process as shutting down, readiness checks fail from then on
+func (reg *Registry) BeginShutdown() {
+	reg.shuttingDown.Store(true)
+}
+
+// ShuttingDown reports whether BeginShutdown was called
+func (reg *Registry) ShuttingDown() bool {
+	return reg.shuttingDown.Load()
+}
+
+// Close closes the registered resources in reverse order of registration, so a resource
+// is closed before the ones it was built on. Every resource is attempted; the errors are joined.
+func (reg *Registry) Close(ctx context.Context) error {
+	reg.mu.Lock()
+	resources := reg.resources
+	reg.resources = nil
+	reg.closed = true
+	reg.mu.Unlock()
+
+	var errs []error
+	for i := len(resources) - 1; i >= 0; i-- {
+		if err := closeResource(ctx, resources[i]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func closeResource(ctx context.Context, res resource) error {
+	start := time.Now()
+	if err := res.close(ctx); err != nil {
+		slog.ErrorContext(ctx, "Failed to close resource", "resource", res.name, "error", err.Error())
+		return fmt.Errorf("close %s: %w", res.name, err)
+	}
+	slog.InfoContext(ctx, "Closed resource", "resource", res.name, "duration", time.Since(start))
+	return nil
+}
+
+// Register adds a resource to the default registry
+func Register(name string, closeFunc CloseFunc) {
+	defaultRegistry.Register(name, closeFunc)
+}
+
+// ShuttingDown reports whether the process is shutting down
+func ShuttingDown() bool {
+	return defaultRegistry.ShuttingDown()
+}
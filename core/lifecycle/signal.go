@@ -0,0 +1,44 @@
+package lifecycle
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+)
+
+// SignalContext returns a context canceled by the first of signals, which starts the graceful
+// shutdown of the commands running with it. A second signal calls exit(1) right away, for a
+// shutdown that hangs. stop releases the signals and cancels the context without logging.
+func SignalContext(parent context.Context, exit func(code int), signals ...os.Signal) (ctx context.Context, stop context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	received := make(chan os.Signal, 1)
+	signal.Notify(received, signals...)
+	stopped := make(chan struct{})
+
+	go func() {
+		select {
+		case sig := <-received:
+			slog.Info("Shutdown signal received, send it again to exit immediately", "signal", sig.String())
+			cancel()
+		case <-stopped:
+			return
+		}
+		select {
+		case sig := <-received:
+			slog.Warn("Second shutdown signal received, exiting", "signal", sig.String())
+			exit(1)
+		case <-stopped:
+		}
+	}()
+
+	var once sync.Once
+	return ctx, func() {
+		once.Do(func() {
+			signal.Stop(received)
+			close(stopped)
+			cancel()
+		})
+	}
+}
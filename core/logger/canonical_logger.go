@@ -4,10 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"html/template"
 	"log/slog"
 	"strings"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
 	"github.com/yourorg/go-api-template/core/exception"
 )
@@ -56,13 +59,7 @@ func CanonicalLogger(ctx context.Context, slogger slog.Logger, level Level, requ
 	logKey := cannonicalLog.Path
 	var reqfields []any
 	// append request log
-	var jsonObj map[string]interface{}
-
-	if unmarshalErr := json.Unmarshal(request, &jsonObj); unmarshalErr != nil {
-		reqfields = append(reqfields, slog.String("request", string(request)))
-	} else {
-		reqfields = append(reqfields, slog.Any("request", jsonObj))
-	}
+	reqfields = append(reqfields, bodyAttr("request", request))
 
 	shouldSanitize := Sanitize(logKey)
 
@@ -76,8 +73,8 @@ func CanonicalLogger(ctx context.Context, slogger slog.Logger, level Level, requ
 	// append response log
 	if err != nil {
 		level = Error
-		cErr, ok := err.(*exception.ExceptionError)
-		if ok && cErr != nil {
+		cErr, ok := exception.AsExceptionError(err)
+		if ok {
 			if cErr.StackErrors != nil {
 				stackTrace := exception.GetStackField(cErr.StackErrors)
 				stackTraceParts := strings.Split(stackTrace.Stack, "\n\t")
@@ -89,20 +86,21 @@ func CanonicalLogger(ctx context.Context, slogger slog.Logger, level Level, requ
 					slog.String("message", stackTrace.Message),
 					slog.String("stack", stackTrace.Stack),
 				))
-				// slogger.ErrorContext(ctx, "logger debug", slog.Bool("override", cErr.OverrideLogLevel), slog.Any("level", cErr.Level))
-				if cErr.OverrideLogLevel {
-					switch cErr.Level {
-					case exception.LevelDebug:
-						level = Debug
-					case exception.LevelInfo:
-						level = Info
-					case exception.LevelWarn:
-						level = Warn
-					case exception.LevelError:
-						level = Error
-					default:
-						level = Error
-					}
+			}
+			// slogger.ErrorContext(ctx, "logger debug", slog.Bool("override", cErr.OverrideLogLevel), slog.Any("level", cErr.Level))
+			// errors below error level usually carry no stack (see exception.StackConfig)
+			if cErr.OverrideLogLevel {
+				switch cErr.Level {
+				case exception.LevelDebug:
+					level = Debug
+				case exception.LevelInfo:
+					level = Info
+				case exception.LevelWarn:
+					level = Warn
+				case exception.LevelError:
+					level = Error
+				default:
+					level = Error
 				}
 			}
 			respFields = append(respFields, slog.Group("response",
@@ -117,29 +115,11 @@ func CanonicalLogger(ctx context.Context, slogger slog.Logger, level Level, requ
 			cannonicalLog.Message = cErr.DebugMessage
 		} else {
 			// This is the case when the error is not an instance of ExceptionError
-			var jsonObj map[string]interface{}
-			if err := json.Unmarshal(response, &jsonObj); err != nil {
-				respFields = append(respFields, slog.String("response",
-					string(response),
-				))
-			} else {
-				respFields = append(respFields, slog.Any("response",
-					jsonObj,
-				))
-			}
+			respFields = append(respFields, bodyAttr("response", response))
 		}
 	} else {
 		level = Info
-		var jsonObj map[string]interface{}
-		if err := json.Unmarshal(response, &jsonObj); err != nil {
-			respFields = append(respFields, slog.String("response",
-				string(response),
-			))
-		} else {
-			respFields = append(respFields, slog.Any("response",
-				jsonObj,
-			))
-		}
+		respFields = append(respFields, bodyAttr("response", response))
 	}
 	if shouldSanitize {
 		respFields = []any{slog.String("response", "REDACTED")}
@@ -183,6 +163,57 @@ func CanonicalLogger(ctx context.Context, slogger slog.Logger, level Level, requ
 	}
 }
 
+// DefaultMaxBodySize caps the request and response bodies written to canonical logs, in bytes
+const DefaultMaxBodySize = 4096
+
+var maxBodySize atomic.Int64
+
+func init() {
+	maxBodySize.Store(DefaultMaxBodySize)
+}
+
+// SetMaxBodySize sets the largest body logged as is; longer ones are cut.
+// 0 restores DefaultMaxBodySize, a negative size logs only the body length.
+func SetMaxBodySize(size int) {
+	if size == 0 {
+		size = DefaultMaxBodySize
+	}
+	maxBodySize.Store(int64(size))
+}
+
+// jsonBody is a JSON document logged as nested JSON without decoding it first
+type jsonBody []byte
+
+func (b jsonBody) MarshalJSON() ([]byte, error) {
+	return b, nil
+}
+
+func (b jsonBody) MarshalText() ([]byte, error) {
+	return b, nil
+}
+
+// bodyAttr logs body as nested JSON when it is a JSON document within the size cap,
+// otherwise as a string cut at the cap
+func bodyAttr(key string, body []byte) slog.Attr {
+	limit := int(maxBodySize.Load())
+	switch {
+	case limit < 0:
+		return slog.String(key, fmt.Sprintf("(%d bytes)", len(body)))
+	case !utf8.Valid(body):
+		return slog.String(key, fmt.Sprintf("(%d bytes, binary)", len(body)))
+	case len(body) > limit:
+		// back off to a rune boundary so the cut stays valid UTF-8
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(body[cut]) {
+			cut--
+		}
+		return slog.String(key, fmt.Sprintf("%s...(truncated, %d bytes)", body[:cut], len(body)))
+	case len(body) > 0 && json.Valid(body):
+		return slog.Any(key, jsonBody(body))
+	}
+	return slog.String(key, string(body))
+}
+
 var DenyPatterns = []string{
 	"login",
 	"api-key",
@@ -21,6 +21,9 @@ var (
 
 var Env, ServiceName, Version string
 
+// DefaultVersion is dd.version when DD_VERSION is unset, the commands set it to the build version
+var DefaultVersion string
+
 type Field = zap.Field
 
 type LogConfig struct {
@@ -45,6 +48,9 @@ func InitLogger(validateProfile runtime.Environment) {
 	Env = os.Getenv("DD_ENV")
 	ServiceName = os.Getenv("DD_SERVICE")
 	Version = os.Getenv("DD_VERSION")
+	if Version == "" {
+		Version = DefaultVersion
+	}
 
 	Slog = newZapLogger(validateProfile)
 	// Slog = newSlogLogger(validateProfile)
@@ -74,6 +80,7 @@ func (h Handler) Enabled(ctx context.Context, level slog.Level) bool {
 
 func (h Handler) Handle(ctx context.Context, record slog.Record) error {
 	AddDDFields(ctx, &record)
+	AddContextFields(ctx, &record)
 	return h.handler.Handle(ctx, record)
 }
 
@@ -99,6 +106,13 @@ func AddDDFields(ctx context.Context, record *slog.Record) {
 	))
 }
 
+// AddContextFields adds the fields of AddFieldsToContext, e.g. request_id and user_id
+func AddContextFields(ctx context.Context, record *slog.Record) {
+	for k, v := range GetFieldsFromContext(ctx) {
+		record.AddAttrs(slog.Any(k, v))
+	}
+}
+
 func (h Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
 	return Handler{h.handler.WithAttrs(attrs)}
 }
@@ -107,37 +121,30 @@ func (h Handler) WithGroup(name string) slog.Handler {
 	return h.handler.WithGroup(name)
 }
 
+// getLogProfile returns the logger setup of the runtime profile of validateProfile
 func getLogProfile(validateProfile runtime.Environment) LogConfig {
-	switch validateProfile {
-	case "local":
-		return LogConfig{
-			Env:             "local",
-			ServiceName:     ServiceName,
-			Level:           "debug",
-			UseJsonEncoder:  false,
-			StacktraceLevel: "error",
-			FileEnabled:     false,
-			FilePath:        "logs/app.log",
-			FileSize:        100, // megabytes
-			FileCompress:    true,
-			MaxAge:          30, // days
-			MaxBackups:      3,  // number of log files
-		}
-	default:
-		return LogConfig{
-			Env:             Env,
-			ServiceName:     ServiceName,
-			Level:           "debug",
-			UseJsonEncoder:  true,
-			StacktraceLevel: "error",
-			FileEnabled:     false,
-			FilePath:        "logs/app.log",
-			FileSize:        100, // megabytes
-			FileCompress:    true,
-			MaxAge:          30, // days
-			MaxBackups:      3,  // number of log files
-		}
+	profile, _ := runtime.LookupProfile(validateProfile)
+
+	log := LogConfig{
+		Env:             Env,
+		ServiceName:     ServiceName,
+		Level:           "debug",
+		UseJsonEncoder:  profile.LogFormat != "console",
+		StacktraceLevel: "error",
+		FileEnabled:     false,
+		FilePath:        "logs/app.log",
+		FileSize:        100, // megabytes
+		FileCompress:    true,
+		MaxAge:          30, // days
+		MaxBackups:      3,  // number of log files
+	}
+	if log.Env == "" {
+		log.Env = string(validateProfile)
+	}
+	if profile.LogLevel != "" {
+		log.Level = profile.LogLevel
 	}
+	return log
 }
 
 type Pathfinder struct {
@@ -171,17 +178,18 @@ const ContextLogFieldsKey LogFieldsKey = "log_fields"
 
 // AddFieldsToContext adds structured logging fields to context
 func AddFieldsToContext(ctx context.Context, fields map[string]interface{}) context.Context {
-	existing, ok := ctx.Value(ContextLogFieldsKey).(map[string]interface{})
-	if !ok {
-		existing = make(map[string]interface{})
+	existing, _ := ctx.Value(ContextLogFieldsKey).(map[string]interface{})
+
+	// Merge into a copy, the parent context may be logging concurrently
+	merged := make(map[string]interface{}, len(existing)+len(fields))
+	for k, v := range existing {
+		merged[k] = v
 	}
-	
-	// Merge new fields with existing ones
 	for k, v := range fields {
-		existing[k] = v
+		merged[k] = v
 	}
-	
-	return context.WithValue(ctx, ContextLogFieldsKey, existing)
+
+	return context.WithValue(ctx, ContextLogFieldsKey, merged)
 }
 
 // GetFieldsFromContext retrieves logging fields from context
@@ -191,3 +199,17 @@ func GetFieldsFromContext(ctx context.Context) map[string]interface{} {
 	}
 	return make(map[string]interface{})
 }
+
+type debugLogsKey struct{}
+
+// WithDebugLogs enables debug logs of ctx whatever the level of the logger, e.g. for a
+// request traced with X-Debug-Trace
+func WithDebugLogs(ctx context.Context) context.Context {
+	return context.WithValue(ctx, debugLogsKey{}, true)
+}
+
+// DebugLogsEnabled reports whether ctx comes from WithDebugLogs
+func DebugLogsEnabled(ctx context.Context) bool {
+	enabled, _ := ctx.Value(debugLogsKey{}).(bool)
+	return enabled
+}
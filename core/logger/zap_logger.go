@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"context"
 	"os"
 
 	"log/slog"
@@ -32,7 +33,7 @@ func newZapLogger(validateProfile runtime.Environment) *slog.Logger {
 	// STEP 0: Get the log profile based on env
 	log := getLogProfile(validateProfile)
 	// STEP 1: Get the log level
-	zapLogLevel := getZapLogLevel(log.Level)
+	zapLevel.SetLevel(getZapLogLevel(log.Level))
 	//stacktraceLogLevel := getZapLogLevel(log.StacktraceLevel)
 
 	// STEP 2: Set up the file writer
@@ -59,21 +60,21 @@ func newZapLogger(validateProfile runtime.Environment) *slog.Logger {
 	encoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
 	consoleEncoder := zapcore.NewConsoleEncoder(encoderConfig)
 
-	// STEP 6: Set up the core
+	// STEP 6: Set up the core; the cores take every level, levelHandler applies zapLevel
 
 	zapCoreList := []zapcore.Core{}
 	if log.FileEnabled {
-		zapCoreList = append(zapCoreList, zapcore.NewCore(jsonEncoder, fileWriter, zapLogLevel))
+		zapCoreList = append(zapCoreList, zapcore.NewCore(jsonEncoder, fileWriter, zapcore.DebugLevel))
 	}
 
 	if log.UseJsonEncoder {
-		zapCoreList = append(zapCoreList, zapcore.NewCore(jsonEncoder, zapcore.AddSync(os.Stdout), zapLogLevel))
+		zapCoreList = append(zapCoreList, zapcore.NewCore(jsonEncoder, zapcore.AddSync(os.Stdout), zapcore.DebugLevel))
 	}
 
 	var core zapcore.Core
 	// Set up the console for default
 	if len(zapCoreList) == 0 {
-		core = zapcore.NewTee(zapcore.NewCore(consoleEncoder, zapcore.AddSync(os.Stdout), zapLogLevel))
+		core = zapcore.NewTee(zapcore.NewCore(consoleEncoder, zapcore.AddSync(os.Stdout), zapcore.DebugLevel))
 	} else {
 		// Set up the console for the rest
 		core = zapcore.NewTee(zapCoreList...)
@@ -83,13 +84,60 @@ func newZapLogger(validateProfile runtime.Environment) *slog.Logger {
 	//logger := zap.New(core, zap.AddCaller(), zap.AddStacktrace(stacktraceLogLevel), zap.AddCallerSkip(skip))
 
 	// STEP 7: Set up the slog logger
-	logger := slog.New(NewOtelHandler((zapslog.NewHandler(core, &zapslog.HandlerOptions{
+	logger := slog.New(NewOtelHandler(levelHandler{zapslog.NewHandler(core, &zapslog.HandlerOptions{
 		AddSource: true,
-	}))))
+	})}))
 
 	return logger
 }
 
+// zapLevel is the level of the zap logger, shared so SetLevel applies without rebuilding it
+var zapLevel = zap.NewAtomicLevel()
+
+// levelHandler drops the records below zapLevel, except debug records of contexts from
+// WithDebugLogs
+type levelHandler struct {
+	slog.Handler
+}
+
+func (h levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if DebugLogsEnabled(ctx) {
+		return true
+	}
+	return zapLevel.Enabled(zapLevelOf(level))
+}
+
+func (h levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return levelHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h levelHandler) WithGroup(name string) slog.Handler {
+	return levelHandler{h.Handler.WithGroup(name)}
+}
+
+func zapLevelOf(level slog.Level) zapcore.Level {
+	switch {
+	case level >= slog.LevelError:
+		return zapcore.ErrorLevel
+	case level >= slog.LevelWarn:
+		return zapcore.WarnLevel
+	case level >= slog.LevelInfo:
+		return zapcore.InfoLevel
+	default:
+		return zapcore.DebugLevel
+	}
+}
+
+// SetLevel changes the level of the zap logger while running: debug, info, warn or error
+func SetLevel(level string) error {
+	parsed, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	zapLevel.SetLevel(parsed)
+	return nil
+}
+
 func getZapLogLevel(level string) zapcore.Level {
 	switch level {
 	case "debug":
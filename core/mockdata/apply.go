@@ -0,0 +1,128 @@
+package mockdata
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Transaction scopes of ApplyOptions
+const (
+	TransactionBatch = "batch"
+	TransactionTable = "table"
+)
+
+// ApplyOptions executes the mock data of a batch against the target database of the Applier
+type ApplyOptions struct {
+	// Transaction is batch to commit the batch at once, only when every table applied, or table
+	// to commit each table on its own
+	Transaction string
+	// BatchSize caps the rows of each INSERT executed, 0 executes the inserts as generated
+	BatchSize int
+	// DryRun executes the inserts in a transaction rolled back, of the whole batch
+	DryRun bool
+}
+
+// Applier executes generated mock data against a database
+type Applier struct {
+	db               Beginner
+	schema           string
+	statementTimeout time.Duration
+	role             string
+}
+
+// NewApplier creates an applier on db, inserting into the tables of schema, or of the search
+// path of db when empty. Each statement is canceled after statementTimeout, when positive, and
+// runs as role, when set, a role of the user of db granted the inserts only.
+func NewApplier(db Beginner, schema string, statementTimeout time.Duration, role string) *Applier {
+	return &Applier{db: db, schema: schema, statementTimeout: statementTimeout, role: role}
+}
+
+// Begin begins a transaction applying mock data
+func (a *Applier) Begin(ctx context.Context) (*Application, error) {
+	tx, err := a.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error beginning the apply transaction: %w", err)
+	}
+	if err := a.restrict(ctx, tx); err != nil {
+		tx.Rollback(ctx)
+		return nil, fmt.Errorf("error configuring the apply transaction: %w", err)
+	}
+	return &Application{applier: a, tx: tx}, nil
+}
+
+// restrict limits the statements of tx to the timeout and the role of a
+func (a *Applier) restrict(ctx context.Context, tx pgx.Tx) error {
+	if err := restrict(ctx, tx, a.statementTimeout); err != nil {
+		return err
+	}
+	if a.role != "" {
+		if _, err := tx.Exec(ctx, "SET LOCAL ROLE "+pgx.Identifier{a.role}.Sanitize()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Application is a transaction of an Applier
+type Application struct {
+	applier *Applier
+	tx      pgx.Tx
+}
+
+// Apply executes the inserts of result, batchSize rows at most per statement, and returns the
+// rows inserted. The inserts are rendered from the parsed statements, into the schema of the
+// applier, and must only have literal values; a failing statement rolls back the inserts of
+// result only.
+func (app *Application) Apply(ctx context.Context, result Result, batchSize int) (int64, error) {
+	for _, insert := range result.Inserts {
+		for i, row := range insert.Rows {
+			for _, value := range row {
+				if !isLiteral(value) {
+					return 0, fmt.Errorf("row %d of %s has the value %.40q, only literals are applied", i+1, result.Table, value)
+				}
+			}
+		}
+	}
+	savepoint, err := app.tx.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error creating a savepoint: %w", err)
+	}
+	var rows int64
+	for i, statement := range app.applier.Statements(result, batchSize) {
+		tag, err := savepoint.Exec(ctx, statement)
+		if err != nil {
+			if rollbackErr := savepoint.Rollback(ctx); rollbackErr != nil {
+				return 0, fmt.Errorf("error rolling back to the savepoint: %w", rollbackErr)
+			}
+			return 0, fmt.Errorf("error applying statement %d of %s: %w", i+1, result.Table, err)
+		}
+		rows += tag.RowsAffected()
+	}
+	if err := savepoint.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("error releasing the savepoint: %w", err)
+	}
+	return rows, nil
+}
+
+// Commit commits the transaction
+func (app *Application) Commit(ctx context.Context) error {
+	return app.tx.Commit(ctx)
+}
+
+// Rollback rolls back the transaction, a no-op once it committed
+func (app *Application) Rollback(ctx context.Context) error {
+	return app.tx.Rollback(ctx)
+}
+
+// Statements renders the inserts of result for the schema of a, splitting them into statements
+// of batchSize rows at most when positive
+func (a *Applier) Statements(result Result, batchSize int) []string {
+	var statements []string
+	for _, insert := range result.Inserts {
+		statements = append(statements, insert.Statements(a.schema, batchSize)...)
+	}
+	return statements
+}
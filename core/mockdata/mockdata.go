@@ -0,0 +1,357 @@
+// Package mockdata generates mock data for the tables of a schema with an LLM. Each table is
+// asked for as INSERT statements from its CREATE TABLE script; Batch generates related tables
+// parents first and gives a child table the keys generated for its parents, so the rows of the
+// batch satisfy their foreign keys.
+package mockdata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+	"strings"
+
+	core_config "github.com/yourorg/go-api-template/core/config"
+	"github.com/yourorg/go-api-template/core/httpclient"
+	"github.com/yourorg/go-api-template/core/httpclient/completions"
+)
+
+var (
+	// ErrInvalidSQL is returned for a completion without a valid INSERT statement
+	ErrInvalidSQL = errors.New("generated SQL is invalid")
+	// ErrApplyNotConfigured is returned by Batch asked to apply without an Applier, see WithApplier
+	ErrApplyNotConfigured = errors.New("applying mock data is not configured")
+)
+
+// Events of a batch, see Progress
+const (
+	EventBatchStarted   = "batch.started"
+	EventTableStarted   = "table.started"
+	EventTableGenerated = "table.generated"
+	EventTableApplied   = "table.applied"
+	EventTableFailed    = "table.failed"
+	EventTableSkipped   = "table.skipped"
+	EventBatchCompleted = "batch.completed"
+)
+
+// Result is the SQL generated for a table
+type Result struct {
+	Table string `json:"table"`
+	// SQL are the statements generated that passed the validation
+	SQL string `json:"sql"`
+	// Rows is the number of rows inserted by SQL
+	Rows  int               `json:"rows"`
+	Usage completions.Usage `json:"usage"`
+	// Rejected are the statements generated that failed the validation, left out of SQL
+	Rejected []Rejection `json:"rejected,omitempty"`
+	// Inserts are the statements parsed from SQL
+	Inserts []Insert `json:"-"`
+
+	schema Table
+}
+
+// Progress is a step of a batch, sent as the SSE event named after Event
+type Progress struct {
+	Event string `json:"event"`
+	// Tables of the batch in generation order, on batch.started
+	Tables []string `json:"tables,omitempty"`
+	Table  string   `json:"table,omitempty"`
+	// Index of Table in the batch, from 1 to Total
+	Index  int     `json:"index,omitempty"`
+	Total  int     `json:"total"`
+	Result *Result `json:"result,omitempty"`
+	Error  string  `json:"error,omitempty"`
+	// Failed are the tables failed or skipped, on batch.completed
+	Failed []string `json:"failed,omitempty"`
+	// Applied is the number of rows inserted into the target database, for Table on
+	// table.applied and for the batch on batch.completed
+	Applied int64 `json:"applied,omitempty"`
+	// Committed reports whether the rows applied are kept, on batch.completed
+	Committed bool `json:"committed,omitempty"`
+	// Usage is the token usage of the batch so far
+	Usage completions.Usage `json:"usage"`
+}
+
+func (p Progress) EventName() string {
+	return p.Event
+}
+
+// Generator asks an LLM for the rows of tables
+type Generator struct {
+	llm     httpclient.LLMProvider
+	config  core_config.MockDataConfig
+	sandbox Sandbox
+	applier *Applier
+}
+
+// GeneratorOption configures a Generator
+type GeneratorOption func(*Generator)
+
+// WithSandbox checks the generated statements with sandbox, nil skips the check
+func WithSandbox(sandbox Sandbox) GeneratorOption {
+	return func(g *Generator) {
+		g.sandbox = sandbox
+	}
+}
+
+// WithApplier applies the batches asking for it with applier, see ApplyOptions
+func WithApplier(applier *Applier) GeneratorOption {
+	return func(g *Generator) {
+		g.applier = applier
+	}
+}
+
+// NewGenerator returns a generator completing with llm
+func NewGenerator(llm httpclient.LLMProvider, config core_config.MockDataConfig, opts ...GeneratorOption) *Generator {
+	if config.MaxParentKeys <= 0 {
+		config.MaxParentKeys = core_config.Defaults().MockData.MaxParentKeys
+	}
+	g := &Generator{llm: llm, config: config}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// Generate asks for rows of table, its foreign keys taking the keys of the results of parents.
+// The statements generated are parsed, checked against the columns of table and by the sandbox
+// if any; those failing are reported in Result.Rejected. The error wraps ErrInvalidSQL when no
+// statement passes.
+func (g *Generator) Generate(ctx context.Context, table Table, rows int, parents map[string]Result) (Result, error) {
+	result := Result{Table: table.Name, schema: table}
+	resp, err := g.llm.Complete(ctx, completions.CompletionRequest{
+		Model:       g.config.Model,
+		Temperature: g.config.Temperature,
+		MaxTokens:   g.config.MaxTokens,
+		Messages: []completions.MessageRequest{
+			{Role: "system", Content: systemPrompt},
+			{Role: "user", Content: g.prompt(table, rows, parents)},
+		},
+	})
+	if err != nil {
+		return result, fmt.Errorf("error generating %s: %w", table.Name, err)
+	}
+	result.Usage = resp.Usage
+	if len(resp.Choices) == 0 {
+		return result, fmt.Errorf("%w: the completion of %s is empty", ErrInvalidSQL, table.Name)
+	}
+
+	inserts, rejected, err := g.validate(ctx, table, ExtractSQL(resp.Choices[0].Message.Content), parents)
+	if err != nil {
+		return result, err
+	}
+	result.Rejected = rejected
+	if len(inserts) == 0 {
+		if len(rejected) == 0 {
+			return result, fmt.Errorf("%w: no INSERT into %s", ErrInvalidSQL, table.Name)
+		}
+		return result, fmt.Errorf("%w: all statements for %s were rejected, statement %d: %s",
+			ErrInvalidSQL, table.Name, rejected[0].Statement, rejected[0].Reason)
+	}
+	for _, insert := range inserts {
+		result.Rows += len(insert.Rows)
+	}
+	result.SQL = JoinStatements(inserts)
+	result.Inserts = inserts
+	return result, nil
+}
+
+// Batch generates rows for each of tables, parents first, see Order. A table whose SQL is
+// invalid fails and its children are skipped; a failing completion or sandbox ends the batch with
+// its error. With apply, each table generated is inserted into the target database before its
+// children are generated; a table failing to apply fails the same way.
+func (g *Generator) Batch(ctx context.Context, tables []Table, rows int, apply *ApplyOptions) iter.Seq2[Progress, error] {
+	return func(yield func(Progress, error) bool) {
+		ordered, err := Order(tables)
+		if err != nil {
+			yield(Progress{}, err)
+			return
+		}
+		// the application of the whole batch, nil when each table is committed on its own
+		var app *Application
+		if apply != nil {
+			if g.applier == nil {
+				yield(Progress{}, ErrApplyNotConfigured)
+				return
+			}
+			if apply.DryRun || apply.Transaction != TransactionTable {
+				if app, err = g.applier.Begin(ctx); err != nil {
+					yield(Progress{}, err)
+					return
+				}
+				defer app.Rollback(ctx)
+			}
+		}
+		names := make([]string, len(ordered))
+		for i, table := range ordered {
+			names[i] = table.Name
+		}
+		if !yield(Progress{Event: EventBatchStarted, Tables: names, Total: len(ordered)}, nil) {
+			return
+		}
+
+		var usage completions.Usage
+		var failed []string
+		var applied int64
+		applyFailed := false
+		results := make(map[string]Result, len(ordered))
+		for i, table := range ordered {
+			progress := Progress{Table: table.Name, Index: i + 1, Total: len(ordered), Usage: usage}
+			if parent := missingParent(table, names, results); parent != "" {
+				failed = append(failed, table.Name)
+				progress.Event = EventTableSkipped
+				progress.Error = fmt.Sprintf("parent table %s was not generated", parent)
+				if !yield(progress, nil) {
+					return
+				}
+				continue
+			}
+			progress.Event = EventTableStarted
+			if !yield(progress, nil) {
+				return
+			}
+
+			result, err := g.Generate(ctx, table, rows, results)
+			usage = addUsage(usage, result.Usage)
+			progress.Usage = usage
+			progress.Result = &result
+			switch {
+			case errors.Is(err, ErrInvalidSQL):
+				failed = append(failed, table.Name)
+				progress.Event = EventTableFailed
+				progress.Error = err.Error()
+			case err != nil:
+				yield(Progress{}, err)
+				return
+			default:
+				progress.Event = EventTableGenerated
+			}
+			if !yield(progress, nil) {
+				return
+			}
+			if progress.Event != EventTableGenerated {
+				continue
+			}
+			if apply == nil {
+				results[table.Name] = result
+				continue
+			}
+
+			progress = Progress{Event: EventTableApplied, Table: table.Name, Index: i + 1, Total: len(ordered), Usage: usage}
+			if progress.Applied, err = g.apply(ctx, app, result, apply.BatchSize); err != nil {
+				failed = append(failed, table.Name)
+				applyFailed = true
+				progress.Event = EventTableFailed
+				progress.Error = err.Error()
+			} else {
+				results[table.Name] = result
+				applied += progress.Applied
+			}
+			if !yield(progress, nil) {
+				return
+			}
+		}
+
+		completed := Progress{Event: EventBatchCompleted, Total: len(ordered), Failed: failed, Usage: usage, Applied: applied}
+		switch {
+		case apply == nil || apply.DryRun:
+		case app == nil:
+			completed.Committed = applied > 0
+		case applyFailed:
+			completed.Error = "the batch was rolled back, a table failed to apply"
+		default:
+			if err := app.Commit(ctx); err != nil {
+				yield(Progress{}, fmt.Errorf("error committing the batch: %w", err))
+				return
+			}
+			completed.Committed = true
+		}
+		yield(completed, nil)
+	}
+}
+
+// apply inserts result within app, or in a transaction of its own when app is nil
+func (g *Generator) apply(ctx context.Context, app *Application, result Result, batchSize int) (int64, error) {
+	if app != nil {
+		return app.Apply(ctx, result, batchSize)
+	}
+	app, err := g.applier.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer app.Rollback(ctx)
+	rows, err := app.Apply(ctx, result, batchSize)
+	if err != nil {
+		return 0, err
+	}
+	if err := app.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("error committing %s: %w", result.Table, err)
+	}
+	return rows, nil
+}
+
+// missingParent returns a parent of table in the batch without result
+func missingParent(table Table, batch []string, results map[string]Result) string {
+	for _, parent := range table.Parents() {
+		if _, ok := results[parent]; !ok && slices.Contains(batch, parent) {
+			return parent
+		}
+	}
+	return ""
+}
+
+func addUsage(a completions.Usage, b completions.Usage) completions.Usage {
+	return completions.Usage{
+		PromptTokens:     a.PromptTokens + b.PromptTokens,
+		CompletionTokens: a.CompletionTokens + b.CompletionTokens,
+		TotalTokens:      a.TotalTokens + b.TotalTokens,
+	}
+}
+
+const systemPrompt = "You generate realistic mock data for PostgreSQL tables. " +
+	"Answer with INSERT statements only, in a single ```sql code block, without explanations."
+
+// prompt asks for rows of table, listing the keys its foreign keys may take
+func (g *Generator) prompt(table Table, rows int, parents map[string]Result) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Generate %d rows for the table %s:\n\n%s\n\n", rows, table.Name, strings.TrimSpace(table.Script))
+	fmt.Fprintf(&b, "Write one INSERT INTO %s statement listing its columns, with an explicit value for every primary key and foreign key column.\n", table.Name)
+
+	for _, ref := range table.References {
+		if ref.Table == table.Name {
+			fmt.Fprintf(&b, "The column %s references the table itself: use NULL or the key of a previous row.\n", strings.Join(ref.Columns, ", "))
+			continue
+		}
+		parent, ok := parents[ref.Table]
+		if !ok {
+			continue
+		}
+		refColumns := ref.RefColumns
+		if len(refColumns) == 0 {
+			refColumns = parent.schema.PrimaryKey
+		}
+		keys := Values(parent.Inserts, parent.Table, parent.schema.Columns, refColumns)
+		if len(keys) == 0 {
+			continue
+		}
+		if len(keys) > g.config.MaxParentKeys {
+			keys = keys[:g.config.MaxParentKeys]
+		}
+		tuples := make([]string, len(keys))
+		for i, key := range keys {
+			tuples[i] = strings.Join(key, ", ")
+			if len(key) > 1 {
+				tuples[i] = "(" + tuples[i] + ")"
+			}
+		}
+		if len(ref.Columns) == 1 {
+			fmt.Fprintf(&b, "The column %s references %s(%s) and must take one of these values: %s\n",
+				ref.Columns[0], ref.Table, strings.Join(refColumns, ", "), strings.Join(tuples, ", "))
+		} else {
+			fmt.Fprintf(&b, "The columns (%s) reference %s(%s) and must take one of these tuples: %s\n",
+				strings.Join(ref.Columns, ", "), ref.Table, strings.Join(refColumns, ", "), strings.Join(tuples, ", "))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,100 @@
+package mockdata
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Sandbox modes, see core_config.MockDataConfig.Sandbox
+const (
+	SandboxNone    = "none"
+	SandboxExplain = "explain"
+	SandboxExecute = "execute"
+)
+
+// Sandbox runs generated statements against a database without keeping their changes
+type Sandbox interface {
+	// Check returns the error of each of statements, nil for those that pass. setup are the
+	// statements of the parent tables, run first for the foreign keys of statements to hold.
+	Check(ctx context.Context, setup []string, statements []string) ([]error, error)
+}
+
+// Beginner begins transactions, e.g. *pgxpool.Pool
+type Beginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// PostgresSandbox checks statements in a PostgreSQL transaction it always rolls back
+type PostgresSandbox struct {
+	db               Beginner
+	mode             string
+	statementTimeout time.Duration
+}
+
+// NewPostgresSandbox creates a sandbox on db, a database of its own or the write pool. The explain
+// mode only plans the statements, the execute mode runs them, catching the constraint violations
+// as well. Each statement is canceled after statementTimeout, when positive.
+func NewPostgresSandbox(db Beginner, mode string, statementTimeout time.Duration) *PostgresSandbox {
+	return &PostgresSandbox{db: db, mode: mode, statementTimeout: statementTimeout}
+}
+
+func (s *PostgresSandbox) Check(ctx context.Context, setup []string, statements []string) ([]error, error) {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error beginning the sandbox transaction: %w", err)
+	}
+	// nothing the sandbox runs is ever committed
+	defer tx.Rollback(ctx)
+	if err := restrict(ctx, tx, s.statementTimeout); err != nil {
+		return nil, fmt.Errorf("error configuring the sandbox transaction: %w", err)
+	}
+
+	prefix := ""
+	if s.mode == SandboxExplain {
+		prefix = "EXPLAIN "
+	} else {
+		for i, statement := range setup {
+			if _, err := tx.Exec(ctx, statement); err != nil {
+				return nil, fmt.Errorf("error inserting the parent rows, statement %d: %w", i+1, err)
+			}
+		}
+	}
+
+	errs := make([]error, len(statements))
+	for i, statement := range statements {
+		// a savepoint per statement, a failing statement aborts only its own
+		savepoint, err := tx.Begin(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error creating a savepoint: %w", err)
+		}
+		if _, errs[i] = savepoint.Exec(ctx, prefix+statement); errs[i] != nil {
+			if err := savepoint.Rollback(ctx); err != nil {
+				return nil, fmt.Errorf("error rolling back to the savepoint: %w", err)
+			}
+			continue
+		}
+		// released for the next statements to see the rows, e.g. of a self reference
+		if err := savepoint.Commit(ctx); err != nil {
+			return nil, fmt.Errorf("error releasing the savepoint: %w", err)
+		}
+	}
+	return errs, nil
+}
+
+// restrict sets the statement timeout of tx, when positive, and standard_conforming_strings for
+// the quoted literals to end where ValidateInsert found them ending
+func restrict(ctx context.Context, tx pgx.Tx, statementTimeout time.Duration) error {
+	if _, err := tx.Exec(ctx, "SET LOCAL standard_conforming_strings = on"); err != nil {
+		return err
+	}
+	if statementTimeout > 0 {
+		milliseconds := max(statementTimeout.Milliseconds(), 1)
+		if _, err := tx.Exec(ctx, fmt.Sprintf("SET LOCAL statement_timeout = %d", milliseconds)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
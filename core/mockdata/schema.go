@@ -0,0 +1,273 @@
+package mockdata
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Table is a table of the schema repository with the columns and keys parsed from its script
+type Table struct {
+	// Schema qualifying the table in its script, empty when it is not qualified
+	Schema     string
+	Name       string
+	Script     string
+	Columns    []string
+	PrimaryKey []string
+	References []Reference
+}
+
+// Reference is a foreign key of a table
+type Reference struct {
+	Columns []string
+	Table   string
+	// RefColumns are the referenced columns, empty for the primary key of Table
+	RefColumns []string
+}
+
+const identPattern = `(?:"[^"]+"|[\w$]+)`
+
+var (
+	createTablePattern = regexp.MustCompile(`(?is)^\s*CREATE\s+(?:(?:GLOBAL|LOCAL)\s+)?(?:(?:TEMP|TEMPORARY|UNLOGGED)\s+)?TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?(` + identPattern + `(?:\s*\.\s*` + identPattern + `)?)\s*\(`)
+	constraintPattern  = regexp.MustCompile(`(?is)^CONSTRAINT\s+` + identPattern + `\s+`)
+	primaryKeyPattern  = regexp.MustCompile(`(?is)^PRIMARY\s+KEY\s*\(([^)]*)\)`)
+	foreignKeyPattern  = regexp.MustCompile(`(?is)^FOREIGN\s+KEY\s*\(([^)]*)\)`)
+	referencesPattern  = regexp.MustCompile(`(?is)\bREFERENCES\s+(` + identPattern + `(?:\s*\.\s*` + identPattern + `)?)\s*(?:\(([^)]*)\))?`)
+	inlineKeyPattern   = regexp.MustCompile(`(?is)\bPRIMARY\s+KEY\b`)
+	tableOnlyPattern   = regexp.MustCompile(`(?is)^(?:UNIQUE|CHECK|EXCLUDE|LIKE)\b`)
+)
+
+// ParseTable parses the CREATE TABLE script of the table name
+func ParseTable(name string, script string) (Table, error) {
+	table := Table{Name: name, Script: script}
+	script = stripComments(script)
+	match := createTablePattern.FindStringSubmatchIndex(script)
+	if match == nil {
+		return table, fmt.Errorf("script of %s is not a CREATE TABLE statement", name)
+	}
+	table.Schema, _ = qualifiedName(script[match[2]:match[3]])
+	body, err := parenthesized(script[match[1]-1:])
+	if err != nil {
+		return table, fmt.Errorf("script of %s: %w", name, err)
+	}
+
+	for _, def := range splitTopLevel(body, ',') {
+		def = strings.TrimSpace(def)
+		if loc := constraintPattern.FindStringIndex(def); loc != nil {
+			def = def[loc[1]:]
+		}
+		switch {
+		case def == "" || tableOnlyPattern.MatchString(def):
+		case primaryKeyPattern.MatchString(def):
+			table.PrimaryKey = identList(primaryKeyPattern.FindStringSubmatch(def)[1])
+		case foreignKeyPattern.MatchString(def):
+			columns := identList(foreignKeyPattern.FindStringSubmatch(def)[1])
+			if ref := referencesPattern.FindStringSubmatch(def); ref != nil {
+				table.References = append(table.References, Reference{Columns: columns, Table: tableName(ref[1]), RefColumns: identList(ref[2])})
+			}
+		default:
+			column, rest := firstIdent(def)
+			table.Columns = append(table.Columns, column)
+			if inlineKeyPattern.MatchString(rest) {
+				table.PrimaryKey = []string{column}
+			}
+			if ref := referencesPattern.FindStringSubmatch(rest); ref != nil {
+				table.References = append(table.References, Reference{Columns: []string{column}, Table: tableName(ref[1]), RefColumns: identList(ref[2])})
+			}
+		}
+	}
+	if len(table.Columns) == 0 {
+		return table, fmt.Errorf("script of %s has no column", name)
+	}
+	return table, nil
+}
+
+// Parents returns the tables referenced by t, itself excluded
+func (t Table) Parents() []string {
+	var parents []string
+	for _, ref := range t.References {
+		if ref.Table != t.Name && !slices.Contains(parents, ref.Table) {
+			parents = append(parents, ref.Table)
+		}
+	}
+	return parents
+}
+
+// HasColumn reports whether column is a column of t
+func (t Table) HasColumn(column string) bool {
+	return slices.Contains(t.Columns, column)
+}
+
+// ErrCycle is returned by Order for tables referencing each other
+var ErrCycle = errors.New("foreign keys form a cycle")
+
+// Order sorts tables parents first, keeping the order of the tables independent of each other.
+// The references to the table itself and to tables missing from tables are ignored.
+func Order(tables []Table) ([]Table, error) {
+	pending := slices.Clone(tables)
+	ordered := make([]Table, 0, len(tables))
+	placed := make(map[string]bool, len(tables))
+	inBatch := make(map[string]bool, len(tables))
+	for _, t := range tables {
+		inBatch[t.Name] = true
+	}
+
+	for len(pending) > 0 {
+		i := slices.IndexFunc(pending, func(t Table) bool {
+			for _, parent := range t.Parents() {
+				if inBatch[parent] && !placed[parent] {
+					return false
+				}
+			}
+			return true
+		})
+		if i < 0 {
+			names := make([]string, len(pending))
+			for j, t := range pending {
+				names[j] = t.Name
+			}
+			return nil, fmt.Errorf("%w between %s", ErrCycle, strings.Join(names, ", "))
+		}
+		placed[pending[i].Name] = true
+		ordered = append(ordered, pending[i])
+		pending = slices.Delete(pending, i, i+1)
+	}
+	return ordered, nil
+}
+
+// parenthesized returns the content of the parentheses s starts with
+func parenthesized(s string) (string, error) {
+	depth := 0
+	inQuote := byte(0)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case inQuote != 0:
+			if c == inQuote {
+				inQuote = 0
+			}
+		case c == '\'' || c == '"':
+			inQuote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return s[1:i], nil
+			}
+		}
+	}
+	return "", errors.New("unbalanced parentheses")
+}
+
+// splitTopLevel splits s at the separators outside of quotes, parentheses and brackets
+func splitTopLevel(s string, sep byte) []string {
+	var parts []string
+	depth, start := 0, 0
+	inQuote := byte(0)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case inQuote != 0:
+			if c == inQuote {
+				inQuote = 0
+			}
+		case c == '\'' || c == '"':
+			inQuote = c
+		case c == '(' || c == '[':
+			depth++
+		case c == ')' || c == ']':
+			depth--
+		case c == sep && depth == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// stripComments removes the -- and /* */ comments outside of quotes
+func stripComments(s string) string {
+	var b strings.Builder
+	inQuote := byte(0)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case inQuote != 0:
+			if c == inQuote {
+				inQuote = 0
+			}
+		case c == '\'' || c == '"':
+			inQuote = c
+		case c == '-' && i+1 < len(s) && s[i+1] == '-':
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+			if i < len(s) {
+				b.WriteByte('\n')
+			}
+			continue
+		case c == '/' && i+1 < len(s) && s[i+1] == '*':
+			end := strings.Index(s[i+2:], "*/")
+			if end < 0 {
+				return b.String()
+			}
+			i += end + 3
+			b.WriteByte(' ')
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// firstIdent returns the identifier def starts with and the rest of def
+func firstIdent(def string) (string, string) {
+	if strings.HasPrefix(def, `"`) {
+		if end := strings.IndexByte(def[1:], '"'); end >= 0 {
+			return def[1 : end+1], def[end+2:]
+		}
+	}
+	end := strings.IndexAny(def, " \t\r\n")
+	if end < 0 {
+		return normalizeIdent(def), ""
+	}
+	return normalizeIdent(def[:end]), def[end:]
+}
+
+// identList returns the identifiers of a comma separated list
+func identList(s string) []string {
+	var idents []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			idents = append(idents, normalizeIdent(part))
+		}
+	}
+	return idents
+}
+
+// tableName returns the table of a possibly schema qualified name, e.g. users for public.users
+func tableName(s string) string {
+	_, name := qualifiedName(s)
+	return name
+}
+
+// qualifiedName splits a possibly schema qualified name, the schema is empty when it is not qualified
+func qualifiedName(s string) (string, string) {
+	parts := splitTopLevel(s, '.')
+	name := normalizeIdent(strings.TrimSpace(parts[len(parts)-1]))
+	if len(parts) == 1 {
+		return "", name
+	}
+	return normalizeIdent(strings.TrimSpace(parts[0])), name
+}
+
+// normalizeIdent unquotes a quoted identifier and lowercases the others, like PostgreSQL
+func normalizeIdent(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		return s[1 : len(s)-1]
+	}
+	return strings.ToLower(s)
+}
@@ -0,0 +1,186 @@
+package mockdata
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Insert is an INSERT ... VALUES statement of generated SQL
+type Insert struct {
+	// SQL is the statement, without the trailing semicolon
+	SQL string
+	// Schema qualifying Table, empty when the statement does not qualify it
+	Schema string
+	Table  string
+	// Columns listed by the statement, nil when it lists none
+	Columns []string
+	// Rows are the values of each row as SQL literals, e.g. 'Ann' or 42
+	Rows [][]string
+}
+
+var (
+	insertPattern = regexp.MustCompile(`(?is)^INSERT\s+INTO\s+(` + identPattern + `(?:\s*\.\s*` + identPattern + `)?)\s*(?:\(([^)]*)\))?\s*VALUES\s*`)
+	fencePattern  = regexp.MustCompile("(?s)```[a-zA-Z]*\\s*\\n?(.*?)```")
+	clausePattern = regexp.MustCompile(`(?is)^(?:ON\s+CONFLICT|RETURNING)\b`)
+)
+
+// ExtractSQL returns the SQL of a completion, the content of its fenced code blocks if any
+func ExtractSQL(content string) string {
+	blocks := fencePattern.FindAllStringSubmatch(content, -1)
+	if len(blocks) == 0 {
+		return strings.TrimSpace(content)
+	}
+	parts := make([]string, len(blocks))
+	for i, block := range blocks {
+		parts[i] = strings.TrimSpace(block[1])
+	}
+	return strings.Join(parts, "\n")
+}
+
+// SplitStatements returns the statements of sql, comments removed
+func SplitStatements(sql string) []string {
+	var statements []string
+	for _, statement := range splitTopLevel(stripComments(sql), ';') {
+		if statement = strings.TrimSpace(statement); statement != "" {
+			statements = append(statements, statement)
+		}
+	}
+	return statements
+}
+
+// ParseInserts parses the INSERT ... VALUES statements of sql, failing on any other statement
+func ParseInserts(sql string) ([]Insert, error) {
+	var inserts []Insert
+	for i, statement := range SplitStatements(sql) {
+		insert, err := ParseInsert(statement)
+		if err != nil {
+			return nil, fmt.Errorf("statement %d: %w", i+1, err)
+		}
+		inserts = append(inserts, insert)
+	}
+	return inserts, nil
+}
+
+// ParseInsert parses an INSERT ... VALUES statement; ON CONFLICT and RETURNING clauses are ignored
+func ParseInsert(statement string) (Insert, error) {
+	match := insertPattern.FindStringSubmatchIndex(statement)
+	if match == nil {
+		return Insert{}, fmt.Errorf("not an INSERT ... VALUES statement: %.40q", statement)
+	}
+	schema, table := qualifiedName(statement[match[2]:match[3]])
+	insert := Insert{SQL: statement, Schema: schema, Table: table}
+	if match[4] >= 0 {
+		insert.Columns = identList(statement[match[4]:match[5]])
+	}
+
+	rest := strings.TrimSpace(statement[match[1]:])
+	for rest != "" {
+		if !strings.HasPrefix(rest, "(") {
+			if clausePattern.MatchString(rest) {
+				break
+			}
+			return Insert{}, fmt.Errorf("unexpected %.20q in the values of %s", rest, insert.Table)
+		}
+		tuple, err := parenthesized(rest)
+		if err != nil {
+			return Insert{}, fmt.Errorf("values of %s: %w", insert.Table, err)
+		}
+		values := splitTopLevel(tuple, ',')
+		for i := range values {
+			values[i] = strings.TrimSpace(values[i])
+		}
+		if insert.Columns != nil && len(values) != len(insert.Columns) {
+			return Insert{}, fmt.Errorf("row %d of %s has %d values for %d columns", len(insert.Rows)+1, insert.Table, len(values), len(insert.Columns))
+		}
+		insert.Rows = append(insert.Rows, values)
+
+		rest = strings.TrimSpace(rest[len(tuple)+2:])
+		rest = strings.TrimSpace(strings.TrimPrefix(rest, ","))
+	}
+	if len(insert.Rows) == 0 {
+		return Insert{}, fmt.Errorf("INSERT into %s has no values", insert.Table)
+	}
+	return insert, nil
+}
+
+// Statements renders insert into schema, or the search path when empty, in statements of
+// batchSize rows at most when positive. Only the parsed table, columns and values are rendered,
+// the ON CONFLICT and RETURNING clauses of SQL are left out.
+func (insert Insert) Statements(schema string, batchSize int) []string {
+	target := pgx.Identifier{insert.Table}
+	if schema != "" {
+		target = pgx.Identifier{schema, insert.Table}
+	}
+	prefix := "INSERT INTO " + target.Sanitize()
+	if insert.Columns != nil {
+		columns := make([]string, len(insert.Columns))
+		for i, column := range insert.Columns {
+			columns[i] = pgx.Identifier{column}.Sanitize()
+		}
+		prefix += " (" + strings.Join(columns, ", ") + ")"
+	}
+
+	size := batchSize
+	if size <= 0 {
+		size = len(insert.Rows)
+	}
+	var statements []string
+	for start := 0; start < len(insert.Rows); start += size {
+		end := min(start+size, len(insert.Rows))
+		tuples := make([]string, end-start)
+		for i, row := range insert.Rows[start:end] {
+			tuples[i] = "(" + strings.Join(row, ", ") + ")"
+		}
+		statements = append(statements, prefix+" VALUES "+strings.Join(tuples, ", "))
+	}
+	return statements
+}
+
+// JoinStatements returns the SQL of the inserts, one statement per line
+func JoinStatements(inserts []Insert) string {
+	var b strings.Builder
+	for _, insert := range inserts {
+		b.WriteString(insert.SQL)
+		b.WriteString(";\n")
+	}
+	return b.String()
+}
+
+// Values returns the distinct values of columns in the rows of the inserts into table, in order.
+// tableColumns are the columns of the statements listing none.
+func Values(inserts []Insert, table string, tableColumns []string, columns []string) [][]string {
+	var values [][]string
+	seen := map[string]bool{}
+	for _, insert := range inserts {
+		if !strings.EqualFold(insert.Table, table) {
+			continue
+		}
+		listed := insert.Columns
+		if listed == nil {
+			listed = tableColumns
+		}
+		indexes := make([]int, len(columns))
+		for i, column := range columns {
+			indexes[i] = slices.Index(listed, column)
+		}
+	rows:
+		for _, row := range insert.Rows {
+			tuple := make([]string, len(columns))
+			for i, index := range indexes {
+				if index < 0 || index >= len(row) || strings.EqualFold(row[index], "NULL") {
+					continue rows
+				}
+				tuple[i] = row[index]
+			}
+			if key := strings.Join(tuple, "\x00"); !seen[key] {
+				seen[key] = true
+				values = append(values, tuple)
+			}
+		}
+	}
+	return values
+}
@@ -0,0 +1,188 @@
+package mockdata
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Stages of the validation of generated statements, see Rejection
+const (
+	StageStructure = "structure"
+	StageSchema    = "schema"
+	StageSandbox   = "sandbox"
+)
+
+// Rejection is a generated statement left out of a Result
+type Rejection struct {
+	// Statement is the position of the statement in the completion, from 1
+	Statement int    `json:"statement"`
+	SQL       string `json:"sql"`
+	// Stage rejecting the statement: structure, schema or sandbox
+	Stage  string `json:"stage"`
+	Reason string `json:"reason"`
+}
+
+// the type of a cast, e.g. numeric(10, 2), varchar[] or timestamp with time zone
+const typePattern = `[a-z_][a-z0-9_]*(?:\s*\.\s*[a-z_][a-z0-9_]*)?(?:\s+(?:varying|precision))?` +
+	`(?:\s*\(\s*\d+(?:\s*,\s*\d+)?\s*\))?(?:\s+with(?:out)?\s+time\s+zone)?(?:\s*\[\s*\])*`
+
+var (
+	numberPattern  = regexp.MustCompile(`^[+-]?(?:\d+(?:\.\d*)?|\.\d+)(?:[eE][+-]?\d+)?`)
+	keywordPattern = regexp.MustCompile(`(?i)^(?:NULL|TRUE|FALSE|DEFAULT)\b`)
+	typedPattern   = regexp.MustCompile(`(?i)^(?:DATE|TIME|TIMESTAMP|TIMESTAMPTZ|INTERVAL)\s+'`)
+	castPattern    = regexp.MustCompile(`(?i)^::\s*` + typePattern)
+)
+
+// ValidateInsert checks that insert only targets table and its columns, with literal values
+func ValidateInsert(table Table, insert Insert) error {
+	if !strings.EqualFold(insert.Table, table.Name) {
+		return fmt.Errorf("inserts into %s instead of %s", insert.Table, table.Name)
+	}
+	if insert.Schema != "" && !strings.EqualFold(insert.Schema, table.Schema) &&
+		!(table.Schema == "" && strings.EqualFold(insert.Schema, "public")) {
+		return fmt.Errorf("inserts into the schema %s instead of the schema of %s", insert.Schema, table.Name)
+	}
+
+	for i, column := range insert.Columns {
+		if !table.HasColumn(column) {
+			return fmt.Errorf("%s has no column %s", table.Name, column)
+		}
+		if slices.Contains(insert.Columns[:i], column) {
+			return fmt.Errorf("column %s is listed twice", column)
+		}
+	}
+	for i, row := range insert.Rows {
+		if insert.Columns == nil && len(row) > len(table.Columns) {
+			return fmt.Errorf("row %d has %d values for the %d columns of %s", i+1, len(row), len(table.Columns), table.Name)
+		}
+		for _, value := range row {
+			if !isLiteral(value) {
+				return fmt.Errorf("row %d has the value %.40q, only literals are allowed", i+1, value)
+			}
+		}
+	}
+	return nil
+}
+
+// validate parses the statements of sql for table, checking the inserts with ValidateInsert and
+// the sandbox if any. It returns the inserts accepted and the statements rejected.
+func (g *Generator) validate(ctx context.Context, table Table, sql string, parents map[string]Result) ([]Insert, []Rejection, error) {
+	var inserts []Insert
+	var positions []int
+	var rejected []Rejection
+	for i, statement := range SplitStatements(sql) {
+		insert, err := ParseInsert(statement)
+		if err != nil {
+			rejected = append(rejected, Rejection{Statement: i + 1, SQL: statement, Stage: StageStructure, Reason: err.Error()})
+			continue
+		}
+		if err := ValidateInsert(table, insert); err != nil {
+			rejected = append(rejected, Rejection{Statement: i + 1, SQL: statement, Stage: StageSchema, Reason: err.Error()})
+			continue
+		}
+		inserts = append(inserts, insert)
+		positions = append(positions, i+1)
+	}
+	if g.sandbox == nil || len(inserts) == 0 {
+		return inserts, rejected, nil
+	}
+
+	// the sandbox runs the statements rendered from the parsed inserts, never the generated SQL
+	statements := make([]string, len(inserts))
+	for i, insert := range inserts {
+		statements[i] = insert.Statements(insert.Schema, 0)[0]
+	}
+	errs, err := g.sandbox.Check(ctx, ancestorStatements(table, parents), statements)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error checking the SQL of %s: %w", table.Name, err)
+	}
+	var accepted []Insert
+	for i, insert := range inserts {
+		if i < len(errs) && errs[i] != nil {
+			rejected = append(rejected, Rejection{Statement: positions[i], SQL: insert.SQL, Stage: StageSandbox, Reason: errs[i].Error()})
+			continue
+		}
+		accepted = append(accepted, insert)
+	}
+	slices.SortFunc(rejected, func(a, b Rejection) int { return a.Statement - b.Statement })
+	return accepted, rejected, nil
+}
+
+// ancestorStatements returns the statements generated for the ancestors of table, parents first,
+// for the sandbox to insert the rows the statements of table reference
+func ancestorStatements(table Table, results map[string]Result) []string {
+	var ancestors []Table
+	seen := map[string]bool{table.Name: true}
+	queue := table.Parents()
+	for i := 0; i < len(queue); i++ {
+		result, ok := results[queue[i]]
+		if !ok || seen[queue[i]] {
+			continue
+		}
+		seen[queue[i]] = true
+		ancestors = append(ancestors, result.schema)
+		queue = append(queue, result.schema.Parents()...)
+	}
+	// the results are generated in order, ancestors form no cycle
+	ancestors, _ = Order(ancestors)
+
+	var statements []string
+	for _, ancestor := range ancestors {
+		for _, insert := range results[ancestor.Name].Inserts {
+			statements = append(statements, insert.Statements(insert.Schema, 0)...)
+		}
+	}
+	return statements
+}
+
+// isLiteral reports whether value is a constant: a quoted string, a number, NULL, TRUE, FALSE or
+// DEFAULT, optionally typed (DATE '2024-01-31') or cast ('{}'::jsonb). Function calls, operators
+// and subqueries are not literals.
+func isLiteral(value string) bool {
+	rest := strings.TrimSpace(value)
+	if match := typedPattern.FindStringIndex(rest); match != nil {
+		rest = rest[match[1]-1:]
+	}
+	switch {
+	case strings.HasPrefix(rest, "'"):
+		end := quotedEnd(rest)
+		if end < 0 {
+			return false
+		}
+		rest = rest[end:]
+	case numberPattern.MatchString(rest):
+		rest = rest[len(numberPattern.FindString(rest)):]
+	case keywordPattern.MatchString(rest):
+		rest = rest[len(keywordPattern.FindString(rest)):]
+	default:
+		return false
+	}
+	for rest = strings.TrimSpace(rest); rest != ""; rest = strings.TrimSpace(rest) {
+		match := castPattern.FindStringIndex(rest)
+		if match == nil {
+			return false
+		}
+		rest = rest[match[1]:]
+	}
+	return true
+}
+
+// quotedEnd returns the end of the quoted string s starts with, -1 when it is not terminated.
+// Quotes are escaped by doubling them, the sandbox and apply transactions set
+// standard_conforming_strings for backslashes to be plain characters.
+func quotedEnd(s string) int {
+	for i := 1; i < len(s); i++ {
+		if s[i] != '\'' {
+			continue
+		}
+		if i+1 < len(s) && s[i+1] == '\'' {
+			i++
+			continue
+		}
+		return i + 1
+	}
+	return -1
+}
@@ -0,0 +1,285 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yourorg/go-api-template/core/exception"
+)
+
+// NewChannels returns the available channels of cfg by name; client defaults to http.DefaultClient
+func NewChannels(cfg Config, client *http.Client) (map[string]Channel, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	channels := map[string]Channel{}
+	if cfg.Available(ChannelEmail) {
+		email, err := NewEmailChannel(cfg.Email)
+		if err != nil {
+			return nil, err
+		}
+		channels[ChannelEmail] = email
+	}
+	if cfg.Available(ChannelWebhook) {
+		channels[ChannelWebhook] = NewWebhookChannel(cfg.Webhook, client)
+	}
+	if cfg.Available(ChannelSlack) {
+		channels[ChannelSlack] = NewSlackChannel(cfg.Slack, client)
+	}
+	return channels, nil
+}
+
+// EmailChannel sends a plain text email to the address of the notification
+type EmailChannel struct {
+	config EmailConfig
+	from   *mail.Address
+}
+
+// NewEmailChannel returns a channel sending through the SMTP server of cfg
+func NewEmailChannel(cfg EmailConfig) (*EmailChannel, error) {
+	if cfg.Host == "" {
+		return nil, errors.New("notification email host is empty")
+	}
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid notification email sender %q: %w", cfg.From, err)
+	}
+	if cfg.Port == 0 {
+		cfg.Port = DefaultConfig().Email.Port
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultConfig().Email.Timeout
+	}
+	return &EmailChannel{config: cfg, from: from}, nil
+}
+
+func (c *EmailChannel) Send(ctx context.Context, msg Message) error {
+	to, err := mail.ParseAddress(msg.Email)
+	if err != nil {
+		return exception.MarkPermanent(fmt.Errorf("invalid notification email address %q: %w", msg.Email, err))
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.config.Timeout)
+	defer cancel()
+
+	client, err := c.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if c.config.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", c.config.Username, c.config.Password, c.config.Host)); err != nil {
+			return smtpError(err)
+		}
+	}
+	if err := client.Mail(c.from.Address); err != nil {
+		return smtpError(err)
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return smtpError(err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return smtpError(err)
+	}
+	if _, err := w.Write(c.compose(to, msg)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return smtpError(err)
+	}
+	return client.Quit()
+}
+
+// dial connects to the server, in TLS from the start or upgraded with STARTTLS per config.TLS.
+// The connection is bound to the deadline of ctx.
+func (c *EmailChannel) dial(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(c.config.Host, strconv.Itoa(c.config.Port))
+	tlsConfig := &tls.Config{ServerName: c.config.Host}
+
+	var conn net.Conn
+	var err error
+	if c.config.TLS == "tls" {
+		conn, err = (&tls.Dialer{Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to %s: %w", addr, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, c.config.Host)
+	if err != nil {
+		conn.Close()
+		return nil, smtpError(err)
+	}
+	if c.config.TLS == "" || c.config.TLS == "starttls" {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				client.Close()
+				return nil, err
+			}
+		}
+	}
+	return client, nil
+}
+
+// compose returns the message of msg in quoted-printable plain text
+func (c *EmailChannel) compose(to *mail.Address, msg Message) []byte {
+	var buf bytes.Buffer
+	for _, header := range [][2]string{
+		{"From", c.from.String()},
+		{"To", to.String()},
+		{"Subject", mime.QEncoding.Encode("utf-8", stripNewlines(msg.Subject))},
+		{"Date", msg.Time.Format(time.RFC1123Z)},
+		{"Message-ID", "<" + msg.ID + "." + msg.Channel + "@" + domain(c.from.Address) + ">"},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "text/plain; charset=utf-8"},
+		{"Content-Transfer-Encoding", "quoted-printable"},
+	} {
+		buf.WriteString(header[0] + ": " + header[1] + "\r\n")
+	}
+	buf.WriteString("\r\n")
+
+	body := quotedprintable.NewWriter(&buf)
+	body.Write([]byte(msg.Body))
+	body.Close()
+	return buf.Bytes()
+}
+
+func domain(address string) string {
+	if at := strings.LastIndexByte(address, '@'); at >= 0 {
+		return address[at+1:]
+	}
+	return "localhost"
+}
+
+func stripNewlines(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
+
+// smtpError marks the permanent replies of the server, in the 5xx range, as not retried
+func smtpError(err error) error {
+	var reply *textproto.Error
+	if errors.As(err, &reply) && reply.Code >= 500 {
+		return exception.MarkPermanent(err)
+	}
+	return err
+}
+
+// WebhookChannel POSTs the messages as JSON, signed when a secret is configured
+type WebhookChannel struct {
+	config WebhookConfig
+	client *http.Client
+}
+
+// NewWebhookChannel returns a channel posting to cfg.URL
+func NewWebhookChannel(cfg WebhookConfig, client *http.Client) *WebhookChannel {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultConfig().Webhook.Timeout
+	}
+	return &WebhookChannel{config: cfg, client: client}
+}
+
+// Send posts msg; receivers deduplicate on the Idempotency-Key header, a delivery being retried
+// until acknowledged, and check X-Signature-256, the hex HMAC-SHA256 of the body prefixed with
+// sha256=
+func (c *WebhookChannel) Send(ctx context.Context, msg Message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return exception.MarkPermanent(err)
+	}
+	headers := http.Header{}
+	headers.Set("Idempotency-Key", msg.ID)
+	headers.Set("X-Notification-Event", msg.Event)
+	if c.config.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(c.config.Secret))
+		mac.Write(body)
+		headers.Set("X-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	return post(ctx, c.client, c.config.URL, c.config.Timeout, headers, body)
+}
+
+// SlackChannel posts the messages to an incoming webhook, the subject in bold above the body
+type SlackChannel struct {
+	config SlackConfig
+	client *http.Client
+}
+
+// NewSlackChannel returns a channel posting to cfg.WebhookURL
+func NewSlackChannel(cfg SlackConfig, client *http.Client) *SlackChannel {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultConfig().Slack.Timeout
+	}
+	return &SlackChannel{config: cfg, client: client}
+}
+
+func (c *SlackChannel) Send(ctx context.Context, msg Message) error {
+	text := slackEscape(msg.Body)
+	if msg.Subject != "" {
+		text = "*" + slackEscape(msg.Subject) + "*\n" + text
+	}
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return exception.MarkPermanent(err)
+	}
+	return post(ctx, c.client, c.config.WebhookURL, c.config.Timeout, nil, body)
+}
+
+// slackEscape escapes the characters Slack reads as markup, see its message formatting docs
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// post sends body as JSON to url; a status outside 2xx fails, permanently when retrying would
+// be answered the same
+func post(ctx context.Context, client *http.Client, url string, timeout time.Duration, headers http.Header, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return exception.MarkPermanent(err)
+	}
+	for key, values := range headers {
+		r.Header[key] = values
+	}
+	r.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		err := fmt.Errorf("notification endpoint returned status %d", resp.StatusCode)
+		if exception.RetryClassFromHTTPStatus(resp.StatusCode) == exception.RetryPermanent {
+			return exception.MarkPermanent(err)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,311 @@
+// Package notify tells users what happened through email, a webhook or Slack. The channels of an
+// event type come from the routes of the configuration, overridden by the preferences of the
+// user. Notify enqueues a job per channel and the workers deliver them, so a slow or failing
+// channel is retried without delaying the request or the other channels.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yourorg/go-api-template/core/exception"
+	"github.com/yourorg/go-api-template/core/jobs"
+)
+
+// Channels delivering the notifications
+const (
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook"
+	ChannelSlack   = "slack"
+)
+
+// Stores of the preferences
+const (
+	StoreMemory   = "memory"
+	StorePostgres = "postgres"
+)
+
+// DeliverJob is the type of the jobs delivering a notification through a channel
+const DeliverJob = "notification.deliver"
+
+// AnyEvent is the event of the routes and preferences applying to every event type
+const AnyEvent = "*"
+
+// ErrUnknownChannel is returned for a channel that is not one of the channels of the package
+var ErrUnknownChannel = errors.New("unknown notification channel")
+
+// Config selects the channels of the event types and configures them. A channel is available
+// once configured: email with a host, webhook and slack with a URL.
+type Config struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Store keeps the preferences of the users: memory, lost on restart, or postgres, the
+	// notification_preferences table
+	Store string `mapstructure:"store" enum:"memory postgres"`
+	// Queue of the delivery jobs, consumed by the workers of jobs.queues
+	Queue string `mapstructure:"queue"`
+	// MaxAttempts of a delivery before its job is dead-lettered, 0 for jobs.maxAttempts
+	MaxAttempts int `mapstructure:"maxAttempts"`
+	// Routes select the channels of the event types for the users without preferences
+	Routes  []Route       `mapstructure:"routes"`
+	Email   EmailConfig   `mapstructure:"email"`
+	Webhook WebhookConfig `mapstructure:"webhook"`
+	Slack   SlackConfig   `mapstructure:"slack"`
+}
+
+// Route notifies the events of a type through channels
+type Route struct {
+	// Event is an event type, e.g. order.shipped, or * for the types without a route
+	Event    string   `mapstructure:"event"`
+	Channels []string `mapstructure:"channels"`
+}
+
+// EmailConfig sends the notifications by SMTP
+type EmailConfig struct {
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password" secret:"true"`
+	// From is the sender address, e.g. "Example <no-reply@example.com>"
+	From string `mapstructure:"from"`
+	// TLS is starttls, upgrading the connection when the server offers it, tls, for servers
+	// expecting TLS from the start such as on port 465, or none
+	TLS     string        `mapstructure:"tls" enum:"starttls tls none"`
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// WebhookConfig POSTs the notifications as JSON to a URL
+type WebhookConfig struct {
+	URL string `mapstructure:"url"`
+	// Secret signs the bodies with HMAC-SHA256 in the X-Signature-256 header, unsigned when empty
+	Secret  string        `mapstructure:"secret" secret:"true"`
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// SlackConfig posts the notifications to a Slack incoming webhook
+type SlackConfig struct {
+	WebhookURL string        `mapstructure:"webhookUrl" secret:"true"`
+	Timeout    time.Duration `mapstructure:"timeout"`
+}
+
+// DefaultConfig returns default notification configuration, disabled and without channels
+func DefaultConfig() Config {
+	return Config{
+		Store: StoreMemory,
+		Queue: jobs.DefaultQueue,
+		Email: EmailConfig{
+			Port:    587,
+			TLS:     "starttls",
+			Timeout: 10 * time.Second,
+		},
+		Webhook: WebhookConfig{
+			Timeout: 10 * time.Second,
+		},
+		Slack: SlackConfig{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// Available reports whether channel is configured
+func (c Config) Available(channel string) bool {
+	switch channel {
+	case ChannelEmail:
+		return c.Email.Host != ""
+	case ChannelWebhook:
+		return c.Webhook.URL != ""
+	case ChannelSlack:
+		return c.Slack.WebhookURL != ""
+	default:
+		return false
+	}
+}
+
+// KnownChannel reports whether channel is one of the channels of the package
+func KnownChannel(channel string) bool {
+	return channel == ChannelEmail || channel == ChannelWebhook || channel == ChannelSlack
+}
+
+// Notification is what a user is told about an event
+type Notification struct {
+	// Event is the type of the event, e.g. order.shipped
+	Event  string `json:"event"`
+	UserID string `json:"user_id"`
+	// Email is the address of the user, the email channel is skipped without it
+	Email   string `json:"email,omitempty"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+	// Data is sent as is to the webhook
+	Data map[string]any `json:"data,omitempty"`
+}
+
+// Message is a notification on its way to a channel, the payload of the delivery jobs
+type Message struct {
+	// ID identifies the notification, the same for all its channels
+	ID      string    `json:"id"`
+	Channel string    `json:"channel"`
+	Time    time.Time `json:"time"`
+	Notification
+}
+
+// Channel delivers messages. An error retries the delivery unless exception.IsPermanent.
+type Channel interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Preference turns a channel on or off for an event type of a user
+type Preference struct {
+	// Event is an event type, or * for every type; the preferences of a type win over *
+	Event   string `json:"event"`
+	Channel string `json:"channel"`
+	Enabled bool   `json:"enabled"`
+}
+
+// PreferenceStore keeps the preferences of the users
+type PreferenceStore interface {
+	// Preferences returns the preferences of a user
+	Preferences(ctx context.Context, userID string) ([]Preference, error)
+	// SetPreferences replaces the preferences of a user with the same event and channel
+	SetPreferences(ctx context.Context, userID string, prefs []Preference) error
+}
+
+// Notifier enqueues the deliveries of the notifications
+type Notifier struct {
+	config Config
+	store  PreferenceStore
+	queue  jobs.Queue
+	logger *slog.Logger
+	now    func() time.Time
+}
+
+// NewNotifier returns a notifier reading the preferences from store and enqueuing the
+// deliveries on queue; logger defaults to slog.Default
+func NewNotifier(config Config, store PreferenceStore, queue jobs.Queue, logger *slog.Logger) *Notifier {
+	if config.Queue == "" {
+		config.Queue = jobs.DefaultQueue
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &Notifier{
+		config: config,
+		store:  store,
+		queue:  queue,
+		logger: logger.With("component", "notify"),
+		now:    time.Now,
+	}
+}
+
+// Notify enqueues a delivery of n through every channel of its user and event, returning the
+// channels. The channels unavailable, and email for a notification without address, are skipped.
+func (n *Notifier) Notify(ctx context.Context, notification Notification) ([]string, error) {
+	if notification.Event == "" {
+		return nil, errors.New("notification event is empty")
+	}
+	channels, err := n.Channels(ctx, notification.UserID, notification.Event)
+	if err != nil {
+		return nil, err
+	}
+	id, err := uuid.NewV7()
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []jobs.EnqueueOption{jobs.OnQueue(n.config.Queue)}
+	if n.config.MaxAttempts > 0 {
+		opts = append(opts, jobs.WithMaxAttempts(n.config.MaxAttempts))
+	}
+	var enqueued []string
+	for _, channel := range channels {
+		if channel == ChannelEmail && notification.Email == "" {
+			n.logger.DebugContext(ctx, "Notification without email address, email skipped", "event", notification.Event, "user_id", notification.UserID)
+			continue
+		}
+		msg := Message{ID: id.String(), Channel: channel, Time: n.now().UTC(), Notification: notification}
+		if _, err := jobs.Enqueue(ctx, n.queue, DeliverJob, msg, opts...); err != nil {
+			return enqueued, fmt.Errorf("error enqueuing %s notification: %w", channel, err)
+		}
+		enqueued = append(enqueued, channel)
+	}
+	return enqueued, nil
+}
+
+// Channels returns the available channels of the routes of event, turned on and off by the
+// preferences of the user for every event, then for event
+func (n *Notifier) Channels(ctx context.Context, userID string, event string) ([]string, error) {
+	var channels []string
+	for _, match := range []string{event, AnyEvent} {
+		if i := slices.IndexFunc(n.config.Routes, func(r Route) bool { return r.Event == match }); i >= 0 {
+			channels = slices.Clone(n.config.Routes[i].Channels)
+			break
+		}
+	}
+
+	if userID != "" && n.store != nil {
+		prefs, err := n.store.Preferences(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("error reading notification preferences: %w", err)
+		}
+		for _, match := range []string{AnyEvent, event} {
+			for _, pref := range prefs {
+				if pref.Event != match {
+					continue
+				}
+				i := slices.Index(channels, pref.Channel)
+				switch {
+				case pref.Enabled && i < 0:
+					channels = append(channels, pref.Channel)
+				case !pref.Enabled && i >= 0:
+					channels = slices.Delete(channels, i, i+1)
+				}
+			}
+		}
+	}
+
+	return slices.DeleteFunc(channels, func(channel string) bool { return !n.config.Available(channel) }), nil
+}
+
+// Preferences returns the preferences of a user
+func (n *Notifier) Preferences(ctx context.Context, userID string) ([]Preference, error) {
+	if n.store == nil {
+		return nil, nil
+	}
+	return n.store.Preferences(ctx, userID)
+}
+
+// SetPreferences stores preferences of a user, failing with ErrUnknownChannel for a channel
+// not of the package
+func (n *Notifier) SetPreferences(ctx context.Context, userID string, prefs []Preference) error {
+	for _, pref := range prefs {
+		if !KnownChannel(pref.Channel) {
+			return fmt.Errorf("%w: %q", ErrUnknownChannel, pref.Channel)
+		}
+	}
+	if n.store == nil {
+		return errors.New("notification preference store is not configured")
+	}
+	return n.store.SetPreferences(ctx, userID, prefs)
+}
+
+// DeliveryHandler returns the handler of the DeliverJob jobs, sending their message through its
+// channel. A message for a channel missing from channels is dead-lettered.
+func DeliveryHandler(channels map[string]Channel) jobs.Handler {
+	return func(ctx context.Context, job jobs.Job) error {
+		var msg Message
+		if err := job.Decode(&msg); err != nil {
+			return err
+		}
+		channel, ok := channels[msg.Channel]
+		if !ok {
+			return exception.MarkPermanent(fmt.Errorf("notification channel %q is not configured", msg.Channel))
+		}
+		if err := channel.Send(ctx, msg); err != nil {
+			return fmt.Errorf("error sending %s notification %s: %w", msg.Channel, msg.ID, err)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,107 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/yourorg/go-api-template/core/pgdb"
+)
+
+type preferenceKey struct {
+	event   string
+	channel string
+}
+
+// MemoryStore keeps preferences in process; they are lost on restart and not shared between instances
+type MemoryStore struct {
+	mu    sync.Mutex
+	users map[string][]Preference
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{users: map[string][]Preference{}}
+}
+
+func (s *MemoryStore) Preferences(ctx context.Context, userID string) ([]Preference, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Preference(nil), s.users[userID]...), nil
+}
+
+func (s *MemoryStore) SetPreferences(ctx context.Context, userID string, prefs []Preference) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current := s.users[userID]
+	index := make(map[preferenceKey]int, len(current))
+	for i, pref := range current {
+		index[preferenceKey{pref.Event, pref.Channel}] = i
+	}
+	for _, pref := range prefs {
+		key := preferenceKey{pref.Event, pref.Channel}
+		if i, ok := index[key]; ok {
+			current[i] = pref
+			continue
+		}
+		index[key] = len(current)
+		current = append(current, pref)
+	}
+	s.users[userID] = current
+	return nil
+}
+
+const TableName = "notification_preferences"
+
+// PostgresStore keeps preferences in the notification_preferences table, see migrations
+type PostgresStore struct {
+	db pgdb.DBTX
+}
+
+// NewPostgresStore creates a store on db, usually the write pool
+func NewPostgresStore(db pgdb.DBTX) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+func (s *PostgresStore) Preferences(ctx context.Context, userID string) ([]Preference, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT event, channel, enabled
+		FROM `+TableName+`
+		WHERE user_id = $1
+		ORDER BY event, channel`,
+		userID)
+	if err != nil {
+		return nil, fmt.Errorf("error reading notification preferences: %w", err)
+	}
+	defer rows.Close()
+
+	var prefs []Preference
+	for rows.Next() {
+		var p Preference
+		if err := rows.Scan(&p.Event, &p.Channel, &p.Enabled); err != nil {
+			return nil, fmt.Errorf("error reading notification preferences: %w", err)
+		}
+		prefs = append(prefs, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading notification preferences: %w", err)
+	}
+	return prefs, nil
+}
+
+// SetPreferences upserts the preferences one by one; pass a transaction as db to apply them
+// all or none
+func (s *PostgresStore) SetPreferences(ctx context.Context, userID string, prefs []Preference) error {
+	for _, p := range prefs {
+		_, err := s.db.Exec(ctx, `
+			INSERT INTO `+TableName+` (user_id, event, channel, enabled)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (user_id, event, channel) DO UPDATE SET enabled = EXCLUDED.enabled, updated_at = CURRENT_TIMESTAMP`,
+			userID, p.Event, p.Channel, p.Enabled)
+		if err != nil {
+			return fmt.Errorf("error storing notification preference: %w", err)
+		}
+	}
+	return nil
+}
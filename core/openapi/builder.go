@@ -0,0 +1,245 @@
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/yourorg/go-api-template/core/transport/httpserver"
+)
+
+const jsonContentType = "application/json"
+
+// errorSchemaName is the component describing the standard error envelope
+const errorSchemaName = "ErrorResponse"
+
+// Build generates the document for routes, see httpserver.Router.Routes.
+// Request and response schemas come from the types given to httpserver.NewTransport:
+// json tags name the properties, `validate` tags mark required fields and constraints,
+// `path` tags become path parameters and `description`/`example` tags are copied as is.
+func Build(info Info, routes []httpserver.Route) *Document {
+	reg := newSchemaRegistry()
+	reg.schemas[errorSchemaName] = errorSchema()
+
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   map[string]map[string]*Operation{},
+	}
+
+	for _, route := range routes {
+		path, pathParams := templatePath(route.Path)
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = map[string]*Operation{}
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = buildOperation(reg, route, path, pathParams)
+	}
+
+	doc.Components.Schemas = reg.schemas
+	return doc
+}
+
+func buildOperation(reg *schemaRegistry, route httpserver.Route, path string, pathParams []string) *Operation {
+	op := &Operation{
+		OperationID: operationID(route.Method, path),
+		Responses: map[string]Response{
+			"default": {
+				Description: "Error",
+				Content:     jsonContent(&Schema{Ref: "#/components/schemas/" + errorSchemaName}),
+			},
+		},
+	}
+	if tag := pathTag(path); tag != "" {
+		op.Tags = []string{tag}
+	}
+
+	// path parameters are documented even when the request type does not bind them
+	bound := map[string]reflect.StructField{}
+	request := structType(route.Request)
+	if request != nil {
+		for i := 0; i < request.NumField(); i++ {
+			if name := request.Field(i).Tag.Get("path"); name != "" {
+				bound[name] = request.Field(i)
+			}
+		}
+	}
+	for _, name := range pathParams {
+		param := Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}}
+		if field, ok := bound[name]; ok {
+			param.Description = field.Tag.Get("description")
+			param.Schema = reg.schema(field.Type)
+		}
+		op.Parameters = append(op.Parameters, param)
+	}
+
+	if request != nil {
+		op.Parameters = append(op.Parameters, reg.queryParams(request)...)
+	}
+
+	// the transport decodes the JSON body for every method, path-bound fields excluded
+	if request != nil {
+		body := reg.structSchema(request, func(field reflect.StructField) bool {
+			return field.Tag.Get("path") != ""
+		})
+		if len(body.Properties) > 0 {
+			op.RequestBody = &RequestBody{
+				Required: len(body.Required) > 0,
+				Content:  jsonContent(reg.namedBody(request, body)),
+			}
+		}
+	}
+
+	status := successStatus(route.Response)
+	success := Response{Description: http.StatusText(status)}
+	if route.Response != nil && status != http.StatusNoContent {
+		success.Content = jsonContent(reg.schema(route.Response))
+	}
+	op.Responses[strconv.Itoa(status)] = success
+	return op
+}
+
+// queryParams documents the fields of t bound by `query` tags, embedded structs included.
+// Values the transport does not convert, e.g. parsed by a httpserver.QueryBinder, are strings.
+func (reg *schemaRegistry) queryParams(t reflect.Type) []Parameter {
+	var params []Parameter
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := field.Tag.Get("query")
+		if name == "" {
+			if field.Anonymous && field.Type.Kind() == reflect.Struct {
+				params = append(params, reg.queryParams(field.Type)...)
+			}
+			continue
+		}
+
+		schema := &Schema{Type: "string"}
+		switch kind := field.Type.Kind(); {
+		case kind == reflect.Slice && field.Type.Elem().Kind() == reflect.String,
+			kind == reflect.String, kind == reflect.Bool,
+			kind >= reflect.Int && kind <= reflect.Uint64:
+			schema = reg.schema(field.Type)
+			applyRules(schema, field.Type, validateRules(field))
+		}
+		params = append(params, Parameter{
+			Name:        name,
+			In:          "query",
+			Required:    isRequired(validateRules(field)),
+			Description: field.Tag.Get("description"),
+			Schema:      schema,
+		})
+	}
+	return params
+}
+
+// successStatus asks a zero response for its status when the type implements httpserver.StatusCoder
+func successStatus(t reflect.Type) int {
+	if t == nil || !t.Implements(reflect.TypeFor[httpserver.StatusCoder]()) {
+		return http.StatusOK
+	}
+	var zero reflect.Value
+	if t.Kind() == reflect.Ptr {
+		zero = reflect.New(t.Elem())
+	} else {
+		zero = reflect.New(t).Elem()
+	}
+	if status := zero.Interface().(httpserver.StatusCoder).StatusCode(); status >= 100 && status <= 599 {
+		return status
+	}
+	return http.StatusOK
+}
+
+// namedBody registers the request body under the type name when no path fields were removed,
+// so it is shared between operations like response types are
+func (reg *schemaRegistry) namedBody(t reflect.Type, body *Schema) *Schema {
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("path") != "" {
+			return body
+		}
+	}
+	if t.Name() == "" {
+		return body
+	}
+	return reg.schema(t)
+}
+
+// structType returns the struct type behind t, nil for other types
+func structType(t reflect.Type) reflect.Type {
+	if t == nil {
+		return nil
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	return t
+}
+
+// templatePath converts a ServeMux pattern to an OpenAPI path, e.g. /files/{path...} to /files/{path},
+// and returns the parameter names
+func templatePath(pattern string) (string, []string) {
+	path := strings.TrimSuffix(pattern, "{$}")
+	var params []string
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, "{") || !strings.HasSuffix(segment, "}") {
+			continue
+		}
+		name := strings.TrimSuffix(strings.Trim(segment, "{}"), "...")
+		segments[i] = "{" + name + "}"
+		params = append(params, name)
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// pathTag groups operations by the first segment after the API version, e.g. /api/v1/examples -> examples
+func pathTag(path string) string {
+	segments := strings.FieldsFunc(path, func(r rune) bool { return r == '/' })
+	for len(segments) > 0 && (segments[0] == "api" || isVersion(segments[0])) {
+		segments = segments[1:]
+	}
+	if len(segments) == 0 || strings.HasPrefix(segments[0], "{") {
+		return ""
+	}
+	return segments[0]
+}
+
+func isVersion(segment string) bool {
+	return len(segment) > 1 && segment[0] == 'v' && strings.IndexFunc(segment[1:], func(r rune) bool { return !unicode.IsDigit(r) }) < 0
+}
+
+// operationID derives a camel case ID, e.g. GET /api/v1/examples/{id} -> getApiV1ExamplesById
+func operationID(method string, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, segment := range strings.FieldsFunc(path, func(r rune) bool { return r == '/' || r == '-' || r == '_' }) {
+		if strings.HasPrefix(segment, "{") {
+			b.WriteString("By")
+			segment = strings.Trim(segment, "{}")
+		}
+		b.WriteString(strings.ToUpper(segment[:1]) + segment[1:])
+	}
+	return b.String()
+}
+
+func jsonContent(schema *Schema) map[string]MediaType {
+	return map[string]MediaType{jsonContentType: {Schema: schema}}
+}
+
+// errorSchema describes the default error response, see httpserver.WriteError
+func errorSchema() *Schema {
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"status":        {Type: "integer", Format: "int32"},
+			"message":       {Type: "string"},
+			"debug_message": {Type: "string", Description: "only outside production"},
+			"fields":        {Type: "array", Items: &Schema{Type: "string"}},
+			"data":          {Type: "object", AdditionalProperties: &Schema{Type: "string"}},
+		},
+		Required: []string{"status", "message"},
+	}
+}
@@ -0,0 +1,50 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+)
+
+// Handler serves doc as JSON, e.g. at /openapi.json
+func Handler(doc *Document) http.Handler {
+	body, err := json.Marshal(doc)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err != nil {
+			http.Error(w, "error encoding openapi document", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", jsonContentType)
+		w.Write(body)
+	})
+}
+
+// swaggerUIVersion is the swagger-ui-dist release loaded from the CDN
+const swaggerUIVersion = "5"
+
+// SwaggerUIHandler serves a Swagger UI page rendering the document at specURL.
+// The UI assets are loaded from unpkg.com, so the browser needs internet access.
+func SwaggerUIHandler(specURL string, title string) http.Handler {
+	page := fmt.Sprintf(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>%[1]s</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@%[3]s/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@%[3]s/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.onload = () => { window.ui = SwaggerUIBundle({ url: %[2]q, dom_id: "#swagger-ui" }); };
+  </script>
+</body>
+</html>
+`, html.EscapeString(title), specURL, swaggerUIVersion)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(page))
+	})
+}
@@ -0,0 +1,256 @@
+package openapi
+
+import (
+	"encoding/json"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	timeType       = reflect.TypeFor[time.Time]()
+	durationType   = reflect.TypeFor[time.Duration]()
+	rawMessageType = reflect.TypeFor[json.RawMessage]()
+)
+
+// schemaRegistry builds schemas, registering named structs as components
+type schemaRegistry struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func newSchemaRegistry() *schemaRegistry {
+	return &schemaRegistry{
+		schemas: make(map[string]*Schema),
+		names:   make(map[reflect.Type]string),
+	}
+}
+
+// schema returns the schema of t; named structs are returned as a $ref
+func (reg *schemaRegistry) schema(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case durationType:
+		return &Schema{Type: "integer", Format: "int64", Description: "nanoseconds"}
+	case rawMessageType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: reg.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: reg.schema(t.Elem())}
+	case reflect.Struct:
+		return reg.structRef(t)
+	default:
+		// interfaces and anything else accept any value
+		return &Schema{}
+	}
+}
+
+// schemaName returns the component name of a named type; instantiated generics are named
+// after their type arguments, e.g. PagedResponse_Item for PagedResponse[pkg.Item]
+func schemaName(t reflect.Type) string {
+	name, args, generic := strings.Cut(t.Name(), "[")
+	if !generic {
+		return name
+	}
+	for _, arg := range strings.Split(strings.TrimSuffix(args, "]"), ",") {
+		// drop the package path and qualifier, []pkg.Item -> Item
+		arg = arg[strings.LastIndexAny(arg, "./")+1:]
+		name += "_" + strings.Map(func(r rune) rune {
+			if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+				return r
+			}
+			return -1
+		}, arg)
+	}
+	return name
+}
+
+// structRef registers a named struct under components/schemas; anonymous structs are inlined
+func (reg *schemaRegistry) structRef(t reflect.Type) *Schema {
+	if t.Name() == "" {
+		return reg.structSchema(t, nil)
+	}
+	if name, ok := reg.names[t]; ok {
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+
+	name := schemaName(t)
+	// the same name from another package gets the package as prefix
+	if _, taken := reg.schemas[name]; taken {
+		name = path.Base(t.PkgPath()) + "_" + name
+	}
+	reg.names[t] = name
+	// placeholder first, so recursive types refer to the component instead of looping
+	reg.schemas[name] = &Schema{}
+	*reg.schemas[name] = *reg.structSchema(t, nil)
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// structSchema describes the JSON fields of t, skipping fields for which skip returns true
+func (reg *schemaRegistry) structSchema(t reflect.Type, skip func(reflect.StructField) bool) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	reg.addFields(schema, t, skip)
+	if len(schema.Properties) == 0 {
+		schema.Properties = nil
+	}
+	return schema
+}
+
+func (reg *schemaRegistry) addFields(schema *Schema, t reflect.Type, skip func(reflect.StructField) bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if skip != nil && skip(field) {
+			continue
+		}
+
+		name, ok := jsonName(field)
+		if !ok {
+			continue
+		}
+		// untagged embedded structs are flattened like encoding/json does
+		if field.Anonymous && field.Tag.Get("json") == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				reg.addFields(schema, embedded, skip)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		property := reg.schema(field.Type)
+		rules := validateRules(field)
+		if property.Ref != "" && (field.Tag.Get("description") != "" || field.Type.Kind() == reflect.Ptr) {
+			// siblings of $ref are ignored in OpenAPI 3.0, wrap it instead
+			property = allOf(property, field.Type.Kind() == reflect.Ptr)
+		} else if field.Type.Kind() == reflect.Ptr {
+			property.Nullable = true
+		}
+		if description := field.Tag.Get("description"); description != "" {
+			property.Description = description
+		}
+		if example := field.Tag.Get("example"); example != "" {
+			property.Example = example
+		}
+		applyRules(property, field.Type, rules)
+
+		schema.Properties[name] = property
+		if isRequired(rules) {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+// allOf wraps a $ref so a description or nullable can be set next to it
+func allOf(ref *Schema, nullable bool) *Schema {
+	return &Schema{Nullable: nullable, AllOf: []*Schema{ref}}
+}
+
+// jsonName returns the JSON property name of field; ok is false for fields encoding/json skips
+func jsonName(field reflect.StructField) (name string, ok bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	name, _, _ = strings.Cut(tag, ",")
+	if name == "" {
+		name = field.Name
+	}
+	return name, true
+}
+
+// isRequired reports a required rule, `validate:"true"` being its legacy spelling
+func isRequired(rules map[string]string) bool {
+	_, required := rules["required"]
+	_, legacy := rules["true"]
+	return required || legacy
+}
+
+// validateRules parses the `validate` tag, e.g. "required,min=3" -> {required: "", min: "3"}
+func validateRules(field reflect.StructField) map[string]string {
+	rules := map[string]string{}
+	for _, rule := range strings.Split(field.Tag.Get("validate"), ",") {
+		name, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		if name != "" {
+			rules[name] = param
+		}
+	}
+	return rules
+}
+
+// applyRules maps validator rules onto schema constraints
+func applyRules(schema *Schema, t reflect.Type, rules map[string]string) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	isString := t.Kind() == reflect.String
+
+	for rule, param := range rules {
+		switch rule {
+		case "email":
+			schema.Format = "email"
+		case "url":
+			schema.Format = "uri"
+		case "uuid", "uuid4":
+			schema.Format = "uuid"
+		case "oneof":
+			schema.Enum = strings.Fields(param)
+		case "min", "max", "gte", "lte":
+			n, err := strconv.ParseFloat(param, 64)
+			if err != nil {
+				continue
+			}
+			lower := rule == "min" || rule == "gte"
+			switch {
+			case isString && lower:
+				schema.MinLength = intPtr(int(n))
+			case isString:
+				schema.MaxLength = intPtr(int(n))
+			case t.Kind() == reflect.Slice || t.Kind() == reflect.Map:
+				// item counts are not expressed
+			case lower:
+				schema.Minimum = &n
+			default:
+				schema.Maximum = &n
+			}
+		}
+	}
+}
+
+func intPtr(n int) *int {
+	return &n
+}
@@ -0,0 +1,33 @@
+package outbox
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const meterName = "github.com/yourorg/go-api-template/core/outbox"
+
+var (
+	deadLetterCounter     metric.Int64Counter
+	deadLetterCounterOnce sync.Once
+)
+
+// recordDeadLetter counts a message the poller gave up on, by topic
+func recordDeadLetter(ctx context.Context, topic string) {
+	deadLetterCounterOnce.Do(func() {
+		// instruments from the global meter follow a provider installed later
+		deadLetterCounter, _ = otel.Meter(meterName).Int64Counter(
+			"outbox.dead_letters",
+			metric.WithDescription("Number of outbox messages dead-lettered out of attempts"),
+			metric.WithUnit("{message}"),
+		)
+	})
+	if deadLetterCounter == nil {
+		return
+	}
+	deadLetterCounter.Add(ctx, 1, metric.WithAttributes(attribute.String("messaging.destination.name", topic)))
+}
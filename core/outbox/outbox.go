@@ -0,0 +1,122 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yourorg/go-api-template/core/pgdb"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+const TableName = "outbox_messages"
+
+// Config holds outbox poller configuration
+type Config struct {
+	Enabled      bool          `mapstructure:"enabled"`
+	PollInterval time.Duration `mapstructure:"pollInterval"`
+	BatchSize    int           `mapstructure:"batchSize"`
+	MaxAttempts  int           `mapstructure:"maxAttempts"`
+	BaseBackoff  time.Duration `mapstructure:"baseBackoff"`
+	MaxBackoff   time.Duration `mapstructure:"maxBackoff"`
+	Sink         SinkConfig    `mapstructure:"sink"`
+}
+
+// SinkConfig selects and configures where pending messages are published
+type SinkConfig struct {
+	// Type is webhook, POSTing each message, or eventbus, publishing it to its topic on the event bus
+	Type       string            `mapstructure:"type" enum:"webhook eventbus"`
+	WebhookURL string            `mapstructure:"webhookUrl"`
+	Headers    map[string]string `mapstructure:"headers"`
+	Timeout    time.Duration     `mapstructure:"timeout"`
+}
+
+// DefaultConfig returns default outbox configuration
+func DefaultConfig() Config {
+	return Config{
+		PollInterval: time.Second,
+		BatchSize:    100,
+		MaxAttempts:  10,
+		BaseBackoff:  time.Second,
+		MaxBackoff:   10 * time.Minute,
+		Sink: SinkConfig{
+			Type:    "webhook",
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// Message is a single event stored in the outbox table
+type Message struct {
+	ID        uuid.UUID         `json:"id"`
+	Topic     string            `json:"topic"`
+	Key       string            `json:"key,omitempty"`
+	Payload   json.RawMessage   `json:"payload"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Attempts  int               `json:"attempts"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// Enqueue stores a message in the outbox. Pass the pgx.Tx from
+// pgdb.WithinTransaction so the message is committed atomically with the
+// business change; it is published later by the Poller.
+func Enqueue(ctx context.Context, db pgdb.DBTX, topic string, key string, payload any, headers map[string]string) (uuid.UUID, error) {
+	if topic == "" {
+		return uuid.Nil, errors.New("outbox topic is empty")
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("error marshalling outbox payload: %w", err)
+	}
+
+	if headers == nil {
+		headers = map[string]string{}
+	}
+	headerBody, err := json.Marshal(headers)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("error marshalling outbox headers: %w", err)
+	}
+
+	id, err := uuid.NewV7()
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	_, err = db.Exec(ctx,
+		`INSERT INTO `+TableName+` (id, topic, message_key, payload, headers) VALUES ($1, $2, $3, $4, $5)`,
+		id, topic, key, body, headerBody,
+	)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("error enqueueing outbox message: %w", err)
+	}
+
+	return id, nil
+}
+
+// HeaderEventType holds the event type of the messages of EnqueueEvent
+const HeaderEventType = "event-type"
+
+// EnqueueEvent stores a domain event for the eventbus sink, which publishes it as an event of
+// type eventType whose ID is the message ID, so consumers deduplicate redeliveries with
+// eventbus.Idempotent. The trace context of ctx goes along, the handlers continue the trace.
+//
+//	err := pgdb.WithinTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+//		if _, err := tx.Exec(ctx, `INSERT INTO orders ...`); err != nil {
+//			return err
+//		}
+//		_, err := outbox.EnqueueEvent(ctx, tx, "orders", "order.created", order.ID, order)
+//		return err
+//	})
+func EnqueueEvent(ctx context.Context, db pgdb.DBTX, topic string, eventType string, key string, data any) (uuid.UUID, error) {
+	if eventType == "" {
+		return uuid.Nil, errors.New("outbox event type is empty")
+	}
+	headers := map[string]string{HeaderEventType: eventType}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(headers))
+	return Enqueue(ctx, db, topic, key, data, headers)
+}
@@ -0,0 +1,137 @@
+package outbox
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/yourorg/go-api-template/core/backoff"
+	"github.com/yourorg/go-api-template/core/exception"
+)
+
+// Poller periodically publishes the pending messages of a Store to a Sink.
+// With the PostgresStore several instances can run side by side without
+// publishing the same message concurrently.
+type Poller struct {
+	config Config
+	store  Store
+	sink   Sink
+	logger *slog.Logger
+}
+
+// NewPoller creates a new outbox poller
+func NewPoller(config Config, store Store, sink Sink, logger *slog.Logger) *Poller {
+	defaults := DefaultConfig()
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaults.PollInterval
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaults.BatchSize
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaults.MaxAttempts
+	}
+	if config.BaseBackoff <= 0 {
+		config.BaseBackoff = defaults.BaseBackoff
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = defaults.MaxBackoff
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &Poller{
+		config: config,
+		store:  store,
+		sink:   sink,
+		logger: logger.With("component", "outbox"),
+	}
+}
+
+// Run polls until ctx is cancelled
+func (p *Poller) Run(ctx context.Context) error {
+	ticker := time.NewTicker(p.config.PollInterval)
+	defer ticker.Stop()
+
+	p.logger.InfoContext(ctx, "Outbox poller started", "interval", p.config.PollInterval.String())
+	for {
+		select {
+		case <-ctx.Done():
+			p.logger.InfoContext(ctx, "Outbox poller stopped")
+			return nil
+		case <-ticker.C:
+			// Keep draining while full batches come back
+			for {
+				n, err := p.ProcessBatch(ctx)
+				if err != nil {
+					p.logger.ErrorContext(ctx, "Error processing outbox batch", "error", err)
+					break
+				}
+				if n < p.config.BatchSize || ctx.Err() != nil {
+					break
+				}
+			}
+		}
+	}
+}
+
+// ProcessBatch publishes one batch of due messages and returns how many were picked up
+func (p *Poller) ProcessBatch(ctx context.Context) (int, error) {
+	processed := 0
+	err := p.store.Claim(ctx, p.config.BatchSize, p.config.MaxAttempts, func(ctx context.Context, messages []Message, outcomes Outcomes) error {
+		processed = len(messages)
+
+		for _, msg := range messages {
+			if err := p.publish(ctx, msg); err != nil {
+				attempts := msg.Attempts + 1
+				if exception.IsPermanent(err) {
+					// stop retrying
+					attempts = max(attempts, p.config.MaxAttempts)
+				}
+				if attempts >= p.config.MaxAttempts {
+					if err := p.bury(ctx, outcomes, msg, attempts, err); err != nil {
+						return err
+					}
+					continue
+				}
+				nextAttempt := time.Now().Add(backoff.Exponential(p.config.BaseBackoff, p.config.MaxBackoff, attempts))
+				p.logger.WarnContext(ctx, "Failed to publish outbox message",
+					"id", msg.ID.String(),
+					"topic", msg.Topic,
+					"attempts", attempts,
+					"error", err,
+				)
+				if err := outcomes.Failed(ctx, msg.ID, attempts, err.Error(), nextAttempt); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := outcomes.Published(ctx, msg.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	return processed, err
+}
+
+// bury dead-letters a message out of attempts; it stays in the store for inspection
+func (p *Poller) bury(ctx context.Context, outcomes Outcomes, msg Message, attempts int, err error) error {
+	p.logger.ErrorContext(ctx, "Outbox message dead-lettered",
+		"id", msg.ID.String(),
+		"topic", msg.Topic,
+		"attempts", attempts,
+		"error", err,
+	)
+	recordDeadLetter(ctx, msg.Topic)
+	return outcomes.Dead(ctx, msg.ID, attempts, err.Error())
+}
+
+// publish calls the sink, turning a panic into a failed attempt
+func (p *Poller) publish(ctx context.Context, msg Message) (err error) {
+	defer exception.Recover(ctx, &err)
+	return p.sink.Publish(ctx, msg)
+}
@@ -0,0 +1,149 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/yourorg/go-api-template/core/eventbus"
+	"github.com/yourorg/go-api-template/core/exception"
+)
+
+// Sink types
+const (
+	SinkWebhook  = "webhook"
+	SinkEventBus = "eventbus"
+)
+
+// Sink publishes outbox messages to an external system.
+// Publish must return an error unless the message was durably accepted;
+// the poller will retry it later (at-least-once delivery) unless the error
+// is marked with exception.MarkPermanent.
+type Sink interface {
+	Publish(ctx context.Context, msg Message) error
+}
+
+// SinkFunc adapts a plain function to the Sink interface
+type SinkFunc func(ctx context.Context, msg Message) error
+
+func (f SinkFunc) Publish(ctx context.Context, msg Message) error {
+	return f(ctx, msg)
+}
+
+// NewSink builds the sink selected in the configuration; bus is the bus of the eventbus sink
+func NewSink(cfg SinkConfig, bus *eventbus.Bus) (Sink, error) {
+	switch cfg.Type {
+	case "", SinkWebhook:
+		return NewWebhookSink(cfg)
+	case SinkEventBus:
+		if bus == nil {
+			return nil, errors.New("outbox eventbus sink requires the event bus")
+		}
+		return NewEventBusSink(bus), nil
+	default:
+		return nil, fmt.Errorf("unsupported outbox sink type: %s", cfg.Type)
+	}
+}
+
+// webhookSink POSTs each message as JSON to a fixed URL
+type webhookSink struct {
+	url        string
+	headers    map[string]string
+	httpClient *http.Client
+}
+
+// NewWebhookSink creates a sink that POSTs messages to cfg.WebhookURL
+func NewWebhookSink(cfg SinkConfig) (Sink, error) {
+	if cfg.WebhookURL == "" {
+		return nil, errors.New("outbox webhook url is empty")
+	}
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+
+	return &webhookSink{
+		url:        cfg.WebhookURL,
+		headers:    cfg.Headers,
+		httpClient: &http.Client{Timeout: timeout},
+	}, nil
+}
+
+func (s *webhookSink) Publish(ctx context.Context, msg Message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	r.Header.Set("Content-Type", "application/json")
+	// Receivers should deduplicate on this header since delivery is at-least-once
+	r.Header.Set("Idempotency-Key", msg.ID.String())
+	r.Header.Set("X-Outbox-Topic", msg.Topic)
+	for k, v := range s.headers {
+		r.Header.Set(k, v)
+	}
+	for k, v := range msg.Headers {
+		r.Header.Set(k, v)
+	}
+
+	resp, err := s.httpClient.Do(r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		err := fmt.Errorf("webhook returned status %d", resp.StatusCode)
+		if exception.RetryClassFromHTTPStatus(resp.StatusCode) == exception.RetryPermanent {
+			return exception.MarkPermanent(err)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// eventBusSink publishes messages to their topic on the event bus
+type eventBusSink struct {
+	bus *eventbus.Bus
+}
+
+// NewEventBusSink creates a sink publishing each message as an event, the message ID as event
+// ID and the type from HeaderEventType, or the topic for messages enqueued without one
+func NewEventBusSink(bus *eventbus.Bus) Sink {
+	return &eventBusSink{bus: bus}
+}
+
+func (s *eventBusSink) Publish(ctx context.Context, msg Message) error {
+	headers := make(map[string]string, len(msg.Headers))
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	eventType := headers[HeaderEventType]
+	delete(headers, HeaderEventType)
+	if eventType == "" {
+		eventType = msg.Topic
+	}
+
+	return s.bus.PublishEnvelope(ctx, eventbus.Envelope{
+		ID:      msg.ID.String(),
+		Type:    eventType,
+		Topic:   msg.Topic,
+		Key:     msg.Key,
+		Time:    msg.CreatedAt,
+		Headers: headers,
+		Data:    msg.Payload,
+	})
+}
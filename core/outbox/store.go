@@ -0,0 +1,112 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/yourorg/go-api-template/core/pgdb"
+)
+
+// Store holds the outbox messages of the Poller
+type Store interface {
+	// Claim calls fn with up to limit messages due for publishing, neither dead nor with
+	// maxAttempts attempts, oldest first. The messages stay claimed from the other pollers until fn returns;
+	// the outcomes fn records are kept only when it returns nil.
+	Claim(ctx context.Context, limit int, maxAttempts int, fn func(ctx context.Context, messages []Message, outcomes Outcomes) error) error
+}
+
+// Outcomes records the result of publishing the messages of a Claim
+type Outcomes interface {
+	// Published marks the message published
+	Published(ctx context.Context, id uuid.UUID) error
+	// Failed records a failed attempt; the message is due again at nextAttempt
+	Failed(ctx context.Context, id uuid.UUID, attempts int, lastError string, nextAttempt time.Time) error
+	// Dead records the last failed attempt of a message, which is never claimed again but stays
+	// in the store for inspection
+	Dead(ctx context.Context, id uuid.UUID, attempts int, lastError string) error
+}
+
+// PostgresStore keeps the messages in the outbox table of the write pool. Rows are locked with
+// FOR UPDATE SKIP LOCKED so several instances can poll side by side without publishing the
+// same message concurrently.
+type PostgresStore struct{}
+
+// NewPostgresStore creates a store on the outbox table
+func NewPostgresStore() *PostgresStore {
+	return &PostgresStore{}
+}
+
+func (s *PostgresStore) Claim(ctx context.Context, limit int, maxAttempts int, fn func(ctx context.Context, messages []Message, outcomes Outcomes) error) error {
+	return pgdb.WithinTransaction(ctx, func(ctx context.Context, tx pgx.Tx) error {
+		messages, err := fetchDue(ctx, tx, limit, maxAttempts)
+		if err != nil {
+			return err
+		}
+		return fn(ctx, messages, postgresOutcomes{tx: tx})
+	})
+}
+
+func fetchDue(ctx context.Context, tx pgx.Tx, limit int, maxAttempts int) ([]Message, error) {
+	rows, err := tx.Query(ctx,
+		`SELECT id, topic, COALESCE(message_key, ''), payload, headers, attempts, created_at
+		FROM `+TableName+`
+		WHERE published_at IS NULL AND dead_at IS NULL AND attempts < $1 AND next_attempt_at <= CURRENT_TIMESTAMP
+		ORDER BY created_at
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED`,
+		maxAttempts, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []Message
+	for rows.Next() {
+		var msg Message
+		var headers []byte
+		if err := rows.Scan(&msg.ID, &msg.Topic, &msg.Key, &msg.Payload, &headers, &msg.Attempts, &msg.CreatedAt); err != nil {
+			return nil, err
+		}
+		if len(headers) > 0 {
+			if err := json.Unmarshal(headers, &msg.Headers); err != nil {
+				return nil, err
+			}
+		}
+		messages = append(messages, msg)
+	}
+
+	return messages, rows.Err()
+}
+
+// postgresOutcomes updates the claimed rows within the transaction of the claim
+type postgresOutcomes struct {
+	tx pgx.Tx
+}
+
+func (o postgresOutcomes) Published(ctx context.Context, id uuid.UUID) error {
+	_, err := o.tx.Exec(ctx,
+		`UPDATE `+TableName+` SET attempts = attempts + 1, published_at = CURRENT_TIMESTAMP, last_error = NULL WHERE id = $1`,
+		id,
+	)
+	return err
+}
+
+func (o postgresOutcomes) Failed(ctx context.Context, id uuid.UUID, attempts int, lastError string, nextAttempt time.Time) error {
+	_, err := o.tx.Exec(ctx,
+		`UPDATE `+TableName+` SET attempts = $2, last_error = $3, next_attempt_at = $4 WHERE id = $1`,
+		id, attempts, lastError, nextAttempt,
+	)
+	return err
+}
+
+func (o postgresOutcomes) Dead(ctx context.Context, id uuid.UUID, attempts int, lastError string) error {
+	_, err := o.tx.Exec(ctx,
+		`UPDATE `+TableName+` SET attempts = $2, last_error = $3, dead_at = CURRENT_TIMESTAMP WHERE id = $1`,
+		id, attempts, lastError,
+	)
+	return err
+}
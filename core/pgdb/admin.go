@@ -0,0 +1,72 @@
+package pgdb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// defaultAdminDatabase is the maintenance database every Postgres server has
+const defaultAdminDatabase = "postgres"
+
+// AdminConfig returns the connection to the maintenance database of the write host, with the
+// admin credentials or those of write when they are not set
+func (p Postgres) AdminConfig() PostgresConfig {
+	cfg := p.Write
+	if p.Admin.Username != "" {
+		cfg.Username = p.Admin.Username
+		cfg.Password = p.Admin.Password
+	}
+	cfg.Database = p.Admin.Database
+	if cfg.Database == "" {
+		cfg.Database = defaultAdminDatabase
+	}
+	cfg.Schema = "public"
+	return cfg
+}
+
+// Connect opens a single connection, for administration outside of the pools
+func Connect(ctx context.Context, postgresConfig PostgresConfig) (*pgx.Conn, error) {
+	return pgx.Connect(ctx, connString(postgresConfig))
+}
+
+// DatabaseExists reports whether the server of conn has the database name
+func DatabaseExists(ctx context.Context, conn *pgx.Conn, name string) (bool, error) {
+	var exists bool
+	err := conn.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM pg_database WHERE datname = $1)`, name).Scan(&exists)
+	return exists, err
+}
+
+// CreateDatabase creates the database name owned by owner, the connected user when empty.
+// It reports false when the database already exists.
+func CreateDatabase(ctx context.Context, conn *pgx.Conn, name string, owner string) (bool, error) {
+	exists, err := DatabaseExists(ctx, conn, name)
+	if err != nil || exists {
+		return false, err
+	}
+
+	// CREATE DATABASE takes no parameters, the identifiers are quoted instead
+	sql := "CREATE DATABASE " + pgx.Identifier{name}.Sanitize()
+	if owner != "" {
+		sql += " OWNER " + pgx.Identifier{owner}.Sanitize()
+	}
+	if _, err := conn.Exec(ctx, sql); err != nil {
+		return false, fmt.Errorf("failed to create database %s: %w", name, err)
+	}
+	return true, nil
+}
+
+// DropDatabase drops the database name, disconnecting its sessions. It reports false when the
+// database does not exist.
+func DropDatabase(ctx context.Context, conn *pgx.Conn, name string) (bool, error) {
+	exists, err := DatabaseExists(ctx, conn, name)
+	if err != nil || !exists {
+		return false, err
+	}
+
+	if _, err := conn.Exec(ctx, "DROP DATABASE "+pgx.Identifier{name}.Sanitize()+" WITH (FORCE)"); err != nil {
+		return false, fmt.Errorf("failed to drop database %s: %w", name, err)
+	}
+	return true, nil
+}
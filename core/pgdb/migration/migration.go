@@ -0,0 +1,185 @@
+// Package migration reads the SQL migrations directory and works out which
+// files golang-migrate would apply, so commands can preview or gate on them
+// without touching the database.
+package migration
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Direction is the way a migration file moves the schema
+type Direction string
+
+const (
+	Up   Direction = "up"
+	Down Direction = "down"
+)
+
+// ErrUnknownVersion is returned when a target version has no migration file
+var ErrUnknownVersion = errors.New("no migration with this version")
+
+// Migration is a versioned pair of up/down SQL files
+type Migration struct {
+	Version  uint
+	Name     string
+	UpPath   string
+	DownPath string
+}
+
+// Step is a single migration file to run in a direction
+type Step struct {
+	Migration
+	Direction Direction
+}
+
+// Path returns the SQL file run by the step
+func (s Step) Path() string {
+	if s.Direction == Down {
+		return s.DownPath
+	}
+	return s.UpPath
+}
+
+// Load returns the migrations of dir sorted by version. Files are named
+// <version>_<name>.(up|down).sql as golang-migrate expects.
+func Load(dir string) ([]Migration, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[uint]*Migration)
+	for _, path := range paths {
+		base := filepath.Base(path)
+		stem, direction, ok := cutDirection(base)
+		if !ok {
+			continue
+		}
+		prefix, name, _ := strings.Cut(stem, "_")
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s has no version prefix", path)
+		}
+
+		m, ok := byVersion[uint(version)]
+		if !ok {
+			m = &Migration{Version: uint(version), Name: name}
+			byVersion[uint(version)] = m
+		}
+		if direction == Up {
+			m.UpPath = path
+		} else {
+			m.DownPath = path
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		migrations = append(migrations, *m)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+	return migrations, nil
+}
+
+// cutDirection splits "<stem>.up.sql" or "<stem>.down.sql"
+func cutDirection(base string) (string, Direction, bool) {
+	if stem, ok := strings.CutSuffix(base, ".up.sql"); ok {
+		return stem, Up, true
+	}
+	if stem, ok := strings.CutSuffix(base, ".down.sql"); ok {
+		return stem, Down, true
+	}
+	return "", "", false
+}
+
+// Versions returns the versions of migrations that have an up file
+func Versions(migrations []Migration) []uint {
+	var versions []uint
+	for _, m := range migrations {
+		if m.UpPath != "" {
+			versions = append(versions, m.Version)
+		}
+	}
+	return versions
+}
+
+// Pending returns the up steps newer than current. A current of 0 means the
+// database has no migration applied.
+func Pending(migrations []Migration, current uint) []Step {
+	var steps []Step
+	for _, m := range migrations {
+		if m.Version > current && m.UpPath != "" {
+			steps = append(steps, Step{Migration: m, Direction: Up})
+		}
+	}
+	return steps
+}
+
+// Steps returns the steps of moving n migrations from current, up when n is
+// positive and down when negative. Fewer steps than |n| are returned when the
+// directory runs out, as golang-migrate stops there too.
+func Steps(migrations []Migration, current uint, n int) []Step {
+	if n >= 0 {
+		pending := Pending(migrations, current)
+		return pending[:min(n, len(pending))]
+	}
+
+	down := applied(migrations, current)
+	return down[:min(-n, len(down))]
+}
+
+// Goto returns the steps of moving from current to target, which must be the
+// version of a migration.
+func Goto(migrations []Migration, current uint, target uint) ([]Step, error) {
+	if !slices.ContainsFunc(migrations, func(m Migration) bool { return m.Version == target }) {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownVersion, target)
+	}
+
+	if target >= current {
+		var steps []Step
+		for _, step := range Pending(migrations, current) {
+			if step.Version <= target {
+				steps = append(steps, step)
+			}
+		}
+		return steps, nil
+	}
+
+	var steps []Step
+	for _, step := range applied(migrations, current) {
+		if step.Version > target {
+			steps = append(steps, step)
+		}
+	}
+	return steps, nil
+}
+
+// applied returns the down steps of the migrations up to current, newest first
+func applied(migrations []Migration, current uint) []Step {
+	var steps []Step
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if m.Version <= current && m.DownPath != "" {
+			steps = append(steps, Step{Migration: m, Direction: Down})
+		}
+	}
+	return steps
+}
+
+// ReadSQL returns the SQL run by step
+func ReadSQL(step Step) (string, error) {
+	content, err := os.ReadFile(step.Path())
+	if err != nil {
+		return "", fmt.Errorf("failed to read migration: %w", err)
+	}
+	return string(content), nil
+}
@@ -0,0 +1,274 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	sqllib "github.com/yourorg/go-api-template/core/pgdb/sql_lib"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Column is a keyset column used for ordering and cursor comparison.
+// The last column should be unique (e.g. the primary key) to keep the order stable.
+// Name is a plain or table-qualified identifier, quoted by Build.
+type Column struct {
+	Name string
+	Desc bool
+}
+
+// Request holds the pagination parameters coming from the client
+type Request struct {
+	Cursor string `json:"cursor,omitempty"`
+	Limit  int    `json:"limit,omitempty"`
+}
+
+// Page is a generic page of results returned to the client
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// Fragment is the SQL produced by Build. Where is empty on the first page.
+type Fragment struct {
+	Where   string
+	OrderBy string
+	Limit   string
+	Args    pgx.NamedArgs
+}
+
+// SQL appends the fragment to a base query that has no WHERE clause of its own.
+func (f Fragment) SQL(baseQuery string) string {
+	sql := baseQuery
+	if f.Where != "" {
+		sql += " WHERE " + f.Where
+	}
+	return sql + " " + f.OrderBy + " " + f.Limit
+}
+
+// cursorValue keeps the Go type of a value so it survives the JSON round trip
+type cursorValue struct {
+	Type  string          `json:"t"`
+	Value json.RawMessage `json:"v"`
+}
+
+// EncodeCursor encodes the keyset values of the last row into an opaque cursor.
+func EncodeCursor(values ...any) (string, error) {
+	encoded := make([]cursorValue, 0, len(values))
+	for _, value := range values {
+		var typ string
+		switch v := value.(type) {
+		case time.Time:
+			typ = "time"
+			value = v.UTC().Format(time.RFC3339Nano)
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+			typ = "int"
+		case float32, float64:
+			typ = "float"
+		case bool:
+			typ = "bool"
+		case string:
+			typ = "string"
+		case fmt.Stringer:
+			typ = "string"
+			value = v.String()
+		default:
+			return "", fmt.Errorf("unsupported cursor value type %T", value)
+		}
+
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		encoded = append(encoded, cursorValue{Type: typ, Value: raw})
+	}
+
+	payload, err := json.Marshal(encoded)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(payload), nil
+}
+
+// DecodeCursor decodes an opaque cursor back into its keyset values.
+func DecodeCursor(cursor string) ([]any, error) {
+	payload, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var encoded []cursorValue
+	if err := json.Unmarshal(payload, &encoded); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	values := make([]any, 0, len(encoded))
+	for _, item := range encoded {
+		var value any
+		switch item.Type {
+		case "time":
+			var s string
+			if err := json.Unmarshal(item.Value, &s); err != nil {
+				return nil, ErrInvalidCursor
+			}
+			t, err := time.Parse(time.RFC3339Nano, s)
+			if err != nil {
+				return nil, ErrInvalidCursor
+			}
+			value = t
+		case "int":
+			var i int64
+			if err := json.Unmarshal(item.Value, &i); err != nil {
+				return nil, ErrInvalidCursor
+			}
+			value = i
+		case "float":
+			var f float64
+			if err := json.Unmarshal(item.Value, &f); err != nil {
+				return nil, ErrInvalidCursor
+			}
+			value = f
+		case "bool":
+			var b bool
+			if err := json.Unmarshal(item.Value, &b); err != nil {
+				return nil, ErrInvalidCursor
+			}
+			value = b
+		case "string":
+			var s string
+			if err := json.Unmarshal(item.Value, &s); err != nil {
+				return nil, ErrInvalidCursor
+			}
+			value = s
+		default:
+			return nil, ErrInvalidCursor
+		}
+		values = append(values, value)
+	}
+
+	return values, nil
+}
+
+// NormalizeLimit clamps the requested limit to [1, MaxLimit], using DefaultLimit when unset.
+func NormalizeLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultLimit
+	}
+	if limit > MaxLimit {
+		return MaxLimit
+	}
+	return limit
+}
+
+// Build generates the keyset WHERE, ORDER BY and LIMIT fragment for the given columns.
+// One extra row is requested so NewPage can tell whether another page exists.
+// A column name that is not a valid identifier fails with sqllib.ErrInvalidIdentifier.
+func Build(columns []Column, req Request) (Fragment, error) {
+	if len(columns) == 0 {
+		return Fragment{}, errors.New("pagination requires at least one column")
+	}
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		name, err := sqllib.QuoteIdentifier(column.Name)
+		if err != nil {
+			return Fragment{}, fmt.Errorf("pagination column: %w", err)
+		}
+		names[i] = name
+	}
+
+	limit := NormalizeLimit(req.Limit)
+	fragment := Fragment{
+		OrderBy: buildOrderBy(columns, names),
+		Limit:   fmt.Sprintf("LIMIT %d", limit+1),
+		Args:    pgx.NamedArgs{},
+	}
+
+	if req.Cursor == "" {
+		return fragment, nil
+	}
+
+	values, err := DecodeCursor(req.Cursor)
+	if err != nil {
+		return Fragment{}, err
+	}
+	if len(values) != len(columns) {
+		return Fragment{}, ErrInvalidCursor
+	}
+
+	// Expand (a, b, c) > (x, y, z) into OR-ed groups so each column can
+	// have its own sort direction:
+	//   a > x OR (a = x AND b > y) OR (a = x AND b = y AND c > z)
+	groups := make([]string, 0, len(columns))
+	for i, column := range columns {
+		parts := make([]string, 0, i+1)
+		for j := range columns[:i] {
+			parts = append(parts, fmt.Sprintf("%s = @%s", names[j], argName(j)))
+		}
+		op := ">"
+		if column.Desc {
+			op = "<"
+		}
+		parts = append(parts, fmt.Sprintf("%s %s @%s", names[i], op, argName(i)))
+		groups = append(groups, "("+strings.Join(parts, " AND ")+")")
+
+		fragment.Args[argName(i)] = values[i]
+	}
+	fragment.Where = "(" + strings.Join(groups, " OR ") + ")"
+
+	return fragment, nil
+}
+
+// NewPage trims the extra row fetched by Build and computes the next cursor
+// from the last item using keyFn.
+func NewPage[T any](rows []T, limit int, keyFn func(T) []any) (Page[T], error) {
+	limit = NormalizeLimit(limit)
+	page := Page[T]{Items: rows}
+	if page.Items == nil {
+		page.Items = []T{}
+	}
+
+	if len(rows) <= limit {
+		return page, nil
+	}
+
+	page.Items = rows[:limit]
+	page.HasMore = true
+
+	cursor, err := EncodeCursor(keyFn(page.Items[limit-1])...)
+	if err != nil {
+		return Page[T]{}, err
+	}
+	page.NextCursor = cursor
+
+	return page, nil
+}
+
+func buildOrderBy(columns []Column, names []string) string {
+	parts := make([]string, 0, len(columns))
+	for i, column := range columns {
+		direction := "ASC"
+		if column.Desc {
+			direction = "DESC"
+		}
+		parts = append(parts, names[i]+" "+direction)
+	}
+	return "ORDER BY " + strings.Join(parts, ", ")
+}
+
+// argName is the named argument of the cursor value of the i-th column
+func argName(i int) string {
+	return "cursor_" + strconv.Itoa(i)
+}
@@ -3,10 +3,12 @@ package pgdb
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"sync"
 
 	"github.com/exaring/otelpgx"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 var (
@@ -18,17 +20,30 @@ var (
 type Postgres struct {
 	Read  PostgresConfig `mapstructure:"read"`
 	Write PostgresConfig `mapstructure:"write"`
+	// Admin creates and drops the database of write, see the db command
+	Admin PostgresAdmin `mapstructure:"admin"`
+}
+
+// PostgresAdmin are the credentials of the db command on the write host; empty ones fall back to write
+type PostgresAdmin struct {
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password" secret:"true"`
+	// Database is the maintenance database connected to while creating or dropping, postgres when empty
+	Database string `mapstructure:"database"`
 }
 
 type PostgresConfig struct {
-	Host                     string `mapstructure:"host"`
-	Port                     int    `mapstructure:"port"`
-	Username                 string `mapstructure:"username"`
-	Password                 string `mapstructure:"password"`
-	Database                 string `mapstructure:"database"`
-	Schema                   string `mapstructure:"schema"`
-	MaxConnections           int32  `mapstructure:"maxConnections"`
-	EnableQueryParamsTracing bool   `mapstructure:"enableQueryParamsTracing"`
+	Host           string `mapstructure:"host"`
+	Port           int    `mapstructure:"port"`
+	Username       string `mapstructure:"username"`
+	Password       string `mapstructure:"password" secret:"true"`
+	Database       string `mapstructure:"database"`
+	Schema         string `mapstructure:"schema"`
+	MaxConnections int32  `mapstructure:"maxConnections"`
+	// EnableQueryParamsTracing records the arguments of queries on their spans
+	EnableQueryParamsTracing bool `mapstructure:"enableQueryParamsTracing"`
+	// MaxStatementLength cuts the statements of query spans; 0 uses 2048, negative keeps them whole
+	MaxStatementLength int `mapstructure:"maxStatementLength"`
 }
 
 func InitPgConnectionPool(ctx context.Context, cfg Postgres) error {
@@ -43,6 +58,7 @@ func InitPgConnectionPool(ctx context.Context, cfg Postgres) error {
 		if err != nil {
 			return err
 		}
+		recordPoolStats(ctx, singlePool, "read_write")
 
 		readPgPool = singlePool
 		writePgPool = singlePool
@@ -58,6 +74,8 @@ func InitPgConnectionPool(ctx context.Context, cfg Postgres) error {
 	if err != nil {
 		return err
 	}
+	recordPoolStats(ctx, readPool, "read")
+	recordPoolStats(ctx, writePool, "write")
 
 	readPgPool = readPool
 	writePgPool = writePool
@@ -78,30 +96,30 @@ func GetWritePgPool() (*pgxpool.Pool, error) {
 	return writePgPool, nil
 }
 
+// recordPoolStats reports the connection counts and acquire waits of pool to the global
+// meter provider, labelled with its role
+func recordPoolStats(ctx context.Context, pool *pgxpool.Pool, name string) {
+	err := otelpgx.RecordStats(pool, otelpgx.WithStatsAttributes(attribute.String("db.client.connection.pool.name", name)))
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to record pgxpool stats", "pool", name, "error", err)
+	}
+}
+
+// NewPool opens a pool on postgresConfig apart from the read and write pools, e.g. on another
+// database; the caller closes it
+func NewPool(ctx context.Context, postgresConfig PostgresConfig) (*pgxpool.Pool, error) {
+	return initSinglePool(ctx, postgresConfig)
+}
+
 // initSinglePool initializes a single pool without acquiring a lock
 func initSinglePool(ctx context.Context, postgresConfig PostgresConfig) (*pgxpool.Pool, error) {
-	connStr := fmt.Sprintf(
-		"host=%s port=%d user=%s password=%s dbname=%s search_path=%s",
-		postgresConfig.Host,
-		postgresConfig.Port,
-		postgresConfig.Username,
-		postgresConfig.Password,
-		postgresConfig.Database,
-		postgresConfig.Schema,
-	)
-
-	connConfig, err := pgxpool.ParseConfig(connStr)
+	connConfig, err := pgxpool.ParseConfig(connString(postgresConfig))
 	if err != nil {
 		fmt.Println("Failed to parse config:", err)
 		return nil, err
 	}
 
-	opts := []otelpgx.Option{}
-	//if postgresConfig.EnableQueryParamsTracing {
-	opts = append(opts, otelpgx.WithIncludeQueryParameters())
-	//}
-
-	connConfig.ConnConfig.Tracer = otelpgx.NewTracer(opts...)
+	connConfig.ConnConfig.Tracer = NewTracer(postgresConfig)
 
 	// Set maximum number of connections
 	connConfig.MaxConns = postgresConfig.MaxConnections
@@ -121,6 +139,19 @@ func initSinglePool(ctx context.Context, postgresConfig PostgresConfig) (*pgxpoo
 	return pgxPool, nil
 }
 
+// connString is the keyword/value connection string of postgresConfig
+func connString(postgresConfig PostgresConfig) string {
+	return fmt.Sprintf(
+		"host=%s port=%d user=%s password=%s dbname=%s search_path=%s",
+		postgresConfig.Host,
+		postgresConfig.Port,
+		postgresConfig.Username,
+		postgresConfig.Password,
+		postgresConfig.Database,
+		postgresConfig.Schema,
+	)
+}
+
 func InitSchema(ctx context.Context, writePgPool *pgxpool.Pool, schema string) (err error) {
 	// Create schema if it doesn't exist
 	// Ignore error if schema already exists or if the user doesn't have permission to create schema
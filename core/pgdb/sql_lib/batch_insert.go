@@ -0,0 +1,101 @@
+package sqllib
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// DefaultBatchSize is the number of rows per statement used by GenerateBatchInsert
+	DefaultBatchSize = 500
+
+	// Postgres allows at most 65535 bind parameters per statement
+	maxBindParameters = 65535
+)
+
+// Statement is a generated query together with its named arguments
+type Statement struct {
+	SQL     string
+	Args    pgx.NamedArgs
+	IsWrite bool
+}
+
+// GenerateBatchInsert generates multi-row INSERT statements, split into chunks of
+// WithBatchSize rows (DefaultBatchSize by default). The column list is the sorted
+// union of all row keys; a row missing a column inserts DEFAULT for it.
+func GenerateBatchInsert(table string, rows []map[string]any, opts ...InsertOption) ([]Statement, error) {
+	o := insertOptions{batchSize: DefaultBatchSize}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if len(rows) == 0 {
+		return nil, errors.New("batch insert requires at least one row")
+	}
+
+	quotedTable, err := quoteTable(table)
+	if err != nil {
+		return nil, err
+	}
+
+	columnSet := map[string]struct{}{}
+	for _, row := range rows {
+		for key := range row {
+			columnSet[key] = struct{}{}
+		}
+	}
+	columns := sortedKeys(columnSet)
+	if len(columns) == 0 {
+		return nil, errors.New("batch insert rows have no columns")
+	}
+
+	quotedColumns, err := quoteColumns(columns)
+	if err != nil {
+		return nil, err
+	}
+
+	tail, err := o.tail(columns)
+	if err != nil {
+		return nil, err
+	}
+
+	batchSize := o.batchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	if limit := maxBindParameters / len(columns); batchSize > limit {
+		batchSize = limit
+	}
+
+	statements := make([]Statement, 0, (len(rows)+batchSize-1)/batchSize)
+	for chunk := range slices.Chunk(rows, batchSize) {
+		args := pgx.NamedArgs{}
+		values := make([]string, 0, len(chunk))
+		for i, row := range chunk {
+			placeholders := make([]string, 0, len(columns))
+			for _, column := range columns {
+				value, ok := row[column]
+				if !ok {
+					placeholders = append(placeholders, "DEFAULT")
+					continue
+				}
+				name := fmt.Sprintf("%s_%d", argName(column), i)
+				placeholders = append(placeholders, "@"+name)
+				args[name] = value
+			}
+			values = append(values, "("+strings.Join(placeholders, ", ")+")")
+		}
+
+		statements = append(statements, Statement{
+			SQL:     fmt.Sprintf("INSERT INTO %s (%s) VALUES %s%s", quotedTable, strings.Join(quotedColumns, ", "), strings.Join(values, ", "), tail),
+			Args:    args,
+			IsWrite: true,
+		})
+	}
+
+	return statements, nil
+}
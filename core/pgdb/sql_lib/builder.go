@@ -0,0 +1,380 @@
+package sqllib
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Expr is a WHERE expression that can be composed with And, Or and Not.
+// Condition (Eq, Gt, In, ...) is the leaf expression.
+type Expr interface {
+	build(b *binder) (string, error)
+}
+
+// binder hands out unique named argument keys while a query is built
+type binder struct {
+	args pgx.NamedArgs
+	n    int
+}
+
+func newBinder() *binder {
+	return &binder{args: pgx.NamedArgs{}}
+}
+
+func (b *binder) next(column string) string {
+	b.n++
+	return fmt.Sprintf("p%d_%s", b.n, argName(column))
+}
+
+func (c Condition) build(b *binder) (string, error) {
+	column, err := quoteColumn(c.Column)
+	if err != nil {
+		return "", err
+	}
+	return c.render(column, b.next(c.Column), b.args)
+}
+
+type group struct {
+	op    string
+	exprs []Expr
+}
+
+// And joins expressions with AND
+func And(exprs ...Expr) Expr {
+	return group{op: "AND", exprs: exprs}
+}
+
+// Or joins expressions with OR
+func Or(exprs ...Expr) Expr {
+	return group{op: "OR", exprs: exprs}
+}
+
+func (g group) build(b *binder) (string, error) {
+	if len(g.exprs) == 0 {
+		return "", fmt.Errorf("empty %s group", g.op)
+	}
+
+	parts := make([]string, 0, len(g.exprs))
+	for _, expr := range g.exprs {
+		part, err := expr.build(b)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, part)
+	}
+
+	if len(parts) == 1 {
+		return parts[0], nil
+	}
+	return "(" + strings.Join(parts, " "+g.op+" ") + ")", nil
+}
+
+type not struct {
+	expr Expr
+}
+
+// Not negates an expression
+func Not(expr Expr) Expr {
+	return not{expr: expr}
+}
+
+func (n not) build(b *binder) (string, error) {
+	part, err := n.expr.build(b)
+	if err != nil {
+		return "", err
+	}
+	return "NOT (" + part + ")", nil
+}
+
+// whereClause renders the ANDed top-level expressions without the WHERE keyword
+func whereClause(exprs []Expr, b *binder) (string, error) {
+	if len(exprs) == 0 {
+		return "", nil
+	}
+
+	parts := make([]string, 0, len(exprs))
+	for _, expr := range exprs {
+		part, err := expr.build(b)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " AND "), nil
+}
+
+// SelectBuilder builds SELECT queries
+type SelectBuilder struct {
+	columns []string
+	table   string
+	where   []Expr
+	options selectOptions
+}
+
+// Select starts a SELECT query, e.g.
+//
+//	sqllib.Select("id", "name").From("products").Where(sqllib.Eq("is_active", true)).OrderBy("name", sqllib.Asc).Limit(10)
+func Select(columns ...string) *SelectBuilder {
+	return &SelectBuilder{columns: columns}
+}
+
+func (s *SelectBuilder) From(table string) *SelectBuilder {
+	s.table = table
+	return s
+}
+
+// Where adds expressions; multiple expressions and calls are ANDed together
+func (s *SelectBuilder) Where(exprs ...Expr) *SelectBuilder {
+	s.where = append(s.where, exprs...)
+	return s
+}
+
+// WithDeleted includes soft-deleted rows for tables registered with EnableSoftDelete
+func (s *SelectBuilder) WithDeleted() *SelectBuilder {
+	WithDeleted()(&s.options)
+	return s
+}
+
+func (s *SelectBuilder) Distinct() *SelectBuilder {
+	WithDistinct()(&s.options)
+	return s
+}
+
+func (s *SelectBuilder) GroupBy(columns ...string) *SelectBuilder {
+	WithGroupBy(columns...)(&s.options)
+	return s
+}
+
+func (s *SelectBuilder) OrderBy(column string, direction SortDirection) *SelectBuilder {
+	WithOrderBy(column, direction)(&s.options)
+	return s
+}
+
+func (s *SelectBuilder) Limit(limit int) *SelectBuilder {
+	WithLimit(limit)(&s.options)
+	return s
+}
+
+func (s *SelectBuilder) Offset(offset int) *SelectBuilder {
+	WithOffset(offset)(&s.options)
+	return s
+}
+
+// Build returns the SQL and its named arguments
+func (s *SelectBuilder) Build() (string, pgx.NamedArgs, error) {
+	quotedTable, err := quoteTable(s.table)
+	if err != nil {
+		return "", nil, err
+	}
+
+	columns := s.columns
+	if len(columns) == 0 {
+		columns = []string{"*"}
+	}
+	quotedColumns, err := quoteColumns(columns)
+	if err != nil {
+		return "", nil, err
+	}
+
+	selectList := strings.Join(quotedColumns, ", ")
+	if s.options.distinct {
+		selectList = "DISTINCT " + selectList
+	}
+
+	b := newBinder()
+	where, err := whereClause(s.where, b)
+	if err != nil {
+		return "", nil, err
+	}
+
+	softDelete, err := softDeleteColumn(s.table)
+	if err != nil {
+		return "", nil, err
+	}
+	if softDelete != "" && !s.options.withDeleted {
+		where = joinWhere(where, softDelete+" IS NULL")
+	} else {
+		where = joinWhere(where)
+	}
+
+	tail, err := s.options.tail()
+	if err != nil {
+		return "", nil, err
+	}
+
+	return fmt.Sprintf("SELECT %s FROM %s%s%s", selectList, quotedTable, where, tail), b.args, nil
+}
+
+// InsertBuilder builds INSERT queries
+type InsertBuilder struct {
+	table   string
+	data    map[string]any
+	options []InsertOption
+}
+
+// InsertInto starts an INSERT query
+func InsertInto(table string) *InsertBuilder {
+	return &InsertBuilder{table: table, data: map[string]any{}}
+}
+
+// Set adds a column value
+func (i *InsertBuilder) Set(column string, value any) *InsertBuilder {
+	i.data[column] = value
+	return i
+}
+
+// Values adds several column values
+func (i *InsertBuilder) Values(data map[string]any) *InsertBuilder {
+	for column, value := range data {
+		i.data[column] = value
+	}
+	return i
+}
+
+func (i *InsertBuilder) Returning(columns ...string) *InsertBuilder {
+	i.options = append(i.options, WithReturning(columns...))
+	return i
+}
+
+func (i *InsertBuilder) OnConflictDoNothing(conflictColumns ...string) *InsertBuilder {
+	i.options = append(i.options, WithOnConflictDoNothing(conflictColumns...))
+	return i
+}
+
+func (i *InsertBuilder) OnConflictDoUpdate(conflictColumns []string, updateColumns ...string) *InsertBuilder {
+	i.options = append(i.options, WithOnConflictDoUpdate(conflictColumns, updateColumns...))
+	return i
+}
+
+// Build returns the SQL and its named arguments
+func (i *InsertBuilder) Build() (string, pgx.NamedArgs, error) {
+	if len(i.data) == 0 {
+		return "", nil, errors.New("insert requires at least one column")
+	}
+	sql, args, _, err := GenerateInsert(i.table, i.data, i.options...)
+	return sql, args, err
+}
+
+// UpdateBuilder builds UPDATE queries
+type UpdateBuilder struct {
+	table     string
+	columns   []string
+	values    []any
+	where     []Expr
+	returning []string
+}
+
+// Update starts an UPDATE query
+func Update(table string) *UpdateBuilder {
+	return &UpdateBuilder{table: table}
+}
+
+// Set adds a column assignment; assignments are rendered in the order they are added
+func (u *UpdateBuilder) Set(column string, value any) *UpdateBuilder {
+	u.columns = append(u.columns, column)
+	u.values = append(u.values, value)
+	return u
+}
+
+// Where adds expressions; multiple expressions and calls are ANDed together
+func (u *UpdateBuilder) Where(exprs ...Expr) *UpdateBuilder {
+	u.where = append(u.where, exprs...)
+	return u
+}
+
+func (u *UpdateBuilder) Returning(columns ...string) *UpdateBuilder {
+	u.returning = append(u.returning, columns...)
+	return u
+}
+
+// Build returns the SQL and its named arguments
+func (u *UpdateBuilder) Build() (string, pgx.NamedArgs, error) {
+	quotedTable, err := quoteTable(u.table)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(u.columns) == 0 {
+		return "", nil, errors.New("update requires at least one column")
+	}
+
+	b := newBinder()
+	sets := make([]string, 0, len(u.columns))
+	for i, column := range u.columns {
+		quoted, err := quoteColumn(column)
+		if err != nil {
+			return "", nil, err
+		}
+		name := b.next(column)
+		b.args[name] = u.values[i]
+		sets = append(sets, fmt.Sprintf("%s = @%s", quoted, name))
+	}
+
+	where, err := whereClause(u.where, b)
+	if err != nil {
+		return "", nil, err
+	}
+
+	sql := fmt.Sprintf("UPDATE %s SET %s%s", quotedTable, strings.Join(sets, ", "), joinWhere(where))
+
+	if len(u.returning) > 0 {
+		columns, err := quoteColumns(u.returning)
+		if err != nil {
+			return "", nil, err
+		}
+		sql += " RETURNING " + strings.Join(columns, ", ")
+	}
+
+	return sql, b.args, nil
+}
+
+// DeleteBuilder builds DELETE queries
+type DeleteBuilder struct {
+	table   string
+	where   []Expr
+	options deleteOptions
+}
+
+// DeleteFrom starts a DELETE query
+func DeleteFrom(table string) *DeleteBuilder {
+	return &DeleteBuilder{table: table}
+}
+
+// Where adds expressions; multiple expressions and calls are ANDed together
+func (d *DeleteBuilder) Where(exprs ...Expr) *DeleteBuilder {
+	d.where = append(d.where, exprs...)
+	return d
+}
+
+// Hard issues a real DELETE even when the table uses soft delete
+func (d *DeleteBuilder) Hard() *DeleteBuilder {
+	WithHardDelete()(&d.options)
+	return d
+}
+
+// Build returns the SQL and its named arguments.
+// For soft-deleted tables it returns an UPDATE setting the deleted_at column unless Hard was called.
+func (d *DeleteBuilder) Build() (string, pgx.NamedArgs, error) {
+	quotedTable, err := quoteTable(d.table)
+	if err != nil {
+		return "", nil, err
+	}
+
+	b := newBinder()
+	where, err := whereClause(d.where, b)
+	if err != nil {
+		return "", nil, err
+	}
+
+	softDelete, err := softDeleteColumn(d.table)
+	if err != nil {
+		return "", nil, err
+	}
+	if softDelete != "" && !d.options.hardDelete {
+		return fmt.Sprintf("UPDATE %s SET %s = now()%s", quotedTable, softDelete, joinWhere(where, softDelete+" IS NULL")), b.args, nil
+	}
+
+	return fmt.Sprintf("DELETE FROM %s%s", quotedTable, joinWhere(where)), b.args, nil
+}
@@ -0,0 +1,126 @@
+package sqllib
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type Operator string
+
+const (
+	OpEq        Operator = "="
+	OpNeq       Operator = "!="
+	OpGt        Operator = ">"
+	OpGte       Operator = ">="
+	OpLt        Operator = "<"
+	OpLte       Operator = "<="
+	OpLike      Operator = "LIKE"
+	OpILike     Operator = "ILIKE"
+	OpIn        Operator = "IN"
+	OpNotIn     Operator = "NOT IN"
+	OpBetween   Operator = "BETWEEN"
+	OpIsNull    Operator = "IS NULL"
+	OpIsNotNull Operator = "IS NOT NULL"
+)
+
+// Condition is a single parameterized comparison.
+// When used as a value in a conditions map, Column may be left empty; the map key is used.
+// Value is used by comparison operators, Values by IN, NOT IN and BETWEEN.
+type Condition struct {
+	Column   string
+	Operator Operator
+	Value    any
+	Values   []any
+}
+
+func Eq(column string, value any) Condition {
+	return Condition{Column: column, Operator: OpEq, Value: value}
+}
+
+func Neq(column string, value any) Condition {
+	return Condition{Column: column, Operator: OpNeq, Value: value}
+}
+
+func Gt(column string, value any) Condition {
+	return Condition{Column: column, Operator: OpGt, Value: value}
+}
+
+func Gte(column string, value any) Condition {
+	return Condition{Column: column, Operator: OpGte, Value: value}
+}
+
+func Lt(column string, value any) Condition {
+	return Condition{Column: column, Operator: OpLt, Value: value}
+}
+
+func Lte(column string, value any) Condition {
+	return Condition{Column: column, Operator: OpLte, Value: value}
+}
+
+func Like(column string, pattern string) Condition {
+	return Condition{Column: column, Operator: OpLike, Value: pattern}
+}
+
+func ILike(column string, pattern string) Condition {
+	return Condition{Column: column, Operator: OpILike, Value: pattern}
+}
+
+func In(column string, values ...any) Condition {
+	return Condition{Column: column, Operator: OpIn, Values: values}
+}
+
+func NotIn(column string, values ...any) Condition {
+	return Condition{Column: column, Operator: OpNotIn, Values: values}
+}
+
+func Between(column string, from, to any) Condition {
+	return Condition{Column: column, Operator: OpBetween, Values: []any{from, to}}
+}
+
+func IsNull(column string) Condition {
+	return Condition{Column: column, Operator: OpIsNull}
+}
+
+func IsNotNull(column string) Condition {
+	return Condition{Column: column, Operator: OpIsNotNull}
+}
+
+// render writes the condition using name as the named argument prefix
+func (c Condition) render(quotedColumn string, name string, args pgx.NamedArgs) (string, error) {
+	switch c.Operator {
+	case OpEq, OpNeq, OpGt, OpGte, OpLt, OpLte, OpLike, OpILike:
+		if c.Value == nil {
+			return "", fmt.Errorf("operator %s on %q requires a value; use IS NULL/IS NOT NULL for nulls", c.Operator, c.Column)
+		}
+		args[name] = c.Value
+		return fmt.Sprintf("%s %s @%s", quotedColumn, c.Operator, name), nil
+	case OpIn, OpNotIn:
+		if len(c.Values) == 0 {
+			// "IN ()" is a syntax error; an empty set matches nothing, NOT IN matches everything
+			if c.Operator == OpIn {
+				return "FALSE", nil
+			}
+			return "TRUE", nil
+		}
+		placeholders := make([]string, 0, len(c.Values))
+		for j, val := range c.Values {
+			key := fmt.Sprintf("%s_%d", name, j)
+			placeholders = append(placeholders, "@"+key)
+			args[key] = val
+		}
+		return fmt.Sprintf("%s %s (%s)", quotedColumn, c.Operator, strings.Join(placeholders, ", ")), nil
+	case OpBetween:
+		if len(c.Values) != 2 {
+			return "", fmt.Errorf("BETWEEN on %q requires exactly two values", c.Column)
+		}
+		args[name+"_from"] = c.Values[0]
+		args[name+"_to"] = c.Values[1]
+		return fmt.Sprintf("%s BETWEEN @%s_from AND @%s_to", quotedColumn, name, name), nil
+	case OpIsNull, OpIsNotNull:
+		return fmt.Sprintf("%s %s", quotedColumn, c.Operator), nil
+	default:
+		return "", fmt.Errorf("unsupported operator: %q", c.Operator)
+	}
+}
@@ -0,0 +1,53 @@
+package sqllib
+
+import (
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// GenerateCount generates `SELECT COUNT(*) FROM table WHERE ...`.
+// Soft-deleted rows are excluded for tables registered with EnableSoftDelete.
+func GenerateCount(table string, where ...Expr) (string, pgx.NamedArgs, bool, error) {
+	from, args, err := fromWhere(table, where)
+	if err != nil {
+		return "", nil, false, err
+	}
+
+	return "SELECT COUNT(*) FROM " + from, args, false, nil
+}
+
+// GenerateExists generates `SELECT EXISTS (SELECT 1 FROM table WHERE ...)`.
+// Soft-deleted rows are excluded for tables registered with EnableSoftDelete.
+func GenerateExists(table string, where ...Expr) (string, pgx.NamedArgs, bool, error) {
+	from, args, err := fromWhere(table, where)
+	if err != nil {
+		return "", nil, false, err
+	}
+
+	return fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s)", from), args, false, nil
+}
+
+// fromWhere renders `"table" WHERE ...` including the soft delete filter
+func fromWhere(table string, exprs []Expr) (string, pgx.NamedArgs, error) {
+	quotedTable, err := quoteTable(table)
+	if err != nil {
+		return "", nil, err
+	}
+
+	b := newBinder()
+	where, err := whereClause(exprs, b)
+	if err != nil {
+		return "", nil, err
+	}
+
+	softDelete, err := softDeleteColumn(table)
+	if err != nil {
+		return "", nil, err
+	}
+	if softDelete != "" {
+		return quotedTable + joinWhere(where, softDelete+" IS NULL"), b.args, nil
+	}
+
+	return quotedTable + joinWhere(where), b.args, nil
+}
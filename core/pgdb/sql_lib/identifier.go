@@ -0,0 +1,131 @@
+package sqllib
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Postgres truncates identifiers longer than 63 bytes
+const maxIdentifierLength = 63
+
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+var ErrInvalidIdentifier = errors.New("invalid sql identifier")
+
+// IdentifierKind tells the allowlist what kind of identifier is being checked
+type IdentifierKind int
+
+const (
+	IdentifierTable IdentifierKind = iota
+	IdentifierColumn
+)
+
+// AllowlistFunc decides whether an identifier may be used in generated SQL.
+// For tables, name is the fully qualified name as given (e.g. "public.users").
+type AllowlistFunc func(kind IdentifierKind, name string) bool
+
+var (
+	allowlist   AllowlistFunc
+	allowlistMu sync.RWMutex
+)
+
+// SetIdentifierAllowlist installs a hook that every table and column name must
+// pass in addition to the syntax check. Pass nil to remove it.
+func SetIdentifierAllowlist(fn AllowlistFunc) {
+	allowlistMu.Lock()
+	defer allowlistMu.Unlock()
+	allowlist = fn
+}
+
+// ValidateIdentifier checks that name is a plain or schema-qualified identifier
+func ValidateIdentifier(name string) error {
+	if name == "" {
+		return fmt.Errorf("%w: empty identifier", ErrInvalidIdentifier)
+	}
+
+	parts := strings.Split(name, ".")
+	if len(parts) > 2 {
+		return fmt.Errorf("%w: %q", ErrInvalidIdentifier, name)
+	}
+	for _, part := range parts {
+		if len(part) > maxIdentifierLength || !identifierPattern.MatchString(part) {
+			return fmt.Errorf("%w: %q", ErrInvalidIdentifier, name)
+		}
+	}
+
+	return nil
+}
+
+// QuoteIdentifier validates name and returns it double-quoted, part by part
+func QuoteIdentifier(name string) (string, error) {
+	if err := ValidateIdentifier(name); err != nil {
+		return "", err
+	}
+
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = `"` + part + `"`
+	}
+	return strings.Join(parts, "."), nil
+}
+
+func quoteTable(table string) (string, error) {
+	return quoteChecked(IdentifierTable, table)
+}
+
+func quoteColumn(column string) (string, error) {
+	return quoteChecked(IdentifierColumn, column)
+}
+
+func quoteColumns(columns []string) ([]string, error) {
+	quoted := make([]string, 0, len(columns))
+	for _, column := range columns {
+		if column == "*" {
+			quoted = append(quoted, column)
+			continue
+		}
+		q, err := quoteColumn(column)
+		if err != nil {
+			return nil, err
+		}
+		quoted = append(quoted, q)
+	}
+	return quoted, nil
+}
+
+func quoteChecked(kind IdentifierKind, name string) (string, error) {
+	quoted, err := QuoteIdentifier(name)
+	if err != nil {
+		return "", err
+	}
+
+	allowlistMu.RLock()
+	fn := allowlist
+	allowlistMu.RUnlock()
+
+	if fn != nil && !fn(kind, name) {
+		return "", fmt.Errorf("%w: %q is not allowed", ErrInvalidIdentifier, name)
+	}
+
+	return quoted, nil
+}
+
+// argName converts a (possibly qualified) column into a named argument key
+func argName(column string) string {
+	return strings.ReplaceAll(column, ".", "_")
+}
+
+// validateLogicalOperator only lets AND/OR through since operators are spliced into SQL
+func validateLogicalOperator(op string) (string, error) {
+	switch strings.ToUpper(strings.TrimSpace(op)) {
+	case "AND":
+		return "AND", nil
+	case "OR":
+		return "OR", nil
+	default:
+		return "", fmt.Errorf("invalid logical operator: %q", op)
+	}
+}
@@ -0,0 +1,117 @@
+package sqllib
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+type conflictAction int
+
+const (
+	conflictNone conflictAction = iota
+	conflictDoNothing
+	conflictDoUpdate
+)
+
+type insertOptions struct {
+	batchSize       int
+	returning       []string
+	conflictAction  conflictAction
+	conflictColumns []string
+	updateColumns   []string
+}
+
+// InsertOption customizes the query produced by GenerateInsert
+type InsertOption func(*insertOptions)
+
+// WithBatchSize sets how many rows GenerateBatchInsert puts in one statement
+func WithBatchSize(rows int) InsertOption {
+	return func(o *insertOptions) {
+		o.batchSize = rows
+	}
+}
+
+// WithReturning adds a RETURNING clause. Use ExecuteReturning to scan the rows.
+func WithReturning(columns ...string) InsertOption {
+	return func(o *insertOptions) {
+		o.returning = append(o.returning, columns...)
+	}
+}
+
+// WithOnConflictDoNothing adds ON CONFLICT [(columns)] DO NOTHING
+func WithOnConflictDoNothing(conflictColumns ...string) InsertOption {
+	return func(o *insertOptions) {
+		o.conflictAction = conflictDoNothing
+		o.conflictColumns = conflictColumns
+	}
+}
+
+// WithOnConflictDoUpdate adds ON CONFLICT (columns) DO UPDATE SET col = EXCLUDED.col.
+// When updateColumns is empty every inserted column except the conflict columns is updated.
+func WithOnConflictDoUpdate(conflictColumns []string, updateColumns ...string) InsertOption {
+	return func(o *insertOptions) {
+		o.conflictAction = conflictDoUpdate
+		o.conflictColumns = conflictColumns
+		o.updateColumns = updateColumns
+	}
+}
+
+// tail renders ON CONFLICT and RETURNING for the given inserted columns
+func (o insertOptions) tail(insertedColumns []string) (string, error) {
+	var sql strings.Builder
+
+	switch o.conflictAction {
+	case conflictDoNothing:
+		sql.WriteString(" ON CONFLICT")
+		if len(o.conflictColumns) > 0 {
+			columns, err := quoteColumns(o.conflictColumns)
+			if err != nil {
+				return "", err
+			}
+			sql.WriteString(" (" + strings.Join(columns, ", ") + ")")
+		}
+		sql.WriteString(" DO NOTHING")
+	case conflictDoUpdate:
+		if len(o.conflictColumns) == 0 {
+			return "", errors.New("ON CONFLICT DO UPDATE requires conflict columns")
+		}
+		columns, err := quoteColumns(o.conflictColumns)
+		if err != nil {
+			return "", err
+		}
+
+		updateColumns := o.updateColumns
+		if len(updateColumns) == 0 {
+			for _, column := range insertedColumns {
+				if !slices.Contains(o.conflictColumns, column) {
+					updateColumns = append(updateColumns, column)
+				}
+			}
+		}
+		if len(updateColumns) == 0 {
+			return "", errors.New("ON CONFLICT DO UPDATE has no columns to update")
+		}
+
+		sets := make([]string, 0, len(updateColumns))
+		for _, column := range updateColumns {
+			quoted, err := quoteColumn(column)
+			if err != nil {
+				return "", err
+			}
+			sets = append(sets, fmt.Sprintf("%s = EXCLUDED.%s", quoted, quoted))
+		}
+		sql.WriteString(" ON CONFLICT (" + strings.Join(columns, ", ") + ") DO UPDATE SET " + strings.Join(sets, ", "))
+	}
+
+	if len(o.returning) > 0 {
+		columns, err := quoteColumns(o.returning)
+		if err != nil {
+			return "", err
+		}
+		sql.WriteString(" RETURNING " + strings.Join(columns, ", "))
+	}
+
+	return sql.String(), nil
+}
@@ -11,7 +11,24 @@ import (
 	"github.com/yourorg/go-api-template/core/pgdb"
 )
 
+// defaultQueryTimeout bounds queries whose context carries no deadline
+const defaultQueryTimeout = 5 * time.Second
+
+// queryContext keeps the caller's deadline, e.g. the request budget set by
+// middleware.TimeoutMiddleware, and only falls back to timeout when there is none
+func queryContext(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
 func Execute[R any](dbModel R, query string, args pgx.NamedArgs, isQueryWrite bool) ([]R, *int, error) {
+	return ExecuteContext(context.Background(), dbModel, query, args, isQueryWrite)
+}
+
+// ExecuteContext is Execute bounded by ctx
+func ExecuteContext[R any](ctx context.Context, dbModel R, query string, args pgx.NamedArgs, isQueryWrite bool) ([]R, *int, error) {
 	var dbPool *pgxpool.Pool
 	var err error
 
@@ -29,8 +46,8 @@ func Execute[R any](dbModel R, query string, args pgx.NamedArgs, isQueryWrite bo
 		return nil, nil, fmt.Errorf("dbPool is nil")
 	}
 
-	// Create a context with a timeout to avoid long-running queries
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	// Bound the query to avoid long-running queries
+	ctx, cancel := queryContext(ctx, defaultQueryTimeout)
 	defer cancel()
 
 	if isQueryWrite {
@@ -69,3 +86,130 @@ func Execute[R any](dbModel R, query string, args pgx.NamedArgs, isQueryWrite bo
 
 	return result, &rowLen, nil
 }
+
+// ExecuteReturning runs a write query that has a RETURNING clause (see WithReturning)
+// on the write pool and scans the returned rows into R.
+func ExecuteReturning[R any](dbModel R, query string, args pgx.NamedArgs) ([]R, *int, error) {
+	return ExecuteReturningContext(context.Background(), dbModel, query, args)
+}
+
+// ExecuteReturningContext is ExecuteReturning bounded by ctx
+func ExecuteReturningContext[R any](ctx context.Context, dbModel R, query string, args pgx.NamedArgs) ([]R, *int, error) {
+	dbPool, err := pgdb.GetWritePgPool()
+	if err != nil {
+		return nil, nil, fmt.Errorf("error getting database pool: %w", err)
+	}
+
+	if dbPool == nil {
+		return nil, nil, fmt.Errorf("dbPool is nil")
+	}
+
+	// Bound the query to avoid long-running queries
+	ctx, cancel := queryContext(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	rows, err := dbPool.Query(ctx, query, args)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error executing query: %w", err)
+	}
+	defer rows.Close()
+
+	result, err := pgx.CollectRows(rows, pgx.RowToStructByNameLax[R])
+	if err != nil {
+		return nil, nil, fmt.Errorf("error processing rows: %w", err)
+	}
+
+	rowLen := len(result)
+
+	return result, &rowLen, nil
+}
+
+// ExecuteStatements runs write statements (e.g. from GenerateBatchInsert) in a
+// single transaction on the write pool and returns the total rows affected.
+func ExecuteStatements(statements []Statement) (*int, error) {
+	return ExecuteStatementsContext(context.Background(), statements)
+}
+
+// ExecuteStatementsContext is ExecuteStatements bounded by ctx
+func ExecuteStatementsContext(ctx context.Context, statements []Statement) (*int, error) {
+	dbPool, err := pgdb.GetWritePgPool()
+	if err != nil {
+		return nil, fmt.Errorf("error getting database pool: %w", err)
+	}
+
+	if dbPool == nil {
+		return nil, fmt.Errorf("dbPool is nil")
+	}
+
+	// Batches can be large, so allow more time than a single query
+	ctx, cancel := queryContext(ctx, 60*time.Second)
+	defer cancel()
+
+	total := 0
+	err = pgx.BeginFunc(ctx, dbPool, func(tx pgx.Tx) error {
+		for _, statement := range statements {
+			tag, err := tx.Exec(ctx, statement.SQL, statement.Args)
+			if err != nil {
+				return fmt.Errorf("error executing query: %w", err)
+			}
+			total += int(tag.RowsAffected())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "Rows affected", slog.Any("rowsAffected", total), slog.Int("statements", len(statements)))
+
+	return &total, nil
+}
+
+// ExecuteCount runs a query returning a single integer (see GenerateCount) on the read pool.
+func ExecuteCount(query string, args pgx.NamedArgs) (int64, error) {
+	return ExecuteCountContext(context.Background(), query, args)
+}
+
+// ExecuteCountContext is ExecuteCount bounded by ctx
+func ExecuteCountContext(ctx context.Context, query string, args pgx.NamedArgs) (int64, error) {
+	var count int64
+	if err := queryRowScalar(ctx, query, args, &count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// ExecuteExists runs a query returning a single boolean (see GenerateExists) on the read pool.
+func ExecuteExists(query string, args pgx.NamedArgs) (bool, error) {
+	return ExecuteExistsContext(context.Background(), query, args)
+}
+
+// ExecuteExistsContext is ExecuteExists bounded by ctx
+func ExecuteExistsContext(ctx context.Context, query string, args pgx.NamedArgs) (bool, error) {
+	var exists bool
+	if err := queryRowScalar(ctx, query, args, &exists); err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+
+func queryRowScalar(ctx context.Context, query string, args pgx.NamedArgs, dest any) error {
+	dbPool, err := pgdb.GetReadPgPool()
+	if err != nil {
+		return fmt.Errorf("error getting database pool: %w", err)
+	}
+
+	if dbPool == nil {
+		return fmt.Errorf("dbPool is nil")
+	}
+
+	// Bound the query to avoid long-running queries
+	ctx, cancel := queryContext(ctx, defaultQueryTimeout)
+	defer cancel()
+
+	if err := dbPool.QueryRow(ctx, query, args).Scan(dest); err != nil {
+		return fmt.Errorf("error executing query: %w", err)
+	}
+
+	return nil
+}
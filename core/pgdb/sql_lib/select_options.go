@@ -0,0 +1,123 @@
+package sqllib
+
+import (
+	"fmt"
+	"strings"
+)
+
+type SortDirection string
+
+const (
+	Asc  SortDirection = "ASC"
+	Desc SortDirection = "DESC"
+)
+
+// OrderBy is a single ORDER BY term
+type OrderBy struct {
+	Column    string
+	Direction SortDirection
+}
+
+type selectOptions struct {
+	distinct    bool
+	withDeleted bool
+	groupBy     []string
+	orderBy     []OrderBy
+	limit       *int
+	offset      *int
+}
+
+// SelectOption customizes the query produced by GenerateSelect
+type SelectOption func(*selectOptions)
+
+// WithDistinct adds DISTINCT to the select list
+func WithDistinct() SelectOption {
+	return func(o *selectOptions) {
+		o.distinct = true
+	}
+}
+
+// WithGroupBy adds a GROUP BY clause
+func WithGroupBy(columns ...string) SelectOption {
+	return func(o *selectOptions) {
+		o.groupBy = append(o.groupBy, columns...)
+	}
+}
+
+// WithOrderBy appends an ORDER BY term. Terms are rendered in the order they are added.
+func WithOrderBy(column string, direction SortDirection) SelectOption {
+	return func(o *selectOptions) {
+		o.orderBy = append(o.orderBy, OrderBy{Column: column, Direction: direction})
+	}
+}
+
+// WithLimit adds a LIMIT clause
+func WithLimit(limit int) SelectOption {
+	return func(o *selectOptions) {
+		o.limit = &limit
+	}
+}
+
+// WithOffset adds an OFFSET clause
+func WithOffset(offset int) SelectOption {
+	return func(o *selectOptions) {
+		o.offset = &offset
+	}
+}
+
+// ParseSortDirection validates a user supplied direction, defaulting to ASC when empty
+func ParseSortDirection(direction string) (SortDirection, error) {
+	switch strings.ToUpper(strings.TrimSpace(direction)) {
+	case "", "ASC":
+		return Asc, nil
+	case "DESC":
+		return Desc, nil
+	default:
+		return "", fmt.Errorf("invalid sort direction: %q", direction)
+	}
+}
+
+// tail renders GROUP BY, ORDER BY, LIMIT and OFFSET in that order
+func (o selectOptions) tail() (string, error) {
+	var sql strings.Builder
+
+	if len(o.groupBy) > 0 {
+		columns, err := quoteColumns(o.groupBy)
+		if err != nil {
+			return "", err
+		}
+		sql.WriteString(" GROUP BY " + strings.Join(columns, ", "))
+	}
+
+	if len(o.orderBy) > 0 {
+		terms := make([]string, 0, len(o.orderBy))
+		for _, term := range o.orderBy {
+			column, err := quoteColumn(term.Column)
+			if err != nil {
+				return "", err
+			}
+			direction, err := ParseSortDirection(string(term.Direction))
+			if err != nil {
+				return "", err
+			}
+			terms = append(terms, column+" "+string(direction))
+		}
+		sql.WriteString(" ORDER BY " + strings.Join(terms, ", "))
+	}
+
+	if o.limit != nil {
+		if *o.limit < 0 {
+			return "", fmt.Errorf("invalid limit: %d", *o.limit)
+		}
+		sql.WriteString(fmt.Sprintf(" LIMIT %d", *o.limit))
+	}
+
+	if o.offset != nil {
+		if *o.offset < 0 {
+			return "", fmt.Errorf("invalid offset: %d", *o.offset)
+		}
+		sql.WriteString(fmt.Sprintf(" OFFSET %d", *o.offset))
+	}
+
+	return sql.String(), nil
+}
@@ -0,0 +1,119 @@
+package sqllib
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// DefaultSoftDeleteColumn is the column used when EnableSoftDelete is called without one
+const DefaultSoftDeleteColumn = "deleted_at"
+
+var (
+	softDeleteTables   = map[string]string{}
+	softDeleteTablesMu sync.RWMutex
+)
+
+// EnableSoftDelete marks tables as soft-deleted using DefaultSoftDeleteColumn.
+// For these tables deletes become `UPDATE ... SET deleted_at = now()` and selects
+// filter out rows where deleted_at is set, unless overridden per call.
+func EnableSoftDelete(tables ...string) {
+	for _, table := range tables {
+		EnableSoftDeleteColumn(table, DefaultSoftDeleteColumn)
+	}
+}
+
+// EnableSoftDeleteColumn marks a table as soft-deleted using a custom timestamp column
+func EnableSoftDeleteColumn(table string, column string) {
+	softDeleteTablesMu.Lock()
+	defer softDeleteTablesMu.Unlock()
+	softDeleteTables[table] = column
+}
+
+// DisableSoftDelete removes tables from the soft delete registry
+func DisableSoftDelete(tables ...string) {
+	softDeleteTablesMu.Lock()
+	defer softDeleteTablesMu.Unlock()
+	for _, table := range tables {
+		delete(softDeleteTables, table)
+	}
+}
+
+// softDeleteColumn returns the quoted soft delete column for table, or "" when disabled
+func softDeleteColumn(table string) (string, error) {
+	softDeleteTablesMu.RLock()
+	column, ok := softDeleteTables[table]
+	softDeleteTablesMu.RUnlock()
+
+	if !ok {
+		return "", nil
+	}
+	return quoteColumn(column)
+}
+
+type deleteOptions struct {
+	hardDelete bool
+}
+
+// DeleteOption customizes the query produced by GenerateDelete
+type DeleteOption func(*deleteOptions)
+
+// WithHardDelete issues a real DELETE even when the table uses soft delete
+func WithHardDelete() DeleteOption {
+	return func(o *deleteOptions) {
+		o.hardDelete = true
+	}
+}
+
+// WithDeleted includes soft-deleted rows in GenerateSelect results
+func WithDeleted() SelectOption {
+	return func(o *selectOptions) {
+		o.withDeleted = true
+	}
+}
+
+// joinWhere ANDs the non-empty parts into a WHERE clause. Each part is
+// parenthesized when there is more than one so OR chains keep their meaning.
+func joinWhere(parts ...string) string {
+	nonEmpty := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part != "" {
+			nonEmpty = append(nonEmpty, part)
+		}
+	}
+
+	switch len(nonEmpty) {
+	case 0:
+		return ""
+	case 1:
+		return " WHERE " + nonEmpty[0]
+	}
+
+	for i, part := range nonEmpty {
+		nonEmpty[i] = "(" + part + ")"
+	}
+	return " WHERE " + strings.Join(nonEmpty, " AND ")
+}
+
+// SoftDeleteMigration returns up and down migration SQL that adds a nullable
+// deleted_at column and a partial index covering live rows.
+func SoftDeleteMigration(table string) (string, string, error) {
+	if err := ValidateIdentifier(table); err != nil {
+		return "", "", err
+	}
+
+	indexName := fmt.Sprintf("idx_%s_not_deleted", strings.ReplaceAll(table, ".", "_"))
+
+	up := fmt.Sprintf(`-- Add soft delete column to %[1]s
+ALTER TABLE %[1]s
+ADD COLUMN IF NOT EXISTS %[2]s TIMESTAMP WITH TIME ZONE;
+
+-- Create partial index so queries filtering live rows stay fast
+CREATE INDEX IF NOT EXISTS %[3]s ON %[1]s(%[2]s) WHERE %[2]s IS NULL;`, table, DefaultSoftDeleteColumn, indexName)
+
+	down := fmt.Sprintf(`-- Remove soft delete column from %[1]s
+DROP INDEX IF EXISTS %[3]s;
+ALTER TABLE %[1]s DROP COLUMN IF EXISTS %[2]s;`, table, DefaultSoftDeleteColumn, indexName)
+
+	return up, down, nil
+}
@@ -2,163 +2,237 @@ package sqllib
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
 
 	"github.com/jackc/pgx/v5"
 )
 
 // GenerateSelect generates a SELECT SQL query.
-func GenerateSelect(table string, columns []string, conditions map[string]interface{}, logicalOperators []string) (string, pgx.NamedArgs, bool) {
-	sql := fmt.Sprintf("SELECT %s FROM %s", strings.Join(columns, ", "), table)
-	args := pgx.NamedArgs{}
-	conditionStr := []string{}
+//
+// Deprecated: use Select(columns...).From(table).Where(...) which supports
+// AND/OR grouping without the conditions map and logicalOperators slice.
+// Use SelectOption values for DISTINCT, GROUP BY, ORDER BY, LIMIT and OFFSET.
+func GenerateSelect(table string, columns []string, conditions map[string]interface{}, logicalOperators []string, opts ...SelectOption) (string, pgx.NamedArgs, bool, error) {
+	o := selectOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
 
-	i := 0
-	for key, value := range conditions {
-		var condition string
-		switch v := value.(type) {
-		case string:
-			if strings.Contains(v, "()") {
-				condition = fmt.Sprintf("%s = %s", key, v)
-			} else {
-				condition = fmt.Sprintf("%s = @%s", key, key)
-				args[key] = v
-			}
-		case []interface{}:
-			placeholders := []string{}
-			for j, val := range v {
-				placeholder := fmt.Sprintf("@%s_%d", key, j)
-				placeholders = append(placeholders, placeholder)
-				args[fmt.Sprintf("%s_%d", key, j)] = val
-			}
-			condition = fmt.Sprintf("%s IN (%s)", key, strings.Join(placeholders, ", "))
-		default:
-			condition = fmt.Sprintf("%s = @%s", key, key)
-			args[key] = v
-		}
+	quotedTable, err := quoteTable(table)
+	if err != nil {
+		return "", nil, false, err
+	}
 
-		if i > 0 && len(logicalOperators) > 0 {
-			conditionStr = append(conditionStr, logicalOperators[i-1])
-		}
-		conditionStr = append(conditionStr, condition)
-		i++
+	quotedColumns, err := quoteColumns(columns)
+	if err != nil {
+		return "", nil, false, err
+	}
+
+	selectList := strings.Join(quotedColumns, ", ")
+	if o.distinct {
+		selectList = "DISTINCT " + selectList
 	}
 
-	if len(conditionStr) > 0 {
-		sql += " WHERE " + strings.Join(conditionStr, " ")
+	sql := fmt.Sprintf("SELECT %s FROM %s", selectList, quotedTable)
+	args := pgx.NamedArgs{}
+
+	where, err := buildConditions(conditions, logicalOperators, args)
+	if err != nil {
+		return "", nil, false, err
 	}
 
-	return sql, args, false
+	softDelete, err := softDeleteColumn(table)
+	if err != nil {
+		return "", nil, false, err
+	}
+	if softDelete != "" && !o.withDeleted {
+		sql += joinWhere(where, softDelete+" IS NULL")
+	} else {
+		sql += joinWhere(where)
+	}
 
+	tail, err := o.tail()
+	if err != nil {
+		return "", nil, false, err
+	}
+	sql += tail
+
+	return sql, args, false, nil
 }
 
 // GenerateInsert generates an INSERT SQL query.
-func GenerateInsert(table string, data map[string]interface{}) (string, pgx.NamedArgs, bool) {
+// Use InsertOption values for RETURNING and ON CONFLICT (upsert) clauses.
+func GenerateInsert(table string, data map[string]interface{}, opts ...InsertOption) (string, pgx.NamedArgs, bool, error) {
+	o := insertOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	quotedTable, err := quoteTable(table)
+	if err != nil {
+		return "", nil, true, err
+	}
+
 	columns := []string{}
 	values := []string{}
 	args := pgx.NamedArgs{}
 
-	for key, value := range data {
-		columns = append(columns, key)
-		values = append(values, fmt.Sprintf("@%s", key))
-		args[key] = value
+	for _, key := range sortedKeys(data) {
+		value := data[key]
+		column, err := quoteColumn(key)
+		if err != nil {
+			return "", nil, true, err
+		}
+		columns = append(columns, column)
+		values = append(values, fmt.Sprintf("@%s", argName(key)))
+		args[argName(key)] = value
 	}
 
-	sql := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", table, strings.Join(columns, ", "), strings.Join(values, ", "))
+	sql := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", quotedTable, strings.Join(columns, ", "), strings.Join(values, ", "))
+
+	tail, err := o.tail(sortedKeys(data))
+	if err != nil {
+		return "", nil, true, err
+	}
+	sql += tail
 
-	return sql, args, true
+	return sql, args, true, nil
 }
 
 // GenerateUpdate generates an UPDATE SQL query.
-func GenerateUpdate(table string, data map[string]interface{}, conditions map[string]interface{}, logicalOperators []string) (string, pgx.NamedArgs, bool) {
-	setStr := []string{}
-	args := pgx.NamedArgs{}
-
-	for key, value := range data {
-		setStr = append(setStr, fmt.Sprintf("%s = @%s", key, key))
-		args[key] = value
+//
+// Deprecated: use Update(table).Set(...).Where(...) instead.
+func GenerateUpdate(table string, data map[string]interface{}, conditions map[string]interface{}, logicalOperators []string) (string, pgx.NamedArgs, bool, error) {
+	quotedTable, err := quoteTable(table)
+	if err != nil {
+		return "", nil, true, err
 	}
 
-	conditionStr := []string{}
-	i := 0
-	for key, value := range conditions {
-		var condition string
-		switch v := value.(type) {
-		case string:
-			if strings.Contains(v, "()") {
-				condition = fmt.Sprintf("%s = %s", key, v)
-			} else {
-				condition = fmt.Sprintf("%s = @%s", key, key)
-				args[key] = v
-			}
-		case []interface{}:
-			placeholders := []string{}
-			for j, val := range v {
-				placeholder := fmt.Sprintf("@%s_%d", key, j)
-				placeholders = append(placeholders, placeholder)
-				args[fmt.Sprintf("%s_%d", key, j)] = val
-			}
-			condition = fmt.Sprintf("%s IN (%s)", key, strings.Join(placeholders, ", "))
-		default:
-			condition = fmt.Sprintf("%s = @%s", key, key)
-			args[key] = v
-		}
+	setStr := []string{}
+	args := pgx.NamedArgs{}
 
-		if i > 0 && len(logicalOperators) > 0 {
-			conditionStr = append(conditionStr, logicalOperators[i-1])
+	for _, key := range sortedKeys(data) {
+		value := data[key]
+		column, err := quoteColumn(key)
+		if err != nil {
+			return "", nil, true, err
 		}
-		conditionStr = append(conditionStr, condition)
-		i++
+		// Prefix SET args so they can't collide with condition args on the same column
+		name := "set_" + argName(key)
+		setStr = append(setStr, fmt.Sprintf("%s = @%s", column, name))
+		args[name] = value
 	}
 
-	sql := fmt.Sprintf("UPDATE %s SET %s", table, strings.Join(setStr, ", "))
-	if len(conditionStr) > 0 {
-		sql += " WHERE " + strings.Join(conditionStr, " ")
+	sql := fmt.Sprintf("UPDATE %s SET %s", quotedTable, strings.Join(setStr, ", "))
+
+	where, err := buildConditions(conditions, logicalOperators, args)
+	if err != nil {
+		return "", nil, true, err
 	}
+	sql += joinWhere(where)
 
-	return sql, args, true
+	return sql, args, true, nil
 }
 
 // GenerateDelete generates a DELETE SQL query.
-func GenerateDelete(table string, conditions map[string]interface{}, logicalOperators []string) (string, pgx.NamedArgs, bool) {
-	sql := fmt.Sprintf("DELETE FROM %s", table)
+// For tables registered with EnableSoftDelete it generates an UPDATE that sets
+// the deleted_at column instead, unless WithHardDelete is passed.
+//
+// Deprecated: use DeleteFrom(table).Where(...) instead.
+func GenerateDelete(table string, conditions map[string]interface{}, logicalOperators []string, opts ...DeleteOption) (string, pgx.NamedArgs, bool, error) {
+	o := deleteOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	quotedTable, err := quoteTable(table)
+	if err != nil {
+		return "", nil, true, err
+	}
+
 	args := pgx.NamedArgs{}
+
+	where, err := buildConditions(conditions, logicalOperators, args)
+	if err != nil {
+		return "", nil, true, err
+	}
+
+	softDelete, err := softDeleteColumn(table)
+	if err != nil {
+		return "", nil, true, err
+	}
+	if softDelete != "" && !o.hardDelete {
+		sql := fmt.Sprintf("UPDATE %s SET %s = now()", quotedTable, softDelete)
+		sql += joinWhere(where, softDelete+" IS NULL")
+		return sql, args, true, nil
+	}
+
+	sql := fmt.Sprintf("DELETE FROM %s", quotedTable)
+	sql += joinWhere(where)
+
+	return sql, args, true, nil
+}
+
+// buildConditions renders the WHERE expression (without the keyword) and adds its values to args.
+// Values may be plain values (equality), []interface{} (IN) or a Condition.
+// Conditions are rendered in sorted key order so the SQL is stable across calls;
+// logicalOperators[i-1] joins the i-th sorted condition to the previous one, defaulting to AND.
+func buildConditions(conditions map[string]interface{}, logicalOperators []string, args pgx.NamedArgs) (string, error) {
 	conditionStr := []string{}
 
 	i := 0
-	for key, value := range conditions {
+	for _, key := range sortedKeys(conditions) {
+		value := conditions[key]
+		column, err := quoteColumn(key)
+		if err != nil {
+			return "", err
+		}
+		name := argName(key)
+
 		var condition string
 		switch v := value.(type) {
+		case Condition:
+			condition, err = v.render(column, name, args)
+			if err != nil {
+				return "", err
+			}
 		case string:
+			// Raw SQL such as "NOW()" used to be passed through here, which
+			// allowed injection through condition values
 			if strings.Contains(v, "()") {
-				condition = fmt.Sprintf("%s = %s", key, v)
-			} else {
-				condition = fmt.Sprintf("%s = @%s", key, key)
-				args[key] = v
+				return "", fmt.Errorf("condition value for %q looks like a SQL function; raw SQL is not allowed", key)
 			}
+			condition = fmt.Sprintf("%s = @%s", column, name)
+			args[name] = v
 		case []interface{}:
-			placeholders := []string{}
-			for j, val := range v {
-				placeholder := fmt.Sprintf("@%s_%d", key, j)
-				placeholders = append(placeholders, placeholder)
-				args[fmt.Sprintf("%s_%d", key, j)] = val
-			}
-			condition = fmt.Sprintf("%s IN (%s)", key, strings.Join(placeholders, ", "))
+			condition, _ = In(key, v...).render(column, name, args)
 		default:
-			condition = fmt.Sprintf("%s = @%s", key, key)
-			args[key] = v
+			condition = fmt.Sprintf("%s = @%s", column, name)
+			args[name] = v
 		}
 
-		if i > 0 && len(logicalOperators) > 0 {
-			conditionStr = append(conditionStr, logicalOperators[i-1])
+		if i > 0 {
+			op := "AND"
+			if i-1 < len(logicalOperators) {
+				op, err = validateLogicalOperator(logicalOperators[i-1])
+				if err != nil {
+					return "", err
+				}
+			}
+			conditionStr = append(conditionStr, op)
 		}
 		conditionStr = append(conditionStr, condition)
 		i++
 	}
 
-	if len(conditionStr) > 0 {
-		sql += " WHERE " + strings.Join(conditionStr, " ")
-	}
+	return strings.Join(conditionStr, " "), nil
+}
 
-	return sql, args, true
+// sortedKeys returns map keys in sorted order. Map iteration order is random,
+// which would otherwise change the generated SQL between calls and defeat
+// prepared statement caching.
+func sortedKeys[V any](m map[string]V) []string {
+	return slices.Sorted(maps.Keys(m))
 }
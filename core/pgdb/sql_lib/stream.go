@@ -0,0 +1,82 @@
+package sqllib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/yourorg/go-api-template/core/pgdb"
+)
+
+// ErrStopStream can be returned from an ExecuteStream callback to stop reading early without an error
+var ErrStopStream = errors.New("stop stream")
+
+// ExecuteStream runs a read query and calls fn for each row scanned into R, one row
+// at a time, so large exports don't hold the whole result set in memory.
+// Unlike Execute there is no built-in timeout; bound the query with ctx.
+func ExecuteStream[R any](ctx context.Context, query string, args pgx.NamedArgs, fn func(R) error) (*int, error) {
+	count := 0
+	for row, err := range Stream[R](ctx, query, args) {
+		if err != nil {
+			return &count, err
+		}
+		if err := fn(row); err != nil {
+			if errors.Is(err, ErrStopStream) {
+				break
+			}
+			return &count, err
+		}
+		count++
+	}
+
+	return &count, nil
+}
+
+// Stream is the iterator form of ExecuteStream. Breaking out of the loop closes the rows.
+//
+//	for product, err := range sqllib.Stream[Product](ctx, query, args) {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+func Stream[R any](ctx context.Context, query string, args pgx.NamedArgs) iter.Seq2[R, error] {
+	return func(yield func(R, error) bool) {
+		var zero R
+
+		dbPool, err := pgdb.GetReadPgPool()
+		if err != nil {
+			yield(zero, fmt.Errorf("error getting database pool: %w", err))
+			return
+		}
+
+		if dbPool == nil {
+			yield(zero, fmt.Errorf("dbPool is nil"))
+			return
+		}
+
+		rows, err := dbPool.Query(ctx, query, args)
+		if err != nil {
+			yield(zero, fmt.Errorf("error executing query: %w", err))
+			return
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			row, err := pgx.RowToStructByNameLax[R](rows)
+			if err != nil {
+				yield(zero, fmt.Errorf("error processing rows: %w", err))
+				return
+			}
+			if !yield(row, nil) {
+				return
+			}
+		}
+
+		if err := rows.Err(); err != nil {
+			yield(zero, fmt.Errorf("error processing rows: %w", err))
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package pgdb
+
+import (
+	"context"
+	"time"
+	"unicode/utf8"
+
+	"github.com/exaring/otelpgx"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DefaultMaxStatementLength bounds the statements of query spans when maxStatementLength is 0
+const DefaultMaxStatementLength = 2048
+
+// WaitTimeKey is the query span attribute holding how long, in seconds, the query waited for
+// a pool connection; it is set on the first query run on an acquired connection
+const WaitTimeKey = attribute.Key("db.client.connection.wait_time")
+
+// waitTimeData is the PgConn custom data key of the wait of the last acquire
+const waitTimeData = "pgdb.acquire_wait_time"
+
+type acquireStartKey struct{}
+
+// Tracer is the otelpgx tracer of a pool, truncating statements to the configured length and
+// adding the pool wait time to query spans. Rows affected are recorded by otelpgx as
+// pgx.rows_affected.
+type Tracer struct {
+	*otelpgx.Tracer
+	maxStatementLength int
+}
+
+// NewTracer returns the tracer of a pool configured with postgresConfig; query parameters
+// are only recorded with EnableQueryParamsTracing
+func NewTracer(postgresConfig PostgresConfig, opts ...otelpgx.Option) *Tracer {
+	if postgresConfig.EnableQueryParamsTracing {
+		opts = append(opts, otelpgx.WithIncludeQueryParameters())
+	}
+	maxStatementLength := postgresConfig.MaxStatementLength
+	if maxStatementLength == 0 {
+		maxStatementLength = DefaultMaxStatementLength
+	}
+	return &Tracer{Tracer: otelpgx.NewTracer(opts...), maxStatementLength: maxStatementLength}
+}
+
+// TraceQueryStart starts the span of Query, QueryRow and Exec calls
+func (t *Tracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	data.SQL = truncateStatement(data.SQL, t.maxStatementLength)
+	ctx = t.Tracer.TraceQueryStart(ctx, conn, data)
+
+	if conn != nil && conn.PgConn() != nil {
+		customData := conn.PgConn().CustomData()
+		if wait, ok := customData[waitTimeData].(time.Duration); ok {
+			delete(customData, waitTimeData)
+			trace.SpanFromContext(ctx).SetAttributes(WaitTimeKey.Float64(wait.Seconds()))
+		}
+	}
+	return ctx
+}
+
+// TraceBatchQuery records a query of a batch
+func (t *Tracer) TraceBatchQuery(ctx context.Context, conn *pgx.Conn, data pgx.TraceBatchQueryData) {
+	data.SQL = truncateStatement(data.SQL, t.maxStatementLength)
+	t.Tracer.TraceBatchQuery(ctx, conn, data)
+}
+
+// TracePrepareStart starts the span of Prepare calls
+func (t *Tracer) TracePrepareStart(ctx context.Context, conn *pgx.Conn, data pgx.TracePrepareStartData) context.Context {
+	data.SQL = truncateStatement(data.SQL, t.maxStatementLength)
+	return t.Tracer.TracePrepareStart(ctx, conn, data)
+}
+
+// TraceAcquireStart starts the span of a pool acquire
+func (t *Tracer) TraceAcquireStart(ctx context.Context, pool *pgxpool.Pool, data pgxpool.TraceAcquireStartData) context.Context {
+	ctx = context.WithValue(ctx, acquireStartKey{}, time.Now())
+	return t.Tracer.TraceAcquireStart(ctx, pool, data)
+}
+
+// TraceAcquireEnd ends the span of a pool acquire, keeping the wait on the connection for
+// the span of its next query
+func (t *Tracer) TraceAcquireEnd(ctx context.Context, pool *pgxpool.Pool, data pgxpool.TraceAcquireEndData) {
+	if start, ok := ctx.Value(acquireStartKey{}).(time.Time); ok {
+		wait := time.Since(start)
+		trace.SpanFromContext(ctx).SetAttributes(WaitTimeKey.Float64(wait.Seconds()))
+		if data.Conn != nil && data.Conn.PgConn() != nil {
+			data.Conn.PgConn().CustomData()[waitTimeData] = wait
+		}
+	}
+	t.Tracer.TraceAcquireEnd(ctx, pool, data)
+}
+
+// truncateStatement cuts sql to max bytes, on a rune boundary; negative keeps it whole
+func truncateStatement(sql string, max int) string {
+	if max < 0 || len(sql) <= max {
+		return sql
+	}
+	cut := max
+	for cut > 0 && !utf8.RuneStart(sql[cut]) {
+		cut--
+	}
+	return sql[:cut] + "..."
+}
@@ -0,0 +1,82 @@
+package pgdb
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// DBTX is satisfied by *pgxpool.Pool, *pgxpool.Conn and pgx.Tx so helpers
+// can run either standalone or inside a caller's transaction.
+type DBTX interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// WithinTransaction runs fn inside a transaction on the write pool.
+// The transaction is committed if fn returns nil and rolled back otherwise.
+// The hooks fn registered with AfterCommit run once the commit succeeded.
+func WithinTransaction(ctx context.Context, fn func(ctx context.Context, tx pgx.Tx) error) error {
+	pool, err := GetWritePgPool()
+	if err != nil {
+		return fmt.Errorf("error getting database pool: %w", err)
+	}
+
+	ctx, hooks := WithCommitHooks(ctx)
+	err = pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+		return fn(ctx, tx)
+	})
+	if err != nil {
+		return err
+	}
+	hooks.Run(ctx)
+	return nil
+}
+
+type commitHooksKey struct{}
+
+// CommitHooks collects the functions registered with AfterCommit during a transaction
+type CommitHooks struct {
+	mu    sync.Mutex
+	hooks []func(ctx context.Context)
+}
+
+// WithCommitHooks returns a context collecting the AfterCommit hooks until Run, for
+// transactions not begun by WithinTransaction; a transaction that rolls back drops the hooks
+// by not running them.
+func WithCommitHooks(ctx context.Context) (context.Context, *CommitHooks) {
+	hooks := &CommitHooks{}
+	return context.WithValue(ctx, commitHooksKey{}, hooks), hooks
+}
+
+// Run calls the hooks in the order they were registered, once; the hooks registered by them
+// run right away
+func (h *CommitHooks) Run(ctx context.Context) {
+	h.mu.Lock()
+	hooks := h.hooks
+	h.hooks = nil
+	h.mu.Unlock()
+
+	// the hooks may register more, they run outside of the transaction
+	ctx = context.WithValue(ctx, commitHooksKey{}, (*CommitHooks)(nil))
+	for _, hook := range hooks {
+		hook(ctx)
+	}
+}
+
+// AfterCommit runs fn once the transaction of ctx committed, or right away when ctx is not
+// within a transaction. The hooks of a transaction rolled back never run.
+func AfterCommit(ctx context.Context, fn func(ctx context.Context)) {
+	hooks, _ := ctx.Value(commitHooksKey{}).(*CommitHooks)
+	if hooks == nil {
+		fn(ctx)
+		return
+	}
+	hooks.mu.Lock()
+	defer hooks.mu.Unlock()
+	hooks.hooks = append(hooks.hooks, fn)
+}
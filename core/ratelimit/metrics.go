@@ -0,0 +1,42 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const meterName = "github.com/yourorg/go-api-template/core/ratelimit"
+
+// Decisions of the rate limiting middleware
+const (
+	decisionAllowed = "allowed"
+	decisionLimited = "limited"
+	// decisionError lets the request through, the limiter failing open
+	decisionError = "error"
+)
+
+var (
+	decisionCounter     metric.Int64Counter
+	decisionCounterOnce sync.Once
+)
+
+// recordDecision counts a request checked by Middleware by decision. Keys are not recorded,
+// client IPs and user IDs would make the attribute unbounded.
+func recordDecision(ctx context.Context, decision string) {
+	decisionCounterOnce.Do(func() {
+		// instruments from the global meter follow a provider installed later
+		decisionCounter, _ = otel.Meter(meterName).Int64Counter(
+			"ratelimit.requests",
+			metric.WithDescription("Number of requests checked by the rate limiter by decision"),
+			metric.WithUnit("{request}"),
+		)
+	})
+	if decisionCounter == nil {
+		return
+	}
+	decisionCounter.Add(ctx, 1, metric.WithAttributes(attribute.String("ratelimit.decision", decision)))
+}
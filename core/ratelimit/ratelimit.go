@@ -2,6 +2,7 @@ package ratelimit
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
@@ -190,6 +191,44 @@ func (m *memoryLimiter) Allow(ctx context.Context, key string) (bool, *Result, e
 	return allowed, result, nil
 }
 
+// ErrWaitExceedsDeadline is returned by Wait when the next slot opens after the context deadline
+var ErrWaitExceedsDeadline = errors.New("rate limit wait would exceed context deadline")
+
+// minWaitInterval keeps Wait from spinning when a limiter reports no RetryAfter
+const minWaitInterval = 10 * time.Millisecond
+
+// Wait blocks until limiter allows a request for key, for callers that should
+// queue rather than be rejected. It fails early when the wait cannot finish
+// before the ctx deadline. Note the Redis limiter counts rejected attempts too,
+// so waiters on it retry at most once per window.
+func Wait(ctx context.Context, limiter Limiter, key string) error {
+	for {
+		allowed, result, err := limiter.Allow(ctx, key)
+		if err != nil {
+			return err
+		}
+		if allowed {
+			return nil
+		}
+
+		delay := result.RetryAfter
+		if delay < minWaitInterval {
+			delay = minWaitInterval
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return ErrWaitExceedsDeadline
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
 // Reset removes rate limit data for a key (Redis implementation)
 func (r *redisLimiter) Reset(ctx context.Context, key string) error {
 	return r.cacheService.Delete(ctx, key)
@@ -220,6 +259,7 @@ func Middleware(limiter Limiter, config Config) func(http.Handler) http.Handler
 			ctx := r.Context()
 			allowed, result, err := limiter.Allow(ctx, key)
 			if err != nil {
+				recordDecision(ctx, decisionError)
 				if logger.Slog != nil {
 					logger.Slog.ErrorContext(ctx, "Rate limiting error", "key", key, "error", err.Error())
 				}
@@ -241,6 +281,7 @@ func Middleware(limiter Limiter, config Config) func(http.Handler) http.Handler
 			
 			if !allowed {
 				// Rate limit exceeded
+				recordDecision(ctx, decisionLimited)
 				if logger.Slog != nil {
 					logger.Slog.WarnContext(ctx, "Rate limit exceeded", 
 						"key", key, 
@@ -257,6 +298,7 @@ func Middleware(limiter Limiter, config Config) func(http.Handler) http.Handler
 			}
 			
 			// Request allowed, log if configured
+			recordDecision(ctx, decisionAllowed)
 			if logger.Slog != nil {
 				logger.Slog.DebugContext(ctx, "Rate limit check passed",
 					"key", key,
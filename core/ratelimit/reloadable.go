@@ -0,0 +1,47 @@
+package ratelimit
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// Reloadable serves rate limiting whose limiter and config can be replaced while running,
+// e.g. on a config reload. A nil limiter disables rate limiting.
+type Reloadable struct {
+	current atomic.Pointer[reloadableState]
+}
+
+type reloadableState struct {
+	limiter Limiter
+	config  Config
+}
+
+// NewReloadable returns a Reloadable starting with limiter and config
+func NewReloadable(limiter Limiter, config Config) *Reloadable {
+	r := &Reloadable{}
+	r.Update(limiter, config)
+	return r
+}
+
+// Update replaces the limiter and config; requests in flight finish with the previous ones.
+// Counters are kept by the limiter, so a new memory limiter starts from zero.
+func (r *Reloadable) Update(limiter Limiter, config Config) {
+	if config.KeyBuilder == nil {
+		config.KeyBuilder = DefaultKeyBuilder
+	}
+	r.current.Store(&reloadableState{limiter: limiter, config: config})
+}
+
+// Middleware applies the current limiter and config to every request
+func (r *Reloadable) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			state := r.current.Load()
+			if state.limiter == nil {
+				next.ServeHTTP(w, req)
+				return
+			}
+			Middleware(state.limiter, state.config)(next).ServeHTTP(w, req)
+		})
+	}
+}
@@ -0,0 +1,253 @@
+// Package scaffold generates the files of a CRUD resource following the layout of the example
+// resource: model, repository, service, migration and test, plus its registration in the
+// repository, the service and the routes at their +scaffold markers.
+package scaffold
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// ErrInvalidName is returned for a resource name that is not made of letters and digits
+var ErrInvalidName = errors.New("invalid resource name")
+
+// Resource holds the forms of a resource name used by the templates, e.g. for "order_item":
+// OrderItem, OrderItems, orderItem, order_item, order_items and order-items
+type Resource struct {
+	Name        string // exported Go name
+	Plural      string // exported Go name of the collection
+	Var         string // unexported Go name
+	File        string // file name prefix
+	Table       string // database table
+	Path        string // URL path segment
+	Human       string // name in doc comments and messages
+	HumanPlural string
+	Module      string // Go module path of the project
+}
+
+// NewResource derives the forms of name, given in CamelCase, snake_case or kebab-case
+func NewResource(name string, module string) (Resource, error) {
+	words := splitWords(name)
+	if len(words) == 0 || !unicode.IsLetter(rune(words[0][0])) {
+		return Resource{}, fmt.Errorf("%w: %q", ErrInvalidName, name)
+	}
+	for _, word := range words {
+		for _, c := range word {
+			if c > unicode.MaxASCII || !(unicode.IsLetter(c) || unicode.IsDigit(c)) {
+				return Resource{}, fmt.Errorf("%w: %q", ErrInvalidName, name)
+			}
+		}
+	}
+
+	plural := append(words[:len(words)-1:len(words)-1], pluralize(words[len(words)-1]))
+	r := Resource{
+		Name:        camel(words),
+		Plural:      camel(plural),
+		File:        strings.Join(words, "_"),
+		Table:       strings.Join(plural, "_"),
+		Path:        strings.Join(plural, "-"),
+		Human:       strings.Join(words, " "),
+		HumanPlural: strings.Join(plural, " "),
+		Module:      module,
+	}
+	r.Var = words[0] + r.Name[len(words[0]):]
+	return r, nil
+}
+
+// splitWords splits name into lower case words at underscores, dashes, spaces and case changes
+func splitWords(name string) []string {
+	var words []string
+	var word []rune
+	runes := []rune(name)
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, strings.ToLower(string(word)))
+			word = nil
+		}
+	}
+	for i, c := range runes {
+		switch {
+		case c == '_' || c == '-' || c == ' ':
+			flush()
+			continue
+		case unicode.IsUpper(c) && i > 0 && (unicode.IsLower(runes[i-1]) ||
+			i+1 < len(runes) && unicode.IsUpper(runes[i-1]) && unicode.IsLower(runes[i+1])):
+			// a new word starts at "Item" of OrderItem and "Item" of HTTPItem
+			flush()
+		}
+		word = append(word, c)
+	}
+	flush()
+	return words
+}
+
+func camel(words []string) string {
+	var b strings.Builder
+	for _, word := range words {
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return b.String()
+}
+
+// pluralize applies the regular English plural rules, enough for table and path names
+func pluralize(word string) string {
+	switch {
+	case strings.HasSuffix(word, "y") && len(word) > 1 && !strings.ContainsRune("aeiou", rune(word[len(word)-2])):
+		return word[:len(word)-1] + "ies"
+	case strings.HasSuffix(word, "s"), strings.HasSuffix(word, "x"), strings.HasSuffix(word, "z"),
+		strings.HasSuffix(word, "ch"), strings.HasSuffix(word, "sh"):
+		return word + "es"
+	default:
+		return word + "s"
+	}
+}
+
+// File is a generated file, Path being relative to the project root
+type File struct {
+	Path    string
+	Content []byte
+}
+
+// Generate renders the Go files of the resource, formatted with gofmt
+func Generate(r Resource) ([]File, error) {
+	files := []struct {
+		path string
+		tmpl *template.Template
+	}{
+		{filepath.Join("internal", "model", r.File+"_model.go"), modelTemplate},
+		{filepath.Join("internal", "repository", r.File+"_repo.go"), repositoryTemplate},
+		{filepath.Join("internal", "service", r.File+"_service.go"), serviceTemplate},
+		{filepath.Join("tests", "unit", r.File+"_service_test.go"), testTemplate},
+	}
+
+	generated := make([]File, 0, len(files))
+	for _, f := range files {
+		src, err := render(f.tmpl, r)
+		if err != nil {
+			return nil, err
+		}
+		formatted, err := format.Source(src)
+		if err != nil {
+			return nil, fmt.Errorf("error formatting %s: %w", f.path, err)
+		}
+		generated = append(generated, File{Path: f.path, Content: formatted})
+	}
+	return generated, nil
+}
+
+// Migration returns the up and down SQL creating the table of the resource
+func Migration(r Resource) (string, string, error) {
+	up, err := render(migrationUpTemplate, r)
+	if err != nil {
+		return "", "", err
+	}
+	down, err := render(migrationDownTemplate, r)
+	if err != nil {
+		return "", "", err
+	}
+	return string(up), string(down), nil
+}
+
+func render(tmpl *template.Template, r Resource) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, r); err != nil {
+		return nil, fmt.Errorf("error rendering %s: %w", tmpl.Name(), err)
+	}
+	return buf.Bytes(), nil
+}
+
+// registration is code inserted above a +scaffold marker of an existing file
+type registration struct {
+	path   string
+	marker string
+	tmpl   *template.Template
+}
+
+var registrations = []registration{
+	{filepath.Join("internal", "repository", "repository.go"), "+scaffold:repository-fields", repositoryFieldTemplate},
+	{filepath.Join("internal", "repository", "repository.go"), "+scaffold:repositories", repositoryInitTemplate},
+	{filepath.Join("internal", "service", "service.go"), "+scaffold:service-fields", serviceFieldTemplate},
+	{filepath.Join("internal", "service", "service.go"), "+scaffold:services", serviceInitTemplate},
+	{filepath.Join("internal", "server", "route.go"), "+scaffold:routes", routesTemplate},
+}
+
+// Register wires the resource into the repository, the service and the routes under root,
+// returning the files it changed. Code already present is left alone, so Register can be rerun.
+func Register(root string, r Resource) ([]string, error) {
+	var changed []string
+	for _, reg := range registrations {
+		path := filepath.Join(root, reg.path)
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return changed, err
+		}
+		snippet, err := render(reg.tmpl, r)
+		if err != nil {
+			return changed, err
+		}
+
+		updated, err := insertAtMarker(src, reg.marker, snippet)
+		if err != nil {
+			return changed, fmt.Errorf("%s: %w", reg.path, err)
+		}
+		if bytes.Equal(updated, src) {
+			continue
+		}
+		if err := os.WriteFile(path, updated, 0644); err != nil {
+			return changed, err
+		}
+		if len(changed) == 0 || changed[len(changed)-1] != reg.path {
+			changed = append(changed, reg.path)
+		}
+	}
+	return changed, nil
+}
+
+// insertAtMarker inserts snippet above the line holding marker, indented like it. src is
+// returned unchanged when it already contains the first line of snippet.
+func insertAtMarker(src []byte, marker string, snippet []byte) ([]byte, error) {
+	lines := strings.Split(strings.TrimSuffix(string(snippet), "\n"), "\n")
+	if bytes.Contains(src, []byte(strings.TrimSpace(lines[0]))) {
+		return src, nil
+	}
+
+	i := bytes.Index(src, []byte("// "+marker))
+	if i < 0 {
+		return nil, fmt.Errorf("marker %q not found", "// "+marker)
+	}
+	lineStart := bytes.LastIndexByte(src[:i], '\n') + 1
+	indent := string(src[lineStart:i])
+
+	var b bytes.Buffer
+	b.Write(src[:lineStart])
+	for _, line := range lines {
+		if line != "" {
+			b.WriteString(indent)
+			b.WriteString(line)
+		}
+		b.WriteByte('\n')
+	}
+	b.Write(src[lineStart:])
+	return b.Bytes(), nil
+}
+
+// ModulePath reads the module path of the go.mod file in root
+func ModulePath(root string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if module, ok := strings.CutPrefix(strings.TrimSpace(line), "module "); ok {
+			return strings.Trim(strings.TrimSpace(module), `"`), nil
+		}
+	}
+	return "", errors.New("go.mod has no module directive")
+}
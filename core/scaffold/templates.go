@@ -0,0 +1,28 @@
+package scaffold
+
+import (
+	"embed"
+	"text/template"
+)
+
+//go:embed templates
+var templates embed.FS
+
+var (
+	modelTemplate         = parse("model.go.tmpl")
+	repositoryTemplate    = parse("repository.go.tmpl")
+	serviceTemplate       = parse("service.go.tmpl")
+	testTemplate          = parse("service_test.go.tmpl")
+	migrationUpTemplate   = parse("migration.up.sql.tmpl")
+	migrationDownTemplate = parse("migration.down.sql.tmpl")
+
+	repositoryFieldTemplate = parse("repository_field.tmpl")
+	repositoryInitTemplate  = parse("repository_init.tmpl")
+	serviceFieldTemplate    = parse("service_field.tmpl")
+	serviceInitTemplate     = parse("service_init.tmpl")
+	routesTemplate          = parse("routes.tmpl")
+)
+
+func parse(name string) *template.Template {
+	return template.Must(template.New(name).ParseFS(templates, "templates/"+name))
+}
@@ -0,0 +1,194 @@
+package secrets
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+)
+
+// Age identities are read from the variables sops uses: SOPS_AGE_KEY holds keys,
+// SOPS_AGE_KEY_FILE names a keys file, otherwise <user config dir>/sops/age/keys.txt is read
+const (
+	AgeKeyEnv     = "SOPS_AGE_KEY"
+	AgeKeyFileEnv = "SOPS_AGE_KEY_FILE"
+)
+
+// ErrNoAgeIdentity is returned when a value is not encrypted to any of the identities
+var ErrNoAgeIdentity = errors.New("no age identity matches the encrypted value")
+
+// AgeIdentity is an X25519 age secret key, AGE-SECRET-KEY-1...
+type AgeIdentity struct {
+	identity *age.X25519Identity
+}
+
+// ParseAgeIdentity parses an AGE-SECRET-KEY-1... key
+func ParseAgeIdentity(key string) (AgeIdentity, error) {
+	identity, err := age.ParseX25519Identity(key)
+	if err != nil {
+		return AgeIdentity{}, fmt.Errorf("invalid age identity: %w", err)
+	}
+	return AgeIdentity{identity: identity}, nil
+}
+
+// ParseAgeIdentities parses the keys of an age keys file, one per line, # starting a comment
+func ParseAgeIdentities(keys string) ([]AgeIdentity, error) {
+	var identities []AgeIdentity
+	scanner := bufio.NewScanner(strings.NewReader(keys))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		identity, err := ParseAgeIdentity(line)
+		if err != nil {
+			return nil, err
+		}
+		identities = append(identities, identity)
+	}
+	return identities, scanner.Err()
+}
+
+// LoadAgeIdentities reads the identities of AgeKeyEnv and AgeKeyFileEnv, or of the default keys
+// file when neither is set. No identity at all is not an error, decrypting then fails.
+func LoadAgeIdentities() ([]AgeIdentity, error) {
+	identities, err := ParseAgeIdentities(os.Getenv(AgeKeyEnv))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", AgeKeyEnv, err)
+	}
+
+	path := os.Getenv(AgeKeyFileEnv)
+	if path == "" && len(identities) == 0 {
+		if dir, err := os.UserConfigDir(); err == nil {
+			path = filepath.Join(dir, "sops", "age", "keys.txt")
+		}
+		if _, err := os.Stat(path); err != nil {
+			return identities, nil
+		}
+	}
+	if path == "" {
+		return identities, nil
+	}
+
+	keys, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	fromFile, err := ParseAgeIdentities(string(keys))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return append(identities, fromFile...), nil
+}
+
+// GenerateAgeIdentity returns a new random identity
+func GenerateAgeIdentity() (AgeIdentity, error) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		return AgeIdentity{}, err
+	}
+	return AgeIdentity{identity: identity}, nil
+}
+
+// String returns the AGE-SECRET-KEY-1... encoding of the identity
+func (i AgeIdentity) String() string {
+	return i.identity.String()
+}
+
+// Recipient returns the age1... public key values are encrypted to for this identity
+func (i AgeIdentity) Recipient() string {
+	return i.identity.Recipient().String()
+}
+
+// EncryptAge encrypts plaintext to the age1... recipients, in the binary age format
+func EncryptAge(plaintext []byte, recipients ...string) ([]byte, error) {
+	if len(recipients) == 0 {
+		return nil, errors.New("no age recipient")
+	}
+	parsed := make([]age.Recipient, len(recipients))
+	for i, recipient := range recipients {
+		r, err := age.ParseX25519Recipient(recipient)
+		if err != nil {
+			return nil, fmt.Errorf("invalid age recipient %s: %w", recipient, err)
+		}
+		parsed[i] = r
+	}
+
+	var out bytes.Buffer
+	w, err := age.Encrypt(&out, parsed...)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// DecryptAge decrypts a binary or armored age file with the first identity it was encrypted to
+func DecryptAge(ciphertext []byte, identities []AgeIdentity) ([]byte, error) {
+	var r io.Reader = bytes.NewReader(ciphertext)
+	if trimmed := bytes.TrimSpace(ciphertext); bytes.HasPrefix(trimmed, []byte(armor.Header)) {
+		r = armor.NewReader(bytes.NewReader(trimmed))
+	}
+
+	parsed := make([]age.Identity, len(identities))
+	for i, identity := range identities {
+		parsed[i] = identity.identity
+	}
+	plaintext, err := age.Decrypt(r, parsed...)
+	if err != nil {
+		var noMatch *age.NoIdentityMatchError
+		if errors.As(err, &noMatch) {
+			return nil, ErrNoAgeIdentity
+		}
+		return nil, err
+	}
+	return io.ReadAll(plaintext)
+}
+
+// AgeProvider decrypts enc: values, age files encoded in base64 such as the output of
+//
+//	printf %s "$VALUE" | age -r age1... | base64 -w0
+type AgeProvider struct {
+	identities func() ([]AgeIdentity, error)
+}
+
+// NewAgeProvider returns an AgeProvider reading its identities with LoadAgeIdentities
+func NewAgeProvider() *AgeProvider {
+	return &AgeProvider{identities: LoadAgeIdentities}
+}
+
+func (p *AgeProvider) Scheme() string { return SchemeEncrypted }
+
+// Fetch decrypts the value; JSON objects expose their fields as keys
+func (p *AgeProvider) Fetch(ctx context.Context, path string) (Secret, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(path)
+	if err != nil {
+		return Secret{}, fmt.Errorf("enc: value is not base64: %w", err)
+	}
+	identities, err := p.identities()
+	if err != nil {
+		return Secret{}, err
+	}
+	if len(identities) == 0 {
+		return Secret{}, fmt.Errorf("no age identity, set %s or %s", AgeKeyEnv, AgeKeyFileEnv)
+	}
+	plaintext, err := DecryptAge(ciphertext, identities)
+	if err != nil {
+		return Secret{}, err
+	}
+	return parseSecretString(string(plaintext)), nil
+}
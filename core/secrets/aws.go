@@ -0,0 +1,234 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AWSConfig configures the awssm: scheme. Credentials come from the default chain, see
+// AWSCredentialsSource; the region defaults to AWS_REGION.
+type AWSConfig struct {
+	Region string `mapstructure:"region"`
+	// Endpoint overrides https://secretsmanager.<region>.amazonaws.com, e.g. for a VPC endpoint
+	Endpoint string `mapstructure:"endpoint"`
+}
+
+// AWSProvider reads AWS Secrets Manager secrets, e.g. awssm:prod/postgres#password.
+// A version stage may follow the name: awssm:prod/postgres@AWSPREVIOUS.
+type AWSProvider struct {
+	config      AWSConfig
+	client      *http.Client
+	credentials *AWSCredentialsSource
+	now         func() time.Time
+}
+
+// NewAWSProvider returns an AWSProvider, filling the region from the environment
+func NewAWSProvider(cfg AWSConfig, client *http.Client) *AWSProvider {
+	if cfg.Region == "" {
+		cfg.Region = os.Getenv("AWS_REGION")
+	}
+	if cfg.Region == "" {
+		cfg.Region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if cfg.Endpoint == "" && cfg.Region != "" {
+		cfg.Endpoint = "https://secretsmanager." + cfg.Region + ".amazonaws.com"
+	}
+	return &AWSProvider{config: cfg, client: client, credentials: NewAWSCredentialsSource("", "", "", client), now: time.Now}
+}
+
+func (p *AWSProvider) Scheme() string { return SchemeAWS }
+
+// Fetch calls GetSecretValue; JSON object secrets expose their fields as keys
+func (p *AWSProvider) Fetch(ctx context.Context, path string) (Secret, error) {
+	if p.config.Endpoint == "" {
+		return Secret{}, fmt.Errorf("aws region is not configured")
+	}
+	input := map[string]string{"SecretId": path}
+	if name, stage, ok := strings.Cut(path, "@"); ok {
+		input = map[string]string{"SecretId": name, "VersionStage": stage}
+	}
+	payload, _ := json.Marshal(input)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.config.Endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return Secret{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	if err := signWithCredentials(ctx, req, payload, p.credentials, p.config.Region, "secretsmanager", p.now()); err != nil {
+		return Secret{}, err
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return Secret{}, err
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		SecretString string `json:"SecretString"`
+		SecretBinary string `json:"SecretBinary"`
+		Type         string `json:"__type"`
+		Message      string `json:"message"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return Secret{}, fmt.Errorf("aws returned %d: %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if strings.HasSuffix(body.Type, "ResourceNotFoundException") {
+			return Secret{}, ErrNotFound
+		}
+		return Secret{}, fmt.Errorf("aws returned %d: %s %s", resp.StatusCode, body.Type, body.Message)
+	}
+
+	if body.SecretString == "" && body.SecretBinary != "" {
+		raw, err := base64.StdEncoding.DecodeString(body.SecretBinary)
+		if err != nil {
+			return Secret{}, fmt.Errorf("aws returned an invalid binary secret: %w", err)
+		}
+		return Secret{Value: string(raw)}, nil
+	}
+	return parseSecretString(body.SecretString), nil
+}
+
+// signWithCredentials signs req with the credentials of source
+func signWithCredentials(ctx context.Context, req *http.Request, payload []byte, source *AWSCredentialsSource, region, service string, now time.Time) error {
+	credentials, err := source.Credentials(ctx)
+	if err != nil {
+		return err
+	}
+	if credentials.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", credentials.SessionToken)
+	}
+	SignV4(req, payload, credentials.AccessKeyID, credentials.SecretAccessKey, region, service, now)
+	return nil
+}
+
+// UnsignedPayload replaces the payload hash of SignV4Hash for bodies streamed without hashing them
+// first, which S3 accepts over HTTPS
+const UnsignedPayload = "UNSIGNED-PAYLOAD"
+
+// SignV4 signs req with AWS Signature Version 4, setting the X-Amz-Date and Authorization headers
+func SignV4(req *http.Request, payload []byte, accessKey, secretKey, region, service string, now time.Time) {
+	payloadHash := sha256.Sum256(payload)
+	SignV4Hash(req, hex.EncodeToString(payloadHash[:]), accessKey, secretKey, region, service, now)
+}
+
+// SignV4Hash signs req like SignV4 given the hex SHA-256 of its payload, or UnsignedPayload
+func SignV4Hash(req *http.Request, payloadHash string, accessKey, secretKey, region, service string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	if req.Host == "" {
+		req.Host = req.URL.Host
+	}
+
+	headers := map[string]string{"host": req.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.Join(values, ",")
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	signature := signatureV4(req.Method, req.URL, canonicalHeaders.String(), signedHeaders, payloadHash, amzDate, scope, secretKey, region, service)
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
+
+// PresignV4 returns u with the query parameters of a Signature Version 4 presigned URL valid for
+// expiry, e.g. for a client downloading an S3 object directly; sessionToken may be empty
+func PresignV4(method string, u *url.URL, accessKey, secretKey, sessionToken, region, service string, now time.Time, expiry time.Duration) *url.URL {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	scope := amzDate[:8] + "/" + region + "/" + service + "/aws4_request"
+
+	presigned := *u
+	query := presigned.Query()
+	query.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	query.Set("X-Amz-Credential", accessKey+"/"+scope)
+	query.Set("X-Amz-Date", amzDate)
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expiry.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+	if sessionToken != "" {
+		query.Set("X-Amz-Security-Token", sessionToken)
+	}
+	presigned.RawQuery = canonicalQuery(query)
+
+	signature := signatureV4(method, &presigned, "host:"+presigned.Host+"\n", "host", UnsignedPayload, amzDate, scope, secretKey, region, service)
+	presigned.RawQuery += "&X-Amz-Signature=" + signature
+	return &presigned
+}
+
+// signatureV4 returns the signature of the canonical request
+func signatureV4(method string, u *url.URL, canonicalHeaders, signedHeaders, payloadHash, amzDate, scope, secretKey, region, service string) string {
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		method,
+		path,
+		canonicalQuery(u.Query()),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), amzDate[:8])
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, awsEscape(k)+"="+awsEscape(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func awsEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,257 @@
+package secrets
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	awsContainerCredentialsHost = "http://169.254.170.2"
+	awsInstanceMetadataURL      = "http://169.254.169.254"
+)
+
+// AWSCredentials sign AWS requests; Expires is zero for long-lived keys
+type AWSCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Expires         time.Time
+}
+
+// AWSCredentialsSource returns the credentials of AWS requests: fixed keys, or those of the
+// default chain of the AWS SDKs, in order
+//   - AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN
+//   - web identity, AWS_WEB_IDENTITY_TOKEN_FILE and AWS_ROLE_ARN exchanged with STS (EKS IRSA)
+//   - the container endpoint, AWS_CONTAINER_CREDENTIALS_RELATIVE_URI or _FULL_URI (ECS tasks)
+//   - the instance profile of the EC2 metadata service (IMDSv2), unless AWS_EC2_METADATA_DISABLED
+//
+// Temporary credentials are cached until 5 minutes before they expire.
+type AWSCredentialsSource struct {
+	static AWSCredentials
+	client *http.Client
+	now    func() time.Time
+
+	mu     sync.Mutex
+	cached AWSCredentials
+}
+
+// NewAWSCredentialsSource returns a source of the given keys, or of the default chain when
+// accessKeyID is empty
+func NewAWSCredentialsSource(accessKeyID, secretAccessKey, sessionToken string, client *http.Client) *AWSCredentialsSource {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &AWSCredentialsSource{
+		static: AWSCredentials{AccessKeyID: accessKeyID, SecretAccessKey: secretAccessKey, SessionToken: sessionToken},
+		client: client,
+		now:    time.Now,
+	}
+}
+
+// Credentials returns the configured keys, or those of the first provider of the chain configured
+func (s *AWSCredentialsSource) Credentials(ctx context.Context) (AWSCredentials, error) {
+	if s.static.AccessKeyID != "" {
+		return s.static, nil
+	}
+	if accessKey, secretKey := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"); accessKey != "" && secretKey != "" {
+		return AWSCredentials{AccessKeyID: accessKey, SecretAccessKey: secretKey, SessionToken: os.Getenv("AWS_SESSION_TOKEN")}, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cached.AccessKeyID != "" && s.now().Before(s.cached.Expires.Add(-5*time.Minute)) {
+		return s.cached, nil
+	}
+
+	var credentials AWSCredentials
+	var err error
+	switch {
+	case os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE") != "" && os.Getenv("AWS_ROLE_ARN") != "":
+		credentials, err = s.webIdentity(ctx)
+	case os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI") != "" || os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI") != "":
+		credentials, err = s.container(ctx)
+	case !strings.EqualFold(os.Getenv("AWS_EC2_METADATA_DISABLED"), "true"):
+		credentials, err = s.instanceProfile(ctx)
+	default:
+		err = errors.New("no provider is configured")
+	}
+	if err != nil {
+		return AWSCredentials{}, fmt.Errorf("aws credentials are not set: %w", err)
+	}
+	s.cached = credentials
+	return credentials, nil
+}
+
+// webIdentity exchanges the token of AWS_WEB_IDENTITY_TOKEN_FILE for the role of AWS_ROLE_ARN
+func (s *AWSCredentialsSource) webIdentity(ctx context.Context) (AWSCredentials, error) {
+	token, err := os.ReadFile(os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE"))
+	if err != nil {
+		return AWSCredentials{}, fmt.Errorf("error reading the web identity token: %w", err)
+	}
+	sessionName := os.Getenv("AWS_ROLE_SESSION_NAME")
+	if sessionName == "" {
+		sessionName = "app-" + strconv.FormatInt(s.now().UnixNano(), 10)
+	}
+	endpoint := os.Getenv("AWS_ENDPOINT_URL_STS")
+	if endpoint == "" {
+		endpoint = "https://sts.amazonaws.com"
+		if region := cmp.Or(os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION")); region != "" {
+			endpoint = "https://sts." + region + ".amazonaws.com"
+		}
+	}
+
+	form := url.Values{
+		"Action":           {"AssumeRoleWithWebIdentity"},
+		"Version":          {"2011-06-15"},
+		"RoleArn":          {os.Getenv("AWS_ROLE_ARN")},
+		"RoleSessionName":  {sessionName},
+		"WebIdentityToken": {strings.TrimSpace(string(token))},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return AWSCredentials{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return AWSCredentials{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return AWSCredentials{}, fmt.Errorf("sts returned %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+
+	var body struct {
+		Credentials struct {
+			AccessKeyID     string    `xml:"AccessKeyId"`
+			SecretAccessKey string    `xml:"SecretAccessKey"`
+			SessionToken    string    `xml:"SessionToken"`
+			Expiration      time.Time `xml:"Expiration"`
+		} `xml:"AssumeRoleWithWebIdentityResult>Credentials"`
+	}
+	if err := xml.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return AWSCredentials{}, fmt.Errorf("sts returned an invalid response: %w", err)
+	}
+	c := body.Credentials
+	return AWSCredentials{AccessKeyID: c.AccessKeyID, SecretAccessKey: c.SecretAccessKey, SessionToken: c.SessionToken, Expires: c.Expiration}, nil
+}
+
+// container reads the credentials of the task role from the container endpoint
+func (s *AWSCredentialsSource) container(ctx context.Context) (AWSCredentials, error) {
+	endpoint := os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI")
+	if relative := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); relative != "" {
+		endpoint = awsContainerCredentialsHost + relative
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return AWSCredentials{}, err
+	}
+	authorization := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN")
+	if file := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE"); file != "" {
+		token, err := os.ReadFile(file)
+		if err != nil {
+			return AWSCredentials{}, fmt.Errorf("error reading the container authorization token: %w", err)
+		}
+		authorization = strings.TrimSpace(string(token))
+	}
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	return s.fetchJSON(req, "container endpoint")
+}
+
+// instanceProfile reads the credentials of the instance role from the EC2 metadata service
+func (s *AWSCredentialsSource) instanceProfile(ctx context.Context) (AWSCredentials, error) {
+	endpoint := strings.TrimSuffix(os.Getenv("AWS_EC2_METADATA_SERVICE_ENDPOINT"), "/")
+	if endpoint == "" {
+		endpoint = awsInstanceMetadataURL
+	}
+	// off EC2 the metadata service does not answer, fail fast
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint+"/latest/api/token", nil)
+	if err != nil {
+		return AWSCredentials{}, err
+	}
+	req.Header.Set("X-Aws-Ec2-Metadata-Token-Ttl-Seconds", "21600")
+	token, err := s.fetchText(req)
+	if err != nil {
+		return AWSCredentials{}, fmt.Errorf("the instance metadata service is unreachable: %w", err)
+	}
+
+	credentialsURL := endpoint + "/latest/meta-data/iam/security-credentials/"
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, credentialsURL, nil)
+	if err != nil {
+		return AWSCredentials{}, err
+	}
+	req.Header.Set("X-Aws-Ec2-Metadata-Token", token)
+	roles, err := s.fetchText(req)
+	if err != nil {
+		return AWSCredentials{}, fmt.Errorf("error listing the instance roles: %w", err)
+	}
+	role, _, _ := strings.Cut(strings.TrimSpace(roles), "\n")
+	if role == "" {
+		return AWSCredentials{}, errors.New("the instance has no role")
+	}
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, credentialsURL+url.PathEscape(role), nil)
+	if err != nil {
+		return AWSCredentials{}, err
+	}
+	req.Header.Set("X-Aws-Ec2-Metadata-Token", token)
+	return s.fetchJSON(req, "instance metadata service")
+}
+
+// fetchJSON reads the {AccessKeyId, SecretAccessKey, Token, Expiration} credentials of the
+// container endpoint and the instance metadata service
+func (s *AWSCredentialsSource) fetchJSON(req *http.Request, service string) (AWSCredentials, error) {
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return AWSCredentials{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return AWSCredentials{}, fmt.Errorf("%s returned %d", service, resp.StatusCode)
+	}
+
+	var body struct {
+		AccessKeyID     string    `json:"AccessKeyId"`
+		SecretAccessKey string    `json:"SecretAccessKey"`
+		Token           string    `json:"Token"`
+		Expiration      time.Time `json:"Expiration"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return AWSCredentials{}, fmt.Errorf("%s returned invalid credentials: %w", service, err)
+	}
+	if body.AccessKeyID == "" || body.SecretAccessKey == "" {
+		return AWSCredentials{}, fmt.Errorf("%s returned no credentials", service)
+	}
+	return AWSCredentials{AccessKeyID: body.AccessKeyID, SecretAccessKey: body.SecretAccessKey, SessionToken: body.Token, Expires: body.Expiration}, nil
+}
+
+func (s *AWSCredentialsSource) fetchText(req *http.Request) (string, error) {
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("status %d", resp.StatusCode)
+	}
+	text, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	return string(text), err
+}
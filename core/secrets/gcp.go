@@ -0,0 +1,157 @@
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const gcpMetadataTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+
+// GCPConfig configures the gcpsm: scheme. Without a token, one is taken from GCP_ACCESS_TOKEN
+// or from the metadata server of the instance.
+type GCPConfig struct {
+	Project string `mapstructure:"project"`
+	// Endpoint overrides https://secretmanager.googleapis.com
+	Endpoint string `mapstructure:"endpoint"`
+	Token    string `mapstructure:"token" secret:"true"`
+}
+
+// GCPProvider reads Secret Manager secrets: gcpsm:name reads the latest version in the
+// configured project, gcpsm:projects/p/secrets/name/versions/3 a given one
+type GCPProvider struct {
+	config GCPConfig
+	client *http.Client
+	tokens *GCPTokenSource
+}
+
+// NewGCPProvider returns a GCPProvider, filling the project and token from the environment
+func NewGCPProvider(cfg GCPConfig, client *http.Client) *GCPProvider {
+	if cfg.Project == "" {
+		cfg.Project = os.Getenv("GOOGLE_CLOUD_PROJECT")
+	}
+	if cfg.Token == "" {
+		cfg.Token = os.Getenv("GCP_ACCESS_TOKEN")
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://secretmanager.googleapis.com"
+	}
+	return &GCPProvider{config: cfg, client: client, tokens: NewGCPTokenSource(cfg.Token, client)}
+}
+
+func (p *GCPProvider) Scheme() string { return SchemeGCP }
+
+// Fetch accesses the secret version; JSON object secrets expose their fields as keys
+func (p *GCPProvider) Fetch(ctx context.Context, path string) (Secret, error) {
+	name := path
+	if !strings.HasPrefix(name, "projects/") {
+		if p.config.Project == "" {
+			return Secret{}, fmt.Errorf("gcp project is not configured")
+		}
+		name = "projects/" + p.config.Project + "/secrets/" + name
+	}
+	if !strings.Contains(name, "/versions/") {
+		name += "/versions/latest"
+	}
+
+	token, err := p.tokens.Token(ctx)
+	if err != nil {
+		return Secret{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(p.config.Endpoint, "/")+"/v1/"+name+":access", nil)
+	if err != nil {
+		return Secret{}, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return Secret{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return Secret{}, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return Secret{}, fmt.Errorf("gcp returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var body struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return Secret{}, fmt.Errorf("gcp returned an invalid secret: %w", err)
+	}
+	raw, err := base64.StdEncoding.DecodeString(body.Payload.Data)
+	if err != nil {
+		return Secret{}, fmt.Errorf("gcp returned an invalid secret: %w", err)
+	}
+	return parseSecretString(string(raw)), nil
+}
+
+// GCPTokenSource returns the access token of GCP requests: a fixed token, or one from the
+// metadata server of the instance cached until it expires
+type GCPTokenSource struct {
+	token    string
+	client   *http.Client
+	tokenURL string
+
+	mu          sync.Mutex
+	cached      string
+	cacheExpiry time.Time
+}
+
+// NewGCPTokenSource returns a source of token, or of metadata server tokens when empty
+func NewGCPTokenSource(token string, client *http.Client) *GCPTokenSource {
+	return &GCPTokenSource{token: token, client: client, tokenURL: gcpMetadataTokenURL}
+}
+
+// Token returns the configured token, or one from the metadata server cached until it expires
+func (s *GCPTokenSource) Token(ctx context.Context) (string, error) {
+	if s.token != "" {
+		return s.token, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cached != "" && time.Now().Before(s.cacheExpiry) {
+		return s.cached, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.tokenURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("gcp token is not configured and the metadata server is unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("gcp metadata server returned %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", err
+	}
+	s.cached = body.AccessToken
+	// renew a minute ahead of the expiry
+	s.cacheExpiry = time.Now().Add(time.Duration(body.ExpiresIn)*time.Second - time.Minute)
+	return s.cached, nil
+}
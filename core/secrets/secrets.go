@@ -0,0 +1,289 @@
+// Package secrets resolves config values referencing a secret store, e.g.
+//
+//	jwtSecretKey: "vault:secret/data/app#jwt"
+//	password: "awssm:prod/postgres#password"
+//	apiKey: "gcpsm:openai-key"
+//	token: "enc:YWdlLWVuY3J5cHRpb24ub3JnL3Yx..."
+//
+// A reference is scheme:path, optionally followed by #key selecting a field of a JSON or
+// key/value secret. Fetched secrets are cached for the config TTL. enc: values are age
+// encrypted in place, and whole config files may be encrypted with sops, see DecryptSOPS.
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Reference schemes
+const (
+	SchemeVault = "vault"
+	SchemeAWS   = "awssm"
+	SchemeGCP   = "gcpsm"
+	// SchemeEncrypted holds an age encrypted value rather than a path
+	SchemeEncrypted = "enc"
+)
+
+// DefaultCacheTTL is how long a fetched secret is reused unless configured otherwise
+const DefaultCacheTTL = 5 * time.Minute
+
+// ErrNotFound is returned for a missing secret or key
+var ErrNotFound = errors.New("secret not found")
+
+// Config configures the providers; a provider is only used when a reference needs it
+type Config struct {
+	// CacheTTL is how long a fetched secret is reused, default DefaultCacheTTL; a Vault lease shorter than that wins
+	CacheTTL time.Duration `mapstructure:"cacheTTL"`
+	// RenewInterval re-fetches the secrets in use and reloads the config when one changed; 0 disables it
+	RenewInterval time.Duration `mapstructure:"renewInterval"`
+	Vault         VaultConfig   `mapstructure:"vault"`
+	AWS           AWSConfig     `mapstructure:"aws"`
+	GCP           GCPConfig     `mapstructure:"gcp"`
+}
+
+// Secret is a fetched secret: its raw value and, for key/value or JSON object secrets, its fields
+type Secret struct {
+	Value string
+	Data  map[string]string
+	// TTL overrides Config.CacheTTL when positive, e.g. a Vault lease
+	TTL time.Duration
+}
+
+// Provider fetches secrets of one scheme
+type Provider interface {
+	Scheme() string
+	Fetch(ctx context.Context, path string) (Secret, error)
+}
+
+// Reference is a parsed scheme:path#key value
+type Reference struct {
+	Scheme string
+	Path   string
+	Key    string
+}
+
+func (r Reference) String() string {
+	if r.Key == "" {
+		return r.Scheme + ":" + r.Path
+	}
+	return r.Scheme + ":" + r.Path + "#" + r.Key
+}
+
+// ParseReference parses value when it starts with a known scheme
+func ParseReference(value string) (Reference, bool) {
+	scheme, rest, ok := strings.Cut(value, ":")
+	if !ok || (scheme != SchemeVault && scheme != SchemeAWS && scheme != SchemeGCP && scheme != SchemeEncrypted) || rest == "" {
+		return Reference{}, false
+	}
+	path, key, _ := strings.Cut(rest, "#")
+	return Reference{Scheme: scheme, Path: path, Key: key}, path != ""
+}
+
+type cacheEntry struct {
+	secret    Secret
+	expiresAt time.Time
+}
+
+// Resolver resolves references with the configured providers and caches the fetched secrets
+type Resolver struct {
+	providers map[string]Provider
+	ttl       time.Duration
+	now       func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]cacheEntry
+}
+
+// NewResolver returns a Resolver with the Vault, AWS and GCP providers of cfg and the age
+// provider, plus the extra providers
+func NewResolver(cfg Config, client *http.Client, extra ...Provider) *Resolver {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	if cfg.CacheTTL <= 0 {
+		cfg.CacheTTL = DefaultCacheTTL
+	}
+
+	r := &Resolver{
+		providers: map[string]Provider{},
+		ttl:       cfg.CacheTTL,
+		now:       time.Now,
+		cache:     map[string]cacheEntry{},
+	}
+	for _, p := range append([]Provider{
+		NewVaultProvider(cfg.Vault, client),
+		NewAWSProvider(cfg.AWS, client),
+		NewGCPProvider(cfg.GCP, client),
+		NewAgeProvider(),
+	}, extra...) {
+		r.providers[p.Scheme()] = p
+	}
+	return r
+}
+
+// Resolve returns the value of a reference, from the cache while it is fresh
+func (r *Resolver) Resolve(ctx context.Context, ref Reference) (string, error) {
+	secret, err := r.secret(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+	return secretValue(ref, secret)
+}
+
+func (r *Resolver) secret(ctx context.Context, ref Reference) (Secret, error) {
+	id := ref.Scheme + ":" + ref.Path
+	r.mu.Lock()
+	entry, ok := r.cache[id]
+	r.mu.Unlock()
+	if ok && r.now().Before(entry.expiresAt) {
+		return entry.secret, nil
+	}
+	return r.fetch(ctx, ref.Scheme, ref.Path)
+}
+
+func (r *Resolver) fetch(ctx context.Context, scheme, path string) (Secret, error) {
+	provider, ok := r.providers[scheme]
+	if !ok {
+		return Secret{}, fmt.Errorf("secrets: no provider for %s", scheme)
+	}
+	secret, err := provider.Fetch(ctx, path)
+	if err != nil {
+		return Secret{}, fmt.Errorf("secrets: %s:%s: %w", scheme, path, err)
+	}
+
+	ttl := r.ttl
+	if secret.TTL > 0 && secret.TTL < ttl {
+		ttl = secret.TTL
+	}
+	r.mu.Lock()
+	r.cache[scheme+":"+path] = cacheEntry{secret: secret, expiresAt: r.now().Add(ttl)}
+	r.mu.Unlock()
+	return secret, nil
+}
+
+// Renew fetches every cached secret again, reporting whether one of them changed.
+// Secrets failing to renew keep their cached value.
+func (r *Resolver) Renew(ctx context.Context) (bool, error) {
+	r.mu.Lock()
+	cached := make(map[string]Secret, len(r.cache))
+	for id, entry := range r.cache {
+		cached[id] = entry.secret
+	}
+	r.mu.Unlock()
+
+	changed := false
+	var errs []error
+	for id, previous := range cached {
+		scheme, path, _ := strings.Cut(id, ":")
+		secret, err := r.fetch(ctx, scheme, path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if secret.Value != previous.Value || !reflect.DeepEqual(secret.Data, previous.Data) {
+			changed = true
+		}
+	}
+	return changed, errors.Join(errs...)
+}
+
+// ResolveStruct replaces the references found in the string fields, string slices and string
+// maps of the struct v points to. Every failing reference is reported, without secret values.
+func (r *Resolver) ResolveStruct(ctx context.Context, v any) error {
+	val := reflect.ValueOf(v)
+	if val.Kind() != reflect.Ptr {
+		return errors.New("secrets: ResolveStruct expects a pointer")
+	}
+	var errs []error
+	r.resolveValue(ctx, val.Elem(), &errs)
+	return errors.Join(errs...)
+}
+
+func (r *Resolver) resolveValue(ctx context.Context, val reflect.Value, errs *[]error) {
+	switch val.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !val.IsNil() {
+			r.resolveValue(ctx, val.Elem(), errs)
+		}
+	case reflect.Struct:
+		for i := 0; i < val.NumField(); i++ {
+			if val.Type().Field(i).IsExported() {
+				r.resolveValue(ctx, val.Field(i), errs)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < val.Len(); i++ {
+			r.resolveValue(ctx, val.Index(i), errs)
+		}
+	case reflect.Map:
+		if val.Type().Elem().Kind() != reflect.String {
+			return
+		}
+		for _, key := range val.MapKeys() {
+			if resolved, ok := r.resolveString(ctx, val.MapIndex(key).String(), errs); ok {
+				val.SetMapIndex(key, reflect.ValueOf(resolved).Convert(val.Type().Elem()))
+			}
+		}
+	case reflect.String:
+		if resolved, ok := r.resolveString(ctx, val.String(), errs); ok && val.CanSet() {
+			val.SetString(resolved)
+		}
+	}
+}
+
+func (r *Resolver) resolveString(ctx context.Context, value string, errs *[]error) (string, bool) {
+	ref, ok := ParseReference(value)
+	if !ok {
+		return "", false
+	}
+	resolved, err := r.Resolve(ctx, ref)
+	if err != nil {
+		*errs = append(*errs, err)
+		return "", false
+	}
+	return resolved, true
+}
+
+// secretValue selects the key of ref, or the raw value when ref has no key
+func secretValue(ref Reference, secret Secret) (string, error) {
+	if ref.Key == "" {
+		if secret.Value == "" && len(secret.Data) > 0 {
+			return "", fmt.Errorf("secrets: %s holds several keys, select one with #key", ref)
+		}
+		return secret.Value, nil
+	}
+	value, ok := secret.Data[ref.Key]
+	if !ok {
+		return "", fmt.Errorf("secrets: %s: %w", ref, ErrNotFound)
+	}
+	return value, nil
+}
+
+// parseSecretString returns a secret whose fields are those of value when it is a JSON object
+func parseSecretString(value string) Secret {
+	secret := Secret{Value: value}
+	var fields map[string]any
+	if strings.HasPrefix(strings.TrimSpace(value), "{") && json.Unmarshal([]byte(value), &fields) == nil {
+		secret.Data = stringFields(fields)
+	}
+	return secret
+}
+
+func stringFields(fields map[string]any) map[string]string {
+	data := make(map[string]string, len(fields))
+	for k, v := range fields {
+		if s, ok := v.(string); ok {
+			data[k] = s
+		} else if raw, err := json.Marshal(v); err == nil {
+			data[k] = string(raw)
+		}
+	}
+	return data
+}
@@ -0,0 +1,318 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// sopsValue matches a value encrypted by sops
+var sopsValue = regexp.MustCompile(`^ENC\[AES256_GCM,data:([^,]*),iv:([^,]*),tag:([^,]*),type:([a-z]+)\]$`)
+
+// sopsMetadata is the part of the sops section needed to unwrap the data key
+type sopsMetadata struct {
+	Age []struct {
+		Recipient string `yaml:"recipient"`
+		Enc       string `yaml:"enc"`
+	} `yaml:"age"`
+	KMS []struct {
+		ARN     string            `yaml:"arn"`
+		Enc     string            `yaml:"enc"`
+		Context map[string]string `yaml:"context"`
+	} `yaml:"kms"`
+	// MAC is the SHA-512 of the values, encrypted with the data key and LastModified
+	MAC              string `yaml:"mac"`
+	LastModified     string `yaml:"lastmodified"`
+	MACOnlyEncrypted bool   `yaml:"mac_only_encrypted"`
+}
+
+// IsSOPS reports whether data is a YAML document encrypted with sops
+func IsSOPS(data []byte) bool {
+	var doc struct {
+		SOPS map[string]any `yaml:"sops"`
+	}
+	return yaml.Unmarshal(data, &doc) == nil && doc.SOPS != nil
+}
+
+// DecryptSOPS returns the sops encrypted YAML document data with its values decrypted and its
+// sops section removed. The data key is unwrapped by an age identity of LoadAgeIdentities or by
+// AWS KMS with the credentials of AWSCredentialsSource; a nil client uses a default one.
+//
+// Each value is authenticated together with its key path, and the document with the sops MAC:
+// a value added, removed or reordered fails the decryption.
+func DecryptSOPS(ctx context.Context, data []byte, client *http.Client) ([]byte, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	if root.Kind != yaml.DocumentNode || len(root.Content) == 0 || root.Content[0].Kind != yaml.MappingNode {
+		return nil, errors.New("sops: not a YAML mapping")
+	}
+	doc := root.Content[0]
+
+	var meta sopsMetadata
+	found := false
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		if doc.Content[i].Value != "sops" {
+			continue
+		}
+		if err := doc.Content[i+1].Decode(&meta); err != nil {
+			return nil, fmt.Errorf("sops: invalid metadata: %w", err)
+		}
+		doc.Content = append(doc.Content[:i], doc.Content[i+2:]...)
+		found = true
+		break
+	}
+	if !found {
+		return nil, errors.New("sops: no sops metadata")
+	}
+
+	key, err := sopsDataKey(ctx, meta, client)
+	if err != nil {
+		return nil, err
+	}
+	mac := sha512.New()
+	d := sopsDecrypter{key: key, mac: mac, macOnlyEncrypted: meta.MACOnlyEncrypted}
+	if err := d.decrypt(doc, nil); err != nil {
+		return nil, err
+	}
+	if err := verifySOPSMAC(meta, key, mac.Sum(nil)); err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), enc.Close()
+}
+
+// sopsDataKey unwraps the data key with the first master key available
+func sopsDataKey(ctx context.Context, meta sopsMetadata, client *http.Client) ([]byte, error) {
+	var errs []error
+	if len(meta.Age) > 0 {
+		identities, err := LoadAgeIdentities()
+		switch {
+		case err != nil:
+			errs = append(errs, err)
+		case len(identities) == 0:
+			errs = append(errs, fmt.Errorf("no age identity, set %s or %s", AgeKeyEnv, AgeKeyFileEnv))
+		default:
+			for _, entry := range meta.Age {
+				key, err := DecryptAge([]byte(entry.Enc), identities)
+				if err == nil {
+					return key, nil
+				}
+				errs = append(errs, fmt.Errorf("age %s: %w", entry.Recipient, err))
+			}
+		}
+	}
+
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	for _, entry := range meta.KMS {
+		key, err := kmsDecrypt(ctx, client, entry.ARN, entry.Enc, entry.Context)
+		if err == nil {
+			return key, nil
+		}
+		errs = append(errs, fmt.Errorf("kms %s: %w", entry.ARN, err))
+	}
+
+	if len(errs) == 0 {
+		return nil, errors.New("sops: no age or kms master key")
+	}
+	return nil, fmt.Errorf("sops: cannot decrypt the data key: %w", errors.Join(errs...))
+}
+
+// sopsDecrypter decrypts the values of a document, hashing them in order for the MAC
+type sopsDecrypter struct {
+	key              []byte
+	mac              hash.Hash
+	macOnlyEncrypted bool
+}
+
+// decrypt decrypts the values under node in place. sops authenticates a value with the keys
+// leading to it, list indexes left out, each followed by a colon.
+func (d sopsDecrypter) decrypt(node *yaml.Node, path []string) error {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if err := d.decrypt(node.Content[i+1], append(path, node.Content[i].Value)); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			if err := d.decrypt(item, path); err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		match := sopsValue.FindStringSubmatch(node.Value)
+		if match == nil {
+			if !d.macOnlyEncrypted && node.Tag != "!!null" {
+				d.mac.Write(sopsMACBytes(node.Value, strings.TrimPrefix(node.Tag, "!!")))
+			}
+			return nil
+		}
+		value, err := decryptSOPSValue(d.key, match, strings.Join(path, ":")+":")
+		if err != nil {
+			return fmt.Errorf("sops: %s: %w", strings.Join(path, "."), err)
+		}
+		d.mac.Write(sopsMACBytes(value, match[4]))
+
+		node.Style = 0
+		node.Value = value
+		switch match[4] {
+		case "int":
+			node.Tag = "!!int"
+		case "float":
+			node.Tag = "!!float"
+		case "bool":
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("sops: %s: invalid bool", strings.Join(path, "."))
+			}
+			node.Tag, node.Value = "!!bool", strconv.FormatBool(parsed)
+		default:
+			node.Tag = "!!str"
+		}
+	}
+	return nil
+}
+
+// sopsMACBytes returns the bytes sops hashes for a value of valueType, the type of an encrypted
+// value or the YAML tag of a plain one
+func sopsMACBytes(value, valueType string) []byte {
+	switch valueType {
+	case "int":
+		if n, err := strconv.Atoi(value); err == nil {
+			return []byte(strconv.Itoa(n))
+		}
+	case "float":
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return []byte(strconv.FormatFloat(f, 'f', -1, 64))
+		}
+	case "bool":
+		if b, err := strconv.ParseBool(value); err == nil {
+			if b {
+				return []byte("True")
+			}
+			return []byte("False")
+		}
+	}
+	return []byte(value)
+}
+
+// verifySOPSMAC checks the MAC of meta against sum, the hash of the values; the MAC is encrypted
+// with the last modification time as additional data
+func verifySOPSMAC(meta sopsMetadata, key []byte, sum []byte) error {
+	match := sopsValue.FindStringSubmatch(meta.MAC)
+	if match == nil {
+		return errors.New("sops: the document has no MAC")
+	}
+	lastModified, err := time.Parse(time.RFC3339, meta.LastModified)
+	if err != nil {
+		return fmt.Errorf("sops: invalid lastmodified: %w", err)
+	}
+	mac, err := decryptSOPSValue(key, match, lastModified.Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("sops: mac: %w", err)
+	}
+	if !hmac.Equal([]byte(mac), []byte(strings.ToUpper(hex.EncodeToString(sum)))) {
+		return errors.New("sops: the MAC does not match, the document was modified")
+	}
+	return nil
+}
+
+func decryptSOPSValue(key []byte, match []string, additionalData string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(match[1])
+	if err != nil {
+		return "", err
+	}
+	iv, err := base64.StdEncoding.DecodeString(match[2])
+	if err != nil {
+		return "", err
+	}
+	tag, err := base64.StdEncoding.DecodeString(match[3])
+	if err != nil {
+		return "", err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(iv))
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := gcm.Open(nil, iv, append(data, tag...), []byte(additionalData))
+	if err != nil {
+		return "", errors.New("value does not decrypt with the data key")
+	}
+	return string(plaintext), nil
+}
+
+// kmsDecrypt calls the KMS Decrypt API of the region of arn
+func kmsDecrypt(ctx context.Context, client *http.Client, arn, ciphertext string, encryptionContext map[string]string) ([]byte, error) {
+	parts := strings.Split(arn, ":")
+	if len(parts) < 6 || parts[2] != "kms" {
+		return nil, fmt.Errorf("invalid kms key arn")
+	}
+	region := parts[3]
+
+	input := map[string]any{"CiphertextBlob": ciphertext, "KeyId": arn}
+	if len(encryptionContext) > 0 {
+		input["EncryptionContext"] = encryptionContext
+	}
+	payload, _ := json.Marshal(input)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://kms."+region+".amazonaws.com", bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "TrentService.Decrypt")
+	if err := signWithCredentials(ctx, req, payload, NewAWSCredentialsSource("", "", "", client), region, "kms", time.Now()); err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Plaintext string `json:"Plaintext"`
+		Type      string `json:"__type"`
+		Message   string `json:"message"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return nil, fmt.Errorf("kms returned %d: %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("kms returned %d: %s %s", resp.StatusCode, body.Type, body.Message)
+	}
+	return base64.StdEncoding.DecodeString(body.Plaintext)
+}
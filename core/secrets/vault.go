@@ -0,0 +1,100 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// VaultConfig configures the vault: scheme; address and token default to VAULT_ADDR and VAULT_TOKEN
+type VaultConfig struct {
+	Address   string `mapstructure:"address"`
+	Token     string `mapstructure:"token" secret:"true"`
+	Namespace string `mapstructure:"namespace"`
+}
+
+// VaultProvider reads KV v1 and v2 secrets, e.g. vault:secret/data/app#jwt for KV v2
+type VaultProvider struct {
+	config VaultConfig
+	client *http.Client
+}
+
+// NewVaultProvider returns a VaultProvider, filling the address and token from the environment
+func NewVaultProvider(cfg VaultConfig, client *http.Client) *VaultProvider {
+	if cfg.Address == "" {
+		cfg.Address = os.Getenv("VAULT_ADDR")
+	}
+	if cfg.Token == "" {
+		cfg.Token = os.Getenv("VAULT_TOKEN")
+	}
+	if cfg.Namespace == "" {
+		cfg.Namespace = os.Getenv("VAULT_NAMESPACE")
+	}
+	return &VaultProvider{config: cfg, client: client}
+}
+
+func (p *VaultProvider) Scheme() string { return SchemeVault }
+
+type vaultResponse struct {
+	LeaseDuration int             `json:"lease_duration"`
+	Data          json.RawMessage `json:"data"`
+	Errors        []string        `json:"errors"`
+}
+
+// Fetch reads path; KV v2 responses nest the secret under data.data
+func (p *VaultProvider) Fetch(ctx context.Context, path string) (Secret, error) {
+	if p.config.Address == "" {
+		return Secret{}, fmt.Errorf("vault address is not configured")
+	}
+
+	url := strings.TrimRight(p.config.Address, "/") + "/v1/" + strings.TrimLeft(path, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return Secret{}, err
+	}
+	req.Header.Set("X-Vault-Token", p.config.Token)
+	if p.config.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.config.Namespace)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return Secret{}, err
+	}
+	defer resp.Body.Close()
+
+	var body vaultResponse
+	if resp.StatusCode == http.StatusNotFound {
+		return Secret{}, ErrNotFound
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return Secret{}, fmt.Errorf("vault returned %d: %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Secret{}, fmt.Errorf("vault returned %d: %s", resp.StatusCode, strings.Join(body.Errors, "; "))
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(body.Data, &fields); err != nil {
+		return Secret{}, fmt.Errorf("vault returned an invalid secret: %w", err)
+	}
+	if nested, ok := fields["data"].(map[string]any); ok {
+		if _, v2 := fields["metadata"]; v2 {
+			fields = nested
+		}
+	}
+
+	secret := Secret{Data: stringFields(fields)}
+	if value, ok := secret.Data["value"]; ok && len(secret.Data) == 1 {
+		secret.Value = value
+	}
+	if body.LeaseDuration > 0 {
+		secret.TTL = time.Duration(body.LeaseDuration) * time.Second
+	}
+	return secret, nil
+}
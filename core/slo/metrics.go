@@ -0,0 +1,60 @@
+package slo
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const meterName = "github.com/yourorg/go-api-template/core/slo"
+
+var (
+	exported     atomic.Pointer[Tracker]
+	exportedOnce sync.Once
+)
+
+// ExportMetrics reports the burn rate of every objective, indicator and window of t as the
+// slo.burn_rate gauge at each metric collection. The last tracker exported is reported.
+func (t *Tracker) ExportMetrics() {
+	exported.Store(t)
+	exportedOnce.Do(func() {
+		// instruments from the global meter follow a provider installed later
+		meter := otel.Meter(meterName)
+		burnRate, err := meter.Float64ObservableGauge(
+			"slo.burn_rate",
+			metric.WithDescription("Rate the error budget of an objective is spent at over a window, 1 spending it exactly over the SLO period"),
+			metric.WithUnit("1"),
+		)
+		if err != nil {
+			return
+		}
+		meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+			if t := exported.Load(); t != nil {
+				t.observe(o, burnRate)
+			}
+			return nil
+		}, burnRate)
+	})
+}
+
+func (t *Tracker) observe(o metric.Observer, burnRate metric.Float64ObservableGauge) {
+	for _, objective := range t.objectives {
+		for _, window := range t.windows {
+			counts := t.counts(objective, window)
+			for _, sli := range []string{SLIAvailability, SLILatency} {
+				if objective.target(sli) == 0 {
+					continue
+				}
+				o.ObserveFloat64(burnRate, counts.BurnRate(objective.Objective, sli), metric.WithAttributes(
+					attribute.String("slo.name", objective.Name),
+					attribute.String("slo.sli", sli),
+					attribute.String("slo.window", window.String()),
+				))
+			}
+		}
+	}
+}
@@ -0,0 +1,265 @@
+// Package slo tracks service level objectives of route groups from their requests, rate,
+// errors and duration, and exports how fast each burns its error budget as gauges, the
+// basis of multiwindow burn rate alerts: with a 99.9% objective over 30 days, a burn rate
+// of 14.4 over both 5m and 1h spends 2% of the monthly budget within the hour.
+package slo
+
+import (
+	"bufio"
+	"math"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Service level indicators of an objective
+const (
+	// SLIAvailability counts the requests answered without a 5xx
+	SLIAvailability = "availability"
+	// SLILatency counts the requests answered within the latency threshold
+	SLILatency = "latency"
+)
+
+// Config defines the objectives and the windows their burn rates are computed over
+type Config struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Windows the burn rates are computed over, e.g. 5m and 1h for a fast burn alert
+	Windows    []time.Duration `mapstructure:"windows"`
+	Objectives []Objective     `mapstructure:"objectives"`
+}
+
+// Objective is the target of a route group, the requests under PathPrefix
+type Objective struct {
+	Name string `mapstructure:"name"`
+	// PathPrefix selects the requests, the longest prefix of the objectives matching wins
+	PathPrefix string `mapstructure:"pathPrefix"`
+	// Availability is the target share of requests answered without a 5xx, e.g. 0.999;
+	// 0 leaves availability untracked
+	Availability float64 `mapstructure:"availability"`
+	// Latency is the target share of requests answered within LatencyThreshold, e.g. 0.99;
+	// 0 leaves latency untracked
+	Latency          float64       `mapstructure:"latency"`
+	LatencyThreshold time.Duration `mapstructure:"latencyThreshold"`
+}
+
+// DefaultConfig returns default SLO configuration, disabled and without objectives
+func DefaultConfig() Config {
+	return Config{
+		Windows: []time.Duration{5 * time.Minute, time.Hour},
+	}
+}
+
+// BurnRate returns how fast bad of total requests spend the error budget of target: 1 spends
+// it exactly over the SLO period, 0 when nothing was spent. Without a budget, a target of 1,
+// any bad request burns at +Inf.
+func BurnRate(bad int64, total int64, target float64) float64 {
+	if total == 0 || bad == 0 {
+		return 0
+	}
+	if target >= 1 {
+		return math.Inf(1)
+	}
+	return float64(bad) / float64(total) / (1 - target)
+}
+
+// Counts are the requests of an objective within a window
+type Counts struct {
+	Total int64
+	// Errors were answered with a 5xx
+	Errors int64
+	// Slow took longer than the latency threshold
+	Slow int64
+}
+
+// BurnRate returns the burn rate of the objective for sli over c
+func (c Counts) BurnRate(objective Objective, sli string) float64 {
+	if sli == SLILatency {
+		return BurnRate(c.Slow, c.Total, objective.Latency)
+	}
+	return BurnRate(c.Errors, c.Total, objective.Availability)
+}
+
+// target returns the objective of sli, 0 when untracked
+func (o Objective) target(sli string) float64 {
+	if sli == SLILatency {
+		return o.Latency
+	}
+	return o.Availability
+}
+
+// Tracker counts the requests of each objective in buckets covering the longest window
+type Tracker struct {
+	windows    []time.Duration
+	objectives []*tracked
+	width      time.Duration
+	now        func() time.Time
+}
+
+type tracked struct {
+	Objective
+	mu      sync.Mutex
+	buckets []bucket
+}
+
+type bucket struct {
+	start int64 // unix nanoseconds of the bucket, 0 when unused
+	Counts
+}
+
+// TrackerOption customizes a tracker
+type TrackerOption func(*Tracker)
+
+// WithClock sets the clock requests are bucketed by
+func WithClock(now func() time.Time) TrackerOption {
+	return func(t *Tracker) {
+		t.now = now
+	}
+}
+
+// NewTracker returns a tracker of the objectives of cfg
+func NewTracker(cfg Config, opts ...TrackerOption) *Tracker {
+	windows := make([]time.Duration, 0, len(cfg.Windows))
+	for _, window := range cfg.Windows {
+		if window > 0 {
+			windows = append(windows, window)
+		}
+	}
+	if len(windows) == 0 {
+		windows = DefaultConfig().Windows
+	}
+	sort.Slice(windows, func(i, j int) bool { return windows[i] < windows[j] })
+
+	// a tenth of the shortest window, the windows ending up to a bucket early
+	width := windows[0] / 10
+	if width < time.Second {
+		width = time.Second
+	}
+	size := int(windows[len(windows)-1]/width) + 1
+
+	t := &Tracker{windows: windows, width: width, now: time.Now}
+	for _, opt := range opts {
+		opt(t)
+	}
+	for _, objective := range cfg.Objectives {
+		t.objectives = append(t.objectives, &tracked{Objective: objective, buckets: make([]bucket, size)})
+	}
+	// longest first, so the most specific prefix matches
+	sort.SliceStable(t.objectives, func(i, j int) bool {
+		return len(t.objectives[i].PathPrefix) > len(t.objectives[j].PathPrefix)
+	})
+	return t
+}
+
+// Windows returns the windows burn rates are computed over, shortest first
+func (t *Tracker) Windows() []time.Duration {
+	return t.windows
+}
+
+// Record counts a request to path answered with status after duration
+func (t *Tracker) Record(path string, status int, duration time.Duration) {
+	objective := t.match(path)
+	if objective == nil {
+		return
+	}
+
+	start := t.now().Truncate(t.width).UnixNano()
+	objective.mu.Lock()
+	defer objective.mu.Unlock()
+	b := &objective.buckets[int(start/int64(t.width))%len(objective.buckets)]
+	if b.start != start {
+		*b = bucket{start: start}
+	}
+	b.Total++
+	if status >= http.StatusInternalServerError {
+		b.Errors++
+	}
+	if objective.LatencyThreshold > 0 && duration > objective.LatencyThreshold {
+		b.Slow++
+	}
+}
+
+// Counts returns the requests of the named objective within window
+func (t *Tracker) Counts(name string, window time.Duration) Counts {
+	for _, objective := range t.objectives {
+		if objective.Name == name {
+			return t.counts(objective, window)
+		}
+	}
+	return Counts{}
+}
+
+func (t *Tracker) counts(objective *tracked, window time.Duration) Counts {
+	since := t.now().Add(-window).Truncate(t.width).UnixNano()
+	objective.mu.Lock()
+	defer objective.mu.Unlock()
+	var counts Counts
+	for _, b := range objective.buckets {
+		if b.start != 0 && b.start > since {
+			counts.Total += b.Total
+			counts.Errors += b.Errors
+			counts.Slow += b.Slow
+		}
+	}
+	return counts
+}
+
+func (t *Tracker) match(path string) *tracked {
+	for _, objective := range t.objectives {
+		if strings.HasPrefix(path, objective.PathPrefix) {
+			return objective
+		}
+	}
+	return nil
+}
+
+// Middleware records every request with the status it was answered with. It goes before
+// the recovery and timeout middlewares, so panics and expired budgets count as errors.
+func (t *Tracker) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if t.match(r.URL.Path) == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			start := time.Now()
+			sw := &statusResponseWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(sw, r)
+			t.Record(r.URL.Path, sw.status, time.Since(start))
+		})
+	}
+}
+
+// statusResponseWriter keeps the status of the response
+type statusResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (sw *statusResponseWriter) WriteHeader(statusCode int) {
+	if !sw.wroteHeader {
+		sw.status, sw.wroteHeader = statusCode, true
+	}
+	sw.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (sw *statusResponseWriter) Write(b []byte) (int, error) {
+	sw.wroteHeader = true
+	return sw.ResponseWriter.Write(b)
+}
+
+// Hijack passes the connection through, e.g. for WebSocket upgrades
+func (sw *statusResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if !sw.wroteHeader {
+		sw.status, sw.wroteHeader = http.StatusSwitchingProtocols, true
+	}
+	return http.NewResponseController(sw.ResponseWriter).Hijack()
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (sw *statusResponseWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
@@ -0,0 +1,304 @@
+package storage
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yourorg/go-api-template/core/exception"
+	"github.com/yourorg/go-api-template/core/secrets"
+)
+
+// GCSConfig configures the gcs driver. Without a token, one is taken from GCP_ACCESS_TOKEN or
+// from the metadata server of the instance.
+type GCSConfig struct {
+	Bucket string `mapstructure:"bucket"`
+	// Endpoint overrides https://storage.googleapis.com, e.g. for an emulator
+	Endpoint string `mapstructure:"endpoint"`
+	Token    string `mapstructure:"token" secret:"true"`
+	// CredentialsFile is the key of the service account signing the URLs, defaulting to
+	// GOOGLE_APPLICATION_CREDENTIALS; SignedURL fails without it
+	CredentialsFile string `mapstructure:"credentialsFile"`
+}
+
+// GCSStorage keeps objects in a Cloud Storage bucket through its JSON API, streaming the
+// uploads as single media requests
+type GCSStorage struct {
+	config   GCSConfig
+	endpoint string
+	client   *http.Client
+	tokens   *secrets.GCPTokenSource
+	now      func() time.Time
+
+	// the service account of the signed URLs, nil without credentials
+	signer      *rsa.PrivateKey
+	clientEmail string
+}
+
+// NewGCSStorage returns a storage on cfg.Bucket, reading the service account key when there is one
+func NewGCSStorage(cfg GCSConfig, client *http.Client) (*GCSStorage, error) {
+	if cfg.Bucket == "" {
+		return nil, errors.New("gcs bucket is empty")
+	}
+	if cfg.Token == "" {
+		cfg.Token = os.Getenv("GCP_ACCESS_TOKEN")
+	}
+	if cfg.CredentialsFile == "" {
+		cfg.CredentialsFile = os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = "https://storage.googleapis.com"
+	}
+
+	s := &GCSStorage{
+		config:   cfg,
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		client:   client,
+		tokens:   secrets.NewGCPTokenSource(cfg.Token, client),
+		now:      time.Now,
+	}
+	if cfg.CredentialsFile != "" {
+		if err := s.loadCredentials(cfg.CredentialsFile); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// loadCredentials reads the client email and private key of a service account key file
+func (s *GCSStorage) loadCredentials(file string) error {
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("error reading gcs credentials: %w", err)
+	}
+	var credentials struct {
+		ClientEmail string `json:"client_email"`
+		PrivateKey  string `json:"private_key"`
+	}
+	if err := json.Unmarshal(raw, &credentials); err != nil {
+		return fmt.Errorf("invalid gcs credentials: %w", err)
+	}
+	block, _ := pem.Decode([]byte(credentials.PrivateKey))
+	if block == nil || credentials.ClientEmail == "" {
+		return errors.New("gcs credentials have no client email or private key")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid gcs private key: %w", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return errors.New("gcs private key is not an RSA key")
+	}
+	s.signer, s.clientEmail = rsaKey, credentials.ClientEmail
+	return nil
+}
+
+// gcsObject is the object resource of the JSON API
+type gcsObject struct {
+	Name        string    `json:"name"`
+	Size        string    `json:"size"`
+	ContentType string    `json:"contentType"`
+	ETag        string    `json:"etag"`
+	Updated     time.Time `json:"updated"`
+}
+
+func (o gcsObject) object() Object {
+	size, _ := strconv.ParseInt(o.Size, 10, 64)
+	return Object{Key: o.Name, Size: size, ContentType: o.ContentType, ETag: o.ETag, UpdatedAt: o.Updated.UTC()}
+}
+
+// do sends an authorized request, turning a 404 into ErrNotFound and the other failures into errors
+func (s *GCSStorage) do(ctx context.Context, method string, rawURL string, body io.Reader, contentType string) (*http.Response, error) {
+	token, err := s.tokens.Token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+	err = fmt.Errorf("gcs returned %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	if exception.RetryClassFromHTTPStatus(resp.StatusCode) == exception.RetryPermanent {
+		return nil, exception.MarkPermanent(err)
+	}
+	return nil, err
+}
+
+func (s *GCSStorage) objectURL(key string) string {
+	return s.endpoint + "/storage/v1/b/" + url.PathEscape(s.config.Bucket) + "/o/" + url.PathEscape(key)
+}
+
+func (s *GCSStorage) Put(ctx context.Context, key string, body io.Reader, opts ...PutOption) (Object, error) {
+	if err := ValidateKey(key); err != nil {
+		return Object{}, err
+	}
+	options := putOptions(opts)
+	contentType, body, err := detectContentType(key, body, options.ContentType)
+	if err != nil {
+		return Object{}, err
+	}
+
+	query := url.Values{"uploadType": {"media"}, "name": {key}}
+	rawURL := s.endpoint + "/upload/storage/v1/b/" + url.PathEscape(s.config.Bucket) + "/o?" + query.Encode()
+	resp, err := s.do(ctx, http.MethodPost, rawURL, body, contentType)
+	if err != nil {
+		return Object{}, fmt.Errorf("error putting object %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+
+	var object gcsObject
+	if err := json.NewDecoder(resp.Body).Decode(&object); err != nil {
+		return Object{}, fmt.Errorf("gcs returned an invalid object: %w", err)
+	}
+	return object.object(), nil
+}
+
+func (s *GCSStorage) Get(ctx context.Context, key string) (io.ReadCloser, Object, error) {
+	if err := ValidateKey(key); err != nil {
+		return nil, Object{}, err
+	}
+	resp, err := s.do(ctx, http.MethodGet, s.objectURL(key)+"?alt=media", nil, "")
+	if err != nil {
+		return nil, Object{}, err
+	}
+	return resp.Body, objectFromHeader(key, resp), nil
+}
+
+func (s *GCSStorage) Stat(ctx context.Context, key string) (Object, error) {
+	if err := ValidateKey(key); err != nil {
+		return Object{}, err
+	}
+	resp, err := s.do(ctx, http.MethodGet, s.objectURL(key), nil, "")
+	if err != nil {
+		return Object{}, err
+	}
+	defer resp.Body.Close()
+
+	var object gcsObject
+	if err := json.NewDecoder(resp.Body).Decode(&object); err != nil {
+		return Object{}, fmt.Errorf("gcs returned an invalid object: %w", err)
+	}
+	return object.object(), nil
+}
+
+func (s *GCSStorage) Delete(ctx context.Context, key string) error {
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+	resp, err := s.do(ctx, http.MethodDelete, s.objectURL(key), nil, "")
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// SignedURL returns a V4 signed URL, signed with the key of the service account and valid up
+// to 7 days
+func (s *GCSStorage) SignedURL(ctx context.Context, key string, method string, expiry time.Duration) (string, error) {
+	if err := ValidateKey(key); err != nil {
+		return "", err
+	}
+	if s.signer == nil {
+		return "", errors.New("gcs credentials file is not configured")
+	}
+
+	base, err := url.Parse(s.endpoint)
+	if err != nil {
+		return "", err
+	}
+	now := s.now().UTC()
+	timestamp := now.Format("20060102T150405Z")
+	scope := timestamp[:8] + "/auto/storage/goog4_request"
+	path := "/" + escapeKey(s.config.Bucket) + "/" + escapeKey(key)
+
+	query := url.Values{
+		"X-Goog-Algorithm":     {"GOOG4-RSA-SHA256"},
+		"X-Goog-Credential":    {s.clientEmail + "/" + scope},
+		"X-Goog-Date":          {timestamp},
+		"X-Goog-Expires":       {strconv.Itoa(int(expiry.Seconds()))},
+		"X-Goog-SignedHeaders": {"host"},
+	}
+	canonicalRequest := strings.Join([]string{
+		method,
+		path,
+		gcsCanonicalQuery(query),
+		"host:" + base.Host + "\n",
+		"host",
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "GOOG4-RSA-SHA256\n" + timestamp + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	digest := sha256.Sum256([]byte(stringToSign))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.signer, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return base.Scheme + "://" + base.Host + path + "?" + gcsCanonicalQuery(query) +
+		"&X-Goog-Signature=" + hex.EncodeToString(signature), nil
+}
+
+// gcsCanonicalQuery sorts and escapes query as the V4 signature expects
+func gcsCanonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		for _, v := range query[k] {
+			parts = append(parts, queryEscape(k)+"="+queryEscape(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func queryEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
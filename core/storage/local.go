@@ -0,0 +1,283 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LocalConfig configures the local driver, which keeps the objects in a directory and serves
+// them through Handler
+type LocalConfig struct {
+	// Root is the directory of the objects, created when missing
+	Root string `mapstructure:"root"`
+	// BaseURL is where Handler is mounted, the signed URLs point there
+	BaseURL string `mapstructure:"baseUrl"`
+	// SigningKey signs the URLs; SignedURL fails without it
+	SigningKey string `mapstructure:"signingKey" secret:"true"`
+}
+
+// LocalStorage keeps objects as files under a root directory. The content type is not
+// stored: it is detected again when an object is read.
+type LocalStorage struct {
+	root       string
+	baseURL    string
+	signingKey []byte
+	now        func() time.Time
+}
+
+// NewLocalStorage returns a storage in cfg.Root, creating the directory
+func NewLocalStorage(cfg LocalConfig) (*LocalStorage, error) {
+	if cfg.Root == "" {
+		return nil, errors.New("local storage root is empty")
+	}
+	if err := os.MkdirAll(cfg.Root, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating local storage root: %w", err)
+	}
+	return &LocalStorage{
+		root:       cfg.Root,
+		baseURL:    strings.TrimSuffix(cfg.BaseURL, "/"),
+		signingKey: []byte(cfg.SigningKey),
+		now:        time.Now,
+	}, nil
+}
+
+func (s *LocalStorage) path(key string) (string, error) {
+	if err := ValidateKey(key); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
+
+// Put writes body to a temporary file renamed over the object, so readers never see a partial one
+func (s *LocalStorage) Put(ctx context.Context, key string, body io.Reader, opts ...PutOption) (Object, error) {
+	name, err := s.path(key)
+	if err != nil {
+		return Object{}, err
+	}
+	options := putOptions(opts)
+	contentType, body, err := detectContentType(key, body, options.ContentType)
+	if err != nil {
+		return Object{}, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return Object{}, err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".upload-*")
+	if err != nil {
+		return Object{}, err
+	}
+	defer os.Remove(tmp.Name())
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), readerWithContext(ctx, body))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return Object{}, fmt.Errorf("error writing object %s: %w", key, err)
+	}
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		return Object{}, err
+	}
+
+	return Object{
+		Key:         key,
+		Size:        size,
+		ContentType: contentType,
+		ETag:        hex.EncodeToString(hash.Sum(nil)),
+		UpdatedAt:   s.now().UTC(),
+	}, nil
+}
+
+func (s *LocalStorage) Get(ctx context.Context, key string) (io.ReadCloser, Object, error) {
+	file, object, err := s.open(key)
+	if err != nil {
+		return nil, Object{}, err
+	}
+	return file, object, nil
+}
+
+func (s *LocalStorage) Stat(ctx context.Context, key string) (Object, error) {
+	file, object, err := s.open(key)
+	if err != nil {
+		return Object{}, err
+	}
+	file.Close()
+	return object, nil
+}
+
+// open opens the file of key, detecting its content type
+func (s *LocalStorage) open(key string) (*os.File, Object, error) {
+	name, err := s.path(key)
+	if err != nil {
+		return nil, Object{}, err
+	}
+	file, err := os.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, Object{}, ErrNotFound
+	}
+	if err != nil {
+		return nil, Object{}, err
+	}
+	info, err := file.Stat()
+	if err == nil && info.IsDir() {
+		err = ErrNotFound
+	}
+	if err != nil {
+		file.Close()
+		return nil, Object{}, err
+	}
+
+	head := make([]byte, SniffLen)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		file.Close()
+		return nil, Object{}, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		file.Close()
+		return nil, Object{}, err
+	}
+
+	return file, Object{
+		Key:         key,
+		Size:        info.Size(),
+		ContentType: SniffContentType(key, head[:n]),
+		// weak, the content is not hashed again on every read
+		ETag:      fmt.Sprintf("%x-%x", info.ModTime().UnixNano(), info.Size()),
+		UpdatedAt: info.ModTime().UTC(),
+	}, nil
+}
+
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	name, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// SignedURL returns a URL of Handler carrying the method, the expiry and their signature
+func (s *LocalStorage) SignedURL(ctx context.Context, key string, method string, expiry time.Duration) (string, error) {
+	if err := ValidateKey(key); err != nil {
+		return "", err
+	}
+	if len(s.signingKey) == 0 {
+		return "", errors.New("local storage signing key is not configured")
+	}
+	if method != http.MethodGet && method != http.MethodPut {
+		return "", fmt.Errorf("signed URLs are for GET or PUT, not %s", method)
+	}
+
+	expires := strconv.FormatInt(s.now().Add(expiry).Unix(), 10)
+	query := url.Values{
+		"method":    {method},
+		"expires":   {expires},
+		"signature": {s.sign(method, key, expires)},
+	}
+	return s.baseURL + "/" + escapeKey(key) + "?" + query.Encode(), nil
+}
+
+func (s *LocalStorage) sign(method, key, expires string) string {
+	mac := hmac.New(sha256.New, s.signingKey)
+	mac.Write([]byte(method + "\n" + key + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Handler serves the signed URLs: GET and HEAD read the object, with range requests, and PUT
+// replaces it. Mount it without the prefix of BaseURL, e.g. with http.StripPrefix.
+func (s *LocalStorage) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.URL.Path, "/")
+		method := r.Method
+		if method == http.MethodHead {
+			method = http.MethodGet
+		}
+
+		query := r.URL.Query()
+		expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+		valid := err == nil && len(s.signingKey) > 0 && query.Get("method") == method &&
+			hmac.Equal([]byte(query.Get("signature")), []byte(s.sign(method, key, query.Get("expires"))))
+		if !valid || s.now().Unix() > expires {
+			http.Error(w, "invalid or expired signature", http.StatusForbidden)
+			return
+		}
+
+		switch method {
+		case http.MethodGet:
+			file, object, err := s.open(key)
+			if err != nil {
+				writeStorageError(w, err)
+				return
+			}
+			defer file.Close()
+			w.Header().Set("Content-Type", object.ContentType)
+			w.Header().Set("ETag", `"`+object.ETag+`"`)
+			http.ServeContent(w, r, "", object.UpdatedAt, file)
+		case http.MethodPut:
+			var opts []PutOption
+			if contentType := r.Header.Get("Content-Type"); contentType != "" {
+				opts = append(opts, WithContentType(contentType))
+			}
+			if _, err := s.Put(r.Context(), key, r.Body, opts...); err != nil {
+				writeStorageError(w, err)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.Header().Set("Allow", "GET, HEAD, PUT")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+func writeStorageError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, ErrInvalidKey):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "storage error", http.StatusInternalServerError)
+	}
+}
+
+// escapeKey escapes every segment of key for a URL path, keeping the slashes
+func escapeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = queryEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// readerWithContext stops reading once ctx is done, e.g. when the client of an upload left
+func readerWithContext(ctx context.Context, r io.Reader) io.Reader {
+	return readerFunc(func(p []byte) (int, error) {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		return r.Read(p)
+	})
+}
+
+type readerFunc func(p []byte) (int, error)
+
+func (f readerFunc) Read(p []byte) (int, error) { return f(p) }
@@ -0,0 +1,260 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yourorg/go-api-template/core/exception"
+	"github.com/yourorg/go-api-template/core/secrets"
+)
+
+// S3Config configures the s3 driver. The credentials default to the chain of
+// secrets.AWSCredentialsSource, the region to AWS_REGION.
+type S3Config struct {
+	Bucket string `mapstructure:"bucket"`
+	Region string `mapstructure:"region"`
+	// Endpoint overrides https://s3.<region>.amazonaws.com, e.g. for MinIO
+	Endpoint string `mapstructure:"endpoint"`
+	// PathStyle puts the bucket in the path instead of the host name, as MinIO expects
+	PathStyle       bool   `mapstructure:"pathStyle"`
+	AccessKeyID     string `mapstructure:"accessKeyId"`
+	SecretAccessKey string `mapstructure:"secretAccessKey" secret:"true"`
+}
+
+// S3Storage keeps objects in an S3 bucket, or one of a compatible service, through its REST
+// API signed with Signature Version 4. The bodies are streamed without being hashed; one of
+// unknown size is copied to a temporary file first, S3 needing its length.
+type S3Storage struct {
+	config      S3Config
+	endpoint    *url.URL
+	credentials *secrets.AWSCredentialsSource
+	client      *http.Client
+	now         func() time.Time
+}
+
+// NewS3Storage returns a storage on cfg.Bucket, filling the region and credentials from the environment
+func NewS3Storage(cfg S3Config, client *http.Client) (*S3Storage, error) {
+	if cfg.Bucket == "" {
+		return nil, errors.New("s3 bucket is empty")
+	}
+	if cfg.Region == "" {
+		cfg.Region = os.Getenv("AWS_REGION")
+	}
+	if cfg.Region == "" {
+		cfg.Region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if cfg.Region == "" {
+		return nil, errors.New("s3 region is not configured")
+	}
+	if cfg.AccessKeyID != "" && cfg.SecretAccessKey == "" {
+		return nil, errors.New("s3 secretAccessKey is not set")
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://s3." + cfg.Region + ".amazonaws.com"
+	}
+	endpoint, err := url.Parse(strings.TrimSuffix(cfg.Endpoint, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid s3 endpoint: %w", err)
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	credentials := secrets.NewAWSCredentialsSource(cfg.AccessKeyID, cfg.SecretAccessKey, "", client)
+	return &S3Storage{config: cfg, endpoint: endpoint, credentials: credentials, client: client, now: time.Now}, nil
+}
+
+// objectURL returns the URL of key, the bucket in the host name unless PathStyle is set
+func (s *S3Storage) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	if s.config.PathStyle {
+		u.Path += "/" + s.config.Bucket + "/" + key
+		u.RawPath = s.endpoint.EscapedPath() + "/" + escapeKey(s.config.Bucket) + "/" + escapeKey(key)
+	} else {
+		u.Host = s.config.Bucket + "." + u.Host
+		u.Path += "/" + key
+		u.RawPath = s.endpoint.EscapedPath() + "/" + escapeKey(key)
+	}
+	return &u
+}
+
+// do sends a signed request for key, turning a 404 into ErrNotFound and the other failures into errors
+func (s *S3Storage) do(ctx context.Context, method string, key string, body io.Reader, size int64, header http.Header) (*http.Response, error) {
+	if err := ValidateKey(key); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, s.objectURL(key).String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.ContentLength = size
+		if size == 0 {
+			// a zero length with a body would be sent chunked
+			req.Body = http.NoBody
+		}
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	credentials, err := s.credentials.Credentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Amz-Content-Sha256", secrets.UnsignedPayload)
+	if credentials.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", credentials.SessionToken)
+	}
+	secrets.SignV4Hash(req, secrets.UnsignedPayload, credentials.AccessKeyID, credentials.SecretAccessKey, s.config.Region, "s3", s.now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+	err = fmt.Errorf("s3 returned %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	if exception.RetryClassFromHTTPStatus(resp.StatusCode) == exception.RetryPermanent {
+		return nil, exception.MarkPermanent(err)
+	}
+	return nil, err
+}
+
+func (s *S3Storage) Put(ctx context.Context, key string, body io.Reader, opts ...PutOption) (Object, error) {
+	if err := ValidateKey(key); err != nil {
+		return Object{}, err
+	}
+	options := putOptions(opts)
+	contentType, body, err := detectContentType(key, body, options.ContentType)
+	if err != nil {
+		return Object{}, err
+	}
+
+	size := options.Size
+	if size < 0 {
+		spooled, n, err := spool(body)
+		if err != nil {
+			return Object{}, err
+		}
+		defer spooled.Close()
+		body, size = spooled, n
+	}
+
+	resp, err := s.do(ctx, http.MethodPut, key, body, size, http.Header{"Content-Type": {contentType}})
+	if err != nil {
+		return Object{}, fmt.Errorf("error putting object %s: %w", key, err)
+	}
+	resp.Body.Close()
+	return Object{
+		Key:         key,
+		Size:        size,
+		ContentType: contentType,
+		ETag:        strings.Trim(resp.Header.Get("ETag"), `"`),
+		UpdatedAt:   s.now().UTC(),
+	}, nil
+}
+
+func (s *S3Storage) Get(ctx context.Context, key string) (io.ReadCloser, Object, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, nil, 0, nil)
+	if err != nil {
+		return nil, Object{}, err
+	}
+	return resp.Body, objectFromHeader(key, resp), nil
+}
+
+func (s *S3Storage) Stat(ctx context.Context, key string) (Object, error) {
+	resp, err := s.do(ctx, http.MethodHead, key, nil, 0, nil)
+	if err != nil {
+		return Object{}, err
+	}
+	resp.Body.Close()
+	return objectFromHeader(key, resp), nil
+}
+
+// Delete succeeds for a missing object, S3 answering 204 either way
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, key, nil, 0, nil)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// SignedURL returns a presigned URL, valid up to 7 days, or until temporary credentials expire
+func (s *S3Storage) SignedURL(ctx context.Context, key string, method string, expiry time.Duration) (string, error) {
+	if err := ValidateKey(key); err != nil {
+		return "", err
+	}
+	credentials, err := s.credentials.Credentials(ctx)
+	if err != nil {
+		return "", err
+	}
+	u := secrets.PresignV4(method, s.objectURL(key), credentials.AccessKeyID, credentials.SecretAccessKey, credentials.SessionToken,
+		s.config.Region, "s3", s.now(), expiry)
+	return u.String(), nil
+}
+
+// objectFromHeader describes the object of a GET or HEAD response
+func objectFromHeader(key string, resp *http.Response) Object {
+	object := Object{
+		Key:         key,
+		Size:        resp.ContentLength,
+		ContentType: resp.Header.Get("Content-Type"),
+		ETag:        strings.Trim(resp.Header.Get("ETag"), `"`),
+	}
+	if size, err := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64); err == nil {
+		object.Size = size
+	}
+	if modified, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		object.UpdatedAt = modified.UTC()
+	}
+	return object
+}
+
+// spool copies body to a temporary file removed on Close, returning it rewound with its size
+func spool(body io.Reader) (io.ReadCloser, int64, error) {
+	tmp, err := os.CreateTemp("", "storage-*")
+	if err != nil {
+		return nil, 0, err
+	}
+	remove := func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}
+	size, err := io.Copy(tmp, body)
+	if err == nil {
+		_, err = tmp.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		remove()
+		return nil, 0, err
+	}
+	return spooledFile{tmp, remove}, size, nil
+}
+
+type spooledFile struct {
+	*os.File
+	remove func()
+}
+
+func (f spooledFile) Close() error {
+	f.remove()
+	return nil
+}
@@ -0,0 +1,195 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Drivers selected by Config.Driver
+const (
+	DriverLocal = "local"
+	DriverS3    = "s3"
+	DriverGCS   = "gcs"
+)
+
+var (
+	// ErrNotFound is returned for a key without an object
+	ErrNotFound = errors.New("object not found")
+	// ErrInvalidKey is returned for a key that is empty, absolute or escapes its prefix
+	ErrInvalidKey = errors.New("invalid object key")
+)
+
+// Config selects and configures the object storage
+type Config struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Driver  string `mapstructure:"driver" enum:"local s3 gcs"`
+	// MaxUploadSize bounds the body of the upload endpoint, in bytes
+	MaxUploadSize int64 `mapstructure:"maxUploadSize"`
+	// MaxFileSize bounds every file of an upload, in bytes; 0 leaves them bounded by MaxUploadSize
+	MaxFileSize int64 `mapstructure:"maxFileSize"`
+	// AllowedTypes are the media types accepted by the upload endpoint, e.g. image/png or
+	// image/*, detected from the content of the files; empty accepts every type
+	AllowedTypes []string `mapstructure:"allowedTypes"`
+	// SignedURLExpiry is the lifetime of the URLs returned for the objects
+	SignedURLExpiry time.Duration `mapstructure:"signedUrlExpiry"`
+	Local           LocalConfig   `mapstructure:"local"`
+	S3              S3Config      `mapstructure:"s3"`
+	GCS             GCSConfig     `mapstructure:"gcs"`
+}
+
+// DefaultConfig returns the local driver under data/storage, served by the API itself
+func DefaultConfig() Config {
+	return Config{
+		Enabled:         false,
+		Driver:          DriverLocal,
+		MaxUploadSize:   32 << 20,
+		SignedURLExpiry: 15 * time.Minute,
+		Local: LocalConfig{
+			Root:    "data/storage",
+			BaseURL: "http://localhost:8080/storage",
+		},
+	}
+}
+
+// Object describes a stored object
+type Object struct {
+	Key         string    `json:"key"`
+	Size        int64     `json:"size"`
+	ContentType string    `json:"content_type"`
+	ETag        string    `json:"etag,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Storage keeps objects under slash separated keys, e.g. avatars/42.png. The bodies are
+// streamed, not held in memory.
+type Storage interface {
+	// Put stores body under key, replacing the object there
+	Put(ctx context.Context, key string, body io.Reader, opts ...PutOption) (Object, error)
+	// Get opens the object of key; the caller closes the reader
+	Get(ctx context.Context, key string) (io.ReadCloser, Object, error)
+	// Stat describes the object of key
+	Stat(ctx context.Context, key string) (Object, error)
+	// Delete removes the object of key, a missing object is not an error
+	Delete(ctx context.Context, key string) error
+	// SignedURL returns a URL letting its holder GET or PUT the object of key until expiry
+	SignedURL(ctx context.Context, key string, method string, expiry time.Duration) (string, error)
+}
+
+// PutOptions are the settings of a Put
+type PutOptions struct {
+	// ContentType is detected from the body, then the key extension, when empty
+	ContentType string
+	// Size is the length of the body, -1 when unknown
+	Size int64
+}
+
+// PutOption customizes a Put
+type PutOption func(*PutOptions)
+
+// WithContentType sets the content type instead of detecting it
+func WithContentType(contentType string) PutOption {
+	return func(o *PutOptions) {
+		o.ContentType = contentType
+	}
+}
+
+// WithSize gives the length of the body, sparing the drivers that need it a temporary copy
+func WithSize(size int64) PutOption {
+	return func(o *PutOptions) {
+		o.Size = size
+	}
+}
+
+func putOptions(opts []PutOption) PutOptions {
+	options := PutOptions{Size: -1}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// New returns the storage of cfg.Driver; client defaults to http.DefaultClient
+func New(cfg Config, client *http.Client) (Storage, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	switch cfg.Driver {
+	case DriverLocal, "":
+		return NewLocalStorage(cfg.Local)
+	case DriverS3:
+		return NewS3Storage(cfg.S3, client)
+	case DriverGCS:
+		return NewGCSStorage(cfg.GCS, client)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Driver)
+	}
+}
+
+// ValidateKey checks key is a relative slash separated path staying under its first segment
+func ValidateKey(key string) error {
+	if key == "" || len(key) > 1024 || strings.HasPrefix(key, "/") || strings.ContainsAny(key, "\\\x00") {
+		return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+		}
+	}
+	return nil
+}
+
+// UploadKey returns a new key under prefix keeping the extension of filename, e.g.
+// uploads/0190c3e8-....png; the file names of clients are not used as keys
+func UploadKey(prefix string, filename string) (string, error) {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return "", err
+	}
+	key := id.String() + strings.ToLower(path.Ext(path.Base(filename)))
+	if prefix = strings.Trim(prefix, "/"); prefix != "" {
+		key = prefix + "/" + key
+	}
+	return key, ValidateKey(key)
+}
+
+// SniffLen is the length of content SniffContentType looks at
+const SniffLen = 512
+
+// detectContentType returns contentType when set, otherwise the type sniffed from the first
+// bytes of body or, when those are not conclusive, the one of the key extension. The reader
+// returned yields the whole body.
+func detectContentType(key string, body io.Reader, contentType string) (string, io.Reader, error) {
+	if contentType != "" {
+		return contentType, body, nil
+	}
+	head := make([]byte, SniffLen)
+	n, err := io.ReadFull(body, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", nil, err
+	}
+	head = head[:n]
+	return SniffContentType(key, head), io.MultiReader(bytes.NewReader(head), body), nil
+}
+
+// SniffContentType returns the content type of the first 512 bytes of a file, or the one of the
+// extension of name when those only tell text from binary
+func SniffContentType(name string, head []byte) string {
+	sniffed := http.DetectContentType(head)
+	// the sniffer only tells text from binary for most formats, e.g. JSON or CSV
+	if strings.HasPrefix(sniffed, "text/plain") || sniffed == "application/octet-stream" {
+		if byExt := mime.TypeByExtension(path.Ext(name)); byExt != "" {
+			return byExt
+		}
+	}
+	return sniffed
+}
@@ -0,0 +1,218 @@
+package telemetry
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const runtimeMeterName = "github.com/yourorg/go-api-template/core/telemetry"
+
+// RuntimeConfig samples the Go runtime for the runtime metrics
+type RuntimeConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Interval between samples, the gauges report the last one
+	Interval time.Duration `mapstructure:"interval"`
+}
+
+// RuntimeSample is the state of the runtime at a sample
+type RuntimeSample struct {
+	Goroutines int
+	// HeapAlloc is the bytes of allocated heap objects
+	HeapAlloc uint64
+	// Sys is the bytes of memory obtained from the OS
+	Sys uint64
+	// NumGC is the number of completed GC cycles
+	NumGC uint32
+	// OpenFDs is the number of open file descriptors, -1 where they cannot be listed
+	OpenFDs int
+}
+
+// RuntimeCollector samples goroutines, GC pauses, the heap and open file descriptors every
+// interval. The samples are exported as gauges and the GC pauses as a histogram, the state
+// the health endpoint only shows at the time it is called.
+type RuntimeCollector struct {
+	interval time.Duration
+	mu       sync.Mutex
+	last     RuntimeSample
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+var (
+	exportedRuntime  atomic.Pointer[RuntimeCollector]
+	runtimeOnce      sync.Once
+	gcPauseHistogram metric.Float64Histogram
+)
+
+// NewRuntimeCollector returns a collector sampling every cfg.Interval, 15s when unset
+func NewRuntimeCollector(cfg RuntimeConfig) *RuntimeCollector {
+	interval := cfg.Interval
+	if interval <= 0 {
+		interval = DefaultConfig().Runtime.Interval
+	}
+	return &RuntimeCollector{interval: interval}
+}
+
+// Start samples the runtime now and every interval until Shutdown, and reports the samples
+// to the global meter provider. The last collector started is reported.
+func (c *RuntimeCollector) Start() {
+	registerRuntimeInstruments()
+	exportedRuntime.Store(c)
+	c.Collect()
+
+	c.stop, c.done = make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(c.done)
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				c.Collect()
+			case <-c.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Shutdown stops the sampling started by Start
+func (c *RuntimeCollector) Shutdown(ctx context.Context) error {
+	if c.stop == nil {
+		return nil
+	}
+	close(c.stop)
+	select {
+	case <-c.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Collect samples the runtime and records the GC pauses since the previous sample
+func (c *RuntimeCollector) Collect() RuntimeSample {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	sample := RuntimeSample{
+		Goroutines: runtime.NumGoroutine(),
+		HeapAlloc:  m.HeapAlloc,
+		Sys:        m.Sys,
+		NumGC:      m.NumGC,
+		OpenFDs:    openFDs(),
+	}
+
+	c.mu.Lock()
+	previous := c.last.NumGC
+	c.last = sample
+	c.mu.Unlock()
+
+	if gcPauseHistogram != nil {
+		// PauseNs keeps the pauses of the last 256 cycles, cycle n at (n-1)%256
+		kept := uint32(len(m.PauseNs))
+		first := previous + 1
+		if sample.NumGC > kept && first <= sample.NumGC-kept {
+			first = sample.NumGC - kept + 1
+		}
+		for gc := first; gc <= sample.NumGC; gc++ {
+			gcPauseHistogram.Record(context.Background(), time.Duration(m.PauseNs[(gc-1)%kept]).Seconds())
+		}
+	}
+	return sample
+}
+
+// Last returns the last sample
+func (c *RuntimeCollector) Last() RuntimeSample {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.last
+}
+
+func registerRuntimeInstruments() {
+	runtimeOnce.Do(func() {
+		// instruments from the global meter follow a provider installed later
+		meter := otel.Meter(runtimeMeterName)
+		goroutines, err := meter.Int64ObservableGauge(
+			"go.goroutine.count",
+			metric.WithDescription("Number of live goroutines"),
+			metric.WithUnit("{goroutine}"),
+		)
+		if err != nil {
+			return
+		}
+		heap, err := meter.Int64ObservableGauge(
+			"go.memory.heap.alloc",
+			metric.WithDescription("Bytes of allocated heap objects"),
+			metric.WithUnit("By"),
+		)
+		if err != nil {
+			return
+		}
+		sys, err := meter.Int64ObservableGauge(
+			"go.memory.sys",
+			metric.WithDescription("Bytes of memory obtained from the OS"),
+			metric.WithUnit("By"),
+		)
+		if err != nil {
+			return
+		}
+		gcCount, err := meter.Int64ObservableCounter(
+			"go.gc.count",
+			metric.WithDescription("Number of completed GC cycles"),
+			metric.WithUnit("{gc_cycle}"),
+		)
+		if err != nil {
+			return
+		}
+		fds, err := meter.Int64ObservableGauge(
+			"process.open_file_descriptor.count",
+			metric.WithDescription("Number of open file descriptors"),
+			metric.WithUnit("{file_descriptor}"),
+		)
+		if err != nil {
+			return
+		}
+		gcPauseHistogram, err = meter.Float64Histogram(
+			"go.gc.pause.duration",
+			metric.WithDescription("Duration of the stop-the-world pauses of GC cycles"),
+			metric.WithUnit("s"),
+			metric.WithExplicitBucketBoundaries(0.00001, 0.00005, 0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1),
+		)
+		if err != nil {
+			return
+		}
+		meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+			c := exportedRuntime.Load()
+			if c == nil {
+				return nil
+			}
+			sample := c.Last()
+			o.ObserveInt64(goroutines, int64(sample.Goroutines))
+			o.ObserveInt64(heap, int64(sample.HeapAlloc))
+			o.ObserveInt64(sys, int64(sample.Sys))
+			o.ObserveInt64(gcCount, int64(sample.NumGC))
+			if sample.OpenFDs >= 0 {
+				o.ObserveInt64(fds, int64(sample.OpenFDs))
+			}
+			return nil
+		}, goroutines, heap, sys, gcCount, fds)
+	})
+}
+
+// openFDs counts the open file descriptors of the process, -1 without /proc or /dev/fd
+func openFDs() int {
+	for _, dir := range []string{"/proc/self/fd", "/dev/fd"} {
+		if entries, err := os.ReadDir(dir); err == nil {
+			// the descriptor of the directory being read
+			return len(entries) - 1
+		}
+	}
+	return -1
+}
@@ -0,0 +1,176 @@
+// Package telemetry installs the OpenTelemetry providers of the process: the meter provider
+// exporting metrics over OTLP, for environments standardized on an OpenTelemetry Collector
+// rather than Prometheus scraping, and the tracer provider of core/tracing, both describing
+// the service with one resource. Instruments and tracers created from otel.Meter and
+// otel.Tracer, such as those of otelhttp, otelpgx and the core packages, report to them once
+// installed.
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/yourorg/go-api-template/core/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const (
+	TemporalityCumulative = "cumulative"
+	TemporalityDelta      = "delta"
+)
+
+// Config holds the OpenTelemetry export configuration
+type Config struct {
+	// ResourceAttributes are added to the service name and version of the exported resource,
+	// e.g. deployment.environment.name or k8s.namespace.name
+	ResourceAttributes map[string]string `mapstructure:"resourceAttributes"`
+	Metrics            MetricsConfig     `mapstructure:"metrics"`
+	Traces             tracing.Config    `mapstructure:"traces"`
+	// Runtime exports goroutines, GC pauses, the heap and open file descriptors with the metrics
+	Runtime RuntimeConfig `mapstructure:"runtime"`
+}
+
+// MetricsConfig selects the OTLP receiver metrics are pushed to
+type MetricsConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Endpoint is host:port of the OTLP gRPC receiver, e.g. an OpenTelemetry Collector
+	Endpoint string `mapstructure:"endpoint"`
+	// Insecure sends without TLS, for a collector on localhost or a sidecar
+	Insecure bool `mapstructure:"insecure"`
+	// Headers are sent with every export, e.g. the API key of a vendor endpoint
+	Headers map[string]string `mapstructure:"headers" secret:"true"`
+	// Interval between exports
+	Interval time.Duration `mapstructure:"interval"`
+	// Timeout bounds a single export
+	Timeout time.Duration `mapstructure:"timeout"`
+	// Temporality is cumulative, or delta for backends that expect it
+	Temporality string `mapstructure:"temporality" enum:"cumulative delta"`
+}
+
+// DefaultConfig returns default telemetry configuration, metrics and traces disabled
+func DefaultConfig() Config {
+	return Config{
+		Metrics: MetricsConfig{
+			Endpoint:    "localhost:4317",
+			Interval:    time.Minute,
+			Timeout:     10 * time.Second,
+			Temporality: TemporalityCumulative,
+		},
+		Traces: tracing.DefaultConfig(),
+		Runtime: RuntimeConfig{
+			Interval: 15 * time.Second,
+		},
+	}
+}
+
+// Resource merges the configured attributes into base, the attributes winning
+func Resource(base *resource.Resource, attributes map[string]string) (*resource.Resource, error) {
+	keys := make([]string, 0, len(attributes))
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	attrs := make([]attribute.KeyValue, 0, len(keys))
+	for _, key := range keys {
+		attrs = append(attrs, attribute.String(key, attributes[key]))
+	}
+	return resource.Merge(base, resource.NewSchemaless(attrs...))
+}
+
+// NewMeterProvider returns a meter provider exporting to the OTLP receiver of cfg every interval
+func NewMeterProvider(ctx context.Context, cfg MetricsConfig, res *resource.Resource) (*sdkmetric.MeterProvider, error) {
+	if cfg.Endpoint == "" {
+		return nil, errors.New("telemetry.metrics.endpoint is required")
+	}
+	opts := []otlpmetricgrpc.Option{
+		otlpmetricgrpc.WithEndpoint(cfg.Endpoint),
+		otlpmetricgrpc.WithHeaders(cfg.Headers),
+	}
+	if cfg.Insecure {
+		opts = append(opts, otlpmetricgrpc.WithInsecure())
+	}
+	if cfg.Timeout > 0 {
+		opts = append(opts, otlpmetricgrpc.WithTimeout(cfg.Timeout))
+	}
+	switch cfg.Temporality {
+	case "", TemporalityCumulative:
+	case TemporalityDelta:
+		opts = append(opts, otlpmetricgrpc.WithTemporalitySelector(deltaTemporality))
+	default:
+		return nil, fmt.Errorf("unknown telemetry.metrics.temporality %q", cfg.Temporality)
+	}
+
+	// the connection is established lazily, an unreachable collector only fails the exports
+	exporter, err := otlpmetricgrpc.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP metric exporter: %w", err)
+	}
+
+	var readerOpts []sdkmetric.PeriodicReaderOption
+	if cfg.Interval > 0 {
+		readerOpts = append(readerOpts, sdkmetric.WithInterval(cfg.Interval))
+	}
+	if cfg.Timeout > 0 {
+		readerOpts = append(readerOpts, sdkmetric.WithTimeout(cfg.Timeout))
+	}
+	return sdkmetric.NewMeterProvider(
+		sdkmetric.WithResource(res),
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter, readerOpts...)),
+	), nil
+}
+
+// Setup installs the meter and tracer providers of cfg as the global ones, those enabled,
+// with base and the configured resource attributes as their resource, and starts the runtime
+// metrics collector with the metrics. processors are passed to tracing.Setup. The returned
+// shutdown flushes the spans and exports the last metrics.
+func Setup(ctx context.Context, cfg Config, base *resource.Resource, processors ...sdktrace.SpanProcessor) (func(context.Context) error, error) {
+	res, err := Resource(base, cfg.ResourceAttributes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid telemetry.resourceAttributes: %w", err)
+	}
+
+	shutdownTraces, err := tracing.Setup(ctx, cfg.Traces, res, processors...)
+	if err != nil {
+		return nil, err
+	}
+	shutdownMetrics := func(context.Context) error { return nil }
+	if cfg.Metrics.Enabled {
+		provider, err := NewMeterProvider(ctx, cfg.Metrics, res)
+		if err != nil {
+			return nil, errors.Join(err, shutdownTraces(ctx))
+		}
+		otel.SetMeterProvider(provider)
+		shutdownMetrics = provider.Shutdown
+
+		if cfg.Runtime.Enabled {
+			collector := NewRuntimeCollector(cfg.Runtime)
+			collector.Start()
+			shutdownMetrics = func(ctx context.Context) error {
+				return errors.Join(collector.Shutdown(ctx), provider.Shutdown(ctx))
+			}
+		}
+	}
+
+	return func(ctx context.Context) error {
+		return errors.Join(shutdownTraces(ctx), shutdownMetrics(ctx))
+	}, nil
+}
+
+// deltaTemporality reports counters and histograms as deltas; up-down counters stay
+// cumulative, as in the OTLP exporter specification
+func deltaTemporality(kind sdkmetric.InstrumentKind) metricdata.Temporality {
+	switch kind {
+	case sdkmetric.InstrumentKindUpDownCounter, sdkmetric.InstrumentKindObservableUpDownCounter:
+		return metricdata.CumulativeTemporality
+	}
+	return metricdata.DeltaTemporality
+}
@@ -0,0 +1,158 @@
+// Package tracing installs the OpenTelemetry tracer provider behind the spans started with
+// otel.Tracer, by otelhttp, otelpgx and the core packages: where they are exported, which
+// are sampled and the resource describing the service. Without it the spans are dropped.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+)
+
+// Config selects the span exporter and sampling
+type Config struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Exporter is otlp, pushing to an OTLP gRPC receiver, or stdout for local debugging
+	Exporter string `mapstructure:"exporter" enum:"otlp stdout"`
+	// Endpoint is host:port of the OTLP gRPC receiver, e.g. an OpenTelemetry Collector
+	Endpoint string `mapstructure:"endpoint"`
+	// Insecure sends without TLS, for a collector on localhost or a sidecar
+	Insecure bool `mapstructure:"insecure"`
+	// Headers are sent with every export, e.g. the API key of a vendor endpoint
+	Headers map[string]string `mapstructure:"headers" secret:"true"`
+	// SampleRatio of the traces started here, 1 keeps all and 0 none; traces continued from
+	// an incoming traceparent follow the sampling decision of the caller
+	SampleRatio float64 `mapstructure:"sampleRatio"`
+	// Timeout bounds a single export
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// DefaultConfig returns default tracing configuration, disabled
+func DefaultConfig() Config {
+	return Config{
+		Exporter:    ExporterOTLP,
+		Endpoint:    "localhost:4317",
+		SampleRatio: 1,
+		Timeout:     10 * time.Second,
+	}
+}
+
+// Sampler samples SampleRatio of the root spans and follows the parent of the others, except
+// the spans started in a context from WithForcedSampling, always sampled
+func (c Config) Sampler() sdktrace.Sampler {
+	return forcedSampler{sdktrace.ParentBased(sdktrace.TraceIDRatioBased(c.SampleRatio))}
+}
+
+type forcedSamplingKey struct{}
+
+// WithForcedSampling samples the spans started in ctx whatever the ratio and the decision of
+// the caller, e.g. for a request traced with X-Debug-Trace
+func WithForcedSampling(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forcedSamplingKey{}, true)
+}
+
+// SamplingForced reports whether ctx comes from WithForcedSampling
+func SamplingForced(ctx context.Context) bool {
+	forced, _ := ctx.Value(forcedSamplingKey{}).(bool)
+	return forced
+}
+
+type forcedSampler struct {
+	sdktrace.Sampler
+}
+
+func (s forcedSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	if SamplingForced(p.ParentContext) {
+		return sdktrace.SamplingResult{
+			Decision:   sdktrace.RecordAndSample,
+			Tracestate: trace.SpanContextFromContext(p.ParentContext).TraceState(),
+		}
+	}
+	return s.Sampler.ShouldSample(p)
+}
+
+func (s forcedSampler) Description() string {
+	return "Forced{" + s.Sampler.Description() + "}"
+}
+
+// NewTracerProvider returns a tracer provider batching the sampled spans to the exporter of
+// cfg. processors, such as middleware.BaggageSpanProcessor, see every span as it starts.
+func NewTracerProvider(ctx context.Context, cfg Config, res *resource.Resource, processors ...sdktrace.SpanProcessor) (*sdktrace.TracerProvider, error) {
+	exporter, err := newExporter(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var batchOpts []sdktrace.BatchSpanProcessorOption
+	if cfg.Timeout > 0 {
+		batchOpts = append(batchOpts, sdktrace.WithExportTimeout(cfg.Timeout))
+	}
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(cfg.Sampler()),
+	}
+	for _, processor := range processors {
+		opts = append(opts, sdktrace.WithSpanProcessor(processor))
+	}
+	opts = append(opts, sdktrace.WithBatcher(exporter, batchOpts...))
+	return sdktrace.NewTracerProvider(opts...), nil
+}
+
+func newExporter(ctx context.Context, cfg Config) (sdktrace.SpanExporter, error) {
+	switch cfg.Exporter {
+	case "", ExporterOTLP:
+		if cfg.Endpoint == "" {
+			return nil, errors.New("telemetry.traces.endpoint is required")
+		}
+		opts := []otlptracegrpc.Option{
+			otlptracegrpc.WithEndpoint(cfg.Endpoint),
+			otlptracegrpc.WithHeaders(cfg.Headers),
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		if cfg.Timeout > 0 {
+			opts = append(opts, otlptracegrpc.WithTimeout(cfg.Timeout))
+		}
+		// the connection is established lazily, an unreachable collector only fails the exports
+		exporter, err := otlptracegrpc.New(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OTLP trace exporter: %w", err)
+		}
+		return exporter, nil
+	case ExporterStdout:
+		return stdouttrace.New(stdouttrace.WithPrettyPrint())
+	default:
+		return nil, fmt.Errorf("unknown telemetry.traces.exporter %q", cfg.Exporter)
+	}
+}
+
+// Setup installs the tracer provider of cfg as the global one when tracing is enabled, with
+// the W3C trace context and baggage propagators. The returned shutdown flushes the spans
+// still batched; it is a no-op when nothing was installed.
+func Setup(ctx context.Context, cfg Config, res *resource.Resource, processors ...sdktrace.SpanProcessor) (func(context.Context) error, error) {
+	if !cfg.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+	provider, err := NewTracerProvider(ctx, cfg, res, processors...)
+	if err != nil {
+		return nil, err
+	}
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
@@ -0,0 +1,410 @@
+package httpserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"github.com/yourorg/go-api-template/core/exception"
+)
+
+// Media types of the built-in codecs
+const (
+	MediaTypeJSON    = "application/json"
+	MediaTypeXML     = "application/xml"
+	MediaTypeMsgpack = "application/msgpack"
+	MediaTypeCSV     = "text/csv"
+)
+
+// ErrUnsupportedValue is returned by a codec asked to encode or decode a value it cannot represent
+var ErrUnsupportedValue = errors.New("value not supported by codec")
+
+// Codec encodes responses and decodes requests of one media type
+type Codec interface {
+	MediaType() string
+	Decode(data []byte, v any) error
+	Encode(w io.Writer, v any) error
+}
+
+// StrictCodec is a Codec that can reject fields the target type does not have,
+// used when DecodeOptions.DisallowUnknownFields is set
+type StrictCodec interface {
+	Codec
+	DecodeStrict(data []byte, v any) error
+}
+
+// UnknownFieldError is returned by DecodeStrict for a field the target type does not have
+type UnknownFieldError struct {
+	Field string
+}
+
+func (e *UnknownFieldError) Error() string {
+	return fmt.Sprintf("unknown field %q", e.Field)
+}
+
+// DecodeOptions control how strictly transports decode request bodies
+type DecodeOptions struct {
+	// DisallowUnknownFields rejects bodies with fields the request type does not have,
+	// for codecs implementing StrictCodec
+	DisallowUnknownFields bool
+	// RequireContentType rejects bodies without a Content-Type instead of decoding them
+	// with the first allowed codec
+	RequireContentType bool
+}
+
+var decodeOptions atomic.Pointer[DecodeOptions]
+
+// SetDecodeOptions sets the request decoding options for all transports
+func SetDecodeOptions(opts DecodeOptions) {
+	decodeOptions.Store(&opts)
+}
+
+func currentDecodeOptions() DecodeOptions {
+	if opts := decodeOptions.Load(); opts != nil {
+		return *opts
+	}
+	return DecodeOptions{}
+}
+
+// TypedCodec is a Codec that only handles some types, e.g. CSV needs rows.
+// Negotiation skips it for response types it does not support.
+type TypedCodec interface {
+	Codec
+	Supports(t reflect.Type) bool
+}
+
+type codecSet struct {
+	codecs  []Codec
+	allowed []string // in order of preference, nil allows every codec
+}
+
+var (
+	codecsMu sync.Mutex
+	codecs   atomic.Pointer[codecSet]
+)
+
+func init() {
+	codecs.Store(&codecSet{codecs: []Codec{JSONCodec{}, XMLCodec{}, MsgpackCodec{}, CSVCodec{}}})
+}
+
+// RegisterCodec adds codec for all transports, replacing the codec of the same media type.
+// New media types rank after the built-in ones when the client accepts several equally.
+func RegisterCodec(codec Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+
+	current := codecs.Load()
+	next := &codecSet{allowed: current.allowed}
+	replaced := false
+	for _, c := range current.codecs {
+		if c.MediaType() == codec.MediaType() {
+			c, replaced = codec, true
+		}
+		next.codecs = append(next.codecs, c)
+	}
+	if !replaced {
+		next.codecs = append(next.codecs, codec)
+	}
+	codecs.Store(next)
+}
+
+// SetAllowedMediaTypes restricts the codecs transports use to mediaTypes, in that order
+// of preference; empty allows all. The first allowed codec decodes requests without
+// a Content-Type and answers Accept: */*.
+func SetAllowedMediaTypes(mediaTypes []string) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+
+	next := &codecSet{codecs: codecs.Load().codecs}
+	for _, mediaType := range mediaTypes {
+		next.allowed = append(next.allowed, strings.ToLower(strings.TrimSpace(mediaType)))
+	}
+	codecs.Store(next)
+}
+
+// allowedCodecs returns the usable codecs in order of preference; allowed media types
+// without a registered codec are ignored
+func allowedCodecs() []Codec {
+	set := codecs.Load()
+	if set.allowed == nil {
+		return set.codecs
+	}
+	allowed := make([]Codec, 0, len(set.allowed))
+	for _, mediaType := range set.allowed {
+		for _, codec := range set.codecs {
+			if codec.MediaType() == mediaType {
+				allowed = append(allowed, codec)
+				break
+			}
+		}
+	}
+	return allowed
+}
+
+func mediaTypesOf(list []Codec) string {
+	names := make([]string, len(list))
+	for i, codec := range list {
+		names[i] = codec.MediaType()
+	}
+	return strings.Join(names, ", ")
+}
+
+// decodeRequest decodes body into v with the codec matching the Content-Type header,
+// the first allowed codec when there is none. An unknown type yields an UnsupportedMediaType error,
+// an unknown field an UnknownFields error when DecodeOptions.DisallowUnknownFields is set.
+func decodeRequest(r *http.Request, body []byte, v any) error {
+	list := allowedCodecs()
+	if len(list) == 0 {
+		return unsupportedMediaType(list)
+	}
+
+	opts := currentDecodeOptions()
+	codec := list[0]
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" && opts.RequireContentType && len(body) > 0 {
+		return unsupportedMediaType(list)
+	}
+	if contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			return unsupportedMediaType(list)
+		}
+		codec = nil
+		for _, c := range list {
+			if c.MediaType() == mediaType {
+				codec = c
+				break
+			}
+		}
+		if codec == nil {
+			return unsupportedMediaType(list)
+		}
+	}
+
+	if len(body) == 0 {
+		return nil
+	}
+
+	var err error
+	if strict, ok := codec.(StrictCodec); ok && opts.DisallowUnknownFields {
+		err = strict.DecodeStrict(body, v)
+	} else {
+		err = codec.Decode(body, v)
+	}
+	var unknownErr *UnknownFieldError
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, ErrUnsupportedValue):
+		return unsupportedMediaType(list)
+	case errors.As(err, &unknownErr):
+		return unknownFields(unknownErr)
+	}
+	return err
+}
+
+// negotiateCodec picks the codec for a response of type t from the Accept header.
+// No Accept header means the first allowed codec; nothing acceptable yields a NotAcceptable error.
+func negotiateCodec(r *http.Request, t reflect.Type) (Codec, error) {
+	var candidates []Codec
+	for _, codec := range allowedCodecs() {
+		if typed, ok := codec.(TypedCodec); ok && !typed.Supports(t) {
+			continue
+		}
+		candidates = append(candidates, codec)
+	}
+
+	accept := r.Header.Get("Accept")
+	if strings.TrimSpace(accept) == "" {
+		if len(candidates) == 0 {
+			return nil, notAcceptable(candidates)
+		}
+		return candidates[0], nil
+	}
+
+	ranges := parseAccept(accept)
+	var best Codec
+	bestQ, bestIndex := 0.0, len(ranges)
+	for _, codec := range candidates {
+		q, index := matchAccept(ranges, codec.MediaType())
+		// higher quality first, then the range the client listed first
+		if q > bestQ || (q == bestQ && q > 0 && index < bestIndex) {
+			best, bestQ, bestIndex = codec, q, index
+		}
+	}
+	if best == nil {
+		return nil, notAcceptable(candidates)
+	}
+	return best, nil
+}
+
+type mediaRange struct {
+	typ, subtype string
+	q            float64
+}
+
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		value, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		typ, subtype, ok := strings.Cut(strings.ToLower(strings.TrimSpace(value)), "/")
+		if !ok {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			if value, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		ranges = append(ranges, mediaRange{typ: typ, subtype: subtype, q: q})
+	}
+	return ranges
+}
+
+// matchAccept returns the quality of mediaType from its most specific matching range
+// and that range's position, so text/csv;q=0 excludes CSV even with */*
+func matchAccept(ranges []mediaRange, mediaType string) (float64, int) {
+	typ, subtype, _ := strings.Cut(mediaType, "/")
+	q, index, specificity := 0.0, len(ranges), -1
+	for i, rng := range ranges {
+		s := -1
+		switch {
+		case rng.typ == typ && rng.subtype == subtype:
+			s = 2
+		case rng.typ == typ && rng.subtype == "*":
+			s = 1
+		case rng.typ == "*" && rng.subtype == "*":
+			s = 0
+		}
+		if s > specificity {
+			q, index, specificity = rng.q, i, s
+		}
+	}
+	return q, index
+}
+
+func notAcceptable(list []Codec) *exception.ExceptionError {
+	cErr := exception.DefaultCatalog().Get("NotAcceptable")
+	if cErr == nil {
+		cErr = exception.NewExceptionError(http.StatusBadRequest, 210002, "Requested media type is not available", http.StatusNotAcceptable)
+	}
+	return cErr.WithDatas(map[string]string{"supported": mediaTypesOf(list)})
+}
+
+func unsupportedMediaType(list []Codec) *exception.ExceptionError {
+	cErr := exception.DefaultCatalog().Get("UnsupportedMediaType")
+	if cErr == nil {
+		cErr = exception.NewExceptionError(http.StatusBadRequest, 210003, "Unsupported request media type", http.StatusUnsupportedMediaType)
+	}
+	return cErr.WithDatas(map[string]string{"supported": mediaTypesOf(list)})
+}
+
+func unknownFields(err *UnknownFieldError) *exception.ExceptionError {
+	cErr := exception.DefaultCatalog().Get("UnknownFields")
+	if cErr == nil {
+		cErr = exception.NewExceptionError(http.StatusBadRequest, 210004, "Request has unknown fields", http.StatusUnprocessableEntity)
+	}
+	return cErr.WithFields([]string{err.Field}).WithDatas(map[string]string{err.Field: "unknown field"}).Wrap(err)
+}
+
+// encodeResponse encodes v with codec, buffered so an encoding error can still become a 500
+func encodeResponse(codec Codec, v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := codec.Encode(&buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// JSONCodec encodes application/json with encoding/json
+type JSONCodec struct{}
+
+func (JSONCodec) MediaType() string { return MediaTypeJSON }
+
+func (JSONCodec) Decode(data []byte, v any) error { return json.Unmarshal(data, v) }
+
+func (JSONCodec) Encode(w io.Writer, v any) error { return json.NewEncoder(w).Encode(v) }
+
+// DecodeStrict decodes like Decode but fails on the first unknown field with an UnknownFieldError
+func (JSONCodec) DecodeStrict(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		// encoding/json reports unknown fields only in the message: json: unknown field "name"
+		if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+			if name, uErr := strconv.Unquote(field); uErr == nil {
+				return &UnknownFieldError{Field: name}
+			}
+		}
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("json: invalid data after top-level value")
+	}
+	return nil
+}
+
+// XMLCodec encodes application/xml with encoding/xml; the root element is the type name
+// unless the type has an XMLName field. Maps are not supported.
+type XMLCodec struct{}
+
+func (XMLCodec) MediaType() string { return MediaTypeXML }
+
+func (XMLCodec) Decode(data []byte, v any) error { return xml.Unmarshal(data, v) }
+
+func (XMLCodec) Encode(w io.Writer, v any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	return xml.NewEncoder(w).Encode(v)
+}
+
+// MsgpackCodec encodes application/msgpack, naming fields by their json tags
+// so the keys match the JSON representation
+type MsgpackCodec struct{}
+
+func (MsgpackCodec) MediaType() string { return MediaTypeMsgpack }
+
+func (MsgpackCodec) Decode(data []byte, v any) error {
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.SetCustomStructTag("json")
+	return dec.Decode(v)
+}
+
+// DecodeStrict decodes like Decode but fails on unknown fields with an UnknownFieldError
+func (MsgpackCodec) DecodeStrict(data []byte, v any) error {
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.SetCustomStructTag("json")
+	dec.DisallowUnknownFields(true)
+	err := dec.Decode(v)
+	// msgpack: unknown field "name"
+	if err != nil {
+		if _, field, ok := strings.Cut(err.Error(), "unknown field "); ok {
+			if name, uErr := strconv.Unquote(field); uErr == nil {
+				return &UnknownFieldError{Field: name}
+			}
+		}
+	}
+	return err
+}
+
+func (MsgpackCodec) Encode(w io.Writer, v any) error {
+	enc := msgpack.NewEncoder(w)
+	enc.SetCustomStructTag("json")
+	return enc.Encode(v)
+}
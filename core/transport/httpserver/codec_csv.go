@@ -0,0 +1,275 @@
+package httpserver
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CSVCodec encodes tabular data as text/csv with a header row, for exports.
+// The rows are the value itself when it is a slice of structs, otherwise the first
+// exported slice of structs field, e.g. Data in {Status, Data []Item}. Columns are
+// named by `csv` tags, falling back to json names; `csv:"-"` skips a field.
+// Nested values are written as JSON.
+type CSVCodec struct{}
+
+var timeType = reflect.TypeFor[time.Time]()
+
+func (CSVCodec) MediaType() string { return MediaTypeCSV }
+
+// Supports reports whether t holds rows
+func (CSVCodec) Supports(t reflect.Type) bool {
+	_, ok := csvRowsIndex(t)
+	return ok
+}
+
+func (CSVCodec) Encode(w io.Writer, v any) error {
+	rows, ok := csvRows(reflect.ValueOf(v))
+	if !ok {
+		return fmt.Errorf("csv: %T has no rows: %w", v, ErrUnsupportedValue)
+	}
+	columns := csvColumns(rowType(rows.Type()))
+
+	cw := csv.NewWriter(w)
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = column.name
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	record := make([]string, len(columns))
+	for i := 0; i < rows.Len(); i++ {
+		row := reflect.Indirect(rows.Index(i))
+		for j, column := range columns {
+			if !row.IsValid() {
+				record[j] = ""
+				continue
+			}
+			cell, err := formatCell(row.FieldByIndex(column.index))
+			if err != nil {
+				return err
+			}
+			record[j] = cell
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Decode reads the rows of data into v, matching the header row to columns
+func (CSVCodec) Decode(data []byte, v any) error {
+	target := reflect.ValueOf(v)
+	if target.Kind() != reflect.Ptr || target.IsNil() {
+		return fmt.Errorf("csv: decode needs a non-nil pointer, got %T: %w", v, ErrUnsupportedValue)
+	}
+	rows, ok := csvRows(target)
+	if !ok || !rows.CanSet() {
+		return fmt.Errorf("csv: %T has no rows: %w", v, ErrUnsupportedValue)
+	}
+
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return nil
+	}
+
+	elem := rows.Type().Elem()
+	byName := map[string]csvColumn{}
+	for _, column := range csvColumns(rowType(rows.Type())) {
+		byName[column.name] = column
+	}
+	header := records[0]
+
+	for line, record := range records[1:] {
+		row := reflect.New(rowType(rows.Type())).Elem()
+		for i, cell := range record {
+			if i >= len(header) {
+				break
+			}
+			column, ok := byName[strings.TrimSpace(header[i])]
+			if !ok {
+				continue
+			}
+			if err := parseCell(row.FieldByIndex(column.index), cell); err != nil {
+				return fmt.Errorf("csv: line %d, column %q: %w", line+2, column.name, err)
+			}
+		}
+		if elem.Kind() == reflect.Ptr {
+			row = row.Addr()
+		}
+		rows.Set(reflect.Append(rows, row))
+	}
+	return nil
+}
+
+type csvColumn struct {
+	name  string
+	index []int
+}
+
+// csvRowsIndex returns the field index of the rows in t, nil when t itself is the rows
+func csvRowsIndex(t reflect.Type) ([]int, bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if isRowSlice(t) {
+		return nil, true
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.IsExported() && isRowSlice(field.Type) {
+			return field.Index, true
+		}
+	}
+	return nil, false
+}
+
+func csvRows(v reflect.Value) (reflect.Value, bool) {
+	if !v.IsValid() {
+		return reflect.Value{}, false
+	}
+	index, ok := csvRowsIndex(v.Type())
+	if !ok {
+		return reflect.Value{}, false
+	}
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return reflect.Value{}, false
+		}
+		v = v.Elem()
+	}
+	if index != nil {
+		v = v.FieldByIndex(index)
+	}
+	return v, true
+}
+
+func isRowSlice(t reflect.Type) bool {
+	return (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && rowType(t).Kind() == reflect.Struct && rowType(t) != timeType
+}
+
+func rowType(slice reflect.Type) reflect.Type {
+	elem := slice.Elem()
+	for elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	return elem
+}
+
+func csvColumns(t reflect.Type) []csvColumn {
+	var columns []csvColumn
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("csv"), ",")
+		if name == "" {
+			name, _, _ = strings.Cut(field.Tag.Get("json"), ",")
+		}
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		columns = append(columns, csvColumn{name: name, index: field.Index})
+	}
+	return columns
+}
+
+func formatCell(v reflect.Value) (string, error) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+	if v.Type() == timeType {
+		return v.Interface().(time.Time).Format(time.RFC3339Nano), nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	}
+	data, err := json.Marshal(v.Interface())
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func parseCell(v reflect.Value, cell string) error {
+	if cell == "" {
+		return nil
+	}
+	if v.Kind() == reflect.Ptr {
+		v.Set(reflect.New(v.Type().Elem()))
+		v = v.Elem()
+	}
+	if v.Type() == timeType {
+		parsed, err := time.Parse(time.RFC3339Nano, cell)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(parsed))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(cell)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(cell)
+		if err != nil {
+			return err
+		}
+		v.SetBool(parsed)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(cell, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(parsed)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parsed, err := strconv.ParseUint(cell, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(parsed)
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(cell, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(parsed)
+	default:
+		return json.Unmarshal([]byte(cell), v.Addr().Interface())
+	}
+	return nil
+}
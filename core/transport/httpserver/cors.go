@@ -0,0 +1,90 @@
+package httpserver
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/rs/cors"
+	core_config "github.com/yourorg/go-api-template/core/config"
+)
+
+// corsRoute is a CORS handler serving the paths under prefix
+type corsRoute struct {
+	prefix  string
+	handler *cors.Cors
+}
+
+// CORSMiddleware applies the CORS policy of cfg, or the policy of the longest route prefix
+// matching the request path. It runs before routing so preflight requests, which no route
+// registers, get the policy of the route they are for.
+func CORSMiddleware(cfg core_config.CORS) func(http.Handler) http.Handler {
+	global := newCORS(cfg.CORSPolicy)
+
+	routes := make([]corsRoute, 0, len(cfg.Routes))
+	for _, route := range cfg.Routes {
+		routes = append(routes, corsRoute{
+			prefix:  strings.TrimSuffix(route.PathPrefix, "/"),
+			handler: newCORS(inheritCORSPolicy(cfg.CORSPolicy, route.CORSPolicy)),
+		})
+	}
+	// longest prefix first, so the first match is the most specific one
+	slices.SortStableFunc(routes, func(a, b corsRoute) int { return len(b.prefix) - len(a.prefix) })
+
+	return func(next http.Handler) http.Handler {
+		globalHandler := global.Handler(next)
+		routeHandlers := make([]http.Handler, len(routes))
+		for i, route := range routes {
+			routeHandlers[i] = route.handler.Handler(next)
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for i, route := range routes {
+				if matchPathPrefix(r.URL.Path, route.prefix) {
+					routeHandlers[i].ServeHTTP(w, r)
+					return
+				}
+			}
+			globalHandler.ServeHTTP(w, r)
+		})
+	}
+}
+
+func newCORS(policy core_config.CORSPolicy) *cors.Cors {
+	return cors.New(cors.Options{
+		AllowedOrigins: policy.AllowedOrigins,
+		AllowedMethods: policy.AllowedMethods,
+		AllowedHeaders: policy.AllowedHeaders,
+		ExposedHeaders: policy.ExposedHeaders,
+		MaxAge:         policy.MaxAge,
+	})
+}
+
+// inheritCORSPolicy returns route with its unset fields taken from global
+func inheritCORSPolicy(global, route core_config.CORSPolicy) core_config.CORSPolicy {
+	if route.AllowedMethods == nil {
+		route.AllowedMethods = global.AllowedMethods
+	}
+	if route.AllowedHeaders == nil {
+		route.AllowedHeaders = global.AllowedHeaders
+	}
+	if route.AllowedOrigins == nil {
+		route.AllowedOrigins = global.AllowedOrigins
+	}
+	if route.ExposedHeaders == nil {
+		route.ExposedHeaders = global.ExposedHeaders
+	}
+	if route.MaxAge == 0 {
+		route.MaxAge = global.MaxAge
+	}
+	return route
+}
+
+// matchPathPrefix reports whether path is prefix or below it, /api/admin matching
+// /api/admin/users but not /api/administrators
+func matchPathPrefix(path, prefix string) bool {
+	if prefix == "" {
+		return true
+	}
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
@@ -2,15 +2,69 @@ package httpserver
 
 import (
 	"encoding/json"
+	"errors"
+	"maps"
 	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/yourorg/go-api-template/core/exception"
+	"github.com/yourorg/go-api-template/core/i18n"
+	middleware "github.com/yourorg/go-api-template/core/transport/httpserver/middlewares"
+	"github.com/yourorg/go-api-template/core/validation"
 )
 
 type ModelResp struct {
-	Status  int    `json:"status"`
-	Message string `json:"message"`
+	Status    int    `json:"status"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id,omitempty"`
+	TraceID   string `json:"trace_id,omitempty"`
+}
+
+// ErrorFormat selects how error responses are rendered
+type ErrorFormat string
+
+const (
+	// ErrorFormatDefault renders {"status", "message", "fields", "data"}
+	ErrorFormatDefault ErrorFormat = "default"
+	// ErrorFormatProblem renders RFC 7807 application/problem+json
+	ErrorFormatProblem ErrorFormat = "problem"
+)
+
+type errorFormatOptions struct {
+	format          ErrorFormat
+	problemTypeBase string
+}
+
+var errorFormat atomic.Pointer[errorFormatOptions]
+
+// SetErrorFormat sets the error response format for all transports.
+// problemTypeBase is the URI prefix used for the problem "type" member, e.g. https://example.com/errors.
+func SetErrorFormat(format ErrorFormat, problemTypeBase string) {
+	if format == "" {
+		format = ErrorFormatDefault
+	}
+	errorFormat.Store(&errorFormatOptions{format: format, problemTypeBase: problemTypeBase})
+}
+
+func currentErrorFormat() errorFormatOptions {
+	if opts := errorFormat.Load(); opts != nil {
+		return *opts
+	}
+	return errorFormatOptions{format: ErrorFormatDefault}
 }
 
-func HandleInternalServerError(w http.ResponseWriter, httpStatusCode int) {
+// HandleInternalServerError renders a generic error with httpStatusCode, with the request and
+// trace IDs of r
+func HandleInternalServerError(w http.ResponseWriter, r *http.Request, httpStatusCode int) {
+	if opts := currentErrorFormat(); opts.format == ErrorFormatProblem {
+		writeProblem(w, r, exception.ProblemDetails{
+			Title:  http.StatusText(httpStatusCode),
+			Status: httpStatusCode,
+		})
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(httpStatusCode)
 
@@ -18,6 +72,137 @@ func HandleInternalServerError(w http.ResponseWriter, httpStatusCode int) {
 		Status:  httpStatusCode,
 		Message: "Internal Server Error",
 	}
+	resp.RequestID, resp.TraceID = errorIDs(r)
 
 	json.NewEncoder(w).Encode(resp)
 }
+
+// ErrorHandler renders an error response, see Router.SetErrorHandler
+type ErrorHandler func(w http.ResponseWriter, r *http.Request, exErr *exception.ExceptionError)
+
+type errorHandlerKey struct{}
+
+// WriteError renders exErr in the configured error format, for handlers outside the transport
+// such as middleware_httpserver.RecoveryMiddleware
+func WriteError(w http.ResponseWriter, r *http.Request, exErr *exception.ExceptionError) {
+	writeExceptionError(w, r, exErr)
+}
+
+// writeExceptionError renders exErr with the error handler of the router, if any
+func writeExceptionError(w http.ResponseWriter, r *http.Request, exErr *exception.ExceptionError) {
+	if handler, ok := r.Context().Value(errorHandlerKey{}).(ErrorHandler); ok && handler != nil {
+		handler(w, r, exErr)
+		return
+	}
+	DefaultErrorHandler(w, r, exErr)
+}
+
+// DefaultErrorHandler renders exErr in the configured format, see SetErrorFormat.
+// Custom error handlers may call it for the errors they do not change.
+func DefaultErrorHandler(w http.ResponseWriter, r *http.Request, exErr *exception.ExceptionError) {
+	if opts := currentErrorFormat(); opts.format == ErrorFormatProblem {
+		localized := *exErr
+		localized.ErrWithDatas = localizedDatas(r, exErr)
+		problem := localized.ToProblem(opts.problemTypeBase, r.URL.Path)
+		problem.Title = exErr.LocalizedMessage(requestLocale(r))
+		if exception.DebugEnabled() {
+			problem.Extensions["debug_message"] = exErr.DebugMessage
+			problem.Extensions["stack"] = stackHint(exErr)
+		}
+		writeProblem(w, r, problem)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(exErr.HttpStatusCode)
+	// Build error response with all available fields
+	errorResponse := errorResp{
+		Status:  exErr.APIStatusCode,
+		Message: exErr.LocalizedMessage(requestLocale(r)),
+		Fields:  exErr.ErrFields,
+		Data:    localizedDatas(r, exErr),
+	}
+	errorResponse.RequestID, errorResponse.TraceID = errorIDs(r)
+	// debug details are only exposed outside production, see core_config.Config.DebugEnabled
+	if exception.DebugEnabled() {
+		errorResponse.DebugMessage = exErr.DebugMessage
+		errorResponse.Stack = stackHint(exErr)
+	}
+	json.NewEncoder(w).Encode(errorResponse)
+}
+
+// stackHintFrames is how many frames of the error stack are included in debug responses
+const stackHintFrames = 5
+
+// stackHint returns the top frames of the error stack as "function file:line"
+func stackHint(exErr *exception.ExceptionError) []string {
+	if exErr.StackErrors == nil {
+		return nil
+	}
+
+	lines := strings.Split(exception.GetStackField(exErr.StackErrors).Stack, "\n")
+	frames := make([]string, 0, stackHintFrames)
+	for i := 0; i+1 < len(lines) && len(frames) < stackHintFrames; i += 2 {
+		frames = append(frames, strings.TrimSpace(lines[i])+" "+strings.TrimSpace(lines[i+1]))
+	}
+	return frames
+}
+
+// requestLocale returns the locale negotiated by i18n.Middleware, or else the first language
+// tag of Accept-Language, e.g. "th-TH"
+func requestLocale(r *http.Request) string {
+	if locale, ok := i18n.LocaleFromContext(r.Context()); ok {
+		return locale
+	}
+	tag, _, _ := strings.Cut(r.Header.Get("Accept-Language"), ",")
+	tag, _, _ = strings.Cut(tag, ";")
+	return strings.TrimSpace(tag)
+}
+
+// localizedDatas returns the data of exErr with the messages of its validation.Errors in the
+// locale negotiated by i18n.Middleware
+func localizedDatas(r *http.Request, exErr *exception.ExceptionError) map[string]string {
+	var fieldErrs validation.Errors
+	if _, ok := i18n.LocaleFromContext(r.Context()); !ok || !errors.As(exErr.StackErrors, &fieldErrs) {
+		return exErr.ErrWithDatas
+	}
+
+	datas := maps.Clone(exErr.ErrWithDatas)
+	if datas == nil {
+		datas = make(map[string]string, len(fieldErrs))
+	}
+	for _, fe := range fieldErrs {
+		if fe.Key == "" {
+			continue
+		}
+		if msg, ok := i18n.Translate(r.Context(), fe.Key, fe.Params()); ok {
+			datas[fe.Field] = msg
+		}
+	}
+	return datas
+}
+
+// errorIDs returns the request ID of r and its trace ID when the trace is sampled, the IDs
+// users quote to find the logs and trace of a failed request
+func errorIDs(r *http.Request) (requestID string, traceID string) {
+	requestID, _ = middleware.GetRequestIDFromContext(r.Context())
+	traceID, _ = middleware.GetTraceIDFromContext(r.Context())
+	return requestID, traceID
+}
+
+// writeProblem renders problem with the request_id and trace_id members of r
+func writeProblem(w http.ResponseWriter, r *http.Request, problem exception.ProblemDetails) {
+	requestID, traceID := errorIDs(r)
+	if problem.Extensions == nil && (requestID != "" || traceID != "") {
+		problem.Extensions = map[string]any{}
+	}
+	if requestID != "" {
+		problem.Extensions["request_id"] = requestID
+	}
+	if traceID != "" {
+		problem.Extensions["trace_id"] = traceID
+	}
+	w.Header().Set("Content-Type", exception.ProblemContentType)
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem)
+}
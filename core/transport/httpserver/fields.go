@@ -0,0 +1,133 @@
+package httpserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// FieldsQueryParam selects the response fields sent to the client, e.g.
+// ?fields=data.id,data.name keeps only those two fields of data. Paths follow the
+// json field names and apply to every element of an array.
+const FieldsQueryParam = "fields"
+
+// fieldTree is a parsed fields selection; a nil subtree keeps the whole field
+type fieldTree map[string]fieldTree
+
+// parseFields parses "a.b,a.c,d" into {a: {b: nil, c: nil}, d: nil}, nil for an empty selection
+func parseFields(query string) fieldTree {
+	var tree fieldTree
+	for _, path := range strings.Split(query, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		if tree == nil {
+			tree = fieldTree{}
+		}
+
+		node := tree
+		names := strings.Split(path, ".")
+		for i, name := range names {
+			sub, seen := node[name]
+			if seen && sub == nil {
+				// the whole field is already selected
+				break
+			}
+			if i == len(names)-1 {
+				node[name] = nil
+				break
+			}
+			if sub == nil {
+				sub = fieldTree{}
+				node[name] = sub
+			}
+			node = sub
+		}
+	}
+	return tree
+}
+
+// selectFields prunes v to the fields requested with FieldsQueryParam. Only JSON and msgpack
+// responses are pruned, other encodings have no field names to select by.
+func selectFields(r *http.Request, codec Codec, v any) (any, error) {
+	query := r.URL.Query().Get(FieldsQueryParam)
+	if query == "" || isNilPointer(v) {
+		return v, nil
+	}
+	if mediaType := codec.MediaType(); mediaType != MediaTypeJSON && mediaType != MediaTypeMsgpack &&
+		!strings.HasSuffix(mediaType, "+json") {
+		return v, nil
+	}
+	tree := parseFields(query)
+	if tree == nil {
+		return v, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	// keeps large integers exact
+	dec.UseNumber()
+	if err := dec.Decode(&generic); err != nil {
+		return nil, err
+	}
+	pruned := pruneFields(generic, tree)
+	if codec.MediaType() == MediaTypeMsgpack {
+		// json.Number is a string to other encoders
+		pruned = decodeNumbers(pruned)
+	}
+	return pruned, nil
+}
+
+// decodeNumbers replaces the json.Number values of v with int64 or float64
+func decodeNumbers(v any) any {
+	switch value := v.(type) {
+	case map[string]any:
+		for name, field := range value {
+			value[name] = decodeNumbers(field)
+		}
+	case []any:
+		for i, elem := range value {
+			value[i] = decodeNumbers(elem)
+		}
+	case json.Number:
+		if n, err := value.Int64(); err == nil {
+			return n
+		}
+		if f, err := value.Float64(); err == nil {
+			return f
+		}
+	}
+	return v
+}
+
+func pruneFields(v any, tree fieldTree) any {
+	switch value := v.(type) {
+	case map[string]any:
+		pruned := make(map[string]any, len(tree))
+		for name, sub := range tree {
+			field, ok := value[name]
+			if !ok {
+				continue
+			}
+			if sub == nil {
+				pruned[name] = field
+			} else {
+				pruned[name] = pruneFields(field, sub)
+			}
+		}
+		return pruned
+	case []any:
+		for i, elem := range value {
+			value[i] = pruneFields(elem, tree)
+		}
+		return value
+	}
+	// a scalar has no fields to select
+	return v
+}
@@ -0,0 +1,111 @@
+package httpserver
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/yourorg/go-api-template/core/validation"
+)
+
+// SortField is one key of a ?sort=-created_at,name parameter
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// ParseSort parses a comma separated list of fields, each optionally prefixed
+// with - for descending or + for ascending order
+func ParseSort(value string) ([]SortField, error) {
+	var fields []SortField
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		field := SortField{Field: part}
+		if name, ok := strings.CutPrefix(part, "-"); ok {
+			field = SortField{Field: name, Desc: true}
+		} else if name, ok := strings.CutPrefix(part, "+"); ok {
+			field.Field = name
+		}
+		if field.Field == "" {
+			return nil, validation.Errors{{Field: "sort", Rule: "sort", Message: "has an empty field"}}
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// Filter operators of ?filter[field][op]=value
+const (
+	FilterEq   = "eq"
+	FilterNe   = "ne"
+	FilterGt   = "gt"
+	FilterGte  = "gte"
+	FilterLt   = "lt"
+	FilterLte  = "lte"
+	FilterLike = "like"
+	FilterIn   = "in"
+)
+
+var filterOps = []string{FilterEq, FilterNe, FilterGt, FilterGte, FilterLt, FilterLte, FilterLike, FilterIn}
+
+// Filter is one ?filter[field]=value (eq) or ?filter[field][op]=value expression
+type Filter struct {
+	Field string
+	Op    string
+	Value string
+}
+
+// Values splits the value of an "in" filter, e.g. filter[status][in]=active,pending
+func (f Filter) Values() []string {
+	return strings.Split(f.Value, ",")
+}
+
+// ParseFilters parses the filter[...] parameters of values, sorted by field and operator
+func ParseFilters(values url.Values) ([]Filter, error) {
+	var filters []Filter
+	var fieldErrs validation.Errors
+	for key, vals := range values {
+		rest, ok := strings.CutPrefix(key, "filter[")
+		if !ok {
+			continue
+		}
+
+		field, rest, _ := strings.Cut(rest, "]")
+		op := FilterEq
+		if rest != "" {
+			inner, ok := strings.CutPrefix(rest, "[")
+			if !ok || !strings.HasSuffix(inner, "]") {
+				fieldErrs = append(fieldErrs, validation.FieldError{Field: key, Rule: "filter", Message: "must be filter[field] or filter[field][op]"})
+				continue
+			}
+			op = strings.TrimSuffix(inner, "]")
+		}
+		if field == "" {
+			fieldErrs = append(fieldErrs, validation.FieldError{Field: key, Rule: "filter", Message: "has an empty field"})
+			continue
+		}
+		if !slices.Contains(filterOps, op) {
+			fieldErrs = append(fieldErrs, validation.FieldError{Field: key, Rule: "filter",
+				Message: "must use one of " + strings.Join(filterOps, ", ")})
+			continue
+		}
+		for _, value := range vals {
+			filters = append(filters, Filter{Field: field, Op: op, Value: value})
+		}
+	}
+	if len(fieldErrs) > 0 {
+		return nil, fieldErrs
+	}
+
+	// map iteration order is random, keep the result stable
+	slices.SortStableFunc(filters, func(a, b Filter) int {
+		if c := strings.Compare(a.Field, b.Field); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Op, b.Op)
+	})
+	return filters, nil
+}
@@ -0,0 +1,49 @@
+package httpserver
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const meterName = "github.com/yourorg/go-api-template/core/transport/httpserver"
+
+var (
+	errorCounter     metric.Int64Counter
+	errorCounterOnce sync.Once
+)
+
+// recordError counts an error response by exception code, HTTP status and route pattern.
+// code is 0 for errors that are not an ExceptionError.
+func recordError(ctx context.Context, r *http.Request, code int32, httpStatusCode int) {
+	errorCounterOnce.Do(func() {
+		// instruments from the global meter follow a provider installed later
+		errorCounter, _ = otel.Meter(meterName).Int64Counter(
+			"http.server.errors",
+			metric.WithDescription("Number of error responses rendered by the transport"),
+			metric.WithUnit("{error}"),
+		)
+	})
+	if errorCounter == nil {
+		return
+	}
+
+	errorCounter.Add(ctx, 1, metric.WithAttributes(
+		attribute.Int("error.code", int(code)),
+		attribute.Int("http.response.status_code", httpStatusCode),
+		attribute.String("http.route", routePattern(r)),
+	))
+}
+
+// routePattern returns the ServeMux pattern that matched the request, e.g. "POST /api/v1/products".
+// The raw path is not used so the label cardinality stays bounded.
+func routePattern(r *http.Request) string {
+	if r.Pattern != "" {
+		return r.Pattern
+	}
+	return "unmatched"
+}
@@ -12,9 +12,10 @@ import (
 
 // UserClaims represents the claims structure for JWT tokens
 type UserClaims struct {
-	UserID string   `json:"user_id"`
-	Email  string   `json:"email"`
-	Roles  []string `json:"roles"`
+	UserID   string   `json:"user_id"`
+	TenantID string   `json:"tenant_id,omitempty"`
+	Email    string   `json:"email"`
+	Roles    []string `json:"roles"`
 	jwt.RegisteredClaims
 }
 
@@ -55,13 +56,7 @@ func AuthMiddleware(config AuthConfig) func(http.Handler) http.Handler {
 			}
 
 			// Parse and validate the JWT token
-			token, err := jwt.ParseWithClaims(tokenString, &UserClaims{}, func(token *jwt.Token) (interface{}, error) {
-				// Validate the signing method
-				if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-					return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-				}
-				return []byte(config.JWTSecretKey), nil
-			})
+			token, err := parseToken(tokenString, config.JWTSecretKey)
 
 			if err != nil {
 				if logger.Slog != nil {
@@ -85,6 +80,11 @@ func AuthMiddleware(config AuthConfig) func(http.Handler) http.Handler {
 			ctx := context.WithValue(r.Context(), "user_id", claims.UserID)
 			ctx = context.WithValue(ctx, "user_email", claims.Email)
 			ctx = context.WithValue(ctx, "user_roles", claims.Roles)
+			if claims.TenantID != "" {
+				ctx = WithTenantID(ctx, claims.TenantID)
+			}
+			// the verified user and tenant are now part of the span, baggage and logs
+			ctx = WithTraceIdentity(ctx)
 
 			// Continue with the authenticated request
 			next.ServeHTTP(w, r.WithContext(ctx))
@@ -162,6 +162,17 @@ func shouldSkipAuth(path string, skipPaths []string) bool {
 	return false
 }
 
+// parseToken parses and validates a JWT signed with HMAC and secretKey
+func parseToken(tokenString string, secretKey string) (*jwt.Token, error) {
+	return jwt.ParseWithClaims(tokenString, &UserClaims{}, func(token *jwt.Token) (interface{}, error) {
+		// Validate the signing method
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(secretKey), nil
+	})
+}
+
 func extractBearerToken(authHeader string) string {
 	const bearerPrefix = "Bearer "
 	if strings.HasPrefix(authHeader, bearerPrefix) {
@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/yourorg/go-api-template/core/logger"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Span attributes and baggage members identifying the caller of a request
+const (
+	BaggageRequestID = "request_id"
+	BaggageUserID    = "user_id"
+	BaggageTenantID  = "tenant_id"
+	// TenantIDHeader is the default header naming the tenant of calls without a tenant claim
+	TenantIDHeader = "X-Tenant-ID"
+)
+
+// TenantIDKey is the key used to store the tenant ID in context
+type TenantIDKey string
+
+// TenantIDContextKey is the context key for the tenant ID
+const TenantIDContextKey TenantIDKey = "tenant_id"
+
+// GetTenantIDFromContext extracts the tenant ID from context
+func GetTenantIDFromContext(ctx context.Context) (string, bool) {
+	tenantID, ok := ctx.Value(TenantIDContextKey).(string)
+	return tenantID, ok && tenantID != ""
+}
+
+// WithTenantID sets the tenant ID in context
+func WithTenantID(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, TenantIDContextKey, tenantID)
+}
+
+// TraceBaggageConfig configures TraceBaggageMiddleware
+type TraceBaggageConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// TenantHeader names the tenant when the token has no tenant_id claim; default X-Tenant-ID
+	TenantHeader string `mapstructure:"tenantHeader"`
+}
+
+// TraceBaggageMiddleware attaches the request ID and tenant to the request span, the baggage
+// propagated to outbound calls and the log fields. Place it after RequestIDMiddleware;
+// AuthMiddleware adds the user once the token is verified.
+func TraceBaggageMiddleware(config TraceBaggageConfig) TransportMiddleware {
+	if config.TenantHeader == "" {
+		config.TenantHeader = TenantIDHeader
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			if _, ok := GetTenantIDFromContext(ctx); !ok {
+				if tenantID := r.Header.Get(config.TenantHeader); tenantID != "" {
+					ctx = WithTenantID(ctx, tenantID)
+				}
+			}
+			next.ServeHTTP(w, r.WithContext(WithTraceIdentity(ctx)))
+		})
+	}
+}
+
+// WithTraceIdentity copies the request ID, user ID and tenant ID of ctx to the current span,
+// the baggage and the log fields. Identity members received from the client are dropped,
+// so downstream services only see what this server verified.
+func WithTraceIdentity(ctx context.Context) context.Context {
+	identity := map[string]string{}
+	if requestID, ok := GetRequestIDFromContext(ctx); ok && requestID != "" {
+		identity[BaggageRequestID] = requestID
+	}
+	if userID, ok := GetUserIDFromContext(ctx); ok && userID != "" {
+		identity[BaggageUserID] = userID
+	}
+	if tenantID, ok := GetTenantIDFromContext(ctx); ok {
+		identity[BaggageTenantID] = tenantID
+	}
+
+	bag := baggage.FromContext(ctx)
+	attrs := make([]attribute.KeyValue, 0, len(identity))
+	fields := make(map[string]interface{}, len(identity))
+	for _, key := range []string{BaggageRequestID, BaggageUserID, BaggageTenantID} {
+		value, ok := identity[key]
+		if !ok {
+			bag = bag.DeleteMember(key)
+			continue
+		}
+		if member, err := baggage.NewMemberRaw(key, value); err == nil {
+			if withMember, err := bag.SetMember(member); err == nil {
+				bag = withMember
+			}
+		}
+		attrs = append(attrs, attribute.String(key, value))
+		fields[key] = value
+	}
+
+	trace.SpanFromContext(ctx).SetAttributes(attrs...)
+	ctx = logger.AddFieldsToContext(ctx, fields)
+	return baggage.ContextWithBaggage(ctx, bag)
+}
+
+// BaggageSpanProcessor copies the identity baggage members to every span started in the
+// request, e.g. pgx queries and outbound HTTP calls, so they can be filtered by user or tenant.
+// Register it with sdktrace.WithSpanProcessor when building the tracer provider.
+type BaggageSpanProcessor struct{}
+
+var _ sdktrace.SpanProcessor = BaggageSpanProcessor{}
+
+// OnStart sets the identity members of the parent context on s
+func (BaggageSpanProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	bag := baggage.FromContext(parent)
+	for _, key := range []string{BaggageRequestID, BaggageUserID, BaggageTenantID} {
+		if value := bag.Member(key).Value(); value != "" {
+			s.SetAttributes(attribute.String(key, value))
+		}
+	}
+}
+
+func (BaggageSpanProcessor) OnEnd(sdktrace.ReadOnlySpan)      {}
+func (BaggageSpanProcessor) Shutdown(context.Context) error   { return nil }
+func (BaggageSpanProcessor) ForceFlush(context.Context) error { return nil }
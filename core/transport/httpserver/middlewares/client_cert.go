@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"net/http"
+	"slices"
+
+	"github.com/yourorg/go-api-template/core/logger"
+)
+
+// ClientIdentityKey is the type for the client identity context key
+type ClientIdentityKey string
+
+// ClientIdentityContextKey is the context key for the mTLS client identity
+const ClientIdentityContextKey ClientIdentityKey = "client_identity"
+
+// ClientIdentity describes the verified certificate a client presented
+type ClientIdentity struct {
+	CommonName   string
+	Organization []string
+	DNSNames     []string
+	URIs         []string // e.g. SPIFFE IDs
+	Emails       []string
+	SerialNumber string
+	Issuer       string
+	// Fingerprint is the hex SHA-256 of the certificate, stable for pinning
+	Fingerprint string
+}
+
+// Names returns the common name and every SAN, the values authorization rules match against
+func (id ClientIdentity) Names() []string {
+	names := make([]string, 0, 1+len(id.DNSNames)+len(id.URIs)+len(id.Emails))
+	if id.CommonName != "" {
+		names = append(names, id.CommonName)
+	}
+	names = append(names, id.DNSNames...)
+	names = append(names, id.URIs...)
+	return append(names, id.Emails...)
+}
+
+// ClientCertMiddleware puts the identity of a verified client certificate into the request context.
+// Certificates the TLS handshake did not verify (client auth mode "request") are ignored.
+func ClientCertMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			identity := newClientIdentity(r.TLS.VerifiedChains[0][0])
+			next.ServeHTTP(w, r.WithContext(WithClientIdentity(r.Context(), identity)))
+		})
+	}
+}
+
+// RequireClientCert creates a middleware that requires a verified client certificate,
+// whose common name or SAN is one of allowedNames when any are given
+func RequireClientCert(allowedNames ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			identity, ok := GetClientIdentityFromContext(r.Context())
+			if !ok {
+				if logger.Slog != nil {
+					logger.Slog.Error("Client certificate required")
+				}
+				http.Error(w, "Unauthorized: Client certificate required", http.StatusUnauthorized)
+				return
+			}
+
+			if len(allowedNames) > 0 && !slices.ContainsFunc(identity.Names(), func(name string) bool {
+				return slices.Contains(allowedNames, name)
+			}) {
+				if logger.Slog != nil {
+					logger.Slog.Error("Client certificate not allowed",
+						"common_name", identity.CommonName,
+						"allowed_names", allowedNames)
+				}
+				http.Error(w, "Forbidden: Client certificate not allowed", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// GetClientIdentityFromContext extracts the mTLS client identity from request context
+func GetClientIdentityFromContext(ctx context.Context) (ClientIdentity, bool) {
+	identity, ok := ctx.Value(ClientIdentityContextKey).(ClientIdentity)
+	return identity, ok
+}
+
+// WithClientIdentity adds a client identity to the context
+func WithClientIdentity(ctx context.Context, identity ClientIdentity) context.Context {
+	return context.WithValue(ctx, ClientIdentityContextKey, identity)
+}
+
+func newClientIdentity(cert *x509.Certificate) ClientIdentity {
+	sum := sha256.Sum256(cert.Raw)
+	identity := ClientIdentity{
+		CommonName:   cert.Subject.CommonName,
+		Organization: cert.Subject.Organization,
+		DNSNames:     cert.DNSNames,
+		Emails:       cert.EmailAddresses,
+		SerialNumber: cert.SerialNumber.String(),
+		Issuer:       cert.Issuer.CommonName,
+		Fingerprint:  hex.EncodeToString(sum[:]),
+	}
+	for _, uri := range cert.URIs {
+		identity.URIs = append(identity.URIs, uri.String())
+	}
+	return identity
+}
@@ -0,0 +1,333 @@
+package middleware
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	EncodingGzip    = "gzip"
+	EncodingDeflate = "deflate"
+
+	// DefaultCompressionMinSize is the smallest response worth compressing
+	DefaultCompressionMinSize = 1024
+)
+
+// DefaultCompressionContentTypes are the media types compressed when none are configured.
+// Event streams are left out so server-sent events are delivered as they are written.
+var DefaultCompressionContentTypes = []string{
+	"application/json",
+	"application/problem+json",
+	"application/xml",
+	"application/javascript",
+	"application/sql",
+	"text/plain",
+	"text/html",
+	"text/css",
+	"text/csv",
+	"text/xml",
+}
+
+// CompressionConfig configures the compression middleware
+type CompressionConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Level is the gzip/flate level, 1 (fastest) to 9 (best); 0 uses the default level
+	Level int `mapstructure:"level"`
+	// MinSize is the response size in bytes below which responses are sent as is
+	MinSize int `mapstructure:"minSize"`
+	// ContentTypes are the compressed media types, e.g. application/json; a trailing "/*" matches a whole type
+	ContentTypes []string `mapstructure:"contentTypes"`
+}
+
+// DefaultCompressionConfig returns a default configuration
+func DefaultCompressionConfig() CompressionConfig {
+	return CompressionConfig{
+		Enabled:      true,
+		Level:        gzip.DefaultCompression,
+		MinSize:      DefaultCompressionMinSize,
+		ContentTypes: DefaultCompressionContentTypes,
+	}
+}
+
+// CompressionMiddleware compresses responses with gzip or deflate, as negotiated by Accept-Encoding.
+// Responses smaller than MinSize, of other content types or already encoded are passed through.
+func CompressionMiddleware(config CompressionConfig) func(http.Handler) http.Handler {
+	if config.Level == 0 || config.Level < flate.HuffmanOnly || config.Level > flate.BestCompression {
+		config.Level = flate.DefaultCompression
+	}
+	if config.MinSize <= 0 {
+		config.MinSize = DefaultCompressionMinSize
+	}
+	if len(config.ContentTypes) == 0 {
+		config.ContentTypes = DefaultCompressionContentTypes
+	}
+	pools := newEncoderPools(config.Level)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+
+			encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+			if encoding == "" || r.Method == http.MethodHead || r.Header.Get("Upgrade") != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressResponseWriter{
+				ResponseWriter: w,
+				config:         &config,
+				encoding:       encoding,
+				pools:          pools,
+			}
+			next.ServeHTTP(cw, r)
+			// not deferred: after a panic the buffered response is dropped so the recovery middleware can answer
+			cw.close()
+		})
+	}
+}
+
+// negotiateEncoding picks gzip or deflate from an Accept-Encoding header, "" when neither is acceptable
+func negotiateEncoding(acceptEncoding string) string {
+	var best string
+	var bestQ float64
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q <= 0 {
+			continue
+		}
+
+		switch coding {
+		case "*":
+			coding = EncodingGzip
+		case EncodingGzip, EncodingDeflate:
+		default:
+			continue
+		}
+		// gzip wins ties, it is the better supported of the two
+		if q > bestQ || (q == bestQ && coding == EncodingGzip) {
+			best, bestQ = coding, q
+		}
+	}
+	return best
+}
+
+type encoderPools struct {
+	gzip  sync.Pool
+	flate sync.Pool
+}
+
+func newEncoderPools(level int) *encoderPools {
+	return &encoderPools{
+		gzip: sync.Pool{New: func() any {
+			zw, _ := gzip.NewWriterLevel(io.Discard, level)
+			return zw
+		}},
+		flate: sync.Pool{New: func() any {
+			fw, _ := flate.NewWriter(io.Discard, level)
+			return fw
+		}},
+	}
+}
+
+// encoder is implemented by *gzip.Writer and *flate.Writer
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// compressResponseWriter buffers the start of the response until MinSize is reached,
+// then decides whether to compress based on the status and headers set by the handler
+type compressResponseWriter struct {
+	http.ResponseWriter
+	config   *CompressionConfig
+	encoding string
+	pools    *encoderPools
+
+	status  int
+	buf     []byte
+	decided bool
+	enc     encoder
+}
+
+func (cw *compressResponseWriter) WriteHeader(statusCode int) {
+	if cw.decided || cw.status != 0 {
+		return
+	}
+	// informational responses are sent right away and do not end the header phase
+	if statusCode >= 100 && statusCode < 200 && statusCode != http.StatusSwitchingProtocols {
+		cw.ResponseWriter.WriteHeader(statusCode)
+		return
+	}
+	cw.status = statusCode
+	// bodiless responses are never compressed
+	if !bodyAllowed(statusCode) {
+		cw.decide(false)
+	}
+}
+
+func (cw *compressResponseWriter) Write(b []byte) (int, error) {
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+	if cw.decided {
+		if cw.enc != nil {
+			return cw.enc.Write(b)
+		}
+		return cw.ResponseWriter.Write(b)
+	}
+
+	cw.buf = append(cw.buf, b...)
+	if len(cw.buf) >= cw.config.MinSize {
+		if err := cw.decide(cw.compressible()); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+// Flush sends what was written so far; a response still under MinSize is not compressed
+func (cw *compressResponseWriter) Flush() {
+	if !cw.decided {
+		if cw.status == 0 {
+			cw.status = http.StatusOK
+		}
+		cw.decide(len(cw.buf) >= cw.config.MinSize && cw.compressible())
+	}
+	if cw.enc != nil {
+		cw.enc.Flush()
+	}
+	http.NewResponseController(cw.ResponseWriter).Flush()
+}
+
+// Hijack passes the connection through uncompressed, e.g. for WebSocket upgrades
+func (cw *compressResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if cw.decided {
+		return nil, nil, errors.New("compression: response already started")
+	}
+	cw.decided = true
+	return http.NewResponseController(cw.ResponseWriter).Hijack()
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (cw *compressResponseWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// compressible reports whether the response headers allow compression
+func (cw *compressResponseWriter) compressible() bool {
+	header := cw.Header()
+	if header.Get("Content-Encoding") != "" || header.Get("Content-Range") != "" {
+		return false
+	}
+	if strings.Contains(header.Get("Cache-Control"), "no-transform") {
+		return false
+	}
+
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(cw.buf)
+		// set it as net/http would, the compressed bytes cannot be sniffed
+		header.Set("Content-Type", contentType)
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, allowed := range cw.config.ContentTypes {
+		if prefix, ok := strings.CutSuffix(allowed, "/*"); ok {
+			if strings.HasPrefix(mediaType, prefix+"/") {
+				return true
+			}
+		} else if mediaType == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// decide writes the header and the buffered body, compressed or as is
+func (cw *compressResponseWriter) decide(compress bool) error {
+	cw.decided = true
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+
+	if compress {
+		header := cw.Header()
+		header.Set("Content-Encoding", cw.encoding)
+		header.Del("Content-Length")
+		// a strong validator no longer matches the encoded representation
+		if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			header.Set("ETag", "W/"+etag)
+		}
+
+		if cw.encoding == EncodingGzip {
+			cw.enc = cw.pools.gzip.Get().(*gzip.Writer)
+		} else {
+			cw.enc = cw.pools.flate.Get().(*flate.Writer)
+		}
+		cw.enc.Reset(cw.ResponseWriter)
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.status)
+	buf := cw.buf
+	cw.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if cw.enc != nil {
+		_, err = cw.enc.Write(buf)
+	} else {
+		_, err = cw.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// close flushes a response that stayed under MinSize and returns the encoder to its pool
+func (cw *compressResponseWriter) close() {
+	if !cw.decided {
+		if cw.status == 0 && len(cw.buf) == 0 {
+			// nothing was written, let net/http send its default response
+			return
+		}
+		cw.decide(false)
+	}
+	if cw.enc == nil {
+		return
+	}
+
+	cw.enc.Close()
+	cw.enc.Reset(io.Discard)
+	switch enc := cw.enc.(type) {
+	case *gzip.Writer:
+		cw.pools.gzip.Put(enc)
+	case *flate.Writer:
+		cw.pools.flate.Put(enc)
+	}
+	cw.enc = nil
+}
+
+func bodyAllowed(status int) bool {
+	return status != http.StatusNoContent && status != http.StatusNotModified
+}
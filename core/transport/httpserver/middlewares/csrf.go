@@ -0,0 +1,266 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/yourorg/go-api-template/core/exception"
+	"github.com/yourorg/go-api-template/core/logger"
+)
+
+// CSRF modes
+const (
+	// CSRFDoubleSubmit compares the token of a cookie with the one sent in a header or form field
+	CSRFDoubleSubmit = "double-submit"
+	// CSRFSynchronizer expects a token derived from the session cookie, see CSRFConfig.SessionCookie
+	CSRFSynchronizer = "synchronizer"
+)
+
+// CSRFTokenKey is the type for the CSRF token context key
+type CSRFTokenKey string
+
+// CSRFTokenContextKey is the context key for the CSRF token of the request
+const CSRFTokenContextKey CSRFTokenKey = "csrf_token"
+
+// CSRFConfig configures the CSRF middleware
+type CSRFConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Mode is "double-submit" (default) or "synchronizer"
+	Mode string `mapstructure:"mode"`
+	// Secret signs tokens; required for "synchronizer", optional for "double-submit" where it
+	// stops subdomains from planting cookies. Empty uses a random secret valid until restart.
+	Secret string `mapstructure:"secret" secret:"true"`
+	// SessionCookie is the cookie of the session the synchronizer token is bound to
+	SessionCookie string `mapstructure:"sessionCookie"`
+	// CookieName holds the double-submit token, readable by scripts
+	CookieName string `mapstructure:"cookieName"`
+	// CookieMaxAge is the lifetime of the double-submit cookie
+	CookieMaxAge time.Duration `mapstructure:"cookieMaxAge"`
+	// CookieSecure marks the cookie HTTPS only
+	CookieSecure bool `mapstructure:"cookieSecure"`
+	// CookieSameSite is "lax" (default), "strict" or "none"
+	CookieSameSite string `mapstructure:"cookieSameSite"`
+	// HeaderName carries the token of API calls
+	HeaderName string `mapstructure:"headerName"`
+	// FormField carries the token of url-encoded form posts
+	FormField string `mapstructure:"formField"`
+	// SafeMethods are never checked
+	SafeMethods []string `mapstructure:"safeMethods"`
+	// ExemptPaths are path prefixes never checked, e.g. webhooks authenticated otherwise
+	ExemptPaths []string `mapstructure:"exemptPaths"`
+	// WriteError renders the rejection. Defaults to the {"status", "message"} envelope;
+	// pass httpserver.WriteError to follow the configured error format.
+	WriteError func(w http.ResponseWriter, r *http.Request, cErr *exception.ExceptionError) `mapstructure:"-"`
+}
+
+// DefaultCSRFConfig returns a default configuration
+func DefaultCSRFConfig() CSRFConfig {
+	return CSRFConfig{
+		Enabled:        true,
+		Mode:           CSRFDoubleSubmit,
+		CookieName:     "csrf_token",
+		CookieMaxAge:   12 * time.Hour,
+		CookieSameSite: "lax",
+		HeaderName:     "X-CSRF-Token",
+		FormField:      "csrf_token",
+		SafeMethods:    []string{http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace},
+	}
+}
+
+// CSRFMiddleware rejects state-changing requests of cookie sessions without a valid CSRF token.
+// Requests with an Authorization header are let through, browsers do not attach it on their own.
+// The token to send back is available with GetCSRFTokenFromContext.
+func CSRFMiddleware(config CSRFConfig) func(http.Handler) http.Handler {
+	defaults := DefaultCSRFConfig()
+	if config.Mode == "" {
+		config.Mode = defaults.Mode
+	}
+	if config.CookieName == "" {
+		config.CookieName = defaults.CookieName
+	}
+	if config.CookieMaxAge <= 0 {
+		config.CookieMaxAge = defaults.CookieMaxAge
+	}
+	if config.HeaderName == "" {
+		config.HeaderName = defaults.HeaderName
+	}
+	if config.FormField == "" {
+		config.FormField = defaults.FormField
+	}
+	if len(config.SafeMethods) == 0 {
+		config.SafeMethods = defaults.SafeMethods
+	}
+	if config.WriteError == nil {
+		config.WriteError = writeErrorEnvelope
+	}
+
+	secret := []byte(config.Secret)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		rand.Read(secret)
+		if logger.Slog != nil && config.Mode == CSRFSynchronizer {
+			logger.Slog.Warn("CSRF secret not configured, tokens are invalidated on restart and differ between instances")
+		}
+	}
+	csrf := &csrfProtection{config: config, secret: secret, signed: config.Secret != ""}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := csrf.token(w, r)
+			if token != "" {
+				r = r.WithContext(WithCSRFToken(r.Context(), token))
+			}
+
+			// without a synchronizer session there is nothing a forged request could act on
+			if !csrf.checked(r) || token == "" || csrf.valid(r, token) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if logger.Slog != nil {
+				logger.Slog.WarnContext(r.Context(), "CSRF token missing or invalid", "method", r.Method, "path", r.URL.Path)
+			}
+			cErr := exception.DefaultCatalog().Get("CSRFTokenInvalid")
+			if cErr == nil {
+				cErr = exception.NewExceptionError(http.StatusBadRequest, 200006, "Invalid or missing CSRF token", http.StatusForbidden)
+			}
+			config.WriteError(w, r, cErr)
+		})
+	}
+}
+
+// GetCSRFTokenFromContext extracts the CSRF token to send back, e.g. in a form, from request context
+func GetCSRFTokenFromContext(ctx context.Context) (string, bool) {
+	token, ok := ctx.Value(CSRFTokenContextKey).(string)
+	return token, ok
+}
+
+// WithCSRFToken adds a CSRF token to the context
+func WithCSRFToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, CSRFTokenContextKey, token)
+}
+
+type csrfProtection struct {
+	config CSRFConfig
+	secret []byte
+	signed bool
+}
+
+// token returns the token expected from the request, issuing the double-submit cookie when
+// missing. It is "" for a synchronizer request without a session.
+func (c *csrfProtection) token(w http.ResponseWriter, r *http.Request) string {
+	if c.config.Mode == CSRFSynchronizer {
+		session, err := r.Cookie(c.config.SessionCookie)
+		if err != nil || session.Value == "" {
+			return ""
+		}
+		return c.sign(session.Value)
+	}
+
+	if cookie, err := r.Cookie(c.config.CookieName); err == nil && c.validCookie(cookie.Value) {
+		return cookie.Value
+	}
+	token := c.newToken()
+	http.SetCookie(w, &http.Cookie{
+		Name:     c.config.CookieName,
+		Value:    token,
+		Path:     "/",
+		MaxAge:   int(c.config.CookieMaxAge.Seconds()),
+		Secure:   c.config.CookieSecure,
+		HttpOnly: false, // scripts copy it into the header
+		SameSite: sameSite(c.config.CookieSameSite),
+	})
+	return token
+}
+
+// checked reports whether the request must carry a token
+func (c *csrfProtection) checked(r *http.Request) bool {
+	if slices.Contains(c.config.SafeMethods, r.Method) || r.Header.Get("Authorization") != "" {
+		return false
+	}
+	for _, prefix := range c.config.ExemptPaths {
+		if strings.HasPrefix(r.URL.Path, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// valid compares the token sent in the header or form field with the expected one
+func (c *csrfProtection) valid(r *http.Request, expected string) bool {
+	sent := r.Header.Get(c.config.HeaderName)
+	if sent == "" {
+		sent = formToken(r, c.config.FormField)
+	}
+	return sent != "" && subtle.ConstantTimeCompare([]byte(sent), []byte(expected)) == 1
+}
+
+// newToken returns a random token, "random.signature" when a secret is configured
+func (c *csrfProtection) newToken() string {
+	nonce := make([]byte, 32)
+	rand.Read(nonce)
+	token := base64.RawURLEncoding.EncodeToString(nonce)
+	if c.signed {
+		token += "." + c.sign(token)
+	}
+	return token
+}
+
+// validCookie rejects cookies whose signature does not match, e.g. planted by a subdomain
+func (c *csrfProtection) validCookie(value string) bool {
+	if value == "" {
+		return false
+	}
+	if !c.signed {
+		return true
+	}
+	nonce, signature, ok := strings.Cut(value, ".")
+	return ok && hmac.Equal([]byte(signature), []byte(c.sign(nonce)))
+}
+
+func (c *csrfProtection) sign(value string) string {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write([]byte(value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// formToken reads field from an url-encoded body, leaving the body readable for the handler
+func formToken(r *http.Request, field string) string {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/x-www-form-urlencoded" || r.Body == nil {
+		return ""
+	}
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return ""
+	}
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return ""
+	}
+	return values.Get(field)
+}
+
+func sameSite(value string) http.SameSite {
+	switch strings.ToLower(value) {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	}
+	return http.SameSiteLaxMode
+}
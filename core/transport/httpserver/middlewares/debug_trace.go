@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/yourorg/go-api-template/core/logger"
+	"github.com/yourorg/go-api-template/core/tracing"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DebugTraceHeader is the default header asking for a debug trace of a request
+const DebugTraceHeader = "X-Debug-Trace"
+
+// DebugTraceConfig configures DebugTraceMiddleware
+type DebugTraceConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Header asks for a debug trace with the value true; default X-Debug-Trace
+	Header string `mapstructure:"header"`
+	// Roles of the JWTs allowed to ask, any of them; default admin
+	Roles []string `mapstructure:"roles"`
+}
+
+// DefaultDebugTraceConfig returns default debug trace configuration, disabled
+func DefaultDebugTraceConfig() DebugTraceConfig {
+	return DebugTraceConfig{
+		Header: DebugTraceHeader,
+		Roles:  []string{"admin"},
+	}
+}
+
+// DebugTraceMiddleware samples the trace of requests sent with the debug trace header and a
+// Bearer JWT carrying one of the roles, and logs them at debug level whatever the level of
+// the logger. Place it outside otelhttp, the sampling decision is made as the server span
+// starts; DebugTraceResponseMiddleware returns the trace ID. The header is ignored for other
+// callers, the request is not rejected.
+func DebugTraceMiddleware(config DebugTraceConfig, jwtSecretKey string) TransportMiddleware {
+	if config.Header == "" {
+		config.Header = DebugTraceHeader
+	}
+	if len(config.Roles) == 0 {
+		config.Roles = DefaultDebugTraceConfig().Roles
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !strings.EqualFold(r.Header.Get(config.Header), "true") || !hasDebugTraceRole(r, config.Roles, jwtSecretKey) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx := tracing.WithForcedSampling(r.Context())
+			ctx = logger.WithDebugLogs(ctx)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// DebugTraceResponseMiddleware sets the X-Trace-ID response header of the requests traced by
+// DebugTraceMiddleware to their trace ID, for looking the trace up. Place it inside otelhttp.
+func DebugTraceResponseMiddleware() TransportMiddleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if tracing.SamplingForced(r.Context()) {
+				if spanCtx := trace.SpanContextFromContext(r.Context()); spanCtx.HasTraceID() {
+					w.Header().Set(TraceIDHeader, spanCtx.TraceID().String())
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// hasDebugTraceRole reports whether the Bearer JWT of r is valid and carries one of roles
+func hasDebugTraceRole(r *http.Request, roles []string, jwtSecretKey string) bool {
+	tokenString := extractBearerToken(r.Header.Get("Authorization"))
+	if tokenString == "" || jwtSecretKey == "" {
+		return false
+	}
+	token, err := parseToken(tokenString, jwtSecretKey)
+	if err != nil {
+		return false
+	}
+	claims, ok := token.Claims.(*UserClaims)
+	if !ok || !token.Valid {
+		return false
+	}
+	return slices.ContainsFunc(claims.Roles, func(role string) bool {
+		return slices.Contains(roles, role)
+	})
+}
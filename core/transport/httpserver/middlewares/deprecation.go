@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// DeprecationConfig describes a deprecated API version, announced with the
+// Deprecation (RFC 9745) and Sunset (RFC 8594) response headers
+type DeprecationConfig struct {
+	// Since is when the version was deprecated; zero sends "Deprecation: true"
+	Since time.Time
+	// Sunset is when the version stops being served; zero omits the header
+	Sunset time.Time
+	// Link points to the migration guide, sent as Link: <...>; rel="deprecation"
+	Link string
+}
+
+// DeprecationMiddleware creates a middleware that marks every response as deprecated,
+// typically applied to the group of an old API version
+func DeprecationMiddleware(config DeprecationConfig) func(http.Handler) http.Handler {
+	deprecation := "true"
+	if !config.Since.IsZero() {
+		deprecation = fmt.Sprintf("@%d", config.Since.Unix())
+	}
+	var sunset string
+	if !config.Sunset.IsZero() {
+		sunset = config.Sunset.UTC().Format(http.TimeFormat)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Deprecation", deprecation)
+			if sunset != "" {
+				w.Header().Set("Sunset", sunset)
+			}
+			if config.Link != "" {
+				w.Header().Add("Link", fmt.Sprintf(`<%s>; rel="deprecation"`, config.Link))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
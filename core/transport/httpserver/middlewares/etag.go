@@ -0,0 +1,166 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ETagConfig configures the ETag middleware
+type ETagConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Weak sends W/"..." validators, for bodies that may differ in bytes but not in meaning
+	Weak bool `mapstructure:"weak"`
+}
+
+// ETagMiddleware sets an ETag computed from the body of successful GET and HEAD responses
+// and answers 304 Not Modified when it matches If-None-Match. An ETag set by the handler is
+// kept. Responses are buffered until the handler returns; a Flush, e.g. of an event stream,
+// sends them as they are written without an ETag.
+func ETagMiddleware(config ETagConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if (r.Method != http.MethodGet && r.Method != http.MethodHead) || r.Header.Get("Upgrade") != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ew := &etagResponseWriter{ResponseWriter: w}
+			next.ServeHTTP(ew, r)
+			// not deferred: after a panic the buffered response is dropped so the recovery middleware can answer
+			ew.finish(r, config.Weak)
+		})
+	}
+}
+
+// etagResponseWriter buffers the response to compute its ETag
+type etagResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	buf         bytes.Buffer
+	passthrough bool
+}
+
+func (ew *etagResponseWriter) WriteHeader(statusCode int) {
+	if ew.passthrough {
+		ew.ResponseWriter.WriteHeader(statusCode)
+		return
+	}
+	// informational responses are sent right away and do not end the header phase
+	if statusCode >= 100 && statusCode < 200 && statusCode != http.StatusSwitchingProtocols {
+		ew.ResponseWriter.WriteHeader(statusCode)
+		return
+	}
+	if ew.status == 0 {
+		ew.status = statusCode
+	}
+}
+
+func (ew *etagResponseWriter) Write(b []byte) (int, error) {
+	if ew.passthrough {
+		return ew.ResponseWriter.Write(b)
+	}
+	if ew.status == 0 {
+		ew.status = http.StatusOK
+	}
+	return ew.buf.Write(b)
+}
+
+// Flush gives up on the ETag and streams the response from here on
+func (ew *etagResponseWriter) Flush() {
+	ew.release()
+	http.NewResponseController(ew.ResponseWriter).Flush()
+}
+
+// Hijack passes the connection through, e.g. for WebSocket upgrades
+func (ew *etagResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if ew.passthrough || ew.status != 0 {
+		return nil, nil, errors.New("etag: response already started")
+	}
+	ew.passthrough = true
+	return http.NewResponseController(ew.ResponseWriter).Hijack()
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (ew *etagResponseWriter) Unwrap() http.ResponseWriter {
+	return ew.ResponseWriter
+}
+
+// release writes what was buffered and passes later writes through
+func (ew *etagResponseWriter) release() {
+	if ew.passthrough {
+		return
+	}
+	ew.passthrough = true
+	if ew.status == 0 {
+		ew.status = http.StatusOK
+	}
+	ew.ResponseWriter.WriteHeader(ew.status)
+	if ew.buf.Len() > 0 {
+		ew.ResponseWriter.Write(ew.buf.Bytes())
+	}
+}
+
+func (ew *etagResponseWriter) finish(r *http.Request, weak bool) {
+	if ew.passthrough {
+		return
+	}
+	if ew.status == 0 {
+		// nothing was written, let net/http send its default response
+		return
+	}
+	if ew.status != http.StatusOK {
+		ew.release()
+		return
+	}
+
+	header := ew.Header()
+	etag := header.Get("ETag")
+	if etag == "" {
+		etag = computeETag(ew.buf.Bytes(), weak)
+		header.Set("ETag", etag)
+	}
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		ew.passthrough = true
+		// a 304 carries the validators and caching headers, not the representation
+		header.Del("Content-Type")
+		header.Del("Content-Length")
+		ew.ResponseWriter.WriteHeader(http.StatusNotModified)
+		return
+	}
+	ew.release()
+}
+
+// computeETag returns the quoted, truncated SHA-256 of body
+func computeETag(body []byte, weak bool) string {
+	sum := sha256.Sum256(body)
+	etag := `"` + base64.RawURLEncoding.EncodeToString(sum[:18]) + `"`
+	if weak {
+		return "W/" + etag
+	}
+	return etag
+}
+
+// etagMatches compares an If-None-Match list with etag using the weak comparison
+// of RFC 9110, so W/"x" matches "x"
+func etagMatches(ifNoneMatch string, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+	opaque := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == opaque {
+			return true
+		}
+	}
+	return false
+}
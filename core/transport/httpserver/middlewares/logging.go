@@ -26,6 +26,7 @@ func LoggingNetHttp(ctx context.Context, l slog.Logger, startTime time.Time, ela
 			slog.String("type", "httpserver"),
 			slog.String("method", method),
 			slog.String("path", path),
+			slog.String("route", routeOf(ctx)),
 			slog.String("ip", fmt.Sprint(headers["X-Forwarded-For"])),
 			slog.String("duration", elapse.String()),
 		),
@@ -59,6 +60,14 @@ func LoggingNetHttp(ctx context.Context, l slog.Logger, startTime time.Time, ela
 	)
 }
 
+// routeOf returns the route pattern of the request, "unmatched" outside the router
+func routeOf(ctx context.Context) string {
+	if route, ok := GetRouteFromContext(ctx); ok {
+		return route
+	}
+	return "unmatched"
+}
+
 func convertHeaderAttrToString(key string, headers map[string][]string) string {
 	if header, ok := headers[key]; ok {
 		return header[0]
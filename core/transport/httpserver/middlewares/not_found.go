@@ -6,8 +6,10 @@ import (
 )
 
 type ModelResp struct {
-	Status  int    `json:"status"`
-	Message string `json:"message"`
+	Status    int    `json:"status"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id,omitempty"`
+	TraceID   string `json:"trace_id,omitempty"`
 }
 
 func NotFound(w http.ResponseWriter, r *http.Request) {
@@ -18,6 +20,8 @@ func NotFound(w http.ResponseWriter, r *http.Request) {
 		Status:  http.StatusNotFound,
 		Message: "Not Found",
 	}
+	resp.RequestID, _ = GetRequestIDFromContext(r.Context())
+	resp.TraceID, _ = GetTraceIDFromContext(r.Context())
 
 	json.NewEncoder(w).Encode(resp)
 }
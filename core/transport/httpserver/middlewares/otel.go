@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
@@ -33,3 +34,13 @@ func otelDatadogCustomAttr(next http.Handler) http.Handler {
 		span.SetAttributes(resourceNameKey.String(r.URL.Path))
 	})
 }
+
+// GetTraceIDFromContext returns the trace ID of the span of ctx when its trace is sampled,
+// the ID an exported trace can be looked up with
+func GetTraceIDFromContext(ctx context.Context) (string, bool) {
+	spanCtx := trace.SpanContextFromContext(ctx)
+	if !spanCtx.IsSampled() {
+		return "", false
+	}
+	return spanCtx.TraceID().String(), true
+}
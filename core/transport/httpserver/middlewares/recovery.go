@@ -0,0 +1,157 @@
+package middleware
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/yourorg/go-api-template/core/exception"
+	"github.com/yourorg/go-api-template/core/logger"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// RecoveryConfig configures the recovery middleware
+type RecoveryConfig struct {
+	// WriteError renders the response for a recovered panic. Defaults to the
+	// {"status", "message"} envelope; pass httpserver.WriteError to follow the configured error format.
+	WriteError func(w http.ResponseWriter, r *http.Request, cErr *exception.ExceptionError)
+}
+
+var (
+	panicCounter     metric.Int64Counter
+	panicCounterOnce sync.Once
+)
+
+// RecoveryMiddleware turns a panic in a handler into a 500 error response.
+// The panic and its full stack are logged through the canonical logger and counted
+// in the http.server.panics metric. http.ErrAbortHandler is re-panicked as net/http expects.
+func RecoveryMiddleware(config RecoveryConfig) func(http.Handler) http.Handler {
+	if config.WriteError == nil {
+		config.WriteError = writeErrorEnvelope
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			startTime := time.Now()
+			rw := &recoveryResponseWriter{ResponseWriter: w}
+
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+
+				cErr := exception.FromPanic(rec)
+				ctx := r.Context()
+				recordPanic(ctx, r)
+				logPanic(ctx, r, startTime, rec, cErr)
+
+				// the handler already started the response, the connection is closed as is
+				if rw.wroteHeader {
+					return
+				}
+				config.WriteError(rw, r, cErr)
+			}()
+
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// recoveryResponseWriter tracks whether the status line was sent
+type recoveryResponseWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (rw *recoveryResponseWriter) WriteHeader(statusCode int) {
+	rw.wroteHeader = true
+	rw.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (rw *recoveryResponseWriter) Write(b []byte) (int, error) {
+	rw.wroteHeader = true
+	return rw.ResponseWriter.Write(b)
+}
+
+// Hijack passes the connection through, e.g. for WebSocket upgrades; a later panic is only logged
+func (rw *recoveryResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	rw.wroteHeader = true
+	return http.NewResponseController(rw.ResponseWriter).Hijack()
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (rw *recoveryResponseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+func writeErrorEnvelope(w http.ResponseWriter, r *http.Request, cErr *exception.ExceptionError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(cErr.HttpStatusCode)
+	resp := ModelResp{
+		Status:  cErr.APIStatusCode,
+		Message: cErr.GlobalMessage,
+	}
+	resp.RequestID, _ = GetRequestIDFromContext(r.Context())
+	resp.TraceID, _ = GetTraceIDFromContext(r.Context())
+	json.NewEncoder(w).Encode(resp)
+}
+
+func logPanic(ctx context.Context, r *http.Request, startTime time.Time, rec any, cErr *exception.ExceptionError) {
+	slogger := slog.Default()
+	if logger.Slog != nil {
+		slogger = logger.Slog
+	}
+
+	if _, err := logger.GetCanonicalLogTemplate(); err != nil {
+		// the canonical logger is not initialized, e.g. in tests
+		slogger.ErrorContext(ctx, "Recovered from panic", "panic", fmt.Sprint(rec), "stack", string(cErr.StackCaller))
+		return
+	}
+
+	logger.CanonicalLogger(ctx, *slogger, logger.Error, nil, nil, cErr,
+		logger.CanonicalLog{
+			Transport: "http",
+			Traffic:   "internal",
+			Method:    r.Method,
+			Status:    cErr.HttpStatusCode,
+			Path:      r.URL.Path,
+			Duration:  time.Since(startTime),
+		},
+		[]any{
+			slog.String("logger_name", "canonical"),
+			slog.Group("panic",
+				slog.String("value", fmt.Sprint(rec)),
+				slog.String("stack", string(cErr.StackCaller)),
+			),
+		},
+	)
+}
+
+// recordPanic counts a recovered panic by request method. The route pattern is
+// only known inside the mux, and the raw path would make the label unbounded.
+func recordPanic(ctx context.Context, r *http.Request) {
+	panicCounterOnce.Do(func() {
+		// instruments from the global meter follow a provider installed later
+		panicCounter, _ = otel.Meter(name).Int64Counter(
+			"http.server.panics",
+			metric.WithDescription("Number of panics recovered from HTTP handlers"),
+			metric.WithUnit("{panic}"),
+		)
+	})
+	if panicCounter == nil {
+		return
+	}
+	panicCounter.Add(ctx, 1, metric.WithAttributes(attribute.String("http.request.method", r.Method)))
+}
@@ -0,0 +1,17 @@
+package middleware
+
+import "context"
+
+type routeKey struct{}
+
+// WithRoute sets the route pattern that matched the request, e.g. /api/v1/examples/{id}
+func WithRoute(ctx context.Context, route string) context.Context {
+	return context.WithValue(ctx, routeKey{}, route)
+}
+
+// GetRouteFromContext returns the route pattern set by WithRoute. Logs and telemetry label
+// requests with it rather than the raw path, whose IDs make the values unbounded.
+func GetRouteFromContext(ctx context.Context) (string, bool) {
+	route, ok := ctx.Value(routeKey{}).(string)
+	return route, ok && route != ""
+}
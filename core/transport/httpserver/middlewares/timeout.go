@@ -0,0 +1,238 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourorg/go-api-template/core/exception"
+)
+
+// ErrRequestTimeout is the context cause when the server-side request budget runs out,
+// see context.Cause
+var ErrRequestTimeout = errors.New("request timeout")
+
+// TimeoutConfig configures the timeout middleware
+type TimeoutConfig struct {
+	// Default is the budget of every request; 0 disables it for routes without an override
+	Default time.Duration `mapstructure:"default"`
+	// Routes overrides the budget by path prefix, the longest matching prefix wins,
+	// e.g. {"/api/v1/llm": "2m"}. A 0 value disables the timeout for the prefix.
+	Routes map[string]time.Duration `mapstructure:"routes"`
+	// WriteError renders the timeout response. Defaults to the {"status", "message"} envelope;
+	// pass httpserver.WriteError to follow the configured error format.
+	WriteError func(w http.ResponseWriter, r *http.Request, cErr *exception.ExceptionError) `mapstructure:"-"`
+}
+
+// TimeoutMiddleware bounds each request with a deadline on its context, so database
+// and outbound calls made with that context stop once the budget is spent.
+// A response not started by then is replaced with 504 Gateway Timeout; a streamed
+// response that already flushed is left to end when the handler returns. WebSocket upgrades
+// are not buffered, and the budget stops once the connection is upgraded.
+func TimeoutMiddleware(config TimeoutConfig) func(http.Handler) http.Handler {
+	if config.WriteError == nil {
+		config.WriteError = writeErrorEnvelope
+	}
+
+	prefixes := make([]string, 0, len(config.Routes))
+	for prefix := range config.Routes {
+		prefixes = append(prefixes, prefix)
+	}
+	// longest first, so the most specific prefix matches
+	sort.Slice(prefixes, func(i, j int) bool { return len(prefixes[i]) > len(prefixes[j]) })
+
+	budget := func(path string) time.Duration {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(path, prefix) {
+				return config.Routes[prefix]
+			}
+		}
+		return config.Default
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			timeout := budget(r.URL.Path)
+			if timeout <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if isWebSocketUpgrade(r) {
+				serveUpgrade(w, r, next, timeout)
+				return
+			}
+
+			ctx, cancel := context.WithTimeoutCause(r.Context(), timeout, ErrRequestTimeout)
+			defer cancel()
+			r = r.WithContext(ctx)
+
+			tw := &timeoutResponseWriter{w: w, header: make(http.Header)}
+			done := make(chan struct{})
+			panicked := make(chan any, 1)
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicked <- p
+					}
+				}()
+				next.ServeHTTP(tw, r)
+				close(done)
+			}()
+
+			select {
+			case p := <-panicked:
+				// re-panic on the serving goroutine, for the recovery middleware
+				panic(p)
+			case <-done:
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				tw.flushBuffered()
+			case <-ctx.Done():
+				tw.mu.Lock()
+				if tw.committed {
+					// streaming: the handler owns the connection until it returns
+					tw.mu.Unlock()
+					select {
+					case p := <-panicked:
+						panic(p)
+					case <-done:
+					}
+					return
+				}
+				tw.timedOut = true
+				tw.mu.Unlock()
+
+				if !errors.Is(context.Cause(ctx), ErrRequestTimeout) {
+					// the client went away, there is nobody to answer
+					return
+				}
+				config.WriteError(w, r, timeoutError(timeout))
+			}
+		})
+	}
+}
+
+func timeoutError(timeout time.Duration) *exception.ExceptionError {
+	cErr := exception.DefaultCatalog().Get("RequestTimeout")
+	if cErr == nil {
+		cErr = exception.NewExceptionError(http.StatusInternalServerError, 209998, "The request took too long to process", http.StatusGatewayTimeout).WithTemporary()
+	}
+	return cErr.WithDatas(map[string]string{
+		"timeout": timeout.String(),
+	}).Wrap(ErrRequestTimeout)
+}
+
+// isWebSocketUpgrade reports whether r asks to switch to the WebSocket protocol
+func isWebSocketUpgrade(r *http.Request) bool {
+	return r.Method == http.MethodGet && strings.EqualFold(r.Header.Get("Upgrade"), "websocket")
+}
+
+// serveUpgrade serves an upgrade request unbuffered, for the handler to hijack the connection.
+// The budget runs until the connection is hijacked: an upgraded connection outlives it.
+func serveUpgrade(w http.ResponseWriter, r *http.Request, next http.Handler, timeout time.Duration) {
+	ctx, cancel := context.WithCancelCause(r.Context())
+	defer cancel(nil)
+	timer := time.AfterFunc(timeout, func() { cancel(ErrRequestTimeout) })
+	defer timer.Stop()
+
+	next.ServeHTTP(&upgradeResponseWriter{ResponseWriter: w, timer: timer}, r.WithContext(ctx))
+}
+
+// upgradeResponseWriter stops the budget of an upgrade request once the connection is hijacked
+type upgradeResponseWriter struct {
+	http.ResponseWriter
+	timer *time.Timer
+}
+
+func (uw *upgradeResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	uw.timer.Stop()
+	return http.NewResponseController(uw.ResponseWriter).Hijack()
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (uw *upgradeResponseWriter) Unwrap() http.ResponseWriter {
+	return uw.ResponseWriter
+}
+
+// timeoutResponseWriter buffers the response until the handler returns or flushes,
+// so a timeout can still replace it. Writes after the timeout fail with http.ErrHandlerTimeout.
+type timeoutResponseWriter struct {
+	w      http.ResponseWriter
+	header http.Header
+	buf    bytes.Buffer
+	status int
+
+	mu        sync.Mutex
+	committed bool // headers sent to w, writes go straight through
+	timedOut  bool
+}
+
+func (tw *timeoutResponseWriter) Header() http.Header {
+	if tw.committed {
+		return tw.w.Header()
+	}
+	return tw.header
+}
+
+func (tw *timeoutResponseWriter) WriteHeader(statusCode int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.committed || tw.status != 0 {
+		return
+	}
+	tw.status = statusCode
+}
+
+func (tw *timeoutResponseWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.committed {
+		return tw.w.Write(b)
+	}
+	if tw.status == 0 {
+		tw.status = http.StatusOK
+	}
+	return tw.buf.Write(b)
+}
+
+// Flush commits the response: the buffered part is sent and a timeout no longer replaces it
+func (tw *timeoutResponseWriter) Flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return
+	}
+	tw.flushBuffered()
+	http.NewResponseController(tw.w).Flush()
+}
+
+// flushBuffered sends the buffered header and body; tw.mu must be held
+func (tw *timeoutResponseWriter) flushBuffered() {
+	if tw.committed || tw.timedOut {
+		return
+	}
+	tw.committed = true
+
+	dst := tw.w.Header()
+	for key, values := range tw.header {
+		dst[key] = values
+	}
+	if tw.status == 0 {
+		tw.status = http.StatusOK
+	}
+	tw.w.WriteHeader(tw.status)
+	if tw.buf.Len() > 0 {
+		tw.w.Write(tw.buf.Bytes())
+		tw.buf.Reset()
+	}
+}
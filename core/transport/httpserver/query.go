@@ -0,0 +1,107 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/yourorg/go-api-template/core/validation"
+)
+
+// QueryBinder is implemented by requests parsing query parameters `query` tags cannot express,
+// e.g. model.ListParams. BindQuery runs after the tagged fields are set; a validation.Errors
+// it returns is reported field by field.
+type QueryBinder interface {
+	BindQuery(values url.Values) error
+}
+
+// bindQueryValues sets fields tagged `query:"name"` from the query string, then calls QueryBinder.
+// Strings, integers, booleans and string slices (repeated or comma separated) are supported;
+// untagged embedded structs are bound as well.
+func bindQueryValues(r *http.Request, req any) error {
+	val := reflect.ValueOf(req)
+	if val.Kind() != reflect.Ptr || val.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+
+	values := r.URL.Query()
+	var fieldErrs validation.Errors
+	bindQueryFields(val.Elem(), values, &fieldErrs)
+	if len(fieldErrs) > 0 {
+		return fieldErrs
+	}
+
+	if binder, ok := req.(QueryBinder); ok {
+		return binder.BindQuery(values)
+	}
+	return nil
+}
+
+func bindQueryFields(val reflect.Value, values url.Values, fieldErrs *validation.Errors) {
+	for i := 0; i < val.NumField(); i++ {
+		structField := val.Type().Field(i)
+		field := val.Field(i)
+		if !field.CanSet() {
+			continue
+		}
+
+		name := structField.Tag.Get("query")
+		if name == "" {
+			if structField.Anonymous && field.Kind() == reflect.Struct {
+				bindQueryFields(field, values, fieldErrs)
+			}
+			continue
+		}
+		raw, ok := values[name]
+		if !ok || len(raw) == 0 {
+			continue
+		}
+
+		if message := setQueryValue(field, raw); message != "" {
+			*fieldErrs = append(*fieldErrs, validation.FieldError{Field: name, Rule: "query", Message: message})
+		}
+	}
+}
+
+// setQueryValue converts raw into field, returning the message of a failed conversion
+func setQueryValue(field reflect.Value, raw []string) string {
+	value := raw[len(raw)-1]
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return "must be an integer"
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return "must be a positive integer"
+		}
+		field.SetUint(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "must be true or false"
+		}
+		field.SetBool(b)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return ""
+		}
+		var items []string
+		for _, v := range raw {
+			for _, item := range strings.Split(v, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+		}
+		field.Set(reflect.ValueOf(items).Convert(field.Type()))
+	}
+	return ""
+}
@@ -0,0 +1,153 @@
+package httpserver
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"sync"
+	"sync/atomic"
+
+	middleware "github.com/yourorg/go-api-template/core/transport/httpserver/middlewares"
+)
+
+// StatusCoder is implemented by responses choosing their HTTP status, e.g. 201 or 202.
+// Responses without it are sent with 200.
+type StatusCoder interface {
+	StatusCode() int
+}
+
+// Headerer is implemented by responses adding headers, e.g. Location
+type Headerer interface {
+	Headers() http.Header
+}
+
+// responseMeta collects what an endpoint set through SetStatus and SetHeader
+type responseMeta struct {
+	mu     sync.Mutex
+	status int
+	header http.Header
+}
+
+type responseMetaKey struct{}
+
+func withResponseMeta(ctx context.Context) (context.Context, *responseMeta) {
+	meta := &responseMeta{header: http.Header{}}
+	return context.WithValue(ctx, responseMetaKey{}, meta), meta
+}
+
+// SetStatus sets the HTTP status of a successful response from inside an endpoint,
+// taking precedence over StatusCoder. It does nothing outside NewTransport.
+func SetStatus(ctx context.Context, status int) {
+	if meta, ok := ctx.Value(responseMetaKey{}).(*responseMeta); ok && status >= 100 && status <= 599 {
+		meta.mu.Lock()
+		meta.status = status
+		meta.mu.Unlock()
+	}
+}
+
+// SetHeader sets a header of the response from inside an endpoint, e.g. Location after a create.
+// It does nothing outside NewTransport.
+func SetHeader(ctx context.Context, key string, value string) {
+	if meta, ok := ctx.Value(responseMetaKey{}).(*responseMeta); ok {
+		meta.mu.Lock()
+		meta.header.Set(key, value)
+		meta.mu.Unlock()
+	}
+}
+
+// successStatus returns the status of a successful response: SetStatus, then StatusCoder, then 200
+func successStatus(meta *responseMeta, resp any) int {
+	meta.mu.Lock()
+	defer meta.mu.Unlock()
+	if meta.status != 0 {
+		return meta.status
+	}
+	if coder, ok := resp.(StatusCoder); ok && !isNilPointer(resp) {
+		if status := coder.StatusCode(); status >= 100 && status <= 599 {
+			return status
+		}
+	}
+	return http.StatusOK
+}
+
+// writeSuccessHeaders copies the headers of Headerer and SetHeader, the latter winning
+func writeSuccessHeaders(w http.ResponseWriter, meta *responseMeta, resp any) {
+	if headerer, ok := resp.(Headerer); ok && !isNilPointer(resp) {
+		for key, values := range headerer.Headers() {
+			w.Header()[http.CanonicalHeaderKey(key)] = values
+		}
+	}
+	meta.mu.Lock()
+	defer meta.mu.Unlock()
+	for key, values := range meta.header {
+		w.Header()[key] = values
+	}
+}
+
+func isNilPointer(v any) bool {
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Ptr && rv.IsNil()
+}
+
+// bodyAllowed reports whether a response with status may have a body
+func bodyAllowed(status int) bool {
+	return status >= 200 && status != http.StatusNoContent && status != http.StatusNotModified
+}
+
+// Envelope wraps the body of successful responses, e.g. to put metadata next to the data.
+// data is the value returned by the endpoint.
+type Envelope func(r *http.Request, status int, data any) any
+
+var envelope atomic.Pointer[Envelope]
+
+// SetEnvelope sets the success envelope for all transports; nil sends responses as is.
+// Tabular encodings such as CSV are never wrapped.
+func SetEnvelope(e Envelope) {
+	if e == nil {
+		envelope.Store(nil)
+		return
+	}
+	envelope.Store(&e)
+}
+
+// SuccessEnvelope is the body produced by DataEnvelope
+type SuccessEnvelope struct {
+	Status    int    `json:"status" xml:"status"`
+	Data      any    `json:"data" xml:"data"`
+	RequestID string `json:"request_id,omitempty" xml:"request_id,omitempty"`
+}
+
+// DataEnvelope renders {"status": 201, "data": ..., "request_id": "..."}
+func DataEnvelope(r *http.Request, status int, data any) any {
+	requestID, _ := middleware.GetRequestIDFromContext(r.Context())
+	return SuccessEnvelope{Status: status, Data: data, RequestID: requestID}
+}
+
+// Envelope names accepted by EnvelopeByName, see restServer.responseEnvelope
+const (
+	EnvelopeNone = "none"
+	EnvelopeData = "data"
+)
+
+// EnvelopeByName returns the built-in envelope called name; "" and "none" return nil
+func EnvelopeByName(name string) (Envelope, bool) {
+	switch name {
+	case "", EnvelopeNone:
+		return nil, true
+	case EnvelopeData:
+		return DataEnvelope, true
+	}
+	return nil, false
+}
+
+// wrapSuccess applies the configured envelope unless codec encodes tabular data
+func wrapSuccess(r *http.Request, codec Codec, status int, resp any) any {
+	e := envelope.Load()
+	if e == nil {
+		return resp
+	}
+	if _, tabular := codec.(TypedCodec); tabular {
+		return resp
+	}
+	return (*e)(r, status, resp)
+}
@@ -1,52 +1,273 @@
 package httpserver
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/yourorg/go-api-template/core/exception"
+	middleware "github.com/yourorg/go-api-template/core/transport/httpserver/middlewares"
 
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 )
 
+// Route is an endpoint registered on a Router. Request and Response are nil
+// for handlers not built with NewTransport.
+type Route struct {
+	Method   string
+	Path     string
+	Request  reflect.Type
+	Response reflect.Type
+}
+
+// typedHandler is implemented by TransportFunc
+type typedHandler interface {
+	Types() (request reflect.Type, response reflect.Type)
+}
+
 type Router struct {
-	mux *http.ServeMux
+	*routerState
+	// prefix and middlewares of a Group, applied to the routes registered through it
+	prefix      string
+	middlewares []func(http.Handler) http.Handler
+}
+
+// routerState is shared by a Router and its groups
+type routerState struct {
+	mux    *http.ServeMux
+	routes []Route
+	// methods registered per path, keyed by the path with wildcard names removed
+	methods map[string]*pathMethods
+
+	notFound         http.Handler
+	notFoundSet      bool
+	methodNotAllowed http.Handler
+	errorHandler     ErrorHandler
+}
+
+type pathMethods struct {
+	router  *Router
+	mu      sync.RWMutex
+	methods []string
 }
 
 func NewRouter(mux *http.ServeMux) *Router {
-	return &Router{mux: mux}
+	return &Router{routerState: &routerState{mux: mux, methods: make(map[string]*pathMethods)}}
 }
 
-func (r *Router) Post(path string, handlerFunc http.HandlerFunc) {
-	r.mux.Handle("POST "+path, otelhttp.NewHandler(handlerFunc, path,
-		otelhttp.WithSpanOptions(
-			trace.WithAttributes(attribute.String("resource.name", fmt.Sprintf("POST %v", path))),
-		),
-	))
+// Group returns a router registering its routes under prefix, wrapped in middlewares, e.g.
+// one tree per API version mounted with the same registration function:
+//
+//	registerV1(r.Group("/api/v1", middleware_httpserver.DeprecationMiddleware(cfg)))
+//	registerV1(r.Group("/api/v2"))
+//
+// Groups share the mux, the not found and error handlers and the routes of r.
+func (r *Router) Group(prefix string, middlewares ...func(http.Handler) http.Handler) *Router {
+	return &Router{
+		routerState: r.routerState,
+		prefix:      r.prefix + strings.TrimSuffix(prefix, "/"),
+		middlewares: append(slices.Clone(r.middlewares), middlewares...),
+	}
 }
 
-func (r *Router) Get(path string, handlerFunc http.HandlerFunc) {
-	r.mux.Handle("GET "+path, otelhttp.NewHandler(handlerFunc, path,
-		otelhttp.WithSpanOptions(
-			trace.WithAttributes(attribute.String("resource.name", fmt.Sprintf("GET %v", path))),
-		),
-	))
+func (r *Router) Post(path string, handler http.Handler) {
+	r.handle(http.MethodPost, path, handler)
 }
 
-func (r *Router) Put(path string, handlerFunc http.HandlerFunc) {
-	r.mux.Handle("PUT "+path, otelhttp.NewHandler(handlerFunc, path,
-		otelhttp.WithSpanOptions(
-			trace.WithAttributes(attribute.String("resource.name", fmt.Sprintf("PUT %v", path))),
-		),
-	))
+func (r *Router) Get(path string, handler http.Handler) {
+	r.handle(http.MethodGet, path, handler)
+}
+
+func (r *Router) Put(path string, handler http.Handler) {
+	r.handle(http.MethodPut, path, handler)
+}
+
+func (r *Router) Delete(path string, handler http.Handler) {
+	r.handle(http.MethodDelete, path, handler)
+}
+
+func (r *Router) Patch(path string, handler http.Handler) {
+	r.handle(http.MethodPatch, path, handler)
+}
+
+// Head registers a HEAD handler; without one, HEAD requests are served by the GET handler
+func (r *Router) Head(path string, handler http.Handler) {
+	r.handle(http.MethodHead, path, handler)
+}
+
+// Options registers an OPTIONS handler replacing the automatic Allow response
+func (r *Router) Options(path string, handler http.Handler) {
+	r.handle(http.MethodOptions, path, handler)
+}
+
+// WebSocket registers a WebSocket endpoint, see NewWebSocketTransport
+func (r *Router) WebSocket(path string, handler http.Handler) {
+	r.handle(http.MethodGet, path, handler)
+}
+
+// SetNotFoundHandler answers requests no route matches with handler, NotFoundHandler when nil.
+// It registers the catch-all "/" pattern, so the mux must not have one.
+func (r *Router) SetNotFoundHandler(handler http.Handler) {
+	if handler == nil {
+		handler = NotFoundHandler()
+	}
+	r.notFound = handler
+	if !r.notFoundSet {
+		r.notFoundSet = true
+		r.mux.Handle("/", r.withErrorHandler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			r.notFound.ServeHTTP(w, req)
+		})))
+	}
+}
+
+// SetMethodNotAllowedHandler answers requests for a registered path with another method,
+// MethodNotAllowedHandler when nil. The Allow header is set before handler runs.
+func (r *Router) SetMethodNotAllowedHandler(handler http.Handler) {
+	r.methodNotAllowed = handler
+}
+
+// SetErrorHandler renders the errors of every handler registered on r, including the not found
+// and method not allowed responses; nil restores DefaultErrorHandler. Set it before serving.
+func (r *Router) SetErrorHandler(handler ErrorHandler) {
+	r.errorHandler = handler
+}
+
+// withErrorHandler makes the error handler of r, read per request, available to WriteError
+func (r *Router) withErrorHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if r.errorHandler != nil {
+			req = req.WithContext(context.WithValue(req.Context(), errorHandlerKey{}, r.errorHandler))
+		}
+		next.ServeHTTP(w, req)
+	})
 }
 
-func (r *Router) Delete(path string, handlerFunc http.HandlerFunc) {
-	r.mux.Handle("DELETE "+path, otelhttp.NewHandler(handlerFunc, path,
+// Routes returns the registered endpoints in registration order, e.g. for openapi.Build
+func (r *Router) Routes() []Route {
+	return append([]Route(nil), r.routes...)
+}
+
+func (r *Router) handle(method string, path string, handler http.Handler) {
+	path = r.prefix + path
+	route := Route{Method: method, Path: path}
+	if typed, ok := handler.(typedHandler); ok {
+		route.Request, route.Response = typed.Types()
+	}
+	r.routes = append(r.routes, route)
+	r.allowMethod(method, path)
+
+	// the first middleware of a group runs first
+	for i := len(r.middlewares) - 1; i >= 0; i-- {
+		handler = r.middlewares[i](handler)
+	}
+
+	r.mux.Handle(method+" "+path, labelRoute(path, otelhttp.NewHandler(r.withErrorHandler(handler), path,
 		otelhttp.WithSpanOptions(
-			trace.WithAttributes(attribute.String("resource.name", fmt.Sprintf("DELETE %v", path))),
+			trace.WithAttributes(attribute.String("resource.name", fmt.Sprintf("%s %v", method, path))),
 		),
-	))
+	)))
+}
+
+// labelRoute names the server span after the route pattern, once known, and adds it as
+// http.route to the span and request metrics and to the context for the canonical log
+func labelRoute(path string, next http.Handler) http.Handler {
+	next = otelhttp.WithRouteTag(path, next)
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx := middleware.WithRoute(req.Context(), path)
+		trace.SpanFromContext(ctx).SetName(req.Method + " " + path)
+		next.ServeHTTP(w, req.WithContext(ctx))
+	})
+}
+
+// allowMethod records method for path. The first method of a path also registers a
+// method-less pattern, which the mux picks for every other method: OPTIONS is answered
+// with the Allow header, anything else with 405.
+func (r *Router) allowMethod(method string, path string) {
+	key := pathShape(path)
+	allowed, ok := r.methods[key]
+	if !ok {
+		allowed = &pathMethods{router: r}
+		r.methods[key] = allowed
+		r.mux.Handle(path, r.withErrorHandler(allowed))
+	}
+	allowed.mu.Lock()
+	defer allowed.mu.Unlock()
+	if !slices.Contains(allowed.methods, method) {
+		allowed.methods = append(allowed.methods, method)
+	}
+}
+
+// Allow returns the value of the Allow header; GET implies HEAD and OPTIONS is always answered
+func (m *pathMethods) Allow() string {
+	m.mu.RLock()
+	methods := slices.Clone(m.methods)
+	m.mu.RUnlock()
+
+	if slices.Contains(methods, http.MethodGet) && !slices.Contains(methods, http.MethodHead) {
+		methods = append(methods, http.MethodHead)
+	}
+	if !slices.Contains(methods, http.MethodOptions) {
+		methods = append(methods, http.MethodOptions)
+	}
+	slices.Sort(methods)
+	return strings.Join(methods, ", ")
+}
+
+func (m *pathMethods) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Allow", m.Allow())
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if handler := m.router.methodNotAllowed; handler != nil {
+		handler.ServeHTTP(w, r)
+		return
+	}
+	MethodNotAllowedHandler().ServeHTTP(w, r)
+}
+
+// NotFoundHandler renders the NotFound catalog error, see Router.SetNotFoundHandler
+func NotFoundHandler() http.Handler {
+	return catalogErrorHandler("NotFound", exception.NewExceptionError(http.StatusBadRequest, 200002, "Not found", http.StatusNotFound))
+}
+
+// MethodNotAllowedHandler renders the MethodNotAllowed catalog error, see Router.SetMethodNotAllowedHandler
+func MethodNotAllowedHandler() http.Handler {
+	return catalogErrorHandler("MethodNotAllowed", exception.NewExceptionError(http.StatusBadRequest, 200005, "Method not allowed", http.StatusMethodNotAllowed))
+}
+
+// catalogErrorHandler writes the catalog error called name, fallback when the catalog lacks it
+func catalogErrorHandler(name string, fallback *exception.ExceptionError) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cErr := exception.DefaultCatalog().Get(name)
+		if cErr == nil {
+			cErr = fallback
+		}
+		recordError(r.Context(), r, cErr.Code, cErr.HttpStatusCode)
+		writeExceptionError(w, r, cErr)
+	})
+}
+
+// pathShape removes wildcard names, /items/{id} and /items/{name} being the same path to the mux
+func pathShape(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") && segment != "{$}" {
+			if strings.HasSuffix(segment, "...}") {
+				segments[i] = "{...}"
+			} else {
+				segments[i] = "{}"
+			}
+		}
+	}
+	return strings.Join(segments, "/")
 }
 
 // ServeHTTP handles HTTP requests
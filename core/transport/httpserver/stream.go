@@ -0,0 +1,287 @@
+package httpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/yourorg/go-api-template/core/exception"
+)
+
+// DefaultHeartbeatInterval is how often an idle stream sends a comment to keep proxies from closing it
+const DefaultHeartbeatInterval = 15 * time.Second
+
+// StreamEndpoint produces the events of a streamed response. It should stop
+// when ctx is canceled, which happens once the client disconnects.
+type StreamEndpoint[T, R any] func(ctx context.Context, req T) iter.Seq2[R, error]
+
+// NamedEvent lets a streamed value set its SSE event name; others are sent as "message"
+type NamedEvent interface {
+	EventName() string
+}
+
+// ErrorEventName is the event sent when the endpoint fails after the stream started
+const ErrorEventName = "error"
+
+type streamOptions struct {
+	heartbeat time.Duration
+	doneData  string
+}
+
+// StreamOption customizes NewStreamTransport
+type StreamOption func(*streamOptions)
+
+// WithHeartbeat sets the heartbeat interval; 0 disables heartbeats
+func WithHeartbeat(interval time.Duration) StreamOption {
+	return func(o *streamOptions) {
+		o.heartbeat = interval
+	}
+}
+
+// WithDoneEvent sends a final "done" event with data once the endpoint completes,
+// e.g. "[DONE]" for OpenAI style clients
+func WithDoneEvent(data string) StreamOption {
+	return func(o *streamOptions) {
+		o.doneData = data
+	}
+}
+
+// NewStreamTransport serves endpoint as Server-Sent Events (text/event-stream).
+// The request is decoded and validated like NewTransport. Every value is sent as a
+// JSON encoded event, strings as is, and flushed right away. An error before anything
+// was sent gets the standard error response; after that an "error" event carrying the
+// error envelope ends the stream.
+func NewStreamTransport[T, R any](req T, endpoint StreamEndpoint[T, R], opts ...StreamOption) http.HandlerFunc {
+	options := streamOptions{heartbeat: DefaultHeartbeatInterval}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		newReq := deepCopy(req)
+		ctx := r.Context()
+		startTime := time.Now()
+
+		requestBody, err := readRequestBody(r)
+		if err != nil {
+			recordError(ctx, r, 0, http.StatusBadRequest)
+			HandleInternalServerError(w, r, http.StatusBadRequest)
+			return
+		}
+		err = decodeRequest(r, requestBody, &newReq)
+		if exErr, ok := exception.AsExceptionError(err); ok {
+			recordError(ctx, r, exErr.Code, exErr.HttpStatusCode)
+			writeExceptionError(w, r, exErr)
+			return
+		}
+		if err != nil {
+			recordError(ctx, r, 0, http.StatusBadRequest)
+			HandleInternalServerError(w, r, http.StatusBadRequest)
+			return
+		}
+		bindPathValues(r, newReq)
+		if err := bindQueryValues(r, newReq); err != nil {
+			exErr := ValidationError(err)
+			recordError(ctx, r, exErr.Code, exErr.HttpStatusCode)
+			writeExceptionError(w, r, exErr)
+			logRequestAndResponse(ctx, startTime, time.Since(startTime), r.Method, r.URL.Path, r.Header, requestBody, nil, exErr, exErr.HttpStatusCode)
+			return
+		}
+		if exErr := validateRequest(newReq); exErr != nil {
+			recordError(ctx, r, exErr.Code, exErr.HttpStatusCode)
+			writeExceptionError(w, r, exErr)
+			logRequestAndResponse(ctx, startTime, time.Since(startTime), r.Method, r.URL.Path, r.Header, requestBody, nil, exErr, exErr.HttpStatusCode)
+			return
+		}
+
+		// canceled when the handler returns, stopping an endpoint still producing
+		streamCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		stream := &eventStream{w: w, rc: http.NewResponseController(w)}
+		events, streamErr := runStream(streamCtx, endpoint(streamCtx, newReq), stream, r, options)
+
+		statusCode := http.StatusOK
+		if streamErr != nil && !stream.started {
+			statusCode = http.StatusInternalServerError
+			if exErr, ok := exception.AsExceptionError(streamErr); ok {
+				statusCode = exErr.HttpStatusCode
+			}
+		}
+		summary := []byte(fmt.Sprintf("streamed %d events", events))
+		logRequestAndResponse(ctx, startTime, time.Since(startTime), r.Method, r.URL.Path, r.Header, requestBody, summary, streamErr, statusCode)
+	}
+}
+
+type streamItem[R any] struct {
+	value R
+	err   error
+}
+
+// runStream pulls events from seq on a separate goroutine, so heartbeats and
+// client disconnects are handled while the endpoint is waiting for its next value
+func runStream[R any](ctx context.Context, seq iter.Seq2[R, error], stream *eventStream, r *http.Request, options streamOptions) (int, error) {
+	items := make(chan streamItem[R])
+	go func() {
+		defer close(items)
+		for value, err := range seq {
+			select {
+			case items <- streamItem[R]{value: value, err: err}:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	var heartbeat <-chan time.Time
+	if options.heartbeat > 0 {
+		ticker := time.NewTicker(options.heartbeat)
+		defer ticker.Stop()
+		heartbeat = ticker.C
+	}
+
+	events := 0
+	for {
+		select {
+		case <-ctx.Done():
+			// client went away
+			return events, ctx.Err()
+		case <-heartbeat:
+			if err := stream.comment("ping"); err != nil {
+				return events, err
+			}
+		case item, ok := <-items:
+			if !ok {
+				if options.doneData != "" {
+					if err := stream.send("done", options.doneData); err != nil {
+						return events, err
+					}
+				}
+				if !stream.started {
+					// nothing was produced, still answer with an empty stream
+					stream.start()
+				}
+				return events, nil
+			}
+			if item.err != nil {
+				streamError(stream, r, item.err)
+				return events, item.err
+			}
+
+			name := "message"
+			if named, ok := any(item.value).(NamedEvent); ok {
+				name = named.EventName()
+			}
+			data, err := eventData(item.value)
+			if err != nil {
+				streamError(stream, r, err)
+				return events, err
+			}
+			if err := stream.send(name, data); err != nil {
+				return events, err
+			}
+			events++
+		}
+	}
+}
+
+// streamError reports err as the response when nothing was sent yet, otherwise as an error event
+func streamError(stream *eventStream, r *http.Request, err error) {
+	exErr, ok := exception.AsExceptionError(err)
+	if !stream.started {
+		if ok {
+			recordError(r.Context(), r, exErr.Code, exErr.HttpStatusCode)
+			writeExceptionError(stream.w, r, exErr)
+		} else {
+			recordError(r.Context(), r, 0, http.StatusInternalServerError)
+			HandleInternalServerError(stream.w, r, http.StatusInternalServerError)
+		}
+		return
+	}
+
+	resp := errorResp{Status: http.StatusInternalServerError, Message: "Internal Server Error"}
+	if ok {
+		resp = errorResp{
+			Status:  exErr.APIStatusCode,
+			Message: exErr.LocalizedMessage(requestLocale(r)),
+			Fields:  exErr.ErrFields,
+			Data:    localizedDatas(r, exErr),
+		}
+		recordError(r.Context(), r, exErr.Code, exErr.HttpStatusCode)
+	} else {
+		recordError(r.Context(), r, 0, http.StatusInternalServerError)
+	}
+	resp.RequestID, resp.TraceID = errorIDs(r)
+	data, _ := json.Marshal(resp)
+	stream.send(ErrorEventName, string(data))
+}
+
+// eventData encodes value as event data; strings and bytes are sent as is
+func eventData(value any) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("error encoding event: %w", err)
+	}
+	return string(data), nil
+}
+
+// eventStream writes the text/event-stream format
+type eventStream struct {
+	w       http.ResponseWriter
+	rc      *http.ResponseController
+	started bool
+}
+
+func (s *eventStream) start() {
+	if s.started {
+		return
+	}
+	s.started = true
+	header := s.w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	// disable response buffering in nginx
+	header.Set("X-Accel-Buffering", "no")
+	s.w.WriteHeader(http.StatusOK)
+}
+
+func (s *eventStream) send(name string, data string) error {
+	s.start()
+
+	var b strings.Builder
+	if name != "" && name != "message" {
+		b.WriteString("event: " + name + "\n")
+	}
+	// each line of the payload gets its own data field
+	for _, line := range strings.Split(data, "\n") {
+		b.WriteString("data: " + strings.TrimSuffix(line, "\r") + "\n")
+	}
+	b.WriteString("\n")
+	return s.write(b.String())
+}
+
+func (s *eventStream) comment(text string) error {
+	s.start()
+	return s.write(": " + text + "\n\n")
+}
+
+func (s *eventStream) write(text string) error {
+	if _, err := s.w.Write([]byte(text)); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
@@ -0,0 +1,128 @@
+package httpserver
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	core_config "github.com/yourorg/go-api-template/core/config"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// Client certificate modes of core_config.ClientAuthConfig
+const (
+	ClientAuthNone    = "none"
+	ClientAuthRequest = "request"
+	ClientAuthVerify  = "verify"
+	ClientAuthRequire = "require"
+)
+
+// NewTLSConfig builds the server TLS configuration, nil when TLS is disabled.
+// Serve it with http.Server.ListenAndServeTLS("", ""); HTTP/2 is offered through ALPN.
+func NewTLSConfig(cfg core_config.TLSConfig) (*tls.Config, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	var tlsConfig *tls.Config
+	switch {
+	case cfg.Autocert.Enabled:
+		if len(cfg.Autocert.Domains) == 0 {
+			return nil, errors.New("tls: autocert needs at least one domain")
+		}
+		cacheDir := cfg.Autocert.CacheDir
+		if cacheDir == "" {
+			cacheDir = "certs"
+		}
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.Autocert.Domains...),
+			Cache:      autocert.DirCache(cacheDir),
+			Email:      cfg.Autocert.Email,
+		}
+		// includes the acme-tls/1 protocol answering the challenge
+		tlsConfig = manager.TLSConfig()
+	case cfg.CertFile != "" && cfg.KeyFile != "":
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("tls: error loading certificate: %w", err)
+		}
+		tlsConfig = &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			NextProtos:   []string{"h2", "http/1.1"},
+		}
+	default:
+		return nil, errors.New("tls: set certFile and keyFile or enable autocert")
+	}
+
+	switch cfg.MinVersion {
+	case "", "1.2":
+		tlsConfig.MinVersion = tls.VersionTLS12
+	case "1.3":
+		tlsConfig.MinVersion = tls.VersionTLS13
+	default:
+		return nil, fmt.Errorf("tls: unsupported minVersion %q, use 1.2 or 1.3", cfg.MinVersion)
+	}
+
+	if cfg.DisableHTTP2 {
+		tlsConfig.NextProtos = withoutProto(tlsConfig.NextProtos, "h2")
+	}
+
+	if err := applyClientAuth(tlsConfig, cfg.ClientAuth); err != nil {
+		return nil, err
+	}
+	return tlsConfig, nil
+}
+
+// DisableHTTP2 keeps server on HTTP/1.1 even when a client offers h2
+func DisableHTTP2(server *http.Server) {
+	server.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){}
+}
+
+func applyClientAuth(tlsConfig *tls.Config, cfg core_config.ClientAuthConfig) error {
+	switch strings.ToLower(cfg.Mode) {
+	case "", ClientAuthNone:
+		tlsConfig.ClientAuth = tls.NoClientCert
+		return nil
+	case ClientAuthRequest:
+		tlsConfig.ClientAuth = tls.RequestClientCert
+	case ClientAuthVerify:
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	case ClientAuthRequire:
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return fmt.Errorf("tls: unknown client auth mode %q", cfg.Mode)
+	}
+
+	if cfg.CAFile == "" {
+		if tlsConfig.ClientAuth == tls.RequestClientCert {
+			return nil
+		}
+		return fmt.Errorf("tls: client auth mode %q needs caFile", cfg.Mode)
+	}
+	pem, err := os.ReadFile(cfg.CAFile)
+	if err != nil {
+		return fmt.Errorf("tls: error reading client CA bundle: %w", err)
+	}
+	// only the configured CAs, the system roots must not vouch for clients
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return fmt.Errorf("tls: no certificates found in client CA bundle %s", cfg.CAFile)
+	}
+	tlsConfig.ClientCAs = pool
+	return nil
+}
+
+func withoutProto(protos []string, proto string) []string {
+	kept := make([]string, 0, len(protos))
+	for _, p := range protos {
+		if p != proto {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
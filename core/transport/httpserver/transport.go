@@ -2,7 +2,7 @@ package httpserver
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -12,6 +12,7 @@ import (
 	"github.com/yourorg/go-api-template/core/exception"
 	"github.com/yourorg/go-api-template/core/logger"
 	"github.com/yourorg/go-api-template/core/transport"
+	"github.com/yourorg/go-api-template/core/validation"
 	middleware "github.com/yourorg/go-api-template/core/transport/httpserver/middlewares"
 )
 
@@ -21,9 +22,25 @@ type errorResp struct {
 	DebugMessage string            `json:"debug_message,omitempty"`
 	Fields       []string          `json:"fields,omitempty"`
 	Data         map[string]string `json:"data,omitempty"`
+	Stack        []string          `json:"stack,omitempty"`
+	RequestID    string            `json:"request_id,omitempty"`
+	TraceID      string            `json:"trace_id,omitempty"`
 }
 
-func NewTransport[T, R any](req T, endpoint func() Endpoint[T, R], middlewares ...transport.EndpointMiddleware[T, R]) func(w http.ResponseWriter, r *http.Request) {
+// TransportFunc is the handler built by NewTransport. It is called like an http.HandlerFunc
+// and also reports its request and response types, which the openapi package documents.
+type TransportFunc[T, R any] func(w http.ResponseWriter, r *http.Request)
+
+func (f TransportFunc[T, R]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f(w, r)
+}
+
+// Types returns the request and response types of the endpoint
+func (f TransportFunc[T, R]) Types() (request reflect.Type, response reflect.Type) {
+	return reflect.TypeFor[T](), reflect.TypeFor[R]()
+}
+
+func NewTransport[T, R any](req T, endpoint func() Endpoint[T, R], middlewares ...transport.EndpointMiddleware[T, R]) TransportFunc[T, R] {
 
 	return func(w http.ResponseWriter, r *http.Request) {
 
@@ -44,60 +61,170 @@ func NewTransport[T, R any](req T, endpoint func() Endpoint[T, R], middlewares .
 		requestBody, err := readRequestBody(r)
 		if err != nil {
 			fmt.Println("Error reading request body")
-			HandleInternalServerError(w, http.StatusBadRequest)
+			recordError(ctx, r, 0, http.StatusBadRequest)
+			HandleInternalServerError(w, r, http.StatusBadRequest)
 			return
 		}
 
-		if len(requestBody) == 0 {
-			requestBody = []byte("{}")
+		err = decodeRequest(r, requestBody, &newReq)
+		if exErr, ok := exception.AsExceptionError(err); ok {
+			recordError(ctx, r, exErr.Code, exErr.HttpStatusCode)
+			writeExceptionError(w, r, exErr)
+			logRequestAndResponse(ctx, time.Now(), 0, method, path, header, requestBody, nil, exErr, exErr.HttpStatusCode)
+			return
 		}
-
-		err = json.Unmarshal(requestBody, &newReq)
 		if err != nil {
 			fmt.Println("Error unmarshalling request body")
-			HandleInternalServerError(w, http.StatusBadRequest)
+			recordError(ctx, r, 0, http.StatusBadRequest)
+			HandleInternalServerError(w, r, http.StatusBadRequest)
+			return
+		}
+
+		bindPathValues(r, newReq)
+		if err := bindQueryValues(r, newReq); err != nil {
+			exErr := ValidationError(err)
+			recordError(ctx, r, exErr.Code, exErr.HttpStatusCode)
+			writeExceptionError(w, r, exErr)
+			logRequestAndResponse(ctx, time.Now(), 0, method, path, header, requestBody, nil, exErr, exErr.HttpStatusCode)
+			return
+		}
+
+		if exErr := validateRequest(newReq); exErr != nil {
+			recordError(ctx, r, exErr.Code, exErr.HttpStatusCode)
+			writeExceptionError(w, r, exErr)
+			logRequestAndResponse(ctx, time.Now(), 0, method, path, header, requestBody, nil, exErr, exErr.HttpStatusCode)
+			return
+		}
+
+		// negotiated before the endpoint runs, so an unacceptable request has no side effects
+		codec, err := negotiateCodec(r, reflect.TypeFor[R]())
+		if exErr, ok := exception.AsExceptionError(err); ok {
+			recordError(ctx, r, exErr.Code, exErr.HttpStatusCode)
+			writeExceptionError(w, r, exErr)
+			logRequestAndResponse(ctx, time.Now(), 0, method, path, header, requestBody, nil, exErr, exErr.HttpStatusCode)
 			return
 		}
 
+		// collects SetStatus and SetHeader calls of the endpoint
+		endpointCtx, meta := withResponseMeta(r.Context())
+
 		startTime := time.Now()
-		resp, serviceError = endpoint()()(r.Context(), newReq)
+		resp, serviceError = endpoint()()(endpointCtx, newReq)
 		elapsedTime = time.Since(startTime)
 
 		if serviceError != nil {
 			// Check if error is an ExceptionError to get proper status code
-			if exErr, ok := serviceError.(*exception.ExceptionError); ok {
+			if exErr, ok := exception.AsExceptionError(serviceError); ok {
 				httpStatusCode = exErr.HttpStatusCode
-				w.Header().Set("Content-Type", "application/json")
-				w.WriteHeader(httpStatusCode)
-				// Build error response with all available fields
-				errorResponse := errorResp{
-					Status:  exErr.APIStatusCode,
-					Message: exErr.GlobalMessage,
-					Fields:  exErr.ErrFields,
-					Data:    exErr.ErrWithDatas,
-				}
-				json.NewEncoder(w).Encode(errorResponse)
+				recordError(ctx, r, exErr.Code, httpStatusCode)
+				writeExceptionError(w, r, exErr)
 			} else {
 				httpStatusCode = http.StatusInternalServerError
-				HandleInternalServerError(w, httpStatusCode)
+				recordError(ctx, r, 0, httpStatusCode)
+				HandleInternalServerError(w, r, httpStatusCode)
 			}
-			logRequestAndResponse(ctx, startTime, elapsedTime, method, path, header, requestBody, []byte(fmt.Sprintf("%v", resp)), serviceError, httpStatusCode)
+			logRequestAndResponse(ctx, startTime, elapsedTime, method, path, header, requestBody, nil, serviceError, httpStatusCode)
 			return
 		} else {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(httpStatusCode)
-			json.NewEncoder(w).Encode(resp)
+			var body []byte
+			httpStatusCode, body, err = writeSuccess(w, r, codec, meta, resp)
+			if err != nil {
+				recordError(ctx, r, 0, httpStatusCode)
+				HandleInternalServerError(w, r, httpStatusCode)
+				logRequestAndResponse(ctx, startTime, elapsedTime, method, path, header, requestBody, nil, err, httpStatusCode)
+				return
+			}
 
-			logRequestAndResponse(ctx, startTime, elapsedTime, method, path, header, requestBody, []byte(fmt.Sprintf("%v", resp)), serviceError, httpStatusCode)
+			// the bytes sent to the client, encoded once
+			logRequestAndResponse(ctx, startTime, elapsedTime, method, path, header, requestBody, body, serviceError, httpStatusCode)
 			return
 		}
 	}
 }
 
+// writeSuccess encodes resp with codec and writes it with the status and headers the endpoint
+// set, returning the status and the bytes sent. Nothing is written when encoding fails, the
+// status returned being then 500.
+func writeSuccess(w http.ResponseWriter, r *http.Request, codec Codec, meta *responseMeta, resp any) (int, []byte, error) {
+	status := successStatus(meta, resp)
+	var body []byte
+	if bodyAllowed(status) {
+		selected, err := selectFields(r, codec, resp)
+		if err == nil {
+			body, err = encodeResponse(codec, wrapSuccess(r, codec, status, selected))
+		}
+		if err != nil {
+			return http.StatusInternalServerError, nil, err
+		}
+		w.Header().Set("Content-Type", codec.MediaType())
+	}
+	writeSuccessHeaders(w, meta, resp)
+	w.Header().Add("Vary", "Accept")
+	w.WriteHeader(status)
+	w.Write(body)
+	return status, body, nil
+}
+
 func deepCopy[T any](src T) T {
 	return reflect.New(reflect.TypeOf(src).Elem()).Interface().(T)
 }
 
+// bindPathValues sets string fields tagged `path:"name"` from the route pattern, e.g. /examples/{id}
+func bindPathValues(r *http.Request, req any) {
+	val := reflect.ValueOf(req)
+	if val.Kind() != reflect.Ptr || val.Elem().Kind() != reflect.Struct {
+		return
+	}
+	val = val.Elem()
+
+	for i := 0; i < val.NumField(); i++ {
+		name := val.Type().Field(i).Tag.Get("path")
+		field := val.Field(i)
+		if name == "" || field.Kind() != reflect.String || !field.CanSet() {
+			continue
+		}
+		if value := r.PathValue(name); value != "" {
+			field.SetString(value)
+		}
+	}
+}
+
+// validateRequest checks the `validate` tags of req, reporting every failing field in one error
+func validateRequest(req any) *exception.ExceptionError {
+	if reflect.Indirect(reflect.ValueOf(req)).Kind() != reflect.Struct {
+		return nil
+	}
+
+	err := validation.ValidateStruct(req)
+	if err == nil {
+		return nil
+	}
+	return ValidationError(err)
+}
+
+// ValidationError converts err into a ValidationFailed error listing every field of
+// a validation.Errors, e.g. for a service rejecting a sort field. An ExceptionError is returned as is.
+func ValidationError(err error) *exception.ExceptionError {
+	if exErr, ok := exception.AsExceptionError(err); ok {
+		return exErr
+	}
+
+	cErr := exception.DefaultCatalog().Get("ValidationFailed")
+	if cErr == nil {
+		cErr = exception.NewExceptionError(http.StatusBadRequest, 210001, "Request validation failed", http.StatusBadRequest)
+	}
+
+	var fieldErrs validation.Errors
+	if !errors.As(err, &fieldErrs) {
+		return cErr.Wrap(err)
+	}
+	messages := make(map[string]string, len(fieldErrs))
+	for _, fe := range fieldErrs {
+		messages[fe.Field] = fe.Message
+	}
+	return cErr.WithFields(fieldErrs.Fields()).WithDatas(messages).Wrap(err)
+}
+
 func readRequestBody(r *http.Request) ([]byte, error) {
 	defer r.Body.Close()
 	return io.ReadAll(r.Body)
@@ -0,0 +1,344 @@
+package httpserver
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yourorg/go-api-template/core/exception"
+	"github.com/yourorg/go-api-template/core/storage"
+)
+
+const (
+	// DefaultMaxUploadSize bounds the body of an upload, 32 MiB
+	DefaultMaxUploadSize int64 = 32 << 20
+	// maxFormValueSize bounds a form field sent along the files
+	maxFormValueSize int64 = 64 << 10
+)
+
+// UploadedFile is a file part of an upload, read from the request body as it arrives. It is
+// only readable until the next call to UploadParts.Next.
+type UploadedFile struct {
+	// Field is the form field of the file
+	Field string
+	// Filename is the base name given by the client
+	Filename string
+	// ContentType is detected from the first bytes of the file and its extension, see
+	// storage.SniffContentType; the type given by the client is in Header
+	ContentType string
+	Header      textproto.MIMEHeader
+	io.Reader
+}
+
+// UploadParts reads the parts of a multipart/form-data body in order, nothing being buffered
+// beyond the first bytes of a file
+type UploadParts struct {
+	reader  *multipart.Reader
+	part    *multipart.Part
+	options uploadOptions
+	// Values holds the form fields read so far; the fields sent after a file are known once
+	// Next went past it
+	Values url.Values
+}
+
+// Next returns the next file, collecting the form fields before it into Values, and io.EOF
+// after the last part. A file of a type not allowed fails with UnsupportedMediaType, and
+// reading past the maximum file size with PayloadTooLarge.
+func (u *UploadParts) Next() (*UploadedFile, error) {
+	if u.part != nil {
+		u.part.Close()
+		u.part = nil
+	}
+	for {
+		part, err := u.reader.NextPart()
+		if err != nil {
+			return nil, partError(err)
+		}
+		if part.FileName() == "" {
+			value, err := io.ReadAll(io.LimitReader(part, maxFormValueSize+1))
+			part.Close()
+			if err != nil {
+				return nil, partError(err)
+			}
+			if int64(len(value)) > maxFormValueSize {
+				return nil, ValidationError(errors.New("form field " + part.FormName() + " is too large"))
+			}
+			u.Values.Add(part.FormName(), string(value))
+			continue
+		}
+
+		u.part = part
+		content := bufio.NewReaderSize(part, storage.SniffLen)
+		head, err := content.Peek(storage.SniffLen)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, partError(err)
+		}
+		contentType := storage.SniffContentType(part.FileName(), head)
+		if !typeAllowed(u.options.allowedTypes, contentType) {
+			return nil, unsupportedFileType(part.FormName(), contentType, u.options.allowedTypes)
+		}
+
+		var reader io.Reader = content
+		if u.options.maxFileSize > 0 {
+			reader = &fileSizeLimiter{reader: content, field: part.FormName(), remaining: u.options.maxFileSize, max: u.options.maxFileSize}
+		}
+		return &UploadedFile{
+			Field:       part.FormName(),
+			Filename:    part.FileName(),
+			ContentType: contentType,
+			Header:      part.Header,
+			Reader:      reader,
+		}, nil
+	}
+}
+
+// typeAllowed reports whether contentType matches one of allowed, e.g. image/png or image/*;
+// every type is allowed when allowed is empty
+func typeAllowed(allowed []string, contentType string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, pattern := range allowed {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == mediaType || pattern == "*/*" ||
+			(strings.HasSuffix(pattern, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(pattern, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+func unsupportedFileType(field string, contentType string, allowed []string) *exception.ExceptionError {
+	cErr := exception.DefaultCatalog().Get("UnsupportedMediaType")
+	if cErr == nil {
+		cErr = exception.NewExceptionError(http.StatusBadRequest, 210003, "Unsupported request media type", http.StatusUnsupportedMediaType)
+	}
+	return cErr.WithFields([]string{field}).WithDatas(map[string]string{
+		field:       contentType,
+		"supported": strings.Join(allowed, ", "),
+	})
+}
+
+// fileSizeLimiter fails with PayloadTooLarge once a file goes past its maximum size
+type fileSizeLimiter struct {
+	reader    io.Reader
+	field     string
+	remaining int64
+	max       int64
+}
+
+func (l *fileSizeLimiter) Read(p []byte) (int, error) {
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.reader.Read(p)
+	if int64(n) > l.remaining {
+		l.remaining = 0
+		return 0, payloadTooLarge(l.max).WithFields([]string{l.field})
+	}
+	l.remaining -= int64(n)
+	return n, err
+}
+
+// StoredFile is a file of an upload saved by UploadParts.SaveTo
+type StoredFile struct {
+	storage.Object
+	// Field is the form field of the file
+	Field string `json:"field"`
+	// Filename is the name given by the client
+	Filename string `json:"filename"`
+}
+
+// SaveTo streams the files left to store under new keys of prefix, at most max of them when
+// max is positive, returning them in order. The objects get the content type detected by Next,
+// the one sent by the client is not trusted. The form fields are left in Values.
+func (u *UploadParts) SaveTo(ctx context.Context, store storage.Storage, prefix string, max int) ([]StoredFile, error) {
+	var files []StoredFile
+	for {
+		file, err := u.Next()
+		if errors.Is(err, io.EOF) {
+			return files, nil
+		}
+		if err != nil {
+			return files, err
+		}
+		if max > 0 && len(files) == max {
+			return files, ValidationError(fmt.Errorf("at most %d files can be uploaded", max))
+		}
+
+		key, err := storage.UploadKey(prefix, file.Filename)
+		if err != nil {
+			return files, err
+		}
+		object, err := store.Put(ctx, key, file, storage.WithContentType(file.ContentType))
+		if err != nil {
+			return files, fmt.Errorf("error storing %s: %w", file.Filename, err)
+		}
+		files = append(files, StoredFile{Object: object, Field: file.Field, Filename: file.Filename})
+	}
+}
+
+// UploadEndpoint handles an upload, reading its files from parts, e.g. into a storage.Storage
+type UploadEndpoint[T, R any] func(ctx context.Context, req T, parts *UploadParts) (R, error)
+
+type uploadOptions struct {
+	maxSize      int64
+	maxFileSize  int64
+	allowedTypes []string
+}
+
+// UploadOption customizes NewUploadTransport
+type UploadOption func(*uploadOptions)
+
+// WithMaxUploadSize bounds the request body to size bytes, larger uploads failing with 413; 0
+// keeps DefaultMaxUploadSize
+func WithMaxUploadSize(size int64) UploadOption {
+	return func(o *uploadOptions) {
+		if size > 0 {
+			o.maxSize = size
+		}
+	}
+}
+
+// WithMaxFileSize bounds every file of the upload to size bytes; 0 leaves files bounded by the
+// maximum upload size only
+func WithMaxFileSize(size int64) UploadOption {
+	return func(o *uploadOptions) {
+		o.maxFileSize = size
+	}
+}
+
+// WithAllowedTypes restricts the files to the media types given, e.g. image/png, or to the
+// types of a family, e.g. image/*. The type is detected from the content of a file, not taken
+// from the client. No type given allows every type.
+func WithAllowedTypes(types ...string) UploadOption {
+	return func(o *uploadOptions) {
+		o.allowedTypes = types
+	}
+}
+
+// NewUploadTransport serves endpoint for multipart/form-data requests. The request is bound
+// from the path and query and validated like NewTransport, then the endpoint streams the
+// files from the body; the response is encoded like NewTransport. A body over the maximum
+// upload size fails with PayloadTooLarge, also once the endpoint started reading it, and so
+// does a file over WithMaxFileSize; a file of a type outside WithAllowedTypes fails with
+// UnsupportedMediaType.
+func NewUploadTransport[T, R any](req T, endpoint UploadEndpoint[T, R], opts ...UploadOption) TransportFunc[T, R] {
+	options := uploadOptions{maxSize: DefaultMaxUploadSize}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		newReq := deepCopy(req)
+		ctx := r.Context()
+		startTime := time.Now()
+
+		fail := func(err error, elapsed time.Duration) {
+			status := http.StatusInternalServerError
+			if exErr, ok := exception.AsExceptionError(err); ok {
+				status = exErr.HttpStatusCode
+				recordError(ctx, r, exErr.Code, status)
+				writeExceptionError(w, r, exErr)
+			} else {
+				recordError(ctx, r, 0, status)
+				HandleInternalServerError(w, r, status)
+			}
+			logRequestAndResponse(ctx, startTime, elapsed, r.Method, r.URL.Path, r.Header, nil, nil, err, status)
+		}
+
+		if r.ContentLength > options.maxSize {
+			fail(payloadTooLarge(options.maxSize), 0)
+			return
+		}
+		mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil || mediaType != "multipart/form-data" || params["boundary"] == "" {
+			cErr := exception.DefaultCatalog().Get("UnsupportedMediaType")
+			if cErr == nil {
+				cErr = exception.NewExceptionError(http.StatusBadRequest, 210003, "Unsupported request media type", http.StatusUnsupportedMediaType)
+			}
+			fail(cErr.WithDatas(map[string]string{"supported": "multipart/form-data"}), 0)
+			return
+		}
+
+		bindPathValues(r, newReq)
+		if err := bindQueryValues(r, newReq); err != nil {
+			fail(ValidationError(err), 0)
+			return
+		}
+		if exErr := validateRequest(newReq); exErr != nil {
+			fail(exErr, 0)
+			return
+		}
+		codec, err := negotiateCodec(r, reflect.TypeFor[R]())
+		if err != nil {
+			fail(err, 0)
+			return
+		}
+
+		body := http.MaxBytesReader(w, r.Body, options.maxSize)
+		defer body.Close()
+		parts := &UploadParts{reader: multipart.NewReader(body, params["boundary"]), options: options, Values: url.Values{}}
+
+		endpointCtx, meta := withResponseMeta(ctx)
+		endpointStart := time.Now()
+		resp, err := endpoint(endpointCtx, newReq, parts)
+		elapsed := time.Since(endpointStart)
+		if err != nil {
+			fail(uploadError(err), elapsed)
+			return
+		}
+
+		status, respBody, err := writeSuccess(w, r, codec, meta, resp)
+		if err != nil {
+			fail(err, elapsed)
+			return
+		}
+		logRequestAndResponse(ctx, endpointStart, elapsed, r.Method, r.URL.Path, r.Header, nil, respBody, nil, status)
+	}
+}
+
+// uploadError turns the body exceeding the maximum size into PayloadTooLarge, also when the
+// endpoint wrapped the error
+func uploadError(err error) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return payloadTooLarge(tooLarge.Limit)
+	}
+	return err
+}
+
+// partError reports a body that is not valid multipart as ValidationFailed; io.EOF is kept
+func partError(err error) error {
+	if errors.Is(err, io.EOF) {
+		return err
+	}
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return payloadTooLarge(tooLarge.Limit)
+	}
+	return ValidationError(err)
+}
+
+func payloadTooLarge(limit int64) *exception.ExceptionError {
+	cErr := exception.DefaultCatalog().Get("PayloadTooLarge")
+	if cErr == nil {
+		cErr = exception.NewExceptionError(http.StatusBadRequest, 210006, "Request body is too large", http.StatusRequestEntityTooLarge)
+	}
+	return cErr.WithDatas(map[string]string{"max_bytes": strconv.FormatInt(limit, 10)})
+}
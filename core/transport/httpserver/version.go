@@ -0,0 +1,74 @@
+package httpserver
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/yourorg/go-api-template/core/exception"
+)
+
+// AcceptVersionHeader selects the API version of a request routed by AcceptVersion
+const AcceptVersionHeader = "Accept-Version"
+
+type apiVersionKey struct{}
+
+// GetAPIVersionFromContext returns the API version set by VersionMiddleware or AcceptVersion,
+// for endpoints shared between versions
+func GetAPIVersionFromContext(ctx context.Context) (string, bool) {
+	version, ok := ctx.Value(apiVersionKey{}).(string)
+	return version, ok
+}
+
+// WithAPIVersion adds an API version to the context
+func WithAPIVersion(ctx context.Context, version string) context.Context {
+	return context.WithValue(ctx, apiVersionKey{}, version)
+}
+
+// VersionMiddleware creates a middleware that sets the API version of a path versioned group,
+// e.g. r.Group("/api/v2", httpserver.VersionMiddleware("v2"))
+func VersionMiddleware(version string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(WithAPIVersion(r.Context(), version)))
+		})
+	}
+}
+
+// AcceptVersion routes a request to the handler of the version named by the Accept-Version
+// header, defaultVersion when there is none; an unknown version yields an UnsupportedAPIVersion error.
+// It is the header alternative to versioned paths:
+//
+//	r.Get("/api/reports", httpserver.AcceptVersion(map[string]http.Handler{"v1": v1, "v2": v2}, "v1"))
+func AcceptVersion(handlers map[string]http.Handler, defaultVersion string) http.Handler {
+	handlers = maps.Clone(handlers)
+	// matched case-insensitively, the context gets the version as registered
+	byVersion := make(map[string]string, len(handlers))
+	for version := range handlers {
+		byVersion[strings.ToLower(version)] = version
+	}
+	supported := strings.Join(slices.Sorted(maps.Keys(handlers)), ", ")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", AcceptVersionHeader)
+
+		version := strings.TrimSpace(r.Header.Get(AcceptVersionHeader))
+		if version == "" {
+			version = defaultVersion
+		}
+		registered, ok := byVersion[strings.ToLower(version)]
+		if !ok {
+			cErr := exception.DefaultCatalog().Get("UnsupportedAPIVersion")
+			if cErr == nil {
+				cErr = exception.NewExceptionError(http.StatusBadRequest, 210005, "Unsupported API version", http.StatusBadRequest)
+			}
+			cErr = cErr.WithDatas(map[string]string{"version": version, "supported": supported})
+			recordError(r.Context(), r, cErr.Code, cErr.HttpStatusCode)
+			writeExceptionError(w, r, cErr)
+			return
+		}
+		handlers[registered].ServeHTTP(w, r.WithContext(WithAPIVersion(r.Context(), registered)))
+	})
+}
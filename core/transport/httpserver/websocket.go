@@ -0,0 +1,317 @@
+package httpserver
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/yourorg/go-api-template/core/exception"
+)
+
+const (
+	// DefaultWebSocketPingInterval is how often the server pings an idle connection
+	DefaultWebSocketPingInterval = 30 * time.Second
+	// DefaultWebSocketPongWait is how long a connection may stay silent, pongs included
+	DefaultWebSocketPongWait = 60 * time.Second
+	// DefaultWebSocketWriteTimeout bounds a single message write
+	DefaultWebSocketWriteTimeout = 10 * time.Second
+	// DefaultWebSocketReadLimit is the largest message accepted, in bytes
+	DefaultWebSocketReadLimit = 1 << 20
+)
+
+// ErrWebSocketClosed is returned by Receive and Send once the connection is closed
+var ErrWebSocketClosed = errors.New("websocket closed")
+
+// WebSocketHandler serves one connection. ctx is derived from the upgrade request,
+// so values set by middlewares such as the auth principal (see middleware.GetUserIDFromContext)
+// are available; it is canceled when the connection closes or the server shuts down.
+// Returning ends the connection, with an error close frame when err is not nil.
+type WebSocketHandler[In, Out any] func(ctx context.Context, conn *WebSocketConn[In, Out]) error
+
+type webSocketOptions struct {
+	pingInterval time.Duration
+	pongWait     time.Duration
+	writeTimeout time.Duration
+	readLimit    int64
+	checkOrigin  func(r *http.Request) bool
+	subprotocols []string
+	hub          *WebSocketHub
+}
+
+// WebSocketOption customizes NewWebSocketTransport
+type WebSocketOption func(*webSocketOptions)
+
+// WithKeepalive sets the ping interval and how long to wait for any message or pong
+func WithKeepalive(pingInterval time.Duration, pongWait time.Duration) WebSocketOption {
+	return func(o *webSocketOptions) {
+		o.pingInterval = pingInterval
+		o.pongWait = pongWait
+	}
+}
+
+// WithReadLimit sets the largest accepted message in bytes
+func WithReadLimit(limit int64) WebSocketOption {
+	return func(o *webSocketOptions) {
+		o.readLimit = limit
+	}
+}
+
+// WithCheckOrigin sets the origin policy; by default only same-origin browsers may connect
+func WithCheckOrigin(checkOrigin func(r *http.Request) bool) WebSocketOption {
+	return func(o *webSocketOptions) {
+		o.checkOrigin = checkOrigin
+	}
+}
+
+// WithSubprotocols sets the subprotocols the server accepts, in order of preference
+func WithSubprotocols(subprotocols ...string) WebSocketOption {
+	return func(o *webSocketOptions) {
+		o.subprotocols = subprotocols
+	}
+}
+
+// WithWebSocketHub tracks connections in hub instead of DefaultWebSocketHub
+func WithWebSocketHub(hub *WebSocketHub) WebSocketOption {
+	return func(o *webSocketOptions) {
+		o.hub = hub
+	}
+}
+
+// NewWebSocketTransport upgrades GET requests to WebSocket and runs handler on the connection.
+// Messages are JSON encoded; a ping is sent every ping interval and a connection that stays
+// silent longer than the pong wait is closed.
+func NewWebSocketTransport[In, Out any](handler WebSocketHandler[In, Out], opts ...WebSocketOption) http.HandlerFunc {
+	options := webSocketOptions{
+		pingInterval: DefaultWebSocketPingInterval,
+		pongWait:     DefaultWebSocketPongWait,
+		writeTimeout: DefaultWebSocketWriteTimeout,
+		readLimit:    DefaultWebSocketReadLimit,
+		hub:          DefaultWebSocketHub,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	upgrader := websocket.Upgrader{
+		CheckOrigin:  options.checkOrigin,
+		Subprotocols: options.subprotocols,
+		Error: func(w http.ResponseWriter, r *http.Request, status int, reason error) {
+			recordError(r.Context(), r, 0, status)
+			HandleInternalServerError(w, r, status)
+		},
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			// the upgrader already answered
+			return
+		}
+
+		ctx, cancel := context.WithCancel(r.Context())
+		conn := &WebSocketConn[In, Out]{ws: ws, options: &options, cancel: cancel}
+		if !options.hub.add(conn) {
+			// the server is shutting down
+			conn.closeWith(websocket.CloseGoingAway, "server shutting down")
+			cancel()
+			return
+		}
+		defer options.hub.remove(conn)
+
+		ws.SetReadLimit(options.readLimit)
+		ws.SetReadDeadline(time.Now().Add(options.pongWait))
+		ws.SetPongHandler(func(string) error {
+			return ws.SetReadDeadline(time.Now().Add(options.pongWait))
+		})
+
+		go conn.keepalive(ctx)
+
+		handlerErr := handler(ctx, conn)
+		cancel()
+		if ctx.Err() != nil && errors.Is(handlerErr, context.Canceled) {
+			handlerErr = nil
+		}
+
+		switch {
+		case handlerErr == nil, errors.Is(handlerErr, ErrWebSocketClosed), conn.isClosed():
+			conn.closeWith(websocket.CloseNormalClosure, "")
+		default:
+			reason := "internal error"
+			if exErr, ok := exception.AsExceptionError(handlerErr); ok {
+				reason = exErr.LocalizedMessage(requestLocale(r))
+			}
+			slog.ErrorContext(r.Context(), "WebSocket handler failed", "path", r.URL.Path, "error", handlerErr.Error())
+			conn.closeWith(websocket.CloseInternalServerErr, reason)
+		}
+	}
+}
+
+// WebSocketConn is a connection exchanging JSON messages, In from the client and Out to it.
+// Receive must be called from one goroutine at a time; Send is safe for concurrent use.
+type WebSocketConn[In, Out any] struct {
+	ws      *websocket.Conn
+	options *webSocketOptions
+	cancel  context.CancelFunc
+
+	writeMu sync.Mutex
+	closed  bool
+}
+
+// Receive reads the next message, returning ErrWebSocketClosed once the client closed the connection
+func (c *WebSocketConn[In, Out]) Receive() (In, error) {
+	var msg In
+	if err := c.ws.ReadJSON(&msg); err != nil {
+		if c.isClosed() || websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway, websocket.CloseNoStatusReceived) {
+			return msg, ErrWebSocketClosed
+		}
+		return msg, err
+	}
+	return msg, nil
+}
+
+// Send writes msg to the client
+func (c *WebSocketConn[In, Out]) Send(msg Out) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.closed {
+		return ErrWebSocketClosed
+	}
+
+	c.ws.SetWriteDeadline(time.Now().Add(c.options.writeTimeout))
+	return c.ws.WriteJSON(msg)
+}
+
+func (c *WebSocketConn[In, Out]) isClosed() bool {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.closed
+}
+
+// Subprotocol returns the negotiated subprotocol, "" when none
+func (c *WebSocketConn[In, Out]) Subprotocol() string {
+	return c.ws.Subprotocol()
+}
+
+func (c *WebSocketConn[In, Out]) keepalive(ctx context.Context) {
+	ticker := time.NewTicker(c.options.pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deadline := time.Now().Add(c.options.writeTimeout)
+			if err := c.ws.WriteControl(websocket.PingMessage, nil, deadline); err != nil {
+				// a failed ping means the connection is gone, unblock the handler
+				c.cancel()
+				c.ws.SetReadDeadline(time.Now())
+				return
+			}
+		}
+	}
+}
+
+// closeWith sends a close frame and closes the connection; later calls do nothing
+func (c *WebSocketConn[In, Out]) closeWith(code int, reason string) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.closed {
+		return
+	}
+	c.closed = true
+
+	// the reason of a close frame is limited to 123 bytes
+	if len(reason) > 123 {
+		reason = reason[:123]
+	}
+	c.ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(c.options.writeTimeout))
+	c.ws.Close()
+}
+
+// shutdown asks the client to go away and unblocks the handler
+func (c *WebSocketConn[In, Out]) shutdown() {
+	c.cancel()
+	c.closeWith(websocket.CloseGoingAway, "server shutting down")
+}
+
+// webSocketConn is the part of WebSocketConn the hub needs
+type webSocketConn interface {
+	shutdown()
+}
+
+// WebSocketHub tracks open connections so they can be closed on shutdown,
+// which http.Server.Shutdown does not do for upgraded connections
+type WebSocketHub struct {
+	mu       sync.Mutex
+	conns    map[webSocketConn]struct{}
+	closing  bool
+	inFlight sync.WaitGroup
+}
+
+// DefaultWebSocketHub tracks connections of transports without WithWebSocketHub
+var DefaultWebSocketHub = NewWebSocketHub()
+
+func NewWebSocketHub() *WebSocketHub {
+	return &WebSocketHub{conns: make(map[webSocketConn]struct{})}
+}
+
+// Len returns the number of open connections
+func (h *WebSocketHub) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.conns)
+}
+
+func (h *WebSocketHub) add(conn webSocketConn) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closing {
+		return false
+	}
+	h.conns[conn] = struct{}{}
+	h.inFlight.Add(1)
+	return true
+}
+
+func (h *WebSocketHub) remove(conn webSocketConn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.conns[conn]; ok {
+		delete(h.conns, conn)
+		h.inFlight.Done()
+	}
+}
+
+// Shutdown refuses new connections, sends a going-away close frame to open ones
+// and waits for their handlers to return or ctx to end. Register it with
+// http.Server.RegisterOnShutdown.
+func (h *WebSocketHub) Shutdown(ctx context.Context) error {
+	h.mu.Lock()
+	h.closing = true
+	conns := make([]webSocketConn, 0, len(h.conns))
+	for conn := range h.conns {
+		conns = append(conns, conn)
+	}
+	h.mu.Unlock()
+
+	for _, conn := range conns {
+		conn.shutdown()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		h.inFlight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,136 @@
+package usage
+
+import (
+	"context"
+	"iter"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/yourorg/go-api-template/core/exception"
+	"github.com/yourorg/go-api-template/core/httpclient"
+	"github.com/yourorg/go-api-template/core/httpclient/completions"
+)
+
+// MeterOption customizes a metered provider
+type MeterOption func(*meteredProvider)
+
+// WithMonthlyBudget rejects generations once a caller used tokens total tokens
+// in the current month; 0 records usage without enforcing a budget
+func WithMonthlyBudget(tokens int64) MeterOption {
+	return func(p *meteredProvider) {
+		p.budget = tokens
+	}
+}
+
+// WithClock sets the clock used to select the accounting period
+func WithClock(now func() time.Time) MeterOption {
+	return func(p *meteredProvider) {
+		p.now = now
+	}
+}
+
+type meteredProvider struct {
+	httpclient.LLMProvider
+	store  Store
+	budget int64
+	now    func() time.Time
+}
+
+// NewMeteredProvider records the token usage reported by provider per caller,
+// see CallerFromContext. Store errors are logged and do not fail the generation.
+func NewMeteredProvider(provider httpclient.LLMProvider, store Store, opts ...MeterOption) httpclient.LLMProvider {
+	p := &meteredProvider{
+		LLMProvider: provider,
+		store:       store,
+		now:         time.Now,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+func (p *meteredProvider) Complete(ctx context.Context, req completions.CompletionRequest) (completions.CompletionResponse, error) {
+	caller, period := CallerFromContext(ctx), Period(p.now())
+	if err := p.checkBudget(ctx, caller, period); err != nil {
+		return completions.CompletionResponse{}, err
+	}
+
+	resp, err := p.LLMProvider.Complete(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+	p.record(ctx, caller, period, resp.Usage)
+	return resp, nil
+}
+
+func (p *meteredProvider) CompleteStream(ctx context.Context, req completions.CompletionRequest) iter.Seq2[completions.CompletionChunk, error] {
+	return func(yield func(completions.CompletionChunk, error) bool) {
+		caller, period := CallerFromContext(ctx), Period(p.now())
+		if err := p.checkBudget(ctx, caller, period); err != nil {
+			yield(completions.CompletionChunk{}, err)
+			return
+		}
+
+		// usage is reported on the last chunk, also record it when the caller stops early
+		var usage *completions.Usage
+		defer func() {
+			if usage != nil {
+				p.record(ctx, caller, period, *usage)
+			}
+		}()
+
+		for chunk, err := range p.LLMProvider.CompleteStream(ctx, req) {
+			if chunk.Usage != nil {
+				usage = chunk.Usage
+			}
+			if !yield(chunk, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (p *meteredProvider) checkBudget(ctx context.Context, caller string, period string) error {
+	if p.budget <= 0 {
+		return nil
+	}
+
+	used, err := p.store.Get(ctx, caller, period)
+	if err != nil {
+		// fail open, the store being down should not take generation down with it
+		slog.WarnContext(ctx, "Failed to read token usage, budget not enforced", "caller", caller, "error", err.Error())
+		return nil
+	}
+	if used.TotalTokens < p.budget {
+		return nil
+	}
+	return budgetError(used.TotalTokens, p.budget)
+}
+
+func (p *meteredProvider) record(ctx context.Context, caller string, period string, usage completions.Usage) {
+	// the request may already be canceled, the tokens were spent regardless
+	err := p.store.Add(context.WithoutCancel(ctx), caller, period, Usage{
+		PromptTokens:     int64(usage.PromptTokens),
+		CompletionTokens: int64(usage.CompletionTokens),
+		TotalTokens:      int64(usage.TotalTokens),
+		Requests:         1,
+	})
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to record token usage", "caller", caller, "error", err.Error())
+	}
+}
+
+// budgetError returns the catalog TokenBudgetExceeded error
+func budgetError(used int64, budget int64) error {
+	cErr := exception.DefaultCatalog().Get("TokenBudgetExceeded")
+	if cErr == nil {
+		cErr = exception.NewExceptionError(http.StatusBadRequest, 200004, "Monthly token budget exceeded", http.StatusTooManyRequests)
+	}
+	return cErr.WithDatas(map[string]string{
+		"used_tokens":   strconv.FormatInt(used, 10),
+		"budget_tokens": strconv.FormatInt(budget, 10),
+	})
+}
@@ -0,0 +1,140 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/yourorg/go-api-template/core/cache"
+	"github.com/yourorg/go-api-template/core/pgdb"
+)
+
+// MemoryStore keeps usage in process; counters are lost on restart and not shared between instances
+type MemoryStore struct {
+	mu    sync.Mutex
+	usage map[string]Usage
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{usage: make(map[string]Usage)}
+}
+
+func (s *MemoryStore) Add(ctx context.Context, caller string, period string, usage Usage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := caller + ":" + period
+	current := s.usage[key]
+	current.PromptTokens += usage.PromptTokens
+	current.CompletionTokens += usage.CompletionTokens
+	current.TotalTokens += usage.TotalTokens
+	current.Requests += usage.Requests
+	s.usage[key] = current
+	return nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, caller string, period string) (Usage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.usage[caller+":"+period], nil
+}
+
+// redisRetention keeps a period's counters for a while after the month ends
+const redisRetention = 62 * 24 * time.Hour
+
+// RedisStore keeps usage in a hash per caller and period, shared between instances
+type RedisStore struct {
+	cacheService cache.CacheService
+}
+
+// NewRedisStore creates a store on the given Redis service
+func NewRedisStore(cacheService cache.CacheService) *RedisStore {
+	return &RedisStore{cacheService: cacheService}
+}
+
+func (s *RedisStore) key(caller string, period string) string {
+	return fmt.Sprintf("llm_usage:%s:%s", caller, period)
+}
+
+func (s *RedisStore) Add(ctx context.Context, caller string, period string, usage Usage) error {
+	key := s.key(caller, period)
+
+	pipe := s.cacheService.GetClient().TxPipeline()
+	pipe.HIncrBy(ctx, key, "prompt_tokens", usage.PromptTokens)
+	pipe.HIncrBy(ctx, key, "completion_tokens", usage.CompletionTokens)
+	pipe.HIncrBy(ctx, key, "total_tokens", usage.TotalTokens)
+	pipe.HIncrBy(ctx, key, "requests", usage.Requests)
+	pipe.Expire(ctx, key, redisRetention)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("error recording usage: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisStore) Get(ctx context.Context, caller string, period string) (Usage, error) {
+	values, err := s.cacheService.GetClient().HGetAll(ctx, s.key(caller, period)).Result()
+	if err != nil {
+		return Usage{}, fmt.Errorf("error reading usage: %w", err)
+	}
+
+	field := func(name string) int64 {
+		n, _ := strconv.ParseInt(values[name], 10, 64)
+		return n
+	}
+	return Usage{
+		PromptTokens:     field("prompt_tokens"),
+		CompletionTokens: field("completion_tokens"),
+		TotalTokens:      field("total_tokens"),
+		Requests:         field("requests"),
+	}, nil
+}
+
+const TableName = "llm_token_usage"
+
+// PostgresStore keeps usage in the llm_token_usage table, see migrations
+type PostgresStore struct {
+	db pgdb.DBTX
+}
+
+// NewPostgresStore creates a store on db, usually the write pool
+func NewPostgresStore(db pgdb.DBTX) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+func (s *PostgresStore) Add(ctx context.Context, caller string, period string, usage Usage) error {
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO `+TableName+` (caller, period, prompt_tokens, completion_tokens, total_tokens, requests)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (caller, period) DO UPDATE SET
+			prompt_tokens = `+TableName+`.prompt_tokens + EXCLUDED.prompt_tokens,
+			completion_tokens = `+TableName+`.completion_tokens + EXCLUDED.completion_tokens,
+			total_tokens = `+TableName+`.total_tokens + EXCLUDED.total_tokens,
+			requests = `+TableName+`.requests + EXCLUDED.requests,
+			updated_at = CURRENT_TIMESTAMP`,
+		caller, period, usage.PromptTokens, usage.CompletionTokens, usage.TotalTokens, usage.Requests)
+	if err != nil {
+		return fmt.Errorf("error recording usage: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) Get(ctx context.Context, caller string, period string) (Usage, error) {
+	var usage Usage
+	err := s.db.QueryRow(ctx, `
+		SELECT prompt_tokens, completion_tokens, total_tokens, requests
+		FROM `+TableName+`
+		WHERE caller = $1 AND period = $2`,
+		caller, period).Scan(&usage.PromptTokens, &usage.CompletionTokens, &usage.TotalTokens, &usage.Requests)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Usage{}, nil
+	}
+	if err != nil {
+		return Usage{}, fmt.Errorf("error reading usage: %w", err)
+	}
+	return usage, nil
+}
@@ -0,0 +1,46 @@
+package usage
+
+import (
+	"context"
+	"time"
+
+	middleware "github.com/yourorg/go-api-template/core/transport/httpserver/middlewares"
+)
+
+const (
+	StoreMemory   = "memory"
+	StoreRedis    = "redis"
+	StorePostgres = "postgres"
+
+	// AnonymousCaller is charged for requests without an authenticated user
+	AnonymousCaller = "anonymous"
+)
+
+// Usage is the token consumption of a caller within a period
+type Usage struct {
+	PromptTokens     int64 `json:"prompt_tokens"`
+	CompletionTokens int64 `json:"completion_tokens"`
+	TotalTokens      int64 `json:"total_tokens"`
+	Requests         int64 `json:"requests"`
+}
+
+// Store accumulates usage per caller and period
+type Store interface {
+	// Add increments the caller's usage for the period
+	Add(ctx context.Context, caller string, period string, usage Usage) error
+	// Get returns the caller's usage for the period, zero when nothing was recorded
+	Get(ctx context.Context, caller string, period string) (Usage, error)
+}
+
+// Period returns the monthly accounting period of t, e.g. "2025-01"
+func Period(t time.Time) string {
+	return t.UTC().Format("2006-01")
+}
+
+// CallerFromContext returns the authenticated user, or AnonymousCaller
+func CallerFromContext(ctx context.Context) string {
+	if userID, ok := middleware.GetUserIDFromContext(ctx); ok && userID != "" {
+		return userID
+	}
+	return AnonymousCaller
+}
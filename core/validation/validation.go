@@ -2,11 +2,79 @@ package validation
 
 import (
 	"errors"
+	"fmt"
 	"reflect"
 	"strings"
+	"sync"
+
+	"github.com/go-playground/validator/v10"
 )
 
-// ValidateStruct checks for fields with the `validate:"true"` tag and ensures they are not empty.
+// FieldError is a single field failing a validation rule
+type FieldError struct {
+	Field   string // json name, dotted for nested fields
+	Rule    string // the failing tag, e.g. required or min
+	Param   string // the parameter of the rule, e.g. 3 for min=3
+	Message string
+	// Key is the i18n message key translating Message, e.g. validation.min.string; empty for a
+	// message without translations
+	Key string
+}
+
+// Params returns the parameters of the translations of Message: field, rule and param
+func (fe FieldError) Params() map[string]string {
+	return map[string]string{"field": fe.Field, "rule": fe.Rule, "param": fe.Param}
+}
+
+// Errors aggregates every failing field of a struct
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, fe := range e {
+		messages[i] = fe.Field + " " + fe.Message
+	}
+	return "validation failed: " + strings.Join(messages, "; ")
+}
+
+// Fields returns the names of the failing fields
+func (e Errors) Fields() []string {
+	fields := make([]string, len(e))
+	for i, fe := range e {
+		fields[i] = fe.Field
+	}
+	return fields
+}
+
+var (
+	validate     *validator.Validate
+	validateOnce sync.Once
+)
+
+// Validator returns the shared validator, e.g. to register custom rules at startup
+func Validator() *validator.Validate {
+	validateOnce.Do(func() {
+		validate = validator.New(validator.WithRequiredStructEnabled())
+		// report fields by their json name
+		validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" {
+				// query parameters are reported by their parameter name
+				return field.Tag.Get("query")
+			}
+			if name == "" {
+				return field.Name
+			}
+			return name
+		})
+		// `validate:"true"` is the legacy spelling of required
+		validate.RegisterAlias("true", "required")
+	})
+	return validate
+}
+
+// ValidateStruct checks s against its `validate` struct tags, see go-playground/validator.
+// It returns Errors listing every failing field.
 func ValidateStruct(s any) error {
 	val := reflect.ValueOf(s)
 	if val.Kind() == reflect.Ptr {
@@ -17,19 +85,103 @@ func ValidateStruct(s any) error {
 		return errors.New("expected a struct or a pointer to a struct")
 	}
 
-	errorFields := []string{}
+	err := Validator().Struct(s)
+	if err == nil {
+		return nil
+	}
+
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return err
+	}
+
+	errs := make(Errors, 0, len(validationErrs))
+	for _, fe := range validationErrs {
+		errs = append(errs, FieldError{
+			Field:   fieldPath(val.Type(), fe),
+			Rule:    fe.Tag(),
+			Param:   fe.Param(),
+			Message: message(fe),
+			Key:     messageKey(fe),
+		})
+	}
+	return errs
+}
 
-	for i := 0; i < val.NumField(); i++ {
-		field := val.Field(i)
-		tag := val.Type().Field(i).Tag.Get("validate")
-		if tag == "true" && field.IsZero() {
-			errorFields = append(errorFields, val.Type().Field(i).Name)
+// fieldPath drops the struct name from the namespace, e.g. Request.items[0].name becomes items[0].name.
+// Untagged embedded structs are flattened like encoding/json does, so their name is dropped too.
+func fieldPath(t reflect.Type, fe validator.FieldError) string {
+	names := strings.Split(fe.Namespace(), ".")
+	goNames := strings.Split(fe.StructNamespace(), ".")
+	if len(names) < 2 || len(names) != len(goNames) {
+		return fe.Field()
+	}
+
+	path := make([]string, 0, len(names)-1)
+	for i := 1; i < len(names); i++ {
+		if t != nil {
+			goName, _, _ := strings.Cut(goNames[i], "[")
+			if field, ok := t.FieldByName(goName); ok {
+				t = structType(field.Type)
+				if field.Anonymous && field.Tag.Get("json") == "" {
+					continue
+				}
+			} else {
+				t = nil
+			}
 		}
+		path = append(path, names[i])
 	}
+	return strings.Join(path, ".")
+}
 
-	if len(errorFields) > 0 {
-		return errors.New("fields are empty or zero value: " + strings.Join(errorFields, ", "))
+// structType returns the struct type behind pointers, slices and maps, nil for other types
+func structType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
 	}
+	return t
+}
+
+func message(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required", "true":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "min":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at least %s characters", fe.Param())
+		}
+		return fmt.Sprintf("must be at least %s", fe.Param())
+	case "max":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at most %s characters", fe.Param())
+		}
+		return fmt.Sprintf("must be at most %s", fe.Param())
+	case "oneof":
+		return "must be one of: " + fe.Param()
+	case "datetime":
+		return "must match the format " + fe.Param()
+	default:
+		return fmt.Sprintf("failed the %s rule", fe.Tag())
+	}
+}
 
-	return nil
+// messageKey returns the i18n key of the message of fe, validation.<rule> with a .string suffix
+// for the length rules of strings, see core/i18n/locales
+func messageKey(fe validator.FieldError) string {
+	tag := fe.Tag()
+	switch tag {
+	case "true":
+		tag = "required"
+	case "min", "max":
+		if fe.Kind() == reflect.String {
+			tag += ".string"
+		}
+	}
+	return "validation." + tag
 }
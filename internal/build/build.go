@@ -1,5 +1,24 @@
+// Package build holds the build metadata of the binary, injected with ldflags:
+//
+//	go build -ldflags "-X github.com/yourorg/go-api-template/internal/build.Version=v1.2.0 \
+//	  -X github.com/yourorg/go-api-template/internal/build.Commit=$(git rev-parse HEAD) \
+//	  -X github.com/yourorg/go-api-template/internal/build.Time=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Values left out fall back to what the Go toolchain embeds in the binary.
 package build
 
+import (
+	"runtime"
+	"runtime/debug"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// unknown is the value of metadata neither injected nor embedded by the toolchain
+const unknown = "N/A"
+
 var (
 	// Service name (fixed)
 	Service = "ai-mock-data-service"
@@ -8,20 +27,83 @@ var (
 	ServiceName = "ai-mock-data-service"
 
 	// Version number
-	Version = "N/A"
+	Version = unknown
 
 	// Build time
-	Time = "N/A"
+	Time = unknown
 
 	// Build commit
-	Commit = "N/A"
+	Commit = unknown
 
 	// OS and architecture used for building
-	OSArch = "N/A"
+	OSArch = unknown
 
 	// Git branch name used for building
-	BranchName = "N/A"
+	BranchName = unknown
 
 	// Go version used for building
-	GoVersion = "N/A"
+	GoVersion = unknown
 )
+
+func init() {
+	GoVersion = orDefault(GoVersion, runtime.Version())
+	OSArch = orDefault(OSArch, runtime.GOOS+"/"+runtime.GOARCH)
+
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return
+	}
+	// go install module@version records the module version, go build of a checkout the VCS state
+	if info.Main.Version != "" && info.Main.Version != "(devel)" {
+		Version = orDefault(Version, info.Main.Version)
+	}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			Commit = orDefault(Commit, setting.Value)
+		case "vcs.time":
+			Time = orDefault(Time, setting.Value)
+		}
+	}
+}
+
+// orDefault returns fallback when value was not injected
+func orDefault(value, fallback string) string {
+	if value == unknown || value == "" {
+		return fallback
+	}
+	return value
+}
+
+// Info is the build metadata printed by the version command
+type Info struct {
+	Service   string `json:"service"`
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	Time      string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+	OSArch    string `json:"os_arch"`
+}
+
+// Get returns the build metadata
+func Get() Info {
+	return Info{
+		Service:   ServiceName,
+		Version:   Version,
+		Commit:    Commit,
+		Time:      Time,
+		GoVersion: GoVersion,
+		OSArch:    OSArch,
+	}
+}
+
+// Resource describes the service to OpenTelemetry, for the resource of tracer and meter providers
+func Resource() *resource.Resource {
+	return resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(ServiceName),
+		semconv.ServiceVersion(Version),
+		semconv.ProcessRuntimeVersion(GoVersion),
+		attribute.String("vcs.ref.head.revision", Commit),
+		attribute.String("build.time", Time),
+	)
+}
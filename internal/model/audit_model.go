@@ -0,0 +1,16 @@
+package model
+
+import "github.com/yourorg/go-api-template/core/audit"
+
+// AuditTrailRequest selects the entity whose changes are listed
+type AuditTrailRequest struct {
+	Entity string `json:"-" path:"entity" validate:"required" description:"Entity type, e.g. example"`
+	ID     string `json:"-" path:"id" validate:"required" description:"Entity ID"`
+	Limit  int    `json:"-" query:"limit" validate:"omitempty,min=1,max=100" description:"Number of records, 20 by default"`
+}
+
+// AuditTrailResponse lists the changes of an entity, newest first
+type AuditTrailResponse struct {
+	Status int            `json:"status"`
+	Data   []audit.Record `json:"data"`
+}
@@ -1,10 +1,12 @@
 package model
 
+import "net/http"
+
 // Example models for demonstration - replace with your actual models
 
 // ExampleRequest represents a request to get an example
 type ExampleRequest struct {
-	ID string `json:"id" validate:"required"`
+	ID string `json:"id" path:"id" validate:"required"`
 }
 
 // ExampleResponse represents a response containing example data
@@ -18,6 +20,19 @@ type ExampleResponse_Data struct {
 	Message string `json:"message"`
 }
 
+// ListExamplesRequest represents a request to list examples, e.g. ?sort=-created_at&cursor=...
+type ListExamplesRequest struct {
+	ListParams
+}
+
+// ExampleItem is an example in a list
+type ExampleItem struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	CreatedAt   string `json:"created_at"`
+}
+
 // CreateExampleRequest represents a request to create a new example
 type CreateExampleRequest struct {
 	Name        string `json:"name" validate:"required,min=3,max=100"`
@@ -30,6 +45,16 @@ type CreateExampleResponse struct {
 	Data   CreateExampleResponse_Data  `json:"data"`
 }
 
+// StatusCode answers 201 Created, see httpserver.StatusCoder
+func (r *CreateExampleResponse) StatusCode() int {
+	return http.StatusCreated
+}
+
+// Headers points Location at the created example, see httpserver.Headerer
+func (r *CreateExampleResponse) Headers() http.Header {
+	return http.Header{"Location": {"/api/v1/examples/" + r.Data.ID}}
+}
+
 type CreateExampleResponse_Data struct {
 	ID      string `json:"id"`
 	Name    string `json:"name"`
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"net/http"
+
+	"github.com/yourorg/go-api-template/core/transport/httpserver"
+)
+
+// UploadFilesRequest is bound from the query, the files come from the multipart body
+type UploadFilesRequest struct{}
+
+// UploadFilesResponse lists the stored files in the order they were sent
+type UploadFilesResponse struct {
+	Status int            `json:"status"`
+	Data   []UploadedFile `json:"data"`
+}
+
+// StatusCode answers 201 Created, see httpserver.StatusCoder
+func (r *UploadFilesResponse) StatusCode() int {
+	return http.StatusCreated
+}
+
+// UploadedFile is a stored file with a signed URL to download it
+type UploadedFile struct {
+	httpserver.StoredFile
+	URL string `json:"url"`
+}
@@ -0,0 +1,137 @@
+package model
+
+import (
+	"net/url"
+	"slices"
+
+	"github.com/yourorg/go-api-template/core/exception"
+	"github.com/yourorg/go-api-template/core/pgdb/pagination"
+	"github.com/yourorg/go-api-template/core/transport/httpserver"
+	"github.com/yourorg/go-api-template/core/validation"
+)
+
+// ListParams is embedded in the request of list endpoints:
+//
+//	?page=2&limit=20 or ?cursor=...&limit=20
+//	?sort=-created_at,name
+//	?filter[status]=active&filter[price][gte]=10
+//
+// The transport binds and validates it; the service checks sort and filter fields with CheckFields.
+type ListParams struct {
+	Page   int    `json:"-" query:"page" validate:"omitempty,min=1" description:"1-based page number, not combined with cursor"`
+	Limit  int    `json:"-" query:"limit" validate:"omitempty,min=1,max=100" description:"Page size, 20 by default"`
+	Cursor string `json:"-" query:"cursor" description:"Opaque cursor of the next page, see next_cursor"`
+	// Sort and Filters are parsed by BindQuery, the tags document them
+	Sort    []httpserver.SortField `json:"-" query:"sort" description:"Comma separated fields, - prefix for descending"`
+	Filters []httpserver.Filter    `json:"-" query:"filter" description:"filter[field]=value or filter[field][op]=value, op being eq, ne, gt, gte, lt, lte, like or in"`
+}
+
+// BindQuery parses sort and filter parameters, see httpserver.QueryBinder
+func (p *ListParams) BindQuery(values url.Values) error {
+	var fieldErrs validation.Errors
+	sort, err := httpserver.ParseSort(values.Get("sort"))
+	if errs, ok := err.(validation.Errors); ok {
+		fieldErrs = append(fieldErrs, errs...)
+	}
+	filters, err := httpserver.ParseFilters(values)
+	if errs, ok := err.(validation.Errors); ok {
+		fieldErrs = append(fieldErrs, errs...)
+	}
+	if p.Page > 0 && p.Cursor != "" {
+		fieldErrs = append(fieldErrs, validation.FieldError{Field: "cursor", Rule: "excluded_with", Message: "cannot be combined with page"})
+	}
+	if p.Cursor != "" {
+		if _, err := pagination.DecodeCursor(p.Cursor); err != nil {
+			fieldErrs = append(fieldErrs, validation.FieldError{Field: "cursor", Rule: "cursor", Message: "is not a valid cursor"})
+		}
+	}
+	if len(fieldErrs) > 0 {
+		return fieldErrs
+	}
+
+	p.Sort, p.Filters = sort, filters
+	return nil
+}
+
+// CheckFields rejects sort and filter fields the endpoint does not support
+func (p ListParams) CheckFields(sortable []string, filterable []string) *exception.ExceptionError {
+	var fieldErrs validation.Errors
+	for _, field := range p.Sort {
+		if !slices.Contains(sortable, field.Field) {
+			fieldErrs = append(fieldErrs, validation.FieldError{Field: "sort", Rule: "oneof", Message: "cannot sort by " + field.Field})
+		}
+	}
+	for _, filter := range p.Filters {
+		if !slices.Contains(filterable, filter.Field) {
+			fieldErrs = append(fieldErrs, validation.FieldError{Field: "filter[" + filter.Field + "]", Rule: "oneof", Message: "is not a filterable field"})
+		}
+	}
+	if len(fieldErrs) > 0 {
+		return httpserver.ValidationError(fieldErrs)
+	}
+	return nil
+}
+
+// CheckCursorPaging rejects the page parameter on endpoints paging with cursors only
+func (p ListParams) CheckCursorPaging() *exception.ExceptionError {
+	if p.Page > 0 {
+		return httpserver.ValidationError(validation.Errors{{Field: "page", Rule: "excluded", Message: "is not supported, follow next_cursor instead"}})
+	}
+	return nil
+}
+
+// PageSize returns Limit clamped to pagination.MaxLimit, pagination.DefaultLimit when unset
+func (p ListParams) PageSize() int {
+	return pagination.NormalizeLimit(p.Limit)
+}
+
+// Offset returns the number of items before the requested page
+func (p ListParams) Offset() int {
+	if p.Page <= 1 {
+		return 0
+	}
+	return (p.Page - 1) * p.PageSize()
+}
+
+// PaginationRequest returns the keyset pagination request, see pagination.Build
+func (p ListParams) PaginationRequest() pagination.Request {
+	return pagination.Request{Cursor: p.Cursor, Limit: p.Limit}
+}
+
+// SortColumns returns the keyset columns of the requested sort, ending with the unique
+// column (e.g. the primary key) that keeps the order stable. Check the fields with CheckFields first.
+func (p ListParams) SortColumns(unique string) []pagination.Column {
+	columns := make([]pagination.Column, 0, len(p.Sort)+1)
+	for _, field := range p.Sort {
+		columns = append(columns, pagination.Column{Name: field.Field, Desc: field.Desc})
+	}
+	if !slices.ContainsFunc(columns, func(c pagination.Column) bool { return c.Name == unique }) {
+		columns = append(columns, pagination.Column{Name: unique})
+	}
+	return columns
+}
+
+// PagedResponse is the response of list endpoints
+type PagedResponse[T any] struct {
+	Status int `json:"status"`
+	Data   []T `json:"data"`
+	// Total is the number of items across pages, omitted when not counted (cursor pages)
+	Total *int64 `json:"total,omitempty"`
+	Page  int    `json:"page,omitempty"`
+	Limit int    `json:"limit"`
+	// NextCursor fetches the following page with ?cursor=, empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// NewPagedResponse returns a page of items counted out of total
+func NewPagedResponse[T any](items []T, params ListParams, total int64) *PagedResponse[T] {
+	if items == nil {
+		items = []T{}
+	}
+	return &PagedResponse[T]{Status: 200, Data: items, Total: &total, Page: max(params.Page, 1), Limit: params.PageSize()}
+}
+
+// NewCursorResponse returns the keyset page made by pagination.NewPage
+func NewCursorResponse[T any](page pagination.Page[T], params ListParams) *PagedResponse[T] {
+	return &PagedResponse[T]{Status: 200, Data: page.Items, Limit: params.PageSize(), NextCursor: page.NextCursor}
+}
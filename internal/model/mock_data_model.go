@@ -0,0 +1,16 @@
+package model
+
+// GenerateMockDataBatchRequest generates mock data for related tables of the schema repository
+type GenerateMockDataBatchRequest struct {
+	Tables []string `json:"tables" validate:"required,min=1,max=100,dive,required,max=100" description:"Tables to generate, or [\"all\"] for every table of the schema repository; their parents are included"`
+	Rows   int      `json:"rows" validate:"omitempty,min=1" description:"Rows per table, defaults to mockData.defaultRows"`
+	// Apply inserts the generated rows into the target database, see mockData.apply
+	Apply *MockDataApplyOptions `json:"apply,omitempty" description:"Inserts the generated rows into the target database instead of only returning the SQL"`
+}
+
+// MockDataApplyOptions executes the generated mock data against the target database
+type MockDataApplyOptions struct {
+	Transaction string `json:"transaction,omitempty" validate:"omitempty,oneof=batch table" description:"batch commits the batch at once when every table applied, table commits each table; defaults to mockData.apply.transaction"`
+	BatchSize   int    `json:"batch_size,omitempty" validate:"omitempty,min=1,max=10000" description:"Rows per INSERT executed, defaults to mockData.apply.batchSize"`
+	DryRun      bool   `json:"dry_run,omitempty" description:"Executes the inserts in a transaction rolled back"`
+}
@@ -0,0 +1,25 @@
+package model
+
+import "github.com/yourorg/go-api-template/core/notify"
+
+// NotificationPreferencesRequest reads the preferences of the authenticated user
+type NotificationPreferencesRequest struct{}
+
+// UpdateNotificationPreferencesRequest turns channels on or off for event types, the other
+// preferences of the user are kept
+type UpdateNotificationPreferencesRequest struct {
+	Preferences []NotificationPreference `json:"preferences" validate:"required,min=1,max=100,dive"`
+}
+
+// NotificationPreference turns a channel on or off for an event type
+type NotificationPreference struct {
+	Event   string `json:"event" validate:"required,max=255" description:"Event type, e.g. order.shipped, or * for every type"`
+	Channel string `json:"channel" validate:"required,oneof=email webhook slack" description:"email, webhook or slack"`
+	Enabled *bool  `json:"enabled" validate:"required"`
+}
+
+// NotificationPreferencesResponse lists the preferences of the user
+type NotificationPreferencesResponse struct {
+	Status int                 `json:"status"`
+	Data   []notify.Preference `json:"data"`
+}
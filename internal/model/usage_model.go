@@ -0,0 +1,23 @@
+package model
+
+import "github.com/yourorg/go-api-template/core/usage"
+
+// UsageRequest selects the month to report, defaults to the current one
+type UsageRequest struct {
+	Period string `json:"period,omitempty" validate:"omitempty,datetime=2006-01"`
+}
+
+// UsageResponse reports the caller's token consumption
+type UsageResponse struct {
+	Status int                `json:"status"`
+	Data   UsageResponse_Data `json:"data"`
+}
+
+type UsageResponse_Data struct {
+	Caller string      `json:"caller"`
+	Period string      `json:"period"`
+	Usage  usage.Usage `json:"usage"`
+	// Budget is the monthly token budget, 0 when not enforced
+	Budget          int64 `json:"budget"`
+	RemainingTokens int64 `json:"remaining_tokens,omitempty"`
+}
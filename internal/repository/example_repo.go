@@ -2,14 +2,17 @@ package repository
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/yourorg/go-api-template/core/pgdb/pagination"
 )
 
 // ExampleRepository demonstrates how to implement a repository in this template
 // This is just an example - replace with your actual data access interfaces
 type ExampleRepository interface {
 	GetExampleByID(ctx context.Context, id string) (*ExampleData, error)
+	ListExamples(ctx context.Context, page pagination.Fragment) ([]*ExampleData, error)
 	CreateExample(ctx context.Context, data *ExampleData) error
 	UpdateExample(ctx context.Context, id string, data *ExampleData) error
 	DeleteExample(ctx context.Context, id string) error
@@ -54,6 +57,29 @@ func (r *exampleRepositoryImpl) GetExampleByID(ctx context.Context, id string) (
 	}, nil
 }
 
+// ListExamples retrieves the examples of a keyset page, see pagination.Build
+func (r *exampleRepositoryImpl) ListExamples(ctx context.Context, page pagination.Fragment) ([]*ExampleData, error) {
+	// Example implementation - replace with your actual SQL queries
+	// rows, err := r.readPgPool.Query(ctx, page.SQL("SELECT id, name, description, created_at FROM examples"), page.Args)
+	// items, err := pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[ExampleData])
+
+	// For now, return mock data: a single page, the cursor of the next one finds no more examples
+	if page.Where != "" {
+		return nil, nil
+	}
+	var items []*ExampleData
+	for i := range 3 {
+		items = append(items, &ExampleData{
+			ID:          fmt.Sprintf("example-%d", i+1),
+			Name:        fmt.Sprintf("Example Item %d", i+1),
+			Description: "This is an example from the template",
+			CreatedAt:   "2024-01-01T00:00:00Z",
+			UpdatedAt:   "2024-01-01T00:00:00Z",
+		})
+	}
+	return items, nil
+}
+
 // CreateExample creates a new example in the database
 func (r *exampleRepositoryImpl) CreateExample(ctx context.Context, data *ExampleData) error {
 	// Example implementation - replace with your actual SQL queries
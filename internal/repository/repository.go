@@ -13,6 +13,9 @@ type Repository struct{
 	
 	// Example repositories - replace with your actual repositories
 	ExampleRepository ExampleRepository
+	// SchemaRepository holds the table scripts mock data is generated for
+	SchemaRepository SchemaRepository
+	// +scaffold:repository-fields - `generate resource` adds repositories above
 }
 
 func NewRepository() (*Repository, error) {
@@ -26,6 +29,10 @@ func NewRepository() (*Repository, error) {
 		return nil, fmt.Errorf("error getting write pool: %w", err)
 	}
 
+	// Register tables that use soft delete (deleted_at column) so sqllib
+	// filters deleted rows and turns deletes into updates, e.g.
+	// sqllib.EnableSoftDelete("products")
+
 	slog.Info("Repository initialized", "readPgPool", readPgPool!=nil, "writePgPool", writePgPool!=nil)
 	// Initialize all repositories here
 	return &Repository{
@@ -33,5 +40,7 @@ func NewRepository() (*Repository, error) {
 		
 		// Example repositories - replace with your actual repositories
 		ExampleRepository: NewExampleRepository(readPgPool, writePgPool),
+		SchemaRepository:  NewSchemaRepository(readPgPool),
+		// +scaffold:repositories
 	}, nil
 }
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	db_sqlc "github.com/yourorg/go-api-template/internal/sqlc/db"
+)
+
+// ErrSchemaNotFound is returned for a table missing from the schema repository
+var ErrSchemaNotFound = errors.New("table schema not found")
+
+// SchemaRepository reads the CREATE TABLE scripts of the database_schemas table, the tables
+// mock data is generated for
+type SchemaRepository interface {
+	GetTableNames(ctx context.Context) ([]string, error)
+	// GetTableScript returns the script of a table, ErrSchemaNotFound when it has none
+	GetTableScript(ctx context.Context, tableName string) (string, error)
+}
+
+type schemaRepositoryImpl struct {
+	queries *db_sqlc.Queries
+}
+
+// NewSchemaRepository creates a schema repository reading from the read pool
+func NewSchemaRepository(readPgPool *pgxpool.Pool) SchemaRepository {
+	return &schemaRepositoryImpl{queries: db_sqlc.New(readPgPool)}
+}
+
+// GetTableNames returns the names of the tables with a script
+func (r *schemaRepositoryImpl) GetTableNames(ctx context.Context) ([]string, error) {
+	names, err := r.queries.GetDatabaseSchemaTableNames(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error listing table schemas: %w", err)
+	}
+	return names, nil
+}
+
+// GetTableScript returns the CREATE TABLE script of tableName
+func (r *schemaRepositoryImpl) GetTableScript(ctx context.Context, tableName string) (string, error) {
+	schema, err := r.queries.GetDatabaseSchemaByTableName(ctx, tableName)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && !schema.TableScript.Valid) {
+		return "", fmt.Errorf("%w: %s", ErrSchemaNotFound, tableName)
+	}
+	if err != nil {
+		return "", fmt.Errorf("error reading the schema of %s: %w", tableName, err)
+	}
+	return schema.TableScript.String, nil
+}
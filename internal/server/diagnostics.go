@@ -0,0 +1,36 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/yourorg/go-api-template/config"
+	"github.com/yourorg/go-api-template/core/diagnostics"
+	middleware_httpserver "github.com/yourorg/go-api-template/core/transport/httpserver/middlewares"
+)
+
+// NewDiagnosticsServer returns the separate pprof and expvar listener of diagnostics.address,
+// nil when the endpoints are disabled or served on the REST port
+func NewDiagnosticsServer() *http.Server {
+	cfg := config.GetConfig().Diagnostics
+	if !cfg.Enabled || cfg.Address == "" {
+		return nil
+	}
+	return diagnostics.NewServer(cfg)
+}
+
+// withDiagnostics serves /debug/ from the diagnostics handler to JWTs carrying cfg.Role and
+// everything else from next. The endpoints bypass the middlewares of next, whose request
+// timeout would cut CPU profiles short.
+func withDiagnostics(cfg diagnostics.Config, jwtSecretKey string, next http.Handler) http.Handler {
+	guarded := middleware_httpserver.AuthMiddleware(middleware_httpserver.AuthConfig{JWTSecretKey: jwtSecretKey})(
+		middleware_httpserver.RequireRoles(cfg.Role)(diagnostics.Handler()),
+	)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/debug/") {
+			guarded.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
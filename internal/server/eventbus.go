@@ -0,0 +1,43 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/yourorg/go-api-template/config"
+	"github.com/yourorg/go-api-template/core/eventbus"
+	"github.com/yourorg/go-api-template/core/lifecycle"
+	"github.com/yourorg/go-api-template/core/logger"
+)
+
+var (
+	eventBusMu sync.Mutex
+	eventBus   *eventbus.Bus
+)
+
+// newEventBus opens the event bus of the eventBus section, nil when it is disabled. The bus is
+// shared by the services and the outbox poller, and closed with the lifecycle resources after
+// the server drained.
+func newEventBus(cfg *config.Config) (*eventbus.Bus, error) {
+	if !cfg.EventBus.Enabled {
+		return nil, nil
+	}
+
+	eventBusMu.Lock()
+	defer eventBusMu.Unlock()
+	if eventBus != nil {
+		return eventBus, nil
+	}
+
+	bus, err := eventbus.Open(cfg.EventBus, logger.Slog)
+	if err != nil {
+		return nil, err
+	}
+	slog.InfoContext(context.Background(), "Initializing event bus", "driver", cfg.EventBus.Driver)
+	lifecycle.Register("eventbus", func(ctx context.Context) error {
+		return bus.Close()
+	})
+	eventBus = bus
+	return bus, nil
+}
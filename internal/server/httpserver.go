@@ -7,18 +7,31 @@ import (
 	"net/http"
 	"time"
 
-	"github.com/rs/cors"
 	"github.com/yourorg/go-api-template/config"
+	"github.com/yourorg/go-api-template/core/audit"
 	"github.com/yourorg/go-api-template/core/cache"
+	core_config "github.com/yourorg/go-api-template/core/config"
+	"github.com/yourorg/go-api-template/core/events"
 	"github.com/yourorg/go-api-template/core/exception"
+	"github.com/yourorg/go-api-template/core/health"
+	"github.com/yourorg/go-api-template/core/i18n"
+	"github.com/yourorg/go-api-template/core/lifecycle"
 	"github.com/yourorg/go-api-template/core/httpclient"
+	"github.com/yourorg/go-api-template/core/httpclient/completions"
 	"github.com/yourorg/go-api-template/core/logger"
 	"github.com/yourorg/go-api-template/core/ratelimit"
+	"github.com/yourorg/go-api-template/core/slo"
+	"github.com/yourorg/go-api-template/core/transport/httpserver"
+	"github.com/yourorg/go-api-template/core/usage"
 	middleware_httpserver "github.com/yourorg/go-api-template/core/transport/httpserver/middlewares"
 	"github.com/yourorg/go-api-template/internal/repository"
 	"github.com/yourorg/go-api-template/internal/service"
 	"github.com/yourorg/go-api-template/utils"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 func NewHttpServer() (*http.Server, error) {
@@ -26,39 +39,95 @@ func NewHttpServer() (*http.Server, error) {
 	slog.InfoContext(context.Background(), "Initializing HTTP server", "port", cfg.RestServer.Port)
 	var middlewares []middleware_httpserver.TransportMiddleware
 
-	// CORS middleware
-	middlewares = append(middlewares, cors.New(cors.Options{
-		AllowedOrigins: cfg.CORS.AllowedOrigins,
-		AllowedMethods: cfg.CORS.AllowedMethods,
-		AllowedHeaders: cfg.CORS.AllowedHeaders,
-		ExposedHeaders: cfg.CORS.ExposedHeaders,
-		MaxAge:         cfg.CORS.MaxAge,
-	}).Handler)
-
-	// Rate limiting middleware
-	if cfg.RateLimit.Enabled {
-		// Initialize Redis cache service for rate limiting
-		err := cache.InitRedisService(cfg.Redis)
+	exception.SetDebug(cfg.DebugEnabled())
+	exception.SetStackConfig(cfg.ErrorStack)
+	applyLoggingConfig(cfg.Logging)
+	config.OnChange("logging", func(_, next *config.Config) {
+		applyLoggingConfig(next.Logging)
+	})
+	httpserver.SetErrorFormat(httpserver.ErrorFormat(cfg.RestServer.ErrorFormat), cfg.RestServer.ProblemTypeBase)
+	httpserver.SetAllowedMediaTypes(cfg.RestServer.MediaTypes)
+	httpserver.SetDecodeOptions(httpserver.DecodeOptions{
+		DisallowUnknownFields: cfg.RestServer.Decoding.DisallowUnknownFields,
+		RequireContentType:    cfg.RestServer.Decoding.RequireContentType,
+	})
+	envelope, ok := httpserver.EnvelopeByName(cfg.RestServer.ResponseEnvelope)
+	if !ok {
+		return nil, fmt.Errorf("unknown response envelope %q", cfg.RestServer.ResponseEnvelope)
+	}
+	httpserver.SetEnvelope(envelope)
+
+	// W3C trace context for inbound requests (otelhttp below) and outbound calls
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	// Request ID middleware, the ID is forwarded on outbound calls
+	middlewares = append(middlewares, middleware_httpserver.RequestIDMiddleware(middleware_httpserver.DefaultRequestIDConfig()))
+
+	// locale of the error and validation messages, from Accept-Language
+	if cfg.I18n.Enabled {
+		bundle, err := i18n.New(cfg.I18n)
 		if err != nil {
-			slog.WarnContext(context.Background(), "Failed to initialize Redis for rate limiting, using memory limiter", "error", err.Error())
+			return nil, fmt.Errorf("failed to load message catalogs: %w", err)
 		}
+		exception.SetTranslator(bundle.ErrorMessage)
+		middlewares = append(middlewares, i18n.Middleware(bundle))
+	}
 
-		// Create rate limiter based on available cache service
-		var limiter ratelimit.Limiter
-		if cacheService := cache.GetRedisService(); cacheService != nil {
-			limiter = ratelimit.NewRedisLimiter(cacheService, createRateLimitConfig(cfg))
-			slog.InfoContext(context.Background(), "Using Redis-based rate limiter")
-		} else {
-			limiter = ratelimit.NewMemoryLimiter(createRateLimitConfig(cfg))
-			slog.InfoContext(context.Background(), "Using memory-based rate limiter")
-		}
+	// trace ID of the requests traced with X-Debug-Trace, see DebugTraceMiddleware below
+	if cfg.RestServer.DebugTrace.Enabled {
+		middlewares = append(middlewares, middleware_httpserver.DebugTraceResponseMiddleware())
+	}
+
+	// burn rates of slo.objectives, ahead of recovery and the timeout so panics and expired
+	// budgets count as errors
+	if cfg.SLO.Enabled {
+		tracker := slo.NewTracker(cfg.SLO)
+		tracker.ExportMetrics()
+		middlewares = append(middlewares, tracker.Middleware())
+	}
+
+	// request, user and tenant IDs on spans, outbound baggage and logs
+	if cfg.RestServer.TraceBaggage.Enabled {
+		middlewares = append(middlewares, middleware_httpserver.TraceBaggageMiddleware(cfg.RestServer.TraceBaggage))
+	}
+
+	// mTLS client identity for authorization, see middleware_httpserver.RequireClientCert
+	if cfg.RestServer.TLS.Enabled {
+		middlewares = append(middlewares, middleware_httpserver.ClientCertMiddleware())
+	}
+
+	// Panic recovery, after the request ID so it is part of the log
+	middlewares = append(middlewares, middleware_httpserver.RecoveryMiddleware(middleware_httpserver.RecoveryConfig{
+		WriteError: httpserver.WriteError,
+	}))
 
-		middlewares = append(middlewares, ratelimit.Middleware(limiter, createRateLimitConfig(cfg)))
-		slog.InfoContext(context.Background(), "Rate limiting enabled",
-			"requests", cfg.RateLimit.Requests,
-			"window", cfg.RateLimit.Window)
+	// Response compression, negotiated by Accept-Encoding
+	if cfg.RestServer.Compression.Enabled {
+		middlewares = append(middlewares, middleware_httpserver.CompressionMiddleware(cfg.RestServer.Compression))
 	}
 
+	// Request budget, the deadline is passed on to database and outbound calls through the context
+	timeoutConfig := cfg.RestServer.Timeout
+	timeoutConfig.WriteError = httpserver.WriteError
+	middlewares = append(middlewares, middleware_httpserver.TimeoutMiddleware(timeoutConfig))
+
+	// CORS middleware, with the policies of cors.routes applied by path prefix
+	middlewares = append(middlewares, httpserver.CORSMiddleware(cfg.CORS))
+
+	// CSRF protection of cookie sessions, after CORS so preflight requests are answered first
+	if cfg.CSRF.Enabled {
+		csrfConfig := cfg.CSRF
+		csrfConfig.WriteError = httpserver.WriteError
+		middlewares = append(middlewares, middleware_httpserver.CSRFMiddleware(csrfConfig))
+	}
+
+	// Rate limiting middleware, replaced when the rateLimit section is reloaded
+	rateLimiter := ratelimit.NewReloadable(newRateLimiter(cfg))
+	middlewares = append(middlewares, rateLimiter.Middleware())
+	config.OnChange("rateLimit", func(_, next *config.Config) {
+		rateLimiter.Update(newRateLimiter(next))
+	})
+
 	middlewareStack := middleware_httpserver.CreateStack(middlewares...)
 
 	// Create repository
@@ -67,45 +136,310 @@ func NewHttpServer() (*http.Server, error) {
 		return nil, fmt.Errorf("failed to initialize repository: %w", err)
 	}
 
-	mockDataAppError := exception.NewMockDataServiceErrors()
+	mockDataAppError, err := newApplicationErrors(cfg.ErrorCatalog)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load error catalog: %w", err)
+	}
 
 	utils := utils.NewUtils()
 
 	logger := *logger.Slog
 
-	lmStudioClient := httpclient.NewLmStudioHttpClient(&cfg.LMStudio, logger)
+	llmProvider, err := httpclient.NewLLMProvider(cfg.LLM, &cfg.LMStudio, logger, append(completionsCacheOptions(cfg), completionsLimitOptions(cfg)...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize llm provider: %w", err)
+	}
+
+	usageStore, err := newUsageStore(cfg, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize usage store: %w", err)
+	}
+	if usageStore != nil {
+		llmProvider = usage.NewMeteredProvider(llmProvider, usageStore, usage.WithMonthlyBudget(cfg.LLM.Usage.MonthlyTokenBudget))
+	}
+
+	jobQueue, err := newJobQueue(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize job queue: %w", err)
+	}
+
+	auditStore, err := newAuditStore(cfg, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize audit store: %w", err)
+	}
+
+	bus, err := newEventBus(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize event bus: %w", err)
+	}
+
+	store, err := newStorage(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize storage: %w", err)
+	}
+
+	notifier, err := newNotifier(cfg, repo, jobQueue)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize notifications: %w", err)
+	}
+
+	sandbox, err := newMockDataSandbox(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize mock data sandbox: %w", err)
+	}
+
+	applier, err := newMockDataApplier(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize mock data apply: %w", err)
+	}
+
+	// domain events, the asynchronous handlers in progress finish after the server drained
+	dispatcher := events.NewDispatcher(&logger)
+	lifecycle.Register("domain events", dispatcher.Close)
 
 	service := service.NewService(
 		repo,
 		cfg,
 		mockDataAppError,
 		utils,
-		lmStudioClient,
+		llmProvider,
+		healthCheckers(cfg, logger),
+		usageStore,
+		jobQueue,
+		auditStore,
+		bus,
+		store,
+		dispatcher,
+		notifier,
+		sandbox,
+		applier,
 	)
 
 	handler := registerRoute(service)
 	wrappedMiddleware := middlewareStack(handler)
+	// pprof and expvar on the REST port, without a separate diagnostics.address
+	if diag := cfg.Diagnostics; diag.Enabled && diag.Address == "" {
+		wrappedMiddleware = withDiagnostics(diag, cfg.Auth.JWTSecretKey, wrappedMiddleware)
+	}
 	wrappedOtel := otelhttp.NewHandler(
 		wrappedMiddleware,
 		"",
+		// the router renames the span to the matched route pattern, raw paths would make
+		// span names unbounded
 		otelhttp.WithSpanNameFormatter(
 			func(operation string, r *http.Request) string {
-				return fmt.Sprintf("%s %s %s", operation, r.Method, r.URL.Path)
+				return r.Method
 			},
 		))
 
-	return &http.Server{
-		Addr:    ":" + cfg.RestServer.Port,
-		Handler: wrappedOtel,
-	}, nil
+	tlsConfig, err := httpserver.NewTLSConfig(cfg.RestServer.TLS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure TLS: %w", err)
+	}
+
+	var rootHandler http.Handler = wrappedOtel
+	// ahead of otelhttp, the sampling of debug traces is decided as the server span starts
+	if cfg.RestServer.DebugTrace.Enabled {
+		rootHandler = middleware_httpserver.DebugTraceMiddleware(cfg.RestServer.DebugTrace, cfg.Auth.JWTSecretKey)(rootHandler)
+	}
+	if cfg.RestServer.H2C && tlsConfig == nil {
+		rootHandler = h2c.NewHandler(rootHandler, &http2.Server{})
+	}
+
+	server := &http.Server{
+		Addr:      ":" + cfg.RestServer.Port,
+		Handler:   rootHandler,
+		TLSConfig: tlsConfig,
+	}
+	if tlsConfig != nil && cfg.RestServer.TLS.DisableHTTP2 {
+		httpserver.DisableHTTP2(server)
+	}
+	// Shutdown does not wait for upgraded connections, close them separately
+	server.RegisterOnShutdown(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := httpserver.DefaultWebSocketHub.Shutdown(ctx); err != nil {
+			slog.WarnContext(ctx, "WebSocket connections did not close in time", "open", httpserver.DefaultWebSocketHub.Len())
+		}
+	})
+
+	// Redis is shared by rate limiting, the LLM cache, usage accounting and jobs
+	if cacheService := cache.GetRedisService(); cacheService != nil {
+		lifecycle.Register("redis", func(ctx context.Context) error {
+			return cacheService.Close()
+		})
+	}
+	return server, nil
+}
+
+// newApplicationErrors loads the error catalog at catalogPath, or the embedded one when empty
+func newApplicationErrors(catalogPath string) (*exception.MockDataServiceErrors, error) {
+	if catalogPath == "" {
+		return exception.NewMockDataServiceErrors(), nil
+	}
+
+	catalog, err := exception.LoadCatalog(catalogPath)
+	if err != nil {
+		return nil, err
+	}
+	slog.InfoContext(context.Background(), "Loaded error catalog", "file", catalogPath, "errors", len(catalog.Errors))
+
+	return exception.NewMockDataServiceErrorsFromCatalog(catalog)
+}
+
+// healthCheckers returns the checkers for external dependencies. For LM Studio this
+// validates that the configured model is loaded.
+func healthCheckers(cfg *config.Config, logger slog.Logger) map[string]health.Checker {
+	checkers := map[string]health.Checker{}
+	if cfg.LMStudio.EnableMock {
+		return checkers
+	}
+
+	switch cfg.LLM.Provider {
+	case "", httpclient.ProviderLMStudio:
+		lmStudio := httpclient.NewLmStudioHttpClient(&cfg.LMStudio, logger)
+		checkers["llm"] = health.NewModelChecker(lmStudio.Models, cfg.LMStudio.Model)
+	}
+	return checkers
+}
+
+// completionsCacheOptions enables the LLM response cache and in-flight deduplication.
+// Without Redis the cache is skipped and only deduplication applies.
+func completionsCacheOptions(cfg *config.Config) []completions.ClientOption {
+	var opts []completions.ClientOption
+	if cfg.LLM.Cache.Dedup {
+		opts = append(opts, completions.WithDeduplication())
+	}
+	if !cfg.LLM.Cache.Enabled {
+		return opts
+	}
+
+	cacheService := cache.GetRedisService()
+	if cacheService == nil {
+		if err := cache.InitRedisService(cfg.Redis); err != nil {
+			slog.WarnContext(context.Background(), "Failed to initialize Redis for the LLM cache, caching disabled", "error", err.Error())
+			return opts
+		}
+		cacheService = cache.GetRedisService()
+	}
+
+	slog.InfoContext(context.Background(), "LLM response cache enabled", "ttl", cfg.LLM.Cache.TTL)
+	return append(opts, completions.WithCache(cacheService, cfg.LLM.Cache.TTL))
+}
+
+// completionsLimitOptions caps concurrent generations and requests per minute against the model server.
+// The rate limit is kept in memory: the Redis limiter counts queued retries as requests.
+func completionsLimitOptions(cfg *config.Config) []completions.ClientOption {
+	limits := cfg.LLM.Limits
+	opts := []completions.ClientOption{
+		completions.WithConcurrencyLimit(limits.MaxConcurrent),
+		completions.WithQueueTimeout(limits.QueueTimeout),
+	}
+	if limits.RequestsPerMinute > 0 {
+		limiter := ratelimit.NewMemoryLimiter(ratelimit.Config{
+			Requests: limits.RequestsPerMinute,
+			Window:   time.Minute,
+		})
+		opts = append(opts, completions.WithRateLimit(limiter, "llm"))
+	}
+	return opts
+}
+
+// newUsageStore returns the token usage store, nil when usage accounting is disabled
+func newUsageStore(cfg *config.Config, repo *repository.Repository) (usage.Store, error) {
+	if !cfg.LLM.Usage.Enabled {
+		return nil, nil
+	}
+
+	switch cfg.LLM.Usage.Store {
+	case "", usage.StoreMemory:
+		return usage.NewMemoryStore(), nil
+	case usage.StoreRedis:
+		cacheService := cache.GetRedisService()
+		if cacheService == nil {
+			if err := cache.InitRedisService(cfg.Redis); err != nil {
+				return nil, err
+			}
+			cacheService = cache.GetRedisService()
+		}
+		return usage.NewRedisStore(cacheService), nil
+	case usage.StorePostgres:
+		if repo == nil || repo.DB == nil {
+			return nil, fmt.Errorf("usage store %s: database is not available", usage.StorePostgres)
+		}
+		return usage.NewPostgresStore(repo.DB), nil
+	default:
+		return nil, fmt.Errorf("unknown usage store: %q", cfg.LLM.Usage.Store)
+	}
+}
+
+// newAuditStore returns the audit store, nil when auditing is disabled
+func newAuditStore(cfg *config.Config, repo *repository.Repository) (audit.Store, error) {
+	if !cfg.Audit.Enabled {
+		return nil, nil
+	}
+
+	switch cfg.Audit.Store {
+	case "", audit.StoreMemory:
+		return audit.NewMemoryStore(), nil
+	case audit.StorePostgres:
+		if repo == nil || repo.DB == nil {
+			return nil, fmt.Errorf("audit store %s: database is not available", audit.StorePostgres)
+		}
+		return audit.NewPostgresStore(repo.DB), nil
+	default:
+		return nil, fmt.Errorf("unknown audit store: %q", cfg.Audit.Store)
+	}
+}
+
+// applyLoggingConfig sets the log level and the logged body size, at startup and on reload
+func applyLoggingConfig(cfg core_config.LoggingConfig) {
+	if cfg.Level != "" {
+		if err := logger.SetLevel(cfg.Level); err != nil {
+			slog.WarnContext(context.Background(), "Invalid log level, keeping the current one", "level", cfg.Level, "error", err.Error())
+		}
+	}
+	logger.SetMaxBodySize(cfg.MaxBodyBytes)
+}
+
+// newRateLimiter returns the limiter of the rateLimit section and its config, a nil limiter when rate limiting is disabled
+func newRateLimiter(cfg *config.Config) (ratelimit.Limiter, ratelimit.Config) {
+	if !cfg.RateLimit.Enabled {
+		return nil, ratelimit.Config{}
+	}
+	rateLimitConfig := createRateLimitConfig(cfg)
+
+	// Initialize Redis cache service for rate limiting
+	if cache.GetRedisService() == nil {
+		err := cache.InitRedisService(cfg.Redis)
+		if err != nil {
+			slog.WarnContext(context.Background(), "Failed to initialize Redis for rate limiting, using memory limiter", "error", err.Error())
+		}
+	}
+
+	// Create rate limiter based on available cache service
+	var limiter ratelimit.Limiter
+	if cacheService := cache.GetRedisService(); cacheService != nil {
+		limiter = ratelimit.NewRedisLimiter(cacheService, rateLimitConfig)
+		slog.InfoContext(context.Background(), "Using Redis-based rate limiter")
+	} else {
+		limiter = ratelimit.NewMemoryLimiter(rateLimitConfig)
+		slog.InfoContext(context.Background(), "Using memory-based rate limiter")
+	}
+
+	slog.InfoContext(context.Background(), "Rate limiting enabled",
+		"requests", cfg.RateLimit.Requests,
+		"window", cfg.RateLimit.Window)
+	return limiter, rateLimitConfig
 }
 
 // createRateLimitConfig converts config values to ratelimit.Config
 func createRateLimitConfig(cfg *config.Config) ratelimit.Config {
+	// unset keys were filled from core_config.Defaults when the config was loaded
 	window, err := time.ParseDuration(cfg.RateLimit.Window)
 	if err != nil {
+		window = ratelimit.DefaultConfig().Window
 		slog.WarnContext(context.Background(), "Invalid rate limit window duration, using default", "window", cfg.RateLimit.Window, "error", err.Error())
-		window = time.Hour
 	}
 
 	config := ratelimit.Config{
@@ -118,19 +452,5 @@ func createRateLimitConfig(cfg *config.Config) ratelimit.Config {
 		StatusCode:     cfg.RateLimit.StatusCode,
 	}
 
-	// Set defaults if not configured
-	if config.Requests == 0 {
-		config.Requests = 100
-	}
-	if config.Message == "" {
-		config.Message = "Rate limit exceeded"
-	}
-	if config.StatusCode == 0 {
-		config.StatusCode = http.StatusTooManyRequests
-	}
-	if len(config.SkipPaths) == 0 {
-		config.SkipPaths = []string{"/health", "/health/*", "/metrics"}
-	}
-
 	return config
 }
@@ -0,0 +1,111 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/yourorg/go-api-template/config"
+	"github.com/yourorg/go-api-template/core/cache"
+	"github.com/yourorg/go-api-template/core/jobs"
+	"github.com/yourorg/go-api-template/core/logger"
+	"github.com/yourorg/go-api-template/internal/repository"
+	"github.com/yourorg/go-api-template/internal/service"
+)
+
+var (
+	memoryQueueOnce sync.Once
+	memoryQueue     *jobs.MemoryQueue
+)
+
+// newJobQueue returns the queue of the jobs section, nil when jobs are disabled.
+// The memory queue is shared by the API and the worker running inside serve.
+func newJobQueue(cfg *config.Config) (jobs.Queue, error) {
+	if !cfg.Jobs.Enabled {
+		return nil, nil
+	}
+
+	switch cfg.Jobs.Store {
+	case "", jobs.StoreRedis:
+		cacheService := cache.GetRedisService()
+		if cacheService == nil {
+			if err := cache.InitRedisService(cfg.Redis); err != nil {
+				return nil, err
+			}
+			cacheService = cache.GetRedisService()
+		}
+		return jobs.NewRedisQueue(cacheService), nil
+	case jobs.StoreMemory:
+		memoryQueueOnce.Do(func() {
+			memoryQueue = jobs.NewMemoryQueue()
+		})
+		return memoryQueue, nil
+	default:
+		return nil, fmt.Errorf("unknown jobs store: %q", cfg.Jobs.Store)
+	}
+}
+
+// NewJobWorker builds the worker of the worker command with the handlers of registerJobHandlers.
+// It returns nil when jobs are disabled.
+func NewJobWorker() (*jobs.Worker, error) {
+	cfg := config.GetConfig()
+	queue, err := newJobQueue(cfg)
+	if err != nil || queue == nil {
+		return nil, err
+	}
+
+	slog.InfoContext(context.Background(), "Initializing job worker", "store", cfg.Jobs.Store, "queues", cfg.Jobs.Queues)
+	worker := jobs.NewWorker(cfg.Jobs, queue, logger.Slog)
+	registerJobHandlers(worker)
+	if err := registerNotificationHandler(cfg, worker); err != nil {
+		return nil, err
+	}
+	return worker, nil
+}
+
+// NewInProcessJobWorker builds the worker serve runs itself, for the memory store whose jobs
+// no other process sees. It returns nil for other stores and when jobs are disabled.
+func NewInProcessJobWorker() (*jobs.Worker, error) {
+	if config.GetConfig().Jobs.Store != jobs.StoreMemory {
+		return nil, nil
+	}
+	return NewJobWorker()
+}
+
+// NewServeAllJobWorker builds the worker serve:all runs, for any store when serveAll.worker is
+// set and for the memory store otherwise
+func NewServeAllJobWorker() (*jobs.Worker, error) {
+	if !config.GetConfig().ServeAll.Worker {
+		return NewInProcessJobWorker()
+	}
+	return NewJobWorker()
+}
+
+// NewJobScheduler builds the scheduler of jobs.schedules when serveAll.scheduler is set.
+// It returns nil when jobs are disabled or nothing is scheduled.
+func NewJobScheduler() (*jobs.Scheduler, error) {
+	cfg := config.GetConfig()
+	if !cfg.ServeAll.Scheduler || len(cfg.Jobs.Schedules) == 0 {
+		return nil, nil
+	}
+	queue, err := newJobQueue(cfg)
+	if err != nil || queue == nil {
+		return nil, err
+	}
+
+	slog.InfoContext(context.Background(), "Initializing job scheduler", "store", cfg.Jobs.Store, "schedules", len(cfg.Jobs.Schedules))
+	return jobs.NewScheduler(queue, cfg.Jobs.Schedules, logger.Slog)
+}
+
+// registerJobHandlers maps job types to their handlers - replace the example with your jobs
+func registerJobHandlers(worker *jobs.Worker) {
+	worker.Handle(service.ExampleCreatedJob, func(ctx context.Context, job jobs.Job) error {
+		var data repository.ExampleData
+		if err := job.Decode(&data); err != nil {
+			return err
+		}
+		slog.InfoContext(ctx, "Example created", "id", data.ID, "name", data.Name, "job_id", job.ID)
+		return nil
+	})
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/yourorg/go-api-template/config"
+	"github.com/yourorg/go-api-template/core/lifecycle"
+	"github.com/yourorg/go-api-template/core/mockdata"
+	"github.com/yourorg/go-api-template/core/pgdb"
+)
+
+// newMockDataSandbox checks the generated mock data against mockData.sandboxTarget, the write
+// database when it has no host; nil when mockData.sandbox is none
+func newMockDataSandbox(cfg *config.Config) (mockdata.Sandbox, error) {
+	switch cfg.MockData.Sandbox {
+	case "", mockdata.SandboxNone:
+		return nil, nil
+	case mockdata.SandboxExplain, mockdata.SandboxExecute:
+		pool, err := mockDataPool("mock data sandbox", cfg.MockData.SandboxTarget)
+		if err != nil {
+			return nil, err
+		}
+		return mockdata.NewPostgresSandbox(pool, cfg.MockData.Sandbox, cfg.MockData.StatementTimeout), nil
+	default:
+		return nil, fmt.Errorf("unknown mock data sandbox: %q", cfg.MockData.Sandbox)
+	}
+}
+
+// newMockDataApplier applies the generated mock data to the target of mockData.apply, the write
+// database when it has no host; nil when apply is disabled
+func newMockDataApplier(cfg *config.Config) (*mockdata.Applier, error) {
+	apply := cfg.MockData.Apply
+	if !apply.Enabled {
+		return nil, nil
+	}
+	pool, err := mockDataPool("mock data target", apply.Target)
+	if err != nil {
+		return nil, err
+	}
+	return mockdata.NewApplier(pool, apply.Target.Schema, cfg.MockData.StatementTimeout, apply.DatabaseRole), nil
+}
+
+// mockDataPool connects to target, closed on shutdown, or returns the write pool when target has
+// no host
+func mockDataPool(name string, target pgdb.PostgresConfig) (mockdata.Beginner, error) {
+	if target.Host == "" {
+		return pgdb.GetWritePgPool()
+	}
+
+	ctx := context.Background()
+	pool, err := pgdb.NewPool(ctx, target)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to the %s database: %w", name, err)
+	}
+	lifecycle.Register(name, func(ctx context.Context) error {
+		pool.Close()
+		return nil
+	})
+	slog.InfoContext(ctx, "Connected the "+name+" database", "host", target.Host, "database", target.Database)
+	return pool, nil
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/yourorg/go-api-template/config"
+	"github.com/yourorg/go-api-template/core/jobs"
+	"github.com/yourorg/go-api-template/core/logger"
+	"github.com/yourorg/go-api-template/core/notify"
+	"github.com/yourorg/go-api-template/internal/repository"
+)
+
+// newNotifier returns the notifier of the notifications section, enqueuing the deliveries on
+// queue, nil when notifications are disabled
+func newNotifier(cfg *config.Config, repo *repository.Repository, queue jobs.Queue) (*notify.Notifier, error) {
+	if !cfg.Notifications.Enabled {
+		return nil, nil
+	}
+	if queue == nil {
+		return nil, errors.New("notifications require the job queue, see jobs.enabled")
+	}
+
+	var store notify.PreferenceStore
+	switch cfg.Notifications.Store {
+	case "", notify.StoreMemory:
+		store = notify.NewMemoryStore()
+	case notify.StorePostgres:
+		if repo == nil || repo.DB == nil {
+			return nil, fmt.Errorf("notification store %s: database is not available", notify.StorePostgres)
+		}
+		store = notify.NewPostgresStore(repo.DB)
+	default:
+		return nil, fmt.Errorf("unknown notification store: %q", cfg.Notifications.Store)
+	}
+	slog.InfoContext(context.Background(), "Initializing notifications", "store", cfg.Notifications.Store, "queue", cfg.Notifications.Queue)
+	return notify.NewNotifier(cfg.Notifications, store, queue, logger.Slog), nil
+}
+
+// registerNotificationHandler delivers the notifications through the configured channels when
+// notifications are enabled
+func registerNotificationHandler(cfg *config.Config, worker *jobs.Worker) error {
+	if !cfg.Notifications.Enabled {
+		return nil
+	}
+	channels, err := notify.NewChannels(cfg.Notifications, nil)
+	if err != nil {
+		return fmt.Errorf("failed to initialize notification channels: %w", err)
+	}
+	worker.Handle(notify.DeliverJob, notify.DeliveryHandler(channels))
+	return nil
+}
@@ -0,0 +1,36 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/yourorg/go-api-template/config"
+	"github.com/yourorg/go-api-template/core/eventbus"
+	"github.com/yourorg/go-api-template/core/logger"
+	"github.com/yourorg/go-api-template/core/outbox"
+)
+
+// NewOutboxPoller builds the outbox poller from config.
+// It returns nil when the outbox is disabled.
+func NewOutboxPoller() (*outbox.Poller, error) {
+	cfg := config.GetConfig()
+	if !cfg.Outbox.Enabled {
+		return nil, nil
+	}
+
+	var bus *eventbus.Bus
+	if cfg.Outbox.Sink.Type == outbox.SinkEventBus {
+		var err error
+		if bus, err = newEventBus(cfg); err != nil {
+			return nil, fmt.Errorf("failed to initialize event bus: %w", err)
+		}
+	}
+	sink, err := outbox.NewSink(cfg.Outbox.Sink, bus)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create outbox sink: %w", err)
+	}
+
+	slog.InfoContext(context.Background(), "Initializing outbox poller", "sink", cfg.Outbox.Sink.Type)
+	return outbox.NewPoller(cfg.Outbox, outbox.NewPostgresStore(), sink, logger.Slog), nil
+}
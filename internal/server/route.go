@@ -4,6 +4,8 @@ import (
 	"context"
 	"net/http"
 
+	"github.com/yourorg/go-api-template/core/audit"
+	"github.com/yourorg/go-api-template/core/openapi"
 	"github.com/yourorg/go-api-template/core/transport/httpserver"
 	middleware_httpserver "github.com/yourorg/go-api-template/core/transport/httpserver/middlewares"
 	"github.com/yourorg/go-api-template/internal/model"
@@ -14,9 +16,8 @@ func registerRoute(service service.Service) http.Handler {
 	mux := http.NewServeMux()
 	r := httpserver.NewRouter(mux)
 
-	mux.Handle("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		middleware_httpserver.NotFound(w, r)
-	}))
+	// unmatched routes get the standard error envelope
+	r.SetNotFoundHandler(httpserver.NotFoundHandler())
 
 	// Health check endpoints (no authentication required)
 	r.Get("/health", httpserver.NewTransport(
@@ -40,23 +41,99 @@ func registerRoute(service service.Service) http.Handler {
 		}),
 	))
 
+	// Versioned API; to add v2, move the registrations into a func(*httpserver.Router),
+	// mount it on both groups and mark v1 with middleware_httpserver.DeprecationMiddleware
+	v1Middlewares := []func(http.Handler) http.Handler{httpserver.VersionMiddleware("v1")}
+	if cfg := service.Config.RestServer.ETag; cfg.Enabled {
+		v1Middlewares = append(v1Middlewares, middleware_httpserver.ETagMiddleware(cfg))
+	}
+	v1 := r.Group("/api/v1", v1Middlewares...)
+
 	// Authentication endpoints (no authentication required)
-	r.Post("/api/v1/auth/login", httpserver.NewTransport(
+	v1.Post("/auth/login", httpserver.NewTransport(
 		&model.LoginRequest{},
 		httpserver.NewEndpoint(service.AuthService.Login),
 	))
 
+	// LLM routes, to authenticated callers only: the tokens are charged to the user of the JWT
+	callers := v1.Group("",
+		middleware_httpserver.AuthMiddleware(middleware_httpserver.AuthConfig{JWTSecretKey: service.Config.Auth.JWTSecretKey}),
+	)
+
+	// LLM token usage of the authenticated caller
+	callers.Get("/usage", httpserver.NewTransport(
+		&model.UsageRequest{},
+		httpserver.NewEndpoint(service.UsageService.GetUsage),
+	))
+
+	// Mock data of related tables, generated parents first and streamed as progress events, to
+	// JWTs carrying mockData.role
+	mockData := callers.Group("", middleware_httpserver.RequireRoles(service.Config.MockData.Role))
+	mockData.Post("/mock-data/batch", httpserver.NewStreamTransport(
+		&model.GenerateMockDataBatchRequest{},
+		service.MockDataService.GenerateBatch,
+	))
+
 	// Example API endpoints - replace with your actual endpoints
-	r.Get("/api/v1/examples/{id}", httpserver.NewTransport(
+	v1.Get("/examples", httpserver.NewTransport(
+		&model.ListExamplesRequest{},
+		httpserver.NewEndpoint(service.ExampleService.ListExamples),
+	))
+
+	v1.Get("/examples/{id}", httpserver.NewTransport(
 		&model.ExampleRequest{},
 		httpserver.NewEndpoint(service.ExampleService.GetExample),
 	))
 
-	r.Post("/api/v1/examples", httpserver.NewTransport(
+	audited(v1, service, audit.Hint{Entity: "example", IDField: "data.id"}).Post("/examples", httpserver.NewTransport(
 		&model.CreateExampleRequest{},
 		httpserver.NewEndpoint(service.ExampleService.CreateExample),
 	))
 
+	// Audit trail of an entity, to JWTs carrying audit.role
+	if cfg := service.Config; cfg.Audit.Enabled {
+		auditors := v1.Group("",
+			middleware_httpserver.AuthMiddleware(middleware_httpserver.AuthConfig{JWTSecretKey: cfg.Auth.JWTSecretKey}),
+			middleware_httpserver.RequireRoles(cfg.Audit.Role),
+		)
+		auditors.Get("/audit/{entity}/{id}", httpserver.NewTransport(
+			&model.AuditTrailRequest{},
+			httpserver.NewEndpoint(service.AuditService.GetAuditTrail),
+		))
+	}
+
+	// Uploads stored in storage, with signed URLs to download them
+	if cfg := service.Config; service.Storage != nil {
+		uploaders := v1.Group("",
+			middleware_httpserver.AuthMiddleware(middleware_httpserver.AuthConfig{JWTSecretKey: cfg.Auth.JWTSecretKey}),
+		)
+		uploaders.Post("/files", httpserver.NewUploadTransport(
+			&model.UploadFilesRequest{},
+			service.FileService.UploadFiles,
+			httpserver.WithMaxUploadSize(cfg.Storage.MaxUploadSize),
+			httpserver.WithMaxFileSize(cfg.Storage.MaxFileSize),
+			httpserver.WithAllowedTypes(cfg.Storage.AllowedTypes...),
+		))
+		mountLocalStorage(mux, service.Storage, cfg.Storage.Local.BaseURL)
+	}
+
+	// Notification preferences of the authenticated user
+	if cfg := service.Config; service.Notifier != nil {
+		recipients := v1.Group("",
+			middleware_httpserver.AuthMiddleware(middleware_httpserver.AuthConfig{JWTSecretKey: cfg.Auth.JWTSecretKey}),
+		)
+		recipients.Get("/notifications/preferences", httpserver.NewTransport(
+			&model.NotificationPreferencesRequest{},
+			httpserver.NewEndpoint(service.NotificationService.GetPreferences),
+		))
+		recipients.Put("/notifications/preferences", httpserver.NewTransport(
+			&model.UpdateNotificationPreferencesRequest{},
+			httpserver.NewEndpoint(service.NotificationService.UpdatePreferences),
+		))
+	}
+
+	// +scaffold:routes - `generate resource` adds the routes of new resources above
+
 	// Legacy health check endpoint (deprecated)
 	r.Post("/health-check",
 		httpserver.NewTransport(
@@ -67,5 +144,23 @@ func registerRoute(service service.Service) http.Handler {
 					Response: "Hello, " + in.Name,
 				}, nil
 			})))
+
+	// API docs generated from the routes above
+	if cfg := service.Config.RestServer.OpenAPI; cfg.Enabled {
+		doc := openapi.Build(openapi.Info{Title: cfg.Title, Version: cfg.Version}, r.Routes())
+		mux.Handle("GET /openapi.json", openapi.Handler(doc))
+		if cfg.SwaggerUI {
+			mux.Handle("GET /docs", openapi.SwaggerUIHandler("/openapi.json", cfg.Title))
+		}
+	}
 	return mux
 }
+
+// audited returns r recording the changes of its routes to the entity of hint when auditing
+// is enabled, see audit.Recorder.Middleware
+func audited(r *httpserver.Router, service service.Service, hint audit.Hint) *httpserver.Router {
+	if service.Audit == nil {
+		return r
+	}
+	return r.Group("", service.Audit.Middleware(hint))
+}
@@ -0,0 +1,38 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/yourorg/go-api-template/config"
+	"github.com/yourorg/go-api-template/core/storage"
+)
+
+// newStorage returns the object storage of the storage section, nil when it is disabled
+func newStorage(cfg *config.Config) (storage.Storage, error) {
+	if !cfg.Storage.Enabled {
+		return nil, nil
+	}
+	store, err := storage.New(cfg.Storage, http.DefaultClient)
+	if err != nil {
+		return nil, err
+	}
+	slog.InfoContext(context.Background(), "Initializing object storage", "driver", cfg.Storage.Driver)
+	return store, nil
+}
+
+// mountLocalStorage serves the signed URLs of the local driver under the path of its base URL
+func mountLocalStorage(mux *http.ServeMux, store storage.Storage, baseURL string) {
+	local, ok := store.(*storage.LocalStorage)
+	if !ok {
+		return
+	}
+	prefix := "/storage"
+	if u, err := url.Parse(baseURL); err == nil && u.Path != "" {
+		prefix = strings.TrimSuffix(u.Path, "/")
+	}
+	mux.Handle(prefix+"/", http.StripPrefix(prefix, local.Handler()))
+}
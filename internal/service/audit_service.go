@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+
+	"github.com/yourorg/go-api-template/core/audit"
+	"github.com/yourorg/go-api-template/core/exception"
+	"github.com/yourorg/go-api-template/internal/model"
+)
+
+// defaultAuditTrailLimit is the number of records listed without a limit
+const defaultAuditTrailLimit = 20
+
+// AuditService lists the audit trail of an entity
+type AuditService interface {
+	GetAuditTrail(ctx context.Context, req *model.AuditTrailRequest) (*model.AuditTrailResponse, error)
+}
+
+type auditService struct {
+	store  audit.Store
+	Errors *exception.MockDataServiceErrors
+}
+
+// NewAuditService creates an audit service; store is nil when auditing is disabled
+func NewAuditService(store audit.Store, errors *exception.MockDataServiceErrors) AuditService {
+	return &auditService{
+		store:  store,
+		Errors: errors,
+	}
+}
+
+// GetAuditTrail returns the last changes of the requested entity
+func (s *auditService) GetAuditTrail(ctx context.Context, req *model.AuditTrailRequest) (*model.AuditTrailResponse, error) {
+	if s.store == nil {
+		return nil, s.Errors.ErrNotFound
+	}
+
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultAuditTrailLimit
+	}
+	records, err := s.store.List(ctx, req.Entity, req.ID, limit)
+	if err != nil {
+		return nil, s.Errors.ErrUnableToProceed.Wrap(err)
+	}
+	if records == nil {
+		records = []audit.Record{}
+	}
+
+	return &model.AuditTrailResponse{
+		Status: 200,
+		Data:   records,
+	}, nil
+}
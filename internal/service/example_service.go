@@ -2,8 +2,12 @@ package service
 
 import (
 	"context"
+	"log/slog"
 
+	"github.com/yourorg/go-api-template/core/events"
 	"github.com/yourorg/go-api-template/core/exception"
+	"github.com/yourorg/go-api-template/core/jobs"
+	"github.com/yourorg/go-api-template/core/pgdb/pagination"
 	"github.com/yourorg/go-api-template/internal/model"
 	"github.com/yourorg/go-api-template/internal/repository"
 )
@@ -12,20 +16,39 @@ import (
 // This is just an example - replace with your actual business services
 type ExampleService interface {
 	GetExample(ctx context.Context, req *model.ExampleRequest) (*model.ExampleResponse, error)
+	ListExamples(ctx context.Context, req *model.ListExamplesRequest) (*model.PagedResponse[model.ExampleItem], error)
 	CreateExample(ctx context.Context, req *model.CreateExampleRequest) (*model.CreateExampleResponse, error)
 }
 
+// ExampleCreatedJob is enqueued after an example is created, to show work moved out of the request
+const ExampleCreatedJob = "example.created"
+
+// ExampleCreated is dispatched once an example is stored, to show side effects kept out of
+// the business logic
+type ExampleCreated struct {
+	Data repository.ExampleData
+}
+
+func (ExampleCreated) EventName() string { return "example.created" }
+
 type exampleService struct {
 	Repo   *repository.Repository
 	Errors *exception.MockDataServiceErrors
+	Jobs   jobs.Queue
+	Events *events.Dispatcher
 }
 
-// NewExampleService creates a new example service
-func NewExampleService(repo *repository.Repository, errors *exception.MockDataServiceErrors) ExampleService {
-	return &exampleService{
+// NewExampleService creates a new example service, registering its event handlers on
+// dispatcher; jobQueue may be nil when jobs are disabled
+func NewExampleService(repo *repository.Repository, errors *exception.MockDataServiceErrors, jobQueue jobs.Queue, dispatcher *events.Dispatcher) ExampleService {
+	s := &exampleService{
 		Repo:   repo,
 		Errors: errors,
+		Jobs:   jobQueue,
+		Events: dispatcher,
 	}
+	events.On(dispatcher, s.enqueueCreatedJob, events.Named("example.enqueue-job"))
+	return s
 }
 
 // GetExample demonstrates a simple GET operation
@@ -51,6 +74,44 @@ func (s *exampleService) GetExample(ctx context.Context, req *model.ExampleReque
 	}, nil
 }
 
+// ListExamples demonstrates a list operation paged with keyset cursors
+func (s *exampleService) ListExamples(ctx context.Context, req *model.ListExamplesRequest) (*model.PagedResponse[model.ExampleItem], error) {
+	// sort and filter fields are endpoint specific, cursor and limit were validated by the transport
+	if err := req.CheckFields([]string{"name", "created_at"}, []string{"name"}); err != nil {
+		return nil, err
+	}
+	if err := req.CheckCursorPaging(); err != nil {
+		return nil, err
+	}
+
+	columns := req.SortColumns("id")
+	fragment, err := pagination.Build(columns, req.PaginationRequest())
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.Repo.ExampleRepository.ListExamples(ctx, fragment)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]model.ExampleItem, len(rows))
+	for i, row := range rows {
+		items[i] = model.ExampleItem{ID: row.ID, Name: row.Name, Description: row.Description, CreatedAt: row.CreatedAt}
+	}
+	// the cursor of the next page holds the sort columns of the last item
+	page, err := pagination.NewPage(items, req.Limit, func(item model.ExampleItem) []any {
+		values := make([]any, len(columns))
+		for i, column := range columns {
+			values[i] = map[string]string{"id": item.ID, "name": item.Name, "created_at": item.CreatedAt}[column.Name]
+		}
+		return values
+	})
+	if err != nil {
+		return nil, err
+	}
+	return model.NewCursorResponse(page, req.ListParams), nil
+}
+
 // CreateExample demonstrates a simple CREATE operation
 func (s *exampleService) CreateExample(ctx context.Context, req *model.CreateExampleRequest) (*model.CreateExampleResponse, error) {
 	// Example business logic - replace with your actual implementation
@@ -72,6 +133,9 @@ func (s *exampleService) CreateExample(ctx context.Context, req *model.CreateExa
 		return nil, err
 	}
 
+	// the handlers run once the example is committed, when the repository writes in a transaction
+	s.Events.DispatchAfterCommit(ctx, ExampleCreated{Data: *data})
+
 	return &model.CreateExampleResponse{
 		Status: 201,
 		Data: model.CreateExampleResponse_Data{
@@ -80,4 +144,16 @@ func (s *exampleService) CreateExample(ctx context.Context, req *model.CreateExa
 			Message: "Example created successfully",
 		},
 	}, nil
-}
\ No newline at end of file
+}
+
+// enqueueCreatedJob moves slow follow-up work (emails, generation) to the worker instead of
+// delaying the response
+func (s *exampleService) enqueueCreatedJob(ctx context.Context, event ExampleCreated) error {
+	if s.Jobs == nil {
+		return nil
+	}
+	if _, err := jobs.Enqueue(ctx, s.Jobs, ExampleCreatedJob, event.Data); err != nil {
+		slog.WarnContext(ctx, "Failed to enqueue example job", "id", event.Data.ID, "error", err.Error())
+	}
+	return nil
+}
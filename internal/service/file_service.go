@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/yourorg/go-api-template/core/exception"
+	"github.com/yourorg/go-api-template/core/storage"
+	"github.com/yourorg/go-api-template/core/transport/httpserver"
+	"github.com/yourorg/go-api-template/internal/model"
+)
+
+const (
+	// uploadPrefix is where the uploaded files are stored
+	uploadPrefix = "uploads"
+	// maxUploadFiles bounds the files of an upload
+	maxUploadFiles = 10
+)
+
+// FileService stores uploaded files
+type FileService interface {
+	UploadFiles(ctx context.Context, req *model.UploadFilesRequest, parts *httpserver.UploadParts) (*model.UploadFilesResponse, error)
+}
+
+type fileService struct {
+	store     storage.Storage
+	urlExpiry time.Duration
+	Errors    *exception.MockDataServiceErrors
+}
+
+// NewFileService creates a file service; store is nil when storage is disabled
+func NewFileService(store storage.Storage, urlExpiry time.Duration, errors *exception.MockDataServiceErrors) FileService {
+	return &fileService{
+		store:     store,
+		urlExpiry: urlExpiry,
+		Errors:    errors,
+	}
+}
+
+// UploadFiles stores the files of the request, returning a download URL for each
+func (s *fileService) UploadFiles(ctx context.Context, req *model.UploadFilesRequest, parts *httpserver.UploadParts) (*model.UploadFilesResponse, error) {
+	if s.store == nil {
+		return nil, s.Errors.ErrNotFound
+	}
+
+	files, err := parts.SaveTo(ctx, s.store, uploadPrefix, maxUploadFiles)
+	if err != nil {
+		if _, ok := exception.AsExceptionError(err); ok {
+			return nil, err
+		}
+		// the transport turns a body over the maximum size into PayloadTooLarge
+		return nil, s.Errors.ErrUnableToProceed.Wrap(err)
+	}
+	if len(files) == 0 {
+		return nil, s.Errors.ErrValidationFailed.WithDebugMessage("no file in the request")
+	}
+
+	data := make([]model.UploadedFile, 0, len(files))
+	for _, file := range files {
+		url, err := s.store.SignedURL(ctx, file.Key, http.MethodGet, s.urlExpiry)
+		if err != nil {
+			return nil, s.Errors.ErrUnableToProceed.Wrap(err)
+		}
+		data = append(data, model.UploadedFile{StoredFile: file, URL: url})
+	}
+
+	return &model.UploadFilesResponse{
+		Status: 201,
+		Data:   data,
+	}, nil
+}
@@ -4,7 +4,10 @@ import (
 	"context"
 	"net/http"
 
+	"github.com/yourorg/go-api-template/core/exception"
 	"github.com/yourorg/go-api-template/core/health"
+	"github.com/yourorg/go-api-template/core/lifecycle"
+	"github.com/yourorg/go-api-template/internal/build"
 	"github.com/yourorg/go-api-template/internal/model"
 	"github.com/yourorg/go-api-template/internal/repository"
 )
@@ -20,9 +23,13 @@ type healthService struct {
 	healthChecker *health.HealthService
 }
 
-// NewHealthService creates a new health service
-func NewHealthService(repo *repository.Repository) HealthServiceInterface {
-	healthChecker := health.NewHealthService("v1.0.0")
+// NewHealthService creates a new health service; checkers are registered in addition to the database
+func NewHealthService(repo *repository.Repository, checkers map[string]health.Checker) HealthServiceInterface {
+	healthChecker := health.NewHealthService(health.BuildInfo{
+		Version: build.Version,
+		Commit:  build.Commit,
+		Time:    build.Time,
+	})
 
 	// Register database checker if database is available
 	if repo != nil && repo.DB != nil {
@@ -30,6 +37,12 @@ func NewHealthService(repo *repository.Repository) HealthServiceInterface {
 		healthChecker.RegisterChecker("database", dbChecker)
 	}
 
+	for name, checker := range checkers {
+		healthChecker.RegisterChecker(name, checker)
+	}
+	// dependency health as gauges, next to the health endpoints
+	healthChecker.ExportMetrics()
+
 	return &healthService{
 		healthChecker: healthChecker,
 	}
@@ -68,6 +81,14 @@ func (s *healthService) Liveness(ctx context.Context) (*model.LivenessResponse,
 func (s *healthService) Readiness(ctx context.Context) (*model.ReadinessResponse, error) {
 	readinessResult := s.healthChecker.Readiness(ctx)
 
+	// answer 503 while draining so load balancers stop routing here
+	if lifecycle.ShuttingDown() {
+		if cErr := exception.DefaultCatalog().Get("ShuttingDown"); cErr != nil {
+			return nil, cErr
+		}
+		return nil, exception.NewExceptionError(http.StatusInternalServerError, 209997, "The server is shutting down, please retry", http.StatusServiceUnavailable).WithTemporary()
+	}
+
 	// Determine HTTP status based on readiness
 	status := http.StatusOK
 	if readinessResult.Status == health.StatusUnhealthy {
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+
+	core_config "github.com/yourorg/go-api-template/core/config"
+	"github.com/yourorg/go-api-template/core/exception"
+	"github.com/yourorg/go-api-template/core/mockdata"
+	"github.com/yourorg/go-api-template/internal/model"
+	"github.com/yourorg/go-api-template/internal/repository"
+)
+
+// AllTables selects every table of the schema repository
+const AllTables = "all"
+
+// MockDataService generates mock data for the tables of the schema repository
+type MockDataService interface {
+	GenerateBatch(ctx context.Context, req *model.GenerateMockDataBatchRequest) iter.Seq2[mockdata.Progress, error]
+}
+
+type mockDataService struct {
+	schemas   repository.SchemaRepository
+	generator *mockdata.Generator
+	config    core_config.MockDataConfig
+	Errors    *exception.MockDataServiceErrors
+}
+
+// NewMockDataService creates a mock data service generating with generator
+func NewMockDataService(schemas repository.SchemaRepository, generator *mockdata.Generator, config core_config.MockDataConfig, errors *exception.MockDataServiceErrors) MockDataService {
+	return &mockDataService{
+		schemas:   schemas,
+		generator: generator,
+		config:    config,
+		Errors:    errors,
+	}
+}
+
+// GenerateBatch generates the tables of the request and their parents, parents first, reporting
+// the progress and token usage of each table. With apply, the rows are inserted into the target
+// database as well.
+func (s *mockDataService) GenerateBatch(ctx context.Context, req *model.GenerateMockDataBatchRequest) iter.Seq2[mockdata.Progress, error] {
+	return func(yield func(mockdata.Progress, error) bool) {
+		rows := req.Rows
+		if rows == 0 {
+			rows = s.config.DefaultRows
+		}
+		if s.config.MaxRows > 0 && rows > s.config.MaxRows {
+			yield(mockdata.Progress{}, s.invalid("rows", fmt.Sprintf("must be at most %d", s.config.MaxRows)))
+			return
+		}
+
+		var apply *mockdata.ApplyOptions
+		if req.Apply != nil {
+			if !s.config.Apply.Enabled {
+				yield(mockdata.Progress{}, s.invalid("apply", "applying mock data is disabled, see mockData.apply.enabled"))
+				return
+			}
+			apply = &mockdata.ApplyOptions{
+				Transaction: cmp.Or(req.Apply.Transaction, s.config.Apply.Transaction),
+				BatchSize:   cmp.Or(req.Apply.BatchSize, s.config.Apply.BatchSize),
+				DryRun:      req.Apply.DryRun,
+			}
+		}
+
+		tables, err := s.tables(ctx, req.Tables)
+		if err != nil {
+			yield(mockdata.Progress{}, err)
+			return
+		}
+		for progress, err := range s.generator.Batch(ctx, tables, rows, apply) {
+			if errors.Is(err, mockdata.ErrCycle) {
+				err = s.invalid("tables", err.Error())
+			}
+			if !yield(progress, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// tables loads the tables of names with their parents found in the schema repository
+func (s *mockDataService) tables(ctx context.Context, names []string) ([]mockdata.Table, error) {
+	if slices.Equal(names, []string{AllTables}) {
+		all, err := s.schemas.GetTableNames(ctx)
+		if err != nil {
+			return nil, s.Errors.ErrUnableToProceed.Wrap(err)
+		}
+		names = all
+	}
+
+	var tables []mockdata.Table
+	loaded := map[string]bool{}
+	queue := slices.Clone(names)
+	for i := 0; i < len(queue); i++ {
+		name := queue[i]
+		if loaded[name] {
+			continue
+		}
+		loaded[name] = true
+
+		script, err := s.schemas.GetTableScript(ctx, name)
+		if errors.Is(err, repository.ErrSchemaNotFound) {
+			if i < len(names) {
+				return nil, s.Errors.ErrNotFound.WithDatas(map[string]string{"table": name}).Wrap(err)
+			}
+			// a parent outside the schema repository is expected to exist already
+			continue
+		}
+		if err != nil {
+			return nil, s.Errors.ErrUnableToProceed.Wrap(err)
+		}
+		table, err := mockdata.ParseTable(name, script)
+		if err != nil {
+			return nil, s.Errors.ErrUnableToProceed.Wrap(err)
+		}
+		tables = append(tables, table)
+		queue = append(queue, table.Parents()...)
+	}
+
+	if s.config.MaxTables > 0 && len(tables) > s.config.MaxTables {
+		return nil, s.invalid("tables", fmt.Sprintf("resolve to %d tables with their parents, more than %d", len(tables), s.config.MaxTables))
+	}
+	return tables, nil
+}
+
+func (s *mockDataService) invalid(field string, message string) error {
+	return s.Errors.ErrValidationFailed.WithFields([]string{field}).WithDatas(map[string]string{field: message})
+}
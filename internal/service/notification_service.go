@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+
+	"github.com/yourorg/go-api-template/core/exception"
+	"github.com/yourorg/go-api-template/core/notify"
+	middleware_httpserver "github.com/yourorg/go-api-template/core/transport/httpserver/middlewares"
+	"github.com/yourorg/go-api-template/internal/model"
+)
+
+// NotificationService manages the notification preferences of the authenticated user
+type NotificationService interface {
+	GetPreferences(ctx context.Context, req *model.NotificationPreferencesRequest) (*model.NotificationPreferencesResponse, error)
+	UpdatePreferences(ctx context.Context, req *model.UpdateNotificationPreferencesRequest) (*model.NotificationPreferencesResponse, error)
+}
+
+type notificationService struct {
+	notifier *notify.Notifier
+	Errors   *exception.MockDataServiceErrors
+}
+
+// NewNotificationService creates a notification service; notifier is nil when notifications
+// are disabled
+func NewNotificationService(notifier *notify.Notifier, errors *exception.MockDataServiceErrors) NotificationService {
+	return &notificationService{
+		notifier: notifier,
+		Errors:   errors,
+	}
+}
+
+// GetPreferences returns the preferences of the user
+func (s *notificationService) GetPreferences(ctx context.Context, req *model.NotificationPreferencesRequest) (*model.NotificationPreferencesResponse, error) {
+	userID, err := s.userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.preferences(ctx, userID)
+}
+
+// UpdatePreferences stores the preferences of the request, returning all the preferences of the user
+func (s *notificationService) UpdatePreferences(ctx context.Context, req *model.UpdateNotificationPreferencesRequest) (*model.NotificationPreferencesResponse, error) {
+	userID, err := s.userID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	prefs := make([]notify.Preference, len(req.Preferences))
+	for i, pref := range req.Preferences {
+		prefs[i] = notify.Preference{Event: pref.Event, Channel: pref.Channel, Enabled: *pref.Enabled}
+	}
+	if err := s.notifier.SetPreferences(ctx, userID, prefs); err != nil {
+		return nil, s.Errors.ErrUnableToProceed.Wrap(err)
+	}
+	return s.preferences(ctx, userID)
+}
+
+// userID returns the user of the request, the preferences being per user
+func (s *notificationService) userID(ctx context.Context) (string, error) {
+	if s.notifier == nil {
+		return "", s.Errors.ErrNotFound
+	}
+	userID, ok := middleware_httpserver.GetUserIDFromContext(ctx)
+	if !ok || userID == "" {
+		return "", s.Errors.ErrUnauthorized
+	}
+	return userID, nil
+}
+
+func (s *notificationService) preferences(ctx context.Context, userID string) (*model.NotificationPreferencesResponse, error) {
+	prefs, err := s.notifier.Preferences(ctx, userID)
+	if err != nil {
+		return nil, s.Errors.ErrUnableToProceed.Wrap(err)
+	}
+	if prefs == nil {
+		prefs = []notify.Preference{}
+	}
+	return &model.NotificationPreferencesResponse{
+		Status: 200,
+		Data:   prefs,
+	}, nil
+}
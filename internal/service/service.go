@@ -2,9 +2,18 @@ package service
 
 import (
 	"github.com/yourorg/go-api-template/config"
+	"github.com/yourorg/go-api-template/core/audit"
 	"github.com/yourorg/go-api-template/core/auth"
+	"github.com/yourorg/go-api-template/core/eventbus"
+	"github.com/yourorg/go-api-template/core/events"
 	"github.com/yourorg/go-api-template/core/exception"
+	"github.com/yourorg/go-api-template/core/health"
 	"github.com/yourorg/go-api-template/core/httpclient"
+	"github.com/yourorg/go-api-template/core/jobs"
+	"github.com/yourorg/go-api-template/core/mockdata"
+	"github.com/yourorg/go-api-template/core/notify"
+	"github.com/yourorg/go-api-template/core/storage"
+	"github.com/yourorg/go-api-template/core/usage"
 	"github.com/yourorg/go-api-template/internal/repository"
 	"github.com/yourorg/go-api-template/utils"
 )
@@ -12,13 +21,32 @@ import (
 type Service struct {
 	Config *config.Config
 	Errors *exception.MockDataServiceErrors
+	LLM    httpclient.LLMProvider
+	// Jobs enqueues background work run by the worker command, nil when jobs are disabled
+	Jobs jobs.Queue
+	// Audit records the changes of the audited routes, nil when auditing is disabled
+	Audit *audit.Recorder
+	// Events publishes to the event bus, nil when it is disabled
+	Events *eventbus.Bus
+	// Storage keeps uploaded files, nil when storage is disabled
+	Storage storage.Storage
+	// DomainEvents runs the handlers of the domain events within the process
+	DomainEvents *events.Dispatcher
+	// Notifier notifies the users through their channels, nil when notifications are disabled
+	Notifier *notify.Notifier
 
 	// Core services
-	HealthService  HealthServiceInterface
-	AuthService    AuthService
+	HealthService       HealthServiceInterface
+	AuthService         AuthService
+	UsageService        UsageService
+	AuditService        AuditService
+	FileService         FileService
+	NotificationService NotificationService
+	MockDataService     MockDataService
 	
 	// Example services - replace with your actual services
 	ExampleService ExampleService
+	// +scaffold:service-fields - `generate resource` adds services above
 }
 
 func NewService(
@@ -26,20 +54,47 @@ func NewService(
 	config *config.Config,
 	errors *exception.MockDataServiceErrors,
 	utils *utils.Utils,
-	lmStudioClient *httpclient.LmStudioServiceClient,
+	llm httpclient.LLMProvider,
+	healthCheckers map[string]health.Checker,
+	usageStore usage.Store,
+	jobQueue jobs.Queue,
+	auditStore audit.Store,
+	bus *eventbus.Bus,
+	store storage.Storage,
+	dispatcher *events.Dispatcher,
+	notifier *notify.Notifier,
+	sandbox mockdata.Sandbox,
+	applier *mockdata.Applier,
 ) Service {
 	// Initialize auth core service
 	authCore := auth.NewAuthService(config.Auth.JWTSecretKey)
+	var auditRecorder *audit.Recorder
+	if auditStore != nil {
+		auditRecorder = audit.NewRecorder(auditStore)
+	}
 	
 	return Service{
-		Config: config,
-		Errors: errors,
+		Config:       config,
+		Errors:       errors,
+		LLM:          llm,
+		Jobs:         jobQueue,
+		Audit:        auditRecorder,
+		Events:       bus,
+		Storage:      store,
+		DomainEvents: dispatcher,
+		Notifier:     notifier,
 
 		// Core services
-		HealthService: NewHealthService(repo),
-		AuthService:   NewAuthService(authCore, errors),
+		HealthService:       NewHealthService(repo, healthCheckers),
+		AuthService:         NewAuthService(authCore, errors),
+		UsageService:        NewUsageService(usageStore, config.LLM.Usage.MonthlyTokenBudget, errors),
+		AuditService:        NewAuditService(auditStore, errors),
+		FileService:         NewFileService(store, config.Storage.SignedURLExpiry, errors),
+		NotificationService: NewNotificationService(notifier, errors),
+		MockDataService:     NewMockDataService(repo.SchemaRepository, mockdata.NewGenerator(llm, config.MockData, mockdata.WithSandbox(sandbox), mockdata.WithApplier(applier)), config.MockData, errors),
 
 		// Example services - replace with your actual services
-		ExampleService: NewExampleService(repo, errors),
+		ExampleService: NewExampleService(repo, errors, jobQueue, dispatcher),
+		// +scaffold:services
 	}
 }
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/yourorg/go-api-template/core/exception"
+	"github.com/yourorg/go-api-template/core/usage"
+	"github.com/yourorg/go-api-template/internal/model"
+)
+
+// UsageService reports LLM token consumption of the authenticated caller
+type UsageService interface {
+	GetUsage(ctx context.Context, req *model.UsageRequest) (*model.UsageResponse, error)
+}
+
+type usageService struct {
+	store  usage.Store
+	budget int64
+	Errors *exception.MockDataServiceErrors
+}
+
+// NewUsageService creates a usage service; store is nil when usage accounting is disabled
+func NewUsageService(store usage.Store, budget int64, errors *exception.MockDataServiceErrors) UsageService {
+	return &usageService{
+		store:  store,
+		budget: budget,
+		Errors: errors,
+	}
+}
+
+// GetUsage returns the caller's usage for the requested month
+func (s *usageService) GetUsage(ctx context.Context, req *model.UsageRequest) (*model.UsageResponse, error) {
+	if s.store == nil {
+		return nil, s.Errors.ErrNotFound
+	}
+
+	period := req.Period
+	if period == "" {
+		period = usage.Period(time.Now())
+	}
+	if _, err := time.Parse("2006-01", period); err != nil {
+		return nil, s.Errors.ErrInvalidRequest.Wrap(err)
+	}
+
+	caller := usage.CallerFromContext(ctx)
+	used, err := s.store.Get(ctx, caller, period)
+	if err != nil {
+		return nil, s.Errors.ErrUnableToProceed.Wrap(err)
+	}
+
+	data := model.UsageResponse_Data{
+		Caller: caller,
+		Period: period,
+		Usage:  used,
+		Budget: s.budget,
+	}
+	if s.budget > 0 {
+		data.RemainingTokens = max(s.budget-used.TotalTokens, 0)
+	}
+
+	return &model.UsageResponse{
+		Status: 200,
+		Data:   data,
+	}, nil
+}
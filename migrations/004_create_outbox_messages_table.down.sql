@@ -0,0 +1,2 @@
+-- Drop the outbox_messages table
+DROP TABLE IF EXISTS outbox_messages;
\ No newline at end of file
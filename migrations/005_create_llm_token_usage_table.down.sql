@@ -0,0 +1,2 @@
+-- Drop the llm_token_usage table
+DROP TABLE IF EXISTS llm_token_usage;
\ No newline at end of file
@@ -0,0 +1,14 @@
+-- Create llm_token_usage table for per-caller monthly token accounting
+CREATE TABLE IF NOT EXISTS llm_token_usage (
+    caller VARCHAR(255) NOT NULL,
+    period CHAR(7) NOT NULL,
+    prompt_tokens BIGINT NOT NULL DEFAULT 0,
+    completion_tokens BIGINT NOT NULL DEFAULT 0,
+    total_tokens BIGINT NOT NULL DEFAULT 0,
+    requests BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (caller, period)
+);
+
+CREATE INDEX IF NOT EXISTS idx_llm_token_usage_period ON llm_token_usage(period);
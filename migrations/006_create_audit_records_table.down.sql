@@ -0,0 +1,2 @@
+-- Drop the audit_records table
+DROP TABLE IF EXISTS audit_records;
\ No newline at end of file
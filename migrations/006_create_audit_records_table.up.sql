@@ -0,0 +1,18 @@
+-- Create audit_records table for the trail of data-changing requests per entity
+CREATE TABLE IF NOT EXISTS audit_records (
+    id BIGSERIAL PRIMARY KEY,
+    recorded_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    actor VARCHAR(255) NOT NULL,
+    request_id VARCHAR(255) NOT NULL DEFAULT '',
+    method VARCHAR(10) NOT NULL,
+    route VARCHAR(255) NOT NULL,
+    entity VARCHAR(100) NOT NULL,
+    entity_id VARCHAR(255) NOT NULL DEFAULT '',
+    old_hash CHAR(64) NOT NULL DEFAULT '',
+    new_hash CHAR(64) NOT NULL DEFAULT '',
+    status INTEGER NOT NULL,
+    outcome VARCHAR(10) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_records_entity ON audit_records(entity, entity_id, id DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_records_actor ON audit_records(actor);
@@ -0,0 +1,2 @@
+-- Drop the processed_events table
+DROP TABLE IF EXISTS processed_events;
\ No newline at end of file
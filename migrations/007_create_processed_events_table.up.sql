@@ -0,0 +1,10 @@
+-- Create processed_events table for the idempotent event consumers (eventbus.IdempotentTx)
+CREATE TABLE IF NOT EXISTS processed_events (
+    consumer_group VARCHAR(255) NOT NULL,
+    event_id VARCHAR(255) NOT NULL,
+    processed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (consumer_group, event_id)
+);
+
+-- Used to purge old rows once redeliveries are no longer expected
+CREATE INDEX IF NOT EXISTS idx_processed_events_processed_at ON processed_events(processed_at);
@@ -0,0 +1,2 @@
+-- Drop the notification_preferences table
+DROP TABLE IF EXISTS notification_preferences;
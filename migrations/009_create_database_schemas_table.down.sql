@@ -0,0 +1,2 @@
+-- Drop the database_schemas table
+DROP TABLE IF EXISTS database_schemas;
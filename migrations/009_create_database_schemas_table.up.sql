@@ -0,0 +1,5 @@
+-- Create database_schemas table holding the CREATE TABLE scripts mock data is generated for
+CREATE TABLE IF NOT EXISTS database_schemas (
+    table_name VARCHAR(100) PRIMARY KEY,
+    table_script TEXT
+);
@@ -0,0 +1,5 @@
+-- Restore the partial index of the pending messages and drop the dead_at column
+DROP INDEX IF EXISTS idx_outbox_messages_dead_at;
+DROP INDEX IF EXISTS idx_outbox_messages_pending;
+CREATE INDEX IF NOT EXISTS idx_outbox_messages_pending ON outbox_messages(next_attempt_at, created_at) WHERE published_at IS NULL;
+ALTER TABLE outbox_messages DROP COLUMN IF EXISTS dead_at;
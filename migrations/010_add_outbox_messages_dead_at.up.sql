@@ -0,0 +1,7 @@
+-- Mark the outbox messages the poller gave up on, out of attempts or rejected for good
+ALTER TABLE outbox_messages ADD COLUMN IF NOT EXISTS dead_at TIMESTAMP WITH TIME ZONE;
+
+-- Keep the dead letters out of the partial index used by the poller
+DROP INDEX IF EXISTS idx_outbox_messages_pending;
+CREATE INDEX IF NOT EXISTS idx_outbox_messages_pending ON outbox_messages(next_attempt_at, created_at) WHERE published_at IS NULL AND dead_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_outbox_messages_dead_at ON outbox_messages(dead_at) WHERE dead_at IS NOT NULL;
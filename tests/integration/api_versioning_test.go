@@ -0,0 +1,90 @@
+package integration
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yourorg/go-api-template/core/logger"
+	"github.com/yourorg/go-api-template/core/transport/httpserver"
+	middleware "github.com/yourorg/go-api-template/core/transport/httpserver/middlewares"
+)
+
+type versionResp struct {
+	Version string `json:"version"`
+}
+
+func TestAPIVersioning(t *testing.T) {
+	logger.Slog = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	version := httpserver.NewTransport(&struct{}{}, httpserver.NewEndpoint(
+		func(ctx context.Context, in *struct{}) (*versionResp, error) {
+			v, _ := httpserver.GetAPIVersionFromContext(ctx)
+			return &versionResp{Version: v}, nil
+		}))
+	register := func(r *httpserver.Router) {
+		r.Get("/version", version)
+	}
+
+	sunset := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	mux := http.NewServeMux()
+	r := httpserver.NewRouter(mux)
+	register(r.Group("/api/v1", httpserver.VersionMiddleware("v1"), middleware.DeprecationMiddleware(middleware.DeprecationConfig{
+		Since:  time.Unix(1700000000, 0),
+		Sunset: sunset,
+		Link:   "https://example.com/migrate-to-v2",
+	})))
+	register(r.Group("/api/v2", httpserver.VersionMiddleware("v2")))
+	r.Get("/api/version", httpserver.AcceptVersion(map[string]http.Handler{
+		"v1": version,
+		"v2": version,
+	}, "v1"))
+
+	serve := func(target string, acceptVersion string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if acceptVersion != "" {
+			req.Header.Set(httpserver.AcceptVersionHeader, acceptVersion)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("path versions share handlers", func(t *testing.T) {
+		rec := serve("/api/v1/version", "")
+		assert.JSONEq(t, `{"version":"v1"}`, rec.Body.String())
+		assert.Equal(t, "@1700000000", rec.Header().Get("Deprecation"))
+		assert.Equal(t, "Fri, 01 Jan 2027 00:00:00 GMT", rec.Header().Get("Sunset"))
+		assert.Equal(t, `<https://example.com/migrate-to-v2>; rel="deprecation"`, rec.Header().Get("Link"))
+
+		rec = serve("/api/v2/version", "")
+		assert.JSONEq(t, `{"version":"v2"}`, rec.Body.String())
+		assert.Empty(t, rec.Header().Get("Deprecation"))
+	})
+
+	t.Run("routes are recorded with the prefix", func(t *testing.T) {
+		var paths []string
+		for _, route := range r.Routes() {
+			paths = append(paths, route.Path)
+		}
+		assert.Equal(t, []string{"/api/v1/version", "/api/v2/version", "/api/version"}, paths)
+	})
+
+	t.Run("Accept-Version header", func(t *testing.T) {
+		rec := serve("/api/version", "")
+		assert.JSONEq(t, `{"version":"v1"}`, rec.Body.String())
+		assert.Contains(t, rec.Header().Values("Vary"), httpserver.AcceptVersionHeader)
+
+		rec = serve("/api/version", "V2")
+		assert.JSONEq(t, `{"version":"v2"}`, rec.Body.String())
+
+		rec = serve("/api/version", "v9")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "v1, v2")
+	})
+}
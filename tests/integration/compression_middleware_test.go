@@ -0,0 +1,87 @@
+package integration
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	middleware "github.com/yourorg/go-api-template/core/transport/httpserver/middlewares"
+)
+
+func compressionHandler(contentType string, body string) http.Handler {
+	return middleware.CompressionMiddleware(middleware.CompressionConfig{
+		Enabled: true,
+		MinSize: 100,
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		// written in parts to cross MinSize mid-response
+		io.WriteString(w, body[:len(body)/2])
+		io.WriteString(w, body[len(body)/2:])
+	}))
+}
+
+func TestCompressionMiddlewareNegotiatesEncoding(t *testing.T) {
+	body := `{"sql":"` + strings.Repeat("SELECT * FROM products; ", 50) + `"}`
+	handler := compressionHandler("application/json", body)
+
+	tests := []struct {
+		acceptEncoding string
+		encoding       string
+	}{
+		{"gzip, deflate, br", "gzip"},
+		{"deflate", "deflate"},
+		{"gzip;q=0.5, deflate", "deflate"},
+		{"gzip;q=0", ""},
+		{"br", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.acceptEncoding, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.encoding, rec.Header().Get("Content-Encoding"))
+			assert.Contains(t, rec.Header().Values("Vary"), "Accept-Encoding")
+
+			var reader io.Reader = rec.Body
+			switch tt.encoding {
+			case "gzip":
+				zr, err := gzip.NewReader(rec.Body)
+				require.NoError(t, err)
+				reader = zr
+			case "deflate":
+				reader = flate.NewReader(rec.Body)
+			}
+			decoded, err := io.ReadAll(reader)
+			require.NoError(t, err)
+			assert.Equal(t, body, string(decoded))
+		})
+	}
+}
+
+func TestCompressionMiddlewareSkipsSmallAndUnlistedResponses(t *testing.T) {
+	for name, handler := range map[string]http.Handler{
+		"below min size": compressionHandler("application/json", `{"status":200}`),
+		"unlisted type":  compressionHandler("image/png", strings.Repeat("x", 500)),
+		"event stream":   compressionHandler("text/event-stream", strings.Repeat("data: x\n\n", 50)),
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept-Encoding", "gzip")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Empty(t, rec.Header().Get("Content-Encoding"))
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.NotEmpty(t, rec.Body.String())
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+	"github.com/yourorg/go-api-template/core/logger"
+	"github.com/yourorg/go-api-template/core/transport/httpserver"
+)
+
+type noteReq struct {
+	Title string `json:"title" xml:"title" validate:"required"`
+}
+
+type noteResp struct {
+	Title string `json:"title" xml:"title"`
+	Words int    `json:"words" xml:"words"`
+}
+
+type noteListReq struct {
+	Rows []noteResp `json:"rows"`
+}
+
+type noteListResp struct {
+	Status int        `json:"status"`
+	Data   []noteResp `json:"data"`
+}
+
+func negotiationMux(t *testing.T) *http.ServeMux {
+	logger.Slog = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	note := func(ctx context.Context, req *noteReq) (*noteResp, error) {
+		return &noteResp{Title: req.Title, Words: len(strings.Fields(req.Title))}, nil
+	}
+	list := func(ctx context.Context, req *noteListReq) (*noteListResp, error) {
+		return &noteListResp{Status: 200, Data: req.Rows}, nil
+	}
+
+	mux := http.NewServeMux()
+	router := httpserver.NewRouter(mux)
+	router.Post("/notes", httpserver.NewTransport(&noteReq{}, httpserver.NewEndpoint(note)))
+	router.Post("/notes/export", httpserver.NewTransport(&noteListReq{}, httpserver.NewEndpoint(list)))
+	return mux
+}
+
+func serveNegotiated(mux *http.ServeMux, path string, contentType string, accept string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestTransportNegotiatesResponseEncoding(t *testing.T) {
+	mux := negotiationMux(t)
+
+	rec := serveNegotiated(mux, "/notes", "", "", `{"title":"hello world"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	rec = serveNegotiated(mux, "/notes", "", "text/html,application/xml;q=0.9,*/*;q=0.8", `{"title":"hello world"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/xml", rec.Header().Get("Content-Type"))
+	var fromXML noteResp
+	require.NoError(t, xml.Unmarshal(rec.Body.Bytes(), &fromXML))
+	assert.Equal(t, noteResp{Title: "hello world", Words: 2}, fromXML)
+
+	rec = serveNegotiated(mux, "/notes", "", "application/msgpack", `{"title":"hello world"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/msgpack", rec.Header().Get("Content-Type"))
+	var fromMsgpack map[string]any
+	require.NoError(t, msgpack.Unmarshal(rec.Body.Bytes(), &fromMsgpack))
+	assert.Equal(t, "hello world", fromMsgpack["title"], "keys follow the json tags")
+
+	// CSV needs rows, a single note cannot be represented
+	rec = serveNegotiated(mux, "/notes", "", "text/csv", `{"title":"hello world"}`)
+	assert.Equal(t, http.StatusNotAcceptable, rec.Code)
+	var errBody struct {
+		Data map[string]string `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&errBody))
+	assert.NotContains(t, errBody.Data["supported"], "text/csv")
+
+	// an explicit q=0 wins over the wildcard
+	rec = serveNegotiated(mux, "/notes", "", "application/json;q=0, */*;q=0.1", `{"title":"hello world"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/xml", rec.Header().Get("Content-Type"))
+}
+
+func TestTransportDecodesRequestByContentType(t *testing.T) {
+	mux := negotiationMux(t)
+
+	rec := serveNegotiated(mux, "/notes", "application/xml; charset=utf-8", "", `<noteReq><title>from xml</title></noteReq>`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp noteResp
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	assert.Equal(t, "from xml", resp.Title)
+
+	rec = serveNegotiated(mux, "/notes", "text/plain", "", `from text`)
+	assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+
+	// CSV in and out for tabular endpoints
+	rec = serveNegotiated(mux, "/notes/export", "text/csv", "text/csv", "title,words\n\"a, b\",2\nc,1\n")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/csv", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "title,words\n\"a, b\",2\nc,1\n", rec.Body.String())
+}
+
+func TestTransportRestrictsMediaTypes(t *testing.T) {
+	mux := negotiationMux(t)
+	httpserver.SetAllowedMediaTypes([]string{"application/xml", "application/json"})
+	t.Cleanup(func() { httpserver.SetAllowedMediaTypes(nil) })
+
+	// the first allowed type is the default
+	rec := serveNegotiated(mux, "/notes", "application/json", "*/*", `{"title":"restricted"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/xml", rec.Header().Get("Content-Type"))
+
+	rec = serveNegotiated(mux, "/notes", "", "application/msgpack", `<noteReq><title>restricted</title></noteReq>`)
+	assert.Equal(t, http.StatusNotAcceptable, rec.Code)
+
+	rec = serveNegotiated(mux, "/notes", "application/msgpack", "", "")
+	assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+}
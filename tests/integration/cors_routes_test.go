@@ -0,0 +1,73 @@
+package integration
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	core_config "github.com/yourorg/go-api-template/core/config"
+	"github.com/yourorg/go-api-template/core/transport/httpserver"
+)
+
+func TestCORSRoutes(t *testing.T) {
+	cfg := core_config.CORS{
+		CORSPolicy: core_config.CORSPolicy{
+			AllowedOrigins: []string{"*"},
+			AllowedMethods: []string{http.MethodGet, http.MethodPost},
+			MaxAge:         600,
+		},
+		Routes: []core_config.CORSRoute{
+			{PathPrefix: "/api/admin", CORSPolicy: core_config.CORSPolicy{AllowedOrigins: []string{"https://admin.internal"}}},
+			{PathPrefix: "/api/admin/public/", CORSPolicy: core_config.CORSPolicy{AllowedOrigins: []string{"https://partner.example"}}},
+		},
+	}
+	handler := httpserver.CORSMiddleware(cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	preflight := func(path, origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodOptions, path, nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		origin  string
+		allowed string
+	}{
+		{"global policy", "/api/v1/examples", "https://any.example", "*"},
+		{"route rejects other origins", "/api/admin/users", "https://any.example", ""},
+		{"route allows its origins", "/api/admin/users", "https://admin.internal", "https://admin.internal"},
+		{"prefix itself", "/api/admin", "https://admin.internal", "https://admin.internal"},
+		{"longest prefix wins", "/api/admin/public/docs", "https://partner.example", "https://partner.example"},
+		{"longest prefix only", "/api/admin/public/docs", "https://admin.internal", ""},
+		{"prefix matches whole segments", "/api/administrators", "https://any.example", "*"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := preflight(tt.path, tt.origin)
+			assert.Equal(t, tt.allowed, rec.Header().Get("Access-Control-Allow-Origin"))
+		})
+	}
+
+	t.Run("unset fields inherit the global policy", func(t *testing.T) {
+		rec := preflight("/api/admin/users", "https://admin.internal")
+		assert.Equal(t, "600", rec.Header().Get("Access-Control-Max-Age"))
+		assert.Equal(t, http.MethodPost, rec.Header().Get("Access-Control-Allow-Methods"))
+	})
+
+	t.Run("simple requests", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/users", nil)
+		req.Header.Set("Origin", "https://any.example")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, rec.Header().Get("Access-Control-Allow-Origin"))
+	})
+}
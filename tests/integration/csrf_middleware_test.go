@@ -0,0 +1,113 @@
+package integration
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/core/transport/httpserver"
+	middleware "github.com/yourorg/go-api-template/core/transport/httpserver/middlewares"
+)
+
+func csrfHandler(config middleware.CSRFConfig) http.Handler {
+	config.WriteError = httpserver.WriteError
+	return middleware.CSRFMiddleware(config)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, _ := middleware.GetCSRFTokenFromContext(r.Context())
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Token", token)
+		w.Write(body)
+	}))
+}
+
+func serveCSRF(handler http.Handler, method, target string, cookies []*http.Cookie, header http.Header, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestCSRFDoubleSubmit(t *testing.T) {
+	config := middleware.DefaultCSRFConfig()
+	config.Secret = "test-secret"
+	config.ExemptPaths = []string{"/webhooks"}
+	handler := csrfHandler(config)
+
+	rec := serveCSRF(handler, http.MethodGet, "/form", nil, nil, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	cookies := rec.Result().Cookies()
+	require.Len(t, cookies, 1)
+	token := cookies[0].Value
+	assert.Equal(t, "csrf_token", cookies[0].Name)
+	assert.Equal(t, token, rec.Header().Get("X-Token"))
+
+	t.Run("header token", func(t *testing.T) {
+		rec := serveCSRF(handler, http.MethodPost, "/items", cookies, http.Header{"X-Csrf-Token": {token}}, `{}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, rec.Result().Cookies(), "valid cookie is kept")
+	})
+
+	t.Run("form token leaves the body readable", func(t *testing.T) {
+		body := "name=widget&csrf_token=" + token
+		rec := serveCSRF(handler, http.MethodPost, "/items", cookies,
+			http.Header{"Content-Type": {"application/x-www-form-urlencoded"}}, body)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, body, rec.Body.String())
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		rec := serveCSRF(handler, http.MethodPost, "/items", cookies, nil, `{}`)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, "Invalid or missing CSRF token", body["message"])
+
+		rec = serveCSRF(handler, http.MethodDelete, "/items/1", cookies, http.Header{"X-Csrf-Token": {"forged"}}, "")
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+
+		// a cookie not signed with the secret, e.g. planted by a subdomain, is replaced
+		planted := []*http.Cookie{{Name: "csrf_token", Value: "planted"}}
+		rec = serveCSRF(handler, http.MethodPost, "/items", planted, http.Header{"X-Csrf-Token": {"planted"}}, `{}`)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("exempt", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serveCSRF(handler, http.MethodPost, "/webhooks/stripe", nil, nil, "").Code)
+		assert.Equal(t, http.StatusOK, serveCSRF(handler, http.MethodPost, "/items", nil,
+			http.Header{"Authorization": {"Bearer abc"}}, "").Code)
+	})
+}
+
+func TestCSRFSynchronizer(t *testing.T) {
+	config := middleware.DefaultCSRFConfig()
+	config.Mode = middleware.CSRFSynchronizer
+	config.Secret = "test-secret"
+	config.SessionCookie = "session"
+	handler := csrfHandler(config)
+
+	session := []*http.Cookie{{Name: "session", Value: "user-1-session"}}
+	rec := serveCSRF(handler, http.MethodGet, "/form", session, nil, "")
+	token := rec.Header().Get("X-Token")
+	require.NotEmpty(t, token)
+	assert.Empty(t, rec.Result().Cookies(), "no token cookie in synchronizer mode")
+
+	rec = serveCSRF(handler, http.MethodPost, "/items", session, http.Header{"X-Csrf-Token": {token}}, "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	other := []*http.Cookie{{Name: "session", Value: "user-2-session"}}
+	rec = serveCSRF(handler, http.MethodPost, "/items", other, http.Header{"X-Csrf-Token": {token}}, "")
+	assert.Equal(t, http.StatusForbidden, rec.Code, "token bound to another session")
+
+	rec = serveCSRF(handler, http.MethodPost, "/items", nil, nil, "")
+	assert.Equal(t, http.StatusOK, rec.Code, "no session to protect")
+}
@@ -0,0 +1,79 @@
+package integration
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/core/logger"
+	"github.com/yourorg/go-api-template/core/tracing"
+	middleware "github.com/yourorg/go-api-template/core/transport/httpserver/middlewares"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestDebugTrace(t *testing.T) {
+	logger.Slog = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	// nothing is sampled but the debug traces
+	cfg := tracing.DefaultConfig()
+	cfg.SampleRatio = 0
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSampler(cfg.Sampler()), sdktrace.WithSpanProcessor(recorder))
+	defer tp.Shutdown(context.Background())
+
+	const secret = "debug-trace-secret"
+	var debugLogs bool
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		debugLogs = logger.DebugLogsEnabled(r.Context())
+	})
+	server := middleware.DebugTraceMiddleware(middleware.DefaultDebugTraceConfig(), secret)(
+		otelhttp.NewHandler(middleware.DebugTraceResponseMiddleware()(handler), "", otelhttp.WithTracerProvider(tp)),
+	)
+
+	token := func(roles ...string) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &middleware.UserClaims{UserID: "user-7", Roles: roles}).
+			SignedString([]byte(secret))
+		require.NoError(t, err)
+		return "Bearer " + token
+	}
+
+	tests := []struct {
+		name    string
+		header  http.Header
+		sampled bool
+	}{
+		{"admin", http.Header{"X-Debug-Trace": {"true"}, "Authorization": {token("user", "admin")}}, true},
+		{"without the header", http.Header{"Authorization": {token("admin")}}, false},
+		{"without the role", http.Header{"X-Debug-Trace": {"true"}, "Authorization": {token("user")}}, false},
+		{"without a token", http.Header{"X-Debug-Trace": {"true"}}, false},
+		{"forged token", http.Header{"X-Debug-Trace": {"true"}, "Authorization": {token("admin") + "x"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder.Reset()
+			debugLogs = false
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/examples", nil)
+			req.Header = tt.header
+			rec := httptest.NewRecorder()
+			server.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.sampled, debugLogs)
+			spans := recorder.Ended()
+			if !tt.sampled {
+				assert.Empty(t, spans)
+				assert.Empty(t, rec.Header().Get(middleware.TraceIDHeader))
+				return
+			}
+			require.Len(t, spans, 1)
+			assert.Equal(t, spans[0].SpanContext().TraceID().String(), rec.Header().Get(middleware.TraceIDHeader))
+		})
+	}
+}
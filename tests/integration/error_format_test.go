@@ -0,0 +1,127 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/core/exception"
+	"github.com/yourorg/go-api-template/core/logger"
+	"github.com/yourorg/go-api-template/core/transport/httpserver"
+	middleware "github.com/yourorg/go-api-template/core/transport/httpserver/middlewares"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type errorFormatReq struct{}
+
+func TestProblemJSONErrorFormat(t *testing.T) {
+	logger.Slog = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	httpserver.SetErrorFormat(httpserver.ErrorFormatProblem, "https://example.com/errors")
+	defer httpserver.SetErrorFormat(httpserver.ErrorFormatDefault, "")
+
+	appErrors := exception.NewMockDataServiceErrors()
+	svc := func(ctx context.Context, req *errorFormatReq) (any, error) {
+		return nil, appErrors.ErrNotFound.WithDatas(map[string]string{"id": "42"})
+	}
+	handler := httpserver.NewTransport(&errorFormatReq{}, httpserver.NewEndpoint(svc))
+
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodPost, "/api/v1/products/42", strings.NewReader("{}")))
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, exception.ProblemContentType, rec.Header().Get("Content-Type"))
+
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "https://example.com/errors/200002", body["type"])
+	assert.Equal(t, "Not found", body["title"])
+	assert.Equal(t, float64(http.StatusNotFound), body["status"])
+	assert.Equal(t, "/api/v1/products/42", body["instance"])
+	assert.Equal(t, "42", body["id"])
+}
+
+func TestDebugMessageGate(t *testing.T) {
+	logger.Slog = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	appErrors := exception.NewMockDataServiceErrors()
+	svc := func(ctx context.Context, req *errorFormatReq) (any, error) {
+		return nil, appErrors.ErrNotFound.WithDebugMessage("product 42 not in catalog")
+	}
+	handler := httpserver.NewTransport(&errorFormatReq{}, httpserver.NewEndpoint(svc))
+
+	call := func() map[string]any {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodPost, "/api/v1/products/42", strings.NewReader("{}")))
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		return body
+	}
+
+	body := call()
+	assert.NotContains(t, body, "debug_message")
+	assert.NotContains(t, body, "stack")
+
+	exception.SetDebug(true)
+	defer exception.SetDebug(false)
+
+	body = call()
+	assert.Equal(t, "product 42 not in catalog", body["debug_message"])
+	assert.NotEmpty(t, body["stack"])
+}
+
+func TestErrorResponseIDs(t *testing.T) {
+	logger.Slog = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	tp := sdktrace.NewTracerProvider()
+	defer tp.Shutdown(context.Background())
+
+	appErrors := exception.NewMockDataServiceErrors()
+	svc := func(ctx context.Context, req *errorFormatReq) (any, error) {
+		return nil, appErrors.ErrNotFound
+	}
+	handler := middleware.RequestIDMiddleware(middleware.DefaultRequestIDConfig())(
+		httpserver.NewTransport(&errorFormatReq{}, httpserver.NewEndpoint(svc)),
+	)
+
+	call := func(sampled bool) (map[string]any, string) {
+		ctx := context.Background()
+		traceID := ""
+		if sampled {
+			var span trace.Span
+			ctx, span = tp.Tracer("test").Start(ctx, "inbound")
+			defer span.End()
+			traceID, _ = middleware.GetTraceIDFromContext(ctx)
+		}
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/products/42", strings.NewReader("{}")).WithContext(ctx)
+		req.Header.Set(middleware.RequestIDHeader, "req-42")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		return body, traceID
+	}
+
+	for _, format := range []httpserver.ErrorFormat{httpserver.ErrorFormatDefault, httpserver.ErrorFormatProblem} {
+		httpserver.SetErrorFormat(format, "")
+
+		body, traceID := call(true)
+		require.NotEmpty(t, traceID)
+		assert.Equal(t, "req-42", body["request_id"], format)
+		assert.Equal(t, traceID, body["trace_id"], format)
+
+		body, _ = call(false)
+		assert.Equal(t, "req-42", body["request_id"], format)
+		assert.NotContains(t, body, "trace_id", "unsampled traces cannot be looked up")
+	}
+	httpserver.SetErrorFormat(httpserver.ErrorFormatDefault, "")
+}
@@ -0,0 +1,62 @@
+package integration
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/core/exception"
+	"github.com/yourorg/go-api-template/core/logger"
+	"github.com/yourorg/go-api-template/core/transport/httpserver"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestErrorMetrics(t *testing.T) {
+	logger.Slog = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	reader := sdkmetric.NewManualReader()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+
+	appErrors := exception.NewMockDataServiceErrors()
+	svc := func(ctx context.Context, req *errorFormatReq) (any, error) {
+		return nil, appErrors.ErrNotFound
+	}
+
+	mux := http.NewServeMux()
+	httpserver.NewRouter(mux).Post("/api/v1/products/{id}", httpserver.NewTransport(&errorFormatReq{}, httpserver.NewEndpoint(svc)))
+
+	for _, id := range []string{"1", "2"} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/products/"+id, strings.NewReader("{}")))
+		require.Equal(t, http.StatusNotFound, rec.Code)
+	}
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+
+	var sum metricdata.Sum[int64]
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name == "http.server.errors" {
+				sum = m.Data.(metricdata.Sum[int64])
+			}
+		}
+	}
+	require.Len(t, sum.DataPoints, 1, "both requests share one route label")
+
+	dp := sum.DataPoints[0]
+	assert.Equal(t, int64(2), dp.Value)
+	route, _ := dp.Attributes.Value(attribute.Key("http.route"))
+	assert.Equal(t, "POST /api/v1/products/{id}", route.AsString())
+	code, _ := dp.Attributes.Value(attribute.Key("error.code"))
+	assert.Equal(t, int64(200002), code.AsInt64())
+}
@@ -0,0 +1,87 @@
+package integration
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	middleware "github.com/yourorg/go-api-template/core/transport/httpserver/middlewares"
+)
+
+func TestETagMiddleware(t *testing.T) {
+	body := `{"id":"42","name":"widget"}`
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case "/versioned":
+			w.Header().Set("ETag", `"v7"`)
+		case "/stream":
+			w.Write([]byte("data: 1\n\n"))
+			w.(http.Flusher).Flush()
+			w.Write([]byte("data: 2\n\n"))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	})
+
+	serve := func(config middleware.ETagConfig, method, path, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		middleware.ETagMiddleware(config)(handler).ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("conditional GET", func(t *testing.T) {
+		rec := serve(middleware.ETagConfig{}, http.MethodGet, "/items", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, body, rec.Body.String())
+		etag := rec.Header().Get("ETag")
+		require.NotEmpty(t, etag)
+		assert.False(t, strings.HasPrefix(etag, "W/"))
+
+		rec = serve(middleware.ETagConfig{}, http.MethodGet, "/items", `"other", `+etag)
+		assert.Equal(t, http.StatusNotModified, rec.Code)
+		assert.Empty(t, rec.Body.String())
+		assert.Equal(t, etag, rec.Header().Get("ETag"))
+		assert.Empty(t, rec.Header().Get("Content-Type"))
+
+		// weak comparison, e.g. after the compression middleware weakened the validator
+		rec = serve(middleware.ETagConfig{}, http.MethodGet, "/items", "W/"+etag)
+		assert.Equal(t, http.StatusNotModified, rec.Code)
+
+		rec = serve(middleware.ETagConfig{}, http.MethodGet, "/items", `"stale"`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("weak validators", func(t *testing.T) {
+		rec := serve(middleware.ETagConfig{Weak: true}, http.MethodGet, "/items", "")
+		assert.True(t, strings.HasPrefix(rec.Header().Get("ETag"), `W/"`))
+	})
+
+	t.Run("handler ETag is kept", func(t *testing.T) {
+		rec := serve(middleware.ETagConfig{}, http.MethodGet, "/versioned", `"v7"`)
+		assert.Equal(t, http.StatusNotModified, rec.Code)
+	})
+
+	t.Run("skipped responses", func(t *testing.T) {
+		rec := serve(middleware.ETagConfig{}, http.MethodGet, "/missing", "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Empty(t, rec.Header().Get("ETag"))
+
+		rec = serve(middleware.ETagConfig{}, http.MethodPost, "/items", "*")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Empty(t, rec.Header().Get("ETag"))
+
+		rec = serve(middleware.ETagConfig{}, http.MethodGet, "/stream", "")
+		assert.Equal(t, "data: 1\n\ndata: 2\n\n", rec.Body.String())
+		assert.Empty(t, rec.Header().Get("ETag"))
+	})
+}
@@ -0,0 +1,87 @@
+package integration
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
+	"github.com/yourorg/go-api-template/core/logger"
+	"github.com/yourorg/go-api-template/core/transport/httpserver"
+)
+
+type orderLine struct {
+	SKU      string `json:"sku" xml:"sku"`
+	Quantity int    `json:"quantity" xml:"quantity"`
+	Note     string `json:"note,omitempty" xml:"note,omitempty"`
+}
+
+type orderResp struct {
+	Status int `json:"status" xml:"status"`
+	Data   struct {
+		ID       int64       `json:"id" xml:"id"`
+		Customer string      `json:"customer" xml:"customer"`
+		Lines    []orderLine `json:"lines" xml:"lines"`
+	} `json:"data" xml:"data"`
+}
+
+func TestFieldSelection(t *testing.T) {
+	logger.Slog = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	mux := http.NewServeMux()
+	httpserver.NewRouter(mux).Get("/orders/{id}", httpserver.NewTransport(&struct{}{}, httpserver.NewEndpoint(
+		func(ctx context.Context, in *struct{}) (*orderResp, error) {
+			resp := &orderResp{Status: 200}
+			resp.Data.ID = 9007199254740993
+			resp.Data.Customer = "acme"
+			resp.Data.Lines = []orderLine{{SKU: "a-1", Quantity: 2, Note: "gift"}, {SKU: "b-2", Quantity: 1}}
+			return resp, nil
+		})))
+
+	serve := func(target string, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("dot paths", func(t *testing.T) {
+		rec := serve("/orders/1?fields=data.id,data.lines.sku,missing", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"data":{"id":9007199254740993,"lines":[{"sku":"a-1"},{"sku":"b-2"}]}}`, rec.Body.String())
+	})
+
+	t.Run("whole field wins", func(t *testing.T) {
+		rec := serve("/orders/1?fields=status,data.customer,data", "")
+		assert.Contains(t, rec.Body.String(), `"lines"`)
+		assert.Contains(t, rec.Body.String(), `"status":200`)
+	})
+
+	t.Run("no selection", func(t *testing.T) {
+		rec := serve("/orders/1?fields=", "")
+		assert.Contains(t, rec.Body.String(), `"customer":"acme"`)
+	})
+
+	t.Run("msgpack", func(t *testing.T) {
+		rec := serve("/orders/1?fields=data.customer,data.id", httpserver.MediaTypeMsgpack)
+		var got struct {
+			Data map[string]any `msgpack:"data"`
+		}
+		assert.NoError(t, msgpack.Unmarshal(rec.Body.Bytes(), &got))
+		assert.Equal(t, "acme", got.Data["customer"])
+		assert.EqualValues(t, int64(9007199254740993), got.Data["id"])
+		assert.Len(t, got.Data, 2)
+	})
+
+	t.Run("XML is not pruned", func(t *testing.T) {
+		rec := serve("/orders/1?fields=data.customer", httpserver.MediaTypeXML)
+		assert.Contains(t, rec.Body.String(), "<sku>a-1</sku>")
+	})
+}
@@ -0,0 +1,107 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/core/logger"
+	"github.com/yourorg/go-api-template/core/pgdb/pagination"
+	"github.com/yourorg/go-api-template/core/transport/httpserver"
+	"github.com/yourorg/go-api-template/internal/model"
+)
+
+type listWidgetsReq struct {
+	model.ListParams
+	Tags []string `json:"-" query:"tag"`
+}
+
+func TestListParams(t *testing.T) {
+	logger.Slog = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	var got *listWidgetsReq
+	mux := http.NewServeMux()
+	httpserver.NewRouter(mux).Get("/widgets", httpserver.NewTransport(&listWidgetsReq{}, httpserver.NewEndpoint(
+		func(ctx context.Context, req *listWidgetsReq) (*model.PagedResponse[string], error) {
+			if err := req.CheckFields([]string{"name", "created_at"}, []string{"status"}); err != nil {
+				return nil, err
+			}
+			got = req
+			return model.NewPagedResponse([]string{"a", "b"}, req.ListParams, 42), nil
+		})))
+
+	serve := func(target string) (*httptest.ResponseRecorder, map[string]any) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		return rec, body
+	}
+
+	t.Run("binds page, sort and filters", func(t *testing.T) {
+		rec, body := serve("/widgets?page=3&limit=10&sort=-created_at,name&filter[status][in]=active,pending&tag=x,y&tag=z")
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		assert.Equal(t, 3, got.Page)
+		assert.Equal(t, 20, got.Offset())
+		assert.Equal(t, []httpserver.SortField{{Field: "created_at", Desc: true}, {Field: "name"}}, got.Sort)
+		require.Len(t, got.Filters, 1)
+		assert.Equal(t, httpserver.FilterIn, got.Filters[0].Op)
+		assert.Equal(t, []string{"active", "pending"}, got.Filters[0].Values())
+		assert.Equal(t, []string{"x", "y", "z"}, got.Tags)
+		assert.Equal(t, []pagination.Column{{Name: "created_at", Desc: true}, {Name: "name"}, {Name: "id"}}, got.SortColumns("id"))
+
+		assert.EqualValues(t, 42, body["total"])
+		assert.EqualValues(t, 3, body["page"])
+		assert.EqualValues(t, 10, body["limit"])
+		assert.Len(t, body["data"], 2)
+		assert.NotContains(t, body, "next_cursor")
+	})
+
+	t.Run("defaults", func(t *testing.T) {
+		rec, body := serve("/widgets")
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.EqualValues(t, 1, body["page"])
+		assert.EqualValues(t, pagination.DefaultLimit, body["limit"])
+	})
+
+	invalid := []struct {
+		name   string
+		target string
+		field  string
+	}{
+		{"page is not a number", "/widgets?page=two", "page"},
+		{"limit over the maximum", "/widgets?limit=500", "limit"},
+		{"unknown filter operator", "/widgets?filter[status][regex]=a", "filter[status][regex]"},
+		{"page with cursor", "/widgets?page=2&cursor=abc", "cursor"},
+		{"invalid cursor", "/widgets?cursor=not-a-cursor!", "cursor"},
+		{"unsupported sort field", "/widgets?sort=price", "sort"},
+		{"unsupported filter field", "/widgets?filter[owner]=me", "filter[owner]"},
+	}
+	for _, tc := range invalid {
+		t.Run(tc.name, func(t *testing.T) {
+			rec, body := serve(tc.target)
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Contains(t, body["fields"], tc.field)
+		})
+	}
+}
+
+func TestCursorResponse(t *testing.T) {
+	type row struct{ ID int64 }
+	rows := []row{{1}, {2}, {3}}
+
+	page, err := pagination.NewPage(rows, 2, func(r row) []any { return []any{r.ID} })
+	require.NoError(t, err)
+
+	resp := model.NewCursorResponse(page, model.ListParams{Limit: 2})
+	assert.Equal(t, []row{{1}, {2}}, resp.Data)
+	assert.NotEmpty(t, resp.NextCursor)
+	assert.Nil(t, resp.Total)
+}
@@ -0,0 +1,104 @@
+package integration
+
+import (
+	"context"
+	"iter"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/config"
+	core_config "github.com/yourorg/go-api-template/core/config"
+	"github.com/yourorg/go-api-template/core/exception"
+	"github.com/yourorg/go-api-template/core/httpclient/completions"
+	"github.com/yourorg/go-api-template/core/logger"
+	"github.com/yourorg/go-api-template/core/mockdata"
+	"github.com/yourorg/go-api-template/core/transport/httpserver"
+	middleware "github.com/yourorg/go-api-template/core/transport/httpserver/middlewares"
+	"github.com/yourorg/go-api-template/internal/model"
+	"github.com/yourorg/go-api-template/internal/repository"
+	"github.com/yourorg/go-api-template/internal/service"
+)
+
+// slowLLM answers each completion with an insert of one row after delay
+type slowLLM struct {
+	delay time.Duration
+}
+
+func (l slowLLM) Name() string { return "slow" }
+
+func (l slowLLM) Complete(ctx context.Context, req completions.CompletionRequest) (completions.CompletionResponse, error) {
+	select {
+	case <-time.After(l.delay):
+	case <-ctx.Done():
+		return completions.CompletionResponse{}, context.Cause(ctx)
+	}
+	table := strings.TrimSuffix(strings.Fields(req.Messages[len(req.Messages)-1].Content)[6], ":")
+	return completions.CompletionResponse{
+		Choices: []completions.Choice{{Message: completions.Message{Content: "INSERT INTO " + table + " (id) VALUES (1);"}}},
+	}, nil
+}
+
+func (l slowLLM) CompleteStream(ctx context.Context, req completions.CompletionRequest) iter.Seq2[completions.CompletionChunk, error] {
+	return func(yield func(completions.CompletionChunk, error) bool) {}
+}
+
+// tableScripts is a repository.SchemaRepository of CREATE TABLE scripts
+type tableScripts map[string]string
+
+func (r tableScripts) GetTableNames(ctx context.Context) ([]string, error) {
+	return []string{"accounts", "projects", "tasks"}, nil
+}
+
+func (r tableScripts) GetTableScript(ctx context.Context, name string) (string, error) {
+	script, ok := r[name]
+	if !ok {
+		return "", repository.ErrSchemaNotFound
+	}
+	return script, nil
+}
+
+func TestMockDataBatchOutlivesDefaultTimeout(t *testing.T) {
+	logger.Slog = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	configs := map[string]middleware.TimeoutConfig{"defaults": core_config.Defaults().RestServer.Timeout}
+	for _, path := range []string{"../../config/example.config.yaml", "../../config/config.docker.yaml"} {
+		require.NoError(t, config.ResolveConfigFromFile(context.Background(), path))
+		configs[path] = config.GetConfig().RestServer.Timeout
+	}
+
+	schemas := tableScripts{
+		"accounts": `CREATE TABLE accounts (id INT PRIMARY KEY)`,
+		"projects": `CREATE TABLE projects (id INT PRIMARY KEY, account_id INT REFERENCES accounts (id))`,
+		"tasks":    `CREATE TABLE tasks (id INT PRIMARY KEY, project_id INT REFERENCES projects (id))`,
+	}
+	mockData := core_config.Defaults().MockData
+	// each completion takes about half the default budget, the batch of 3 tables outlives it
+	generator := mockdata.NewGenerator(slowLLM{delay: 30 * time.Millisecond}, mockData)
+	svc := service.NewMockDataService(schemas, generator, mockData, exception.NewMockDataServiceErrors())
+
+	for name, timeout := range configs {
+		t.Run(name, func(t *testing.T) {
+			budget, ok := timeout.Routes["/api/v1/mock-data"]
+			require.True(t, ok, "the mock data routes have a timeout of their own")
+			assert.Zero(t, budget)
+
+			timeout.Default = 60 * time.Millisecond
+			handler := middleware.TimeoutMiddleware(timeout)(httpserver.NewStreamTransport(&model.GenerateMockDataBatchRequest{}, svc.GenerateBatch))
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/mock-data/batch", strings.NewReader(`{"tables":["tasks"],"rows":1}`)))
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, 3, strings.Count(rec.Body.String(), "event: "+mockdata.EventTableGenerated), rec.Body.String())
+			assert.Contains(t, rec.Body.String(), "event: "+mockdata.EventBatchCompleted)
+			assert.NotContains(t, rec.Body.String(), "event: error")
+		})
+	}
+}
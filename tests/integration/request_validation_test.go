@@ -0,0 +1,97 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/core/i18n"
+	"github.com/yourorg/go-api-template/core/logger"
+	"github.com/yourorg/go-api-template/core/transport/httpserver"
+)
+
+type validatedReq struct {
+	ID    string `json:"id" path:"id" validate:"required"`
+	Name  string `json:"name" validate:"required,min=3"`
+	Email string `json:"email" validate:"omitempty,email"`
+	Note  string `json:"note" validate:"true"`
+}
+
+func TestTransportRejectsInvalidRequest(t *testing.T) {
+	logger.Slog = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	var called bool
+	svc := func(ctx context.Context, req *validatedReq) (*validatedReq, error) {
+		called = true
+		return req, nil
+	}
+	mux := http.NewServeMux()
+	httpserver.NewRouter(mux).Post("/api/v1/items/{id}", httpserver.NewTransport(&validatedReq{}, httpserver.NewEndpoint(svc)))
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/items/7", strings.NewReader(`{"name":"ab","email":"nope"}`)))
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.False(t, called)
+
+	var body struct {
+		Fields []string          `json:"fields"`
+		Data   map[string]string `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	assert.ElementsMatch(t, []string{"name", "email", "note"}, body.Fields)
+	assert.Equal(t, "must be at least 3 characters", body.Data["name"])
+	assert.Equal(t, "is required", body.Data["note"])
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/items/7", strings.NewReader(`{"name":"abc","note":"x"}`)))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp validatedReq
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	assert.Equal(t, "7", resp.ID, "bound from the path")
+}
+
+func TestTransportLocalizesValidationErrors(t *testing.T) {
+	logger.Slog = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	bundle, err := i18n.New(i18n.DefaultConfig())
+	require.NoError(t, err)
+	svc := func(ctx context.Context, req *validatedReq) (*validatedReq, error) {
+		return req, nil
+	}
+	mux := http.NewServeMux()
+	httpserver.NewRouter(mux).Post("/api/v1/items/{id}", httpserver.NewTransport(&validatedReq{}, httpserver.NewEndpoint(svc)))
+	handler := i18n.Middleware(bundle)(mux)
+
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/items/7", strings.NewReader(`{"name":"ab"}`))
+	r.Header.Set("Accept-Language", "th-TH, en;q=0.8")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, r)
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "th", rec.Header().Get("Content-Language"))
+	var body struct {
+		Message string            `json:"message"`
+		Data    map[string]string `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	assert.Equal(t, "ต้องมีความยาวอย่างน้อย 3 ตัวอักษร", body.Data["name"])
+	assert.Equal(t, "จำเป็นต้องระบุ", body.Data["note"])
+
+	r = httptest.NewRequest(http.MethodPost, "/api/v1/items/7", strings.NewReader(`{"name":"ab"}`))
+	r.Header.Set("Accept-Language", "de")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, r)
+
+	assert.Equal(t, "en", rec.Header().Get("Content-Language"))
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	assert.Equal(t, "must be at least 3 characters", body.Data["name"])
+}
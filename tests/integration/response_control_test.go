@@ -0,0 +1,90 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/core/logger"
+	"github.com/yourorg/go-api-template/core/transport/httpserver"
+	"github.com/yourorg/go-api-template/internal/model"
+)
+
+type jobReq struct {
+	Name string `json:"name"`
+}
+
+type jobResp struct {
+	ID string `json:"id"`
+}
+
+func responseControlMux() *http.ServeMux {
+	logger.Slog = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	mux := http.NewServeMux()
+	r := httpserver.NewRouter(mux)
+	r.Post("/examples", httpserver.NewTransport(&model.CreateExampleRequest{}, httpserver.NewEndpoint(
+		func(ctx context.Context, req *model.CreateExampleRequest) (*model.CreateExampleResponse, error) {
+			return &model.CreateExampleResponse{Status: 201, Data: model.CreateExampleResponse_Data{ID: "42", Name: req.Name}}, nil
+		})))
+	r.Post("/jobs", httpserver.NewTransport(&jobReq{}, httpserver.NewEndpoint(
+		func(ctx context.Context, req *jobReq) (*jobResp, error) {
+			httpserver.SetStatus(ctx, http.StatusAccepted)
+			httpserver.SetHeader(ctx, "Location", "/jobs/7")
+			return &jobResp{ID: "7"}, nil
+		})))
+	r.Delete("/jobs/{id}", httpserver.NewTransport(&jobReq{}, httpserver.NewEndpoint(
+		func(ctx context.Context, req *jobReq) (*jobResp, error) {
+			httpserver.SetStatus(ctx, http.StatusNoContent)
+			return &jobResp{}, nil
+		})))
+	return mux
+}
+
+func TestTransportResponseStatusAndHeaders(t *testing.T) {
+	mux := responseControlMux()
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/examples", strings.NewReader(`{"name":"first"}`)))
+	assert.Equal(t, http.StatusCreated, rec.Code, "from StatusCoder")
+	assert.Equal(t, "/api/v1/examples/42", rec.Header().Get("Location"), "from Headerer")
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/jobs", strings.NewReader(`{"name":"export"}`)))
+	assert.Equal(t, http.StatusAccepted, rec.Code, "from SetStatus")
+	assert.Equal(t, "/jobs/7", rec.Header().Get("Location"))
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/jobs/7", nil))
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Empty(t, rec.Body.String())
+	assert.Empty(t, rec.Header().Get("Content-Type"))
+}
+
+func TestTransportSuccessEnvelope(t *testing.T) {
+	mux := responseControlMux()
+	httpserver.SetEnvelope(httpserver.DataEnvelope)
+	t.Cleanup(func() { httpserver.SetEnvelope(nil) })
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/jobs", strings.NewReader(`{"name":"export"}`)))
+	require.Equal(t, http.StatusAccepted, rec.Code)
+
+	var body struct {
+		Status int     `json:"status"`
+		Data   jobResp `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	assert.Equal(t, http.StatusAccepted, body.Status)
+	assert.Equal(t, "7", body.Data.ID)
+
+	_, ok := httpserver.EnvelopeByName("wrapped")
+	assert.False(t, ok)
+}
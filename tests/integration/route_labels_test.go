@@ -0,0 +1,84 @@
+package integration
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/core/logger"
+	"github.com/yourorg/go-api-template/core/transport/httpserver"
+	middleware "github.com/yourorg/go-api-template/core/transport/httpserver/middlewares"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestRouteLabels(t *testing.T) {
+	logger.Slog = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	defer tp.Shutdown(context.Background())
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	var routes []string
+	mux := http.NewServeMux()
+	router := httpserver.NewRouter(mux)
+	router.SetNotFoundHandler(httpserver.NotFoundHandler())
+	router.Get("/api/v1/examples/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route, _ := middleware.GetRouteFromContext(r.Context())
+		routes = append(routes, route)
+	}))
+
+	// the server span of internal/server, named before the route is known
+	handler := otelhttp.NewHandler(router, "",
+		otelhttp.WithTracerProvider(tp),
+		otelhttp.WithMeterProvider(mp),
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string { return r.Method }),
+	)
+	for _, path := range []string{"/api/v1/examples/1", "/api/v1/examples/2", "/missing"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	assert.Equal(t, []string{"/api/v1/examples/{id}", "/api/v1/examples/{id}"}, routes)
+
+	var names []string
+	for _, span := range recorder.Ended() {
+		if span.Parent().IsValid() {
+			continue
+		}
+		names = append(names, span.Name())
+		for _, attr := range span.Attributes() {
+			if attr.Key == "http.route" {
+				assert.Equal(t, "/api/v1/examples/{id}", attr.Value.AsString())
+			}
+		}
+	}
+	assert.Equal(t, []string{"GET /api/v1/examples/{id}", "GET /api/v1/examples/{id}", "GET"}, names)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	var routeLabels []string
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if !strings.HasPrefix(m.Name, "http.server.") || !strings.Contains(m.Name, "duration") {
+				continue
+			}
+			for _, dp := range m.Data.(metricdata.Histogram[float64]).DataPoints {
+				route, _ := dp.Attributes.Value(attribute.Key("http.route"))
+				routeLabels = append(routeLabels, route.AsString())
+			}
+		}
+	}
+	assert.ElementsMatch(t, []string{"/api/v1/examples/{id}", ""}, routeLabels, "requests of a route share one label")
+}
@@ -0,0 +1,142 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/core/exception"
+	"github.com/yourorg/go-api-template/core/logger"
+	"github.com/yourorg/go-api-template/core/transport/httpserver"
+)
+
+func routerMethodsMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	r := httpserver.NewRouter(mux)
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Method))
+	})
+	r.Get("/items/{id}", ok)
+	r.Patch("/items/{itemID}", ok)
+	r.Delete("/items/{id}", ok)
+	r.Post("/reports", ok)
+	r.Options("/reports", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Custom", "yes")
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// the catch-all of internal/server, which would otherwise answer 404 for other methods
+	mux.Handle("/", http.NotFoundHandler())
+	return mux
+}
+
+func TestRouterMethods(t *testing.T) {
+	mux := routerMethodsMux()
+	serve := func(method, target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+		return rec
+	}
+
+	t.Run("registered methods", func(t *testing.T) {
+		rec := serve(http.MethodPatch, "/items/1")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, http.MethodPatch, rec.Body.String())
+
+		rec = serve(http.MethodHead, "/items/1")
+		assert.Equal(t, http.StatusOK, rec.Code, "HEAD is served by GET")
+	})
+
+	t.Run("automatic OPTIONS", func(t *testing.T) {
+		rec := serve(http.MethodOptions, "/items/1")
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Equal(t, "DELETE, GET, HEAD, OPTIONS, PATCH", rec.Header().Get("Allow"))
+	})
+
+	t.Run("explicit OPTIONS", func(t *testing.T) {
+		rec := serve(http.MethodOptions, "/reports")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "yes", rec.Header().Get("X-Custom"))
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		rec := serve(http.MethodPut, "/items/1")
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+		assert.Equal(t, "DELETE, GET, HEAD, OPTIONS, PATCH", rec.Header().Get("Allow"))
+
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, "Method not allowed", body["message"])
+
+		rec = serve(http.MethodGet, "/reports")
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+		assert.Equal(t, "OPTIONS, POST", rec.Header().Get("Allow"))
+	})
+
+	t.Run("unknown path", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/missing").Code)
+	})
+}
+
+func TestRouterCustomErrorHandlers(t *testing.T) {
+	logger.Slog = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	mux := http.NewServeMux()
+	r := httpserver.NewRouter(mux)
+	r.Get("/items/{id}", httpserver.NewTransport(&struct{}{}, httpserver.NewEndpoint(
+		func(ctx context.Context, in *struct{}) (*struct{}, error) {
+			return nil, exception.NewExceptionError(http.StatusBadRequest, 200002, "Not found", http.StatusNotFound)
+		})))
+	r.SetErrorHandler(func(w http.ResponseWriter, r *http.Request, exErr *exception.ExceptionError) {
+		w.Header().Set("X-Error-Code", strconv.Itoa(int(exErr.Code)))
+		httpserver.DefaultErrorHandler(w, r, exErr)
+	})
+
+	serve := func(method, target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+		return rec
+	}
+
+	t.Run("transport errors", func(t *testing.T) {
+		rec := serve(http.MethodGet, "/items/1")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, "200002", rec.Header().Get("X-Error-Code"))
+	})
+
+	t.Run("default not found", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, serve(http.MethodGet, "/missing").Code, "no catch-all yet")
+
+		r.SetNotFoundHandler(nil)
+		rec := serve(http.MethodGet, "/missing")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		assert.Equal(t, "200002", rec.Header().Get("X-Error-Code"))
+
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, "Not found", body["message"])
+	})
+
+	t.Run("custom handlers", func(t *testing.T) {
+		r.SetNotFoundHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+		}))
+		assert.Equal(t, http.StatusTeapot, serve(http.MethodGet, "/missing").Code)
+
+		r.SetMethodNotAllowedHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Allow", w.Header().Get("Allow"))
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}))
+		rec := serve(http.MethodDelete, "/items/1")
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+		assert.Equal(t, "GET, HEAD, OPTIONS", rec.Header().Get("X-Allow"))
+	})
+}
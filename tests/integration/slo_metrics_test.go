@@ -0,0 +1,68 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/core/health"
+	"github.com/yourorg/go-api-template/core/slo"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+type staticChecker health.ComponentHealth
+
+func (c staticChecker) Check(context.Context) health.ComponentHealth {
+	return health.ComponentHealth(c)
+}
+
+func TestSLOAndHealthMetrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+
+	tracker := slo.NewTracker(slo.Config{
+		Windows:    []time.Duration{time.Hour},
+		Objectives: []slo.Objective{{Name: "api", PathPrefix: "/api", Availability: 0.9, Latency: 0.5, LatencyThreshold: time.Second}},
+	})
+	tracker.ExportMetrics()
+	tracker.Record("/api/a", http.StatusBadGateway, 2*time.Second)
+	tracker.Record("/api/b", http.StatusOK, time.Millisecond)
+
+	hs := health.NewHealthService(health.BuildInfo{})
+	hs.RegisterChecker("llm", staticChecker{Status: health.StatusDegraded, Duration: 250 * time.Millisecond})
+	hs.ExportMetrics()
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	gauges := map[string]metricdata.Gauge[float64]{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if gauge, ok := m.Data.(metricdata.Gauge[float64]); ok {
+				gauges[m.Name] = gauge
+			}
+		}
+	}
+
+	burnRates := map[string]float64{}
+	for _, dp := range gauges["slo.burn_rate"].DataPoints {
+		sli, _ := dp.Attributes.Value(attribute.Key("slo.sli"))
+		window, _ := dp.Attributes.Value(attribute.Key("slo.window"))
+		assert.Equal(t, "1h0m0s", window.AsString())
+		burnRates[sli.AsString()] = dp.Value
+	}
+	assert.InDelta(t, 5.0, burnRates[slo.SLIAvailability], 1e-9, "half the requests failed against a 10% budget")
+	assert.InDelta(t, 1.0, burnRates[slo.SLILatency], 1e-9)
+
+	require.Len(t, gauges["health.component.status"].DataPoints, 1)
+	status := gauges["health.component.status"].DataPoints[0]
+	component, _ := status.Attributes.Value(attribute.Key("health.component"))
+	assert.Equal(t, "llm", component.AsString())
+	assert.Equal(t, 0.5, status.Value)
+	assert.Equal(t, 0.25, gauges["health.component.check.duration"].DataPoints[0].Value)
+}
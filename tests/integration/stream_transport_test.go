@@ -0,0 +1,117 @@
+package integration
+
+import (
+	"bufio"
+	"context"
+	"iter"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/core/exception"
+	"github.com/yourorg/go-api-template/core/logger"
+	"github.com/yourorg/go-api-template/core/transport/httpserver"
+)
+
+type progressReq struct {
+	Steps int `json:"steps" validate:"required,min=1"`
+}
+
+type progressEvent struct {
+	Step int `json:"step"`
+}
+
+func (progressEvent) EventName() string { return "progress" }
+
+func TestStreamTransportSendsEvents(t *testing.T) {
+	logger.Slog = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	handler := httpserver.NewStreamTransport(&progressReq{}, func(ctx context.Context, req *progressReq) iter.Seq2[progressEvent, error] {
+		return func(yield func(progressEvent, error) bool) {
+			for i := 1; i <= req.Steps; i++ {
+				if !yield(progressEvent{Step: i}, nil) {
+					return
+				}
+			}
+		}
+	}, httpserver.WithDoneEvent("[DONE]"))
+
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodPost, "/api/v1/progress", strings.NewReader(`{"steps":2}`)))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/event-stream", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "event: progress\ndata: {\"step\":1}\n\n"+
+		"event: progress\ndata: {\"step\":2}\n\n"+
+		"event: done\ndata: [DONE]\n\n", rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodPost, "/api/v1/progress", strings.NewReader(`{}`)))
+	assert.Equal(t, http.StatusBadRequest, rec.Code, "requests are validated before streaming")
+}
+
+func TestStreamTransportReportsErrors(t *testing.T) {
+	logger.Slog = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	appErrors := exception.NewMockDataServiceErrors()
+
+	failAfter := func(n int) httpserver.StreamEndpoint[*progressReq, progressEvent] {
+		return func(ctx context.Context, req *progressReq) iter.Seq2[progressEvent, error] {
+			return func(yield func(progressEvent, error) bool) {
+				for i := 1; i <= n; i++ {
+					if !yield(progressEvent{Step: i}, nil) {
+						return
+					}
+				}
+				yield(progressEvent{}, appErrors.ErrTooManyRequests)
+			}
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	httpserver.NewStreamTransport(&progressReq{}, failAfter(0))(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"steps":1}`)))
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+
+	rec = httptest.NewRecorder()
+	httpserver.NewStreamTransport(&progressReq{}, failAfter(1))(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"steps":1}`)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "event: error\ndata: {\"status\":400")
+}
+
+func TestStreamTransportHeartbeatAndDisconnect(t *testing.T) {
+	logger.Slog = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	stopped := make(chan struct{})
+	handler := httpserver.NewStreamTransport(&progressReq{}, func(ctx context.Context, req *progressReq) iter.Seq2[string, error] {
+		return func(yield func(string, error) bool) {
+			defer close(stopped)
+			<-ctx.Done()
+		}
+	}, httpserver.WithHeartbeat(10*time.Millisecond))
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, server.URL, strings.NewReader(`{"steps":1}`))
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, ": ping\n", line)
+
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("endpoint was not canceled after the client disconnected")
+	}
+}
@@ -0,0 +1,57 @@
+package integration
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+	"github.com/yourorg/go-api-template/core/transport/httpserver"
+)
+
+func TestStrictDecoding(t *testing.T) {
+	mux := negotiationMux(t)
+	httpserver.SetDecodeOptions(httpserver.DecodeOptions{DisallowUnknownFields: true, RequireContentType: true})
+	defer httpserver.SetDecodeOptions(httpserver.DecodeOptions{})
+
+	t.Run("known fields", func(t *testing.T) {
+		rec := serveNegotiated(mux, "/notes", "application/json", "", `{"title":"hello world"}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("unknown JSON field", func(t *testing.T) {
+		rec := serveNegotiated(mux, "/notes", "application/json", "", `{"title":"hello","titel":"typo"}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+		var body struct {
+			Message string            `json:"message"`
+			Fields  []string          `json:"fields"`
+			Data    map[string]string `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, "Request has unknown fields", body.Message)
+		assert.Equal(t, []string{"titel"}, body.Fields)
+		assert.Equal(t, "unknown field", body.Data["titel"])
+	})
+
+	t.Run("unknown msgpack field", func(t *testing.T) {
+		payload, err := msgpack.Marshal(map[string]string{"title": "hello", "extra": "x"})
+		require.NoError(t, err)
+		rec := serveNegotiated(mux, "/notes", "application/msgpack", "", string(payload))
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"extra"`)
+	})
+
+	t.Run("missing content type", func(t *testing.T) {
+		rec := serveNegotiated(mux, "/notes", "", "", `{"title":"hello"}`)
+		assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+	})
+
+	t.Run("lenient by default", func(t *testing.T) {
+		httpserver.SetDecodeOptions(httpserver.DecodeOptions{})
+		rec := serveNegotiated(mux, "/notes", "", "", `{"title":"hello","titel":"typo"}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+}
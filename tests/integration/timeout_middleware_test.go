@@ -0,0 +1,77 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/core/transport/httpserver"
+	middleware "github.com/yourorg/go-api-template/core/transport/httpserver/middlewares"
+)
+
+func TestTimeoutMiddlewareReplacesSlowResponse(t *testing.T) {
+	causes := make(chan error, 1)
+	handler := middleware.TimeoutMiddleware(middleware.TimeoutConfig{
+		Default:    20 * time.Millisecond,
+		WriteError: httpserver.WriteError,
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		causes <- context.Cause(r.Context())
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/examples/1", nil))
+
+	assert.Equal(t, http.StatusGatewayTimeout, rec.Code)
+	var body map[string]any
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	assert.Equal(t, "20ms", body["data"].(map[string]any)["timeout"])
+	assert.True(t, errors.Is(<-causes, middleware.ErrRequestTimeout), "handlers see why their context ended")
+}
+
+func TestTimeoutMiddlewarePassesFastResponse(t *testing.T) {
+	handler := middleware.TimeoutMiddleware(middleware.TimeoutConfig{
+		Default: time.Second,
+		Routes:  map[string]time.Duration{"/api/v1/reports": 0},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Deadline(); ok {
+			w.Header().Set("X-Deadline", "yes")
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("ok"))
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/examples", nil))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "ok", rec.Body.String())
+	assert.Equal(t, "yes", rec.Header().Get("X-Deadline"))
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/reports/monthly", nil))
+	assert.Empty(t, rec.Header().Get("X-Deadline"), "a 0 route override disables the timeout")
+}
+
+func TestTimeoutMiddlewareKeepsFlushedStream(t *testing.T) {
+	handler := middleware.TimeoutMiddleware(middleware.TimeoutConfig{
+		Default: 20 * time.Millisecond,
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Write([]byte("data: 1\n\n"))
+		http.NewResponseController(w).Flush()
+		<-r.Context().Done()
+		w.Write([]byte("data: 2\n\n"))
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stream", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "data: 1\n\ndata: 2\n\n", rec.Body.String())
+}
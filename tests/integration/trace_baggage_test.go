@@ -0,0 +1,123 @@
+package integration
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/core/logger"
+	middleware "github.com/yourorg/go-api-template/core/transport/httpserver/middlewares"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTraceBaggage(t *testing.T) {
+	logger.Slog = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(middleware.BaggageSpanProcessor{}),
+		sdktrace.WithSpanProcessor(recorder),
+	)
+	defer tp.Shutdown(context.Background())
+	propagator := propagation.Baggage{}
+
+	var outbound http.Header
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		outbound = r.Header.Clone()
+	}))
+	defer downstream.Close()
+
+	const secret = "trace-baggage-secret"
+	var fields map[string]interface{}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fields = logger.GetFieldsFromContext(r.Context())
+		ctx, span := tp.Tracer("test").Start(r.Context(), "SELECT examples")
+		defer span.End()
+
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, downstream.URL, nil)
+		propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+		resp, err := http.DefaultClient.Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+	})
+	stack := middleware.CreateStack(
+		middleware.RequestIDMiddleware(middleware.DefaultRequestIDConfig()),
+		middleware.TraceBaggageMiddleware(middleware.TraceBaggageConfig{Enabled: true}),
+		middleware.AuthMiddleware(middleware.AuthConfig{JWTSecretKey: secret, SkipPaths: []string{"/public"}}),
+	)(handler)
+
+	serve := func(path string, header http.Header) sdktrace.ReadOnlySpan {
+		ctx, span := tp.Tracer("test").Start(context.Background(), "inbound")
+		req := httptest.NewRequest(http.MethodGet, path, nil).WithContext(ctx)
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		// the client's baggage, as extracted by otelhttp
+		req = req.WithContext(propagator.Extract(req.Context(), propagation.HeaderCarrier(req.Header)))
+		stack.ServeHTTP(httptest.NewRecorder(), req)
+		span.End()
+
+		spans := recorder.Ended()
+		require.GreaterOrEqual(t, len(spans), 2)
+		return spans[len(spans)-1]
+	}
+	attrs := func(span sdktrace.ReadOnlySpan) map[attribute.Key]string {
+		m := map[attribute.Key]string{}
+		for _, kv := range span.Attributes() {
+			m[kv.Key] = kv.Value.AsString()
+		}
+		return m
+	}
+
+	t.Run("authenticated", func(t *testing.T) {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &middleware.UserClaims{UserID: "user-7", TenantID: "acme"}).
+			SignedString([]byte(secret))
+		require.NoError(t, err)
+
+		inbound := serve("/orders", http.Header{
+			"Authorization": {"Bearer " + token},
+			"X-Request-Id":  {"req-1"},
+			"Baggage":       {"user_id=spoofed,region=eu"},
+		})
+		assert.Equal(t, map[attribute.Key]string{"request_id": "req-1", "user_id": "user-7", "tenant_id": "acme"}, attrs(inbound))
+
+		child := recorder.Ended()[len(recorder.Ended())-2]
+		assert.Equal(t, "SELECT examples", child.Name())
+		assert.Equal(t, "user-7", attrs(child)["user_id"])
+		assert.Equal(t, "acme", attrs(child)["tenant_id"])
+
+		bag, err := baggage.Parse(outbound.Get("Baggage"))
+		require.NoError(t, err)
+		assert.Equal(t, "user-7", bag.Member("user_id").Value())
+		assert.Equal(t, "req-1", bag.Member("request_id").Value())
+		assert.Equal(t, "eu", bag.Member("region").Value(), "other members are forwarded")
+
+		assert.Equal(t, "user-7", fields["user_id"])
+		assert.Equal(t, "acme", fields["tenant_id"])
+	})
+
+	t.Run("anonymous drops client identity", func(t *testing.T) {
+		inbound := serve("/public", http.Header{
+			"X-Tenant-Id": {"globex"},
+			"Baggage":     {"user_id=spoofed"},
+		})
+		assert.Equal(t, "globex", attrs(inbound)["tenant_id"])
+		assert.NotContains(t, attrs(inbound), attribute.Key("user_id"))
+
+		bag, err := baggage.Parse(outbound.Get("Baggage"))
+		require.NoError(t, err)
+		assert.Empty(t, bag.Member("user_id").Value())
+		assert.Equal(t, "globex", bag.Member("tenant_id").Value())
+	})
+}
@@ -0,0 +1,177 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/core/logger"
+	"github.com/yourorg/go-api-template/core/storage"
+	"github.com/yourorg/go-api-template/core/transport/httpserver"
+)
+
+type uploadReq struct {
+	Folder string `json:"-" path:"folder" validate:"required,alphanum"`
+}
+
+type uploadResp struct {
+	Folder string                  `json:"folder"`
+	Title  string                  `json:"title"`
+	Files  []httpserver.StoredFile `json:"files"`
+}
+
+func (uploadResp) StatusCode() int { return http.StatusCreated }
+
+// multipartBody builds a form with a title field followed by the files, by name
+func multipartBody(t *testing.T, files map[string]string) (*bytes.Buffer, string) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	require.NoError(t, writer.WriteField("title", "holiday"))
+	for name, content := range files {
+		part, err := writer.CreateFormFile("file", name)
+		require.NoError(t, err)
+		_, _ = io.WriteString(part, content)
+	}
+	require.NoError(t, writer.Close())
+	return &body, writer.FormDataContentType()
+}
+
+func newUploadHandler(t *testing.T, opts ...httpserver.UploadOption) (http.Handler, storage.Storage) {
+	logger.Slog = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	store, err := storage.NewLocalStorage(storage.LocalConfig{Root: t.TempDir()})
+	require.NoError(t, err)
+
+	mux := http.NewServeMux()
+	mux.Handle("POST /folders/{folder}/files", httpserver.NewUploadTransport(&uploadReq{},
+		func(ctx context.Context, req *uploadReq, parts *httpserver.UploadParts) (uploadResp, error) {
+			files, err := parts.SaveTo(ctx, store, req.Folder, 2)
+			if err != nil {
+				return uploadResp{}, err
+			}
+			return uploadResp{Folder: req.Folder, Title: parts.Values.Get("title"), Files: files}, nil
+		}, opts...))
+	return mux, store
+}
+
+func TestUploadTransportStoresFiles(t *testing.T) {
+	handler, store := newUploadHandler(t)
+
+	body, contentType := multipartBody(t, map[string]string{"notes.txt": "hello"})
+	req := httptest.NewRequest(http.MethodPost, "/folders/trips/files", body)
+	req.Header.Set("Content-Type", contentType)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+
+	var resp uploadResp
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "trips", resp.Folder)
+	assert.Equal(t, "holiday", resp.Title, "fields before the files are in Values")
+	require.Len(t, resp.Files, 1)
+	file := resp.Files[0]
+	assert.Equal(t, "notes.txt", file.Filename)
+	assert.Equal(t, "file", file.Field)
+	assert.True(t, strings.HasPrefix(file.Key, "trips/") && strings.HasSuffix(file.Key, ".txt"), file.Key)
+	assert.Equal(t, int64(5), file.Size)
+
+	stored, _, err := store.Get(context.Background(), file.Key)
+	require.NoError(t, err)
+	content, _ := io.ReadAll(stored)
+	stored.Close()
+	assert.Equal(t, "hello", string(content))
+}
+
+func TestUploadTransportRejectsRequests(t *testing.T) {
+	handler, _ := newUploadHandler(t, httpserver.WithMaxUploadSize(1024))
+
+	tests := []struct {
+		name   string
+		path   string
+		files  map[string]string
+		json   bool
+		status int
+	}{
+		{name: "not multipart", path: "/folders/trips/files", json: true, status: http.StatusUnsupportedMediaType},
+		{name: "invalid path value", path: "/folders/a-b/files", files: map[string]string{"a.txt": "a"}, status: http.StatusBadRequest},
+		{name: "too many files", path: "/folders/trips/files", files: map[string]string{"a.txt": "a", "b.txt": "b", "c.txt": "c"}, status: http.StatusBadRequest},
+		{name: "too large", path: "/folders/trips/files", files: map[string]string{"big.txt": strings.Repeat("x", 2048)}, status: http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req *http.Request
+			if tt.json {
+				req = httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(`{}`))
+				req.Header.Set("Content-Type", "application/json")
+			} else {
+				body, contentType := multipartBody(t, tt.files)
+				req = httptest.NewRequest(http.MethodPost, tt.path, body)
+				req.Header.Set("Content-Type", contentType)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			assert.Equal(t, tt.status, rec.Code, rec.Body.String())
+		})
+	}
+}
+
+// TestUploadTransportLimitsStreamedBodies checks a body of unknown length is cut at the maximum
+// size while the endpoint reads it
+func TestUploadTransportLimitsStreamedBodies(t *testing.T) {
+	handler, _ := newUploadHandler(t, httpserver.WithMaxUploadSize(1024))
+
+	body, contentType := multipartBody(t, map[string]string{"big.txt": strings.Repeat("x", 4096)})
+	req := httptest.NewRequest(http.MethodPost, "/folders/trips/files", io.NopCloser(body))
+	req.ContentLength = -1
+	req.Header.Set("Content-Type", contentType)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code, rec.Body.String())
+}
+
+func TestUploadTransportLimitsFiles(t *testing.T) {
+	handler, store := newUploadHandler(t, httpserver.WithMaxFileSize(16), httpserver.WithAllowedTypes("image/*", "text/plain"))
+	png := "\x89PNG\r\n\x1a\n" + strings.Repeat("\x00", 8)
+
+	tests := []struct {
+		name        string
+		files       map[string]string
+		status      int
+		contentType string
+	}{
+		{name: "allowed type", files: map[string]string{"a.txt": "hello"}, status: http.StatusCreated, contentType: "text/plain; charset=utf-8"},
+		{name: "type sniffed from the content", files: map[string]string{"a.txt": png}, status: http.StatusCreated, contentType: "image/png"},
+		{name: "type not allowed", files: map[string]string{"a.pdf": "%PDF-1.7\n"}, status: http.StatusUnsupportedMediaType},
+		{name: "file too large", files: map[string]string{"a.txt": strings.Repeat("x", 17)}, status: http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, contentType := multipartBody(t, tt.files)
+			req := httptest.NewRequest(http.MethodPost, "/folders/trips/files", body)
+			req.Header.Set("Content-Type", contentType)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			require.Equal(t, tt.status, rec.Code, rec.Body.String())
+			if tt.status != http.StatusCreated {
+				return
+			}
+
+			var resp uploadResp
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			require.Len(t, resp.Files, 1)
+			assert.Equal(t, tt.contentType, resp.Files[0].ContentType)
+			object, err := store.Stat(context.Background(), resp.Files[0].Key)
+			require.NoError(t, err)
+			assert.Equal(t, resp.Files[0].Size, object.Size)
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/core/transport/httpserver"
+	middleware "github.com/yourorg/go-api-template/core/transport/httpserver/middlewares"
+)
+
+type chatIn struct {
+	Text string `json:"text"`
+}
+
+type chatOut struct {
+	Echo string `json:"echo"`
+}
+
+func echoWebSocket(ctx context.Context, conn *httpserver.WebSocketConn[chatIn, chatOut]) error {
+	for {
+		msg, err := conn.Receive()
+		if err != nil {
+			return err
+		}
+		if msg.Text == "fail" {
+			return errors.New("boom")
+		}
+		if err := conn.Send(chatOut{Echo: msg.Text}); err != nil {
+			return err
+		}
+	}
+}
+
+func dialWebSocket(t *testing.T, server *httptest.Server) *websocket.Conn {
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+	ws, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { ws.Close() })
+	return ws
+}
+
+func TestWebSocketTransportExchangesJSON(t *testing.T) {
+	hub := httpserver.NewWebSocketHub()
+	server := httptest.NewServer(httpserver.NewWebSocketTransport(echoWebSocket, httpserver.WithWebSocketHub(hub)))
+	defer server.Close()
+
+	ws := dialWebSocket(t, server)
+	require.NoError(t, ws.WriteJSON(chatIn{Text: "hello"}))
+
+	var out chatOut
+	require.NoError(t, ws.ReadJSON(&out))
+	assert.Equal(t, "hello", out.Echo)
+	assert.Equal(t, 1, hub.Len())
+
+	// a handler error closes the connection with an internal error frame
+	require.NoError(t, ws.WriteJSON(chatIn{Text: "fail"}))
+	_, _, err := ws.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseInternalServerErr), "got %v", err)
+}
+
+func TestWebSocketTransportRejectsPlainRequests(t *testing.T) {
+	server := httptest.NewServer(httpserver.NewWebSocketTransport(echoWebSocket, httpserver.WithWebSocketHub(httpserver.NewWebSocketHub())))
+	defer server.Close()
+
+	resp, err := server.Client().Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, 400, resp.StatusCode)
+}
+
+func TestWebSocketHubShutdownClosesConnections(t *testing.T) {
+	hub := httpserver.NewWebSocketHub()
+	server := httptest.NewServer(httpserver.NewWebSocketTransport(echoWebSocket, httpserver.WithWebSocketHub(hub)))
+	defer server.Close()
+
+	ws := dialWebSocket(t, server)
+	require.NoError(t, ws.WriteJSON(chatIn{Text: "ping"}))
+	var out chatOut
+	require.NoError(t, ws.ReadJSON(&out))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, hub.Shutdown(ctx))
+	assert.Equal(t, 0, hub.Len())
+
+	_, _, err := ws.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway), "got %v", err)
+
+	// new connections are refused once the hub is shutting down
+	late := dialWebSocket(t, server)
+	_, _, err = late.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway), "got %v", err)
+}
+
+func TestWebSocketTransportThroughMiddlewareStack(t *testing.T) {
+	// the middlewares of the REST server wrapping the response writer, the timeout included
+	stack := middleware.CreateStack(
+		middleware.RecoveryMiddleware(middleware.RecoveryConfig{WriteError: httpserver.WriteError}),
+		middleware.CompressionMiddleware(middleware.CompressionConfig{Enabled: true}),
+		middleware.TimeoutMiddleware(middleware.TimeoutConfig{Default: 50 * time.Millisecond, WriteError: httpserver.WriteError}),
+	)
+	mux := http.NewServeMux()
+	httpserver.NewRouter(mux).WebSocket("/ws", httpserver.NewWebSocketTransport(echoWebSocket, httpserver.WithWebSocketHub(httpserver.NewWebSocketHub())))
+	server := httptest.NewServer(stack(mux))
+	defer server.Close()
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+	ws, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Accept-Encoding": {"gzip"}})
+	require.NoError(t, err)
+	defer ws.Close()
+
+	// the request budget does not end an upgraded connection
+	time.Sleep(100 * time.Millisecond)
+	require.NoError(t, ws.WriteJSON(chatIn{Text: "still here"}))
+	var out chatOut
+	require.NoError(t, ws.ReadJSON(&out))
+	assert.Equal(t, "still here", out.Echo)
+
+	// plain requests keep the budget
+	slow := httpserver.NewRouter(mux)
+	slow.Get("/slow", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	resp, err := server.Client().Get(server.URL + "/slow")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusGatewayTimeout, resp.StatusCode)
+}
@@ -0,0 +1,96 @@
+package unit
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/core/audit"
+)
+
+func TestAuditMiddleware(t *testing.T) {
+	store := audit.NewMemoryStore()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	recorder := audit.NewRecorder(store, audit.WithClock(func() time.Time { return now }))
+
+	examples := map[string]string{"7": "old"}
+	mux := http.NewServeMux()
+	mux.Handle("POST /examples", recorder.Middleware(audit.Hint{Entity: "example", IDField: "data.id"})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			if !strings.Contains(string(body), "name") {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+			io.WriteString(w, `{"status":201,"data":{"id":"8"}}`)
+		}),
+	))
+	update := audit.Hint{Entity: "example", IDParam: "id", Load: func(ctx context.Context, id string) (any, error) {
+		return map[string]string{"name": examples[id]}, nil
+	}}
+	mux.Handle("/examples/{id}", recorder.Middleware(update)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPut {
+				examples[r.PathValue("id")] = "new"
+			}
+		}),
+	))
+	serve := func(method string, path string, body string) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+	}
+
+	serve(http.MethodPost, "/examples", `{"name":"a"}`)
+	serve(http.MethodPost, "/examples", `{}`)
+	serve(http.MethodPut, "/examples/7", `{"name":"new"}`)
+	serve(http.MethodGet, "/examples/7", "")
+
+	created, err := store.List(context.Background(), "example", "8", 10)
+	require.NoError(t, err)
+	require.Len(t, created, 1)
+	assert.Equal(t, audit.Record{
+		ID:       1,
+		Time:     now,
+		Actor:    audit.AnonymousActor,
+		Method:   http.MethodPost,
+		Route:    "POST /examples",
+		Entity:   "example",
+		EntityID: "8",
+		NewHash:  audit.HashBytes([]byte(`{"name":"a"}`)),
+		Status:   http.StatusCreated,
+		Outcome:  audit.OutcomeSuccess,
+	}, created[0], "the ID of a created entity comes from the response")
+
+	failed, err := store.List(context.Background(), "example", "", 10)
+	require.NoError(t, err)
+	require.Len(t, failed, 1)
+	assert.Equal(t, audit.OutcomeFailure, failed[0].Outcome)
+	assert.Empty(t, failed[0].NewHash)
+
+	updated, err := store.List(context.Background(), "example", "7", 10)
+	require.NoError(t, err)
+	require.Len(t, updated, 1, "reads are not recorded")
+	assert.Equal(t, audit.Hash(map[string]string{"name": "old"}), updated[0].OldHash)
+	assert.Equal(t, audit.Hash(map[string]string{"name": "new"}), updated[0].NewHash)
+	assert.Equal(t, "/examples/{id}", updated[0].Route)
+}
+
+func TestAuditMemoryStoreList(t *testing.T) {
+	store := audit.NewMemoryStore()
+	for i := 0; i < 3; i++ {
+		require.NoError(t, store.Append(context.Background(), &audit.Record{Entity: "example", EntityID: "7", Status: 200 + i}))
+	}
+	require.NoError(t, store.Append(context.Background(), &audit.Record{Entity: "example", EntityID: "8"}))
+
+	records, err := store.List(context.Background(), "example", "7", 2)
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, []int64{3, 2}, []int64{records[0].ID, records[1].ID}, "newest first")
+}
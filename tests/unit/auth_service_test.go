@@ -2,10 +2,13 @@ package unit
 
 import (
 	"testing"
+	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 	"github.com/yourorg/go-api-template/core/auth"
+	middleware "github.com/yourorg/go-api-template/core/transport/httpserver/middlewares"
 )
 
 // AuthServiceTestSuite defines the test suite for AuthService
@@ -51,6 +54,28 @@ func (suite *AuthServiceTestSuite) TestValidateRefreshToken() {
 	assert.Equal(suite.T(), userID, extractedUserID)
 }
 
+// TestGenerateAccessToken tests tokens minted with a custom lifetime
+func (suite *AuthServiceTestSuite) TestGenerateAccessToken() {
+	token, err := suite.authService.GenerateAccessToken("user-123", "test@example.com", []string{"admin"}, time.Hour)
+	assert.NoError(suite.T(), err)
+
+	claims := &middleware.UserClaims{}
+	_, err = jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(suite.secretKey), nil
+	})
+	assert.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "user-123", claims.UserID)
+	assert.Equal(suite.T(), []string{"admin"}, claims.Roles)
+	assert.WithinDuration(suite.T(), time.Now().Add(time.Hour), claims.ExpiresAt.Time, time.Minute)
+
+	expired, err := suite.authService.GenerateAccessToken("user-123", "", nil, -time.Minute)
+	assert.NoError(suite.T(), err)
+	_, err = jwt.ParseWithClaims(expired, &middleware.UserClaims{}, func(token *jwt.Token) (interface{}, error) {
+		return []byte(suite.secretKey), nil
+	})
+	assert.ErrorIs(suite.T(), err, jwt.ErrTokenExpired)
+}
+
 // TestValidateRefreshTokenWithInvalidToken tests validation with invalid token
 func (suite *AuthServiceTestSuite) TestValidateRefreshTokenWithInvalidToken() {
 	invalidToken := "invalid.token.here"
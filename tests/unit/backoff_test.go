@@ -0,0 +1,31 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yourorg/go-api-template/core/backoff"
+)
+
+func TestExponentialBackoff(t *testing.T) {
+	base, ceiling := 100*time.Millisecond, time.Second
+	for attempt, limit := range map[int]time.Duration{
+		0:  base,
+		1:  base,
+		2:  2 * base,
+		3:  4 * base,
+		4:  8 * base,
+		5:  ceiling,
+		50: ceiling,
+	} {
+		for range 100 {
+			delay := backoff.Exponential(base, ceiling, attempt)
+			assert.Positive(t, delay, "attempt %d", attempt)
+			assert.LessOrEqual(t, delay, limit, "attempt %d", attempt)
+		}
+	}
+
+	assert.Zero(t, backoff.Exponential(0, time.Second, 3), "a zero base does not panic")
+	assert.Zero(t, backoff.Exponential(time.Second, 0, 1), "a zero cap disables the delay")
+}
@@ -0,0 +1,48 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/core/health"
+	"github.com/yourorg/go-api-template/internal/build"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+func TestBuildMetadata(t *testing.T) {
+	version, commit := build.Version, build.Commit
+	build.Version, build.Commit = "v1.2.3", "abc123"
+	t.Cleanup(func() { build.Version, build.Commit = version, commit })
+
+	info := build.Get()
+	assert.Equal(t, "v1.2.3", info.Version)
+	assert.Equal(t, "abc123", info.Commit)
+	assert.NotEmpty(t, info.GoVersion)
+	assert.NotEqual(t, "N/A", info.GoVersion, "go version falls back to the runtime")
+
+	var buf bytes.Buffer
+	require.NoError(t, json.NewEncoder(&buf).Encode(info))
+	assert.Contains(t, buf.String(), `"build_time"`)
+
+	attrs := map[string]string{}
+	for _, kv := range build.Resource().Attributes() {
+		attrs[string(kv.Key)] = kv.Value.AsString()
+	}
+	assert.Equal(t, "v1.2.3", attrs[string(semconv.ServiceVersionKey)])
+	assert.Equal(t, build.ServiceName, attrs[string(semconv.ServiceNameKey)])
+	assert.Equal(t, "abc123", attrs["vcs.ref.head.revision"])
+}
+
+func TestHealthReportsBuildInfo(t *testing.T) {
+	hs := health.NewHealthService(health.BuildInfo{Version: "v1.2.3", Commit: "abc123", Time: "2025-01-01T00:00:00Z"})
+
+	resp := hs.Liveness(context.Background())
+	assert.Equal(t, "v1.2.3", resp.Version)
+	assert.Equal(t, "v1.2.3", resp.System.Version)
+	assert.Equal(t, "abc123", resp.System.Commit)
+	assert.Equal(t, "2025-01-01T00:00:00Z", resp.System.BuildTime)
+}
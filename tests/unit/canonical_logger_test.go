@@ -0,0 +1,53 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/core/logger"
+)
+
+func canonicalLogEntry(t *testing.T, request []byte, response []byte) map[string]any {
+	var buf bytes.Buffer
+	logger.CompileCanonicalLogTemplate()
+	logger.CanonicalLogger(context.Background(), *slog.New(slog.NewJSONHandler(&buf, nil)), logger.Info,
+		request, response, nil, logger.CanonicalLog{Transport: "http", Method: "GET", Path: "/items", Status: 200}, nil)
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	return entry
+}
+
+func TestCanonicalLoggerLogsBodies(t *testing.T) {
+	entry := canonicalLogEntry(t, []byte(`{"name":"a"}`), []byte(`[{"id":1},{"id":2}]`))
+
+	assert.Equal(t, map[string]any{"name": "a"}, entry["request"], "JSON is nested, not quoted")
+	assert.Len(t, entry["response"], 2, "arrays are JSON too")
+
+	entry = canonicalLogEntry(t, []byte("plain text"), []byte{0x82, 0xa2, 0x69, 0x64, 0xff})
+	assert.Equal(t, "plain text", entry["request"])
+	assert.Equal(t, "(5 bytes, binary)", entry["response"])
+}
+
+func TestCanonicalLoggerCapsBodies(t *testing.T) {
+	logger.SetMaxBodySize(16)
+	t.Cleanup(func() { logger.SetMaxBodySize(0) })
+
+	long := `{"message":"` + strings.Repeat("ก", 20) + `"}`
+	entry := canonicalLogEntry(t, nil, []byte(long))
+
+	response, ok := entry["response"].(string)
+	require.True(t, ok, "a cut document is logged as a string")
+	assert.True(t, strings.HasPrefix(response, `{"message":"ก`))
+	assert.Contains(t, response, "...(truncated, 74 bytes)")
+
+	logger.SetMaxBodySize(-1)
+	entry = canonicalLogEntry(t, []byte(`{"password":"secret"}`), nil)
+	assert.Equal(t, "(21 bytes)", entry["request"])
+}
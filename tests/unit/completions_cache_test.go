@@ -0,0 +1,112 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/core/cache"
+	core_config "github.com/yourorg/go-api-template/core/config"
+	"github.com/yourorg/go-api-template/core/httpclient/completions"
+)
+
+// memoryCache implements the JSON part of cache.CacheService
+type memoryCache struct {
+	cache.CacheService
+	mu   sync.Mutex
+	data map[string][]byte
+}
+
+func (m *memoryCache) GetJSON(_ context.Context, key string, dest interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	value, ok := m.data[key]
+	if !ok {
+		return cache.ErrCacheKeyNotFound
+	}
+	return json.Unmarshal(value, dest)
+}
+
+func (m *memoryCache) SetJSON(_ context.Context, key string, value interface{}, _ time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, err := json.Marshal(value)
+	m.data[key] = data
+	return err
+}
+
+func newCountingCompletionsServer(t *testing.T, calls *atomic.Int32, delay time.Duration) *core_config.LMStudioConfig {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		n := calls.Add(1)
+		time.Sleep(delay)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id":"call-%d","choices":[{"index":0,"message":{"role":"assistant","content":"hi"}}]}`, n)
+	}))
+	t.Cleanup(server.Close)
+
+	return &core_config.LMStudioConfig{
+		Protocol: "http",
+		BaseUrl:  strings.TrimPrefix(server.URL, "http://"),
+	}
+}
+
+func TestGetCompletionsServiceCache(t *testing.T) {
+	var calls atomic.Int32
+	cfg := newCountingCompletionsServer(t, &calls, 0)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	client := completions.NewCompletionsServiceClient(cfg, *logger,
+		completions.WithCache(&memoryCache{data: map[string][]byte{}}, time.Minute))
+
+	req := completions.CompletionRequest{Model: "m", Messages: []completions.MessageRequest{{Role: "user", Content: "users table"}}}
+	first, err := client.GetCompletionsService(context.Background(), req)
+	require.NoError(t, err)
+	second, err := client.GetCompletionsService(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, second.ID)
+	assert.EqualValues(t, 1, calls.Load())
+
+	// a different prompt is a different key
+	req.Messages[0].Content = "orders table"
+	_, err = client.GetCompletionsService(context.Background(), req)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, calls.Load())
+}
+
+func TestGetCompletionsServiceDeduplication(t *testing.T) {
+	var calls atomic.Int32
+	cfg := newCountingCompletionsServer(t, &calls, 100*time.Millisecond)
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	client := completions.NewCompletionsServiceClient(cfg, *logger, completions.WithDeduplication())
+
+	req := completions.CompletionRequest{Model: "m", Messages: []completions.MessageRequest{{Role: "user", Content: "users table"}}}
+	var wg sync.WaitGroup
+	ids := make([]string, 5)
+	for i := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.GetCompletionsService(context.Background(), req)
+			assert.NoError(t, err)
+			ids[i] = resp.ID
+		}()
+	}
+	wg.Wait()
+
+	assert.EqualValues(t, 1, calls.Load())
+	for _, id := range ids {
+		assert.Equal(t, "call-1", id)
+	}
+}
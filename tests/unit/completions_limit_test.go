@@ -0,0 +1,93 @@
+package unit
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/core/exception"
+	"github.com/yourorg/go-api-template/core/httpclient/completions"
+	"github.com/yourorg/go-api-template/core/ratelimit"
+)
+
+func TestGetCompletionsServiceConcurrencyLimit(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	release := make(chan struct{})
+	client := newTestCompletionsClient(t, func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		<-release
+		w.Write([]byte(`{"id":"ok"}`))
+	}, completions.WithConcurrencyLimit(2), completions.WithQueueTimeout(50*time.Millisecond))
+
+	var wg sync.WaitGroup
+	errs := make([]error, 3)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = client.GetCompletionsService(context.Background(), completions.CompletionRequest{Model: "m"})
+		}()
+	}
+
+	// the third request times out in the queue while two are generating
+	time.Sleep(200 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.EqualValues(t, 2, peak.Load())
+	var rejected []error
+	for _, err := range errs {
+		if err != nil {
+			rejected = append(rejected, err)
+		}
+	}
+	require.Len(t, rejected, 1)
+	cErr, ok := exception.AsExceptionError(rejected[0])
+	require.True(t, ok)
+	assert.Equal(t, http.StatusTooManyRequests, cErr.HttpStatusCode)
+	assert.True(t, exception.IsRetryable(rejected[0]))
+}
+
+func TestRateLimitWait(t *testing.T) {
+	limiter := ratelimit.NewMemoryLimiter(ratelimit.Config{Requests: 1, Window: 100 * time.Millisecond})
+
+	require.NoError(t, ratelimit.Wait(context.Background(), limiter, "k"))
+	start := time.Now()
+	require.NoError(t, ratelimit.Wait(context.Background(), limiter, "k"))
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+	// the next slot opens after the deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, ratelimit.Wait(ctx, limiter, "k"), ratelimit.ErrWaitExceedsDeadline)
+}
+
+func TestGetCompletionsServiceRateLimitQueueTimeout(t *testing.T) {
+	limiter := ratelimit.NewMemoryLimiter(ratelimit.Config{Requests: 1, Window: time.Minute})
+	client := newTestCompletionsClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"ok"}`))
+	}, completions.WithRateLimit(limiter, "llm"), completions.WithQueueTimeout(20*time.Millisecond))
+
+	_, err := client.GetCompletionsService(context.Background(), completions.CompletionRequest{Model: "m"})
+	require.NoError(t, err)
+
+	_, err = client.GetCompletionsService(context.Background(), completions.CompletionRequest{Model: "m"})
+	cErr, ok := exception.AsExceptionError(err)
+	require.True(t, ok)
+	assert.Equal(t, http.StatusTooManyRequests, cErr.HttpStatusCode)
+	assert.ErrorIs(t, err, ratelimit.ErrWaitExceedsDeadline)
+}
@@ -0,0 +1,62 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core_config "github.com/yourorg/go-api-template/core/config"
+	"github.com/yourorg/go-api-template/core/httpclient/completions"
+)
+
+func newTestCompletionsClient(t *testing.T, handler http.HandlerFunc, opts ...completions.ClientOption) completions.CompletionsServiceClient {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	cfg := &core_config.LMStudioConfig{
+		Protocol: "http",
+		BaseUrl:  strings.TrimPrefix(server.URL, "http://"),
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	return completions.NewCompletionsServiceClient(cfg, *logger, opts...)
+}
+
+func TestGetCompletionsStream(t *testing.T) {
+	client := newTestCompletionsClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "text/event-stream", r.Header.Get("Accept"))
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, content := range []string{"Hel", "lo"} {
+			fmt.Fprintf(w, "data: {\"id\":\"1\",\"choices\":[{\"index\":0,\"delta\":{\"content\":%q}}]}\n\n", content)
+		}
+		fmt.Fprint(w, ": keep-alive\n\ndata: [DONE]\n\n")
+	})
+
+	var text strings.Builder
+	for chunk, err := range client.GetCompletionsStream(context.Background(), completions.CompletionRequest{Model: "m"}) {
+		require.NoError(t, err)
+		require.Len(t, chunk.Choices, 1)
+		text.WriteString(chunk.Choices[0].Delta.Content)
+	}
+	assert.Equal(t, "Hello", text.String())
+}
+
+func TestGetCompletionsStreamError(t *testing.T) {
+	client := newTestCompletionsClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error":{"message":"model not loaded"}}`)
+	})
+
+	var got error
+	for _, err := range client.GetCompletionsStream(context.Background(), completions.CompletionRequest{Model: "m"}) {
+		got = err
+	}
+	require.Error(t, got)
+	assert.Equal(t, "model not loaded", got.Error())
+}
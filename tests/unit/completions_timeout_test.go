@@ -0,0 +1,71 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/core/httpclient/completions"
+)
+
+// blockUntilCanceled waits for the client to go away. The body has to be
+// consumed first, otherwise the server never notices the closed connection.
+func blockUntilCanceled(r *http.Request) {
+	io.Copy(io.Discard, r.Body)
+	<-r.Context().Done()
+}
+
+func TestGetCompletionsServiceTimeout(t *testing.T) {
+	client := newTestCompletionsClient(t, func(w http.ResponseWriter, r *http.Request) {
+		blockUntilCanceled(r)
+	})
+
+	start := time.Now()
+	_, err := client.GetCompletionsService(context.Background(), completions.CompletionRequest{Model: "m", Timeout: 50 * time.Millisecond})
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+
+	var timeoutErr *completions.TimeoutError
+	require.True(t, errors.As(err, &timeoutErr))
+	assert.Equal(t, 50*time.Millisecond, timeoutErr.Budget)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+func TestGetCompletionsStreamTimeout(t *testing.T) {
+	client := newTestCompletionsClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		blockUntilCanceled(r)
+	})
+
+	var err error
+	for _, err = range client.GetCompletionsStream(context.Background(), completions.CompletionRequest{Model: "m", Timeout: 50 * time.Millisecond}) {
+		if err != nil {
+			break
+		}
+	}
+
+	var timeoutErr *completions.TimeoutError
+	require.True(t, errors.As(err, &timeoutErr))
+	assert.True(t, timeoutErr.Timeout())
+}
+
+func TestGetCompletionsServiceCallerDeadline(t *testing.T) {
+	client := newTestCompletionsClient(t, func(w http.ResponseWriter, r *http.Request) {
+		blockUntilCanceled(r)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := client.GetCompletionsService(ctx, completions.CompletionRequest{Model: "m"})
+
+	var timeoutErr *completions.TimeoutError
+	require.True(t, errors.As(err, &timeoutErr))
+	assert.Zero(t, timeoutErr.Budget)
+}
@@ -0,0 +1,210 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/config"
+	"github.com/yourorg/go-api-template/core/secrets"
+)
+
+func newAgeIdentity(t *testing.T) secrets.AgeIdentity {
+	identity, err := secrets.GenerateAgeIdentity()
+	require.NoError(t, err)
+	return identity
+}
+
+func TestAgeEncryption(t *testing.T) {
+	identity, other := newAgeIdentity(t), newAgeIdentity(t)
+
+	parsed, err := secrets.ParseAgeIdentity(identity.String())
+	require.NoError(t, err)
+	assert.Equal(t, identity.Recipient(), parsed.Recipient())
+	assert.True(t, strings.HasPrefix(identity.String(), "AGE-SECRET-KEY-1"))
+	assert.True(t, strings.HasPrefix(identity.Recipient(), "age1"))
+
+	for _, size := range []int{0, 11, 64 * 1024, 64*1024 + 1, 200 * 1024} {
+		t.Run(fmt.Sprintf("%d bytes", size), func(t *testing.T) {
+			plaintext := make([]byte, size)
+			_, _ = rand.Read(plaintext)
+
+			ciphertext, err := secrets.EncryptAge(plaintext, other.Recipient(), identity.Recipient())
+			require.NoError(t, err)
+			decrypted, err := secrets.DecryptAge(ciphertext, []secrets.AgeIdentity{identity})
+			require.NoError(t, err)
+			assert.Equal(t, len(plaintext), len(decrypted))
+			assert.True(t, bytes.Equal(plaintext, decrypted))
+		})
+	}
+
+	ciphertext, err := secrets.EncryptAge([]byte("secret"), identity.Recipient())
+	require.NoError(t, err)
+	_, err = secrets.DecryptAge(ciphertext, []secrets.AgeIdentity{other})
+	assert.ErrorIs(t, err, secrets.ErrNoAgeIdentity)
+
+	decrypted, err := secrets.DecryptAge([]byte(armorAge(ciphertext)), []secrets.AgeIdentity{identity})
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(decrypted))
+
+	tampered := append([]byte{}, ciphertext...)
+	tampered[len(tampered)-1] ^= 1
+	_, err = secrets.DecryptAge(tampered, []secrets.AgeIdentity{identity})
+	assert.Error(t, err)
+
+	_, err = secrets.EncryptAge([]byte("secret"), "age1invalid")
+	assert.Error(t, err)
+}
+
+func TestEncryptedConfigValues(t *testing.T) {
+	identity := newAgeIdentity(t)
+	t.Setenv(secrets.AgeKeyEnv, "# created for the test\n"+identity.String())
+
+	ciphertext, err := secrets.EncryptAge([]byte(`{"user":"app","password":"s3cret"}`), identity.Recipient())
+	require.NoError(t, err)
+	value := secrets.SchemeEncrypted + ":" + base64.StdEncoding.EncodeToString(ciphertext)
+
+	target := struct {
+		User     string
+		Password string
+	}{User: value + "#user", Password: value + "#password"}
+	resolver := secrets.NewResolver(secrets.Config{}, nil)
+	require.NoError(t, resolver.ResolveStruct(context.Background(), &target))
+	assert.Equal(t, "app", target.User)
+	assert.Equal(t, "s3cret", target.Password)
+
+	t.Setenv(secrets.AgeKeyEnv, newAgeIdentity(t).String())
+	t.Setenv(secrets.AgeKeyFileEnv, "")
+	target.Password = value + "#password"
+	err = secrets.NewResolver(secrets.Config{}, nil).ResolveStruct(context.Background(), &target)
+	assert.ErrorIs(t, err, secrets.ErrNoAgeIdentity)
+}
+
+func TestSOPSConfigFile(t *testing.T) {
+	identity := newAgeIdentity(t)
+	keyFile := filepath.Join(t.TempDir(), "keys.txt")
+	require.NoError(t, os.WriteFile(keyFile, []byte(identity.String()+"\n"), 0o600))
+	t.Setenv(secrets.AgeKeyEnv, "")
+	t.Setenv(secrets.AgeKeyFileEnv, keyFile)
+
+	dataKey := make([]byte, 32)
+	_, _ = rand.Read(dataKey)
+	wrapped, err := secrets.EncryptAge(dataKey, identity.Recipient())
+	require.NoError(t, err)
+
+	encrypted := fmt.Sprintf(`env: local
+restServer:
+  port: %s
+auth:
+  jwtSecretKey: %s
+  skipAuthPaths:
+    - %s
+    - /metrics
+rateLimit:
+  enabled: %s
+  requests: %s
+sops:
+  age:
+    - recipient: %s
+      enc: |
+%s
+  lastmodified: "2026-10-18T10:00:00Z"
+  mac: %s
+  version: 3.9.0
+`,
+		sopsEncrypt(t, dataKey, "8080", "str", "restServer:port:"),
+		sopsEncrypt(t, dataKey, "from-sops", "str", "auth:jwtSecretKey:"),
+		sopsEncrypt(t, dataKey, "/health", "str", "auth:skipAuthPaths:"),
+		sopsEncrypt(t, dataKey, "True", "bool", "rateLimit:enabled:"),
+		sopsEncrypt(t, dataKey, "42", "int", "rateLimit:requests:"),
+		identity.Recipient(),
+		indent(armorAge(wrapped), "        "),
+		sopsMAC(t, dataKey, "2026-10-18T10:00:00Z", "local", "8080", "from-sops", "/health", "/metrics", "True", "42"),
+	)
+	require.True(t, secrets.IsSOPS([]byte(encrypted)))
+	assert.False(t, secrets.IsSOPS([]byte("env: local\n")))
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(encrypted), 0o600))
+	require.NoError(t, config.ResolveConfigFromFiles(context.Background(), path))
+	cfg := config.GetConfig()
+	assert.Equal(t, "8080", cfg.RestServer.Port)
+	assert.Equal(t, "from-sops", cfg.Auth.JWTSecretKey)
+	assert.Equal(t, []string{"/health", "/metrics"}, cfg.Auth.SkipAuthPaths)
+	assert.True(t, cfg.RateLimit.Enabled)
+	assert.Equal(t, 42, cfg.RateLimit.Requests)
+
+	// a value moved to another key no longer authenticates
+	moved := strings.Replace(encrypted, "  port: ENC", "  host: ENC", 1)
+	_, err = secrets.DecryptSOPS(context.Background(), []byte(moved), nil)
+	assert.ErrorContains(t, err, "restServer.host")
+
+	// the MAC covers the plain values and the order of the values as well
+	edited := strings.Replace(encrypted, "env: local", "env: production", 1)
+	_, err = secrets.DecryptSOPS(context.Background(), []byte(edited), nil)
+	assert.ErrorContains(t, err, "MAC does not match")
+	i, j := strings.Index(encrypted, "    - ENC"), strings.Index(encrypted, "    - /metrics")
+	swapped := encrypted[:i] + "    - /metrics\n" + encrypted[i:j] + encrypted[j+len("    - /metrics\n"):]
+	_, err = secrets.DecryptSOPS(context.Background(), []byte(swapped), nil)
+	assert.ErrorContains(t, err, "MAC does not match")
+	stripped := regexp.MustCompile(`(?m)^  mac: .*\n`).ReplaceAllString(encrypted, "")
+	_, err = secrets.DecryptSOPS(context.Background(), []byte(stripped), nil)
+	assert.ErrorContains(t, err, "no MAC")
+
+	t.Setenv(secrets.AgeKeyFileEnv, "")
+	t.Setenv(secrets.AgeKeyEnv, newAgeIdentity(t).String())
+	_, err = secrets.DecryptSOPS(context.Background(), []byte(encrypted), nil)
+	assert.ErrorContains(t, err, "cannot decrypt the data key")
+}
+
+// sopsMAC returns the MAC sops stores for a document of values, the SHA-512 of the values in
+// order encrypted with the last modification time
+func sopsMAC(t *testing.T, key []byte, lastModified string, values ...string) string {
+	sum := sha512.Sum512([]byte(strings.Join(values, "")))
+	return sopsEncrypt(t, key, strings.ToUpper(hex.EncodeToString(sum[:])), "str", lastModified)
+}
+
+// sopsEncrypt encrypts value the way sops does, authenticated with the key path
+func sopsEncrypt(t *testing.T, key []byte, value, valueType, path string) string {
+	block, err := aes.NewCipher(key)
+	require.NoError(t, err)
+	iv := make([]byte, 32)
+	_, _ = rand.Read(iv)
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(iv))
+	require.NoError(t, err)
+	sealed := gcm.Seal(nil, iv, []byte(value), []byte(path))
+	data, tag := sealed[:len(sealed)-gcm.Overhead()], sealed[len(sealed)-gcm.Overhead():]
+
+	enc := base64.StdEncoding.EncodeToString
+	return fmt.Sprintf("ENC[AES256_GCM,data:%s,iv:%s,tag:%s,type:%s]", enc(data), enc(iv), enc(tag), valueType)
+}
+
+func armorAge(ciphertext []byte) string {
+	encoded := base64.StdEncoding.EncodeToString(ciphertext)
+	var armored strings.Builder
+	armored.WriteString("-----BEGIN AGE ENCRYPTED FILE-----\n")
+	for len(encoded) > 64 {
+		armored.WriteString(encoded[:64] + "\n")
+		encoded = encoded[64:]
+	}
+	armored.WriteString(encoded + "\n-----END AGE ENCRYPTED FILE-----\n")
+	return armored.String()
+}
+
+func indent(text, prefix string) string {
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	return prefix + strings.Join(lines, "\n"+prefix)
+}
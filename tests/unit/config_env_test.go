@@ -0,0 +1,82 @@
+package unit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/config"
+	core_config "github.com/yourorg/go-api-template/core/config"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.test.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestConfigEnvExpansion(t *testing.T) {
+	t.Setenv("TEST_JWT_SECRET", "from-env: with #yaml chars")
+	t.Setenv("APP_RESTSERVER_PORT", "9090")
+	t.Setenv("APP_REDIS_HOST", "redis.internal")
+	t.Setenv("APP_CORS_ALLOWEDORIGINS", "https://a.example,https://b.example")
+
+	path := writeConfigFile(t, `
+restServer:
+  port: "8080"
+auth:
+  jwtSecretKey: "${TEST_JWT_SECRET}"
+postgres:
+  write:
+    password: "${TEST_UNSET_PASSWORD:-fallback}"
+    username: "svc-${TEST_JWT_SECRET_UNUSED:-api}"
+`)
+	require.NoError(t, config.ResolveConfigFromFile(context.Background(), path))
+
+	cfg := config.GetConfig()
+	assert.Equal(t, "from-env: with #yaml chars", cfg.Auth.JWTSecretKey)
+	assert.Equal(t, "fallback", cfg.Postgres.Write.Password)
+	assert.Equal(t, "svc-api", cfg.Postgres.Write.Username)
+	assert.Equal(t, "9090", cfg.RestServer.Port, "environment variables override the file")
+	assert.Equal(t, "redis.internal", cfg.Redis.Host, "keys absent from the file are bound too")
+	assert.Equal(t, []string{"https://a.example", "https://b.example"}, cfg.CORS.AllowedOrigins)
+}
+
+func TestConfigEnvExpansionMissing(t *testing.T) {
+	path := writeConfigFile(t, `
+auth:
+  jwtSecretKey: "${TEST_MISSING_SECRET}"
+`)
+	err := config.ResolveConfigFromFile(context.Background(), path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "TEST_MISSING_SECRET")
+}
+
+func TestConfigDefaults(t *testing.T) {
+	t.Setenv("APP_RATELIMIT_MESSAGE", "slow down")
+	path := writeConfigFile(t, `
+restServer:
+  port: "8080"
+rateLimit:
+  enabled: true
+  window: "1m"
+`)
+	require.NoError(t, config.ResolveConfigFromFile(context.Background(), path))
+
+	cfg := config.GetConfig()
+	defaults := core_config.Defaults()
+	assert.Equal(t, "1m", cfg.RateLimit.Window, "the file overrides the default")
+	assert.Equal(t, "slow down", cfg.RateLimit.Message, "the environment overrides the default")
+	assert.Equal(t, defaults.RateLimit.Requests, cfg.RateLimit.Requests)
+	assert.Equal(t, defaults.RateLimit.SkipPaths, cfg.RateLimit.SkipPaths)
+	assert.Equal(t, 7200, cfg.CORS.MaxAge)
+	assert.Equal(t, time.Minute, cfg.RestServer.Shutdown.Timeout)
+	assert.Equal(t, 24*time.Hour, cfg.LLM.Cache.TTL)
+	assert.Equal(t, defaults.Outbox, cfg.Outbox)
+	assert.False(t, cfg.CSRF.Enabled, "optional features stay disabled")
+	assert.Equal(t, "csrf_token", cfg.CSRF.CookieName)
+}
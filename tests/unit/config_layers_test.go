@@ -0,0 +1,119 @@
+package unit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/config"
+	core_config "github.com/yourorg/go-api-template/core/config"
+	"github.com/yourorg/go-api-template/utils/runtime"
+)
+
+func TestConfigFilePaths(t *testing.T) {
+	paths, err := core_config.GetConfigFilePaths(runtime.RuntimeCfg{Env: runtime.Stg})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"config/config.yaml", "config/config.stg.yaml", "config/config.local.yaml"}, paths)
+
+	paths, err = core_config.GetConfigFilePaths(runtime.RuntimeCfg{Env: runtime.Local})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"config/config.yaml", "config/config.local.yaml"}, paths)
+}
+
+func TestCustomProfiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profiles.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+uat:
+  logLevel: info
+  overlays: [stg, uat]
+perf:
+  production: true
+  logFormat: console
+`), 0o600))
+	require.NoError(t, runtime.LoadProfiles(path))
+	require.NoError(t, runtime.LoadProfiles(filepath.Join(t.TempDir(), "missing.yaml")))
+
+	assert.Equal(t, runtime.Environment("uat"), runtime.ValidateProfile("uat"))
+	assert.Equal(t, runtime.Local, runtime.ValidateProfile("unknown"))
+	assert.Contains(t, runtime.Environments(), runtime.Environment("perf"))
+	assert.True(t, runtime.Environment("perf").IsProduction())
+	assert.False(t, runtime.Environment("uat").IsProduction())
+	assert.True(t, runtime.Prd.IsProduction())
+
+	profile, ok := runtime.LookupProfile("uat")
+	require.True(t, ok)
+	assert.Equal(t, "info", profile.LogLevel)
+
+	paths, err := core_config.GetConfigFilePaths(runtime.RuntimeCfg{Env: "uat"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"config/config.yaml", "config/config.stg.yaml", "config/config.uat.yaml", "config/config.local.yaml"}, paths)
+
+	paths, err = core_config.GetConfigFilePaths(runtime.RuntimeCfg{Env: "perf"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"config/config.yaml", "config/config.perf.yaml", "config/config.local.yaml"}, paths)
+
+	require.NoError(t, os.WriteFile(path, []byte("bad:\n  logFormat: xml\n"), 0o600))
+	assert.Error(t, runtime.LoadProfiles(path))
+	_, ok = runtime.LookupProfile("bad")
+	assert.False(t, ok, "an invalid file registers nothing")
+}
+
+func TestConfigLayers(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+	base := write("config.yaml", `
+env: local
+restServer:
+  port: "8080"
+  h2c: true
+auth:
+  jwtSecretKey: "base-secret"
+  skipAuthPaths: ["/health", "/metrics"]
+rateLimit:
+  enabled: true
+  requests: 100
+`)
+	overlay := write("config.stg.yaml", `
+env: stg
+restServer:
+  h2c: false
+auth:
+  skipAuthPaths: ["/health"]
+rateLimit:
+  requests: 50
+`)
+	missing := filepath.Join(dir, "config.local.yaml")
+
+	require.NoError(t, config.ResolveConfigFromFiles(context.Background(), base, overlay, missing))
+	cfg := config.GetConfig()
+	assert.Equal(t, "stg", cfg.Env)
+	assert.Equal(t, "8080", cfg.RestServer.Port, "keys absent from the overlay are kept")
+	assert.False(t, cfg.RestServer.H2C, "an overlay can turn a setting off")
+	assert.Equal(t, "base-secret", cfg.Auth.JWTSecretKey)
+	assert.Equal(t, []string{"/health"}, cfg.Auth.SkipAuthPaths, "lists are replaced, not appended")
+	assert.True(t, cfg.RateLimit.Enabled)
+	assert.Equal(t, 50, cfg.RateLimit.Requests)
+
+	t.Run("local overrides reload", func(t *testing.T) {
+		write("config.local.yaml", `
+rateLimit:
+  requests: 10
+`)
+		require.NoError(t, config.Reload(context.Background()))
+		assert.Equal(t, 10, config.GetConfig().RateLimit.Requests)
+		assert.Equal(t, "stg", config.GetConfig().Env)
+	})
+
+	t.Run("no file exists", func(t *testing.T) {
+		err := config.ResolveConfigFromFiles(context.Background(), filepath.Join(dir, "a.yaml"), filepath.Join(dir, "b.yaml"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "a.yaml")
+	})
+}
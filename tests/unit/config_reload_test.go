@@ -0,0 +1,100 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/config"
+	core_config "github.com/yourorg/go-api-template/core/config"
+	"github.com/yourorg/go-api-template/core/ratelimit"
+)
+
+const reloadConfigTemplate = `
+env: local
+restServer:
+  port: "%s"
+auth:
+  jwtSecretKey: "secret"
+rateLimit:
+  enabled: true
+  requests: %d
+  window: "1m"
+`
+
+func TestConfigReload(t *testing.T) {
+	path := writeConfigFile(t, fmt.Sprintf(reloadConfigTemplate, "8080", 10))
+	require.NoError(t, config.ResolveConfigFromFile(context.Background(), path))
+
+	var rateLimitCalls, loggingCalls, anyCalls int
+	var oldRequests, newRequests int
+	defer config.OnChange("rateLimit", func(old, new *config.Config) {
+		rateLimitCalls++
+		oldRequests, newRequests = old.RateLimit.Requests, new.RateLimit.Requests
+	})()
+	defer config.OnChange("logging", func(old, new *config.Config) { loggingCalls++ })()
+	defer config.OnChange("", func(old, new *config.Config) { anyCalls++ })()
+
+	require.NoError(t, os.WriteFile(path, []byte(fmt.Sprintf(reloadConfigTemplate, "8080", 20)), 0o600))
+	require.NoError(t, config.Reload(context.Background()))
+	assert.Equal(t, 1, rateLimitCalls)
+	assert.Equal(t, 0, loggingCalls, "unchanged sections are not notified")
+	assert.Equal(t, 1, anyCalls)
+	assert.Equal(t, 10, oldRequests)
+	assert.Equal(t, 20, newRequests)
+	assert.Equal(t, 20, config.GetConfig().RateLimit.Requests)
+
+	t.Run("unchanged file", func(t *testing.T) {
+		require.NoError(t, config.Reload(context.Background()))
+		assert.Equal(t, 1, anyCalls)
+	})
+
+	t.Run("invalid config is rejected", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path, []byte(fmt.Sprintf(reloadConfigTemplate, "http", 30)), 0o600))
+		err := config.Reload(context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "restServer.port")
+		assert.Equal(t, 20, config.GetConfig().RateLimit.Requests, "the current config is kept")
+		assert.Equal(t, 1, rateLimitCalls)
+	})
+
+	t.Run("SIGHUP", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		config.Watch(ctx, core_config.ReloadConfig{SIGHUP: true})
+
+		require.NoError(t, os.WriteFile(path, []byte(fmt.Sprintf(reloadConfigTemplate, "8080", 40)), 0o600))
+		require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGHUP))
+		assert.Eventually(t, func() bool { return config.GetConfig().RateLimit.Requests == 40 }, 2*time.Second, 10*time.Millisecond)
+	})
+}
+
+func TestReloadableRateLimit(t *testing.T) {
+	cfg := ratelimit.Config{Requests: 1, Window: time.Minute, StatusCode: http.StatusTooManyRequests}
+	limiter := ratelimit.NewReloadable(ratelimit.NewMemoryLimiter(cfg), cfg)
+	handler := limiter.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	serve := func() int {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/examples", nil))
+		return rec.Code
+	}
+	assert.Equal(t, http.StatusOK, serve())
+	assert.Equal(t, http.StatusTooManyRequests, serve())
+
+	cfg.Requests = 3
+	limiter.Update(ratelimit.NewMemoryLimiter(cfg), cfg)
+	assert.Equal(t, http.StatusOK, serve(), "the new limit applies right away")
+
+	limiter.Update(nil, ratelimit.Config{})
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, http.StatusOK, serve(), "a nil limiter disables rate limiting")
+	}
+}
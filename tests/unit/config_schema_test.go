@@ -0,0 +1,148 @@
+package unit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core_config "github.com/yourorg/go-api-template/core/config"
+	"gopkg.in/yaml.v3"
+)
+
+func TestConfigSchemaUpToDate(t *testing.T) {
+	var generated bytes.Buffer
+	enc := json.NewEncoder(&generated)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	require.NoError(t, enc.Encode(core_config.Schema()))
+
+	committed, err := os.ReadFile("../../config/config.schema.json")
+	require.NoError(t, err)
+	assert.Equal(t, string(committed), generated.String(), "run make config-schema")
+}
+
+func TestConfigFilesMatchSchema(t *testing.T) {
+	schema := core_config.Schema()
+	for _, path := range []string{"../../config/example.config.yaml", "../../config/config.docker.yaml"} {
+		raw, err := os.ReadFile(path)
+		require.NoError(t, err)
+		var doc any
+		require.NoError(t, yaml.Unmarshal(raw, &doc))
+
+		var problems []string
+		checkSchema(schema, doc, "", &problems)
+		assert.Empty(t, problems, path)
+	}
+
+	t.Run("rejects mistakes", func(t *testing.T) {
+		var doc any
+		require.NoError(t, yaml.Unmarshal([]byte(`
+restServer:
+  errorFormt: problem
+  h2c: "yes"
+logging:
+  level: verbose
+cors:
+  maxAge: "${CORS_MAX_AGE:-600}"
+`), &doc))
+		var problems []string
+		checkSchema(schema, doc, "", &problems)
+		assert.ElementsMatch(t, []string{
+			"restServer.errorFormt: unknown key",
+			"restServer.h2c: matches none of the allowed schemas",
+			"logging.level: verbose is not allowed",
+		}, problems)
+	})
+
+	t.Run("describes keys", func(t *testing.T) {
+		assert.Equal(t, "JWT authentication", schema.Properties["auth"].Description)
+		provider := schema.Properties["llm"].Properties["provider"]
+		assert.Equal(t, "lmstudio", provider.Default)
+		assert.Contains(t, provider.Enum, "azure")
+		maxAge := schema.Properties["cors"].Properties["maxAge"]
+		assert.EqualValues(t, 7200, maxAge.Default)
+	})
+}
+
+// checkSchema validates value against the subset of JSON Schema generated by core_config.Schema
+func checkSchema(schema *core_config.JSONSchema, value any, key string, problems *[]string) {
+	report := func(format string, args ...any) {
+		*problems = append(*problems, key+": "+fmt.Sprintf(format, args...))
+	}
+	if len(schema.AnyOf) > 0 {
+		for _, option := range schema.AnyOf {
+			var optionProblems []string
+			if checkSchema(option, value, key, &optionProblems); len(optionProblems) == 0 {
+				return
+			}
+		}
+		report("matches none of the allowed schemas")
+		return
+	}
+	if value == nil {
+		return
+	}
+
+	switch schema.Type {
+	case "object":
+		fields, ok := value.(map[string]any)
+		if !ok {
+			report("must be an object")
+			return
+		}
+		for name, item := range fields {
+			child := key + "." + name
+			if key == "" {
+				child = name
+			}
+			if property, ok := schema.Properties[name]; ok {
+				checkSchema(property, item, child, problems)
+			} else if additional, ok := schema.AdditionalProperties.(*core_config.JSONSchema); ok {
+				checkSchema(additional, item, child, problems)
+			} else {
+				*problems = append(*problems, child+": unknown key")
+			}
+		}
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			report("must be an array")
+			return
+		}
+		for i, item := range items {
+			checkSchema(schema.Items, item, fmt.Sprintf("%s[%d]", key, i), problems)
+		}
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			report("must be a string")
+			return
+		}
+		if schema.Pattern != "" && !regexp.MustCompile(schema.Pattern).MatchString(s) {
+			report("%q does not match %s", s, schema.Pattern)
+		}
+		if len(schema.Enum) > 0 && !slices.Contains(schema.Enum, any(s)) {
+			report("%s is not allowed", s)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			report("must be a boolean")
+		}
+	case "integer":
+		if _, ok := value.(int); !ok {
+			report("must be an integer")
+		}
+	case "number":
+		switch value.(type) {
+		case int, float64:
+		default:
+			report("must be a number")
+		}
+	}
+}
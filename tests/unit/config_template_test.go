@@ -0,0 +1,77 @@
+package unit
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core_config "github.com/yourorg/go-api-template/core/config"
+	"gopkg.in/yaml.v3"
+)
+
+func TestSetValuesKeepsComments(t *testing.T) {
+	file := []byte(`# header
+env: local
+
+restServer:
+  port: "8080" # listen port
+  h2c: false
+
+# LM Studio endpoint
+lmStudio:
+  requestTimeout: "2m"
+`)
+
+	out, err := core_config.SetValues(file, map[string]any{
+		"restServer.port":  "9090",
+		"lmStudio.baseUrl": "localhost:1234",
+		"redis.port":       6380,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, `# header
+env: local
+
+restServer:
+  port: "9090" # listen port
+  h2c: false
+
+# LM Studio endpoint
+lmStudio:
+  requestTimeout: "2m"
+  baseUrl: "localhost:1234"
+
+redis:
+  port: 6380
+`, string(out))
+}
+
+func TestSetValuesExampleConfig(t *testing.T) {
+	file, err := os.ReadFile("../../config/example.config.yaml")
+	require.NoError(t, err)
+
+	out, err := core_config.SetValues(file, map[string]any{
+		"postgres.write.host": "db",
+		"auth.jwtSecretKey":   "secret",
+	})
+	require.NoError(t, err)
+
+	var cfg struct {
+		Postgres struct {
+			Write struct{ Host, Schema string }
+		}
+		Auth struct {
+			JWTSecretKey string `yaml:"jwtSecretKey"`
+		}
+	}
+	require.NoError(t, yaml.Unmarshal(out, &cfg))
+	assert.Equal(t, "db", cfg.Postgres.Write.Host)
+	assert.Equal(t, "public", cfg.Postgres.Write.Schema)
+	assert.Equal(t, "secret", cfg.Auth.JWTSecretKey)
+}
+
+func TestSetValuesRejectsValueAsSection(t *testing.T) {
+	_, err := core_config.SetValues([]byte("env: local\n"), map[string]any{"env.name": "x"})
+	assert.ErrorContains(t, err, "env.name")
+}
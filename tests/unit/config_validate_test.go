@@ -0,0 +1,134 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/config"
+	"github.com/yourorg/go-api-template/core/audit"
+	core_config "github.com/yourorg/go-api-template/core/config"
+	"github.com/yourorg/go-api-template/core/diagnostics"
+	"github.com/yourorg/go-api-template/core/eventbus"
+	"github.com/yourorg/go-api-template/core/i18n"
+	"github.com/yourorg/go-api-template/core/jobs"
+	"github.com/yourorg/go-api-template/core/notify"
+	"github.com/yourorg/go-api-template/core/outbox"
+	"github.com/yourorg/go-api-template/core/pgdb"
+	"github.com/yourorg/go-api-template/core/slo"
+	"github.com/yourorg/go-api-template/core/storage"
+	"github.com/yourorg/go-api-template/core/telemetry"
+	"github.com/yourorg/go-api-template/core/tracing"
+	middleware "github.com/yourorg/go-api-template/core/transport/httpserver/middlewares"
+)
+
+func TestShippedConfigsAreValid(t *testing.T) {
+	for _, path := range []string{"../../config/example.config.yaml", "../../config/config.docker.yaml"} {
+		t.Run(path, func(t *testing.T) {
+			require.NoError(t, config.ResolveConfigFromFile(context.Background(), path))
+			assert.NoError(t, config.GetConfig().Validate())
+		})
+	}
+}
+
+func TestConfigValidateListsEveryViolation(t *testing.T) {
+	cfg := core_config.Config{
+		Env: "prd",
+		RestServer: core_config.RestServer{
+			Port:        "80a",
+			ErrorFormat: "xml",
+			TLS:         core_config.TLSConfig{Enabled: true, ClientAuth: core_config.ClientAuthConfig{Mode: "require"}},
+		},
+		Auth:      core_config.AuthConfig{JWTSecretKey: "short", TokenDuration: "1 day"},
+		RateLimit: core_config.RateLimitConfig{Enabled: true, Window: "hourly"},
+		CSRF:      middleware.CSRFConfig{Enabled: true, Mode: middleware.CSRFSynchronizer},
+		LLM: core_config.LLMConfig{
+			Provider: "azure",
+			Cache:    core_config.LLMCacheConfig{Enabled: true},
+		},
+		Jobs: jobs.Config{
+			Enabled: true, Store: jobs.StoreRedis, Queues: map[string]int{"emails": -1},
+			Schedules: []jobs.Schedule{{Name: "nightly"}},
+		},
+		Telemetry: telemetry.Config{
+			Metrics: telemetry.MetricsConfig{Enabled: true, Temporality: "sometimes"},
+			Traces:  tracing.Config{Enabled: true, SampleRatio: 2},
+		},
+		Diagnostics: diagnostics.Config{Enabled: true, Address: "6060"},
+		SLO:         slo.Config{Enabled: true, Objectives: []slo.Objective{{Name: "api", Latency: 0.99}}},
+		Audit:       audit.Config{Enabled: true, Store: audit.StorePostgres},
+		Outbox:      outbox.Config{Enabled: true},
+		EventBus:    eventbus.Config{Enabled: true, Driver: eventbus.DriverKafka, MaxAttempts: -1},
+		Storage:     storage.Config{Enabled: true, Driver: storage.DriverLocal, SignedURLExpiry: 30 * 24 * time.Hour, AllowedTypes: []string{"image"}},
+		Notifications: notify.Config{
+			Enabled: true, Queue: "notifications",
+			Routes: []notify.Route{{Event: "order.shipped", Channels: []string{notify.ChannelSlack}}},
+			Email:  notify.EmailConfig{Host: "smtp.example.com", From: "nobody"},
+		},
+		I18n: i18n.Config{Enabled: true, DefaultLocale: "en_US"},
+		MockData: core_config.MockDataConfig{
+			DefaultRows: 300, MaxRows: 200, Temperature: 3, Sandbox: "rollback", StatementTimeout: -time.Second,
+			SandboxTarget: pgdb.PostgresConfig{Host: "sandbox-db", Port: 5432},
+			Apply:         core_config.MockDataApplyConfig{Enabled: true, Transaction: "statement", Target: pgdb.PostgresConfig{Host: "seed-db", Port: 5432}},
+		},
+	}
+
+	err := cfg.Validate()
+	var validationErr *core_config.ValidationError
+	require.True(t, errors.As(err, &validationErr))
+
+	keys := map[string]string{}
+	for _, v := range validationErr.Violations {
+		keys[v.Key] = v.Message
+	}
+	for _, key := range []string{
+		"restServer.port", "restServer.errorFormat", "restServer.tls", "restServer.tls.clientAuth.caFile",
+		"auth.jwtSecretKey", "auth.tokenDuration", "rateLimit.window", "csrf.secret", "csrf.sessionCookie",
+		"llm.azure.baseUrl", "llm.azure.apiKey", "llm.azure.apiVersion", "llm.cache.enabled",
+		"jobs.store", "jobs.queues.emails", "jobs.schedules[0].type", "jobs.schedules[0].every",
+		"telemetry.metrics.endpoint", "telemetry.metrics.temporality",
+		"telemetry.traces.endpoint", "telemetry.traces.sampleRatio",
+		"diagnostics.address", "slo.objectives[0].pathPrefix", "slo.objectives[0].latencyThreshold",
+		"audit.store", "audit.role", "eventBus.kafka.restProxyUrl", "eventBus.maxAttempts",
+		"outbox.sink.webhookUrl", "storage.local.root", "storage.local.signingKey", "storage.signedUrlExpiry", "storage.allowedTypes[0]",
+		"notifications.queue", "notifications.routes[0].channels[0]", "notifications.email.from",
+		"i18n.defaultLocale", "mockData.defaultRows", "mockData.temperature", "mockData.sandbox", "mockData.sandboxTarget.database", "mockData.statementTimeout", "mockData.apply.enabled", "mockData.apply.transaction", "mockData.apply.target.database",
+	} {
+		assert.Contains(t, keys, key)
+	}
+	assert.Contains(t, keys["restServer.port"], `"80a"`)
+	assert.Contains(t, err.Error(), "rateLimit.window: must be a duration")
+
+	valid := core_config.Config{Env: "local", RestServer: core_config.RestServer{Port: "8080"}, Auth: core_config.AuthConfig{JWTSecretKey: "secret"}}
+	assert.NoError(t, valid.Validate())
+}
+
+func TestConfigValidateDiagnosticsAddress(t *testing.T) {
+	for address, valid := range map[string]bool{
+		"127.0.0.1:6060": true,
+		"localhost:6060": true,
+		"[::1]:6060":     true,
+		":6060":          false,
+		"0.0.0.0:6060":   false,
+		"10.0.0.5:6060":  false,
+		"pprof:6060":     false,
+	} {
+		cfg := core_config.Config{
+			Env:         "local",
+			RestServer:  core_config.RestServer{Port: "8080"},
+			Auth:        core_config.AuthConfig{JWTSecretKey: "secret"},
+			Diagnostics: diagnostics.Config{Enabled: true, Address: address},
+		}
+		if valid {
+			assert.NoError(t, cfg.Validate(), address)
+			continue
+		}
+		assert.ErrorContains(t, cfg.Validate(), "must be a loopback address", address)
+
+		cfg.Diagnostics.AllowRemote = true
+		assert.NoError(t, cfg.Validate(), "%s is accepted with allowRemote", address)
+	}
+}
@@ -0,0 +1,104 @@
+package unit
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/core/devserver"
+)
+
+func TestWatchOptionsMatches(t *testing.T) {
+	opts := devserver.DefaultWatchOptions()
+
+	assert.True(t, opts.Matches("cmd/serve.go"))
+	assert.True(t, opts.Matches("config/config.local.yaml"))
+	assert.True(t, opts.Matches("migrations/000001_init.up.sql"))
+	assert.False(t, opts.Matches("README.md"))
+	assert.False(t, opts.Matches("bin/app.go"))
+	assert.False(t, opts.Matches("tests/unit/devserver_test.go"))
+	assert.False(t, opts.Matches("cmd/.serve.go.swp"))
+	assert.False(t, opts.Matches("cmd/serve.go~"))
+}
+
+func TestWatchSendsChangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "internal"), 0755))
+	opts := devserver.DefaultWatchOptions()
+	opts.Dirs = []string{dir}
+	opts.Debounce = 50 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes, err := devserver.Watch(ctx, opts, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	file := filepath.Join(dir, "internal", "server.go")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.md"), []byte("ignored"), 0644))
+	require.NoError(t, os.WriteFile(file, []byte("package internal"), 0644))
+
+	select {
+	case files := <-changes:
+		assert.Equal(t, []string{file}, files)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no changes sent")
+	}
+}
+
+func TestProxyHoldsRequestsUntilReady(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "ok "+r.URL.Path)
+	}))
+	defer upstream.Close()
+	target, err := url.Parse(upstream.URL)
+	require.NoError(t, err)
+
+	proxy := devserver.NewProxy(5 * time.Second)
+	proxy.Restarting()
+	done := make(chan *httptest.ResponseRecorder)
+	go func() {
+		rec := httptest.NewRecorder()
+		proxy.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+		done <- rec
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("request answered before the server was ready")
+	case <-time.After(100 * time.Millisecond):
+	}
+	proxy.Ready(target)
+
+	rec := <-done
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "ok /health", rec.Body.String())
+}
+
+func TestProxyFailedBuild(t *testing.T) {
+	proxy := devserver.NewProxy(time.Second)
+	proxy.Failed("build failed:\ncmd/serve.go:1: syntax error")
+
+	rec := httptest.NewRecorder()
+	proxy.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusBadGateway, rec.Code)
+	assert.Contains(t, rec.Body.String(), "syntax error")
+}
+
+func TestProxyWaitTimeout(t *testing.T) {
+	proxy := devserver.NewProxy(50 * time.Millisecond)
+
+	rec := httptest.NewRecorder()
+	proxy.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
@@ -0,0 +1,84 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/core/doctor"
+	"github.com/yourorg/go-api-template/core/health"
+)
+
+type staticChecker health.ComponentHealth
+
+func (c staticChecker) Check(ctx context.Context) health.ComponentHealth {
+	return health.ComponentHealth(c)
+}
+
+func TestDoctorRun(t *testing.T) {
+	report := doctor.Run(context.Background(), 50*time.Millisecond,
+		doctor.Check{Name: "ok", Run: func(ctx context.Context) (doctor.Status, string) {
+			return doctor.StatusOK, "reachable"
+		}},
+		doctor.Check{Name: "slow", Run: func(ctx context.Context) (doctor.Status, string) {
+			<-ctx.Done()
+			return doctor.StatusWarn, ctx.Err().Error()
+		}},
+		doctor.Check{Name: "panic", Run: func(ctx context.Context) (doctor.Status, string) {
+			panic("boom")
+		}},
+		doctor.Check{Name: "skipped", Run: func(ctx context.Context) (doctor.Status, string) {
+			return doctor.StatusSkip, "not configured"
+		}},
+		doctor.Check{Name: "degraded", Run: doctor.FromHealth(staticChecker{Status: health.StatusDegraded, Message: "pool is running low"})},
+	)
+
+	require.Len(t, report.Results, 5)
+	statuses := map[string]doctor.Status{}
+	for _, result := range report.Results {
+		statuses[result.Name] = result.Status
+	}
+	assert.Equal(t, "ok", report.Results[0].Name, "results keep the order of the checks")
+	assert.Equal(t, doctor.StatusOK, statuses["ok"])
+	assert.Equal(t, doctor.StatusFail, statuses["slow"], "a timed out check fails")
+	assert.Contains(t, report.Results[1].Message, "timed out")
+	assert.Equal(t, doctor.StatusFail, statuses["panic"])
+	assert.Equal(t, doctor.StatusSkip, statuses["skipped"])
+	assert.Equal(t, doctor.StatusWarn, statuses["degraded"])
+	assert.False(t, report.Healthy())
+	assert.Equal(t, 2, report.Count(doctor.StatusFail))
+
+	healthy := doctor.Run(context.Background(), time.Second,
+		doctor.Check{Name: "db", Run: doctor.FromHealth(staticChecker{Status: health.StatusHealthy})},
+		doctor.Check{Name: "cache", Run: doctor.FromHealth(staticChecker{Status: health.StatusDegraded})},
+	)
+	assert.True(t, healthy.Healthy(), "warnings do not fail the report")
+}
+
+func TestDoctorReportOutput(t *testing.T) {
+	report := doctor.Report{Results: []doctor.Result{
+		{Name: "config", Status: doctor.StatusFail, Message: "invalid config, 1 problem(s):\n  - redis.host: is required", Duration: 2 * time.Millisecond},
+		{Name: "lm studio", Status: doctor.StatusSkip, Message: "lmStudio.enableMock is set"},
+	}}
+
+	var text bytes.Buffer
+	require.NoError(t, report.WriteText(&text))
+	assert.Equal(t, "[fail] config     invalid config, 1 problem(s): (2ms)\n"+
+		"                    - redis.host: is required\n"+
+		"[skip] lm studio  lmStudio.enableMock is set\n"+
+		"\n0 ok, 0 warnings, 1 failed, 1 skipped\n", text.String())
+
+	var out bytes.Buffer
+	require.NoError(t, report.WriteJSON(&out))
+	var decoded struct {
+		Results []map[string]any `json:"results"`
+	}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	require.Len(t, decoded.Results, 2)
+	assert.Equal(t, "fail", decoded.Results[0]["status"])
+	assert.Equal(t, 2.0, decoded.Results[0]["duration_ms"])
+}
@@ -0,0 +1,395 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/core/eventbus"
+	"github.com/yourorg/go-api-template/core/exception"
+	"github.com/yourorg/go-api-template/core/outbox"
+)
+
+func newTestEventBus(t *testing.T, driver eventbus.Driver) *eventbus.Bus {
+	t.Helper()
+	bus := eventbus.New(eventbus.Config{
+		MaxAttempts: 3,
+		BaseBackoff: time.Millisecond,
+		MaxBackoff:  time.Millisecond,
+	}, driver, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	t.Cleanup(func() { bus.Close() })
+	return bus
+}
+
+// receive waits for an event of ch
+func receive(t *testing.T, ch <-chan eventbus.Envelope) eventbus.Envelope {
+	t.Helper()
+	select {
+	case event := <-ch:
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatal("no event received")
+		return eventbus.Envelope{}
+	}
+}
+
+func TestEventBusTypedHandler(t *testing.T) {
+	bus := newTestEventBus(t, eventbus.NewMemoryDriver())
+
+	type created struct {
+		Name string `json:"name"`
+	}
+	got := make(chan created, 1)
+	_, err := bus.Subscribe(context.Background(), "examples", "mailer", eventbus.Typed(func(ctx context.Context, event eventbus.Envelope, data created) error {
+		assert.Equal(t, "example.created", event.Type)
+		assert.Equal(t, "examples", event.Topic)
+		got <- data
+		return nil
+	}))
+	require.NoError(t, err)
+
+	id, err := bus.Publish(context.Background(), "examples", "example.created", created{Name: "gopher"}, eventbus.WithKey("42"))
+	require.NoError(t, err)
+	assert.NotEmpty(t, id)
+
+	select {
+	case data := <-got:
+		assert.Equal(t, "gopher", data.Name)
+	case <-time.After(2 * time.Second):
+		t.Fatal("handler not called")
+	}
+}
+
+func TestEventBusConsumerGroups(t *testing.T) {
+	bus := newTestEventBus(t, eventbus.NewMemoryDriver())
+
+	var mailer, billing atomic.Int32
+	var wg sync.WaitGroup
+	wg.Add(6)
+	handler := func(counter *atomic.Int32) eventbus.Handler {
+		return func(ctx context.Context, event eventbus.Envelope) error {
+			counter.Add(1)
+			wg.Done()
+			return nil
+		}
+	}
+	// two instances of the mailer share its events
+	for i := 0; i < 2; i++ {
+		_, err := bus.Subscribe(context.Background(), "examples", "mailer", handler(&mailer))
+		require.NoError(t, err)
+	}
+	_, err := bus.Subscribe(context.Background(), "examples", "billing", handler(&billing))
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err := bus.Publish(context.Background(), "examples", "example.created", i)
+		require.NoError(t, err)
+	}
+	wg.Wait()
+
+	assert.EqualValues(t, 3, mailer.Load())
+	assert.EqualValues(t, 3, billing.Load())
+}
+
+func TestEventBusRetriesThenDeadLetters(t *testing.T) {
+	bus := newTestEventBus(t, eventbus.NewMemoryDriver())
+
+	var attempts atomic.Int32
+	_, err := bus.Subscribe(context.Background(), "examples", "mailer", func(ctx context.Context, event eventbus.Envelope) error {
+		attempts.Add(1)
+		return errors.New("smtp unavailable")
+	})
+	require.NoError(t, err)
+
+	dead := make(chan eventbus.Envelope, 1)
+	_, err = bus.Subscribe(context.Background(), bus.DeadLetterTopic("examples"), "ops", func(ctx context.Context, event eventbus.Envelope) error {
+		dead <- event
+		return nil
+	})
+	require.NoError(t, err)
+
+	id, err := bus.Publish(context.Background(), "examples", "example.created", map[string]string{"name": "gopher"})
+	require.NoError(t, err)
+
+	event := receive(t, dead)
+	assert.Equal(t, id, event.ID)
+	assert.Equal(t, "examples.dlq", bus.DeadLetterTopic("examples"))
+	assert.Equal(t, "examples", event.Headers[eventbus.HeaderDeadLetterTopic])
+	assert.Equal(t, "mailer", event.Headers[eventbus.HeaderDeadLetterGroup])
+	assert.Equal(t, "3", event.Headers[eventbus.HeaderDeadLetterAttempts])
+	assert.Equal(t, "smtp unavailable", event.Headers[eventbus.HeaderDeadLetterError])
+	assert.EqualValues(t, 3, attempts.Load())
+}
+
+func TestEventBusPermanentErrorIsNotRetried(t *testing.T) {
+	bus := newTestEventBus(t, eventbus.NewMemoryDriver())
+
+	var attempts atomic.Int32
+	_, err := bus.Subscribe(context.Background(), "examples", "mailer", eventbus.Typed(func(ctx context.Context, event eventbus.Envelope, data int) error {
+		attempts.Add(1)
+		return nil
+	}))
+	require.NoError(t, err)
+	dead := make(chan eventbus.Envelope, 1)
+	_, err = bus.Subscribe(context.Background(), "examples.dlq", "ops", func(ctx context.Context, event eventbus.Envelope) error {
+		dead <- event
+		return nil
+	})
+	require.NoError(t, err)
+
+	// a string does not decode into the int of the handler
+	_, err = bus.Publish(context.Background(), "examples", "example.created", "not a number")
+	require.NoError(t, err)
+
+	event := receive(t, dead)
+	assert.Equal(t, "1", event.Headers[eventbus.HeaderDeadLetterAttempts])
+	assert.Zero(t, attempts.Load())
+	assert.True(t, exception.IsPermanent(event.Decode(new(int))))
+}
+
+func TestEventBusRecoversHandlerPanic(t *testing.T) {
+	bus := newTestEventBus(t, eventbus.NewMemoryDriver())
+
+	var attempts atomic.Int32
+	handled := make(chan eventbus.Envelope, 1)
+	_, err := bus.Subscribe(context.Background(), "examples", "mailer", func(ctx context.Context, event eventbus.Envelope) error {
+		if attempts.Add(1) == 1 {
+			panic("boom")
+		}
+		handled <- event
+		return nil
+	})
+	require.NoError(t, err)
+
+	_, err = bus.Publish(context.Background(), "examples", "example.created", nil)
+	require.NoError(t, err)
+
+	receive(t, handled)
+	assert.EqualValues(t, 2, attempts.Load())
+}
+
+func TestEventBusUnsubscribe(t *testing.T) {
+	bus := newTestEventBus(t, eventbus.NewMemoryDriver())
+
+	var calls atomic.Int32
+	sub, err := bus.Subscribe(context.Background(), "examples", "mailer", func(ctx context.Context, event eventbus.Envelope) error {
+		calls.Add(1)
+		return nil
+	})
+	require.NoError(t, err)
+	require.NoError(t, sub.Unsubscribe())
+
+	_, err = bus.Publish(context.Background(), "examples", "example.created", nil)
+	require.NoError(t, err)
+	time.Sleep(20 * time.Millisecond)
+	assert.Zero(t, calls.Load())
+}
+
+func TestEventBusValidatesTopicAndGroup(t *testing.T) {
+	bus := newTestEventBus(t, eventbus.NewMemoryDriver())
+
+	_, err := bus.Publish(context.Background(), "", "example.created", nil)
+	assert.Error(t, err)
+	_, err = bus.Publish(context.Background(), "examples", "", nil)
+	assert.Error(t, err)
+	_, err = bus.Subscribe(context.Background(), "examples", "", func(ctx context.Context, event eventbus.Envelope) error { return nil })
+	assert.Error(t, err)
+}
+
+func TestEventBusNewDriverRejectsUnknownDriver(t *testing.T) {
+	_, err := eventbus.NewDriver(eventbus.Config{Driver: "rabbitmq"}, nil)
+	assert.ErrorContains(t, err, "unknown event bus driver")
+
+	_, err = eventbus.NewDriver(eventbus.Config{Driver: eventbus.DriverKafka}, nil)
+	assert.ErrorContains(t, err, "rest proxy url is empty")
+}
+
+// fakeKafkaRestProxy serves the v2 API of the REST Proxy for one consumer instance, handing
+// out each produced record once and keeping the committed offsets
+type fakeKafkaRestProxy struct {
+	mu        sync.Mutex
+	records   []map[string]any
+	committed []int64
+	deleted   bool
+}
+
+func (p *fakeKafkaRestProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var body map[string]any
+	json.NewDecoder(r.Body).Decode(&body)
+	switch {
+	case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/topics/"):
+		if r.Header.Get("Content-Type") != "application/vnd.kafka.binary.v2+json" {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+		topic := strings.TrimPrefix(r.URL.Path, "/topics/")
+		for _, record := range body["records"].([]any) {
+			record := record.(map[string]any)
+			record["topic"] = topic
+			record["partition"] = 0
+			record["offset"] = len(p.records)
+			p.records = append(p.records, record)
+		}
+		json.NewEncoder(w).Encode(map[string]any{"offsets": []map[string]any{{"partition": 0, "offset": len(p.records) - 1}}})
+	case r.Method == http.MethodPost && r.URL.Path == "/consumers/mailer":
+		json.NewEncoder(w).Encode(map[string]string{"instance_id": body["name"].(string)})
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/subscription"):
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/records"):
+		records := p.records
+		p.records = nil
+		if records == nil {
+			records = []map[string]any{}
+		}
+		json.NewEncoder(w).Encode(records)
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/offsets"):
+		for _, offset := range body["offsets"].([]any) {
+			p.committed = append(p.committed, int64(offset.(map[string]any)["offset"].(float64)))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/consumers/mailer/instances/"):
+		p.deleted = true
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]any{"error_code": 40403, "message": "Consumer instance not found."})
+	}
+}
+
+func TestEventBusKafkaDriver(t *testing.T) {
+	proxy := &fakeKafkaRestProxy{}
+	server := httptest.NewServer(proxy)
+	defer server.Close()
+
+	driver, err := eventbus.NewKafkaDriver(eventbus.KafkaConfig{
+		RestProxyURL: server.URL,
+		PollInterval: 10 * time.Millisecond,
+		Timeout:      time.Second,
+	}, server.Client(), nil)
+	require.NoError(t, err)
+	bus := newTestEventBus(t, driver)
+
+	handled := make(chan eventbus.Envelope, 1)
+	sub, err := bus.Subscribe(context.Background(), "examples", "mailer", func(ctx context.Context, event eventbus.Envelope) error {
+		handled <- event
+		return nil
+	})
+	require.NoError(t, err)
+
+	id, err := bus.Publish(context.Background(), "examples", "example.created", map[string]string{"name": "gopher"}, eventbus.WithKey("42"))
+	require.NoError(t, err)
+
+	event := receive(t, handled)
+	assert.Equal(t, id, event.ID)
+	assert.Equal(t, "42", event.Key)
+	assert.JSONEq(t, `{"name":"gopher"}`, string(event.Data))
+
+	require.NoError(t, sub.Unsubscribe())
+	proxy.mu.Lock()
+	defer proxy.mu.Unlock()
+	assert.Equal(t, []int64{0}, proxy.committed)
+	assert.True(t, proxy.deleted)
+}
+
+func TestEventBusIdempotentSkipsProcessedEvents(t *testing.T) {
+	bus := newTestEventBus(t, eventbus.NewMemoryDriver())
+	store := eventbus.NewMemoryProcessedStore(0)
+
+	var calls atomic.Int32
+	handled := make(chan eventbus.Envelope, 2)
+	for _, group := range []string{"mailer", "billing"} {
+		_, err := bus.Subscribe(context.Background(), "examples", group, eventbus.Idempotent(store, func(ctx context.Context, event eventbus.Envelope) error {
+			calls.Add(1)
+			group, _ := eventbus.GroupFromContext(ctx)
+			handled <- eventbus.Envelope{ID: event.ID, Key: group}
+			return nil
+		}))
+		require.NoError(t, err)
+	}
+
+	// an outbox message published again after its update was lost
+	event := eventbus.Envelope{ID: "0190b6a2-0000-7000-8000-000000000001", Type: "example.created", Topic: "examples", Data: json.RawMessage(`{}`)}
+	require.NoError(t, bus.PublishEnvelope(context.Background(), event))
+	groups := []string{receive(t, handled).Key, receive(t, handled).Key}
+	assert.ElementsMatch(t, []string{"mailer", "billing"}, groups)
+
+	require.NoError(t, bus.PublishEnvelope(context.Background(), event))
+	time.Sleep(20 * time.Millisecond)
+	assert.EqualValues(t, 2, calls.Load())
+
+	processed, err := store.Processed(context.Background(), "mailer", event.ID)
+	require.NoError(t, err)
+	assert.True(t, processed)
+}
+
+func TestEventBusPublishEnvelopeRequiresIDTypeAndTopic(t *testing.T) {
+	bus := newTestEventBus(t, eventbus.NewMemoryDriver())
+
+	err := bus.PublishEnvelope(context.Background(), eventbus.Envelope{Type: "example.created", Topic: "examples"})
+	assert.Error(t, err)
+	assert.True(t, exception.IsPermanent(err))
+}
+
+func TestMemoryProcessedStoreForgetsOldestEvents(t *testing.T) {
+	store := eventbus.NewMemoryProcessedStore(2)
+	ctx := context.Background()
+	for _, id := range []string{"1", "2", "3"} {
+		require.NoError(t, store.MarkProcessed(ctx, "mailer", id))
+	}
+
+	for id, want := range map[string]bool{"1": false, "2": true, "3": true} {
+		processed, err := store.Processed(ctx, "mailer", id)
+		require.NoError(t, err)
+		assert.Equal(t, want, processed, id)
+	}
+	processed, _ := store.Processed(ctx, "billing", "3")
+	assert.False(t, processed)
+}
+
+func TestOutboxEventBusSink(t *testing.T) {
+	bus := newTestEventBus(t, eventbus.NewMemoryDriver())
+	received := make(chan eventbus.Envelope, 1)
+	_, err := bus.Subscribe(context.Background(), "examples", "mailer", func(ctx context.Context, event eventbus.Envelope) error {
+		received <- event
+		return nil
+	})
+	require.NoError(t, err)
+
+	sink, err := outbox.NewSink(outbox.SinkConfig{Type: outbox.SinkEventBus}, bus)
+	require.NoError(t, err)
+	msg := outbox.Message{
+		ID:        uuid.Must(uuid.NewV7()),
+		Topic:     "examples",
+		Key:       "42",
+		Payload:   json.RawMessage(`{"name":"gopher"}`),
+		Headers:   map[string]string{outbox.HeaderEventType: "example.created", "tenant": "acme"},
+		CreatedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	require.NoError(t, sink.Publish(context.Background(), msg))
+
+	event := receive(t, received)
+	assert.Equal(t, msg.ID.String(), event.ID)
+	assert.Equal(t, "example.created", event.Type)
+	assert.Equal(t, "42", event.Key)
+	assert.Equal(t, msg.CreatedAt, event.Time)
+	assert.Equal(t, map[string]string{"tenant": "acme"}, event.Headers)
+	assert.JSONEq(t, `{"name":"gopher"}`, string(event.Data))
+
+	_, err = outbox.NewSink(outbox.SinkConfig{Type: outbox.SinkEventBus}, nil)
+	assert.Error(t, err)
+}
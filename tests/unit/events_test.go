@@ -0,0 +1,112 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/core/events"
+	"github.com/yourorg/go-api-template/core/pgdb"
+)
+
+type orderPlaced struct {
+	ID string
+}
+
+func (orderPlaced) EventName() string { return "order.placed" }
+
+type orderCancelled struct{}
+
+func (orderCancelled) EventName() string { return "order.cancelled" }
+
+func newTestDispatcher() *events.Dispatcher {
+	return events.NewDispatcher(slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestDispatcherIsolatesHandlers(t *testing.T) {
+	dispatcher := newTestDispatcher()
+
+	var calls []string
+	dispatcher.Register("order.placed", func(ctx context.Context, event events.Event) error {
+		calls = append(calls, "first")
+		return errors.New("cache unavailable")
+	})
+	dispatcher.Register("order.placed", func(ctx context.Context, event events.Event) error {
+		calls = append(calls, "second")
+		panic("boom")
+	}, events.Named("notifier"))
+	events.On(dispatcher, func(ctx context.Context, event orderPlaced) error {
+		calls = append(calls, "typed "+event.ID)
+		return nil
+	})
+	events.On(dispatcher, func(ctx context.Context, event orderCancelled) error {
+		calls = append(calls, "cancelled")
+		return nil
+	})
+
+	err := dispatcher.Dispatch(context.Background(), orderPlaced{ID: "42"})
+	assert.Equal(t, []string{"first", "second", "typed 42"}, calls, "every handler of the event runs in order")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "order.placed#1: cache unavailable")
+	assert.Contains(t, err.Error(), "notifier:")
+
+	err = dispatcher.Dispatch(context.Background(), struct{ orderCancelled }{})
+	assert.ErrorContains(t, err, "not a unit.orderCancelled", "a typed handler fails on an event of another type with its name")
+	assert.NotContains(t, calls, "cancelled")
+}
+
+func TestDispatcherAsyncHandlers(t *testing.T) {
+	dispatcher := newTestDispatcher()
+
+	release := make(chan struct{})
+	done := make(chan string, 2)
+	events.On(dispatcher, func(ctx context.Context, event orderPlaced) error {
+		<-release
+		assert.NoError(t, ctx.Err(), "the handler outlives the context of Dispatch")
+		done <- event.ID
+		return errors.New("logged only")
+	}, events.Async())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	require.NoError(t, dispatcher.Dispatch(ctx, orderPlaced{ID: "1"}), "Dispatch does not wait for the asynchronous handlers")
+	cancel()
+
+	closeCtx, closeCancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer closeCancel()
+	assert.Error(t, dispatcher.Close(closeCtx), "Close gives up once its context is done")
+
+	close(release)
+	require.NoError(t, dispatcher.Close(context.Background()))
+	assert.Equal(t, "1", <-done)
+
+	require.NoError(t, dispatcher.Dispatch(context.Background(), orderPlaced{ID: "2"}))
+	assert.Empty(t, done, "the asynchronous handlers are skipped once closed")
+}
+
+func TestDispatcherAfterCommit(t *testing.T) {
+	dispatcher := newTestDispatcher()
+	var handled []string
+	events.On(dispatcher, func(ctx context.Context, event orderPlaced) error {
+		handled = append(handled, event.ID)
+		return nil
+	})
+
+	dispatcher.DispatchAfterCommit(context.Background(), orderPlaced{ID: "now"})
+	assert.Equal(t, []string{"now"}, handled, "outside of a transaction the event is dispatched right away")
+
+	ctx, hooks := pgdb.WithCommitHooks(context.Background())
+	dispatcher.DispatchAfterCommit(ctx, orderPlaced{ID: "committed"})
+	assert.Equal(t, []string{"now"}, handled, "the event waits for the commit")
+	hooks.Run(ctx)
+	hooks.Run(ctx)
+	assert.Equal(t, []string{"now", "committed"}, handled, "the hooks run once")
+
+	ctx, _ = pgdb.WithCommitHooks(context.Background())
+	dispatcher.DispatchAfterCommit(ctx, orderPlaced{ID: "rolled back"})
+	assert.Equal(t, []string{"now", "committed"}, handled, "the events of a transaction rolled back are dropped")
+}
@@ -0,0 +1,62 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/core/events"
+	"github.com/yourorg/go-api-template/core/pgdb/pagination"
+	"github.com/yourorg/go-api-template/core/transport/httpserver"
+	"github.com/yourorg/go-api-template/internal/model"
+	"github.com/yourorg/go-api-template/internal/repository"
+	"github.com/yourorg/go-api-template/internal/service"
+)
+
+// pagedExamples serves the examples after the id of the cursor, recording the pages asked for
+type pagedExamples struct {
+	repository.ExampleRepository
+	examples []*repository.ExampleData
+	pages    []pagination.Fragment
+}
+
+func (r *pagedExamples) ListExamples(ctx context.Context, page pagination.Fragment) ([]*repository.ExampleData, error) {
+	r.pages = append(r.pages, page)
+	after, _ := page.Args["cursor_0"].(string)
+	var items []*repository.ExampleData
+	for _, example := range r.examples {
+		if example.ID > after {
+			items = append(items, example)
+		}
+	}
+	return items, nil
+}
+
+func TestListExamplesPagesWithCursors(t *testing.T) {
+	repo := &pagedExamples{examples: []*repository.ExampleData{{ID: "a", Name: "Ann"}, {ID: "b", Name: "Bob"}, {ID: "c", Name: "Cid"}}}
+	svc := service.NewExampleService(&repository.Repository{ExampleRepository: repo}, nil, nil, events.NewDispatcher(nil))
+
+	first, err := svc.ListExamples(context.Background(), &model.ListExamplesRequest{ListParams: model.ListParams{Limit: 2}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, []string{first.Data[0].ID, first.Data[1].ID})
+	assert.Nil(t, first.Total, "cursor pages are not counted")
+	require.NotEmpty(t, first.NextCursor)
+	assert.Empty(t, repo.pages[0].Where)
+	assert.Equal(t, `ORDER BY "id" ASC`, repo.pages[0].OrderBy)
+
+	next, err := svc.ListExamples(context.Background(), &model.ListExamplesRequest{ListParams: model.ListParams{Limit: 2, Cursor: first.NextCursor}})
+	require.NoError(t, err)
+	require.Len(t, next.Data, 1)
+	assert.Equal(t, "c", next.Data[0].ID)
+	assert.Empty(t, next.NextCursor)
+	assert.Equal(t, `(("id" > @cursor_0))`, repo.pages[1].Where)
+
+	sorted := model.ListParams{Sort: []httpserver.SortField{{Field: "name", Desc: true}}}
+	_, err = svc.ListExamples(context.Background(), &model.ListExamplesRequest{ListParams: sorted})
+	require.NoError(t, err)
+	assert.Equal(t, `ORDER BY "name" DESC, "id" ASC`, repo.pages[2].OrderBy)
+
+	_, err = svc.ListExamples(context.Background(), &model.ListExamplesRequest{ListParams: model.ListParams{Page: 2}})
+	assert.ErrorContains(t, err, "page", "the endpoint no longer pages with offsets")
+}
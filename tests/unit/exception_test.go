@@ -0,0 +1,200 @@
+package unit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core_config "github.com/yourorg/go-api-template/core/config"
+	"github.com/yourorg/go-api-template/core/exception"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestExceptionErrorWrapping(t *testing.T) {
+	appErrors := exception.NewMockDataServiceErrors()
+
+	err := appErrors.ErrNotFound.Wrap(sql.ErrNoRows).WithDebugMessage("product not found")
+	wrapped := fmt.Errorf("get product: %w", err)
+
+	assert.True(t, errors.Is(wrapped, appErrors.ErrNotFound))
+	assert.False(t, errors.Is(wrapped, appErrors.ErrUnauthorized))
+	assert.True(t, errors.Is(wrapped, sql.ErrNoRows), "cause must survive WithDebugMessage")
+
+	cErr, ok := exception.AsExceptionError(wrapped)
+	require.True(t, ok)
+	assert.Equal(t, "product not found", cErr.DebugMessage)
+	assert.NotEmpty(t, exception.GetStackField(cErr.StackErrors).Stack)
+
+	// the shared error definition is not modified
+	assert.Nil(t, appErrors.ErrNotFound.StackErrors)
+}
+
+func TestErrorCatalog(t *testing.T) {
+	catalog, err := exception.ParseCatalog([]byte(`
+errors:
+  - name: OutOfStock
+    code: 300000
+    httpStatus: 409
+    apiStatus: 400
+    message: "Out of stock"
+    messages:
+      th: "สินค้าหมด"
+`))
+	require.NoError(t, err)
+
+	cErr := catalog.Get("OutOfStock")
+	require.NotNil(t, cErr)
+	assert.Equal(t, int32(300000), cErr.Code)
+	assert.Equal(t, 409, cErr.HttpStatusCode)
+	assert.Equal(t, "สินค้าหมด", cErr.LocalizedMessage("th-TH"))
+	assert.Equal(t, "Out of stock", cErr.LocalizedMessage("de"))
+	assert.Nil(t, catalog.Get("Missing"))
+
+	_, err = exception.NewMockDataServiceErrorsFromCatalog(catalog)
+	assert.ErrorContains(t, err, "missing")
+}
+
+func TestErrorCatalogValidation(t *testing.T) {
+	_, err := exception.ParseCatalog([]byte(`
+errors:
+  - name: A
+    code: 1
+    httpStatus: 400
+    message: "a"
+  - name: B
+    code: 1
+    httpStatus: 999
+    message: ""
+`))
+	require.Error(t, err)
+	assert.ErrorContains(t, err, "already used")
+	assert.ErrorContains(t, err, "invalid httpStatus")
+	assert.ErrorContains(t, err, "no message")
+}
+
+func TestDefaultErrorCatalog(t *testing.T) {
+	errs := exception.NewMockDataServiceErrors()
+	assert.Equal(t, int32(200002), errs.ErrNotFound.Code)
+	assert.Equal(t, 404, errs.ErrNotFound.HttpStatusCode)
+}
+
+func TestExceptionErrorGRPCStatus(t *testing.T) {
+	appErrors := exception.NewMockDataServiceErrors()
+	cErr := appErrors.ErrNotFound.
+		WithFields([]string{"id"}).
+		WithDatas(map[string]string{"id": "42"})
+
+	assert.Equal(t, codes.NotFound, status.Code(fmt.Errorf("get product: %w", cErr)))
+
+	st := cErr.GRPCStatus()
+	assert.Equal(t, codes.NotFound, st.Code())
+	assert.Equal(t, "Not found", st.Message())
+
+	back := exception.FromGRPCError(st.Err())
+	assert.True(t, errors.Is(back, appErrors.ErrNotFound))
+	assert.Equal(t, http.StatusNotFound, back.HttpStatusCode)
+	assert.Equal(t, appErrors.ErrNotFound.APIStatusCode, back.APIStatusCode)
+	assert.Equal(t, []string{"id"}, back.ErrFields)
+	assert.Equal(t, "42", back.ErrWithDatas["id"])
+
+	unknown := exception.FromGRPCError(status.Error(codes.Unavailable, "upstream down"))
+	assert.Equal(t, http.StatusServiceUnavailable, unknown.HttpStatusCode)
+	assert.Equal(t, "upstream down", unknown.GlobalMessage)
+}
+
+func TestClassifyRetry(t *testing.T) {
+	appErrors := exception.NewMockDataServiceErrors()
+
+	tests := []struct {
+		name      string
+		err       error
+		class     exception.RetryClass
+		temporary bool
+	}{
+		{"nil", nil, exception.RetryUnknown, false},
+		{"plain", errors.New("boom"), exception.RetryUnknown, false},
+		{"canceled", context.Canceled, exception.RetryPermanent, false},
+		{"deadline", fmt.Errorf("query: %w", context.DeadlineExceeded), exception.RetryRetryable, true},
+		{"serialization failure", &pgconn.PgError{Code: "40001"}, exception.RetryRetryable, false},
+		{"connection failure", &pgconn.PgError{Code: "08006"}, exception.RetryRetryable, true},
+		{"unique violation", &pgconn.PgError{Code: "23505"}, exception.RetryPermanent, false},
+		{"redis nil", redis.Nil, exception.RetryPermanent, false},
+		{"marked retryable", exception.MarkRetryable(errors.New("boom")), exception.RetryRetryable, false},
+		{"marked permanent", exception.MarkPermanent(context.DeadlineExceeded), exception.RetryPermanent, true},
+		{"exception temporary", appErrors.ErrUnableToProceed.WithTemporary(), exception.RetryRetryable, true},
+		{"exception permanent cause retryable", appErrors.ErrNotFound.WithPermanent().Wrap(context.DeadlineExceeded), exception.RetryPermanent, true},
+		{"exception unmarked", appErrors.ErrNotFound.Wrap(&pgconn.PgError{Code: "40P01"}), exception.RetryRetryable, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.class, exception.ClassifyRetry(tt.err))
+			assert.Equal(t, tt.temporary, exception.IsTemporary(tt.err))
+		})
+	}
+
+	assert.Equal(t, exception.RetryRetryable, exception.RetryClassFromHTTPStatus(http.StatusServiceUnavailable))
+	assert.Equal(t, exception.RetryPermanent, exception.RetryClassFromHTTPStatus(http.StatusBadRequest))
+}
+
+func TestRecover(t *testing.T) {
+	run := func() (err error) {
+		defer exception.Recover(context.Background(), &err)
+		var m map[string]int
+		m["boom"] = 1
+		return nil
+	}
+
+	err := run()
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, exception.ErrPanic))
+	assert.True(t, errors.Is(err, exception.NewMockDataServiceErrors().ErrUnableToProceed))
+
+	cErr, ok := exception.AsExceptionError(err)
+	require.True(t, ok)
+	assert.Equal(t, exception.ErrPanic.Error(), cErr.DebugMessage, "panic value is hidden outside debug mode")
+	assert.Contains(t, exception.GetStackField(cErr.StackErrors).Stack, "TestRecover")
+
+	exception.SetDebug(true)
+	defer exception.SetDebug(false)
+	cErr = exception.FromPanic("bad state")
+	assert.Equal(t, "bad state", cErr.DebugMessage)
+}
+
+func TestConfigDebugEnabled(t *testing.T) {
+	assert.True(t, core_config.Config{Env: "dev", Debug: true}.DebugEnabled())
+	assert.False(t, core_config.Config{Env: "dev"}.DebugEnabled())
+	assert.False(t, core_config.Config{Env: "prd", Debug: true}.DebugEnabled())
+}
+
+func TestStackCaptureByLevel(t *testing.T) {
+	appErrors := exception.NewMockDataServiceErrors()
+	defer exception.SetStackConfig(exception.DefaultStackConfig())
+
+	cErr := appErrors.ErrNotFound.WithDebugMessage("missing")
+	assert.Contains(t, exception.GetStackField(cErr.StackErrors).Stack, "TestStackCaptureByLevel")
+
+	cErr = appErrors.ErrNotFound.WithLevel(exception.LevelWarn).WithDebugMessage("missing")
+	assert.Empty(t, exception.GetStackField(cErr.StackErrors).Stack, "no stack below error level by default")
+	assert.True(t, errors.Is(cErr, appErrors.ErrNotFound))
+
+	exception.SetStackConfig(exception.StackConfig{Error: 1, Warn: 2})
+	cErr = appErrors.ErrNotFound.WithLevel(exception.LevelWarn).Wrap(sql.ErrNoRows)
+	stack := exception.GetStackField(cErr.StackErrors).Stack
+	assert.Contains(t, stack, "TestStackCaptureByLevel")
+	assert.Len(t, strings.Split(stack, "\n"), 4, "two frames of function and file lines")
+
+	exception.SetStackConfig(exception.StackConfig{})
+	cErr = appErrors.ErrNotFound.WithDebugMessage("missing")
+	assert.Empty(t, exception.GetStackField(cErr.StackErrors).Stack, "a zero config disables capture")
+	assert.Equal(t, exception.DefaultStackConfig(), core_config.Defaults().ErrorStack, "the config file defaults to DefaultStackConfig")
+}
@@ -0,0 +1,112 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/core/fixtures"
+)
+
+type recordingExecer struct {
+	sql  []string
+	args [][]any
+	fail string
+}
+
+func (e *recordingExecer) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	if e.fail != "" && sql == e.fail {
+		return pgconn.CommandTag{}, errors.New("boom")
+	}
+	e.sql = append(e.sql, sql)
+	e.args = append(e.args, args)
+	return pgconn.CommandTag{}, nil
+}
+
+func writeFixtureSet(t *testing.T, name string, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, name), 0755))
+	for file, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(root, name, file), []byte(content), 0644))
+	}
+	return root
+}
+
+func TestFixturesLoad(t *testing.T) {
+	root := writeFixtureSet(t, "demo", map[string]string{
+		"01_users.yaml": `users:
+  - email: admin@example.com
+    roles: [admin, user]
+    is_active: true
+app.products:
+  - sku: DEMO-1
+    metadata: {color: red}
+`,
+		"02_extra.sql": "UPDATE users SET first_name = 'Demo';",
+		"README.md":    "ignored",
+	})
+
+	names, err := fixtures.List(root)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"demo"}, names)
+
+	set, err := fixtures.Load(root, "demo")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"users", "app.products"}, set.Tables)
+	require.Len(t, set.Statements, 3)
+
+	users := set.Statements[0]
+	assert.Equal(t, `INSERT INTO "users" ("email", "is_active", "roles") VALUES ($1, $2, $3)`, users.SQL)
+	assert.Equal(t, []any{"admin@example.com", true, []string{"admin", "user"}}, users.Args)
+
+	products := set.Statements[1]
+	assert.Equal(t, `INSERT INTO "app"."products" ("metadata", "sku") VALUES ($1, $2)`, products.SQL)
+	assert.Equal(t, []any{`{"color":"red"}`, "DEMO-1"}, products.Args)
+
+	assert.Equal(t, "UPDATE users SET first_name = 'Demo';", set.Statements[2].SQL)
+	assert.Empty(t, set.Statements[2].Args)
+}
+
+func TestFixturesLoadErrors(t *testing.T) {
+	root := writeFixtureSet(t, "broken", map[string]string{"01.yaml": "users: {email: a}"})
+
+	_, err := fixtures.Load(root, "missing")
+	assert.ErrorIs(t, err, fixtures.ErrUnknownSet)
+
+	_, err = fixtures.Load(root, "../broken")
+	assert.ErrorIs(t, err, fixtures.ErrUnknownSet)
+
+	_, err = fixtures.Load(root, "broken")
+	assert.ErrorContains(t, err, "expected a list of rows")
+}
+
+func TestFixturesApply(t *testing.T) {
+	root := writeFixtureSet(t, "demo", map[string]string{
+		"01_users.yaml": "users:\n  - email: a@example.com\nproducts: []\n",
+		"02_after.sql":  "SELECT 1;",
+	})
+	set, err := fixtures.Load(root, "demo")
+	require.NoError(t, err)
+
+	db := &recordingExecer{}
+	require.NoError(t, set.Apply(context.Background(), db, true))
+	assert.Equal(t, []string{
+		`TRUNCATE "users", "products" RESTART IDENTITY CASCADE`,
+		`INSERT INTO "users" ("email") VALUES ($1)`,
+		"SELECT 1;",
+	}, db.sql)
+
+	db = &recordingExecer{}
+	require.NoError(t, set.Apply(context.Background(), db, false))
+	assert.Len(t, db.sql, 2)
+
+	db = &recordingExecer{fail: "SELECT 1;"}
+	err = set.Apply(context.Background(), db, false)
+	assert.ErrorContains(t, err, "02_after.sql")
+}
@@ -0,0 +1,113 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core_config "github.com/yourorg/go-api-template/core/config"
+	"github.com/yourorg/go-api-template/core/httpclient/common"
+)
+
+func TestNewHTTPClientAuth(t *testing.T) {
+	tests := []struct {
+		name   string
+		auth   core_config.OutboundAuthConfig
+		header string
+		want   string
+	}{
+		{"bearer", core_config.OutboundAuthConfig{Type: common.AuthTypeBearer, Token: "t0k"}, "Authorization", "Bearer t0k"},
+		{"basic", core_config.OutboundAuthConfig{Type: common.AuthTypeBasic, Username: "u", Password: "p"}, "Authorization", "Basic dTpw"},
+		{"api key", core_config.OutboundAuthConfig{Type: common.AuthTypeAPIKey, Header: "api-key", Token: "k"}, "api-key", "k"},
+		{"api key default header", core_config.OutboundAuthConfig{Type: common.AuthTypeAPIKey, Token: "k"}, common.DefaultAPIKeyHeader, "k"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.Header.Get(tt.header)
+			}))
+			defer server.Close()
+
+			client, err := common.NewHTTPClient(&core_config.LMStudioConfig{Auth: tt.auth}, nil)
+			require.NoError(t, err)
+
+			resp, err := client.Get(server.URL)
+			require.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := common.NewHTTPClient(&core_config.LMStudioConfig{Auth: core_config.OutboundAuthConfig{Type: "kerberos"}}, nil)
+	assert.Error(t, err)
+	_, err = common.NewHTTPClient(&core_config.LMStudioConfig{Auth: core_config.OutboundAuthConfig{Type: common.AuthTypeOAuth2}}, nil)
+	assert.Error(t, err)
+}
+
+func TestClientCredentialsProvider(t *testing.T) {
+	var issued atomic.Int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+		assert.Equal(t, "read write", r.PostForm.Get("scope"))
+		id, secret, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "id", id)
+		assert.Equal(t, "secret", secret)
+
+		n := issued.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"Bearer","expires_in":3600}`, n)
+	}))
+	defer tokenServer.Close()
+
+	// the api rejects the first token to force a refresh
+	var seen []string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		seen = append(seen, auth)
+		if auth == "Bearer token-1" && len(seen) > 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer api.Close()
+
+	client, err := common.NewHTTPClient(&core_config.LMStudioConfig{Auth: core_config.OutboundAuthConfig{
+		Type:         common.AuthTypeOAuth2,
+		TokenURL:     tokenServer.URL,
+		ClientID:     "id",
+		ClientSecret: "secret",
+		Scopes:       []string{"read", "write"},
+	}}, nil)
+	require.NoError(t, err)
+
+	for range 3 {
+		resp, err := client.Get(api.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	// cached for the second call, refreshed after the 401
+	assert.Equal(t, []string{"Bearer token-1", "Bearer token-1", "Bearer token-2"}, seen)
+	assert.EqualValues(t, 2, issued.Load())
+}
+
+func TestClientCredentialsProviderError(t *testing.T) {
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+	}))
+	defer tokenServer.Close()
+
+	provider := common.NewClientCredentialsProvider(tokenServer.URL, "id", "bad", nil, nil)
+	_, err := provider.Token(context.Background())
+	require.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), "invalid_client"))
+}
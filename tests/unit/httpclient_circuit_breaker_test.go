@@ -0,0 +1,65 @@
+package unit
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/core/httpclient/common"
+)
+
+func TestCircuitBreakerTransport(t *testing.T) {
+	var healthy atomic.Bool
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	breaker := common.NewCircuitBreakerTransport(server.Client().Transport, common.CircuitBreakerPolicy{
+		FailureThreshold: 2,
+		OpenDuration:     50 * time.Millisecond,
+		HalfOpenProbes:   1,
+	}, nil)
+	client := &http.Client{Transport: breaker}
+	host := server.Listener.Addr().String()
+
+	get := func() (*http.Response, error) {
+		resp, err := client.Get(server.URL)
+		if resp != nil {
+			resp.Body.Close()
+		}
+		return resp, err
+	}
+
+	for range 2 {
+		_, err := get()
+		require.NoError(t, err)
+	}
+	assert.Equal(t, common.CircuitOpen, breaker.State(host))
+
+	_, err := get()
+	var urlErr *url.Error
+	require.True(t, errors.As(err, &urlErr))
+	assert.ErrorIs(t, err, common.ErrCircuitOpen)
+	assert.Equal(t, int32(2), calls.Load(), "open circuit fails fast without calling the host")
+
+	time.Sleep(60 * time.Millisecond)
+	healthy.Store(true)
+
+	resp, err := get()
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, common.CircuitClosed, breaker.State(host))
+}
@@ -0,0 +1,93 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/core/httpclient/common"
+	"github.com/yourorg/go-api-template/core/httpclient/completions"
+)
+
+func TestChainOrder(t *testing.T) {
+	var order []string
+	record := func(name string) common.Middleware {
+		return func(next common.Doer) common.Doer {
+			return common.DoerFunc(func(r *http.Request) (*http.Response, error) {
+				order = append(order, name)
+				return next.Do(r)
+			})
+		}
+	}
+	final := common.DoerFunc(func(r *http.Request) (*http.Response, error) {
+		order = append(order, "client")
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+	_, err := common.Chain(final, record("a"), record("b")).Do(r)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "client"}, order)
+}
+
+func TestCompletionsMiddleware(t *testing.T) {
+	injectHeader := func(next common.Doer) common.Doer {
+		return common.DoerFunc(func(r *http.Request) (*http.Response, error) {
+			r.Header.Set("X-Tenant", "acme")
+			return next.Do(r)
+		})
+	}
+	var statuses []int
+	recordStatus := func(next common.Doer) common.Doer {
+		return common.DoerFunc(func(r *http.Request) (*http.Response, error) {
+			resp, err := next.Do(r)
+			if err == nil {
+				statuses = append(statuses, resp.StatusCode)
+			}
+			return resp, err
+		})
+	}
+
+	client := newTestCompletionsClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "acme", r.Header.Get("X-Tenant"))
+		w.Write([]byte(`{"id":"1"}`))
+	}, completions.WithMiddleware(injectHeader, recordStatus))
+
+	resp, err := client.GetCompletionsService(context.Background(), completions.CompletionRequest{Model: "m"})
+	require.NoError(t, err)
+	assert.Equal(t, "1", resp.ID)
+	assert.Equal(t, []int{http.StatusOK}, statuses)
+}
+
+func TestCanonicalLoggingMiddleware(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte(`{"error":"upstream"}`))
+	}))
+	defer server.Close()
+
+	var logs bytes.Buffer
+	slogger := slog.New(slog.NewJSONHandler(&logs, nil))
+	doer := common.Chain(server.Client(), common.CanonicalLogging(*slogger, "v1/test"))
+
+	r, err := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(`{"q":1}`))
+	require.NoError(t, err)
+	resp, err := doer.Do(r)
+	require.NoError(t, err)
+
+	// logged once the body is closed
+	assert.Empty(t, logs.String())
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	assert.Equal(t, `{"error":"upstream"}`, string(body))
+	assert.Contains(t, logs.String(), `"level":"ERROR"`)
+	assert.Contains(t, logs.String(), "upstream")
+}
@@ -0,0 +1,59 @@
+package unit
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core_config "github.com/yourorg/go-api-template/core/config"
+	"github.com/yourorg/go-api-template/core/httpclient/common"
+)
+
+type uploadResponse struct {
+	ID string `json:"id"`
+}
+
+func TestDoMultipart(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseMultipartForm(1<<20))
+		assert.Equal(t, "fine-tune", r.FormValue("purpose"))
+
+		file, header, err := r.FormFile("file")
+		require.NoError(t, err)
+		defer file.Close()
+		content, _ := io.ReadAll(file)
+		assert.Equal(t, `{"prompt":"hi"}`, string(content))
+		assert.Equal(t, `train "v1".jsonl`, header.Filename)
+		assert.Equal(t, "application/jsonl", header.Header.Get("Content-Type"))
+
+		w.Write([]byte(`{"id":"file-1"}`))
+	}))
+	defer server.Close()
+
+	cfg := &core_config.LMStudioConfig{Protocol: "http", BaseUrl: strings.TrimPrefix(server.URL, "http://")}
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	resp, err := common.DoMultipart[uploadResponse, common.TransportError](context.Background(), cfg, server.Client(), "v1/files", []common.Part{
+		common.FieldPart("purpose", "fine-tune"),
+		common.FilePart("file", `train "v1".jsonl`, "application/jsonl", strings.NewReader(`{"prompt":"hi"}`)),
+	}, logger)
+	require.NoError(t, err)
+	assert.Equal(t, "file-1", resp.ID)
+}
+
+func TestNewMultipartBodyDefaultsFileContentType(t *testing.T) {
+	body, contentType := common.NewMultipartBody(common.FilePart("file", "blob.bin", "", strings.NewReader("x")))
+	defer body.Close()
+
+	assert.True(t, strings.HasPrefix(contentType, "multipart/form-data; boundary="))
+	data, err := io.ReadAll(body)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "Content-Type: application/octet-stream")
+}
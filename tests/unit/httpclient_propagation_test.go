@@ -0,0 +1,39 @@
+package unit
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/core/httpclient/completions"
+	middleware "github.com/yourorg/go-api-template/core/transport/httpserver/middlewares"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestOutboundPropagation(t *testing.T) {
+	tp := sdktrace.NewTracerProvider()
+	defer tp.Shutdown(context.Background())
+
+	ctx, span := tp.Tracer("test").Start(context.Background(), "inbound")
+	defer span.End()
+	ctx = middleware.SetRequestIDInContext(ctx, "req-123")
+
+	var traceparent, requestID string
+	client := newTestCompletionsClient(t, func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		requestID = r.Header.Get(middleware.RequestIDHeader)
+		w.Write([]byte(`{"id":"1"}`))
+	})
+
+	_, err := client.GetCompletionsService(ctx, completions.CompletionRequest{Model: "m"})
+	require.NoError(t, err)
+
+	// traceparent: version-traceid-spanid-flags
+	parts := strings.Split(traceparent, "-")
+	require.Len(t, parts, 4)
+	assert.Equal(t, span.SpanContext().TraceID().String(), parts[1])
+	assert.Equal(t, "req-123", requestID)
+}
@@ -0,0 +1,56 @@
+package unit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core_config "github.com/yourorg/go-api-template/core/config"
+	"github.com/yourorg/go-api-template/core/httpclient/common"
+)
+
+func TestNewTransport(t *testing.T) {
+	transport, err := common.NewTransport(core_config.HTTPTransportConfig{
+		MaxIdleConnsPerHost: 32,
+		TLSHandshakeTimeout: 3 * time.Second,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 32, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, 3*time.Second, transport.TLSHandshakeTimeout)
+
+	_, err = common.NewTransport(core_config.HTTPTransportConfig{ProxyURL: "::bad"})
+	assert.Error(t, err)
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, []byte("not a certificate"), 0600))
+	_, err = common.NewTransport(core_config.HTTPTransportConfig{CAFile: caFile})
+	assert.ErrorContains(t, err, "no certificates")
+}
+
+func TestNewHTTPClientUsesProxy(t *testing.T) {
+	var proxied atomic.Int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied.Add(1)
+		assert.Equal(t, "upstream.invalid", r.URL.Host)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer proxy.Close()
+
+	client, err := common.NewHTTPClient(&core_config.LMStudioConfig{
+		Transport: core_config.HTTPTransportConfig{ProxyURL: proxy.URL},
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, common.DefaultClientTimeout, client.Timeout)
+
+	resp, err := client.Get("http://upstream.invalid/health")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Equal(t, int32(1), proxied.Load())
+}
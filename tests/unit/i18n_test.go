@@ -0,0 +1,86 @@
+package unit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/core/exception"
+	"github.com/yourorg/go-api-template/core/i18n"
+)
+
+func TestBundleMatch(t *testing.T) {
+	bundle := i18n.NewBundle("en")
+	for _, locale := range []string{"en", "th", "fr-CA"} {
+		bundle.AddMessages(locale, map[string]string{})
+	}
+
+	for header, want := range map[string]string{
+		"":                            "en",
+		"th":                          "th",
+		"th-TH,en;q=0.5":              "th",
+		"de;q=0.9,th;q=0.8,fr;q=0.95": "fr-CA",
+		"EN-us":                       "en",
+		"de, *;q=0.1":                 "en",
+		"th;q=0, en-GB;q=0.3":         "en",
+		"ja":                          "en",
+	} {
+		assert.Equal(t, want, bundle.Match(header), "Accept-Language: %s", header)
+	}
+}
+
+func TestBundleTranslate(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "th.yaml"), []byte(`
+validation:
+  required: "ต้องระบุ {field}"
+errors:
+  200002: "ไม่พบ"
+`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "th-TH.yaml"), []byte(`greeting: "สวัสดีครับ"`), 0o644))
+
+	bundle, err := i18n.New(i18n.Config{DefaultLocale: "en", Dir: dir})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"en", "th", "th-TH"}, bundle.Locales())
+
+	msg, ok := bundle.Translate("th-TH", "validation.required", map[string]string{"field": "name"})
+	assert.True(t, ok)
+	assert.Equal(t, "ต้องระบุ name", msg, "the directory overrides the embedded catalog, th-TH falls back to th")
+	msg, _ = bundle.Translate("th", "validation.min.string", map[string]string{"param": "3"})
+	assert.Equal(t, "ต้องมีความยาวอย่างน้อย 3 ตัวอักษร", msg, "the embedded messages are kept")
+	msg, _ = bundle.Translate("ja", "validation.email", nil)
+	assert.Equal(t, "must be a valid email address", msg, "the default locale is the last fallback")
+	_, ok = bundle.Translate("th", "greeting", nil)
+	assert.False(t, ok, "a region does not fall back to another one")
+
+	exception.SetTranslator(bundle.ErrorMessage)
+	defer exception.SetTranslator(nil)
+	notFound := exception.NewExceptionError(400, 200002, "Not found", 404)
+	assert.Equal(t, "ไม่พบ", notFound.LocalizedMessage("th-TH"))
+	assert.Equal(t, "Not found", notFound.LocalizedMessage("en"))
+
+	_, err = i18n.New(i18n.Config{DefaultLocale: "en", Dir: filepath.Join(dir, "missing")})
+	assert.Error(t, err)
+}
+
+func TestI18nMiddleware(t *testing.T) {
+	bundle, err := i18n.New(i18n.DefaultConfig())
+	require.NoError(t, err)
+
+	var locale string
+	handler := i18n.Middleware(bundle)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		locale, _ = i18n.LocaleFromContext(r.Context())
+	}))
+	rec := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept-Language", "th-TH,th;q=0.9,en;q=0.8")
+	handler.ServeHTTP(rec, r)
+
+	assert.Equal(t, "th", locale)
+	assert.Equal(t, "th", rec.Header().Get("Content-Language"))
+	assert.Equal(t, "Accept-Language", rec.Header().Get("Vary"))
+}
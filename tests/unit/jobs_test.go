@@ -0,0 +1,275 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/core/exception"
+	"github.com/yourorg/go-api-template/core/jobs"
+)
+
+func newTestJobWorker(queue jobs.Queue) *jobs.Worker {
+	return jobs.NewWorker(jobs.Config{
+		PollInterval: 10 * time.Millisecond,
+		MaxAttempts:  3,
+		BaseBackoff:  time.Millisecond,
+		MaxBackoff:   time.Millisecond,
+		Timeout:      time.Second,
+	}, queue, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+// processAll runs the jobs of queue until none is runnable, waiting for retries
+func processAll(t *testing.T, worker *jobs.Worker, queue string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		processed, err := worker.ProcessNext(context.Background(), queue)
+		require.NoError(t, err)
+		if !processed {
+			time.Sleep(5 * time.Millisecond)
+			if processed, _ = worker.ProcessNext(context.Background(), queue); !processed {
+				return
+			}
+		}
+	}
+}
+
+func TestJobWorkerRunsHandler(t *testing.T) {
+	queue := jobs.NewMemoryQueue()
+	worker := newTestJobWorker(queue)
+
+	type payload struct {
+		Name string `json:"name"`
+	}
+	var got payload
+	worker.Handle("greet", func(ctx context.Context, job jobs.Job) error {
+		return job.Decode(&got)
+	})
+
+	id, err := jobs.Enqueue(context.Background(), queue, "greet", payload{Name: "gopher"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, id)
+
+	processed, err := worker.ProcessNext(context.Background(), jobs.DefaultQueue)
+	require.NoError(t, err)
+	assert.True(t, processed)
+	assert.Equal(t, "gopher", got.Name)
+
+	processed, err = worker.ProcessNext(context.Background(), jobs.DefaultQueue)
+	require.NoError(t, err)
+	assert.False(t, processed)
+}
+
+func TestJobWorkerRetriesWithBackoff(t *testing.T) {
+	queue := jobs.NewMemoryQueue()
+	worker := newTestJobWorker(queue)
+
+	var calls atomic.Int32
+	worker.Handle("flaky", func(ctx context.Context, job jobs.Job) error {
+		if calls.Add(1) < 3 {
+			return errors.New("temporary failure")
+		}
+		return nil
+	})
+
+	_, err := jobs.Enqueue(context.Background(), queue, "flaky", nil)
+	require.NoError(t, err)
+	processAll(t, worker, jobs.DefaultQueue)
+
+	assert.EqualValues(t, 3, calls.Load())
+	dead, err := queue.Dead(context.Background(), jobs.DefaultQueue, 0)
+	require.NoError(t, err)
+	assert.Empty(t, dead)
+}
+
+func TestJobWorkerBuriesFailedJobs(t *testing.T) {
+	queue := jobs.NewMemoryQueue()
+	worker := newTestJobWorker(queue)
+
+	var calls atomic.Int32
+	worker.Handle("failing", func(ctx context.Context, job jobs.Job) error {
+		calls.Add(1)
+		return errors.New("still failing")
+	})
+	worker.Handle("invalid", func(ctx context.Context, job jobs.Job) error {
+		return exception.MarkPermanent(errors.New("invalid payload"))
+	})
+	worker.Handle("panicking", func(ctx context.Context, job jobs.Job) error {
+		panic("boom")
+	})
+
+	ctx := context.Background()
+	_, err := jobs.Enqueue(ctx, queue, "failing", nil)
+	require.NoError(t, err)
+	_, err = jobs.Enqueue(ctx, queue, "invalid", nil)
+	require.NoError(t, err)
+	_, err = jobs.Enqueue(ctx, queue, "unknown", nil)
+	require.NoError(t, err)
+	_, err = jobs.Enqueue(ctx, queue, "panicking", nil, jobs.WithMaxAttempts(1))
+	require.NoError(t, err)
+	processAll(t, worker, jobs.DefaultQueue)
+
+	assert.EqualValues(t, 3, calls.Load(), "failing job runs maxAttempts times")
+	dead, err := queue.Dead(ctx, jobs.DefaultQueue, 0)
+	require.NoError(t, err)
+	require.Len(t, dead, 4)
+
+	byType := map[string]jobs.Job{}
+	for _, job := range dead {
+		byType[job.Type] = job
+	}
+	assert.Equal(t, 3, byType["failing"].Attempts)
+	assert.Equal(t, "still failing", byType["failing"].LastError)
+	assert.Equal(t, 1, byType["invalid"].Attempts, "permanent errors are not retried")
+	assert.Contains(t, byType["unknown"].LastError, "no handler")
+	assert.Equal(t, 1, byType["panicking"].Attempts)
+
+	limited, err := queue.Dead(ctx, jobs.DefaultQueue, 2)
+	require.NoError(t, err)
+	assert.Len(t, limited, 2)
+}
+
+func TestJobQueueDelaysJobs(t *testing.T) {
+	queue := jobs.NewMemoryQueue()
+	worker := newTestJobWorker(queue)
+
+	var ran atomic.Bool
+	worker.Handle("later", func(ctx context.Context, job jobs.Job) error {
+		ran.Store(true)
+		return nil
+	})
+
+	_, err := jobs.Enqueue(context.Background(), queue, "later", nil, jobs.OnQueue("emails"), jobs.WithDelay(50*time.Millisecond))
+	require.NoError(t, err)
+
+	processed, err := worker.ProcessNext(context.Background(), "emails")
+	require.NoError(t, err)
+	assert.False(t, processed, "delayed job is not runnable yet")
+
+	processed, err = worker.ProcessNext(context.Background(), jobs.DefaultQueue)
+	require.NoError(t, err)
+	assert.False(t, processed, "job is on its own queue")
+
+	assert.Eventually(t, func() bool {
+		processed, err := worker.ProcessNext(context.Background(), "emails")
+		return err == nil && processed
+	}, time.Second, 10*time.Millisecond)
+	assert.True(t, ran.Load())
+}
+
+func TestJobQueueReleasesExpiredLeases(t *testing.T) {
+	queue := jobs.NewMemoryQueue()
+	ctx := context.Background()
+
+	_, err := jobs.Enqueue(ctx, queue, "crash", nil)
+	require.NoError(t, err)
+
+	job, ok, err := queue.Reserve(ctx, jobs.DefaultQueue, 10*time.Millisecond)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	_, ok, err = queue.Reserve(ctx, jobs.DefaultQueue, time.Minute)
+	require.NoError(t, err)
+	assert.False(t, ok, "leased job is not handed out twice")
+
+	time.Sleep(20 * time.Millisecond)
+	again, ok, err := queue.Reserve(ctx, jobs.DefaultQueue, time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, job.ID, again.ID)
+}
+
+func TestJobWorkerRunStopsOnCancel(t *testing.T) {
+	queue := jobs.NewMemoryQueue()
+	worker := jobs.NewWorker(jobs.Config{
+		PollInterval: 10 * time.Millisecond,
+		Queues:       map[string]int{"default": 2, "reports": 1, "paused": 0},
+	}, queue, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	var done atomic.Int32
+	worker.Handle("count", func(ctx context.Context, job jobs.Job) error {
+		done.Add(1)
+		return nil
+	})
+
+	ctx := context.Background()
+	for _, name := range []string{"default", "default", "reports", "paused"} {
+		_, err := jobs.Enqueue(ctx, queue, "count", nil, jobs.OnQueue(name))
+		require.NoError(t, err)
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	stopped := make(chan error, 1)
+	go func() { stopped <- worker.Run(runCtx) }()
+
+	assert.Eventually(t, func() bool { return done.Load() == 3 }, time.Second, 5*time.Millisecond)
+	cancel()
+	select {
+	case err := <-stopped:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("worker did not stop")
+	}
+	assert.EqualValues(t, 3, done.Load(), "queue without concurrency is not consumed")
+}
+
+func TestJobSchedulerEnqueuesEveryInterval(t *testing.T) {
+	queue := jobs.NewMemoryQueue()
+	scheduler, err := jobs.NewScheduler(queue, []jobs.Schedule{
+		{Name: "report", Type: "report", Queue: "reports", Every: 10 * time.Millisecond, Payload: map[string]any{"kind": "daily"}},
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan error, 1)
+	go func() { stopped <- scheduler.Run(ctx) }()
+
+	var reserved []jobs.Job
+	assert.Eventually(t, func() bool {
+		job, ok, err := queue.Reserve(context.Background(), "reports", time.Minute)
+		require.NoError(t, err)
+		if ok {
+			reserved = append(reserved, job)
+		}
+		return len(reserved) >= 2
+	}, time.Second, 5*time.Millisecond)
+	cancel()
+	select {
+	case err := <-stopped:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("scheduler did not stop")
+	}
+
+	assert.Equal(t, "report", reserved[0].Type)
+	var payload map[string]string
+	require.NoError(t, reserved[0].Decode(&payload))
+	assert.Equal(t, "daily", payload["kind"])
+	assert.NotEqual(t, reserved[0].ID, reserved[1].ID)
+}
+
+func TestJobSchedulerRejectsInvalidSchedules(t *testing.T) {
+	queue := jobs.NewMemoryQueue()
+
+	_, err := jobs.NewScheduler(queue, []jobs.Schedule{{Name: "nightly", Every: time.Hour}}, nil)
+	assert.ErrorContains(t, err, "no job type")
+
+	_, err = jobs.NewScheduler(queue, []jobs.Schedule{{Name: "nightly", Type: "report"}}, nil)
+	assert.ErrorContains(t, err, "nightly: every must be positive")
+
+	scheduler, err := jobs.NewScheduler(queue, []jobs.Schedule{{Type: "report", Every: time.Hour}}, nil)
+	require.NoError(t, err)
+	id, err := scheduler.Enqueue(context.Background(), jobs.Schedule{Type: "report", Every: time.Hour})
+	require.NoError(t, err)
+	job, ok, err := queue.Reserve(context.Background(), jobs.DefaultQueue, time.Minute)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, id, job.ID)
+}
@@ -0,0 +1,105 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/core/lifecycle"
+)
+
+func TestRegistryClosesInReverseOrder(t *testing.T) {
+	reg := lifecycle.NewRegistry()
+	var closed []string
+	closer := func(name string, err error) lifecycle.CloseFunc {
+		return func(ctx context.Context) error {
+			closed = append(closed, name)
+			return err
+		}
+	}
+
+	reg.Register("postgres", closer("postgres", nil))
+	reg.Register("redis", closer("redis", errors.New("connection reset")))
+	reg.Register("tracer", closer("tracer", nil))
+
+	err := reg.Close(context.Background())
+
+	assert.Equal(t, []string{"tracer", "redis", "postgres"}, closed, "every resource is closed despite the redis error")
+	assert.ErrorContains(t, err, "close redis: connection reset")
+
+	// closing again does nothing, late registrations are closed right away
+	assert.NoError(t, reg.Close(context.Background()))
+	reg.Register("late", closer("late", nil))
+	assert.Equal(t, []string{"tracer", "redis", "postgres", "late"}, closed)
+}
+
+func TestRegistryShuttingDown(t *testing.T) {
+	reg := lifecycle.NewRegistry()
+	assert.False(t, reg.ShuttingDown())
+
+	reg.BeginShutdown()
+	assert.True(t, reg.ShuttingDown())
+}
+
+func TestSignalContext(t *testing.T) {
+	logs := &lockedBuffer{}
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(logs, nil)))
+	t.Cleanup(func() { slog.SetDefault(defaultLogger) })
+
+	exits := make(chan int, 1)
+	exit := func(code int) { exits <- code }
+
+	// a normal exit cancels the context without logging a signal
+	ctx, stop := lifecycle.SignalContext(context.Background(), exit, os.Interrupt)
+	stop()
+	<-ctx.Done()
+	assert.Empty(t, logs.String())
+
+	ctx, stop = lifecycle.SignalContext(context.Background(), exit, os.Interrupt)
+	defer stop()
+	self, err := os.FindProcess(os.Getpid())
+	require.NoError(t, err)
+
+	require.NoError(t, self.Signal(os.Interrupt))
+	select {
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("the first signal does not cancel the context")
+	}
+	assert.Contains(t, logs.String(), "Shutdown signal received")
+	assert.Empty(t, exits, "the first signal starts the graceful shutdown")
+
+	require.NoError(t, self.Signal(os.Interrupt))
+	select {
+	case code := <-exits:
+		assert.Equal(t, 1, code)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the second signal does not exit")
+	}
+}
+
+// lockedBuffer is a bytes.Buffer safe for concurrent use
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,78 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core_config "github.com/yourorg/go-api-template/core/config"
+	"github.com/yourorg/go-api-template/core/httpclient"
+	"github.com/yourorg/go-api-template/core/httpclient/completions"
+)
+
+func TestLLMProviders(t *testing.T) {
+	var got *http.Request
+	var body completions.CompletionRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"1","choices":[{"message":{"role":"assistant","content":"hi"}}]}`))
+	}))
+	defer server.Close()
+
+	endpoint := core_config.LMStudioConfig{
+		Protocol: "http",
+		BaseUrl:  strings.TrimPrefix(server.URL, "http://"),
+		Model:    "gpt-4o-mini",
+	}
+	logger := *slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	t.Run("openai", func(t *testing.T) {
+		cfg := core_config.LLMConfig{
+			Provider: "openai",
+			OpenAI:   core_config.LLMProviderConfig{LMStudioConfig: endpoint, APIKey: "sk-test"},
+		}
+		provider, err := httpclient.NewLLMProvider(cfg, &core_config.LMStudioConfig{}, logger)
+		require.NoError(t, err)
+
+		resp, err := provider.Complete(context.Background(), completions.CompletionRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, "hi", resp.Choices[0].Message.Content)
+		assert.Equal(t, "/v1/chat/completions", got.URL.Path)
+		assert.Equal(t, "Bearer sk-test", got.Header.Get("Authorization"))
+		assert.Equal(t, "gpt-4o-mini", body.Model, "falls back to the configured model")
+	})
+
+	t.Run("azure", func(t *testing.T) {
+		cfg := core_config.LLMConfig{
+			Provider: "azure",
+			Azure: core_config.LLMProviderConfig{
+				LMStudioConfig: endpoint,
+				APIKey:         "azure-key",
+				APIVersion:     "2024-06-01",
+				ModelMapping:   map[string]string{"gpt-4o-mini": "prod-gpt4o"},
+			},
+		}
+		provider, err := httpclient.NewLLMProvider(cfg, &core_config.LMStudioConfig{}, logger)
+		require.NoError(t, err)
+
+		_, err = provider.Complete(context.Background(), completions.CompletionRequest{Model: "gpt-4o-mini"})
+		require.NoError(t, err)
+		assert.Equal(t, "/openai/deployments/prod-gpt4o/chat/completions", got.URL.Path)
+		assert.Equal(t, "2024-06-01", got.URL.Query().Get("api-version"))
+		assert.Equal(t, "azure-key", got.Header.Get("api-key"))
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		_, err := httpclient.NewLLMProvider(core_config.LLMConfig{Provider: "bard"}, &endpoint, logger)
+		assert.Error(t, err)
+	})
+}
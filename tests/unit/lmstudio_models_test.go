@@ -0,0 +1,88 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core_config "github.com/yourorg/go-api-template/core/config"
+	"github.com/yourorg/go-api-template/core/health"
+	"github.com/yourorg/go-api-template/core/httpclient"
+	"github.com/yourorg/go-api-template/core/httpclient/embeddings"
+)
+
+func newTestLmStudioClient(t *testing.T, handler http.HandlerFunc) *httpclient.LmStudioServiceClient {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	cfg := &core_config.LMStudioConfig{
+		Protocol: "http",
+		BaseUrl:  strings.TrimPrefix(server.URL, "http://"),
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	return httpclient.NewLmStudioHttpClient(cfg, *logger)
+}
+
+func modelsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprint(w, `{"object":"list","data":[{"id":"qwen2.5-7b-instruct","object":"model","owned_by":"organization_owner"}]}`)
+}
+
+func TestListModels(t *testing.T) {
+	client := newTestLmStudioClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "/v1/models", r.URL.Path)
+		modelsHandler(w, r)
+	})
+
+	list, err := client.Models.ListModels(context.Background())
+	require.NoError(t, err)
+	require.Len(t, list.Data, 1)
+	assert.True(t, list.Has("qwen2.5-7b-instruct"))
+	assert.False(t, list.Has("llama"))
+}
+
+func TestGetEmbeddings(t *testing.T) {
+	client := newTestLmStudioClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/v1/embeddings", r.URL.Path)
+
+		var req embeddings.EmbeddingRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "nomic-embed", req.Model)
+		assert.Equal(t, []string{"a", "b"}, req.Input)
+
+		// out of order on purpose
+		fmt.Fprint(w, `{"object":"list","model":"nomic-embed","data":[
+			{"object":"embedding","index":1,"embedding":[0.3,0.4]},
+			{"object":"embedding","index":0,"embedding":[0.1,0.2]}],
+			"usage":{"prompt_tokens":2,"total_tokens":2}}`)
+	})
+
+	resp, err := client.Embeddings.GetEmbeddings(context.Background(), embeddings.EmbeddingRequest{Model: "nomic-embed", Input: []string{"a", "b"}})
+	require.NoError(t, err)
+	require.Len(t, resp.Data, 2)
+	assert.Equal(t, []float64{0.1, 0.2}, resp.Data[0].Embedding)
+	assert.Equal(t, []float64{0.3, 0.4}, resp.Data[1].Embedding)
+	assert.Equal(t, 2, resp.Usage.TotalTokens)
+}
+
+func TestModelChecker(t *testing.T) {
+	client := newTestLmStudioClient(t, modelsHandler)
+
+	assert.Equal(t, health.StatusHealthy, health.NewModelChecker(client.Models, "qwen2.5-7b-instruct").Check(context.Background()).Status)
+	assert.Equal(t, health.StatusUnhealthy, health.NewModelChecker(client.Models, "llama").Check(context.Background()).Status)
+
+	down := newTestLmStudioClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	assert.Equal(t, health.StatusUnhealthy, health.NewModelChecker(down.Models, "qwen2.5-7b-instruct").Check(context.Background()).Status)
+}
@@ -0,0 +1,102 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/core/pgdb/migration"
+)
+
+func writeMigrationDir(t *testing.T, files ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("-- "+name+"\n"), 0644))
+	}
+	return dir
+}
+
+func stepVersions(steps []migration.Step) []uint {
+	var versions []uint
+	for _, step := range steps {
+		versions = append(versions, step.Version)
+	}
+	return versions
+}
+
+func TestMigrationLoad(t *testing.T) {
+	dir := writeMigrationDir(t,
+		"010_add_index.up.sql", "010_add_index.down.sql",
+		"002_create_orders.up.sql", "002_create_orders.down.sql",
+		"001_create_users.up.sql", "001_create_users.down.sql",
+		"README.md",
+	)
+
+	migrations, err := migration.Load(dir)
+	require.NoError(t, err)
+	require.Len(t, migrations, 3)
+	assert.Equal(t, []uint{1, 2, 10}, migration.Versions(migrations))
+	assert.Equal(t, "create_users", migrations[0].Name)
+	assert.Equal(t, filepath.Join(dir, "010_add_index.down.sql"), migrations[2].DownPath)
+
+	_, err = migration.Load(writeMigrationDir(t, "initial.up.sql"))
+	assert.Error(t, err)
+}
+
+func TestMigrationPlan(t *testing.T) {
+	dir := writeMigrationDir(t,
+		"001_a.up.sql", "001_a.down.sql",
+		"002_b.up.sql", "002_b.down.sql",
+		"003_c.up.sql", "003_c.down.sql",
+	)
+	migrations, err := migration.Load(dir)
+	require.NoError(t, err)
+
+	t.Run("pending", func(t *testing.T) {
+		assert.Equal(t, []uint{1, 2, 3}, stepVersions(migration.Pending(migrations, 0)))
+		assert.Equal(t, []uint{3}, stepVersions(migration.Pending(migrations, 2)))
+		assert.Empty(t, migration.Pending(migrations, 3))
+	})
+
+	t.Run("steps", func(t *testing.T) {
+		up := migration.Steps(migrations, 1, 1)
+		assert.Equal(t, []uint{2}, stepVersions(up))
+		assert.Equal(t, migration.Up, up[0].Direction)
+		assert.Equal(t, filepath.Join(dir, "002_b.up.sql"), up[0].Path())
+
+		down := migration.Steps(migrations, 3, -2)
+		assert.Equal(t, []uint{3, 2}, stepVersions(down))
+		assert.Equal(t, filepath.Join(dir, "003_c.down.sql"), down[0].Path())
+
+		// Like golang-migrate, steps stop at the ends of the directory
+		assert.Equal(t, []uint{2, 3}, stepVersions(migration.Steps(migrations, 1, 5)))
+		assert.Equal(t, []uint{1}, stepVersions(migration.Steps(migrations, 1, -5)))
+	})
+
+	t.Run("goto", func(t *testing.T) {
+		steps, err := migration.Goto(migrations, 0, 2)
+		require.NoError(t, err)
+		assert.Equal(t, []uint{1, 2}, stepVersions(steps))
+
+		steps, err = migration.Goto(migrations, 3, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []uint{3, 2}, stepVersions(steps))
+		assert.Equal(t, migration.Down, steps[0].Direction)
+
+		steps, err = migration.Goto(migrations, 2, 2)
+		require.NoError(t, err)
+		assert.Empty(t, steps)
+
+		_, err = migration.Goto(migrations, 1, 7)
+		assert.ErrorIs(t, err, migration.ErrUnknownVersion)
+	})
+
+	t.Run("sql", func(t *testing.T) {
+		sql, err := migration.ReadSQL(migration.Pending(migrations, 0)[0])
+		require.NoError(t, err)
+		assert.Equal(t, "-- 001_a.up.sql\n", sql)
+	})
+}
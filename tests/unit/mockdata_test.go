@@ -0,0 +1,556 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	core_config "github.com/yourorg/go-api-template/core/config"
+	"github.com/yourorg/go-api-template/core/exception"
+	"github.com/yourorg/go-api-template/core/httpclient/completions"
+	"github.com/yourorg/go-api-template/core/mockdata"
+	"github.com/yourorg/go-api-template/internal/model"
+	"github.com/yourorg/go-api-template/internal/repository"
+	"github.com/yourorg/go-api-template/internal/service"
+)
+
+const (
+	usersScript = `CREATE TABLE users (
+    id SERIAL PRIMARY KEY, -- surrogate key
+    email VARCHAR(255) NOT NULL UNIQUE
+);`
+	ordersScript = `CREATE TABLE IF NOT EXISTS public.orders (
+    id BIGINT,
+    user_id INT NOT NULL REFERENCES public.users, /* the buyer */
+    note TEXT DEFAULT 'a, b',
+    CONSTRAINT orders_pk PRIMARY KEY (id)
+);`
+	orderItemsScript = `CREATE TABLE order_items (
+    order_id BIGINT,
+    "Line" INT,
+    parent_line INT,
+    price NUMERIC(10, 2) CHECK (price > 0),
+    PRIMARY KEY (order_id, "Line"),
+    FOREIGN KEY (order_id) REFERENCES orders (id) ON DELETE CASCADE,
+    FOREIGN KEY (order_id, parent_line) REFERENCES order_items (order_id, "Line")
+);`
+)
+
+func mustParseTable(t *testing.T, name string, script string) mockdata.Table {
+	t.Helper()
+	table, err := mockdata.ParseTable(name, script)
+	require.NoError(t, err)
+	return table
+}
+
+func TestParseTable(t *testing.T) {
+	users := mustParseTable(t, "users", usersScript)
+	assert.Equal(t, []string{"id", "email"}, users.Columns)
+	assert.Equal(t, []string{"id"}, users.PrimaryKey)
+	assert.Empty(t, users.References)
+
+	orders := mustParseTable(t, "orders", ordersScript)
+	assert.Equal(t, []string{"id", "user_id", "note"}, orders.Columns, "a comma in a default does not split the columns")
+	assert.Equal(t, []string{"id"}, orders.PrimaryKey)
+	assert.Equal(t, []mockdata.Reference{{Columns: []string{"user_id"}, Table: "users"}}, orders.References)
+
+	items := mustParseTable(t, "order_items", orderItemsScript)
+	assert.Equal(t, []string{"order_id", "Line", "parent_line", "price"}, items.Columns)
+	assert.Equal(t, []string{"order_id", "Line"}, items.PrimaryKey)
+	assert.Equal(t, []mockdata.Reference{
+		{Columns: []string{"order_id"}, Table: "orders", RefColumns: []string{"id"}},
+		{Columns: []string{"order_id", "parent_line"}, Table: "order_items", RefColumns: []string{"order_id", "Line"}},
+	}, items.References)
+	assert.Equal(t, []string{"orders"}, items.Parents(), "a reference to the table itself is not a parent")
+
+	_, err := mockdata.ParseTable("v", "CREATE VIEW v AS SELECT 1")
+	assert.Error(t, err)
+}
+
+func TestOrderTables(t *testing.T) {
+	users := mustParseTable(t, "users", usersScript)
+	orders := mustParseTable(t, "orders", ordersScript)
+	items := mustParseTable(t, "order_items", orderItemsScript)
+	tags := mustParseTable(t, "tags", `CREATE TABLE tags (name TEXT PRIMARY KEY)`)
+
+	ordered, err := mockdata.Order([]mockdata.Table{items, tags, orders, users})
+	require.NoError(t, err)
+	names := make([]string, len(ordered))
+	for i, table := range ordered {
+		names[i] = table.Name
+	}
+	assert.Equal(t, []string{"tags", "users", "orders", "order_items"}, names)
+
+	ordered, err = mockdata.Order([]mockdata.Table{items})
+	require.NoError(t, err, "a parent outside of the batch is ignored")
+	assert.Len(t, ordered, 1)
+
+	a := mustParseTable(t, "a", `CREATE TABLE a (id INT PRIMARY KEY, b_id INT REFERENCES b (id))`)
+	b := mustParseTable(t, "b", `CREATE TABLE b (id INT PRIMARY KEY, a_id INT REFERENCES a (id))`)
+	_, err = mockdata.Order([]mockdata.Table{users, a, b})
+	assert.ErrorIs(t, err, mockdata.ErrCycle)
+	assert.ErrorContains(t, err, "a, b")
+}
+
+func TestParseInserts(t *testing.T) {
+	sql := mockdata.ExtractSQL("Here you go:\n```sql\n" +
+		"-- users\n" +
+		"INSERT INTO users (id, email) VALUES (1, 'ann@example.com'), (2, 'o''brien@example.com');\n" +
+		"INSERT INTO \"users\" VALUES (3, lower('X, Y')) ON CONFLICT DO NOTHING;\n" +
+		"```\nEnjoy!")
+	inserts, err := mockdata.ParseInserts(sql)
+	require.NoError(t, err)
+	require.Len(t, inserts, 2)
+	assert.Equal(t, []string{"id", "email"}, inserts[0].Columns)
+	assert.Equal(t, [][]string{{"1", "'ann@example.com'"}, {"2", "'o''brien@example.com'"}}, inserts[0].Rows)
+	assert.Nil(t, inserts[1].Columns)
+	assert.Equal(t, [][]string{{"3", "lower('X, Y')"}}, inserts[1].Rows)
+
+	assert.Equal(t, [][]string{{"1"}, {"2"}, {"3"}}, mockdata.Values(inserts, "users", []string{"id", "email"}, []string{"id"}))
+
+	_, err = mockdata.ParseInserts("INSERT INTO users (id, email) VALUES (1)")
+	assert.ErrorContains(t, err, "1 values for 2 columns")
+	_, err = mockdata.ParseInserts("INSERT INTO users VALUES (1); DROP TABLE users")
+	assert.ErrorContains(t, err, "statement 2")
+}
+
+// scriptedLLM answers the prompt of a table with its SQL, recording the prompts
+type scriptedLLM struct {
+	answers map[string]string
+	prompts map[string]string
+	err     error
+}
+
+func (l *scriptedLLM) Name() string { return "scripted" }
+
+func (l *scriptedLLM) Complete(ctx context.Context, req completions.CompletionRequest) (completions.CompletionResponse, error) {
+	prompt := req.Messages[len(req.Messages)-1].Content
+	table := strings.TrimSuffix(strings.Fields(prompt)[6], ":")
+	l.prompts[table] = prompt
+	if l.err != nil {
+		return completions.CompletionResponse{}, l.err
+	}
+	return completions.CompletionResponse{
+		Choices: []completions.Choice{{Message: completions.Message{Content: "```sql\n" + l.answers[table] + "\n```"}}},
+		Usage:   completions.Usage{PromptTokens: 100, CompletionTokens: 50, TotalTokens: 150},
+	}, nil
+}
+
+func (l *scriptedLLM) CompleteStream(ctx context.Context, req completions.CompletionRequest) iter.Seq2[completions.CompletionChunk, error] {
+	return func(yield func(completions.CompletionChunk, error) bool) {}
+}
+
+func TestGeneratorBatch(t *testing.T) {
+	llm := &scriptedLLM{prompts: map[string]string{}, answers: map[string]string{
+		"users":       "INSERT INTO users (id, email) VALUES (7, 'a@example.com'), (8, 'b@example.com');",
+		"orders":      "INSERT INTO orders (id, user_id, note) VALUES (100, 7, 'x'), (101, 8, NULL);",
+		"order_items": "SELECT 1;",
+		"tags":        "INSERT INTO tags (name) VALUES ('new');",
+	}}
+	generator := mockdata.NewGenerator(llm, core_config.Defaults().MockData)
+	tables := []mockdata.Table{
+		mustParseTable(t, "order_items", orderItemsScript),
+		mustParseTable(t, "orders", ordersScript),
+		mustParseTable(t, "users", usersScript),
+	}
+	reviews := mustParseTable(t, "reviews", `CREATE TABLE reviews (item_order_id BIGINT, item_line INT, FOREIGN KEY (item_order_id, item_line) REFERENCES order_items)`)
+
+	var events []mockdata.Progress
+	for progress, err := range generator.Batch(context.Background(), append(tables, reviews), 2, nil) {
+		require.NoError(t, err)
+		events = append(events, progress)
+	}
+
+	eventNames := make([]string, len(events))
+	for i, progress := range events {
+		eventNames[i] = progress.Event + " " + progress.Table
+	}
+	assert.Equal(t, []string{
+		"batch.started ",
+		"table.started users", "table.generated users",
+		"table.started orders", "table.generated orders",
+		"table.started order_items", "table.failed order_items",
+		"table.skipped reviews",
+		"batch.completed ",
+	}, eventNames)
+	assert.Equal(t, []string{"users", "orders", "order_items", "reviews"}, events[0].Tables)
+
+	generated := events[4]
+	require.NotNil(t, generated.Result)
+	assert.Equal(t, 2, generated.Result.Rows)
+	assert.Equal(t, 2, generated.Index)
+	assert.Equal(t, 4, generated.Total)
+	assert.Equal(t, 300, generated.Usage.TotalTokens, "the usage of the batch so far")
+	assert.Contains(t, llm.prompts["orders"], "Generate 2 rows for the table orders")
+	assert.Contains(t, llm.prompts["orders"], "The column user_id references users(id) and must take one of these values: 7, 8")
+	assert.Contains(t, llm.prompts["order_items"], "The column order_id references orders(id) and must take one of these values: 100, 101")
+	assert.Contains(t, llm.prompts["order_items"], "The column order_id, parent_line references the table itself")
+
+	assert.Contains(t, events[6].Error, "not an INSERT")
+	assert.Equal(t, "parent table order_items was not generated", events[7].Error)
+	assert.Equal(t, []string{"order_items", "reviews"}, events[8].Failed)
+	assert.Equal(t, 450, events[8].Usage.TotalTokens)
+
+	llm.err = errors.New("model unavailable")
+	var batchErr error
+	for _, err := range generator.Batch(context.Background(), tables, 2, nil) {
+		if err != nil {
+			batchErr = err
+		}
+	}
+	assert.ErrorContains(t, batchErr, "model unavailable", "a failing completion ends the batch")
+}
+
+// schemaRepo serves the scripts of a map as the schema repository
+type schemaRepo map[string]string
+
+func (r schemaRepo) GetTableNames(ctx context.Context) ([]string, error) {
+	names := make([]string, 0, len(r))
+	for name := range r {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+func (r schemaRepo) GetTableScript(ctx context.Context, name string) (string, error) {
+	script, ok := r[name]
+	if !ok {
+		return "", repository.ErrSchemaNotFound
+	}
+	return script, nil
+}
+
+func TestMockDataServiceGenerateBatch(t *testing.T) {
+	llm := &scriptedLLM{prompts: map[string]string{}, answers: map[string]string{
+		"users":  "INSERT INTO users (id, email) VALUES (1, 'a@example.com');",
+		"orders": "INSERT INTO orders (id, user_id) VALUES (1, 1);",
+	}}
+	config := core_config.MockDataConfig{DefaultRows: 3, MaxRows: 10, MaxTables: 2}
+	errs := exception.NewMockDataServiceErrors()
+	svc := service.NewMockDataService(schemaRepo{"users": usersScript, "orders": ordersScript},
+		mockdata.NewGenerator(llm, config), config, errs)
+
+	batch := func(req model.GenerateMockDataBatchRequest) ([]mockdata.Progress, error) {
+		var events []mockdata.Progress
+		for progress, err := range svc.GenerateBatch(context.Background(), &req) {
+			if err != nil {
+				return events, err
+			}
+			events = append(events, progress)
+		}
+		return events, nil
+	}
+
+	events, err := batch(model.GenerateMockDataBatchRequest{Tables: []string{"orders"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"users", "orders"}, events[0].Tables, "the parents of a table are generated with it")
+	assert.Contains(t, llm.prompts["users"], "Generate 3 rows")
+
+	events, err = batch(model.GenerateMockDataBatchRequest{Tables: []string{service.AllTables}, Rows: 5})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"users", "orders"}, events[0].Tables)
+	assert.Contains(t, llm.prompts["users"], "Generate 5 rows")
+
+	_, err = batch(model.GenerateMockDataBatchRequest{Tables: []string{"invoices"}})
+	assert.ErrorIs(t, err, errs.ErrNotFound)
+	_, err = batch(model.GenerateMockDataBatchRequest{Tables: []string{"users"}, Rows: 11})
+	assert.ErrorIs(t, err, errs.ErrValidationFailed)
+	_, err = batch(model.GenerateMockDataBatchRequest{Tables: []string{"users"}, Apply: &model.MockDataApplyOptions{DryRun: true}})
+	assert.ErrorIs(t, err, errs.ErrValidationFailed, "applying is disabled by default")
+
+	config.MaxTables = 1
+	svc = service.NewMockDataService(schemaRepo{"users": usersScript, "orders": ordersScript},
+		mockdata.NewGenerator(llm, config), config, errs)
+	_, err = batch(model.GenerateMockDataBatchRequest{Tables: []string{"orders"}})
+	assert.ErrorIs(t, err, errs.ErrValidationFailed, "the parents count against the table limit")
+}
+
+func TestValidateInsert(t *testing.T) {
+	orders := mustParseTable(t, "orders", ordersScript)
+	assert.Equal(t, "public", orders.Schema)
+
+	for statement, reason := range map[string]string{
+		"INSERT INTO orders (id, user_id) VALUES (1, 7)":                                   "",
+		"INSERT INTO public.orders VALUES (1, 7, 'SELECT is fine in a string')":            "",
+		"INSERT INTO users (id) VALUES (1)":                                                "inserts into users instead of orders",
+		"INSERT INTO audit.orders (id) VALUES (1)":                                         "inserts into the schema audit",
+		"INSERT INTO orders (id, total) VALUES (1, 2)":                                     "orders has no column total",
+		"INSERT INTO orders (id, ID) VALUES (1, 2)":                                        "column id is listed twice",
+		"INSERT INTO orders VALUES (1, 7, 'x', 4)":                                         "row 1 has 4 values for the 3 columns of orders",
+		"INSERT INTO orders (id, user_id) VALUES (1, 7), (2, (SELECT max(id) FROM users))": "row 2 has the value",
+		"INSERT INTO orders VALUES (-1.5e3, NULL, 'it''s'::varchar(20)[])":                 "",
+		"INSERT INTO orders VALUES (DEFAULT, TRUE, TIMESTAMP '2024-01-31 10:00')":          "",
+		"INSERT INTO orders VALUES (1, 7, '2024-01-31'::timestamp with time zone)":         "",
+		"INSERT INTO orders (id, user_id) VALUES (1, pg_sleep(10))":                        "only literals are allowed",
+		"INSERT INTO orders (id, user_id) VALUES (nextval('orders_id_seq'), 7)":            "only literals are allowed",
+		"INSERT INTO orders (id, user_id) VALUES (1, 7 + 1)":                               "only literals are allowed",
+		"INSERT INTO orders (id, user_id) VALUES (1, '7' || current_user)":                 "only literals are allowed",
+		"INSERT INTO orders (id, user_id) VALUES (1, '7'::int(pg_sleep(1)))":               "only literals are allowed",
+		"INSERT INTO orders (id, user_id) VALUES (1, E'\\x27')":                            "only literals are allowed",
+	} {
+		insert, err := mockdata.ParseInsert(statement)
+		require.NoError(t, err)
+		err = mockdata.ValidateInsert(orders, insert)
+		if reason == "" {
+			assert.NoError(t, err, statement)
+		} else {
+			assert.ErrorContains(t, err, reason, statement)
+		}
+	}
+}
+
+// recordingSandbox rejects the statements containing fail, recording the setup of each check
+type recordingSandbox struct {
+	setups [][]string
+}
+
+func (s *recordingSandbox) Check(ctx context.Context, setup []string, statements []string) ([]error, error) {
+	s.setups = append(s.setups, setup)
+	errs := make([]error, len(statements))
+	for i, statement := range statements {
+		if strings.Contains(statement, "fail") {
+			errs[i] = errors.New("violates a check constraint")
+		}
+	}
+	return errs, nil
+}
+
+func TestGeneratorRejectsStatements(t *testing.T) {
+	llm := &scriptedLLM{prompts: map[string]string{}, answers: map[string]string{
+		"users": "INSERT INTO users (id, email) VALUES (1, 'a@example.com');\n" +
+			"DELETE FROM users;\n" +
+			"INSERT INTO users (id, email) VALUES (2, 'fail@example.com');",
+		"orders":      "INSERT INTO orders (id, user_id) VALUES (10, 1);\nINSERT INTO orders (id, total) VALUES (11, 5);",
+		"order_items": "INSERT INTO order_items (order_id, \"Line\", price) VALUES (10, 1, 9.5);",
+	}}
+	sandbox := &recordingSandbox{}
+	generator := mockdata.NewGenerator(llm, core_config.Defaults().MockData, mockdata.WithSandbox(sandbox))
+	tables := []mockdata.Table{
+		mustParseTable(t, "users", usersScript),
+		mustParseTable(t, "orders", ordersScript),
+		mustParseTable(t, "order_items", orderItemsScript),
+	}
+
+	results := map[string]*mockdata.Result{}
+	for progress, err := range generator.Batch(context.Background(), tables, 1, nil) {
+		require.NoError(t, err)
+		if progress.Event == mockdata.EventTableGenerated {
+			results[progress.Table] = progress.Result
+		}
+	}
+	require.Len(t, results, 3)
+
+	users := results["users"]
+	assert.Equal(t, "INSERT INTO users (id, email) VALUES (1, 'a@example.com');\n", users.SQL, "only the statements passing are returned")
+	assert.Equal(t, 1, users.Rows)
+	assert.Equal(t, []mockdata.Rejection{
+		{Statement: 2, SQL: "DELETE FROM users", Stage: mockdata.StageStructure, Reason: `not an INSERT ... VALUES statement: "DELETE FROM users"`},
+		{Statement: 3, SQL: "INSERT INTO users (id, email) VALUES (2, 'fail@example.com')", Stage: mockdata.StageSandbox, Reason: "violates a check constraint"},
+	}, users.Rejected)
+
+	require.Len(t, results["orders"].Rejected, 1)
+	assert.Equal(t, mockdata.StageSchema, results["orders"].Rejected[0].Stage)
+	assert.Contains(t, llm.prompts["order_items"], "must take one of these values: 10\n", "the keys of rejected statements are not offered")
+
+	assert.Equal(t, [][]string{
+		nil,
+		{`INSERT INTO "users" ("id", "email") VALUES (1, 'a@example.com')`},
+		{`INSERT INTO "users" ("id", "email") VALUES (1, 'a@example.com')`, `INSERT INTO "orders" ("id", "user_id") VALUES (10, 1)`},
+	}, sandbox.setups, "the sandbox inserts the accepted rows of the ancestors first")
+}
+
+func TestApplierStatements(t *testing.T) {
+	inserts, err := mockdata.ParseInserts("INSERT INTO public.users (id, Email) VALUES (1, 'a'), (2, 'b'), (3, 'c'); INSERT INTO users VALUES (4, 'd')")
+	require.NoError(t, err)
+	result := mockdata.Result{Table: "users", Inserts: inserts}
+
+	assert.Equal(t, []string{
+		`INSERT INTO "seed"."users" ("id", "email") VALUES (1, 'a'), (2, 'b')`,
+		`INSERT INTO "seed"."users" ("id", "email") VALUES (3, 'c')`,
+		`INSERT INTO "seed"."users" VALUES (4, 'd')`,
+	}, mockdata.NewApplier(nil, "seed", 0, "").Statements(result, 2))
+	assert.Equal(t, []string{
+		`INSERT INTO "users" ("id", "email") VALUES (1, 'a'), (2, 'b'), (3, 'c')`,
+		`INSERT INTO "users" VALUES (4, 'd')`,
+	}, mockdata.NewApplier(nil, "", 0, "").Statements(result, 0), "the schema of the statements is replaced by the one of the applier")
+}
+
+func TestApplierRestricts(t *testing.T) {
+	db := &fakeDB{}
+	app, err := mockdata.NewApplier(db, "", 30*time.Second, "mock_seeder").Begin(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"SET LOCAL standard_conforming_strings = on",
+		"SET LOCAL statement_timeout = 30000",
+		`SET LOCAL ROLE "mock_seeder"`,
+	}, db.log, "the inserts run under a timeout, as the restricted role")
+
+	// the rows of a result not validated are refused
+	result := mockdata.Result{Table: "users", Inserts: []mockdata.Insert{{Table: "users", Rows: [][]string{{"1", "pg_terminate_backend(42)"}}}}}
+	_, err = app.Apply(context.Background(), result, 0)
+	assert.ErrorContains(t, err, "only literals are applied")
+	require.NoError(t, app.Rollback(context.Background()))
+	assert.Len(t, db.log, 4, "nothing is executed")
+}
+
+// fakeTx records the statements executed and the transactions ended, failing the statements
+// containing fail; Begin creates a savepoint
+type fakeTx struct {
+	pgx.Tx
+	log   *[]string
+	fail  string
+	depth int
+}
+
+func (tx *fakeTx) Begin(ctx context.Context) (pgx.Tx, error) {
+	return &fakeTx{log: tx.log, fail: tx.fail, depth: tx.depth + 1}, nil
+}
+
+func (tx *fakeTx) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	if tx.fail != "" && strings.Contains(sql, tx.fail) {
+		return pgconn.CommandTag{}, errors.New("duplicate key value violates unique constraint")
+	}
+	*tx.log = append(*tx.log, sql)
+	return pgconn.NewCommandTag("INSERT 0 " + strconv.Itoa(strings.Count(sql, "), (")+1)), nil
+}
+
+func (tx *fakeTx) Commit(ctx context.Context) error {
+	if tx.depth == 0 {
+		*tx.log = append(*tx.log, "COMMIT")
+	}
+	return nil
+}
+
+func (tx *fakeTx) Rollback(ctx context.Context) error {
+	if tx.depth == 0 {
+		*tx.log = append(*tx.log, "ROLLBACK")
+	}
+	return nil
+}
+
+// fakeDB begins fakeTx transactions
+type fakeDB struct {
+	log  []string
+	fail string
+}
+
+func (db *fakeDB) Begin(ctx context.Context) (pgx.Tx, error) {
+	return &fakeTx{log: &db.log, fail: db.fail}, nil
+}
+
+func TestPostgresSandbox(t *testing.T) {
+	db := &fakeDB{fail: "fail"}
+	sandbox := mockdata.NewPostgresSandbox(db, mockdata.SandboxExecute, 5*time.Second)
+	errs, err := sandbox.Check(context.Background(), []string{`INSERT INTO "users" ("id") VALUES (1)`}, []string{
+		`INSERT INTO "orders" ("id", "user_id") VALUES (10, 1)`,
+		`INSERT INTO "orders" ("id", "user_id") VALUES (11, 'fail')`,
+	})
+	require.NoError(t, err)
+	require.Len(t, errs, 2)
+	assert.NoError(t, errs[0])
+	assert.Error(t, errs[1])
+	assert.Equal(t, []string{
+		"SET LOCAL standard_conforming_strings = on",
+		"SET LOCAL statement_timeout = 5000",
+		`INSERT INTO "users" ("id") VALUES (1)`,
+		`INSERT INTO "orders" ("id", "user_id") VALUES (10, 1)`,
+		"ROLLBACK",
+	}, db.log, "the statements run under a timeout, in a transaction rolled back")
+
+	// the generated SQL is rendered again, without the clauses after its values
+	llm := &scriptedLLM{prompts: map[string]string{}, answers: map[string]string{
+		"users": "INSERT INTO users (id, email) VALUES (1, 'a@example.com') ON CONFLICT (id) DO UPDATE SET email = pg_read_file('/etc/passwd') RETURNING *;",
+	}}
+	recording := &recordingStatements{}
+	generator := mockdata.NewGenerator(llm, core_config.Defaults().MockData, mockdata.WithSandbox(recording))
+	for _, err := range generator.Batch(context.Background(), []mockdata.Table{mustParseTable(t, "users", usersScript)}, 1, nil) {
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []string{`INSERT INTO "users" ("id", "email") VALUES (1, 'a@example.com')`}, recording.statements)
+}
+
+// recordingStatements accepts every statement, recording them
+type recordingStatements struct {
+	statements []string
+}
+
+func (s *recordingStatements) Check(ctx context.Context, setup []string, statements []string) ([]error, error) {
+	s.statements = append(s.statements, statements...)
+	return make([]error, len(statements)), nil
+}
+
+func TestGeneratorBatchApply(t *testing.T) {
+	llm := &scriptedLLM{prompts: map[string]string{}, answers: map[string]string{
+		"users":  "INSERT INTO users (id, email) VALUES (1, 'a@example.com'), (2, 'b@example.com');",
+		"orders": "INSERT INTO orders (id, user_id) VALUES (10, 1);",
+		"tags":   "INSERT INTO tags (name) VALUES ('new');",
+	}}
+	tables := []mockdata.Table{
+		mustParseTable(t, "users", usersScript),
+		mustParseTable(t, "orders", ordersScript),
+		mustParseTable(t, "tags", `CREATE TABLE tags (name TEXT PRIMARY KEY)`),
+	}
+	batch := func(db *fakeDB, apply *mockdata.ApplyOptions) []mockdata.Progress {
+		generator := mockdata.NewGenerator(llm, core_config.Defaults().MockData, mockdata.WithApplier(mockdata.NewApplier(db, "", 0, "")))
+		var events []mockdata.Progress
+		for progress, err := range generator.Batch(context.Background(), tables, 2, apply) {
+			require.NoError(t, err)
+			events = append(events, progress)
+		}
+		return events
+	}
+
+	const conforming = "SET LOCAL standard_conforming_strings = on"
+	db := &fakeDB{}
+	events := batch(db, &mockdata.ApplyOptions{Transaction: mockdata.TransactionBatch, BatchSize: 1})
+	completed := events[len(events)-1]
+	assert.Equal(t, int64(4), completed.Applied)
+	assert.True(t, completed.Committed)
+	assert.Equal(t, []string{
+		conforming,
+		`INSERT INTO "users" ("id", "email") VALUES (1, 'a@example.com')`,
+		`INSERT INTO "users" ("id", "email") VALUES (2, 'b@example.com')`,
+		`INSERT INTO "orders" ("id", "user_id") VALUES (10, 1)`,
+		`INSERT INTO "tags" ("name") VALUES ('new')`,
+		"COMMIT", "ROLLBACK",
+	}, db.log, "the batch is committed at once")
+	assert.Equal(t, mockdata.EventTableApplied, events[3].Event)
+	assert.Equal(t, int64(2), events[3].Applied)
+
+	db = &fakeDB{fail: `"users"`}
+	events = batch(db, &mockdata.ApplyOptions{Transaction: mockdata.TransactionBatch})
+	completed = events[len(events)-1]
+	assert.False(t, completed.Committed)
+	assert.Equal(t, "the batch was rolled back, a table failed to apply", completed.Error)
+	assert.Equal(t, []string{"users", "orders"}, completed.Failed, "the children of a table failing to apply are skipped")
+	assert.Equal(t, []string{conforming, `INSERT INTO "tags" ("name") VALUES ('new')`, "ROLLBACK"}, db.log)
+
+	db = &fakeDB{fail: `"users"`}
+	events = batch(db, &mockdata.ApplyOptions{Transaction: mockdata.TransactionTable})
+	completed = events[len(events)-1]
+	assert.True(t, completed.Committed)
+	assert.Equal(t, int64(1), completed.Applied)
+	assert.Equal(t, []string{conforming, "ROLLBACK", conforming, `INSERT INTO "tags" ("name") VALUES ('new')`, "COMMIT", "ROLLBACK"}, db.log, "each table is committed on its own")
+
+	db = &fakeDB{}
+	events = batch(db, &mockdata.ApplyOptions{Transaction: mockdata.TransactionTable, DryRun: true})
+	completed = events[len(events)-1]
+	assert.False(t, completed.Committed)
+	assert.Equal(t, int64(4), completed.Applied)
+	assert.NotContains(t, db.log, "COMMIT", "a dry run is rolled back")
+
+	generator := mockdata.NewGenerator(llm, core_config.Defaults().MockData)
+	for _, err := range generator.Batch(context.Background(), tables, 2, &mockdata.ApplyOptions{}) {
+		assert.ErrorIs(t, err, mockdata.ErrApplyNotConfigured)
+	}
+}
@@ -0,0 +1,271 @@
+package unit
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/core/exception"
+	"github.com/yourorg/go-api-template/core/jobs"
+	"github.com/yourorg/go-api-template/core/notify"
+)
+
+func testNotifyConfig() notify.Config {
+	cfg := notify.DefaultConfig()
+	cfg.Enabled = true
+	cfg.Email.Host = "smtp.example.com"
+	cfg.Email.From = "no-reply@example.com"
+	cfg.Slack.WebhookURL = "https://hooks.slack.com/services/T/B/X"
+	cfg.Routes = []notify.Route{
+		{Event: "order.shipped", Channels: []string{notify.ChannelEmail, notify.ChannelSlack, notify.ChannelWebhook}},
+		{Event: notify.AnyEvent, Channels: []string{notify.ChannelEmail}},
+	}
+	return cfg
+}
+
+func TestNotifierChannels(t *testing.T) {
+	cfg := testNotifyConfig()
+	cfg.Webhook.URL = "https://example.com/hooks"
+	store := notify.NewMemoryStore()
+	notifier := notify.NewNotifier(cfg, store, jobs.NewMemoryQueue(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx := context.Background()
+
+	channels, err := notifier.Channels(ctx, "u1", "order.shipped")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"email", "slack", "webhook"}, channels, "the route of the event")
+	channels, err = notifier.Channels(ctx, "u1", "invoice.paid")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"email"}, channels, "the route of every event")
+
+	require.NoError(t, notifier.SetPreferences(ctx, "u1", []notify.Preference{
+		{Event: notify.AnyEvent, Channel: notify.ChannelEmail, Enabled: false},
+		{Event: "order.shipped", Channel: notify.ChannelEmail, Enabled: true},
+		{Event: "order.shipped", Channel: notify.ChannelSlack, Enabled: false},
+		{Event: "invoice.paid", Channel: notify.ChannelSlack, Enabled: true},
+	}))
+	channels, err = notifier.Channels(ctx, "u1", "order.shipped")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"webhook", "email"}, channels, "the preferences of the event win over the ones of every event")
+	channels, err = notifier.Channels(ctx, "u1", "invoice.paid")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"slack"}, channels)
+	channels, err = notifier.Channels(ctx, "u2", "invoice.paid")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"email"}, channels, "the preferences are per user")
+
+	require.NoError(t, notifier.SetPreferences(ctx, "u1", []notify.Preference{{Event: "invoice.paid", Channel: notify.ChannelSlack, Enabled: false}}))
+	prefs, err := notifier.Preferences(ctx, "u1")
+	require.NoError(t, err)
+	assert.Len(t, prefs, 4, "a preference of the same event and channel is replaced")
+	assert.Contains(t, prefs, notify.Preference{Event: "invoice.paid", Channel: notify.ChannelSlack, Enabled: false})
+
+	err = notifier.SetPreferences(ctx, "u1", []notify.Preference{{Event: "order.shipped", Channel: "sms", Enabled: true}})
+	assert.ErrorIs(t, err, notify.ErrUnknownChannel)
+}
+
+// recordingChannel keeps the messages it is sent, failing with err
+type recordingChannel struct {
+	mu       sync.Mutex
+	messages []notify.Message
+	err      error
+}
+
+func (c *recordingChannel) Send(ctx context.Context, msg notify.Message) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.messages = append(c.messages, msg)
+	return c.err
+}
+
+func TestNotifierDeliversThroughJobs(t *testing.T) {
+	queue := jobs.NewMemoryQueue()
+	notifier := notify.NewNotifier(testNotifyConfig(), notify.NewMemoryStore(), queue, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ctx := context.Background()
+
+	slack := &recordingChannel{}
+	email := &recordingChannel{err: exception.MarkPermanent(errors.New("mailbox unavailable"))}
+	worker := newTestJobWorker(queue)
+	worker.Handle(notify.DeliverJob, notify.DeliveryHandler(map[string]notify.Channel{
+		notify.ChannelEmail: email,
+		notify.ChannelSlack: slack,
+	}))
+
+	channels, err := notifier.Notify(ctx, notify.Notification{Event: "order.shipped", UserID: "u1", Subject: "Shipped", Body: "On its way"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"slack"}, channels, "email is skipped without an address")
+
+	channels, err = notifier.Notify(ctx, notify.Notification{Event: "order.shipped", UserID: "u1", Email: "ann@example.com", Subject: "Shipped"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"email", "slack"}, channels)
+
+	processAll(t, worker, jobs.DefaultQueue)
+	require.Len(t, slack.messages, 2)
+	assert.Equal(t, "On its way", slack.messages[0].Body)
+	assert.Equal(t, notify.ChannelSlack, slack.messages[0].Channel)
+	require.Len(t, email.messages, 1)
+	assert.Equal(t, slack.messages[1].ID, email.messages[0].ID, "the channels of a notification share its ID")
+
+	dead, err := queue.Dead(ctx, jobs.DefaultQueue, 10)
+	require.NoError(t, err)
+	require.Len(t, dead, 1, "a permanent failure is not retried")
+	assert.Contains(t, dead[0].LastError, "mailbox unavailable")
+
+	_, err = notifier.Notify(ctx, notify.Notification{UserID: "u1"})
+	assert.Error(t, err, "the event is required")
+}
+
+func TestWebhookChannel(t *testing.T) {
+	status := http.StatusNoContent
+	var received *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	channel := notify.NewWebhookChannel(notify.WebhookConfig{URL: server.URL, Secret: "s3cret"}, server.Client())
+	msg := notify.Message{ID: "n1", Channel: notify.ChannelWebhook, Time: time.Now(), Notification: notify.Notification{
+		Event: "order.shipped", UserID: "u1", Subject: "Shipped", Data: map[string]any{"order_id": "o1"},
+	}}
+	require.NoError(t, channel.Send(context.Background(), msg))
+
+	assert.Equal(t, "n1", received.Header.Get("Idempotency-Key"))
+	assert.Equal(t, "order.shipped", received.Header.Get("X-Notification-Event"))
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(body)
+	assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), received.Header.Get("X-Signature-256"))
+	var sent notify.Message
+	require.NoError(t, json.Unmarshal(body, &sent))
+	assert.Equal(t, "o1", sent.Data["order_id"])
+
+	status = http.StatusServiceUnavailable
+	err := channel.Send(context.Background(), msg)
+	require.Error(t, err)
+	assert.False(t, exception.IsPermanent(err), "a server error is retried")
+	status = http.StatusGone
+	assert.True(t, exception.IsPermanent(channel.Send(context.Background(), msg)))
+}
+
+func TestSlackChannel(t *testing.T) {
+	var payload map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&payload)
+	}))
+	defer server.Close()
+
+	channel := notify.NewSlackChannel(notify.SlackConfig{WebhookURL: server.URL}, server.Client())
+	err := channel.Send(context.Background(), notify.Message{Notification: notify.Notification{Subject: "Order <42> shipped", Body: "Tom & Jerry"}})
+	require.NoError(t, err)
+	assert.Equal(t, "*Order &lt;42&gt; shipped*\nTom &amp; Jerry", payload["text"])
+}
+
+// fakeSMTPServer accepts one message per connection, answering 550 to the recipients of reject
+func fakeSMTPServer(t *testing.T, reject string) (string, int, <-chan string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	messages := make(chan string, 1)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+				reply("220 localhost ESMTP")
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					command := strings.ToUpper(strings.TrimSpace(line))
+					switch {
+					case strings.HasPrefix(command, "EHLO"):
+						reply("250-localhost")
+						reply("250 8BITMIME")
+					case strings.HasPrefix(command, "RCPT") && reject != "" && strings.Contains(command, strings.ToUpper(reject)):
+						reply("550 no such user")
+					case strings.HasPrefix(command, "DATA"):
+						reply("354 go ahead")
+						var data strings.Builder
+						for {
+							line, err := r.ReadString('\n')
+							if err != nil || line == ".\r\n" {
+								break
+							}
+							data.WriteString(line)
+						}
+						messages <- data.String()
+						reply("250 queued")
+					case strings.HasPrefix(command, "QUIT"):
+						reply("221 bye")
+						return
+					default:
+						reply("250 OK")
+					}
+				}
+			}()
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, messages
+}
+
+func TestEmailChannel(t *testing.T) {
+	host, port, messages := fakeSMTPServer(t, "gone@example.com")
+	channel, err := notify.NewEmailChannel(notify.EmailConfig{Host: host, Port: port, From: "Shop <no-reply@example.com>", TLS: "none", Timeout: time.Second})
+	require.NoError(t, err)
+
+	msg := notify.Message{ID: "n1", Channel: notify.ChannelEmail, Time: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), Notification: notify.Notification{
+		Event: "order.shipped", Email: "ann@example.com", Subject: "Commande expédiée", Body: "Bonjour Ann,\nvotre commande est en route.",
+	}}
+	require.NoError(t, channel.Send(context.Background(), msg))
+
+	var data string
+	select {
+	case data = <-messages:
+	case <-time.After(2 * time.Second):
+		t.Fatal("no message received")
+	}
+	assert.Contains(t, data, "From: \"Shop\" <no-reply@example.com>\r\n")
+	assert.Contains(t, data, "To: <ann@example.com>\r\n")
+	assert.Contains(t, data, "Subject: =?utf-8?q?Commande_exp=C3=A9di=C3=A9e?=\r\n")
+	assert.Contains(t, data, "Message-ID: <n1.email@example.com>\r\n")
+	assert.Contains(t, data, "Date: Fri, 02 Jan 2026 03:04:05 +0000\r\n")
+	assert.Contains(t, data, "votre commande est en route.")
+
+	msg.Email = "gone@example.com"
+	err = channel.Send(context.Background(), msg)
+	require.Error(t, err)
+	assert.True(t, exception.IsPermanent(err), "a 5xx reply is not retried")
+
+	msg.Email = "not an address"
+	assert.True(t, exception.IsPermanent(channel.Send(context.Background(), msg)))
+
+	_, err = notify.NewEmailChannel(notify.EmailConfig{Host: host, Port: port, From: strconv.Itoa(port)})
+	assert.Error(t, err, "the sender must be an address")
+}
@@ -0,0 +1,70 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/core/openapi"
+	"github.com/yourorg/go-api-template/core/transport/httpserver"
+	"github.com/yourorg/go-api-template/internal/model"
+)
+
+func TestOpenAPIBuildFromRoutes(t *testing.T) {
+	mux := http.NewServeMux()
+	r := httpserver.NewRouter(mux)
+	r.Get("/api/v1/examples/{id}", httpserver.NewTransport(&model.ExampleRequest{}, httpserver.NewEndpoint(
+		func(ctx context.Context, in *model.ExampleRequest) (*model.ExampleResponse, error) { return nil, nil })))
+	r.Post("/api/v1/examples", httpserver.NewTransport(&model.CreateExampleRequest{}, httpserver.NewEndpoint(
+		func(ctx context.Context, in *model.CreateExampleRequest) (*model.CreateExampleResponse, error) { return nil, nil })))
+	r.Get("/api/v1/examples", httpserver.NewTransport(&model.ListExamplesRequest{}, httpserver.NewEndpoint(
+		func(ctx context.Context, in *model.ListExamplesRequest) (*model.PagedResponse[model.ExampleItem], error) {
+			return nil, nil
+		})))
+	r.Get("/health/liveness", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	doc := openapi.Build(openapi.Info{Title: "test", Version: "1"}, r.Routes())
+
+	get := doc.Paths["/api/v1/examples/{id}"]["get"]
+	require.NotNil(t, get)
+	assert.Equal(t, []string{"examples"}, get.Tags)
+	require.Len(t, get.Parameters, 1)
+	assert.Equal(t, "id", get.Parameters[0].Name)
+	assert.Equal(t, "path", get.Parameters[0].In)
+	assert.Nil(t, get.RequestBody, "the only field is bound from the path")
+	assert.Equal(t, "#/components/schemas/ExampleResponse", get.Responses["200"].Content["application/json"].Schema.Ref)
+
+	post := doc.Paths["/api/v1/examples"]["post"]
+	require.NotNil(t, post)
+	require.NotNil(t, post.RequestBody)
+	assert.True(t, post.RequestBody.Required)
+	assert.Contains(t, post.Responses, "201", "the response implements httpserver.StatusCoder")
+
+	create := doc.Components.Schemas["CreateExampleRequest"]
+	require.NotNil(t, create)
+	assert.Equal(t, []string{"name"}, create.Required)
+	assert.Equal(t, 3, *create.Properties["name"].MinLength)
+	assert.Equal(t, 500, *create.Properties["description"].MaxLength)
+
+	list := doc.Paths["/api/v1/examples"]["get"]
+	require.NotNil(t, list)
+	params := map[string]string{}
+	for _, p := range list.Parameters {
+		params[p.Name] = p.In
+	}
+	assert.Equal(t, map[string]string{"page": "query", "limit": "query", "cursor": "query", "sort": "query", "filter": "query"}, params)
+	assert.Nil(t, list.RequestBody, "list parameters are bound from the query")
+	assert.Equal(t, "#/components/schemas/PagedResponse_ExampleItem", list.Responses["200"].Content["application/json"].Schema.Ref)
+
+	assert.NotNil(t, doc.Paths["/health/liveness"]["get"], "untyped handlers are listed without schemas")
+
+	rec := httptest.NewRecorder()
+	openapi.Handler(doc).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	var decoded map[string]any
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&decoded))
+	assert.Equal(t, openapi.Version, decoded["openapi"])
+}
@@ -0,0 +1,252 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/core/exception"
+	"github.com/yourorg/go-api-template/core/outbox"
+	"go.opentelemetry.io/otel"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// recordingDB records the statements executed through pgdb.DBTX
+type recordingDB struct {
+	sql  []string
+	args [][]any
+}
+
+func (db *recordingDB) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	db.sql = append(db.sql, sql)
+	db.args = append(db.args, args)
+	return pgconn.NewCommandTag("INSERT 0 1"), nil
+}
+
+func (db *recordingDB) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (db *recordingDB) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	return nil
+}
+
+func TestOutboxEnqueue(t *testing.T) {
+	db := &recordingDB{}
+	id, err := outbox.EnqueueEvent(context.Background(), db, "orders", "order.created", "42", map[string]int{"total": 10})
+	require.NoError(t, err)
+	require.Len(t, db.sql, 1)
+	assert.Contains(t, db.sql[0], "INSERT INTO "+outbox.TableName)
+
+	args := db.args[0]
+	assert.Equal(t, id, args[0])
+	assert.Equal(t, []any{"orders", "42"}, args[1:3])
+	assert.JSONEq(t, `{"total":10}`, string(args[3].([]byte)))
+	assert.JSONEq(t, `{"event-type":"order.created"}`, string(args[4].([]byte)))
+
+	_, err = outbox.Enqueue(context.Background(), db, "", "", nil, nil)
+	assert.Error(t, err, "a message needs a topic")
+	_, err = outbox.EnqueueEvent(context.Background(), db, "orders", "", "", nil)
+	assert.Error(t, err, "an event needs a type")
+	assert.Len(t, db.sql, 1)
+}
+
+func TestOutboxWebhookSink(t *testing.T) {
+	var received outbox.Message
+	var headers http.Header
+	status := http.StatusAccepted
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	sink, err := outbox.NewSink(outbox.SinkConfig{WebhookURL: server.URL, Headers: map[string]string{"Authorization": "Bearer token"}}, nil)
+	require.NoError(t, err)
+	msg := outbox.Message{ID: uuid.New(), Topic: "orders", Payload: json.RawMessage(`{"id":1}`), Headers: map[string]string{"traceparent": "00-abc"}}
+	require.NoError(t, sink.Publish(context.Background(), msg))
+
+	assert.Equal(t, msg.ID, received.ID)
+	assert.JSONEq(t, `{"id":1}`, string(received.Payload))
+	assert.Equal(t, msg.ID.String(), headers.Get("Idempotency-Key"))
+	assert.Equal(t, "orders", headers.Get("X-Outbox-Topic"))
+	assert.Equal(t, "Bearer token", headers.Get("Authorization"))
+	assert.Equal(t, "00-abc", headers.Get("Traceparent"))
+
+	status = http.StatusServiceUnavailable
+	err = sink.Publish(context.Background(), msg)
+	assert.ErrorContains(t, err, "status 503")
+	assert.False(t, exception.IsPermanent(err), "a server error is retried")
+
+	status = http.StatusUnprocessableEntity
+	err = sink.Publish(context.Background(), msg)
+	assert.True(t, exception.IsPermanent(err), "a rejected message is not retried")
+
+	_, err = outbox.NewSink(outbox.SinkConfig{Type: outbox.SinkWebhook}, nil)
+	assert.Error(t, err, "the webhook sink needs a URL")
+}
+
+// memoryOutbox is an outbox.Store keeping its messages in memory
+type memoryOutbox struct {
+	messages []*outboxRow
+}
+
+type outboxRow struct {
+	msg         outbox.Message
+	published   bool
+	dead        bool
+	lastError   string
+	nextAttempt time.Time
+}
+
+func (s *memoryOutbox) add(attempts int) *outboxRow {
+	row := &outboxRow{msg: outbox.Message{ID: uuid.New(), Topic: "orders", Attempts: attempts}}
+	s.messages = append(s.messages, row)
+	return row
+}
+
+func (s *memoryOutbox) Claim(ctx context.Context, limit int, maxAttempts int, fn func(ctx context.Context, messages []outbox.Message, outcomes outbox.Outcomes) error) error {
+	var due []outbox.Message
+	for _, row := range s.messages {
+		if !row.published && !row.dead && row.msg.Attempts < maxAttempts && !row.nextAttempt.After(time.Now()) && len(due) < limit {
+			due = append(due, row.msg)
+		}
+	}
+	return fn(ctx, due, s)
+}
+
+func (s *memoryOutbox) row(id uuid.UUID) *outboxRow {
+	for _, row := range s.messages {
+		if row.msg.ID == id {
+			return row
+		}
+	}
+	return nil
+}
+
+func (s *memoryOutbox) Published(ctx context.Context, id uuid.UUID) error {
+	row := s.row(id)
+	row.published = true
+	row.msg.Attempts++
+	return nil
+}
+
+func (s *memoryOutbox) Failed(ctx context.Context, id uuid.UUID, attempts int, lastError string, nextAttempt time.Time) error {
+	row := s.row(id)
+	row.msg.Attempts = attempts
+	row.lastError = lastError
+	row.nextAttempt = nextAttempt
+	return nil
+}
+
+func (s *memoryOutbox) Dead(ctx context.Context, id uuid.UUID, attempts int, lastError string) error {
+	row := s.row(id)
+	row.msg.Attempts = attempts
+	row.lastError = lastError
+	row.dead = true
+	return nil
+}
+
+// outboxMetrics installs, once, the meter provider the dead-letter counter of the outbox binds to
+var outboxMetrics = sync.OnceValue(func() *sdkmetric.ManualReader {
+	reader := sdkmetric.NewManualReader()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	return reader
+})
+
+// outboxDeadLetters returns the number of messages dead-lettered so far
+func outboxDeadLetters(t *testing.T) int64 {
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, outboxMetrics().Collect(context.Background(), &rm))
+	var total int64
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if sum, ok := m.Data.(metricdata.Sum[int64]); ok && m.Name == "outbox.dead_letters" {
+				for _, point := range sum.DataPoints {
+					total += point.Value
+				}
+			}
+		}
+	}
+	return total
+}
+
+func TestOutboxPollerRetries(t *testing.T) {
+	deadLettersBefore := outboxDeadLetters(t)
+	store := &memoryOutbox{}
+	failing := map[string]error{}
+	sink := outbox.SinkFunc(func(ctx context.Context, msg outbox.Message) error {
+		if msg.Topic == "panics" {
+			panic("sink bug")
+		}
+		return failing[msg.ID.String()]
+	})
+	poller := outbox.NewPoller(outbox.Config{
+		BatchSize:   10,
+		MaxAttempts: 3,
+		BaseBackoff: time.Second,
+		MaxBackoff:  4 * time.Second,
+	}, store, sink, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	published := store.add(0)
+	retried := store.add(0)
+	exhausted := store.add(2)
+	permanent := store.add(0)
+	panicking := store.add(0)
+	panicking.msg.Topic = "panics"
+	failing[retried.msg.ID.String()] = errors.New("connection refused")
+	failing[exhausted.msg.ID.String()] = errors.New("connection refused")
+	failing[permanent.msg.ID.String()] = exception.MarkPermanent(errors.New("status 400"))
+
+	before := time.Now()
+	n, err := poller.ProcessBatch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 5, n)
+
+	assert.True(t, published.published)
+	assert.Equal(t, 1, published.msg.Attempts)
+
+	assert.False(t, retried.published)
+	assert.Equal(t, 1, retried.msg.Attempts)
+	assert.Equal(t, "connection refused", retried.lastError)
+	assert.True(t, retried.nextAttempt.After(before), "the retry is delayed")
+	assert.False(t, retried.nextAttempt.After(time.Now().Add(time.Second)), "the first retry waits BaseBackoff at most")
+
+	assert.False(t, retried.dead)
+	assert.Equal(t, 3, exhausted.msg.Attempts, "the last attempt reaches MaxAttempts")
+	assert.True(t, exhausted.dead, "a message out of attempts is dead-lettered")
+	assert.Equal(t, "connection refused", exhausted.lastError)
+	assert.Equal(t, 3, permanent.msg.Attempts, "a permanent error uses up the attempts")
+	assert.True(t, permanent.dead)
+	assert.False(t, panicking.dead)
+	assert.Equal(t, 1, panicking.msg.Attempts, "a panicking sink fails the attempt")
+	assert.NotEmpty(t, panicking.lastError)
+
+	assert.Equal(t, deadLettersBefore+2, outboxDeadLetters(t), "the dead letters are counted")
+
+	// the dead letters are never claimed again, even once due
+	for _, row := range []*outboxRow{retried, exhausted, permanent, panicking} {
+		row.nextAttempt = time.Time{}
+	}
+	delete(failing, retried.msg.ID.String())
+	n, err = poller.ProcessBatch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.True(t, retried.published)
+	assert.False(t, exhausted.published)
+	assert.False(t, permanent.published)
+}
@@ -0,0 +1,82 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/core/pgdb/pagination"
+	sqllib "github.com/yourorg/go-api-template/core/pgdb/sql_lib"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	createdAt := time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)
+
+	cursor, err := pagination.EncodeCursor(createdAt, 42, "abc")
+	require.NoError(t, err)
+
+	values, err := pagination.DecodeCursor(cursor)
+	require.NoError(t, err)
+	assert.Equal(t, []any{createdAt, int64(42), "abc"}, values)
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	_, err := pagination.DecodeCursor("not-a-cursor!")
+	assert.ErrorIs(t, err, pagination.ErrInvalidCursor)
+}
+
+func TestBuildFirstPage(t *testing.T) {
+	fragment, err := pagination.Build([]pagination.Column{{Name: "created_at", Desc: true}, {Name: "id"}}, pagination.Request{Limit: 10})
+	require.NoError(t, err)
+
+	assert.Empty(t, fragment.Where)
+	assert.Equal(t, `ORDER BY "created_at" DESC, "id" ASC`, fragment.OrderBy)
+	assert.Equal(t, "LIMIT 11", fragment.Limit)
+}
+
+func TestBuildRejectsInvalidColumns(t *testing.T) {
+	for _, name := range []string{"", "id; DROP TABLE users", "created_at DESC", "a.b.c", `"id"`} {
+		_, err := pagination.Build([]pagination.Column{{Name: name}}, pagination.Request{})
+		assert.ErrorIs(t, err, sqllib.ErrInvalidIdentifier, name)
+	}
+}
+
+func TestBuildWithCursor(t *testing.T) {
+	cursor, err := pagination.EncodeCursor("2024-01-01", "id-1")
+	require.NoError(t, err)
+
+	fragment, err := pagination.Build([]pagination.Column{{Name: "p.created_at", Desc: true}, {Name: "p.id", Desc: true}}, pagination.Request{Cursor: cursor})
+	require.NoError(t, err)
+
+	assert.Equal(t, `(("p"."created_at" < @cursor_0) OR ("p"."created_at" = @cursor_0 AND "p"."id" < @cursor_1))`, fragment.Where)
+	assert.Equal(t, "2024-01-01", fragment.Args["cursor_0"])
+	assert.Equal(t, "id-1", fragment.Args["cursor_1"])
+	assert.Equal(t, "SELECT * FROM products p WHERE "+fragment.Where+` ORDER BY "p"."created_at" DESC, "p"."id" DESC LIMIT 21`, fragment.SQL("SELECT * FROM products p"))
+
+	// names differing only by their punctuation get arguments of their own
+	cursor, err = pagination.EncodeCursor("x", "y")
+	require.NoError(t, err)
+	fragment, err = pagination.Build([]pagination.Column{{Name: "a.b_c"}, {Name: "a_b.c"}}, pagination.Request{Cursor: cursor})
+	require.NoError(t, err)
+	assert.Equal(t, pgx.NamedArgs{"cursor_0": "x", "cursor_1": "y"}, fragment.Args)
+}
+
+func TestNewPage(t *testing.T) {
+	rows := []int{1, 2, 3}
+
+	page, err := pagination.NewPage(rows, 2, func(i int) []any { return []any{i} })
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, page.Items)
+	assert.True(t, page.HasMore)
+
+	values, err := pagination.DecodeCursor(page.NextCursor)
+	require.NoError(t, err)
+	assert.Equal(t, []any{int64(2)}, values)
+
+	page, err = pagination.NewPage(rows[:1], 2, func(i int) []any { return []any{i} })
+	require.NoError(t, err)
+	assert.False(t, page.HasMore)
+	assert.Empty(t, page.NextCursor)
+}
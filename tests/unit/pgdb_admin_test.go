@@ -0,0 +1,30 @@
+package unit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yourorg/go-api-template/core/pgdb"
+)
+
+func TestPostgresAdminConfig(t *testing.T) {
+	postgres := pgdb.Postgres{
+		Write: pgdb.PostgresConfig{
+			Host: "db", Port: 5432, Username: "app", Password: "app-pw", Database: "orders", Schema: "sales",
+		},
+	}
+
+	admin := postgres.AdminConfig()
+	assert.Equal(t, "db", admin.Host)
+	assert.Equal(t, "app", admin.Username, "write credentials without admin ones")
+	assert.Equal(t, "app-pw", admin.Password)
+	assert.Equal(t, "postgres", admin.Database, "maintenance database by default")
+	assert.Equal(t, "public", admin.Schema)
+
+	postgres.Admin = pgdb.PostgresAdmin{Username: "root", Password: "root-pw", Database: "template1"}
+	admin = postgres.AdminConfig()
+	assert.Equal(t, "root", admin.Username)
+	assert.Equal(t, "root-pw", admin.Password)
+	assert.Equal(t, "template1", admin.Database)
+	assert.Equal(t, "orders", postgres.Write.Database, "write is left untouched")
+}
@@ -0,0 +1,77 @@
+package unit
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/exaring/otelpgx"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/core/pgdb"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// traceQuery runs the query span hooks of a tracer configured with cfg under a recording span
+func traceQuery(t *testing.T, cfg pgdb.PostgresConfig, sql string) map[attribute.Key]attribute.Value {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	tracer := pgdb.NewTracer(cfg, otelpgx.WithTracerProvider(tp))
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "handler")
+	ctx = tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{SQL: sql, Args: []any{"alice@example.com"}})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag("UPDATE 3")})
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, attr := range spans[0].Attributes() {
+		attrs[attr.Key] = attr.Value
+	}
+	return attrs
+}
+
+func TestPgdbTracerQuerySpans(t *testing.T) {
+	attrs := traceQuery(t, pgdb.PostgresConfig{MaxStatementLength: 20}, "UPDATE users SET email = $1 WHERE id = 42")
+	assert.Equal(t, "UPDATE users SET ema...", attrs["db.query.text"].AsString())
+	assert.Equal(t, int64(3), attrs[otelpgx.RowsAffectedKey].AsInt64())
+	assert.NotContains(t, attrs, otelpgx.QueryParametersKey, "arguments need enableQueryParamsTracing")
+
+	attrs = traceQuery(t, pgdb.PostgresConfig{EnableQueryParamsTracing: true, MaxStatementLength: -1}, "SELECT 1")
+	assert.Equal(t, "SELECT 1", attrs["db.query.text"].AsString())
+	assert.Equal(t, []string{"alice@example.com"}, attrs[otelpgx.QueryParametersKey].AsStringSlice())
+
+	// the default length
+	attrs = traceQuery(t, pgdb.PostgresConfig{}, "SELECT '"+strings.Repeat("é", 2000)+"'")
+	text := attrs["db.query.text"].AsString()
+	assert.LessOrEqual(t, len(text), pgdb.DefaultMaxStatementLength+len("..."))
+	assert.True(t, strings.HasSuffix(text, "é..."), "cut on a rune boundary")
+}
+
+func TestPgdbTracerAcquireWaitTime(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	tracer := pgdb.NewTracer(pgdb.PostgresConfig{}, otelpgx.WithTracerProvider(tp))
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "handler")
+	ctx = tracer.TraceAcquireStart(ctx, nil, pgxpool.TraceAcquireStartData{})
+	tracer.TraceAcquireEnd(ctx, nil, pgxpool.TraceAcquireEndData{})
+	parent.End()
+
+	acquire := recorder.Ended()[0]
+	assert.Equal(t, "pool.acquire", acquire.Name())
+	var found bool
+	for _, attr := range acquire.Attributes() {
+		if attr.Key == pgdb.WaitTimeKey {
+			found = true
+			assert.GreaterOrEqual(t, attr.Value.AsFloat64(), 0.0)
+		}
+	}
+	assert.True(t, found)
+}
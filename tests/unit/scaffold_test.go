@@ -0,0 +1,99 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/core/scaffold"
+)
+
+func TestScaffoldResourceNames(t *testing.T) {
+	for _, name := range []string{"OrderItem", "order_item", "order-item", "orderItem"} {
+		r, err := scaffold.NewResource(name, "example.com/app")
+		require.NoError(t, err, name)
+		assert.Equal(t, "OrderItem", r.Name)
+		assert.Equal(t, "OrderItems", r.Plural)
+		assert.Equal(t, "orderItem", r.Var)
+		assert.Equal(t, "order_item", r.File)
+		assert.Equal(t, "order_items", r.Table)
+		assert.Equal(t, "order-items", r.Path)
+	}
+
+	for name, plural := range map[string]string{"Category": "categories", "Box": "boxes", "Key": "keys", "HTTPRoute": "http_routes"} {
+		r, err := scaffold.NewResource(name, "example.com/app")
+		require.NoError(t, err)
+		assert.Equal(t, plural, r.Table, name)
+	}
+
+	for _, name := range []string{"", "1item", "order.item", "ürün"} {
+		_, err := scaffold.NewResource(name, "example.com/app")
+		assert.ErrorIs(t, err, scaffold.ErrInvalidName, name)
+	}
+}
+
+func TestScaffoldGenerate(t *testing.T) {
+	r, err := scaffold.NewResource("Invoice", "example.com/app")
+	require.NoError(t, err)
+
+	files, err := scaffold.Generate(r)
+	require.NoError(t, err)
+
+	byPath := map[string]string{}
+	for _, f := range files {
+		byPath[filepath.ToSlash(f.Path)] = string(f.Content)
+	}
+	require.Contains(t, byPath, "internal/model/invoice_model.go")
+	require.Contains(t, byPath, "internal/repository/invoice_repo.go")
+	require.Contains(t, byPath, "internal/service/invoice_service.go")
+	require.Contains(t, byPath, "tests/unit/invoice_service_test.go")
+
+	assert.Contains(t, byPath["internal/model/invoice_model.go"], "validate:\"required,min=1,max=255\"")
+	assert.Contains(t, byPath["internal/repository/invoice_repo.go"], `InvoiceTableName = "invoices"`)
+	assert.Contains(t, byPath["internal/service/invoice_service.go"], `"example.com/app/internal/repository"`)
+
+	up, down, err := scaffold.Migration(r)
+	require.NoError(t, err)
+	assert.Contains(t, up, "CREATE TABLE IF NOT EXISTS invoices")
+	assert.Contains(t, down, "DROP TABLE IF EXISTS invoices")
+}
+
+func TestScaffoldRegister(t *testing.T) {
+	root := t.TempDir()
+	for _, path := range []string{"internal/repository/repository.go", "internal/service/service.go", "internal/server/route.go"} {
+		src, err := os.ReadFile(filepath.Join("../..", path))
+		require.NoError(t, err)
+		require.NoError(t, os.MkdirAll(filepath.Join(root, filepath.Dir(path)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(root, path), src, 0644))
+	}
+
+	r, err := scaffold.NewResource("Invoice", "example.com/app")
+	require.NoError(t, err)
+
+	changed, err := scaffold.Register(root, r)
+	require.NoError(t, err)
+	assert.Len(t, changed, 3)
+
+	routes, err := os.ReadFile(filepath.Join(root, "internal/server/route.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(routes), "\tv1.Patch(\"/invoices/{id}\", httpserver.NewTransport(")
+	assert.Less(t, strings.Index(string(routes), "/invoices"), strings.Index(string(routes), "+scaffold:routes"))
+
+	repo, err := os.ReadFile(filepath.Join(root, "internal/repository/repository.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(repo), "\t\tInvoiceRepository: NewInvoiceRepository(readPgPool, writePgPool),\n")
+
+	// registering again changes nothing
+	changed, err = scaffold.Register(root, r)
+	require.NoError(t, err)
+	assert.Empty(t, changed)
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, "internal/server/route.go"), []byte("package server\n"), 0644))
+	other, err := scaffold.NewResource("Payment", "example.com/app")
+	require.NoError(t, err)
+	_, err = scaffold.Register(root, other)
+	assert.ErrorContains(t, err, "+scaffold:routes")
+}
@@ -0,0 +1,66 @@
+package unit
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yourorg/go-api-template/core/slo"
+)
+
+func TestSLOBurnRate(t *testing.T) {
+	assert.InDelta(t, 1.0, slo.BurnRate(1, 1000, 0.999), 1e-9, "spending the budget exactly")
+	assert.InDelta(t, 14.4, slo.BurnRate(144, 10000, 0.999), 1e-9)
+	assert.Zero(t, slo.BurnRate(0, 1000, 0.999))
+	assert.Zero(t, slo.BurnRate(0, 0, 0.999))
+	assert.True(t, math.IsInf(slo.BurnRate(1, 10, 1), 1), "no budget to spend")
+}
+
+func TestSLOTrackerWindows(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tracker := slo.NewTracker(slo.Config{
+		Windows: []time.Duration{5 * time.Minute, time.Hour},
+		Objectives: []slo.Objective{
+			{Name: "api", PathPrefix: "/api/v1", Availability: 0.99},
+			{Name: "llm", PathPrefix: "/api/v1/llm", Latency: 0.9, LatencyThreshold: time.Second},
+		},
+	}, slo.WithClock(func() time.Time { return now }))
+
+	tracker.Record("/api/v1/examples/1", http.StatusInternalServerError, time.Millisecond)
+	tracker.Record("/api/v1/examples/2", http.StatusOK, time.Millisecond)
+	tracker.Record("/api/v1/llm/chat", http.StatusOK, 2*time.Second)
+	tracker.Record("/health", http.StatusInternalServerError, time.Millisecond)
+
+	assert.Equal(t, slo.Counts{Total: 2, Errors: 1}, tracker.Counts("api", 5*time.Minute))
+	assert.Equal(t, slo.Counts{Total: 1, Slow: 1}, tracker.Counts("llm", 5*time.Minute), "the longest prefix wins")
+
+	// the errors leave the short window first
+	now = now.Add(10 * time.Minute)
+	tracker.Record("/api/v1/examples/3", http.StatusOK, time.Millisecond)
+	short, long := tracker.Counts("api", 5*time.Minute), tracker.Counts("api", time.Hour)
+	assert.Equal(t, slo.Counts{Total: 1}, short)
+	assert.Equal(t, slo.Counts{Total: 3, Errors: 1}, long)
+	assert.InDelta(t, 100.0/3, long.BurnRate(slo.Objective{Availability: 0.99}, slo.SLIAvailability), 1e-9)
+
+	now = now.Add(2 * time.Hour)
+	assert.Equal(t, slo.Counts{}, tracker.Counts("api", time.Hour))
+}
+
+func TestSLOMiddleware(t *testing.T) {
+	tracker := slo.NewTracker(slo.Config{Objectives: []slo.Objective{{Name: "api", PathPrefix: "/api", Availability: 0.999}}})
+	handler := tracker.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/fail" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+
+	for _, path := range []string{"/api/ok", "/api/fail", "/other"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	assert.Equal(t, slo.Counts{Total: 2, Errors: 1}, tracker.Counts("api", time.Hour))
+}
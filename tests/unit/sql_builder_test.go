@@ -0,0 +1,115 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sqllib "github.com/yourorg/go-api-template/core/pgdb/sql_lib"
+)
+
+func TestSelectBuilder(t *testing.T) {
+	sql, args, err := sqllib.Select("id", "name").
+		From("products").
+		Where(
+			sqllib.Eq("is_active", true),
+			sqllib.Or(sqllib.In("category", "a", "b"), sqllib.Gt("price", 10)),
+		).
+		Where(sqllib.Not(sqllib.IsNull("sku"))).
+		OrderBy("name", sqllib.Asc).
+		Limit(10).
+		Build()
+	require.NoError(t, err)
+
+	assert.Equal(t, `SELECT "id", "name" FROM "products" WHERE "is_active" = @p1_is_active AND ("category" IN (@p2_category_0, @p2_category_1) OR "price" > @p3_price) AND NOT ("sku" IS NULL) ORDER BY "name" ASC LIMIT 10`, sql)
+	assert.Equal(t, true, args["p1_is_active"])
+	assert.Equal(t, "b", args["p2_category_1"])
+	assert.Equal(t, 10, args["p3_price"])
+}
+
+func TestSelectBuilderSameColumnTwice(t *testing.T) {
+	sql, args, err := sqllib.Select().From("products").Where(sqllib.Gte("price", 1), sqllib.Lt("price", 5)).Build()
+	require.NoError(t, err)
+
+	assert.Equal(t, `SELECT * FROM "products" WHERE "price" >= @p1_price AND "price" < @p2_price`, sql)
+	assert.Len(t, args, 2)
+}
+
+func TestUpdateAndDeleteBuilder(t *testing.T) {
+	sql, args, err := sqllib.Update("products").Set("name", "x").Set("price", 2).Where(sqllib.Eq("id", "1")).Returning("id").Build()
+	require.NoError(t, err)
+	assert.Equal(t, `UPDATE "products" SET "name" = @p1_name, "price" = @p2_price WHERE "id" = @p3_id RETURNING "id"`, sql)
+	assert.Len(t, args, 3)
+
+	sql, _, err = sqllib.DeleteFrom("products").Where(sqllib.Eq("id", "1")).Build()
+	require.NoError(t, err)
+	assert.Equal(t, `DELETE FROM "products" WHERE "id" = @p1_id`, sql)
+
+	_, _, err = sqllib.Select("id").From("products; DROP TABLE products").Build()
+	assert.ErrorIs(t, err, sqllib.ErrInvalidIdentifier)
+}
+
+func TestSoftDelete(t *testing.T) {
+	sqllib.EnableSoftDelete("products")
+	defer sqllib.DisableSoftDelete("products")
+
+	sql, _, _, err := sqllib.GenerateSelect("products", []string{"id"}, map[string]interface{}{"sku": "A"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, `SELECT "id" FROM "products" WHERE ("sku" = @sku) AND ("deleted_at" IS NULL)`, sql)
+
+	sql, _, _, err = sqllib.GenerateSelect("products", []string{"id"}, nil, nil, sqllib.WithDeleted())
+	require.NoError(t, err)
+	assert.Equal(t, `SELECT "id" FROM "products"`, sql)
+
+	sql, _, isWrite, err := sqllib.GenerateDelete("products", map[string]interface{}{"id": "1"}, nil)
+	require.NoError(t, err)
+	assert.True(t, isWrite)
+	assert.Equal(t, `UPDATE "products" SET "deleted_at" = now() WHERE ("id" = @id) AND ("deleted_at" IS NULL)`, sql)
+
+	sql, _, _, err = sqllib.GenerateDelete("products", map[string]interface{}{"id": "1"}, nil, sqllib.WithHardDelete())
+	require.NoError(t, err)
+	assert.Equal(t, `DELETE FROM "products" WHERE "id" = @id`, sql)
+
+	sql, _, err = sqllib.Select("id").From("products").Where(sqllib.Or(sqllib.Eq("a", 1), sqllib.Eq("b", 2))).Build()
+	require.NoError(t, err)
+	assert.Equal(t, `SELECT "id" FROM "products" WHERE (("a" = @p1_a OR "b" = @p2_b)) AND ("deleted_at" IS NULL)`, sql)
+
+	sql, _, err = sqllib.DeleteFrom("products").Build()
+	require.NoError(t, err)
+	assert.Equal(t, `UPDATE "products" SET "deleted_at" = now() WHERE "deleted_at" IS NULL`, sql)
+
+	sql, _, err = sqllib.DeleteFrom("products").Hard().Build()
+	require.NoError(t, err)
+	assert.Equal(t, `DELETE FROM "products"`, sql)
+}
+
+func TestGenerateCountAndExists(t *testing.T) {
+	sql, args, isWrite, err := sqllib.GenerateCount("products", sqllib.Eq("category", "a"))
+	require.NoError(t, err)
+	assert.False(t, isWrite)
+	assert.Equal(t, `SELECT COUNT(*) FROM "products" WHERE "category" = @p1_category`, sql)
+	assert.Equal(t, "a", args["p1_category"])
+
+	sqllib.EnableSoftDelete("products")
+	defer sqllib.DisableSoftDelete("products")
+
+	sql, _, _, err = sqllib.GenerateExists("products", sqllib.Eq("sku", "A"))
+	require.NoError(t, err)
+	assert.Equal(t, `SELECT EXISTS (SELECT 1 FROM "products" WHERE ("sku" = @p1_sku) AND ("deleted_at" IS NULL))`, sql)
+}
+
+func TestExecuteStreamWithoutPool(t *testing.T) {
+	type row struct {
+		ID int `db:"id"`
+	}
+
+	called := false
+	count, err := sqllib.ExecuteStream(context.Background(), "SELECT 1 AS id", nil, func(row) error {
+		called = true
+		return nil
+	})
+	require.Error(t, err)
+	assert.Equal(t, 0, *count)
+	assert.False(t, called)
+}
@@ -0,0 +1,83 @@
+package unit
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sqllib "github.com/yourorg/go-api-template/core/pgdb/sql_lib"
+)
+
+// Run `go test ./tests/unit/ -run TestSQLGenGolden -update` to rewrite the golden files
+var updateGolden = flag.Bool("update", false, "update golden files")
+
+type generated struct {
+	SQL     string        `json:"sql"`
+	Args    pgx.NamedArgs `json:"args"`
+	IsWrite bool          `json:"is_write"`
+}
+
+func TestSQLGenGolden(t *testing.T) {
+	conditions := map[string]interface{}{
+		"status":     "active",
+		"category":   []interface{}{"a", "b"},
+		"price":      sqllib.Between("price", 1, 100),
+		"deleted_at": sqllib.IsNull("deleted_at"),
+		"created_by": 42,
+	}
+	data := map[string]interface{}{
+		"name":        "Widget",
+		"sku":         "W-1",
+		"price":       9.99,
+		"description": "A widget",
+	}
+
+	cases := map[string]func() (string, pgx.NamedArgs, bool, error){
+		"select": func() (string, pgx.NamedArgs, bool, error) {
+			return sqllib.GenerateSelect("products", []string{"id", "name", "price"}, conditions, []string{"AND", "OR", "AND", "AND"},
+				sqllib.WithOrderBy("name", sqllib.Asc), sqllib.WithLimit(50))
+		},
+		"insert": func() (string, pgx.NamedArgs, bool, error) {
+			return sqllib.GenerateInsert("products", data)
+		},
+		"update": func() (string, pgx.NamedArgs, bool, error) {
+			return sqllib.GenerateUpdate("products", data, conditions, nil)
+		},
+		"delete": func() (string, pgx.NamedArgs, bool, error) {
+			return sqllib.GenerateDelete("products", conditions, nil)
+		},
+	}
+
+	for name, generate := range cases {
+		t.Run(name, func(t *testing.T) {
+			// Generate several times to catch map-order nondeterminism
+			var first []byte
+			for i := 0; i < 20; i++ {
+				sql, args, isWrite, err := generate()
+				require.NoError(t, err)
+
+				got, err := json.MarshalIndent(generated{SQL: sql, Args: args, IsWrite: isWrite}, "", "  ")
+				require.NoError(t, err)
+
+				if first == nil {
+					first = got
+				}
+				require.Equal(t, string(first), string(got), "generated SQL is not deterministic")
+			}
+
+			golden := filepath.Join("testdata", "sql_gen", name+".golden")
+			if *updateGolden {
+				require.NoError(t, os.WriteFile(golden, append(first, '\n'), 0644))
+			}
+
+			want, err := os.ReadFile(golden)
+			require.NoError(t, err)
+			assert.Equal(t, string(want), string(first)+"\n")
+		})
+	}
+}
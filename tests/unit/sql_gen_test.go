@@ -0,0 +1,136 @@
+package unit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sqllib "github.com/yourorg/go-api-template/core/pgdb/sql_lib"
+)
+
+func TestQuoteIdentifier(t *testing.T) {
+	quoted, err := sqllib.QuoteIdentifier("public.users")
+	require.NoError(t, err)
+	assert.Equal(t, `"public"."users"`, quoted)
+
+	for _, name := range []string{"", "users; DROP TABLE users", `users"`, "a.b.c", "1users", "users--"} {
+		_, err := sqllib.QuoteIdentifier(name)
+		assert.ErrorIs(t, err, sqllib.ErrInvalidIdentifier, name)
+	}
+}
+
+func TestGenerateSelectRejectsInjection(t *testing.T) {
+	_, _, _, err := sqllib.GenerateSelect("users; DROP TABLE users", []string{"id"}, nil, nil)
+	assert.ErrorIs(t, err, sqllib.ErrInvalidIdentifier)
+
+	_, _, _, err = sqllib.GenerateSelect("users", []string{"id"}, map[string]interface{}{"id": "pg_sleep()"}, nil)
+	assert.Error(t, err)
+
+	_, _, _, err = sqllib.GenerateSelect("users", []string{"id"}, map[string]interface{}{"id": 1, "email": "a"}, []string{"; DROP TABLE users"})
+	assert.Error(t, err)
+}
+
+func TestIdentifierAllowlist(t *testing.T) {
+	sqllib.SetIdentifierAllowlist(func(kind sqllib.IdentifierKind, name string) bool {
+		return kind != sqllib.IdentifierTable || name == "users"
+	})
+	defer sqllib.SetIdentifierAllowlist(nil)
+
+	sql, _, _, err := sqllib.GenerateSelect("users", []string{"id", "email"}, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, `SELECT "id", "email" FROM "users"`, sql)
+
+	_, _, _, err = sqllib.GenerateDelete("api_keys", nil, nil)
+	assert.ErrorIs(t, err, sqllib.ErrInvalidIdentifier)
+}
+
+func TestGenerateSelectWithOptions(t *testing.T) {
+	sql, args, isWrite, err := sqllib.GenerateSelect(
+		"products",
+		[]string{"category"},
+		map[string]interface{}{"is_active": true},
+		nil,
+		sqllib.WithDistinct(),
+		sqllib.WithGroupBy("category"),
+		sqllib.WithOrderBy("category", sqllib.Desc),
+		sqllib.WithLimit(10),
+		sqllib.WithOffset(20),
+	)
+	require.NoError(t, err)
+	assert.False(t, isWrite)
+	assert.Equal(t, `SELECT DISTINCT "category" FROM "products" WHERE "is_active" = @is_active GROUP BY "category" ORDER BY "category" DESC LIMIT 10 OFFSET 20`, sql)
+	assert.Equal(t, true, args["is_active"])
+
+	_, _, _, err = sqllib.GenerateSelect("products", []string{"id"}, nil, nil, sqllib.WithOrderBy("id", "DESC; DROP TABLE products"))
+	assert.Error(t, err)
+}
+
+func TestGenerateSelectConditionOperators(t *testing.T) {
+	cases := []struct {
+		name      string
+		condition sqllib.Condition
+		where     string
+		args      map[string]any
+	}{
+		{"gt", sqllib.Gt("price", 10), `"price" > @price`, map[string]any{"price": 10}},
+		{"neq", sqllib.Neq("price", 10), `"price" != @price`, map[string]any{"price": 10}},
+		{"ilike", sqllib.ILike("price", "%a%"), `"price" ILIKE @price`, map[string]any{"price": "%a%"}},
+		{"between", sqllib.Between("price", 1, 5), `"price" BETWEEN @price_from AND @price_to`, map[string]any{"price_from": 1, "price_to": 5}},
+		{"not in", sqllib.NotIn("price", 1, 2), `"price" NOT IN (@price_0, @price_1)`, map[string]any{"price_0": 1, "price_1": 2}},
+		{"is null", sqllib.IsNull("price"), `"price" IS NULL`, map[string]any{}},
+		{"is not null", sqllib.Condition{Operator: sqllib.OpIsNotNull}, `"price" IS NOT NULL`, map[string]any{}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			sql, args, _, err := sqllib.GenerateSelect("products", []string{"id"}, map[string]interface{}{"price": tc.condition}, nil)
+			require.NoError(t, err)
+			assert.Equal(t, `SELECT "id" FROM "products" WHERE `+tc.where, sql)
+			assert.Equal(t, len(tc.args), len(args))
+			for k, v := range tc.args {
+				assert.Equal(t, v, args[k])
+			}
+		})
+	}
+
+	_, _, _, err := sqllib.GenerateSelect("products", []string{"id"}, map[string]interface{}{"price": sqllib.Condition{Operator: "; DROP"}}, nil)
+	assert.Error(t, err)
+}
+
+func TestGenerateInsertUpsertReturning(t *testing.T) {
+	data := map[string]interface{}{"sku": "W-1", "name": "Widget", "price": 10}
+
+	sql, _, _, err := sqllib.GenerateInsert("products", data,
+		sqllib.WithOnConflictDoUpdate([]string{"sku"}),
+		sqllib.WithReturning("id", "created_at"),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, `INSERT INTO "products" ("name", "price", "sku") VALUES (@name, @price, @sku) ON CONFLICT ("sku") DO UPDATE SET "name" = EXCLUDED."name", "price" = EXCLUDED."price" RETURNING "id", "created_at"`, sql)
+
+	sql, _, _, err = sqllib.GenerateInsert("products", data, sqllib.WithOnConflictDoNothing("sku"))
+	require.NoError(t, err)
+	assert.Equal(t, `INSERT INTO "products" ("name", "price", "sku") VALUES (@name, @price, @sku) ON CONFLICT ("sku") DO NOTHING`, sql)
+
+	_, _, _, err = sqllib.GenerateInsert("products", data, sqllib.WithOnConflictDoUpdate(nil))
+	assert.Error(t, err)
+}
+
+func TestGenerateBatchInsert(t *testing.T) {
+	rows := []map[string]any{
+		{"name": "a", "sku": "A"},
+		{"name": "b", "sku": "B", "price": 2},
+		{"name": "c", "sku": "C"},
+	}
+
+	statements, err := sqllib.GenerateBatchInsert("products", rows, sqllib.WithBatchSize(2), sqllib.WithOnConflictDoNothing("sku"))
+	require.NoError(t, err)
+	require.Len(t, statements, 2)
+
+	assert.Equal(t, `INSERT INTO "products" ("name", "price", "sku") VALUES (@name_0, DEFAULT, @sku_0), (@name_1, @price_1, @sku_1) ON CONFLICT ("sku") DO NOTHING`, statements[0].SQL)
+	assert.Equal(t, 2, statements[0].Args["price_1"])
+	assert.Equal(t, `INSERT INTO "products" ("name", "price", "sku") VALUES (@name_0, DEFAULT, @sku_0) ON CONFLICT ("sku") DO NOTHING`, statements[1].SQL)
+	assert.Equal(t, "C", statements[1].Args["sku_0"])
+
+	_, err = sqllib.GenerateBatchInsert("products", nil)
+	assert.Error(t, err)
+}
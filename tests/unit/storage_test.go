@@ -0,0 +1,292 @@
+package unit
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/core/storage"
+)
+
+// pngHeader is the signature of a PNG file, enough for content sniffing
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestLocalStorage(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewLocalStorage(storage.LocalConfig{Root: t.TempDir(), BaseURL: "http://localhost/storage", SigningKey: "secret"})
+	require.NoError(t, err)
+
+	object, err := store.Put(ctx, "avatars/42.bin", strings.NewReader(string(pngHeader)))
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", object.ContentType, "sniffed from the content")
+	assert.Equal(t, int64(len(pngHeader)), object.Size)
+
+	object, err = store.Put(ctx, "data/report.json", strings.NewReader(`{"ok":true}`))
+	require.NoError(t, err)
+	assert.Equal(t, "application/json", object.ContentType, "text is typed by the extension")
+
+	body, object, err := store.Get(ctx, "data/report.json")
+	require.NoError(t, err)
+	content, _ := io.ReadAll(body)
+	body.Close()
+	assert.Equal(t, `{"ok":true}`, string(content))
+	assert.Equal(t, int64(11), object.Size)
+
+	require.NoError(t, store.Delete(ctx, "data/report.json"))
+	require.NoError(t, store.Delete(ctx, "data/report.json"), "deleting a missing object succeeds")
+	_, err = store.Stat(ctx, "data/report.json")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	for _, key := range []string{"", "/etc/passwd", "a/../../b", "a//b", `a\b`} {
+		_, err := store.Put(ctx, key, strings.NewReader("x"))
+		assert.ErrorIs(t, err, storage.ErrInvalidKey, key)
+	}
+}
+
+func TestLocalStorageSignedURL(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewLocalStorage(storage.LocalConfig{Root: t.TempDir(), BaseURL: "http://localhost/storage", SigningKey: "secret"})
+	require.NoError(t, err)
+	server := httptest.NewServer(http.StripPrefix("/storage", store.Handler()))
+	defer server.Close()
+	local := func(signed string) string {
+		return strings.Replace(signed, "http://localhost", server.URL, 1)
+	}
+
+	putURL, err := store.SignedURL(ctx, "docs/a b.txt", http.MethodPut, time.Minute)
+	require.NoError(t, err)
+	req, _ := http.NewRequest(http.MethodPut, local(putURL), strings.NewReader("hello"))
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	getURL, err := store.SignedURL(ctx, "docs/a b.txt", http.MethodGet, time.Minute)
+	require.NoError(t, err)
+	resp, err = http.Get(local(getURL))
+	require.NoError(t, err)
+	content, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "hello", string(content))
+	assert.Equal(t, "text/plain; charset=utf-8", resp.Header.Get("Content-Type"))
+
+	resp, err = http.Get(local(putURL))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "a URL signed for PUT does not allow GET")
+
+	expired, err := store.SignedURL(ctx, "docs/a b.txt", http.MethodGet, -time.Minute)
+	require.NoError(t, err)
+	resp, err = http.Get(local(expired))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	_, err = storage.New(storage.Config{Driver: "ftp"}, nil)
+	assert.Error(t, err)
+}
+
+// fakeObjectServer keeps the bodies of the requests it was sent by path
+type fakeObjectServer struct {
+	mu       sync.Mutex
+	objects  map[string]string
+	types    map[string]string
+	requests []*http.Request
+}
+
+func newFakeObjectServer() *fakeObjectServer {
+	return &fakeObjectServer{objects: map[string]string{}, types: map[string]string{}}
+}
+
+func (f *fakeObjectServer) record(r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, r)
+}
+
+func TestS3Storage(t *testing.T) {
+	fake := newFakeObjectServer()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fake.record(r)
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+		switch r.Method {
+		case http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			fake.objects[r.URL.EscapedPath()] = string(body)
+			fake.types[r.URL.EscapedPath()] = r.Header.Get("Content-Type")
+			w.Header().Set("ETag", `"abc"`)
+		case http.MethodGet:
+			body, ok := fake.objects[r.URL.EscapedPath()]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Type", fake.types[r.URL.EscapedPath()])
+			w.Header().Set("Last-Modified", "Wed, 21 Oct 2015 07:28:00 GMT")
+			_, _ = io.WriteString(w, body)
+		case http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	store, err := storage.NewS3Storage(storage.S3Config{
+		Bucket: "media", Region: "us-east-1", Endpoint: server.URL, PathStyle: true,
+		AccessKeyID: "AKID", SecretAccessKey: "secret",
+	}, server.Client())
+	require.NoError(t, err)
+
+	object, err := store.Put(ctx, "reports/q1 2024.csv", strings.NewReader("a,b\n1,2\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "text/csv; charset=utf-8", object.ContentType)
+	assert.Equal(t, int64(8), object.Size, "the size of a body of unknown length is measured")
+	assert.Equal(t, "abc", object.ETag)
+
+	put := fake.requests[0]
+	assert.Equal(t, "/media/reports/q1%202024.csv", put.URL.EscapedPath())
+	assert.Equal(t, "UNSIGNED-PAYLOAD", put.Header.Get("X-Amz-Content-Sha256"))
+	assert.Equal(t, int64(8), put.ContentLength)
+	assert.Contains(t, put.Header.Get("Authorization"), "Credential=AKID/")
+
+	body, object, err := store.Get(ctx, "reports/q1 2024.csv")
+	require.NoError(t, err)
+	content, _ := io.ReadAll(body)
+	body.Close()
+	assert.Equal(t, "a,b\n1,2\n", string(content))
+	assert.Equal(t, time.Date(2015, 10, 21, 7, 28, 0, 0, time.UTC), object.UpdatedAt)
+
+	_, _, err = store.Get(ctx, "missing.txt")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+	require.NoError(t, store.Delete(ctx, "reports/q1 2024.csv"))
+
+	signed, err := store.SignedURL(ctx, "reports/q1 2024.csv", http.MethodGet, time.Hour)
+	require.NoError(t, err)
+	u, err := url.Parse(signed)
+	require.NoError(t, err)
+	assert.Equal(t, "/media/reports/q1%202024.csv", u.EscapedPath())
+	assert.Equal(t, "3600", u.Query().Get("X-Amz-Expires"))
+	assert.NotEmpty(t, u.Query().Get("X-Amz-Signature"))
+}
+
+func TestGCSStorage(t *testing.T) {
+	fake := newFakeObjectServer()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fake.record(r)
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/upload/storage/v1/b/media/o":
+			name := r.URL.Query().Get("name")
+			body, _ := io.ReadAll(r.Body)
+			fake.objects[name] = string(body)
+			fake.types[name] = r.Header.Get("Content-Type")
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"name": name, "size": "5", "contentType": r.Header.Get("Content-Type"),
+				"etag": "CAE=", "updated": "2024-01-02T03:04:05Z",
+			})
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/storage/v1/b/media/o/"):
+			name := strings.TrimPrefix(r.URL.Path, "/storage/v1/b/media/o/")
+			body, ok := fake.objects[name]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Type", fake.types[name])
+			_, _ = io.WriteString(w, body)
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	credentials := writeServiceAccountKey(t)
+	ctx := context.Background()
+	store, err := storage.NewGCSStorage(storage.GCSConfig{
+		Bucket: "media", Endpoint: server.URL, Token: "token", CredentialsFile: credentials,
+	}, server.Client())
+	require.NoError(t, err)
+
+	object, err := store.Put(ctx, "notes/today.txt", strings.NewReader("hello"), storage.WithContentType("text/markdown"))
+	require.NoError(t, err)
+	assert.Equal(t, "notes/today.txt", object.Key)
+	assert.Equal(t, "text/markdown", object.ContentType, "an explicit type is kept")
+	assert.Equal(t, int64(5), object.Size)
+	assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), object.UpdatedAt)
+
+	get := "/storage/v1/b/media/o/notes%2Ftoday.txt"
+	body, _, err := store.Get(ctx, "notes/today.txt")
+	require.NoError(t, err)
+	content, _ := io.ReadAll(body)
+	body.Close()
+	assert.Equal(t, "hello", string(content))
+	assert.Equal(t, get, fake.requests[len(fake.requests)-1].URL.EscapedPath(), "the key is a single path segment")
+	assert.Equal(t, "media", fake.requests[len(fake.requests)-1].URL.Query().Get("alt"))
+
+	require.NoError(t, store.Delete(ctx, "notes/today.txt"), "deleting a missing object succeeds")
+
+	signed, err := store.SignedURL(ctx, "notes/today.txt", http.MethodGet, 10*time.Minute)
+	require.NoError(t, err)
+	u, err := url.Parse(signed)
+	require.NoError(t, err)
+	assert.Equal(t, "/media/notes/today.txt", u.Path)
+	assert.Equal(t, "GOOG4-RSA-SHA256", u.Query().Get("X-Goog-Algorithm"))
+	assert.True(t, strings.HasPrefix(u.Query().Get("X-Goog-Credential"), "uploader@example.iam.gserviceaccount.com/"))
+	assert.Equal(t, "600", u.Query().Get("X-Goog-Expires"))
+	assert.Len(t, u.Query().Get("X-Goog-Signature"), 512, "hex of a 2048 bit RSA signature")
+
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", "")
+	withoutKey, err := storage.NewGCSStorage(storage.GCSConfig{Bucket: "media", Endpoint: server.URL, Token: "token"}, server.Client())
+	require.NoError(t, err)
+	_, err = withoutKey.SignedURL(ctx, "notes/today.txt", http.MethodGet, time.Minute)
+	assert.Error(t, err, "signing needs the service account key")
+}
+
+// writeServiceAccountKey writes a service account key file with a new RSA key
+func writeServiceAccountKey(t *testing.T) string {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	raw, err := json.Marshal(map[string]string{
+		"type":         "service_account",
+		"client_email": "uploader@example.iam.gserviceaccount.com",
+		"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+	})
+	require.NoError(t, err)
+	file := filepath.Join(t.TempDir(), "credentials.json")
+	require.NoError(t, os.WriteFile(file, raw, 0o600))
+	return file
+}
+
+func TestUploadKey(t *testing.T) {
+	key, err := storage.UploadKey("/uploads/", "../../Photo.JPG")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(key, "uploads/"))
+	assert.True(t, strings.HasSuffix(key, ".jpg"))
+
+	other, err := storage.UploadKey("uploads", "Photo.JPG")
+	require.NoError(t, err)
+	assert.NotEqual(t, key, other)
+}
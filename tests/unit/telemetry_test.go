@@ -0,0 +1,96 @@
+package unit
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/core/telemetry"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+func TestTelemetrySetupDisabled(t *testing.T) {
+	shutdown, err := telemetry.Setup(context.Background(), telemetry.DefaultConfig(), resource.Empty())
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+}
+
+func TestTelemetryResourceAttributes(t *testing.T) {
+	base := resource.NewSchemaless(attribute.String("service.name", "api"), attribute.String("service.version", "v1"))
+
+	res, err := telemetry.Resource(base, map[string]string{
+		"deployment.environment.name": "stg",
+		"service.version":             "v2",
+	})
+	require.NoError(t, err)
+
+	set := res.Set()
+	name, _ := set.Value("service.name")
+	env, _ := set.Value("deployment.environment.name")
+	version, _ := set.Value("service.version")
+	assert.Equal(t, "api", name.AsString())
+	assert.Equal(t, "stg", env.AsString())
+	assert.Equal(t, "v2", version.AsString(), "configured attributes win")
+}
+
+func TestTelemetryMeterProvider(t *testing.T) {
+	ctx := context.Background()
+	cfg := telemetry.DefaultConfig().Metrics
+	cfg.Insecure = true
+
+	// the exporter connects lazily, no collector is needed to build the provider
+	provider, err := telemetry.NewMeterProvider(ctx, cfg, resource.Empty())
+	require.NoError(t, err)
+	shutdownCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	_ = provider.Shutdown(shutdownCtx)
+
+	cfg.Temporality = "sometimes"
+	_, err = telemetry.NewMeterProvider(ctx, cfg, resource.Empty())
+	assert.ErrorContains(t, err, "temporality")
+
+	cfg.Endpoint = ""
+	_, err = telemetry.NewMeterProvider(ctx, cfg, resource.Empty())
+	assert.ErrorContains(t, err, "endpoint")
+}
+
+func TestTelemetryRuntimeMetrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+
+	collector := telemetry.NewRuntimeCollector(telemetry.RuntimeConfig{Interval: time.Hour})
+	collector.Start()
+	defer collector.Shutdown(context.Background())
+
+	runtime.GC()
+	sample := collector.Collect()
+	assert.Positive(t, sample.Goroutines)
+	assert.Positive(t, sample.HeapAlloc)
+	assert.Positive(t, sample.NumGC)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	metrics := map[string]metricdata.Aggregation{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			metrics[m.Name] = m.Data
+		}
+	}
+
+	goroutines, ok := metrics["go.goroutine.count"].(metricdata.Gauge[int64])
+	require.True(t, ok)
+	assert.Equal(t, int64(sample.Goroutines), goroutines.DataPoints[0].Value, "the gauges report the last sample")
+	assert.Contains(t, metrics, "go.memory.heap.alloc")
+	assert.Contains(t, metrics, "go.gc.count")
+
+	pauses, ok := metrics["go.gc.pause.duration"].(metricdata.Histogram[float64])
+	require.True(t, ok)
+	assert.Positive(t, pauses.DataPoints[0].Count, "the pauses of the cycles since the start")
+}
@@ -0,0 +1,90 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/core/tracing"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracingSamplerFollowsParent(t *testing.T) {
+	cfg := tracing.DefaultConfig()
+	cfg.SampleRatio = 0
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSampler(cfg.Sampler()), sdktrace.WithSyncer(exporter))
+	tracer := provider.Tracer("test")
+
+	_, root := tracer.Start(context.Background(), "root")
+	root.End()
+	assert.False(t, root.SpanContext().IsSampled(), "ratio 0 drops the traces started here")
+
+	// a request whose caller sampled its trace
+	parent := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{1},
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	})
+	_, child := tracer.Start(trace.ContextWithRemoteSpanContext(context.Background(), parent), "child")
+	child.End()
+	assert.True(t, child.SpanContext().IsSampled())
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "child", spans[0].Name)
+}
+
+func TestTracingExporters(t *testing.T) {
+	ctx := context.Background()
+	for _, exporter := range []string{tracing.ExporterOTLP, tracing.ExporterStdout} {
+		cfg := tracing.DefaultConfig()
+		cfg.Exporter = exporter
+		cfg.Insecure = true
+
+		// the OTLP exporter connects lazily, no collector is needed to build the provider
+		provider, err := tracing.NewTracerProvider(ctx, cfg, resource.Empty())
+		require.NoError(t, err, exporter)
+		assert.NoError(t, provider.Shutdown(ctx), exporter)
+	}
+
+	cfg := tracing.DefaultConfig()
+	cfg.Exporter = "zipkin"
+	_, err := tracing.NewTracerProvider(ctx, cfg, resource.Empty())
+	assert.ErrorContains(t, err, "exporter")
+}
+
+func TestTracingSetupDisabled(t *testing.T) {
+	shutdown, err := tracing.Setup(context.Background(), tracing.DefaultConfig(), resource.Empty())
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+}
+
+func TestTracingSamplerForced(t *testing.T) {
+	cfg := tracing.DefaultConfig()
+	cfg.SampleRatio = 0
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSampler(cfg.Sampler()))
+	tracer := provider.Tracer("test")
+
+	// a caller that dropped its trace
+	parent := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{1},
+		SpanID:  trace.SpanID{1},
+		Remote:  true,
+	})
+	ctx := trace.ContextWithRemoteSpanContext(context.Background(), parent)
+	_, span := tracer.Start(ctx, "dropped")
+	assert.False(t, span.SpanContext().IsSampled())
+
+	_, span = tracer.Start(tracing.WithForcedSampling(ctx), "forced")
+	assert.True(t, span.SpanContext().IsSampled())
+	assert.Equal(t, parent.TraceID(), span.SpanContext().TraceID(), "the trace of the caller is kept")
+
+	_, span = tracer.Start(tracing.WithForcedSampling(context.Background()), "forced root")
+	assert.True(t, span.SpanContext().IsSampled())
+}
@@ -0,0 +1,89 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yourorg/go-api-template/core/exception"
+	"github.com/yourorg/go-api-template/core/httpclient/completions"
+	"github.com/yourorg/go-api-template/core/usage"
+)
+
+// fakeLLMProvider reports usage of 10 prompt and 5 completion tokens per call
+type fakeLLMProvider struct {
+	calls int
+}
+
+func (p *fakeLLMProvider) Name() string { return "fake" }
+
+func (p *fakeLLMProvider) Complete(ctx context.Context, req completions.CompletionRequest) (completions.CompletionResponse, error) {
+	p.calls++
+	return completions.CompletionResponse{
+		Usage: completions.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+	}, nil
+}
+
+func (p *fakeLLMProvider) CompleteStream(ctx context.Context, req completions.CompletionRequest) iter.Seq2[completions.CompletionChunk, error] {
+	return func(yield func(completions.CompletionChunk, error) bool) {
+		p.calls++
+		if !yield(completions.CompletionChunk{ID: "1"}, nil) {
+			return
+		}
+		yield(completions.CompletionChunk{
+			ID:    "2",
+			Usage: &completions.Usage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+		}, nil)
+	}
+}
+
+func TestMeteredProviderRecordsUsagePerCaller(t *testing.T) {
+	store := usage.NewMemoryStore()
+	now := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+	provider := usage.NewMeteredProvider(&fakeLLMProvider{}, store, usage.WithClock(func() time.Time { return now }))
+
+	ctx := context.WithValue(context.Background(), "user_id", "user-1")
+	_, err := provider.Complete(ctx, completions.CompletionRequest{})
+	require.NoError(t, err)
+	for _, err := range provider.CompleteStream(ctx, completions.CompletionRequest{}) {
+		require.NoError(t, err)
+	}
+	_, err = provider.Complete(context.Background(), completions.CompletionRequest{})
+	require.NoError(t, err)
+
+	used, err := store.Get(context.Background(), "user-1", "2025-03")
+	require.NoError(t, err)
+	assert.Equal(t, usage.Usage{PromptTokens: 20, CompletionTokens: 10, TotalTokens: 30, Requests: 2}, used)
+
+	anonymous, err := store.Get(context.Background(), usage.AnonymousCaller, "2025-03")
+	require.NoError(t, err)
+	assert.Equal(t, int64(15), anonymous.TotalTokens)
+}
+
+func TestMeteredProviderEnforcesMonthlyBudget(t *testing.T) {
+	store := usage.NewMemoryStore()
+	fake := &fakeLLMProvider{}
+	provider := usage.NewMeteredProvider(fake, store, usage.WithMonthlyBudget(20))
+
+	ctx := context.Background()
+	_, err := provider.Complete(ctx, completions.CompletionRequest{})
+	require.NoError(t, err)
+	_, err = provider.Complete(ctx, completions.CompletionRequest{})
+	require.NoError(t, err)
+
+	_, err = provider.Complete(ctx, completions.CompletionRequest{})
+	var cErr *exception.ExceptionError
+	require.True(t, errors.As(err, &cErr))
+	assert.Equal(t, http.StatusTooManyRequests, cErr.HttpStatusCode)
+	assert.Equal(t, 2, fake.calls)
+
+	for _, err := range provider.CompleteStream(ctx, completions.CompletionRequest{}) {
+		assert.True(t, errors.As(err, &cErr))
+	}
+	assert.Equal(t, 2, fake.calls)
+}
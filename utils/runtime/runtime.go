@@ -1,5 +1,16 @@
 package runtime
 
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
 type Environment string
 
 const (
@@ -10,13 +21,110 @@ const (
 	Prd   Environment = "prd"
 )
 
+// ProfilesFile registers custom environments when it exists, see LoadProfiles
+const ProfilesFile = "config/profiles.yaml"
+
+// Profile is what an environment changes: how the logger starts and which config files overlay
+// config/config.yaml
+type Profile struct {
+	// Production environments never expose debug details and require strong secrets
+	Production bool `yaml:"production"`
+	// LogLevel is the level the logger starts with, debug when empty
+	LogLevel string `yaml:"logLevel"`
+	// LogFormat is console or json, json when empty
+	LogFormat string `yaml:"logFormat"`
+	// Overlays are the environments whose config/config.<env>.yaml are merged in order, e.g.
+	// [stg, uat] to run uat with the stg settings and its own differences; the environment itself when empty
+	Overlays []Environment `yaml:"overlays"`
+}
+
+var (
+	profilesMu sync.RWMutex
+	profiles   = map[Environment]Profile{
+		Local: {LogFormat: "console"},
+		Dev:   {},
+		Sit:   {},
+		Stg:   {},
+		Prd:   {Production: true},
+	}
+)
+
+// RegisterProfile adds or replaces the profile of env
+func RegisterProfile(env Environment, profile Profile) {
+	profilesMu.Lock()
+	defer profilesMu.Unlock()
+	profiles[env] = profile
+}
+
+// LookupProfile returns the profile of env, with its overlays defaulting to env itself
+func LookupProfile(env Environment) (Profile, bool) {
+	profilesMu.RLock()
+	profile, ok := profiles[env]
+	profilesMu.RUnlock()
+	if ok && len(profile.Overlays) == 0 {
+		profile.Overlays = []Environment{env}
+	}
+	return profile, ok
+}
+
+// Environments returns the registered environments, sorted
+func Environments() []Environment {
+	profilesMu.RLock()
+	defer profilesMu.RUnlock()
+	envs := make([]Environment, 0, len(profiles))
+	for env := range profiles {
+		envs = append(envs, env)
+	}
+	slices.Sort(envs)
+	return envs
+}
+
+// LoadProfiles registers the environments of a YAML file mapping names to profiles, e.g.
+//
+//	uat:
+//	  logFormat: json
+//	  overlays: [stg, uat]
+//
+// A missing file registers nothing.
+func LoadProfiles(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var loaded map[Environment]Profile
+	if err := yaml.Unmarshal(data, &loaded); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	for env, profile := range loaded {
+		if env == "" {
+			return fmt.Errorf("%s: empty environment name", path)
+		}
+		if profile.LogFormat != "" && profile.LogFormat != "console" && profile.LogFormat != "json" {
+			return fmt.Errorf("%s: %s: logFormat must be console or json", path, env)
+		}
+	}
+	for env, profile := range loaded {
+		RegisterProfile(env, profile)
+	}
+	return nil
+}
+
+// ValidateProfile returns the environment of a registered profile, local otherwise
 func ValidateProfile(profile string) Environment {
-	switch profile {
-	case "local", "dev", "sit", "stg", "prd":
+	if _, ok := LookupProfile(Environment(profile)); ok {
 		return Environment(profile)
-	default:
-		return "local"
 	}
+	return Local
+}
+
+// IsProduction reports whether the environment serves production traffic
+func (e Environment) IsProduction() bool {
+	profile, _ := LookupProfile(e)
+	return profile.Production
 }
 
 type RuntimeCfg struct {